@@ -128,6 +128,9 @@ func (s *Server) setupRoutes() {
 			// AI交易员管理
 			protected.GET("/my-traders", s.handleTraderList)
 			protected.GET("/traders/:id/config", s.handleGetTraderConfig)
+			protected.GET("/prompt-templates/:name/render", s.handleRenderPromptTemplate)
+			protected.GET("/prompt-revisions", s.handleGetPromptRevisions)
+			protected.GET("/prompt-revisions/:hash", s.handleGetPromptRevision)
 			protected.POST("/traders", s.handleCreateTrader)
 			protected.PUT("/traders/:id", s.handleUpdateTrader)
 			protected.DELETE("/traders/:id", s.handleDeleteTrader)
@@ -155,6 +158,8 @@ func (s *Server) setupRoutes() {
 			protected.GET("/trades", s.handleTradeHistory)
 			protected.GET("/decisions", s.handleDecisions)
 			protected.GET("/decisions/latest", s.handleLatestDecisions)
+			protected.GET("/decisions/thinking", s.handleLiveThinking)
+			protected.GET("/decisions/thinking/stream", s.handleLiveThinkingStream)
 			protected.GET("/statistics", s.handleStatistics)
 			protected.GET("/performance", s.handlePerformance)
 
@@ -371,20 +376,30 @@ func (s *Server) getTraderFromQuery(c *gin.Context) (*manager.TraderManager, str
 
 // AI交易员管理相关结构体
 type CreateTraderRequest struct {
-	Name                 string  `json:"name" binding:"required"`
-	AIModelID            string  `json:"ai_model_id" binding:"required"`
-	ExchangeID           string  `json:"exchange_id" binding:"required"`
-	InitialBalance       float64 `json:"initial_balance"`
-	ScanIntervalMinutes  int     `json:"scan_interval_minutes"`
-	BTCETHLeverage       int     `json:"btc_eth_leverage"`
-	AltcoinLeverage      int     `json:"altcoin_leverage"`
-	TradingSymbols       string  `json:"trading_symbols"`
-	CustomPrompt         string  `json:"custom_prompt"`
-	OverrideBasePrompt   bool    `json:"override_base_prompt"`
-	SystemPromptTemplate string  `json:"system_prompt_template"` // 系统提示词模板名称
-	IsCrossMargin        *bool   `json:"is_cross_margin"`        // 指针类型，nil表示使用默认值true
-	UseCoinPool          bool    `json:"use_coin_pool"`
-	UseOITop             bool    `json:"use_oi_top"`
+	Name                  string  `json:"name" binding:"required"`
+	AIModelID             string  `json:"ai_model_id" binding:"required"`
+	ExchangeID            string  `json:"exchange_id" binding:"required"`
+	InitialBalance        float64 `json:"initial_balance"`
+	ScanIntervalMinutes   int     `json:"scan_interval_minutes"`
+	BTCETHLeverage        int     `json:"btc_eth_leverage"`
+	AltcoinLeverage       int     `json:"altcoin_leverage"`
+	TradingSymbols        string  `json:"trading_symbols"`
+	CustomPrompt          string  `json:"custom_prompt"`
+	OverrideBasePrompt    bool    `json:"override_base_prompt"`
+	SystemPromptTemplate  string  `json:"system_prompt_template"` // 系统提示词模板名称
+	IsCrossMargin         *bool   `json:"is_cross_margin"`        // 指针类型，nil表示使用默认值true
+	UseCoinPool           bool    `json:"use_coin_pool"`
+	UseOITop              bool    `json:"use_oi_top"`
+	PendingOrderMaxCycles int     `json:"pending_order_max_cycles"` // 限价单最多挂单周期数，<=0 使用默认值3
+	TakeProfitLadder      string  `json:"take_profit_ladder"`       // 分批止盈模板（JSON 数组），空表示不启用
+	BreakevenAfterTP1     bool    `json:"breakeven_after_tp1"`      // 第一档止盈成交后把止损移到开仓价
+	DecisionMode          string  `json:"decision_mode"`            // 决策输出方式：text（默认）| tool_call | agent
+	EnsembleModelIDs      string  `json:"ensemble_model_ids"`       // 参与集成决策的其他AI模型ID（逗号分隔），空表示不启用
+	EnsembleVote          string  `json:"ensemble_vote"`            // 集成决策投票方式：majority（默认）| confidence_weighted | unanimous
+	ValidationRules       string  `json:"validation_rules"`         // 决策校验规则链配置（JSON 对象），空表示默认规则
+	PromptExperiment      string  `json:"prompt_experiment"`        // 提示词 A/B 实验配置（JSON 对象），空表示不开启
+	FallbackModelIDs      string  `json:"fallback_model_ids"`       // 故障转移的备用AI模型ID（按尝试顺序逗号分隔），空表示不启用
+	AISafeMode            bool    `json:"ai_safe_mode"`             // 所有AI模型不可用时进入安全模式，只管理现有持仓
 }
 
 type ModelConfig struct {
@@ -449,6 +464,7 @@ type UpdateExchangeConfigRequest struct {
 		AsterPrivateKey       string `json:"aster_private_key"`
 		LighterWalletAddr     string `json:"lighter_wallet_addr"`
 		LighterPrivateKey     string `json:"lighter_private_key"`
+		Passphrase            string `json:"passphrase"` // OKX/Bitget API Passphrase
 	} `json:"exchanges"`
 }
 
@@ -528,6 +544,42 @@ func (s *Server) handleCreateTrader(c *gin.Context) {
 		scanIntervalMinutes = 3 // 默认3分钟，且不允许小于3
 	}
 
+	// 设置限价单挂单周期默认值
+	pendingOrderMaxCycles := req.PendingOrderMaxCycles
+	if pendingOrderMaxCycles <= 0 {
+		pendingOrderMaxCycles = 3
+	}
+
+	// 校验分批止盈模板
+	if _, err := decision.ParseTakeProfitLadder(req.TakeProfitLadder); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 校验决策校验规则配置
+	if _, err := decision.ParseValidationConfig(req.ValidationRules); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 校验提示词 A/B 实验配置
+	if _, err := decision.ParsePromptExperiment(req.PromptExperiment); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	decisionMode, err := decision.NormalizeDecisionMode(req.DecisionMode)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ensembleVote, err := decision.NormalizeEnsembleVote(req.EnsembleVote)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	// ✨ 查询交易所实际余额，覆盖用户输入
 	actualBalance := req.InitialBalance // 默认使用用户输入
 	exchanges, err := s.database.GetExchanges(userID)
@@ -573,8 +625,25 @@ func (s *Server) handleCreateTrader(c *gin.Context) {
 				exchangeCfg.APIKey,
 				exchangeCfg.SecretKey,
 			)
+		case "okx":
+			tempTrader = trader.NewOKXTrader(
+				exchangeCfg.APIKey,
+				exchangeCfg.SecretKey,
+				exchangeCfg.Passphrase,
+				exchangeCfg.Testnet,
+			)
+		case "bitget":
+			tempTrader = trader.NewBitgetTrader(
+				exchangeCfg.APIKey,
+				exchangeCfg.SecretKey,
+				exchangeCfg.Passphrase,
+				exchangeCfg.Testnet,
+			)
 		case "gate":
 			tempTrader = trader.NewGateFuturesTrader(exchangeCfg.APIKey, exchangeCfg.SecretKey, userID)
+		case "paper":
+			// 模拟盘没有真实余额，直接使用用户输入的初始资金
+			log.Printf("✓ 模拟盘交易员使用用户输入的初始资金: %.2f USDT", req.InitialBalance)
 		default:
 			log.Printf("⚠️ 不支持的交易所类型: %s，使用用户输入的初始资金", req.ExchangeID)
 		}
@@ -614,23 +683,33 @@ func (s *Server) handleCreateTrader(c *gin.Context) {
 	// 创建交易员配置（数据库实体）
 	log.Printf("🔧 DEBUG: 开始创建交易员配置, ID=%s, Name=%s, AIModel=%s, Exchange=%s", traderID, req.Name, req.AIModelID, req.ExchangeID)
 	trader := &config.TraderRecord{
-		ID:                   traderID,
-		UserID:               userID,
-		Name:                 req.Name,
-		AIModelID:            req.AIModelID,
-		ExchangeID:           req.ExchangeID,
-		InitialBalance:       actualBalance, // 使用实际查询的余额
-		BTCETHLeverage:       btcEthLeverage,
-		AltcoinLeverage:      altcoinLeverage,
-		TradingSymbols:       req.TradingSymbols,
-		UseCoinPool:          req.UseCoinPool,
-		UseOITop:             req.UseOITop,
-		CustomPrompt:         req.CustomPrompt,
-		OverrideBasePrompt:   req.OverrideBasePrompt,
-		SystemPromptTemplate: systemPromptTemplate,
-		IsCrossMargin:        isCrossMargin,
-		ScanIntervalMinutes:  scanIntervalMinutes,
-		IsRunning:            false,
+		ID:                    traderID,
+		UserID:                userID,
+		Name:                  req.Name,
+		AIModelID:             req.AIModelID,
+		ExchangeID:            req.ExchangeID,
+		InitialBalance:        actualBalance, // 使用实际查询的余额
+		BTCETHLeverage:        btcEthLeverage,
+		AltcoinLeverage:       altcoinLeverage,
+		TradingSymbols:        req.TradingSymbols,
+		UseCoinPool:           req.UseCoinPool,
+		UseOITop:              req.UseOITop,
+		CustomPrompt:          req.CustomPrompt,
+		OverrideBasePrompt:    req.OverrideBasePrompt,
+		SystemPromptTemplate:  systemPromptTemplate,
+		IsCrossMargin:         isCrossMargin,
+		ScanIntervalMinutes:   scanIntervalMinutes,
+		PendingOrderMaxCycles: pendingOrderMaxCycles,
+		TakeProfitLadder:      strings.TrimSpace(req.TakeProfitLadder),
+		BreakevenAfterTP1:     req.BreakevenAfterTP1,
+		DecisionMode:          decisionMode,
+		EnsembleModelIDs:      normalizeEnsembleModelIDs(req.EnsembleModelIDs, req.AIModelID),
+		FallbackModelIDs:      normalizeEnsembleModelIDs(req.FallbackModelIDs, req.AIModelID),
+		AISafeMode:            req.AISafeMode,
+		EnsembleVote:          ensembleVote,
+		ValidationRules:       strings.TrimSpace(req.ValidationRules),
+		PromptExperiment:      strings.TrimSpace(req.PromptExperiment),
+		IsRunning:             false,
 	}
 
 	// 保存到数据库
@@ -664,17 +743,42 @@ func (s *Server) handleCreateTrader(c *gin.Context) {
 
 // UpdateTraderRequest 更新交易员请求
 type UpdateTraderRequest struct {
-	Name                string  `json:"name" binding:"required"`
-	AIModelID           string  `json:"ai_model_id" binding:"required"`
-	ExchangeID          string  `json:"exchange_id" binding:"required"`
-	InitialBalance      float64 `json:"initial_balance"`
-	ScanIntervalMinutes int     `json:"scan_interval_minutes"`
-	BTCETHLeverage      int     `json:"btc_eth_leverage"`
-	AltcoinLeverage     int     `json:"altcoin_leverage"`
-	TradingSymbols      string  `json:"trading_symbols"`
-	CustomPrompt        string  `json:"custom_prompt"`
-	OverrideBasePrompt  bool    `json:"override_base_prompt"`
-	IsCrossMargin       *bool   `json:"is_cross_margin"`
+	Name                  string  `json:"name" binding:"required"`
+	AIModelID             string  `json:"ai_model_id" binding:"required"`
+	ExchangeID            string  `json:"exchange_id" binding:"required"`
+	InitialBalance        float64 `json:"initial_balance"`
+	ScanIntervalMinutes   int     `json:"scan_interval_minutes"`
+	BTCETHLeverage        int     `json:"btc_eth_leverage"`
+	AltcoinLeverage       int     `json:"altcoin_leverage"`
+	TradingSymbols        string  `json:"trading_symbols"`
+	CustomPrompt          string  `json:"custom_prompt"`
+	OverrideBasePrompt    bool    `json:"override_base_prompt"`
+	IsCrossMargin         *bool   `json:"is_cross_margin"`
+	PendingOrderMaxCycles int     `json:"pending_order_max_cycles"`
+	TakeProfitLadder      *string `json:"take_profit_ladder"`  // nil 时保持原值，空字符串表示关闭分批止盈
+	BreakevenAfterTP1     *bool   `json:"breakeven_after_tp1"` // nil 时保持原值
+	DecisionMode          string  `json:"decision_mode"`       // 为空时保持原值
+	EnsembleModelIDs      *string `json:"ensemble_model_ids"`  // nil 时保持原值，空字符串表示关闭集成决策
+	EnsembleVote          string  `json:"ensemble_vote"`       // 为空时保持原值
+	ValidationRules       *string `json:"validation_rules"`    // nil 时保持原值，空字符串表示恢复默认规则
+	PromptExperiment      *string `json:"prompt_experiment"`   // nil 时保持原值，空字符串表示结束实验
+	FallbackModelIDs      *string `json:"fallback_model_ids"`  // nil 时保持原值，空字符串表示关闭故障转移
+	AISafeMode            *bool   `json:"ai_safe_mode"`        // nil 时保持原值
+}
+
+// normalizeEnsembleModelIDs 清理逗号分隔的集成/备用模型ID：去除空白、重复项以及主模型本身，保持原有顺序
+func normalizeEnsembleModelIDs(ids string, primaryModelID string) string {
+	seen := map[string]bool{primaryModelID: true}
+	var result []string
+	for _, id := range strings.Split(ids, ",") {
+		id = strings.TrimSpace(id)
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		result = append(result, id)
+	}
+	return strings.Join(result, ",")
 }
 
 // handleUpdateTrader 更新交易员配置
@@ -732,23 +836,103 @@ func (s *Server) handleUpdateTrader(c *gin.Context) {
 		scanIntervalMinutes = 3
 	}
 
+	pendingOrderMaxCycles := req.PendingOrderMaxCycles
+	if pendingOrderMaxCycles <= 0 {
+		pendingOrderMaxCycles = existingTrader.PendingOrderMaxCycles // 保持原值
+	}
+
+	takeProfitLadder := existingTrader.TakeProfitLadder // 保持原值
+	if req.TakeProfitLadder != nil {
+		if _, err := decision.ParseTakeProfitLadder(*req.TakeProfitLadder); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		takeProfitLadder = strings.TrimSpace(*req.TakeProfitLadder)
+	}
+
+	breakevenAfterTP1 := existingTrader.BreakevenAfterTP1 // 保持原值
+	if req.BreakevenAfterTP1 != nil {
+		breakevenAfterTP1 = *req.BreakevenAfterTP1
+	}
+
+	validationRules := existingTrader.ValidationRules // 保持原值
+	if req.ValidationRules != nil {
+		if _, err := decision.ParseValidationConfig(*req.ValidationRules); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		validationRules = strings.TrimSpace(*req.ValidationRules)
+	}
+
+	promptExperiment := existingTrader.PromptExperiment // 保持原值
+	if req.PromptExperiment != nil {
+		if _, err := decision.ParsePromptExperiment(*req.PromptExperiment); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		promptExperiment = strings.TrimSpace(*req.PromptExperiment)
+	}
+
+	decisionMode := existingTrader.DecisionMode // 保持原值
+	if req.DecisionMode != "" {
+		if decisionMode, err = decision.NormalizeDecisionMode(req.DecisionMode); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	ensembleModelIDs := existingTrader.EnsembleModelIDs // 保持原值
+	if req.EnsembleModelIDs != nil {
+		ensembleModelIDs = *req.EnsembleModelIDs
+	}
+	ensembleModelIDs = normalizeEnsembleModelIDs(ensembleModelIDs, req.AIModelID)
+
+	fallbackModelIDs := existingTrader.FallbackModelIDs // 保持原值
+	if req.FallbackModelIDs != nil {
+		fallbackModelIDs = *req.FallbackModelIDs
+	}
+	fallbackModelIDs = normalizeEnsembleModelIDs(fallbackModelIDs, req.AIModelID)
+
+	aiSafeMode := existingTrader.AISafeMode // 保持原值
+	if req.AISafeMode != nil {
+		aiSafeMode = *req.AISafeMode
+	}
+
+	ensembleVote := existingTrader.EnsembleVote // 保持原值
+	if req.EnsembleVote != "" {
+		if ensembleVote, err = decision.NormalizeEnsembleVote(req.EnsembleVote); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
 	// 更新交易员配置
 	trader := &config.TraderRecord{
-		ID:                   traderID,
-		UserID:               userID,
-		Name:                 req.Name,
-		AIModelID:            req.AIModelID,
-		ExchangeID:           req.ExchangeID,
-		InitialBalance:       req.InitialBalance,
-		BTCETHLeverage:       btcEthLeverage,
-		AltcoinLeverage:      altcoinLeverage,
-		TradingSymbols:       req.TradingSymbols,
-		CustomPrompt:         req.CustomPrompt,
-		OverrideBasePrompt:   req.OverrideBasePrompt,
-		SystemPromptTemplate: existingTrader.SystemPromptTemplate, // 保持原值
-		IsCrossMargin:        isCrossMargin,
-		ScanIntervalMinutes:  scanIntervalMinutes,
-		IsRunning:            existingTrader.IsRunning, // 保持原值
+		ID:                    traderID,
+		UserID:                userID,
+		Name:                  req.Name,
+		AIModelID:             req.AIModelID,
+		ExchangeID:            req.ExchangeID,
+		InitialBalance:        req.InitialBalance,
+		BTCETHLeverage:        btcEthLeverage,
+		AltcoinLeverage:       altcoinLeverage,
+		TradingSymbols:        req.TradingSymbols,
+		CustomPrompt:          req.CustomPrompt,
+		OverrideBasePrompt:    req.OverrideBasePrompt,
+		SystemPromptTemplate:  existingTrader.SystemPromptTemplate, // 保持原值
+		IsCrossMargin:         isCrossMargin,
+		ScanIntervalMinutes:   scanIntervalMinutes,
+		PendingOrderMaxCycles: pendingOrderMaxCycles,
+		TakeProfitLadder:      takeProfitLadder,
+		BreakevenAfterTP1:     breakevenAfterTP1,
+		DecisionMode:          decisionMode,
+		EnsembleModelIDs:      ensembleModelIDs,
+		EnsembleVote:          ensembleVote,
+		ValidationRules:       validationRules,
+		PromptExperiment:      promptExperiment,
+		FallbackModelIDs:      fallbackModelIDs,
+		AISafeMode:            aiSafeMode,
+		IsRunning:             existingTrader.IsRunning, // 保持原值
 	}
 
 	// 更新数据库
@@ -984,8 +1168,24 @@ func (s *Server) handleSyncBalance(c *gin.Context) {
 			exchangeCfg.APIKey,
 			exchangeCfg.SecretKey,
 		)
+	case "okx":
+		tempTrader = trader.NewOKXTrader(
+			exchangeCfg.APIKey,
+			exchangeCfg.SecretKey,
+			exchangeCfg.Passphrase,
+			exchangeCfg.Testnet,
+		)
+	case "bitget":
+		tempTrader = trader.NewBitgetTrader(
+			exchangeCfg.APIKey,
+			exchangeCfg.SecretKey,
+			exchangeCfg.Passphrase,
+			exchangeCfg.Testnet,
+		)
 	case "gate":
 		tempTrader = trader.NewGateFuturesTrader(exchangeCfg.APIKey, exchangeCfg.SecretKey, userID)
+	case "paper":
+		tempTrader, createErr = trader.NewPaperTrader(traderConfig.ID, userID, traderConfig.InitialBalance, s.database)
 	default:
 		c.JSON(http.StatusBadRequest, gin.H{"error": "不支持的交易所类型"})
 		return
@@ -1251,6 +1451,10 @@ func (s *Server) handleUpdateExchangeConfigs(c *gin.Context) {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("更新交易所 %s 失败: %v", exchangeID, err)})
 			return
 		}
+		if err := s.database.UpdateExchangePassphrase(userID, exchangeID, exchangeData.Passphrase); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("更新交易所 %s 失败: %v", exchangeID, err)})
+			return
+		}
 	}
 
 	// 重新加载该用户的所有交易员，使新配置立即生效
@@ -1370,21 +1574,31 @@ func (s *Server) handleGetTraderConfig(c *gin.Context) {
 	aiModelID := traderConfig.AIModelID
 
 	result := map[string]interface{}{
-		"trader_id":             traderConfig.ID,
-		"trader_name":           traderConfig.Name,
-		"ai_model":              aiModelID,
-		"exchange_id":           traderConfig.ExchangeID,
-		"initial_balance":       traderConfig.InitialBalance,
-		"scan_interval_minutes": traderConfig.ScanIntervalMinutes,
-		"btc_eth_leverage":      traderConfig.BTCETHLeverage,
-		"altcoin_leverage":      traderConfig.AltcoinLeverage,
-		"trading_symbols":       traderConfig.TradingSymbols,
-		"custom_prompt":         traderConfig.CustomPrompt,
-		"override_base_prompt":  traderConfig.OverrideBasePrompt,
-		"is_cross_margin":       traderConfig.IsCrossMargin,
-		"use_coin_pool":         traderConfig.UseCoinPool,
-		"use_oi_top":            traderConfig.UseOITop,
-		"is_running":            isRunning,
+		"trader_id":                traderConfig.ID,
+		"trader_name":              traderConfig.Name,
+		"ai_model":                 aiModelID,
+		"exchange_id":              traderConfig.ExchangeID,
+		"initial_balance":          traderConfig.InitialBalance,
+		"scan_interval_minutes":    traderConfig.ScanIntervalMinutes,
+		"btc_eth_leverage":         traderConfig.BTCETHLeverage,
+		"altcoin_leverage":         traderConfig.AltcoinLeverage,
+		"trading_symbols":          traderConfig.TradingSymbols,
+		"custom_prompt":            traderConfig.CustomPrompt,
+		"override_base_prompt":     traderConfig.OverrideBasePrompt,
+		"is_cross_margin":          traderConfig.IsCrossMargin,
+		"use_coin_pool":            traderConfig.UseCoinPool,
+		"use_oi_top":               traderConfig.UseOITop,
+		"pending_order_max_cycles": traderConfig.PendingOrderMaxCycles,
+		"take_profit_ladder":       traderConfig.TakeProfitLadder,
+		"breakeven_after_tp1":      traderConfig.BreakevenAfterTP1,
+		"decision_mode":            traderConfig.DecisionMode,
+		"ensemble_model_ids":       traderConfig.EnsembleModelIDs,
+		"ensemble_vote":            traderConfig.EnsembleVote,
+		"validation_rules":         traderConfig.ValidationRules,
+		"prompt_experiment":        traderConfig.PromptExperiment,
+		"fallback_model_ids":       traderConfig.FallbackModelIDs,
+		"ai_safe_mode":             traderConfig.AISafeMode,
+		"is_running":               isRunning,
 	}
 
 	c.JSON(http.StatusOK, result)
@@ -1527,6 +1741,83 @@ func (s *Server) handleLatestDecisions(c *gin.Context) {
 	c.JSON(http.StatusOK, records)
 }
 
+// handleLiveThinking 当前决策周期 AI 的流式输出快照（思考过程和正文）
+func (s *Server) handleLiveThinking(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	thinking, _ := trader.GetLiveThinking()
+	c.JSON(http.StatusOK, thinking)
+}
+
+// liveThinkingEvent 实时思考 SSE 事件：reasoning/content 为自上次推送以来的增量，reset 表示进入了新周期
+type liveThinkingEvent struct {
+	CycleNumber int    `json:"cycle_number"`
+	Reasoning   string `json:"reasoning"`
+	Content     string `json:"content"`
+	Done        bool   `json:"done"`
+	Reset       bool   `json:"reset"`
+}
+
+// handleLiveThinkingStream 以 SSE 推送 AI 的流式输出增量，连接期间跨周期持续推送
+func (s *Server) handleLiveThinkingStream(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // 禁用 nginx 缓冲
+
+	keepAlive := time.NewTicker(15 * time.Second)
+	defer keepAlive.Stop()
+
+	sentCycle, sentReasoning, sentContent, sentDone := -1, 0, 0, false
+	for {
+		thinking, updated := trader.GetLiveThinking()
+		event := liveThinkingEvent{CycleNumber: thinking.CycleNumber, Done: thinking.Done}
+		if thinking.CycleNumber != sentCycle || len(thinking.Reasoning) < sentReasoning || len(thinking.Content) < sentContent {
+			event.Reset = true
+			sentCycle, sentReasoning, sentContent, sentDone = thinking.CycleNumber, 0, 0, false
+		}
+		event.Reasoning = thinking.Reasoning[sentReasoning:]
+		event.Content = thinking.Content[sentContent:]
+
+		if event.Reset || event.Reasoning != "" || event.Content != "" || event.Done != sentDone {
+			c.SSEvent("thinking", event)
+			c.Writer.Flush()
+			sentReasoning, sentContent, sentDone = len(thinking.Reasoning), len(thinking.Content), thinking.Done
+		}
+
+		select {
+		case <-updated:
+		case <-keepAlive.C:
+			c.Writer.WriteString(": ping\n\n")
+			c.Writer.Flush()
+		case <-c.Request.Context().Done():
+			return
+		}
+	}
+}
+
 // handleStatistics 统计信息
 func (s *Server) handleStatistics(c *gin.Context) {
 	_, traderID, err := s.getTraderFromQuery(c)
@@ -2207,6 +2498,99 @@ func (s *Server) handleGetPromptTemplate(c *gin.Context) {
 	})
 }
 
+// handleRenderPromptTemplate 使用交易员当前上下文预览提示词模板的渲染结果
+func (s *Server) handleRenderPromptTemplate(c *gin.Context) {
+	templateName := c.Param("name")
+	if _, err := decision.GetPromptTemplate(templateName); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("模板不存在: %s", templateName)})
+		return
+	}
+
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, err := trader.BuildTradingContext()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("构建交易上下文失败: %v", err)})
+		return
+	}
+
+	content, data, err := decision.RenderPromptTemplate(templateName, ctx)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "data": data})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"name":      templateName,
+		"trader_id": traderID,
+		"content":   content,
+		"data":      data,
+	})
+}
+
+// handleGetPromptRevisions 获取交易员自定义提示词和当前模板的历史版本
+func (s *Server) handleGetPromptRevisions(c *gin.Context) {
+	userID := c.GetString("user_id")
+	traderID := c.Query("trader_id")
+	if traderID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少 trader_id"})
+		return
+	}
+
+	traderConfig, _, _, err := s.database.GetTraderConfig(userID, traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "交易员不存在"})
+		return
+	}
+
+	customRevisions, err := s.database.GetPromptRevisions(config.PromptRevisionCustom, traderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取提示词版本失败: %v", err)})
+		return
+	}
+	templateRevisions, err := s.database.GetPromptRevisions(config.PromptRevisionTemplate, traderConfig.SystemPromptTemplate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取提示词版本失败: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"trader_id":          traderID,
+		"template":           traderConfig.SystemPromptTemplate,
+		"custom_revisions":   customRevisions,
+		"template_revisions": templateRevisions,
+	})
+}
+
+// handleGetPromptRevision 按内容哈希获取提示词版本原文
+func (s *Server) handleGetPromptRevision(c *gin.Context) {
+	userID := c.GetString("user_id")
+	hash := c.Param("hash")
+
+	rev, err := s.database.GetPromptRevision(hash)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取提示词版本失败: %v", err)})
+		return
+	}
+	// 其他用户的自定义提示词不可见
+	if rev == nil || (rev.Kind == config.PromptRevisionCustom && rev.UserID != userID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("提示词版本不存在: %s", hash)})
+		return
+	}
+
+	c.JSON(http.StatusOK, rev)
+}
+
 // handlePublicTraderList 获取公开的交易员列表（无需认证）
 func (s *Server) handlePublicTraderList(c *gin.Context) {
 	// 从所有用户获取交易员信息
@@ -3,11 +3,17 @@ package backtest
 import (
 	"fmt"
 	"math"
+	"sort"
 	"strings"
+
+	"nofx/market"
 )
 
 const epsilon = 1e-8
 
+// fundingAction 资金费结算事件的 TradeEvent.Action。
+const fundingAction = "funding"
+
 type position struct {
 	Symbol           string
 	Side             string
@@ -18,6 +24,18 @@ type position struct {
 	Notional         float64
 	LiquidationPrice float64
 	OpenTime         int64
+	StopLoss         float64
+	TakeProfit       float64
+
+	// 追踪止损：回调比例（百分比）与回调距离二选一，TrailingExtreme 为激活后的最高/最低价（0 表示尚未激活）
+	TrailingRate       float64
+	TrailingDistance   float64
+	TrailingActivation float64
+	TrailingExtreme    float64
+
+	// 分批止盈：按触发顺序排列，TakeProfit 始终指向下一档，最后一档平掉全部剩余仓位
+	TakeProfitLevels  []TakeProfitLevel
+	BreakevenAfterTP1 bool // 第一档止盈成交后把止损移到开仓价（移动后清除）
 }
 
 type BacktestAccount struct {
@@ -27,6 +45,7 @@ type BacktestAccount struct {
 	slippageRate   float64
 	positions      map[string]*position
 	realizedPnL    float64
+	fundingPnL     float64
 }
 
 func NewBacktestAccount(initialBalance, feeBps, slippageBps float64) *BacktestAccount {
@@ -66,7 +85,24 @@ func (acc *BacktestAccount) Open(symbol, side string, quantity float64, leverage
 		return nil, 0, 0, fmt.Errorf("leverage must be positive")
 	}
 
-	execPrice := applySlippage(price, acc.slippageRate, side, true)
+	return acc.openAt(symbol, side, quantity, leverage, applySlippage(price, acc.slippageRate, side, true), ts)
+}
+
+// OpenLimit 以限价成交开仓，挂单成交价即为限价，不计滑点
+func (acc *BacktestAccount) OpenLimit(symbol, side string, quantity float64, leverage int, limitPrice float64, ts int64) (*position, float64, float64, error) {
+	if quantity <= 0 {
+		return nil, 0, 0, fmt.Errorf("quantity must be positive")
+	}
+	if leverage <= 0 {
+		return nil, 0, 0, fmt.Errorf("leverage must be positive")
+	}
+	if limitPrice <= 0 {
+		return nil, 0, 0, fmt.Errorf("limit price must be positive")
+	}
+	return acc.openAt(symbol, side, quantity, leverage, limitPrice, ts)
+}
+
+func (acc *BacktestAccount) openAt(symbol, side string, quantity float64, leverage int, execPrice float64, ts int64) (*position, float64, float64, error) {
 	notional := execPrice * quantity
 	margin := notional / float64(leverage)
 	fee := notional * acc.feeRate
@@ -139,6 +175,148 @@ func (acc *BacktestAccount) Close(symbol, side string, quantity float64, price f
 	return realized, fee, execPrice, nil
 }
 
+// ApplyFunding 按资金费率对该币种所有持仓结算资金费，返回每个方向的资金费收支（正数为收入）。
+// 费率为正时多头支付、空头收取；名义价值按结算价计算。
+func (acc *BacktestAccount) ApplyFunding(symbol string, rate, markPrice float64) map[string]float64 {
+	result := make(map[string]float64)
+	if rate == 0 || markPrice <= 0 {
+		return result
+	}
+	for _, side := range []string{"long", "short"} {
+		pos, ok := acc.positions[positionKey(symbol, side)]
+		if !ok || pos.Quantity <= epsilon {
+			continue
+		}
+		payment := pos.Quantity * markPrice * rate
+		if side == "long" {
+			payment = -payment
+		}
+		acc.cash += payment
+		acc.fundingPnL += payment
+		result[side] = payment
+	}
+	return result
+}
+
+// SetStopLoss 为持仓设置（或清除，price<=0）止损触发价。
+func (acc *BacktestAccount) SetStopLoss(symbol, side string, price float64) error {
+	pos, ok := acc.positions[positionKey(symbol, side)]
+	if !ok || pos.Quantity <= epsilon {
+		return fmt.Errorf("no active %s position for %s", side, symbol)
+	}
+	pos.StopLoss = math.Max(price, 0)
+	return nil
+}
+
+// SetTrailingStop 为持仓设置追踪止损，activation<=0 时以 price 为起点立即开始追踪。
+func (acc *BacktestAccount) SetTrailingStop(symbol, side string, rate, distance, activation, price float64) error {
+	pos, ok := acc.positions[positionKey(symbol, side)]
+	if !ok || pos.Quantity <= epsilon {
+		return fmt.Errorf("no active %s position for %s", side, symbol)
+	}
+	pos.TrailingRate = math.Max(rate, 0)
+	pos.TrailingDistance = math.Max(distance, 0)
+	pos.TrailingActivation = math.Max(activation, 0)
+	pos.TrailingExtreme = 0
+	if pos.TrailingActivation == 0 {
+		pos.TrailingExtreme = price
+	}
+	return nil
+}
+
+// SetTakeProfit 为持仓设置（或清除，price<=0）止盈触发价，同时清除已有的分批止盈档位。
+func (acc *BacktestAccount) SetTakeProfit(symbol, side string, price float64) error {
+	pos, ok := acc.positions[positionKey(symbol, side)]
+	if !ok || pos.Quantity <= epsilon {
+		return fmt.Errorf("no active %s position for %s", side, symbol)
+	}
+	pos.TakeProfit = math.Max(price, 0)
+	pos.TakeProfitLevels = nil
+	pos.BreakevenAfterTP1 = false
+	return nil
+}
+
+// SetTakeProfitLadder 为持仓设置分批止盈，替换已有的止盈设置。
+func (acc *BacktestAccount) SetTakeProfitLadder(symbol, side string, levels []TakeProfitLevel, breakeven bool) error {
+	pos, ok := acc.positions[positionKey(symbol, side)]
+	if !ok || pos.Quantity <= epsilon {
+		return fmt.Errorf("no active %s position for %s", side, symbol)
+	}
+	pos.TakeProfit = 0
+	pos.TakeProfitLevels = nil
+	pos.BreakevenAfterTP1 = breakeven
+	for _, level := range levels {
+		addTakeProfitLevel(pos, level)
+	}
+	return nil
+}
+
+// AddTakeProfitLevel 为持仓追加一档分批止盈（同价位则覆盖数量）。
+// 已有的单一止盈会先转为覆盖全部仓位的一档，保证最终仍能平掉剩余仓位。
+func (acc *BacktestAccount) AddTakeProfitLevel(symbol, side string, price, quantity float64) error {
+	pos, ok := acc.positions[positionKey(symbol, side)]
+	if !ok || pos.Quantity <= epsilon {
+		return fmt.Errorf("no active %s position for %s", side, symbol)
+	}
+	if price <= 0 || quantity <= 0 {
+		return fmt.Errorf("invalid take profit level %.4f x %.4f", price, quantity)
+	}
+	if len(pos.TakeProfitLevels) == 0 && pos.TakeProfit > 0 {
+		addTakeProfitLevel(pos, TakeProfitLevel{Price: pos.TakeProfit, Quantity: pos.Quantity})
+	}
+	addTakeProfitLevel(pos, TakeProfitLevel{Price: price, Quantity: quantity})
+	return nil
+}
+
+func addTakeProfitLevel(pos *position, level TakeProfitLevel) {
+	if level.Price <= 0 {
+		return
+	}
+	for i := range pos.TakeProfitLevels {
+		if math.Abs(pos.TakeProfitLevels[i].Price-level.Price) <= epsilon {
+			pos.TakeProfitLevels[i].Quantity = level.Quantity
+			return
+		}
+	}
+	pos.TakeProfitLevels = append(pos.TakeProfitLevels, level)
+	sort.SliceStable(pos.TakeProfitLevels, func(i, j int) bool {
+		if pos.Side == "short" {
+			return pos.TakeProfitLevels[i].Price > pos.TakeProfitLevels[j].Price
+		}
+		return pos.TakeProfitLevels[i].Price < pos.TakeProfitLevels[j].Price
+	})
+	pos.TakeProfit = pos.TakeProfitLevels[0].Price
+}
+
+// takeProfitQuantity 返回下一档止盈的平仓数量：分批止盈非最后一档按档位数量，否则平掉全部仓位。
+func takeProfitQuantity(pos *position) float64 {
+	if len(pos.TakeProfitLevels) > 1 {
+		return math.Min(pos.TakeProfitLevels[0].Quantity, pos.Quantity)
+	}
+	return pos.Quantity
+}
+
+// advanceTakeProfitLadder 在一档止盈部分成交后推进到下一档，需要时把止损移到开仓价，返回是否移动了止损。
+func advanceTakeProfitLadder(pos *position) bool {
+	if len(pos.TakeProfitLevels) == 0 {
+		return false
+	}
+	pos.TakeProfitLevels = pos.TakeProfitLevels[1:]
+	pos.TakeProfit = 0
+	if len(pos.TakeProfitLevels) > 0 {
+		pos.TakeProfit = pos.TakeProfitLevels[0].Price
+	}
+	if !pos.BreakevenAfterTP1 {
+		return false
+	}
+	pos.BreakevenAfterTP1 = false
+	if pos.StopLoss > 0 && ((pos.Side == "long" && pos.StopLoss >= pos.EntryPrice) || (pos.Side == "short" && pos.StopLoss <= pos.EntryPrice)) {
+		return false
+	}
+	pos.StopLoss = pos.EntryPrice
+	return true
+}
+
 func (acc *BacktestAccount) TotalEquity(priceMap map[string]float64) (float64, float64, map[string]float64) {
 	unrealized := 0.0
 	margin := 0.0
@@ -227,10 +405,16 @@ func (acc *BacktestAccount) RealizedPnL() float64 {
 	return acc.realizedPnL
 }
 
+// FundingPnL 返回累计资金费收支（不计入 RealizedPnL）。
+func (acc *BacktestAccount) FundingPnL() float64 {
+	return acc.fundingPnL
+}
+
 // RestoreFromSnapshots 用于从检查点恢复账户状态。
-func (acc *BacktestAccount) RestoreFromSnapshots(cash float64, realized float64, snaps []PositionSnapshot) {
+func (acc *BacktestAccount) RestoreFromSnapshots(cash float64, realized float64, funding float64, snaps []PositionSnapshot) {
 	acc.cash = cash
 	acc.realizedPnL = realized
+	acc.fundingPnL = funding
 	acc.positions = make(map[string]*position)
 	for _, snap := range snaps {
 		pos := &position{
@@ -243,8 +427,120 @@ func (acc *BacktestAccount) RestoreFromSnapshots(cash float64, realized float64,
 			Notional:         snap.Quantity * snap.AvgPrice,
 			LiquidationPrice: snap.LiquidationPrice,
 			OpenTime:         snap.OpenTime,
+			StopLoss:         snap.StopLoss,
+			TakeProfit:       snap.TakeProfit,
+
+			TrailingRate:       snap.TrailingRate,
+			TrailingDistance:   snap.TrailingDistance,
+			TrailingActivation: snap.TrailingActivation,
+			TrailingExtreme:    snap.TrailingExtreme,
+
+			TakeProfitLevels:  append([]TakeProfitLevel(nil), snap.TakeProfitLevels...),
+			BreakevenAfterTP1: snap.BreakevenAfterTP1,
 		}
 		key := positionKey(pos.Symbol, pos.Side)
 		acc.positions[key] = pos
 	}
 }
+
+// PositionSnapshots 导出当前持仓快照（按 symbol/side 排序），供检查点和外部账户持久化使用。
+func (acc *BacktestAccount) PositionSnapshots() []PositionSnapshot {
+	list := make([]PositionSnapshot, 0, len(acc.positions))
+	for _, pos := range acc.positions {
+		list = append(list, PositionSnapshot{
+			Symbol:           pos.Symbol,
+			Side:             pos.Side,
+			Quantity:         pos.Quantity,
+			AvgPrice:         pos.EntryPrice,
+			Leverage:         pos.Leverage,
+			LiquidationPrice: pos.LiquidationPrice,
+			MarginUsed:       pos.Margin,
+			OpenTime:         pos.OpenTime,
+			StopLoss:         pos.StopLoss,
+			TakeProfit:       pos.TakeProfit,
+
+			TrailingRate:       pos.TrailingRate,
+			TrailingDistance:   pos.TrailingDistance,
+			TrailingActivation: pos.TrailingActivation,
+			TrailingExtreme:    pos.TrailingExtreme,
+
+			TakeProfitLevels:  append([]TakeProfitLevel(nil), pos.TakeProfitLevels...),
+			BreakevenAfterTP1: pos.BreakevenAfterTP1,
+		})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return positionKey(list[i].Symbol, list[i].Side) < positionKey(list[j].Symbol, list[j].Side)
+	})
+	return list
+}
+
+// TriggerFill 描述一次由价格触发的被动平仓（强平、止损或止盈）。
+type TriggerFill struct {
+	Symbol       string
+	Side         string
+	Kind         string // liquidated / stop_loss / take_profit
+	Quantity     float64
+	TriggerPrice float64
+	Price        float64
+	Fee          float64
+	RealizedPnL  float64 // 已扣除手续费
+	Leverage     int
+}
+
+// ApplyPriceTriggers 用实时价格检查所有持仓的强平价与止损/止盈价，触发即按账户的手续费与滑点模型平仓。
+// 与回测按 K 线高低点判断不同，这里把单个价格视为一根 open=high=low 的 K 线，同一价格穿越两侧时按 priority 决定。
+func (acc *BacktestAccount) ApplyPriceTriggers(priceMap map[string]float64, priority string) ([]TriggerFill, error) {
+	positions := append([]*position(nil), acc.Positions()...)
+	sort.Slice(positions, func(i, j int) bool {
+		return positionKey(positions[i].Symbol, positions[i].Side) < positionKey(positions[j].Symbol, positions[j].Side)
+	})
+
+	fills := make([]TriggerFill, 0)
+	for _, pos := range positions {
+		price, ok := priceMap[pos.Symbol]
+		if !ok || price <= 0 {
+			continue
+		}
+
+		// 分批止盈部分成交后继续检查同一价格是否越过后续档位
+		for {
+			kind, triggerPrice := "", 0.0
+			liq := pos.LiquidationPrice
+			if liq > 0 && ((pos.Side == "long" && price <= liq) || (pos.Side == "short" && price >= liq)) {
+				kind, triggerPrice = "liquidated", liq
+			} else {
+				bar := market.Kline{Open: price, High: price, Low: price, Close: price}
+				kind, triggerPrice = resolveProtectiveTrigger(pos, bar, priority)
+			}
+			if kind == "" {
+				break
+			}
+
+			symbol, side, qty, lev := pos.Symbol, pos.Side, pos.Quantity, pos.Leverage
+			ladder := kind == protectiveTakeProfit && len(pos.TakeProfitLevels) > 1
+			if ladder {
+				qty = takeProfitQuantity(pos)
+			}
+			realized, fee, execPrice, err := acc.Close(symbol, side, qty, triggerPrice)
+			if err != nil {
+				return fills, fmt.Errorf("trigger %s for %s %s: %w", kind, symbol, side, err)
+			}
+			fills = append(fills, TriggerFill{
+				Symbol:       symbol,
+				Side:         side,
+				Kind:         kind,
+				Quantity:     qty,
+				TriggerPrice: triggerPrice,
+				Price:        execPrice,
+				Fee:          fee,
+				RealizedPnL:  realized - fee,
+				Leverage:     lev,
+			})
+			if !ladder || pos.Quantity <= epsilon {
+				break
+			}
+			advanceTakeProfitLadder(pos)
+		}
+	}
+	return fills, nil
+}
@@ -0,0 +1,67 @@
+package backtest
+
+import (
+	"fmt"
+	"sort"
+
+	"nofx/decision"
+	"nofx/market"
+)
+
+// feedAgentTools agent 决策模式的回测数据源：K 线和资金费率来自 DataFeed，成交来自本次回测的成交记录（不含资金费结算），
+// 所有数据都截止到当前决策时刻，避免模型看到未来数据。
+type feedAgentTools struct {
+	feed  *DataFeed
+	runID string
+	ts    int64
+}
+
+func (t *feedAgentTools) GetKlines(symbol, interval string, limit int) ([]market.Kline, error) {
+	ss, ok := t.feed.symbolSeries[symbol]
+	if !ok {
+		return nil, fmt.Errorf("回测未包含 %s，可用币种: %v", symbol, t.feed.symbols)
+	}
+	if _, ok := ss.byTF[interval]; !ok {
+		return nil, fmt.Errorf("回测未加载 %s 周期，可用周期: %v", interval, t.feed.timeframes)
+	}
+	series := t.feed.sliceUpTo(symbol, interval, t.ts)
+	return series[max(0, len(series)-limit):], nil
+}
+
+func (t *feedAgentTools) GetOrderBook(symbol string, limit int) (*market.OrderBook, error) {
+	return nil, fmt.Errorf("回测不提供历史订单簿")
+}
+
+func (t *feedAgentTools) GetFundingHistory(symbol string, limit int) ([]market.FundingRatePoint, error) {
+	if _, ok := t.feed.symbolSeries[symbol]; !ok {
+		return nil, fmt.Errorf("回测未包含 %s，可用币种: %v", symbol, t.feed.symbols)
+	}
+	points := t.feed.funding[symbol]
+	idx := sort.Search(len(points), func(i int) bool {
+		return points[i].FundingTime > t.ts
+	})
+	return points[max(0, idx-limit):idx], nil
+}
+
+func (t *feedAgentTools) GetRecentFills(symbol string, limit int) ([]decision.FillInfo, error) {
+	events, err := LoadTradeEvents(t.runID)
+	if err != nil {
+		return nil, err
+	}
+	fills := make([]decision.FillInfo, 0, limit)
+	for _, evt := range events {
+		if evt.Timestamp > t.ts || evt.Action == fundingAction || (symbol != "" && evt.Symbol != symbol) {
+			continue
+		}
+		fills = append(fills, decision.FillInfo{
+			Symbol:      evt.Symbol,
+			Side:        evt.Action,
+			Price:       evt.Price,
+			Quantity:    evt.Quantity,
+			Fee:         evt.Fee,
+			RealizedPnL: evt.RealizedPnL,
+			Time:        evt.Timestamp,
+		})
+	}
+	return fills[max(0, len(fills)-limit):], nil
+}
@@ -36,6 +36,20 @@ func configureMCPClient(cfg BacktestConfig, base mcp.AIClient) (mcp.AIClient, er
 		qc := mcp.NewQwenClientWithOptions()
 		qc.(*mcp.QwenClient).SetAPIKey(cfg.AICfg.APIKey, cfg.AICfg.BaseURL, cfg.AICfg.Model)
 		return qc, nil
+	case "anthropic":
+		if cfg.AICfg.APIKey == "" {
+			return nil, fmt.Errorf("anthropic provider requires api key")
+		}
+		ac := mcp.NewAnthropicClientWithOptions()
+		ac.(*mcp.AnthropicClient).SetAPIKey(cfg.AICfg.APIKey, cfg.AICfg.BaseURL, cfg.AICfg.Model)
+		return ac, nil
+	case "gemini":
+		if cfg.AICfg.APIKey == "" {
+			return nil, fmt.Errorf("gemini provider requires api key")
+		}
+		gc := mcp.NewGeminiClientWithOptions()
+		gc.(*mcp.GeminiClient).SetAPIKey(cfg.AICfg.APIKey, cfg.AICfg.BaseURL, cfg.AICfg.Model)
+		return gc, nil
 	case "custom":
 		if cfg.AICfg.BaseURL == "" || cfg.AICfg.APIKey == "" || cfg.AICfg.Model == "" {
 			return nil, fmt.Errorf("custom provider requires base_url, api key and model")
@@ -53,17 +67,16 @@ func cloneBaseClient(base mcp.AIClient) *mcp.Client {
 	// 优先尝试复用传入的基础客户端（深拷贝）
 	switch c := base.(type) {
 	case *mcp.Client:
-		cp := *c
-		return &cp
+		if c != nil {
+			return c.Clone()
+		}
 	case *mcp.DeepSeekClient:
 		if c != nil && c.Client != nil {
-			cp := *c.Client
-			return &cp
+			return c.Client.Clone()
 		}
 	case *mcp.QwenClient:
 		if c != nil && c.Client != nil {
-			cp := *c.Client
-			return &cp
+			return c.Client.Clone()
 		}
 	}
 	// 回退到新的默认客户端
@@ -5,11 +5,13 @@ import (
 	"strings"
 	"time"
 
+	"nofx/decision"
 	"nofx/market"
 )
 
 // AIConfig 定义回测中使用的 AI 客户端配置。
 type AIConfig struct {
+	Name        string  `json:"name,omitempty"` // 集成决策中的成员名称（用于日志和缓存键）
 	Provider    string  `json:"provider"`
 	Model       string  `json:"model"`
 	APIKey      string  `json:"key"`
@@ -25,29 +27,48 @@ type LeverageConfig struct {
 
 // BacktestConfig 描述一次回测运行的输入配置。
 type BacktestConfig struct {
-	RunID                string   `json:"run_id"`
-	UserID               string   `json:"user_id,omitempty"`
-	AIModelID            string   `json:"ai_model_id,omitempty"`
-	Symbols              []string `json:"symbols"`
-	Timeframes           []string `json:"timeframes"`
-	DecisionTimeframe    string   `json:"decision_timeframe"`
-	DecisionCadenceNBars int      `json:"decision_cadence_nbars"`
-	StartTS              int64    `json:"start_ts"`
-	EndTS                int64    `json:"end_ts"`
-	InitialBalance       float64  `json:"initial_balance"`
-	FeeBps               float64  `json:"fee_bps"`
-	SlippageBps          float64  `json:"slippage_bps"`
-	FillPolicy           string   `json:"fill_policy"`
-	PromptVariant        string   `json:"prompt_variant"`
-	PromptTemplate       string   `json:"prompt_template"`
-	CustomPrompt         string   `json:"custom_prompt"`
-	OverrideBasePrompt   bool     `json:"override_prompt"`
-	CacheAI              bool     `json:"cache_ai"`
-	ReplayOnly           bool     `json:"replay_only"`
+	RunID                 string   `json:"run_id"`
+	UserID                string   `json:"user_id,omitempty"`
+	AIModelID             string   `json:"ai_model_id,omitempty"`
+	Exchange              string   `json:"exchange,omitempty"` // 历史K线数据源对应的交易所，为空时使用币安
+	Symbols               []string `json:"symbols"`
+	Timeframes            []string `json:"timeframes"`
+	DecisionTimeframe     string   `json:"decision_timeframe"`
+	DecisionCadenceNBars  int      `json:"decision_cadence_nbars"`
+	StartTS               int64    `json:"start_ts"`
+	EndTS                 int64    `json:"end_ts"`
+	InitialBalance        float64  `json:"initial_balance"`
+	FeeBps                float64  `json:"fee_bps"`
+	SlippageBps           float64  `json:"slippage_bps"`
+	FillPolicy            string   `json:"fill_policy"`
+	ProtectivePriority    string   `json:"protective_priority,omitempty"`
+	PendingOrderMaxCycles int      `json:"pending_order_max_cycles,omitempty"`
+	BreakevenAfterTP1     bool     `json:"breakeven_after_tp1,omitempty"`
+	DisableFunding        bool     `json:"disable_funding,omitempty"`
+	PromptVariant         string   `json:"prompt_variant"`
+	PromptTemplate        string   `json:"prompt_template"`
+	CustomPrompt          string   `json:"custom_prompt"`
+	OverrideBasePrompt    bool     `json:"override_prompt"`
+	DecisionMode          string   `json:"decision_mode,omitempty"`
+	CacheAI               bool     `json:"cache_ai"`
+	ReplayOnly            bool     `json:"replay_only"`
 
 	AICfg    AIConfig       `json:"ai"`
 	Leverage LeverageConfig `json:"leverage"`
 
+	// 分批止盈模板：AI 未给出 take_profit_levels 时按模板把入场价到止盈价的区间拆分
+	TakeProfitLadder []decision.TakeProfitLadderStep `json:"take_profit_ladder,omitempty"`
+
+	// 多模型集成决策：Ensemble 为参与投票的其他模型，主模型 AICfg 始终参与
+	Ensemble     []AIConfig `json:"ensemble,omitempty"`
+	EnsembleVote string     `json:"ensemble_vote,omitempty"`
+
+	// 决策校验规则链配置，零值为默认规则
+	ValidationRules decision.ValidationConfig `json:"validation_rules"`
+
+	// agent 决策模式的轮数和 token 预算，零值为默认预算
+	AgentBudget decision.AgentBudget `json:"agent_budget,omitempty"`
+
 	SharedAICachePath         string `json:"ai_cache_path,omitempty"`
 	CheckpointIntervalBars    int    `json:"checkpoint_interval_bars,omitempty"`
 	CheckpointIntervalSeconds int    `json:"checkpoint_interval_seconds,omitempty"`
@@ -68,6 +89,7 @@ func (cfg *BacktestConfig) Validate() error {
 		cfg.UserID = "default"
 	}
 	cfg.AIModelID = strings.TrimSpace(cfg.AIModelID)
+	cfg.Exchange = strings.ToLower(strings.TrimSpace(cfg.Exchange))
 
 	if len(cfg.Symbols) == 0 {
 		return fmt.Errorf("at least one symbol is required")
@@ -117,6 +139,21 @@ func (cfg *BacktestConfig) Validate() error {
 		return err
 	}
 
+	if cfg.ProtectivePriority == "" {
+		cfg.ProtectivePriority = ProtectivePriorityStopLoss
+	}
+	if err := validateProtectivePriority(cfg.ProtectivePriority); err != nil {
+		return err
+	}
+
+	if cfg.PendingOrderMaxCycles <= 0 {
+		cfg.PendingOrderMaxCycles = 3
+	}
+
+	if err := decision.ValidateTakeProfitLadder(cfg.TakeProfitLadder); err != nil {
+		return fmt.Errorf("invalid take_profit_ladder: %w", err)
+	}
+
 	if cfg.CheckpointIntervalBars <= 0 {
 		cfg.CheckpointIntervalBars = 20
 	}
@@ -134,6 +171,16 @@ func (cfg *BacktestConfig) Validate() error {
 	}
 	cfg.CustomPrompt = strings.TrimSpace(cfg.CustomPrompt)
 
+	mode, err := decision.NormalizeDecisionMode(cfg.DecisionMode)
+	if err != nil {
+		return fmt.Errorf("invalid decision_mode: %w", err)
+	}
+	cfg.DecisionMode = mode
+
+	if err := cfg.ValidationRules.Validate(); err != nil {
+		return fmt.Errorf("invalid validation_rules: %w", err)
+	}
+
 	if cfg.AICfg.Provider == "" {
 		cfg.AICfg.Provider = "inherit"
 	}
@@ -141,6 +188,29 @@ func (cfg *BacktestConfig) Validate() error {
 		cfg.AICfg.Temperature = 0.4
 	}
 
+	if len(cfg.Ensemble) > 0 {
+		vote, err := decision.NormalizeEnsembleVote(cfg.EnsembleVote)
+		if err != nil {
+			return fmt.Errorf("invalid ensemble_vote: %w", err)
+		}
+		cfg.EnsembleVote = vote
+
+		cfg.AICfg.Name = ensembleMemberName(cfg.AICfg, 0)
+		names := map[string]bool{cfg.AICfg.Name: true}
+		for i := range cfg.Ensemble {
+			member := &cfg.Ensemble[i]
+			member.Provider = strings.TrimSpace(member.Provider)
+			if member.Provider == "" {
+				member.Provider = "inherit"
+			}
+			member.Name = ensembleMemberName(*member, i+1)
+			if names[member.Name] {
+				return fmt.Errorf("duplicate ensemble member name %q", member.Name)
+			}
+			names[member.Name] = true
+		}
+	}
+
 	if cfg.Leverage.BTCETHLeverage <= 0 {
 		cfg.Leverage.BTCETHLeverage = 5
 	}
@@ -151,6 +221,17 @@ func (cfg *BacktestConfig) Validate() error {
 	return nil
 }
 
+// ensembleMemberName 返回集成成员名称：优先使用配置的 name，其次 provider/model，最后按序号区分。
+func ensembleMemberName(ai AIConfig, index int) string {
+	if name := strings.TrimSpace(ai.Name); name != "" {
+		return name
+	}
+	if ai.Model != "" {
+		return fmt.Sprintf("%s/%s", ai.Provider, ai.Model)
+	}
+	return fmt.Sprintf("%s-%d", ai.Provider, index)
+}
+
 // Duration 返回回测区间时长。
 func (cfg *BacktestConfig) Duration() time.Duration {
 	if cfg == nil {
@@ -176,3 +257,21 @@ func validateFillPolicy(policy string) error {
 		return fmt.Errorf("unsupported fill_policy '%s'", policy)
 	}
 }
+
+const (
+	// ProtectivePriorityStopLoss 同一根 K 线同时触及止损与止盈时按止损成交（保守）。
+	ProtectivePriorityStopLoss = "stop_loss_first"
+	// ProtectivePriorityTakeProfit 同一根 K 线同时触及时按止盈成交（乐观）。
+	ProtectivePriorityTakeProfit = "take_profit_first"
+	// ProtectivePriorityNearestOpen 同一根 K 线同时触及时，离开盘价更近的一侧先成交。
+	ProtectivePriorityNearestOpen = "nearest_open"
+)
+
+func validateProtectivePriority(priority string) error {
+	switch priority {
+	case ProtectivePriorityStopLoss, ProtectivePriorityTakeProfit, ProtectivePriorityNearestOpen:
+		return nil
+	default:
+		return fmt.Errorf("unsupported protective_priority '%s'", priority)
+	}
+}
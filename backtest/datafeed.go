@@ -2,6 +2,7 @@ package backtest
 
 import (
 	"fmt"
+	"log"
 	"sort"
 	"time"
 
@@ -26,6 +27,8 @@ type DataFeed struct {
 	decisionTimes []int64
 	primaryTF     string
 	longerTF      string
+	funding       map[string][]market.FundingRatePoint
+	provider      market.MarketDataProvider // 历史K线数据源，交易所专用数据源失败时回退到币安
 }
 
 func NewDataFeed(cfg BacktestConfig) (*DataFeed, error) {
@@ -35,6 +38,8 @@ func NewDataFeed(cfg BacktestConfig) (*DataFeed, error) {
 		timeframes:   append([]string(nil), cfg.Timeframes...),
 		symbolSeries: make(map[string]*symbolSeries),
 		primaryTF:    cfg.DecisionTimeframe,
+		funding:      make(map[string][]market.FundingRatePoint),
+		provider:     market.ProviderForExchange(cfg.Exchange, market.ProviderBinance),
 	}
 	copy(df.symbols, cfg.Symbols)
 
@@ -73,7 +78,7 @@ func (df *DataFeed) loadAll() error {
 			}
 			fetchEnd := end.Add(dur)
 
-			klines, err := market.GetKlinesRange(symbol, tf, fetchStart, fetchEnd)
+			klines, err := market.GetKlinesRange(df.provider, symbol, tf, fetchStart, fetchEnd)
 			if err != nil {
 				return fmt.Errorf("fetch klines for %s %s: %w", symbol, tf, err)
 			}
@@ -91,6 +96,16 @@ func (df *DataFeed) loadAll() error {
 			ss.byTF[tf] = series
 		}
 		df.symbolSeries[symbol] = ss
+
+		if !df.cfg.DisableFunding {
+			// 资金费率缺失不影响回测主体，仅记录告警（该币种视为零资金费）
+			points, err := market.GetFundingRateHistory(symbol, start.Add(-24*time.Hour), end)
+			if err != nil {
+				log.Printf("⚠️  fetch funding rates for %s failed, funding ignored: %v", symbol, err)
+			} else {
+				df.funding[symbol] = points
+			}
+		}
 	}
 
 	// 以第一个符号的主周期生成回测进度时间轴
@@ -157,6 +172,7 @@ func (df *DataFeed) BuildMarketData(ts int64) (map[string]*market.Data, map[stri
 			if err != nil {
 				return nil, nil, err
 			}
+			data.FundingRate = df.latestFundingRate(symbol, ts)
 			perTF[tf] = data
 			if tf == df.primaryTF {
 				result[symbol] = data
@@ -192,3 +208,33 @@ func (df *DataFeed) decisionBarSnapshot(symbol string, ts int64) (*market.Kline,
 	}
 	return curr, next
 }
+
+// FundingEventsBetween 返回 (fromTs, toTs] 区间内发生的资金费结算。
+func (df *DataFeed) FundingEventsBetween(symbol string, fromTs, toTs int64) []market.FundingRatePoint {
+	points := df.funding[symbol]
+	if len(points) == 0 || toTs <= fromTs {
+		return nil
+	}
+	lo := sort.Search(len(points), func(i int) bool {
+		return points[i].FundingTime > fromTs
+	})
+	hi := sort.Search(len(points), func(i int) bool {
+		return points[i].FundingTime > toTs
+	})
+	if lo >= hi {
+		return nil
+	}
+	return points[lo:hi]
+}
+
+// latestFundingRate 返回 ts 时刻已公布的最近一期资金费率（与实盘 prompt 中的 FundingRate 对应）。
+func (df *DataFeed) latestFundingRate(symbol string, ts int64) float64 {
+	points := df.funding[symbol]
+	idx := sort.Search(len(points), func(i int) bool {
+		return points[i].FundingTime > ts
+	})
+	if idx <= 0 {
+		return 0
+	}
+	return points[idx-1].Rate
+}
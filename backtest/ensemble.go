@@ -0,0 +1,94 @@
+package backtest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+
+	"nofx/decision"
+	"nofx/logger"
+	"nofx/mcp"
+)
+
+// buildEnsembleMembers 根据配置构建集成决策成员，主模型排在第一位。未配置 Ensemble 时返回 nil。
+func buildEnsembleMembers(cfg BacktestConfig, primary mcp.AIClient, base mcp.AIClient) ([]decision.EnsembleMember, error) {
+	if len(cfg.Ensemble) == 0 {
+		return nil, nil
+	}
+
+	members := []decision.EnsembleMember{{Name: cfg.AICfg.Name, Client: primary}}
+	for _, aiCfg := range cfg.Ensemble {
+		memberCfg := cfg
+		memberCfg.AICfg = aiCfg
+		client, err := configureMCPClient(memberCfg, base)
+		if err != nil {
+			return nil, fmt.Errorf("ensemble member %s: %w", aiCfg.Name, err)
+		}
+		members = append(members, decision.EnsembleMember{Name: aiCfg.Name, Client: client})
+	}
+	return members, nil
+}
+
+// memberCacheKey 在周期缓存键的基础上区分集成成员，每个成员的决策单独缓存。
+func memberCacheKey(cacheKey, memberName string) string {
+	if cacheKey == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(cacheKey + "|" + memberName))
+	return hex.EncodeToString(sum[:])
+}
+
+// invokeEnsemble 并发请求所有集成成员并投票合并。成员决策优先从 AI 缓存读取，
+// replay_only 下缓存未命中的成员视为失败；新获得的成员决策在合并后写入缓存。
+func (r *Runner) invokeEnsemble(ctx *decision.Context, cacheKey string, ts int64) (*decision.FullDecision, []logger.EnsembleMemberRecord, error) {
+	var (
+		mu        sync.Mutex
+		fromCache = make(map[string]bool)
+	)
+	decide := func(member decision.EnsembleMember) (*decision.FullDecision, error) {
+		key := memberCacheKey(cacheKey, member.Name)
+		if r.aiCache != nil && key != "" {
+			if cached, ok := r.aiCache.Get(key); ok {
+				mu.Lock()
+				fromCache[member.Name] = true
+				mu.Unlock()
+				return cached, nil
+			}
+			if r.cfg.ReplayOnly {
+				return nil, fmt.Errorf("replay_only enabled but cache miss at %d", ts)
+			}
+		}
+		return r.invokeAIWithRetry(ctx, member.Client)
+	}
+
+	merged, results, err := decision.GetEnsembleDecision(ctx, r.ensembleMembers, r.cfg.EnsembleVote, decide)
+
+	records := make([]logger.EnsembleMemberRecord, 0, len(results))
+	for _, res := range results {
+		rec := logger.EnsembleMemberRecord{Name: res.Name, FromCache: fromCache[res.Name]}
+		if res.Err != nil {
+			rec.Error = res.Err.Error()
+		}
+		if res.Decision != nil {
+			rec.RawResponse = res.Decision.RawResponse
+			rec.CoTTrace = res.Decision.CoTTrace
+			rec.DurationMs = res.Decision.AIRequestDurationMs
+			if len(res.Decision.Decisions) > 0 {
+				if data, err := json.MarshalIndent(res.Decision.Decisions, "", "  "); err == nil {
+					rec.DecisionJSON = string(data)
+				}
+			}
+		}
+		records = append(records, rec)
+
+		if res.Err == nil && !rec.FromCache && r.cfg.CacheAI && r.aiCache != nil {
+			if err := r.aiCache.Put(memberCacheKey(cacheKey, res.Name), r.cfg.PromptVariant, ts, res.Decision); err != nil {
+				log.Printf("failed to persist ai cache for %s/%s: %v", r.cfg.RunID, res.Name, err)
+			}
+		}
+	}
+	return merged, records, err
+}
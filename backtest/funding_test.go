@@ -0,0 +1,139 @@
+package backtest
+
+import (
+	"testing"
+
+	"nofx/market"
+)
+
+func fundingFeed(points ...market.FundingRatePoint) *DataFeed {
+	return &DataFeed{funding: map[string][]market.FundingRatePoint{"BTCUSDT": points}}
+}
+
+func TestFundingEventsBetween(t *testing.T) {
+	feed := fundingFeed(
+		market.FundingRatePoint{FundingTime: 100, Rate: 0.0001},
+		market.FundingRatePoint{FundingTime: 200, Rate: -0.0002},
+		market.FundingRatePoint{FundingTime: 300, Rate: 0.0003},
+	)
+
+	tests := []struct {
+		name     string
+		symbol   string
+		from, to int64
+		want     []int64
+	}{
+		{"结算时间等于K线收盘时间时归入该K线", "BTCUSDT", 100, 200, []int64{200}},
+		{"上一根K线收盘时刻的结算不重复计入", "BTCUSDT", 200, 250, nil},
+		{"跨越多个结算时间", "BTCUSDT", 150, 350, []int64{200, 300}},
+		{"区间内没有结算", "BTCUSDT", 310, 400, nil},
+		{"区间无效", "BTCUSDT", 300, 300, nil},
+		{"未知币种", "ETHUSDT", 0, 1000, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := feed.FundingEventsBetween(tt.symbol, tt.from, tt.to)
+			if len(events) != len(tt.want) {
+				t.Fatalf("got %+v, want times %v", events, tt.want)
+			}
+			for i, ev := range events {
+				if ev.FundingTime != tt.want[i] {
+					t.Errorf("event %d at %d, want %d", i, ev.FundingTime, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLatestFundingRate(t *testing.T) {
+	feed := fundingFeed(
+		market.FundingRatePoint{FundingTime: 100, Rate: 0.0001},
+		market.FundingRatePoint{FundingTime: 200, Rate: -0.0002},
+	)
+	if rate := feed.latestFundingRate("BTCUSDT", 50); rate != 0 {
+		t.Errorf("before the first settlement the rate should be 0, got %v", rate)
+	}
+	if rate := feed.latestFundingRate("BTCUSDT", 200); rate != -0.0002 {
+		t.Errorf("settlement at ts should be visible, got %v", rate)
+	}
+	if rate := feed.latestFundingRate("BTCUSDT", 199); rate != 0.0001 {
+		t.Errorf("future settlement must not leak, got %v", rate)
+	}
+	if rate := feed.latestFundingRate("ETHUSDT", 500); rate != 0 {
+		t.Errorf("missing funding data should return 0, got %v", rate)
+	}
+}
+
+func TestSettleFunding(t *testing.T) {
+	newRunner := func(points ...market.FundingRatePoint) *Runner {
+		r := &Runner{
+			cfg:     BacktestConfig{Symbols: []string{"BTCUSDT"}},
+			feed:    fundingFeed(points...),
+			account: NewBacktestAccount(10000, 0, 0),
+		}
+		if _, _, _, err := r.account.Open("BTCUSDT", "long", 2, 5, 100, 0); err != nil {
+			t.Fatalf("open long: %v", err)
+		}
+		if _, _, _, err := r.account.Open("BTCUSDT", "short", 1, 5, 100, 0); err != nil {
+			t.Fatalf("open short: %v", err)
+		}
+		return r
+	}
+
+	t.Run("正费率多头支付空头收取", func(t *testing.T) {
+		r := newRunner(market.FundingRatePoint{FundingTime: 100, Rate: 0.001, MarkPrice: 110})
+		cashBefore := r.account.Cash()
+
+		events, _ := r.settleFunding(0, 100, map[string]float64{"BTCUSDT": 105}, 1)
+		if len(events) != 2 {
+			t.Fatalf("expected one event per side, got %+v", events)
+		}
+		payments := map[string]float64{}
+		for _, ev := range events {
+			payments[ev.Side] = ev.RealizedPnL
+			if ev.Action != fundingAction || ev.Price != 110 || ev.Timestamp != 100 {
+				t.Errorf("unexpected event: %+v", ev)
+			}
+		}
+		if !approxEqual(payments["long"], -2*110*0.001) || !approxEqual(payments["short"], 1*110*0.001) {
+			t.Errorf("unexpected payments: %v", payments)
+		}
+		if !approxEqual(r.account.Cash()-cashBefore, -0.11) || !approxEqual(r.account.FundingPnL(), -0.11) {
+			t.Errorf("cash change %v, funding pnl %v", r.account.Cash()-cashBefore, r.account.FundingPnL())
+		}
+	})
+
+	t.Run("负费率空头支付", func(t *testing.T) {
+		r := newRunner(market.FundingRatePoint{FundingTime: 100, Rate: -0.001, MarkPrice: 100})
+		events, _ := r.settleFunding(0, 100, nil, 1)
+		for _, ev := range events {
+			if (ev.Side == "long") != (ev.RealizedPnL > 0) {
+				t.Errorf("%s payment has wrong sign: %v", ev.Side, ev.RealizedPnL)
+			}
+		}
+	})
+
+	t.Run("缺少标记价格时使用当前价格", func(t *testing.T) {
+		r := newRunner(market.FundingRatePoint{FundingTime: 100, Rate: 0.001})
+		events, _ := r.settleFunding(0, 100, map[string]float64{"BTCUSDT": 105}, 1)
+		if len(events) != 2 || events[0].Price != 105 {
+			t.Errorf("mark price should fall back to the bar price: %+v", events)
+		}
+	})
+
+	t.Run("缺少费率时不结算", func(t *testing.T) {
+		r := newRunner(market.FundingRatePoint{FundingTime: 100, Rate: 0, MarkPrice: 100})
+		cashBefore := r.account.Cash()
+		if events, _ := r.settleFunding(0, 100, nil, 1); len(events) != 0 || r.account.Cash() != cashBefore {
+			t.Errorf("zero rate should not settle: %+v", events)
+		}
+		if events, _ := newRunner().settleFunding(0, 100, nil, 1); len(events) != 0 {
+			t.Errorf("no funding data should not settle: %+v", events)
+		}
+	})
+}
+
+func approxEqual(a, b float64) bool {
+	const tolerance = 1e-9
+	return a-b < tolerance && b-a < tolerance
+}
@@ -0,0 +1,192 @@
+package backtest
+
+import (
+	"fmt"
+	"strings"
+
+	"nofx/decision"
+	"nofx/logger"
+)
+
+// PendingLimitOrder 回测中已挂出但尚未成交的限价开仓单。
+type PendingLimitOrder struct {
+	Symbol      string  `json:"symbol"`
+	Side        string  `json:"side"`
+	Action      string  `json:"action"`
+	Quantity    float64 `json:"quantity"`
+	Leverage    int     `json:"leverage"`
+	LimitPrice  float64 `json:"limit_price"`
+	StopLoss    float64 `json:"stop_loss,omitempty"`
+	TakeProfit  float64 `json:"take_profit,omitempty"`
+	PlacedCycle int     `json:"placed_cycle"`
+
+	TakeProfitLevels  []decision.TakeProfitLevel `json:"take_profit_levels,omitempty"`
+	BreakevenAfterTP1 bool                       `json:"breakeven_after_tp1,omitempty"`
+}
+
+// placeLimitOrder 处理限价/只做 Maker 开仓决策。
+// 决策时即可成交的限价单按 min(当前价, 限价)（空单取 max）立即成交；只做 Maker 单在此情况下被拒绝；
+// 无法立即成交的 IOC 单直接取消，其余挂单等待后续 K 线撮合。
+func (r *Runner) placeLimitOrder(dec decision.Decision, side string, leverage int, basePrice float64, ts int64, cycle int, actionRecord logger.DecisionAction) (logger.DecisionAction, []TradeEvent, string, error) {
+	symbol := strings.ToUpper(dec.Symbol)
+	limitPrice := dec.LimitPrice
+
+	for _, order := range r.pendingOrders {
+		if order.Symbol == symbol && order.Side == side {
+			return actionRecord, nil, "", fmt.Errorf("%s %s already has a pending limit order @ %.4f", symbol, side, order.LimitPrice)
+		}
+	}
+
+	qty := r.determineQuantity(dec, limitPrice)
+	if qty <= 0 {
+		return actionRecord, nil, "", fmt.Errorf("invalid qty")
+	}
+	actionRecord.Quantity = qty
+	actionRecord.Price = limitPrice
+
+	marketable := basePrice <= limitPrice
+	if side == "short" {
+		marketable = basePrice >= limitPrice
+	}
+
+	if marketable {
+		if dec.OrderType == decision.OrderTypePostOnly {
+			return actionRecord, nil, "", fmt.Errorf("post_only order would cross the book (price %.4f, limit %.4f)", basePrice, limitPrice)
+		}
+		fillPrice := limitPrice
+		if (side == "long" && basePrice < limitPrice) || (side == "short" && basePrice > limitPrice) {
+			fillPrice = basePrice
+		}
+		order := &PendingLimitOrder{
+			Symbol:      symbol,
+			Side:        side,
+			Action:      dec.Action,
+			Quantity:    qty,
+			Leverage:    leverage,
+			LimitPrice:  limitPrice,
+			StopLoss:    dec.StopLoss,
+			TakeProfit:  dec.TakeProfit,
+			PlacedCycle: cycle,
+
+			TakeProfitLevels:  r.takeProfitLadder(dec, limitPrice),
+			BreakevenAfterTP1: r.breakevenAfterTP1(dec),
+		}
+		trade, err := r.fillLimitOrder(order, fillPrice, ts, cycle)
+		if err != nil {
+			return actionRecord, nil, "", err
+		}
+		actionRecord.Price = trade.Price
+		actionRecord.Leverage = trade.Leverage
+		return actionRecord, []TradeEvent{trade}, "", nil
+	}
+
+	if dec.TimeInForce == decision.TimeInForceIOC {
+		return actionRecord, nil, "", fmt.Errorf("IOC limit order not filled (price %.4f, limit %.4f)", basePrice, limitPrice)
+	}
+
+	r.pendingOrders = append(r.pendingOrders, &PendingLimitOrder{
+		Symbol:      symbol,
+		Side:        side,
+		Action:      dec.Action,
+		Quantity:    qty,
+		Leverage:    leverage,
+		LimitPrice:  limitPrice,
+		StopLoss:    dec.StopLoss,
+		TakeProfit:  dec.TakeProfit,
+		PlacedCycle: cycle,
+
+		TakeProfitLevels:  r.takeProfitLadder(dec, limitPrice),
+		BreakevenAfterTP1: r.breakevenAfterTP1(dec),
+	})
+	logEntry := fmt.Sprintf("📝 %s %s 挂出限价单 %.4f @ %.4f（最多等待 %d 个周期）", symbol, strings.ToUpper(side), qty, limitPrice, r.cfg.PendingOrderMaxCycles)
+	return actionRecord, nil, logEntry, nil
+}
+
+// checkPendingOrders 使用当前决策 K 线撮合挂单：多单最低价触及限价即成交，空单最高价触及限价即成交，
+// 开盘即跳空越过限价时按更优的开盘价成交。决策 K 线上超过挂单周期的订单先撤销再参与本轮决策。
+func (r *Runner) checkPendingOrders(ts int64, callCount int, decisionBar bool) ([]TradeEvent, []string) {
+	if len(r.pendingOrders) == 0 {
+		return nil, nil
+	}
+
+	events := make([]TradeEvent, 0)
+	logs := make([]string, 0)
+	remaining := r.pendingOrders[:0]
+	for _, order := range r.pendingOrders {
+		bar, _ := r.feed.decisionBarSnapshot(order.Symbol, ts)
+		if bar != nil {
+			fillPrice, filled := 0.0, false
+			if order.Side == "long" && bar.Low > 0 && bar.Low <= order.LimitPrice {
+				fillPrice, filled = order.LimitPrice, true
+				if bar.Open > 0 && bar.Open < order.LimitPrice {
+					fillPrice = bar.Open
+				}
+			} else if order.Side == "short" && bar.High >= order.LimitPrice {
+				fillPrice, filled = order.LimitPrice, true
+				if bar.Open > order.LimitPrice {
+					fillPrice = bar.Open
+				}
+			}
+			if filled {
+				trade, err := r.fillLimitOrder(order, fillPrice, ts, callCount)
+				if err != nil {
+					logs = append(logs, fmt.Sprintf("⚠️ %s %s 限价单成交失败，已撤销: %v", order.Symbol, strings.ToUpper(order.Side), err))
+					continue
+				}
+				events = append(events, trade)
+				logs = append(logs, fmt.Sprintf("📝 %s %s 限价单成交 %.4f @ %.4f", order.Symbol, strings.ToUpper(order.Side), trade.Quantity, trade.Price))
+				continue
+			}
+		}
+
+		if decisionBar && callCount-order.PlacedCycle >= r.cfg.PendingOrderMaxCycles {
+			logs = append(logs, fmt.Sprintf("⏰ %s %s 限价单 @ %.4f 挂单 %d 个周期未成交，已撤销", order.Symbol, strings.ToUpper(order.Side), order.LimitPrice, callCount-order.PlacedCycle))
+			continue
+		}
+		remaining = append(remaining, order)
+	}
+	r.pendingOrders = remaining
+	return events, logs
+}
+
+// fillLimitOrder 按成交价开仓并登记决策中的止损/止盈。
+func (r *Runner) fillLimitOrder(order *PendingLimitOrder, fillPrice float64, ts int64, cycle int) (TradeEvent, error) {
+	pos, fee, execPrice, err := r.account.OpenLimit(order.Symbol, order.Side, order.Quantity, order.Leverage, fillPrice, ts)
+	if err != nil {
+		return TradeEvent{}, err
+	}
+	r.applyProtectiveOrders(order.Symbol, order.Side, order.StopLoss, order.TakeProfit, order.TakeProfitLevels, order.BreakevenAfterTP1)
+	return TradeEvent{
+		Timestamp:     ts,
+		Symbol:        order.Symbol,
+		Action:        order.Action,
+		Side:          order.Side,
+		Quantity:      order.Quantity,
+		Price:         execPrice,
+		Fee:           fee,
+		OrderValue:    execPrice * order.Quantity,
+		Leverage:      pos.Leverage,
+		Cycle:         cycle,
+		PositionAfter: pos.Quantity,
+		Note:          fmt.Sprintf("limit order @ %.4f", order.LimitPrice),
+	}, nil
+}
+
+func (r *Runner) snapshotPendingOrders() []PendingLimitOrder {
+	if len(r.pendingOrders) == 0 {
+		return nil
+	}
+	list := make([]PendingLimitOrder, 0, len(r.pendingOrders))
+	for _, order := range r.pendingOrders {
+		list = append(list, *order)
+	}
+	return list
+}
+
+func (r *Runner) restorePendingOrders(orders []PendingLimitOrder) {
+	r.pendingOrders = make([]*PendingLimitOrder, 0, len(orders))
+	for i := range orders {
+		order := orders[i]
+		r.pendingOrders = append(r.pendingOrders, &order)
+	}
+}
@@ -46,6 +46,10 @@ func CalculateMetrics(runID string, cfg *BacktestConfig, state *BacktestState) (
 
 	fillTradeMetrics(metrics, events)
 
+	if state != nil {
+		metrics.AIUsage = state.AIUsage
+	}
+
 	return metrics, nil
 }
 
@@ -150,7 +154,14 @@ func fillTradeMetrics(metrics *Metrics, events []TradeEvent) {
 	totalLossAmount := 0.0
 
 	for _, evt := range events {
-		include := evt.LiquidationFlag || strings.HasPrefix(evt.Action, "close")
+		// 资金费单独统计，不计入交易笔数与胜率
+		if evt.Action == fundingAction {
+			metrics.FundingPnL += evt.RealizedPnL
+			metrics.FundingEvents++
+			continue
+		}
+		include := evt.LiquidationFlag || strings.HasPrefix(evt.Action, "close") ||
+			evt.Action == protectiveStopLoss || evt.Action == protectiveTakeProfit
 		if evt.RealizedPnL != 0 {
 			include = true
 		}
@@ -0,0 +1,231 @@
+package backtest
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"nofx/market"
+)
+
+const (
+	protectiveStopLoss     = "stop_loss"
+	protectiveTakeProfit   = "take_profit"
+	protectiveTrailingStop = "trailing_stop"
+)
+
+// checkProtectiveOrders 使用当前决策 K 线的高低点模拟交易所侧止损/止盈单的触发。
+// 只检查本根 K 线开始前已存在的持仓，本轮新开仓位从下一根 K 线开始生效，避免未来函数。
+func (r *Runner) checkProtectiveOrders(ts int64, cycle int) ([]TradeEvent, []string, error) {
+	positions := append([]*position(nil), r.account.Positions()...)
+	sort.Slice(positions, func(i, j int) bool {
+		return positionKey(positions[i].Symbol, positions[i].Side) < positionKey(positions[j].Symbol, positions[j].Side)
+	})
+
+	events := make([]TradeEvent, 0)
+	logs := make([]string, 0)
+	for _, pos := range positions {
+		if pos.StopLoss <= 0 && pos.TakeProfit <= 0 && !hasTrailingStop(pos) {
+			continue
+		}
+		bar, _ := r.feed.decisionBarSnapshot(pos.Symbol, ts)
+		if bar == nil {
+			continue
+		}
+
+		// 分批止盈部分成交后继续用同一根 K 线检查后续档位；移动后的保本止损从下一根 K 线开始生效
+		for partial := false; ; partial = true {
+			// 已激活的追踪止损比固定止损更近时，按追踪止损价参与撮合
+			effective := *pos
+			trailing := false
+			if partial {
+				effective.StopLoss = 0
+			} else if trail := trailingStopPrice(pos); trail > 0 {
+				if effective.StopLoss <= 0 || (pos.Side == "long" && trail > effective.StopLoss) || (pos.Side == "short" && trail < effective.StopLoss) {
+					effective.StopLoss = trail
+					trailing = true
+				}
+			}
+
+			kind, triggerPrice := resolveProtectiveTrigger(&effective, *bar, r.cfg.ProtectivePriority)
+			if kind == "" {
+				advanceTrailingStop(pos, *bar)
+				break
+			}
+			if kind == protectiveStopLoss && trailing {
+				kind = protectiveTrailingStop
+			}
+
+			symbol, side, qty, lev := pos.Symbol, pos.Side, pos.Quantity, pos.Leverage
+			ladder := kind == protectiveTakeProfit && len(pos.TakeProfitLevels) > 1
+			note := fmt.Sprintf("%s triggered at %.4f", kind, triggerPrice)
+			if ladder {
+				qty = takeProfitQuantity(pos)
+				note = fmt.Sprintf("take_profit level triggered at %.4f (%d levels left)", triggerPrice, len(pos.TakeProfitLevels)-1)
+			}
+			realized, fee, execPrice, err := r.account.Close(symbol, side, qty, triggerPrice)
+			if err != nil {
+				return nil, nil, fmt.Errorf("trigger %s for %s %s: %w", kind, symbol, side, err)
+			}
+
+			slippage := triggerPrice - execPrice
+			if side == "short" {
+				slippage = execPrice - triggerPrice
+			}
+			remaining := r.remainingPosition(symbol, side)
+			events = append(events, TradeEvent{
+				Timestamp:     ts,
+				Symbol:        symbol,
+				Action:        kind,
+				Side:          side,
+				Quantity:      qty,
+				Price:         execPrice,
+				Fee:           fee,
+				Slippage:      slippage,
+				OrderValue:    execPrice * qty,
+				RealizedPnL:   realized - fee,
+				Leverage:      lev,
+				Cycle:         cycle,
+				PositionAfter: remaining,
+				Note:          note,
+			})
+
+			label := "止损"
+			switch {
+			case ladder:
+				label = "分批止盈"
+			case kind == protectiveTakeProfit:
+				label = "止盈"
+			case kind == protectiveTrailingStop:
+				label = "追踪止损"
+			}
+			logs = append(logs, fmt.Sprintf("🎯 %s %s 触发%s @ %.4f (盈亏 %+.2f)", symbol, strings.ToUpper(side), label, execPrice, realized-fee))
+
+			if !ladder || remaining <= epsilon {
+				break
+			}
+			if advanceTakeProfitLadder(pos) {
+				logs = append(logs, fmt.Sprintf("🛡️ %s %s 第一档止盈成交，止损移至开仓价 %.4f", symbol, strings.ToUpper(side), pos.StopLoss))
+			}
+		}
+	}
+	return events, logs, nil
+}
+
+// resolveProtectiveTrigger 判断单根 K 线内止损/止盈是否触发，返回触发类型与成交参考价。
+// 开盘即跳空越过触发价时按开盘价成交；同一根 K 线同时触及两侧时按 priority 决定先后。
+func resolveProtectiveTrigger(pos *position, bar market.Kline, priority string) (string, float64) {
+	var (
+		slHit, tpHit     bool
+		slPrice, tpPrice float64
+		slGap, tpGap     bool
+	)
+
+	if pos.Side == "long" {
+		if pos.StopLoss > 0 && bar.Low <= pos.StopLoss {
+			slHit = true
+			slPrice = pos.StopLoss
+			if bar.Open > 0 && bar.Open < pos.StopLoss {
+				slPrice, slGap = bar.Open, true
+			}
+		}
+		if pos.TakeProfit > 0 && bar.High >= pos.TakeProfit {
+			tpHit = true
+			tpPrice = pos.TakeProfit
+			if bar.Open > pos.TakeProfit {
+				tpPrice, tpGap = bar.Open, true
+			}
+		}
+	} else {
+		if pos.StopLoss > 0 && bar.High >= pos.StopLoss {
+			slHit = true
+			slPrice = pos.StopLoss
+			if bar.Open > pos.StopLoss {
+				slPrice, slGap = bar.Open, true
+			}
+		}
+		if pos.TakeProfit > 0 && bar.Low <= pos.TakeProfit {
+			tpHit = true
+			tpPrice = pos.TakeProfit
+			if bar.Open > 0 && bar.Open < pos.TakeProfit {
+				tpPrice, tpGap = bar.Open, true
+			}
+		}
+	}
+
+	switch {
+	case slHit && !tpHit:
+		return protectiveStopLoss, slPrice
+	case tpHit && !slHit:
+		return protectiveTakeProfit, tpPrice
+	case !slHit && !tpHit:
+		return "", 0
+	}
+
+	// 两侧同时触及：跳空一侧必然先成交
+	if slGap {
+		return protectiveStopLoss, slPrice
+	}
+	if tpGap {
+		return protectiveTakeProfit, tpPrice
+	}
+
+	switch priority {
+	case ProtectivePriorityTakeProfit:
+		return protectiveTakeProfit, tpPrice
+	case ProtectivePriorityNearestOpen:
+		if math.Abs(bar.Open-pos.TakeProfit) < math.Abs(bar.Open-pos.StopLoss) {
+			return protectiveTakeProfit, tpPrice
+		}
+		return protectiveStopLoss, slPrice
+	default:
+		return protectiveStopLoss, slPrice
+	}
+}
+
+func hasTrailingStop(pos *position) bool {
+	return pos.TrailingRate > 0 || pos.TrailingDistance > 0
+}
+
+// trailingStopPrice 返回已激活追踪止损的当前触发价，未设置或未激活时返回 0。
+func trailingStopPrice(pos *position) float64 {
+	if !hasTrailingStop(pos) || pos.TrailingExtreme <= 0 {
+		return 0
+	}
+	offset := pos.TrailingDistance
+	if pos.TrailingRate > 0 {
+		offset = pos.TrailingExtreme * pos.TrailingRate / 100
+	}
+	if pos.Side == "long" {
+		return pos.TrailingExtreme - offset
+	}
+	return pos.TrailingExtreme + offset
+}
+
+// advanceTrailingStop 在未触发的 K 线收盘后推进追踪止损：先判断激活，再用本根最高/最低价更新极值。
+// 本根 K 线内新产生的极值从下一根 K 线开始生效，避免同一根 K 线内先创新高再回撤的顺序无法判定。
+func advanceTrailingStop(pos *position, bar market.Kline) {
+	if !hasTrailingStop(pos) {
+		return
+	}
+	if pos.Side == "long" {
+		if pos.TrailingExtreme <= 0 {
+			if bar.High < pos.TrailingActivation {
+				return
+			}
+			pos.TrailingExtreme = pos.TrailingActivation
+		}
+		pos.TrailingExtreme = math.Max(pos.TrailingExtreme, bar.High)
+		return
+	}
+	if pos.TrailingExtreme <= 0 {
+		if bar.Low <= 0 || bar.Low > pos.TrailingActivation {
+			return
+		}
+		pos.TrailingExtreme = pos.TrailingActivation
+	}
+	if bar.Low > 0 {
+		pos.TrailingExtreme = math.Min(pos.TrailingExtreme, bar.Low)
+	}
+}
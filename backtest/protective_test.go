@@ -0,0 +1,127 @@
+package backtest
+
+import (
+	"testing"
+
+	"nofx/market"
+)
+
+func TestResolveProtectiveTrigger(t *testing.T) {
+	long := &position{Symbol: "BTCUSDT", Side: "long", StopLoss: 95, TakeProfit: 110}
+	short := &position{Symbol: "BTCUSDT", Side: "short", StopLoss: 105, TakeProfit: 90}
+	bar := func(open, high, low float64) market.Kline {
+		return market.Kline{Open: open, High: high, Low: low, Close: open}
+	}
+
+	tests := []struct {
+		name      string
+		pos       *position
+		bar       market.Kline
+		priority  string
+		wantKind  string
+		wantPrice float64
+	}{
+		{"多头止损", long, bar(100, 102, 94), ProtectivePriorityStopLoss, protectiveStopLoss, 95},
+		{"多头止盈", long, bar(100, 111, 99), ProtectivePriorityStopLoss, protectiveTakeProfit, 110},
+		{"空头止损", short, bar(100, 106, 99), ProtectivePriorityStopLoss, protectiveStopLoss, 105},
+		{"空头止盈", short, bar(100, 101, 89), ProtectivePriorityStopLoss, protectiveTakeProfit, 90},
+		{"多头跳空越过止损按开盘价成交", long, bar(93, 96, 92), ProtectivePriorityStopLoss, protectiveStopLoss, 93},
+		{"空头跳空越过止损按开盘价成交", short, bar(107, 108, 104), ProtectivePriorityStopLoss, protectiveStopLoss, 107},
+		{"多头跳空越过止盈按开盘价成交", long, bar(112, 113, 108), ProtectivePriorityStopLoss, protectiveTakeProfit, 112},
+		{"同时触及_止损优先", long, bar(100, 111, 94), ProtectivePriorityStopLoss, protectiveStopLoss, 95},
+		{"同时触及_止盈优先", long, bar(100, 111, 94), ProtectivePriorityTakeProfit, protectiveTakeProfit, 110},
+		{"同时触及_离开盘价近的止盈先成交", long, bar(108, 111, 94), ProtectivePriorityNearestOpen, protectiveTakeProfit, 110},
+		{"同时触及_离开盘价近的止损先成交", long, bar(97, 111, 94), ProtectivePriorityNearestOpen, protectiveStopLoss, 95},
+		{"空头同时触及_止盈优先", short, bar(100, 106, 89), ProtectivePriorityTakeProfit, protectiveTakeProfit, 90},
+		{"同时触及但跳空越过止损时止损先成交", long, bar(94, 111, 93), ProtectivePriorityTakeProfit, protectiveStopLoss, 94},
+		{"多头均未触及", long, bar(100, 105, 97), ProtectivePriorityStopLoss, "", 0},
+		{"空头均未触及", short, bar(100, 104, 91), ProtectivePriorityTakeProfit, "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kind, price := resolveProtectiveTrigger(tt.pos, tt.bar, tt.priority)
+			if kind != tt.wantKind || price != tt.wantPrice {
+				t.Errorf("got (%q, %v), want (%q, %v)", kind, price, tt.wantKind, tt.wantPrice)
+			}
+		})
+	}
+}
+
+// newProtectiveRunner 创建只包含账户和单根决策 K 线的 Runner
+func newProtectiveRunner(priority string, ts int64, bar market.Kline) *Runner {
+	bar.CloseTime = ts
+	feed := &DataFeed{
+		primaryTF: "3m",
+		symbolSeries: map[string]*symbolSeries{
+			"BTCUSDT": {byTF: map[string]*timeframeSeries{
+				"3m": {klines: []market.Kline{bar}, closeTimes: []int64{ts}},
+			}},
+		},
+	}
+	return &Runner{
+		cfg:     BacktestConfig{ProtectivePriority: priority},
+		feed:    feed,
+		account: NewBacktestAccount(10000, 0, 0),
+	}
+}
+
+func TestCheckProtectiveOrders(t *testing.T) {
+	const ts = int64(1_700_000_000_000)
+
+	tests := []struct {
+		name      string
+		side      string
+		priority  string
+		bar       market.Kline
+		wantKind  string
+		wantPrice float64
+	}{
+		{"多头止损", "long", ProtectivePriorityStopLoss, market.Kline{Open: 100, High: 102, Low: 94}, protectiveStopLoss, 95},
+		{"空头止盈", "short", ProtectivePriorityStopLoss, market.Kline{Open: 100, High: 101, Low: 89}, protectiveTakeProfit, 90},
+		{"多头跳空止损", "long", ProtectivePriorityStopLoss, market.Kline{Open: 93, High: 96, Low: 92}, protectiveStopLoss, 93},
+		{"同时触及_止损优先", "long", ProtectivePriorityStopLoss, market.Kline{Open: 100, High: 111, Low: 94}, protectiveStopLoss, 95},
+		{"同时触及_止盈优先", "long", ProtectivePriorityTakeProfit, market.Kline{Open: 100, High: 111, Low: 94}, protectiveTakeProfit, 110},
+		{"均未触及", "long", ProtectivePriorityStopLoss, market.Kline{Open: 100, High: 105, Low: 97}, "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newProtectiveRunner(tt.priority, ts, tt.bar)
+			if _, _, _, err := r.account.Open("BTCUSDT", tt.side, 1, 5, 100, ts-1); err != nil {
+				t.Fatalf("open: %v", err)
+			}
+			sl, tp := 95.0, 110.0
+			if tt.side == "short" {
+				sl, tp = 105, 90
+			}
+			if err := r.account.SetStopLoss("BTCUSDT", tt.side, sl); err != nil {
+				t.Fatalf("set stop loss: %v", err)
+			}
+			if err := r.account.SetTakeProfit("BTCUSDT", tt.side, tp); err != nil {
+				t.Fatalf("set take profit: %v", err)
+			}
+
+			events, _, err := r.checkProtectiveOrders(ts, 1)
+			if err != nil {
+				t.Fatalf("checkProtectiveOrders: %v", err)
+			}
+			if tt.wantKind == "" {
+				if len(events) != 0 || len(r.account.Positions()) != 1 {
+					t.Errorf("no trigger expected, got %+v", events)
+				}
+				return
+			}
+			if len(events) != 1 {
+				t.Fatalf("expected one event, got %+v", events)
+			}
+			ev := events[0]
+			if ev.Action != tt.wantKind || ev.Price != tt.wantPrice || ev.Side != tt.side || ev.PositionAfter != 0 {
+				t.Errorf("unexpected event: %+v", ev)
+			}
+			if len(r.account.Positions()) != 0 {
+				t.Error("position should be closed")
+			}
+		})
+	}
+}
@@ -38,6 +38,9 @@ type Runner struct {
 	decisionLogger logger.IDecisionLogger
 	mcpClient      mcp.AIClient
 
+	ensembleMembers []decision.EnsembleMember // 集成决策成员（含主模型），为空时只用主模型
+	aiUsage         mcp.UsageMeter            // 当前周期 AI 调用的用量，周期结束时累计到 state.AIUsage
+
 	statusMu sync.RWMutex
 	status   RunState
 
@@ -61,6 +64,8 @@ type Runner struct {
 
 	lockInfo *RunLockInfo
 	lockStop chan struct{}
+
+	pendingOrders []*PendingLimitOrder
 }
 
 // NewRunner 构建回测运行器。
@@ -74,6 +79,11 @@ func NewRunner(cfg BacktestConfig, mcpClient mcp.AIClient) (*Runner, error) {
 		return nil, err
 	}
 
+	members, err := buildEnsembleMembers(cfg, client, mcpClient)
+	if err != nil {
+		return nil, err
+	}
+
 	feed, err := NewDataFeed(cfg)
 	if err != nil {
 		return nil, err
@@ -131,6 +141,8 @@ func NewRunner(cfg BacktestConfig, mcpClient mcp.AIClient) (*Runner, error) {
 		aiCache:        aiCache,
 		cachePath:      cachePath,
 	}
+	r.ensembleMembers = members
+	r.attachUsageMeter()
 
 	if err := r.initLock(); err != nil {
 		return nil, err
@@ -288,6 +300,36 @@ func (r *Runner) stepOnce() error {
 
 	decisionAttempted := shouldDecide
 
+	// 先撮合上一轮遗留的止损/止盈单（基于本根 K 线的高低点）
+	protectiveEvents, protectiveLogs, err := r.checkProtectiveOrders(ts, state.DecisionCycle)
+	if err != nil {
+		return err
+	}
+	if len(protectiveEvents) > 0 {
+		tradeEvents = append(tradeEvents, protectiveEvents...)
+		execLog = append(execLog, protectiveLogs...)
+	}
+
+	// 撮合挂出的限价单，新成交仓位的止损/止盈从下一根 K 线开始检查
+	limitEvents, limitLogs := r.checkPendingOrders(ts, callCount, shouldDecide)
+	if len(limitEvents) > 0 {
+		tradeEvents = append(tradeEvents, limitEvents...)
+	}
+	if len(limitLogs) > 0 {
+		execLog = append(execLog, limitLogs...)
+	}
+
+	// 结算自上一根 K 线以来发生的资金费
+	prevTs := state.BarTimestamp
+	if prevTs == 0 {
+		prevTs = r.cfg.StartTS * 1000
+	}
+	fundingEvents, fundingLogs := r.settleFunding(prevTs, ts, priceMap, state.DecisionCycle)
+	if len(fundingEvents) > 0 {
+		tradeEvents = append(tradeEvents, fundingEvents...)
+		execLog = append(execLog, fundingLogs...)
+	}
+
 	if shouldDecide {
 		ctx, rec, err := r.buildDecisionContext(ts, marketData, multiTF, priceMap, callCount)
 		if err != nil {
@@ -304,7 +346,12 @@ func (r *Runner) stepOnce() error {
 			cacheKey     string
 		)
 		if r.aiCache != nil {
-			if key, err := computeCacheKey(ctx, r.cfg.PromptVariant, ts); err == nil {
+			if key, err := computeCacheKey(ctx, r.cfg.PromptVariant, ts); err != nil {
+				log.Printf("failed to compute ai cache key: %v", err)
+			} else if len(r.ensembleMembers) > 0 {
+				// 集成决策按成员分别缓存，在 invokeEnsemble 中查找
+				cacheKey = key
+			} else {
 				cacheKey = key
 				if cached, ok := r.aiCache.Get(cacheKey); ok {
 					fullDecision = cached
@@ -316,14 +363,29 @@ func (r *Runner) stepOnce() error {
 					_ = r.logDecision(record)
 					return decisionErr
 				}
-			} else {
-				log.Printf("failed to compute ai cache key: %v", err)
 			}
 		}
 
-		if !fromCache {
-			fd, err := r.invokeAIWithRetry(ctx)
+		if len(r.ensembleMembers) > 0 {
+			fd, members, err := r.invokeEnsemble(ctx, cacheKey, ts)
+			record.EnsembleVote = r.cfg.EnsembleVote
+			record.EnsembleMembers = members
+			if err != nil {
+				decisionAttempted = true
+				hadError = true
+				record.Success = false
+				record.ErrorMessage = fmt.Sprintf("AI决策失败: %v", err)
+				execLog = append(execLog, fmt.Sprintf("⚠️ AI决策失败: %v", err))
+				r.setLastError(err)
+			} else {
+				fullDecision = fd
+			}
+		} else if !fromCache {
+			fd, err := r.invokeAIWithRetry(ctx, r.mcpClient)
 			if err != nil {
+				if fd != nil {
+					r.recordRuleRejections(record, fd)
+				}
 				decisionAttempted = true
 				hadError = true
 				record.Success = false
@@ -340,6 +402,8 @@ func (r *Runner) stepOnce() error {
 			}
 		}
 
+		r.recordAIUsage(record)
+
 		if fullDecision != nil {
 			r.fillDecisionRecord(record, fullDecision)
 
@@ -499,10 +563,16 @@ func (r *Runner) buildDecisionContext(ts int64, marketData map[string]*market.Da
 		Positions:       positions,
 		CandidateCoins:  candidateCoins,
 		PromptVariant:   r.cfg.PromptVariant,
+		DecisionMode:    r.cfg.DecisionMode,
 		MarketDataMap:   marketData,
 		MultiTFMarket:   multiTF,
 		BTCETHLeverage:  r.cfg.Leverage.BTCETHLeverage,
 		AltcoinLeverage: r.cfg.Leverage.AltcoinLeverage,
+		Validation:      r.cfg.ValidationRules,
+	}
+	if ctx.DecisionMode == decision.DecisionModeAgent {
+		ctx.AgentTools = &feedAgentTools{feed: r.feed, runID: r.cfg.RunID, ts: ts}
+		ctx.AgentBudget = r.cfg.AgentBudget
 	}
 
 	record := &logger.DecisionRecord{
@@ -520,6 +590,8 @@ func (r *Runner) buildDecisionContext(ts int64, marketData map[string]*market.Da
 		record.CandidateCoins = append(record.CandidateCoins, coin.Symbol)
 	}
 	record.Timestamp = time.UnixMilli(ts).UTC()
+	version := decision.ResolvePromptVersion("", r.cfg.PromptTemplate, r.cfg.CustomPrompt, r.cfg.OverrideBasePrompt)
+	record.PromptVersions = []logger.PromptVersion{logger.PromptVersion(version)}
 
 	return ctx, record, nil
 }
@@ -532,14 +604,54 @@ func (r *Runner) fillDecisionRecord(record *logger.DecisionRecord, full *decisio
 			record.DecisionJSON = string(data)
 		}
 	}
+	r.recordRuleRejections(record, full)
+}
+
+// attachUsageMeter 统计主模型和集成成员每次 AI 调用的用量
+func (r *Runner) attachUsageMeter() {
+	clients := []mcp.AIClient{r.mcpClient}
+	for _, member := range r.ensembleMembers {
+		clients = append(clients, member.Client)
+	}
+	for _, client := range clients {
+		if reporter, ok := client.(mcp.UsageReporter); ok {
+			reporter.SetUsageHandler(r.aiUsage.Record)
+		}
+	}
+}
+
+// recordAIUsage 把本周期的 AI 用量写入决策记录，并累计到整个回测
+func (r *Runner) recordAIUsage(record *logger.DecisionRecord) {
+	usage := r.aiUsage.Take()
+	if usage.Calls == 0 {
+		return
+	}
+	aiUsage := logger.AIUsage(usage)
+	record.AIUsage = &aiUsage
+
+	r.stateMu.Lock()
+	r.state.AIUsage.Add(usage)
+	r.stateMu.Unlock()
 }
 
-func (r *Runner) invokeAIWithRetry(ctx *decision.Context) (*decision.FullDecision, error) {
-	var lastErr error
+// recordRuleRejections 把被校验规则拒绝的决策和 prompt 压缩情况写入决策日志
+func (r *Runner) recordRuleRejections(record *logger.DecisionRecord, full *decision.FullDecision) {
+	for _, rejection := range full.RuleRejections {
+		record.RuleRejections = append(record.RuleRejections, logger.RuleRejection(rejection))
+	}
+	record.PromptTokens = full.PromptTokens
+	record.PromptTrims = full.PromptTrims
+}
+
+func (r *Runner) invokeAIWithRetry(ctx *decision.Context, client mcp.AIClient) (*decision.FullDecision, error) {
+	var (
+		lastDecision *decision.FullDecision
+		lastErr      error
+	)
 	for attempt := 0; attempt < aiDecisionMaxRetries; attempt++ {
 		fd, err := decision.GetFullDecisionWithCustomPrompt(
 			ctx,
-			r.mcpClient,
+			client,
 			r.cfg.CustomPrompt,
 			r.cfg.OverrideBasePrompt,
 			r.cfg.PromptTemplate,
@@ -547,11 +659,12 @@ func (r *Runner) invokeAIWithRetry(ctx *decision.Context) (*decision.FullDecisio
 		if err == nil {
 			return fd, nil
 		}
-		lastErr = err
+		lastDecision, lastErr = fd, err
 		delay := time.Duration(attempt+1) * 500 * time.Millisecond
 		time.Sleep(delay)
 	}
-	return nil, lastErr
+	// 失败时返回最后一次解析出的部分决策，便于记录被拒绝的规则
+	return lastDecision, lastErr
 }
 
 func (r *Runner) executeDecision(dec decision.Decision, priceMap map[string]float64, ts int64, cycle int) (logger.DecisionAction, []TradeEvent, string, error) {
@@ -572,6 +685,9 @@ func (r *Runner) executeDecision(dec decision.Decision, priceMap map[string]floa
 
 	switch dec.Action {
 	case "open_long":
+		if dec.IsLimitOrder() {
+			return r.placeLimitOrder(dec, "long", usedLeverage, basePrice, ts, cycle, actionRecord)
+		}
 		qty := r.determineQuantity(dec, basePrice)
 		if qty <= 0 {
 			return actionRecord, nil, "", fmt.Errorf("invalid qty")
@@ -580,6 +696,7 @@ func (r *Runner) executeDecision(dec decision.Decision, priceMap map[string]floa
 		if err != nil {
 			return actionRecord, nil, "", err
 		}
+		r.applyProtectiveOrders(symbol, "long", dec.StopLoss, dec.TakeProfit, r.takeProfitLadder(dec, execPrice), r.breakevenAfterTP1(dec))
 		actionRecord.Quantity = qty
 		actionRecord.Price = execPrice
 		actionRecord.Leverage = pos.Leverage
@@ -601,6 +718,9 @@ func (r *Runner) executeDecision(dec decision.Decision, priceMap map[string]floa
 		return actionRecord, []TradeEvent{trade}, "", nil
 
 	case "open_short":
+		if dec.IsLimitOrder() {
+			return r.placeLimitOrder(dec, "short", usedLeverage, basePrice, ts, cycle, actionRecord)
+		}
 		qty := r.determineQuantity(dec, basePrice)
 		if qty <= 0 {
 			return actionRecord, nil, "", fmt.Errorf("invalid qty")
@@ -609,6 +729,7 @@ func (r *Runner) executeDecision(dec decision.Decision, priceMap map[string]floa
 		if err != nil {
 			return actionRecord, nil, "", err
 		}
+		r.applyProtectiveOrders(symbol, "short", dec.StopLoss, dec.TakeProfit, r.takeProfitLadder(dec, execPrice), r.breakevenAfterTP1(dec))
 		actionRecord.Quantity = qty
 		actionRecord.Price = execPrice
 		actionRecord.Leverage = pos.Leverage
@@ -689,6 +810,50 @@ func (r *Runner) executeDecision(dec decision.Decision, priceMap map[string]floa
 		}
 		return actionRecord, []TradeEvent{trade}, "", nil
 
+	case "update_stop_loss":
+		side, ok := r.activePositionSide(symbol)
+		if !ok {
+			return actionRecord, nil, "", fmt.Errorf("no active position for %s", symbol)
+		}
+		if err := validateProtectivePrice(side, basePrice, dec.NewStopLoss, true); err != nil {
+			return actionRecord, nil, "", err
+		}
+		if err := r.account.SetStopLoss(symbol, side, dec.NewStopLoss); err != nil {
+			return actionRecord, nil, "", err
+		}
+		actionRecord.Price = basePrice
+		return actionRecord, nil, fmt.Sprintf("止损已调整: %s %s → %.4f", symbol, side, dec.NewStopLoss), nil
+
+	case "update_take_profit":
+		side, ok := r.activePositionSide(symbol)
+		if !ok {
+			return actionRecord, nil, "", fmt.Errorf("no active position for %s", symbol)
+		}
+		if err := validateProtectivePrice(side, basePrice, dec.NewTakeProfit, false); err != nil {
+			return actionRecord, nil, "", err
+		}
+		if err := r.account.SetTakeProfit(symbol, side, dec.NewTakeProfit); err != nil {
+			return actionRecord, nil, "", err
+		}
+		actionRecord.Price = basePrice
+		return actionRecord, nil, fmt.Sprintf("止盈已调整: %s %s → %.4f", symbol, side, dec.NewTakeProfit), nil
+
+	case "set_trailing_stop":
+		side, ok := r.activePositionSide(symbol)
+		if !ok {
+			return actionRecord, nil, "", fmt.Errorf("no active position for %s", symbol)
+		}
+		if dec.ActivationPrice > 0 {
+			if (side == "long" && dec.ActivationPrice <= basePrice) || (side == "short" && dec.ActivationPrice >= basePrice) {
+				return actionRecord, nil, "", fmt.Errorf("invalid activation price %.4f for %s position (price %.4f)", dec.ActivationPrice, side, basePrice)
+			}
+		}
+		if err := r.account.SetTrailingStop(symbol, side, dec.CallbackRate, dec.TrailingDistance, dec.ActivationPrice, basePrice); err != nil {
+			return actionRecord, nil, "", err
+		}
+		actionRecord.Price = basePrice
+		return actionRecord, nil, fmt.Sprintf("追踪止损已设置: %s %s (回调 %.2f%% / %.4f, 激活价 %.4f)", symbol, side, dec.CallbackRate, dec.TrailingDistance, dec.ActivationPrice), nil
+
 	case "hold", "wait":
 		return actionRecord, nil, fmt.Sprintf("保持仓位: %s", dec.Action), nil
 	default:
@@ -696,6 +861,116 @@ func (r *Runner) executeDecision(dec decision.Decision, priceMap map[string]floa
 	}
 }
 
+// settleFunding 对 (fromTs, toTs] 区间内的每次资金费结算，按持仓名义价值收付资金费。
+func (r *Runner) settleFunding(fromTs, toTs int64, priceMap map[string]float64, cycle int) ([]TradeEvent, []string) {
+	events := make([]TradeEvent, 0)
+	logs := make([]string, 0)
+	for _, symbol := range r.cfg.Symbols {
+		for _, point := range r.feed.FundingEventsBetween(symbol, fromTs, toTs) {
+			mark := point.MarkPrice
+			if mark <= 0 {
+				mark = priceMap[symbol]
+			}
+			payments := r.account.ApplyFunding(symbol, point.Rate, mark)
+			for _, side := range []string{"long", "short"} {
+				amount, ok := payments[side]
+				if !ok {
+					continue
+				}
+				qty := r.remainingPosition(symbol, side)
+				events = append(events, TradeEvent{
+					Timestamp:     point.FundingTime,
+					Symbol:        symbol,
+					Action:        fundingAction,
+					Side:          side,
+					Quantity:      qty,
+					Price:         mark,
+					OrderValue:    qty * mark,
+					RealizedPnL:   amount,
+					Leverage:      r.account.positionLeverage(symbol, side),
+					Cycle:         cycle,
+					PositionAfter: qty,
+					Note:          fmt.Sprintf("funding rate %.6f%%", point.Rate*100),
+				})
+				logs = append(logs, fmt.Sprintf("💸 %s %s 资金费 %+.4f USDT (费率 %.4f%%)", symbol, strings.ToUpper(side), amount, point.Rate*100))
+			}
+		}
+	}
+	return events, logs
+}
+
+// applyProtectiveOrders 开仓后登记 AI 给出的止损/止盈价，未提供（<=0）时保留原有设置。
+// 提供分批止盈档位时按持仓数量拆分各档平仓数量，替代单一止盈价。
+func (r *Runner) applyProtectiveOrders(symbol, side string, stopLoss, takeProfit float64, levels []decision.TakeProfitLevel, breakeven bool) {
+	if stopLoss > 0 {
+		if err := r.account.SetStopLoss(symbol, side, stopLoss); err != nil {
+			log.Printf("failed to set stop loss for %s %s: %v", symbol, side, err)
+		}
+	}
+	if len(levels) > 0 {
+		quantities := decision.TakeProfitLevelQuantities(r.remainingPosition(symbol, side), levels)
+		ladder := make([]TakeProfitLevel, 0, len(levels))
+		for i, level := range levels {
+			ladder = append(ladder, TakeProfitLevel{Price: level.Price, Quantity: quantities[i]})
+		}
+		if err := r.account.SetTakeProfitLadder(symbol, side, ladder, breakeven); err != nil {
+			log.Printf("failed to set take profit ladder for %s %s: %v", symbol, side, err)
+		}
+		return
+	}
+	if takeProfit > 0 {
+		if err := r.account.SetTakeProfit(symbol, side, takeProfit); err != nil {
+			log.Printf("failed to set take profit for %s %s: %v", symbol, side, err)
+		}
+	}
+}
+
+// takeProfitLadder 返回开仓使用的分批止盈档位：优先使用 AI 给出的档位，否则按配置模板从成交价展开。
+func (r *Runner) takeProfitLadder(dec decision.Decision, entryPrice float64) []decision.TakeProfitLevel {
+	if len(dec.TakeProfitLevels) > 0 {
+		return dec.TakeProfitLevels
+	}
+	return decision.BuildTakeProfitLadder(entryPrice, dec.TakeProfit, r.cfg.TakeProfitLadder)
+}
+
+func (r *Runner) breakevenAfterTP1(dec decision.Decision) bool {
+	return dec.BreakevenAfterTP1 || r.cfg.BreakevenAfterTP1
+}
+
+// activePositionSide 返回该币种当前持仓方向（与实盘一致，默认单向持仓）。
+func (r *Runner) activePositionSide(symbol string) (string, bool) {
+	for _, pos := range r.account.Positions() {
+		if pos.Symbol == strings.ToUpper(symbol) && pos.Quantity > epsilon {
+			return pos.Side, true
+		}
+	}
+	return "", false
+}
+
+// validateProtectivePrice 校验新的止损/止盈价相对当前价格的方向是否合理（规则与实盘 AutoTrader 一致）。
+func validateProtectivePrice(side string, price, target float64, isStop bool) error {
+	if target <= 0 {
+		return fmt.Errorf("invalid trigger price %.4f", target)
+	}
+	below := target < price
+	if isStop {
+		if side == "long" && !below {
+			return fmt.Errorf("多单止损必须低于当前价格 (当前: %.4f, 新止损: %.4f)", price, target)
+		}
+		if side == "short" && below {
+			return fmt.Errorf("空单止损必须高于当前价格 (当前: %.4f, 新止损: %.4f)", price, target)
+		}
+		return nil
+	}
+	if side == "long" && below {
+		return fmt.Errorf("多单止盈必须高于当前价格 (当前: %.4f, 新止盈: %.4f)", price, target)
+	}
+	if side == "short" && !below {
+		return fmt.Errorf("空单止盈必须低于当前价格 (当前: %.4f, 新止盈: %.4f)", price, target)
+	}
+	return nil
+}
+
 func (r *Runner) determineQuantity(dec decision.Decision, price float64) float64 {
 	snapshot := r.snapshotState()
 	equity := snapshot.Equity
@@ -836,18 +1111,9 @@ func (r *Runner) updateState(ts int64, equity, unrealized, marginUsed float64, p
 	}
 
 	positions := make(map[string]PositionSnapshot)
-	for _, pos := range r.account.Positions() {
-		key := fmt.Sprintf("%s:%s", pos.Symbol, pos.Side)
-		positions[key] = PositionSnapshot{
-			Symbol:           pos.Symbol,
-			Side:             pos.Side,
-			Quantity:         pos.Quantity,
-			AvgPrice:         pos.EntryPrice,
-			Leverage:         pos.Leverage,
-			LiquidationPrice: pos.LiquidationPrice,
-			MarginUsed:       pos.Margin,
-			OpenTime:         pos.OpenTime,
-		}
+	for _, snap := range r.account.PositionSnapshots() {
+		key := fmt.Sprintf("%s:%s", snap.Symbol, snap.Side)
+		positions[key] = snap
 	}
 
 	r.state.BarTimestamp = ts
@@ -859,6 +1125,7 @@ func (r *Runner) updateState(ts int64, equity, unrealized, marginUsed float64, p
 	r.state.Equity = equity
 	r.state.UnrealizedPnL = unrealized
 	r.state.RealizedPnL = r.account.RealizedPnL()
+	r.state.FundingPnL = r.account.FundingPnL()
 	r.state.Positions = positions
 	r.state.LastUpdate = time.Now().UTC()
 }
@@ -1100,6 +1367,7 @@ func (r *Runner) StatusPayload() StatusPayload {
 		Equity:         snapshot.Equity,
 		UnrealizedPnL:  snapshot.UnrealizedPnL,
 		RealizedPnL:    snapshot.RealizedPnL,
+		FundingPnL:     snapshot.FundingPnL,
 		Note:           snapshot.LiquidationNote,
 		LastError:      r.lastErrorString(),
 		LastUpdatedIso: snapshot.LastUpdate.UTC().Format(time.RFC3339),
@@ -1238,6 +1506,7 @@ func (r *Runner) buildCheckpointFromState(state BacktestState) *Checkpoint {
 		Equity:          state.Equity,
 		UnrealizedPnL:   state.UnrealizedPnL,
 		RealizedPnL:     state.RealizedPnL,
+		FundingPnL:      state.FundingPnL,
 		Positions:       r.snapshotForCheckpoint(state),
 		DecisionCycle:   state.DecisionCycle,
 		Liquidated:      state.Liquidated,
@@ -1246,6 +1515,8 @@ func (r *Runner) buildCheckpointFromState(state BacktestState) *Checkpoint {
 		MinEquity:       state.MinEquity,
 		MaxDrawdownPct:  state.MaxDrawdownPct,
 		AICacheRef:      r.cachePath,
+		PendingOrders:   r.snapshotPendingOrders(),
+		AIUsage:         state.AIUsage,
 	}
 }
 
@@ -1280,7 +1551,8 @@ func (r *Runner) applyCheckpoint(ckpt *Checkpoint) error {
 	if ckpt == nil {
 		return fmt.Errorf("checkpoint is nil")
 	}
-	r.account.RestoreFromSnapshots(ckpt.Cash, ckpt.RealizedPnL, ckpt.Positions)
+	r.account.RestoreFromSnapshots(ckpt.Cash, ckpt.RealizedPnL, ckpt.FundingPnL, ckpt.Positions)
+	r.restorePendingOrders(ckpt.PendingOrders)
 	r.decisionLogger.SetCycleNumber(ckpt.DecisionCycle)
 	r.stateMu.Lock()
 	defer r.stateMu.Unlock()
@@ -1290,6 +1562,7 @@ func (r *Runner) applyCheckpoint(ckpt *Checkpoint) error {
 	r.state.Equity = ckpt.Equity
 	r.state.UnrealizedPnL = ckpt.UnrealizedPnL
 	r.state.RealizedPnL = ckpt.RealizedPnL
+	r.state.FundingPnL = ckpt.FundingPnL
 	r.state.DecisionCycle = ckpt.DecisionCycle
 	r.state.Liquidated = ckpt.Liquidated
 	r.state.LiquidationNote = ckpt.LiquidationNote
@@ -1297,6 +1570,7 @@ func (r *Runner) applyCheckpoint(ckpt *Checkpoint) error {
 	r.state.MinEquity = ckpt.MinEquity
 	r.state.MaxDrawdownPct = ckpt.MaxDrawdownPct
 	r.state.Positions = snapshotsToMap(ckpt.Positions)
+	r.state.AIUsage = ckpt.AIUsage
 	r.state.LastUpdate = time.Now().UTC()
 	r.lastCheckpoint = time.Now()
 	return nil
@@ -1320,10 +1594,12 @@ func sortDecisionsByPriority(decisions []decision.Decision) []decision.Decision
 		switch action {
 		case "close_long", "close_short":
 			return 1
-		case "open_long", "open_short":
+		case "update_stop_loss", "update_take_profit", "set_trailing_stop":
 			return 2
-		case "hold", "wait":
+		case "open_long", "open_short":
 			return 3
+		case "hold", "wait":
+			return 4
 		default:
 			return 99
 		}
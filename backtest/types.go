@@ -1,6 +1,10 @@
 package backtest
 
-import "time"
+import (
+	"time"
+
+	"nofx/mcp"
+)
 
 // RunState 表示回测运行当前状态。
 type RunState string
@@ -25,6 +29,22 @@ type PositionSnapshot struct {
 	LiquidationPrice float64 `json:"liquidation_price"`
 	MarginUsed       float64 `json:"margin_used"`
 	OpenTime         int64   `json:"open_time"`
+	StopLoss         float64 `json:"stop_loss,omitempty"`
+	TakeProfit       float64 `json:"take_profit,omitempty"`
+
+	TrailingRate       float64 `json:"trailing_rate,omitempty"`
+	TrailingDistance   float64 `json:"trailing_distance,omitempty"`
+	TrailingActivation float64 `json:"trailing_activation,omitempty"`
+	TrailingExtreme    float64 `json:"trailing_extreme,omitempty"`
+
+	TakeProfitLevels  []TakeProfitLevel `json:"take_profit_levels,omitempty"`
+	BreakevenAfterTP1 bool              `json:"breakeven_after_tp1,omitempty"`
+}
+
+// TakeProfitLevel 分批止盈中的一档：触发价与该档平仓数量。
+type TakeProfitLevel struct {
+	Price    float64 `json:"price"`
+	Quantity float64 `json:"quantity"`
 }
 
 // BacktestState 表示执行过程中的实时状态（内存态）。
@@ -37,6 +57,7 @@ type BacktestState struct {
 	Equity          float64
 	UnrealizedPnL   float64
 	RealizedPnL     float64
+	FundingPnL      float64
 	MaxEquity       float64
 	MinEquity       float64
 	MaxDrawdownPct  float64
@@ -44,6 +65,7 @@ type BacktestState struct {
 	LastUpdate      time.Time
 	Liquidated      bool
 	LiquidationNote string
+	AIUsage         mcp.Usage // 本次回测所有 AI 调用的累计用量与费用（缓存命中不计）
 }
 
 // EquityPoint 表示资金曲线中的单个节点。
@@ -86,10 +108,13 @@ type Metrics struct {
 	Trades         int                      `json:"trades"`
 	AvgWin         float64                  `json:"avg_win"`
 	AvgLoss        float64                  `json:"avg_loss"`
+	FundingPnL     float64                  `json:"funding_pnl"`
+	FundingEvents  int                      `json:"funding_events"`
 	BestSymbol     string                   `json:"best_symbol"`
 	WorstSymbol    string                   `json:"worst_symbol"`
 	SymbolStats    map[string]SymbolMetrics `json:"symbol_stats"`
 	Liquidated     bool                     `json:"liquidated"`
+	AIUsage        mcp.Usage                `json:"ai_usage"` // AI 调用的 token 用量与费用
 }
 
 // SymbolMetrics 记录单个标的的表现。
@@ -113,6 +138,7 @@ type Checkpoint struct {
 	MaxDrawdownPct  float64                   `json:"max_drawdown_pct"`
 	UnrealizedPnL   float64                   `json:"unrealized_pnl"`
 	RealizedPnL     float64                   `json:"realized_pnl"`
+	FundingPnL      float64                   `json:"funding_pnl,omitempty"`
 	Positions       []PositionSnapshot        `json:"positions"`
 	DecisionCycle   int                       `json:"decision_cycle"`
 	IndicatorsState map[string]map[string]any `json:"indicators_state,omitempty"`
@@ -120,6 +146,8 @@ type Checkpoint struct {
 	AICacheRef      string                    `json:"ai_cache_ref,omitempty"`
 	Liquidated      bool                      `json:"liquidated"`
 	LiquidationNote string                    `json:"liquidation_note,omitempty"`
+	PendingOrders   []PendingLimitOrder       `json:"pending_orders,omitempty"`
+	AIUsage         mcp.Usage                 `json:"ai_usage"`
 }
 
 // RunMetadata 记录 run.json 所需摘要。
@@ -158,6 +186,7 @@ type StatusPayload struct {
 	Equity         float64  `json:"equity"`
 	UnrealizedPnL  float64  `json:"unrealized_pnl"`
 	RealizedPnL    float64  `json:"realized_pnl"`
+	FundingPnL     float64  `json:"funding_pnl"`
 	Note           string   `json:"note,omitempty"`
 	LastError      string   `json:"last_error,omitempty"`
 	LastUpdatedIso string   `json:"last_updated_iso"`
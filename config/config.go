@@ -29,19 +29,27 @@ type TelegramConfig struct {
 
 // Config 总配置
 type Config struct {
-	BetaMode           bool           `json:"beta_mode"`
-	APIServerPort      int            `json:"api_server_port"`
-	UseDefaultCoins    bool           `json:"use_default_coins"`
-	DefaultCoins       []string       `json:"default_coins"`
-	CoinPoolAPIURL     string         `json:"coin_pool_api_url"`
-	OITopAPIURL        string         `json:"oi_top_api_url"`
-	MaxDailyLoss       float64        `json:"max_daily_loss"`
-	MaxDrawdown        float64        `json:"max_drawdown"`
-	StopTradingMinutes int            `json:"stop_trading_minutes"`
-	Leverage           LeverageConfig `json:"leverage"`
-	JWTSecret          string         `json:"jwt_secret"`
-	DataKLineTime      string         `json:"data_k_line_time"`
-	Log                *LogConfig     `json:"log"` // 日志配置
+	BetaMode           bool            `json:"beta_mode"`
+	APIServerPort      int             `json:"api_server_port"`
+	UseDefaultCoins    bool            `json:"use_default_coins"`
+	DefaultCoins       []string        `json:"default_coins"`
+	CoinPoolAPIURL     string          `json:"coin_pool_api_url"`
+	OITopAPIURL        string          `json:"oi_top_api_url"`
+	MaxDailyLoss       float64         `json:"max_daily_loss"`
+	MaxDrawdown        float64         `json:"max_drawdown"`
+	StopTradingMinutes int             `json:"stop_trading_minutes"`
+	RiskClosePositions bool            `json:"risk_close_positions"` // 触发风控熔断时平掉所有持仓
+	DailyResetTimezone string          `json:"daily_reset_timezone"` // 日盈亏重置时区（IANA 名称），默认 UTC
+	AIMonthlySpendCap  float64         `json:"ai_monthly_spend_cap"` // 每个交易员每月AI调用费用上限（美元），0 表示不限制
+	AIModelPrices      json.RawMessage `json:"ai_model_prices"`      // AI模型价格表（美元/百万token），如 {"deepseek-chat":{"input":0.28,"output":0.42}}
+	AIRateLimits       json.RawMessage `json:"ai_rate_limits"`       // AI请求限流（按提供商），如 {"deepseek":{"requests_per_minute":30,"max_concurrent":2}}
+	MarketDataFallback string          `json:"market_data_fallback"` // 交易所专用行情失败时的备用数据源：binance（默认）| none
+	MaxSlippagePct     float64         `json:"max_slippage_pct"`     // 市价开仓的最大预计滑点（%），0 表示不检查
+	LiquidityAction    string          `json:"liquidity_action"`     // 预计滑点超限时的处理：shrink（默认）| reject
+	Leverage           LeverageConfig  `json:"leverage"`
+	JWTSecret          string          `json:"jwt_secret"`
+	DataKLineTime      string          `json:"data_k_line_time"`
+	Log                *LogConfig      `json:"log"` // 日志配置
 }
 
 // LoadConfig 从文件加载配置
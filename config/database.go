@@ -30,6 +30,7 @@ type DatabaseInterface interface {
 	UpdateAIModel(userID, id string, enabled bool, apiKey, customAPIURL, customModelName string) error
 	GetExchanges(userID string) ([]*ExchangeConfig, error)
 	UpdateExchange(userID, id string, enabled bool, apiKey, secretKey string, testnet bool, hyperliquidWalletAddr, asterUser, asterSigner, asterPrivateKey, lighterWalletAddr, lighterPrivateKey string) error
+	UpdateExchangePassphrase(userID, id, passphrase string) error
 	CreateAIModel(userID, id, name, provider string, enabled bool, apiKey, customAPIURL string) error
 	CreateExchange(userID, id, name, typ string, enabled bool, apiKey, secretKey string, testnet bool, hyperliquidWalletAddr, asterUser, asterSigner, asterPrivateKey string) error
 	CreateTrader(trader *TraderRecord) error
@@ -152,6 +153,8 @@ func (d *Database) createTables() error {
 			lighter_wallet_addr TEXT DEFAULT '',
 			lighter_private_key TEXT DEFAULT '',
 			lighter_api_key_private_key TEXT DEFAULT '',
+			-- OKX、Bitget 等交易所的 API Passphrase
+			passphrase TEXT DEFAULT '',
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
@@ -309,6 +312,50 @@ func (d *Database) createTables() error {
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
 		)`,
 
+		// 模拟盘账户状态（每个交易员一行，state 为 JSON）
+		`CREATE TABLE IF NOT EXISTS paper_accounts (
+			trader_id TEXT PRIMARY KEY,
+			user_id TEXT NOT NULL DEFAULT 'default',
+			state TEXT NOT NULL DEFAULT '',
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+
+		// 追踪止损状态（每个交易员一行，state 为 JSON，重启后恢复）
+		`CREATE TABLE IF NOT EXISTS trailing_stops (
+			trader_id TEXT PRIMARY KEY,
+			user_id TEXT NOT NULL DEFAULT 'default',
+			state TEXT NOT NULL DEFAULT '',
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+
+		// 未成交的限价开仓单（每个交易员一行，state 为 JSON，重启后继续跟踪成交）
+		`CREATE TABLE IF NOT EXISTS pending_orders (
+			trader_id TEXT PRIMARY KEY,
+			user_id TEXT NOT NULL DEFAULT 'default',
+			state TEXT NOT NULL DEFAULT '',
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+
+		// 分批止盈状态（每个交易员一行，state 为 JSON，重启后继续识别档位成交）
+		`CREATE TABLE IF NOT EXISTS take_profit_ladders (
+			trader_id TEXT PRIMARY KEY,
+			user_id TEXT NOT NULL DEFAULT 'default',
+			state TEXT NOT NULL DEFAULT '',
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+
+		// 提示词版本（模板和自定义提示词的每个历史版本，按内容哈希去重）
+		`CREATE TABLE IF NOT EXISTS prompt_revisions (
+			hash TEXT NOT NULL,
+			kind TEXT NOT NULL,
+			name TEXT NOT NULL,
+			user_id TEXT NOT NULL DEFAULT '',
+			content TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (kind, name, hash)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_prompt_revisions_hash ON prompt_revisions(hash)`,
+
 		// 触发器：自动更新 updated_at
 		`CREATE TRIGGER IF NOT EXISTS update_users_updated_at
 			AFTER UPDATE ON users
@@ -362,6 +409,7 @@ func (d *Database) createTables() error {
 		`ALTER TABLE exchanges ADD COLUMN lighter_wallet_addr TEXT DEFAULT ''`,
 		`ALTER TABLE exchanges ADD COLUMN lighter_private_key TEXT DEFAULT ''`,
 		`ALTER TABLE exchanges ADD COLUMN lighter_api_key_private_key TEXT DEFAULT ''`,
+		`ALTER TABLE exchanges ADD COLUMN passphrase TEXT DEFAULT ''`,
 		`ALTER TABLE traders ADD COLUMN custom_prompt TEXT DEFAULT ''`,
 		`ALTER TABLE traders ADD COLUMN override_base_prompt BOOLEAN DEFAULT 0`,
 		`ALTER TABLE traders ADD COLUMN is_cross_margin BOOLEAN DEFAULT 1`,             // 默认为全仓模式
@@ -373,6 +421,16 @@ func (d *Database) createTables() error {
 		`ALTER TABLE traders ADD COLUMN use_coin_pool BOOLEAN DEFAULT 0`,               // 是否使用COIN POOL信号源
 		`ALTER TABLE traders ADD COLUMN use_oi_top BOOLEAN DEFAULT 0`,                  // 是否使用OI TOP信号源
 		`ALTER TABLE traders ADD COLUMN system_prompt_template TEXT DEFAULT 'default'`, // 系统提示词模板名称
+		`ALTER TABLE traders ADD COLUMN pending_order_max_cycles INTEGER DEFAULT 3`,    // 限价单最多挂单周期数
+		`ALTER TABLE traders ADD COLUMN take_profit_ladder TEXT DEFAULT ''`,            // 分批止盈模板（JSON）
+		`ALTER TABLE traders ADD COLUMN breakeven_after_tp1 BOOLEAN DEFAULT 0`,         // 第一档止盈后止损移到开仓价
+		`ALTER TABLE traders ADD COLUMN decision_mode TEXT DEFAULT 'text'`,             // 决策输出方式（text/tool_call）
+		`ALTER TABLE traders ADD COLUMN ensemble_model_ids TEXT DEFAULT ''`,            // 集成决策的其他模型ID（逗号分隔）
+		`ALTER TABLE traders ADD COLUMN ensemble_vote TEXT DEFAULT ''`,                 // 集成决策投票方式
+		`ALTER TABLE traders ADD COLUMN validation_rules TEXT DEFAULT ''`,              // 决策校验规则链配置（JSON）
+		`ALTER TABLE traders ADD COLUMN prompt_experiment TEXT DEFAULT ''`,             // 提示词 A/B 实验配置（JSON）
+		`ALTER TABLE traders ADD COLUMN fallback_model_ids TEXT DEFAULT ''`,            // 故障转移的备用模型ID（按顺序，逗号分隔）
+		`ALTER TABLE traders ADD COLUMN ai_safe_mode BOOLEAN DEFAULT 0`,                // 所有AI模型不可用时进入安全模式
 		`ALTER TABLE ai_models ADD COLUMN custom_api_url TEXT DEFAULT ''`,              // 自定义API地址
 		`ALTER TABLE ai_models ADD COLUMN custom_model_name TEXT DEFAULT ''`,           // 自定义模型名称
 	}
@@ -471,6 +529,8 @@ func (d *Database) initDefaultData() error {
 	}{
 		{"deepseek", "DeepSeek", "deepseek"},
 		{"qwen", "Qwen", "qwen"},
+		{"anthropic", "Claude", "anthropic"},
+		{"gemini", "Gemini", "gemini"},
 	}
 
 	for _, model := range aiModels {
@@ -493,6 +553,9 @@ func (d *Database) initDefaultData() error {
 		{"aster", "Aster DEX", "aster"},
 		{"lighter", "LIGHTER DEX", "lighter"},
 		{"gate", "Gate.io Futures", "cex"},
+		{"okx", "OKX Futures", "cex"},
+		{"bitget", "Bitget Futures", "cex"},
+		{"paper", "Paper Trading", "paper"},
 	}
 
 	for _, exchange := range exchanges {
@@ -511,9 +574,17 @@ func (d *Database) initDefaultData() error {
 		"api_server_port":      "8080",                                                                                // 默认API端口
 		"use_default_coins":    "true",                                                                                // 默认使用内置币种列表
 		"default_coins":        `["BTCUSDT","ETHUSDT","SOLUSDT","BNBUSDT","XRPUSDT","DOGEUSDT","ADAUSDT","HYPEUSDT"]`, // 默认币种列表（JSON格式）
-		"max_daily_loss":       "10.0",                                                                                // 最大日损失百分比
-		"max_drawdown":         "20.0",                                                                                // 最大回撤百分比
+		"max_daily_loss":       "10.0",                                                                                // 最大日损失百分比，超过后触发风控熔断（0 表示不限制）
+		"max_drawdown":         "20.0",                                                                                // 最大回撤百分比，超过后触发风控熔断（0 表示不限制）
 		"stop_trading_minutes": "60",                                                                                  // 停止交易时间（分钟）
+		"risk_close_positions": "false",                                                                               // 触发风控熔断时是否平掉所有持仓
+		"daily_reset_timezone": "UTC",                                                                                 // 日盈亏重置时区（IANA 名称）
+		"ai_monthly_spend_cap": "0",                                                                                   // 每个交易员每月AI调用费用上限（美元），0 表示不限制
+		"ai_model_prices":      "",                                                                                    // AI模型价格表（JSON，美元/百万token），为空时使用内置参考价
+		"market_data_fallback": "binance",                                                                             // 交易所专用行情（Hyperliquid/Bybit）失败时的备用数据源：binance | none
+		"max_slippage_pct":     "0",                                                                                   // 市价开仓的最大预计滑点（%，按订单簿估算），0 表示不检查
+		"liquidity_action":     "shrink",                                                                              // 预计滑点超限时的处理：shrink（缩小仓位）| reject（拒绝开仓）
+		"ai_rate_limits":       "",                                                                                    // AI请求限流（JSON，按提供商配置每分钟请求数/token数和最大并发），为空时不限流
 		"btc_eth_leverage":     "5",                                                                                   // BTC/ETH杠杆倍数
 		"altcoin_leverage":     "5",                                                                                   // 山寨币杠杆倍数
 		"jwt_secret":           "",                                                                                    // JWT密钥，默认为空，由config.json或系统生成
@@ -570,6 +641,7 @@ func (d *Database) migrateExchangesTable() error {
 			lighter_wallet_addr TEXT DEFAULT '',
 			lighter_private_key TEXT DEFAULT '',
 			lighter_api_key_private_key TEXT DEFAULT '',
+			passphrase TEXT DEFAULT '',
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			PRIMARY KEY (id, user_id),
@@ -657,6 +729,16 @@ func (d *Database) migrateTradersTable() error {
 			override_base_prompt BOOLEAN DEFAULT 0,
 			system_prompt_template TEXT DEFAULT 'default',
 			is_cross_margin BOOLEAN DEFAULT 1,
+			pending_order_max_cycles INTEGER DEFAULT 3,
+			take_profit_ladder TEXT DEFAULT '',
+			breakeven_after_tp1 BOOLEAN DEFAULT 0,
+			decision_mode TEXT DEFAULT 'text',
+			ensemble_model_ids TEXT DEFAULT '',
+			ensemble_vote TEXT DEFAULT '',
+			validation_rules TEXT DEFAULT '',
+			prompt_experiment TEXT DEFAULT '',
+			fallback_model_ids TEXT DEFAULT '',
+			ai_safe_mode BOOLEAN DEFAULT 0,
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
@@ -671,14 +753,19 @@ func (d *Database) migrateTradersTable() error {
 		INSERT INTO traders_new (id, user_id, name, ai_model_id, exchange_id, initial_balance, 
 			scan_interval_minutes, is_running, btc_eth_leverage, altcoin_leverage, trading_symbols,
 			use_coin_pool, use_oi_top, custom_prompt, override_base_prompt, system_prompt_template,
-			is_cross_margin, created_at, updated_at)
+			is_cross_margin, pending_order_max_cycles, take_profit_ladder, breakeven_after_tp1,
+			decision_mode, ensemble_model_ids, ensemble_vote, validation_rules, prompt_experiment,
+			fallback_model_ids, ai_safe_mode, created_at, updated_at)
 		SELECT id, user_id, name, ai_model_id, exchange_id, initial_balance, 
 			scan_interval_minutes, is_running, 
 			COALESCE(btc_eth_leverage, 5), COALESCE(altcoin_leverage, 5), 
 			COALESCE(trading_symbols, ''), COALESCE(use_coin_pool, 0), COALESCE(use_oi_top, 0),
 			COALESCE(custom_prompt, ''), COALESCE(override_base_prompt, 0), 
 			COALESCE(system_prompt_template, 'default'), COALESCE(is_cross_margin, 1),
-			created_at, updated_at
+			COALESCE(pending_order_max_cycles, 3), COALESCE(take_profit_ladder, ''), COALESCE(breakeven_after_tp1, 0),
+			COALESCE(decision_mode, 'text'), COALESCE(ensemble_model_ids, ''), COALESCE(ensemble_vote, ''),
+			COALESCE(validation_rules, ''), COALESCE(prompt_experiment, ''),
+			COALESCE(fallback_model_ids, ''), COALESCE(ai_safe_mode, 0), created_at, updated_at
 		FROM traders
 	`)
 	if err != nil {
@@ -749,31 +836,42 @@ type ExchangeConfig struct {
 	LighterWalletAddr       string    `json:"lighterWalletAddr"`       // Ethereum 钱包地址 (L1)
 	LighterPrivateKey       string    `json:"lighterPrivateKey"`       // L1私钥（用于识别账户）
 	LighterAPIKeyPrivateKey string    `json:"lighterAPIKeyPrivateKey"` // API Key私钥（40字节，用于签名交易）
+	Passphrase              string    `json:"passphrase"`              // OKX、Bitget 等交易所创建 API Key 时设置的 Passphrase
 	CreatedAt               time.Time `json:"created_at"`
 	UpdatedAt               time.Time `json:"updated_at"`
 }
 
 // TraderRecord 交易员配置（数据库实体）
 type TraderRecord struct {
-	ID                   string    `json:"id"`
-	UserID               string    `json:"user_id"`
-	Name                 string    `json:"name"`
-	AIModelID            string    `json:"ai_model_id"`
-	ExchangeID           string    `json:"exchange_id"`
-	InitialBalance       float64   `json:"initial_balance"`
-	ScanIntervalMinutes  int       `json:"scan_interval_minutes"`
-	IsRunning            bool      `json:"is_running"`
-	BTCETHLeverage       int       `json:"btc_eth_leverage"`       // BTC/ETH杠杆倍数
-	AltcoinLeverage      int       `json:"altcoin_leverage"`       // 山寨币杠杆倍数
-	TradingSymbols       string    `json:"trading_symbols"`        // 交易币种，逗号分隔
-	UseCoinPool          bool      `json:"use_coin_pool"`          // 是否使用COIN POOL信号源
-	UseOITop             bool      `json:"use_oi_top"`             // 是否使用OI TOP信号源
-	CustomPrompt         string    `json:"custom_prompt"`          // 自定义交易策略prompt
-	OverrideBasePrompt   bool      `json:"override_base_prompt"`   // 是否覆盖基础prompt
-	SystemPromptTemplate string    `json:"system_prompt_template"` // 系统提示词模板名称
-	IsCrossMargin        bool      `json:"is_cross_margin"`        // 是否为全仓模式（true=全仓，false=逐仓）
-	CreatedAt            time.Time `json:"created_at"`
-	UpdatedAt            time.Time `json:"updated_at"`
+	ID                    string    `json:"id"`
+	UserID                string    `json:"user_id"`
+	Name                  string    `json:"name"`
+	AIModelID             string    `json:"ai_model_id"`
+	ExchangeID            string    `json:"exchange_id"`
+	InitialBalance        float64   `json:"initial_balance"`
+	ScanIntervalMinutes   int       `json:"scan_interval_minutes"`
+	IsRunning             bool      `json:"is_running"`
+	BTCETHLeverage        int       `json:"btc_eth_leverage"`         // BTC/ETH杠杆倍数
+	AltcoinLeverage       int       `json:"altcoin_leverage"`         // 山寨币杠杆倍数
+	TradingSymbols        string    `json:"trading_symbols"`          // 交易币种，逗号分隔
+	UseCoinPool           bool      `json:"use_coin_pool"`            // 是否使用COIN POOL信号源
+	UseOITop              bool      `json:"use_oi_top"`               // 是否使用OI TOP信号源
+	CustomPrompt          string    `json:"custom_prompt"`            // 自定义交易策略prompt
+	OverrideBasePrompt    bool      `json:"override_base_prompt"`     // 是否覆盖基础prompt
+	SystemPromptTemplate  string    `json:"system_prompt_template"`   // 系统提示词模板名称
+	IsCrossMargin         bool      `json:"is_cross_margin"`          // 是否为全仓模式（true=全仓，false=逐仓）
+	PendingOrderMaxCycles int       `json:"pending_order_max_cycles"` // 限价单最多挂单的决策周期数，超过后自动撤单
+	TakeProfitLadder      string    `json:"take_profit_ladder"`       // 分批止盈模板（JSON 数组，空表示不启用）
+	BreakevenAfterTP1     bool      `json:"breakeven_after_tp1"`      // 第一档止盈成交后把止损移到开仓价
+	DecisionMode          string    `json:"decision_mode"`            // 决策输出方式：text（解析文本JSON）| tool_call（函数调用）| agent（多轮工具调用）
+	EnsembleModelIDs      string    `json:"ensemble_model_ids"`       // 参与集成决策的其他AI模型ID，逗号分隔（空表示不启用）
+	EnsembleVote          string    `json:"ensemble_vote"`            // 集成决策投票方式：majority | confidence_weighted | unanimous
+	ValidationRules       string    `json:"validation_rules"`         // 决策校验规则链配置（JSON 对象，空表示默认规则）
+	PromptExperiment      string    `json:"prompt_experiment"`        // 提示词 A/B 实验配置（JSON 对象，空表示不开启）
+	FallbackModelIDs      string    `json:"fallback_model_ids"`       // 故障转移的备用AI模型ID，按尝试顺序逗号分隔（空表示不启用）
+	AISafeMode            bool      `json:"ai_safe_mode"`             // 所有AI模型不可用时进入安全模式，只管理现有持仓
+	CreatedAt             time.Time `json:"created_at"`
+	UpdatedAt             time.Time `json:"updated_at"`
 }
 
 // UserSignalSource 用户信号源配置
@@ -1103,7 +1201,7 @@ func (d *Database) UpdateAIModel(userID, id string, enabled bool, apiKey, custom
 
 	// 没有找到任何现有配置，创建新的
 	// 推断 provider（从 id 中提取，或者直接使用 id）
-	if provider == id && (provider == "deepseek" || provider == "qwen" || provider == "openai" || provider == "doubao") {
+	if provider == id && (provider == "deepseek" || provider == "qwen" || provider == "anthropic" || provider == "gemini" || provider == "openai" || provider == "doubao") {
 		// id 本身就是 provider
 		provider = id
 	} else {
@@ -1127,6 +1225,10 @@ func (d *Database) UpdateAIModel(userID, id string, enabled bool, apiKey, custom
 			name = "DeepSeek AI"
 		} else if provider == "qwen" {
 			name = "Qwen AI"
+		} else if provider == "anthropic" {
+			name = "Claude"
+		} else if provider == "gemini" {
+			name = "Gemini"
 		} else if provider == "openai" {
 			name = "ChatGPT"
 		} else if provider == "doubao" {
@@ -1165,6 +1267,7 @@ func (d *Database) GetExchanges(userID string) ([]*ExchangeConfig, error) {
 		       COALESCE(lighter_wallet_addr, '') as lighter_wallet_addr,
 		       COALESCE(lighter_private_key, '') as lighter_private_key,
 		       COALESCE(lighter_api_key_private_key, '') as lighter_api_key_private_key,
+		       COALESCE(passphrase, '') as passphrase,
 		       created_at, updated_at
 		FROM exchanges WHERE user_id = ? ORDER BY id
 	`, userID)
@@ -1184,7 +1287,7 @@ func (d *Database) GetExchanges(userID string) ([]*ExchangeConfig, error) {
 			&exchange.HyperliquidWalletAddr, &exchange.AsterUser,
 			&exchange.AsterSigner, &exchange.AsterPrivateKey,
 			&exchange.LighterWalletAddr, &exchange.LighterPrivateKey,
-			&exchange.LighterAPIKeyPrivateKey,
+			&exchange.LighterAPIKeyPrivateKey, &exchange.Passphrase,
 			&createdAt, &updatedAt,
 		)
 		if err != nil {
@@ -1201,6 +1304,7 @@ func (d *Database) GetExchanges(userID string) ([]*ExchangeConfig, error) {
 		exchange.AsterPrivateKey = d.decryptSensitiveData(exchange.AsterPrivateKey)
 		exchange.LighterPrivateKey = d.decryptSensitiveData(exchange.LighterPrivateKey)
 		exchange.LighterAPIKeyPrivateKey = d.decryptSensitiveData(exchange.LighterAPIKeyPrivateKey)
+		exchange.Passphrase = d.decryptSensitiveData(exchange.Passphrase)
 
 		exchanges = append(exchanges, &exchange)
 	}
@@ -1297,6 +1401,12 @@ func (d *Database) UpdateExchange(userID, id string, enabled bool, apiKey, secre
 		} else if id == "lighter" {
 			name = "LIGHTER DEX"
 			typ = "dex"
+		} else if id == "okx" {
+			name = "OKX Futures"
+			typ = "cex"
+		} else if id == "bitget" {
+			name = "Bitget Futures"
+			typ = "cex"
 		} else {
 			name = id + " Exchange"
 			typ = "cex"
@@ -1330,6 +1440,19 @@ func (d *Database) UpdateExchange(userID, id string, enabled bool, apiKey, secre
 	return nil
 }
 
+// UpdateExchangePassphrase 更新交易所 API Passphrase（OKX、Bitget 等交易所签名需要）
+// 🔒 与其他敏感字段一致：空值不会覆盖现有数据
+func (d *Database) UpdateExchangePassphrase(userID, id, passphrase string) error {
+	if passphrase == "" {
+		return nil
+	}
+	_, err := d.db.Exec(`
+		UPDATE exchanges SET passphrase = ?, updated_at = datetime('now')
+		WHERE id = ? AND user_id = ?
+	`, d.encryptSensitiveData(passphrase), id, userID)
+	return err
+}
+
 // CreateAIModel 创建AI模型配置
 func (d *Database) CreateAIModel(userID, id, name, provider string, enabled bool, apiKey, customAPIURL string) error {
 	_, err := d.db.Exec(`
@@ -1356,9 +1479,9 @@ func (d *Database) CreateExchange(userID, id, name, typ string, enabled bool, ap
 // CreateTrader 创建交易员
 func (d *Database) CreateTrader(trader *TraderRecord) error {
 	_, err := d.db.Exec(`
-		INSERT INTO traders (id, user_id, name, ai_model_id, exchange_id, initial_balance, scan_interval_minutes, is_running, btc_eth_leverage, altcoin_leverage, trading_symbols, use_coin_pool, use_oi_top, custom_prompt, override_base_prompt, system_prompt_template, is_cross_margin)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`, trader.ID, trader.UserID, trader.Name, trader.AIModelID, trader.ExchangeID, trader.InitialBalance, trader.ScanIntervalMinutes, trader.IsRunning, trader.BTCETHLeverage, trader.AltcoinLeverage, trader.TradingSymbols, trader.UseCoinPool, trader.UseOITop, trader.CustomPrompt, trader.OverrideBasePrompt, trader.SystemPromptTemplate, trader.IsCrossMargin)
+		INSERT INTO traders (id, user_id, name, ai_model_id, exchange_id, initial_balance, scan_interval_minutes, is_running, btc_eth_leverage, altcoin_leverage, trading_symbols, use_coin_pool, use_oi_top, custom_prompt, override_base_prompt, system_prompt_template, is_cross_margin, pending_order_max_cycles, take_profit_ladder, breakeven_after_tp1, decision_mode, ensemble_model_ids, ensemble_vote, validation_rules, prompt_experiment, fallback_model_ids, ai_safe_mode)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, trader.ID, trader.UserID, trader.Name, trader.AIModelID, trader.ExchangeID, trader.InitialBalance, trader.ScanIntervalMinutes, trader.IsRunning, trader.BTCETHLeverage, trader.AltcoinLeverage, trader.TradingSymbols, trader.UseCoinPool, trader.UseOITop, trader.CustomPrompt, trader.OverrideBasePrompt, trader.SystemPromptTemplate, trader.IsCrossMargin, trader.PendingOrderMaxCycles, trader.TakeProfitLadder, trader.BreakevenAfterTP1, trader.DecisionMode, trader.EnsembleModelIDs, trader.EnsembleVote, trader.ValidationRules, trader.PromptExperiment, trader.FallbackModelIDs, trader.AISafeMode)
 	return err
 }
 
@@ -1371,7 +1494,16 @@ func (d *Database) GetTraders(userID string) ([]*TraderRecord, error) {
 		       COALESCE(use_coin_pool, 0) as use_coin_pool, COALESCE(use_oi_top, 0) as use_oi_top,
 		       COALESCE(custom_prompt, '') as custom_prompt, COALESCE(override_base_prompt, 0) as override_base_prompt,
 		       COALESCE(system_prompt_template, 'default') as system_prompt_template,
-		       COALESCE(is_cross_margin, 1) as is_cross_margin, created_at, updated_at
+		       COALESCE(is_cross_margin, 1) as is_cross_margin,
+		       COALESCE(pending_order_max_cycles, 3) as pending_order_max_cycles,
+		       COALESCE(take_profit_ladder, '') as take_profit_ladder,
+		       COALESCE(breakeven_after_tp1, 0) as breakeven_after_tp1,
+		       COALESCE(decision_mode, 'text') as decision_mode,
+		       COALESCE(ensemble_model_ids, '') as ensemble_model_ids, COALESCE(ensemble_vote, '') as ensemble_vote,
+		       COALESCE(validation_rules, '') as validation_rules,
+		       COALESCE(prompt_experiment, '') as prompt_experiment,
+		       COALESCE(fallback_model_ids, '') as fallback_model_ids, COALESCE(ai_safe_mode, 0) as ai_safe_mode,
+		       created_at, updated_at
 		FROM traders WHERE user_id = ? ORDER BY created_at DESC
 	`, userID)
 	if err != nil {
@@ -1389,7 +1521,10 @@ func (d *Database) GetTraders(userID string) ([]*TraderRecord, error) {
 			&trader.BTCETHLeverage, &trader.AltcoinLeverage, &trader.TradingSymbols,
 			&trader.UseCoinPool, &trader.UseOITop,
 			&trader.CustomPrompt, &trader.OverrideBasePrompt, &trader.SystemPromptTemplate,
-			&trader.IsCrossMargin,
+			&trader.IsCrossMargin, &trader.PendingOrderMaxCycles,
+			&trader.TakeProfitLadder, &trader.BreakevenAfterTP1, &trader.DecisionMode,
+			&trader.EnsembleModelIDs, &trader.EnsembleVote, &trader.ValidationRules,
+			&trader.PromptExperiment, &trader.FallbackModelIDs, &trader.AISafeMode,
 			&createdAt, &updatedAt,
 		)
 		if err != nil {
@@ -1417,12 +1552,20 @@ func (d *Database) UpdateTrader(trader *TraderRecord) error {
 			name = ?, ai_model_id = ?, exchange_id = ?,
 			scan_interval_minutes = ?, btc_eth_leverage = ?, altcoin_leverage = ?,
 			trading_symbols = ?, custom_prompt = ?, override_base_prompt = ?,
-			system_prompt_template = ?, is_cross_margin = ?, updated_at = CURRENT_TIMESTAMP
+			system_prompt_template = ?, is_cross_margin = ?, pending_order_max_cycles = ?,
+			take_profit_ladder = ?, breakeven_after_tp1 = ?, decision_mode = ?,
+			ensemble_model_ids = ?, ensemble_vote = ?, validation_rules = ?,
+			prompt_experiment = ?, fallback_model_ids = ?, ai_safe_mode = ?,
+			updated_at = CURRENT_TIMESTAMP
 		WHERE id = ? AND user_id = ?
 	`, trader.Name, trader.AIModelID, trader.ExchangeID,
 		trader.ScanIntervalMinutes, trader.BTCETHLeverage, trader.AltcoinLeverage,
 		trader.TradingSymbols, trader.CustomPrompt, trader.OverrideBasePrompt,
-		trader.SystemPromptTemplate, trader.IsCrossMargin, trader.ID, trader.UserID)
+		trader.SystemPromptTemplate, trader.IsCrossMargin, trader.PendingOrderMaxCycles,
+		trader.TakeProfitLadder, trader.BreakevenAfterTP1, trader.DecisionMode,
+		trader.EnsembleModelIDs, trader.EnsembleVote, trader.ValidationRules,
+		trader.PromptExperiment, trader.FallbackModelIDs, trader.AISafeMode,
+		trader.ID, trader.UserID)
 	return err
 }
 
@@ -1442,9 +1585,180 @@ func (d *Database) UpdateTraderInitialBalance(userID, id string, newBalance floa
 // DeleteTrader 删除交易员
 func (d *Database) DeleteTrader(userID, id string) error {
 	_, err := d.db.Exec(`DELETE FROM traders WHERE id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return err
+	}
+	// 同时清理模拟盘账户状态
+	_, err = d.db.Exec(`DELETE FROM paper_accounts WHERE trader_id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return err
+	}
+	// 同时清理追踪止损状态
+	_, err = d.db.Exec(`DELETE FROM trailing_stops WHERE trader_id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return err
+	}
+	// 同时清理限价挂单状态
+	_, err = d.db.Exec(`DELETE FROM pending_orders WHERE trader_id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return err
+	}
+	// 同时清理分批止盈状态
+	_, err = d.db.Exec(`DELETE FROM take_profit_ladders WHERE trader_id = ? AND user_id = ?`, id, userID)
+	return err
+}
+
+// GetPaperAccountState 获取模拟盘账户状态JSON，不存在时返回空字符串
+func (d *Database) GetPaperAccountState(traderID string) (string, error) {
+	var state string
+	err := d.db.QueryRow(`SELECT state FROM paper_accounts WHERE trader_id = ?`, traderID).Scan(&state)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return state, err
+}
+
+// SavePaperAccountState 保存模拟盘账户状态JSON
+func (d *Database) SavePaperAccountState(traderID, userID, state string) error {
+	_, err := d.db.Exec(`
+		INSERT INTO paper_accounts (trader_id, user_id, state, updated_at)
+		VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(trader_id) DO UPDATE SET
+			state = excluded.state,
+			updated_at = CURRENT_TIMESTAMP
+	`, traderID, userID, state)
+	return err
+}
+
+// GetTrailingStopState 获取追踪止损状态JSON，不存在时返回空字符串
+func (d *Database) GetTrailingStopState(traderID string) (string, error) {
+	var state string
+	err := d.db.QueryRow(`SELECT state FROM trailing_stops WHERE trader_id = ?`, traderID).Scan(&state)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return state, err
+}
+
+// SaveTrailingStopState 保存追踪止损状态JSON
+func (d *Database) SaveTrailingStopState(traderID, userID, state string) error {
+	_, err := d.db.Exec(`
+		INSERT INTO trailing_stops (trader_id, user_id, state, updated_at)
+		VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(trader_id) DO UPDATE SET
+			state = excluded.state,
+			updated_at = CURRENT_TIMESTAMP
+	`, traderID, userID, state)
+	return err
+}
+
+// GetPendingOrderState 获取限价挂单状态JSON，不存在时返回空字符串
+func (d *Database) GetPendingOrderState(traderID string) (string, error) {
+	var state string
+	err := d.db.QueryRow(`SELECT state FROM pending_orders WHERE trader_id = ?`, traderID).Scan(&state)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return state, err
+}
+
+// SavePendingOrderState 保存限价挂单状态JSON
+func (d *Database) SavePendingOrderState(traderID, userID, state string) error {
+	_, err := d.db.Exec(`
+		INSERT INTO pending_orders (trader_id, user_id, state, updated_at)
+		VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(trader_id) DO UPDATE SET
+			state = excluded.state,
+			updated_at = CURRENT_TIMESTAMP
+	`, traderID, userID, state)
 	return err
 }
 
+// GetTakeProfitLadderState 获取分批止盈状态JSON，不存在时返回空字符串
+func (d *Database) GetTakeProfitLadderState(traderID string) (string, error) {
+	var state string
+	err := d.db.QueryRow(`SELECT state FROM take_profit_ladders WHERE trader_id = ?`, traderID).Scan(&state)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return state, err
+}
+
+// SaveTakeProfitLadderState 保存分批止盈状态JSON
+func (d *Database) SaveTakeProfitLadderState(traderID, userID, state string) error {
+	_, err := d.db.Exec(`
+		INSERT INTO take_profit_ladders (trader_id, user_id, state, updated_at)
+		VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(trader_id) DO UPDATE SET
+			state = excluded.state,
+			updated_at = CURRENT_TIMESTAMP
+	`, traderID, userID, state)
+	return err
+}
+
+// 提示词版本类型
+const (
+	PromptRevisionTemplate = "template" // 系统提示词模板（name 为模板名称）
+	PromptRevisionCustom   = "custom"   // 交易员自定义提示词（name 为交易员ID）
+)
+
+// PromptRevision 提示词的一个历史版本
+type PromptRevision struct {
+	Hash      string    `json:"hash"`    // 内容 SHA-256 哈希
+	Kind      string    `json:"kind"`    // template | custom
+	Name      string    `json:"name"`    // 模板名称或交易员ID
+	UserID    string    `json:"user_id"` // 自定义提示词所属用户（模板为空）
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"` // 首次记录时间
+}
+
+// SavePromptRevision 保存提示词版本，相同内容只记录一次
+func (d *Database) SavePromptRevision(hash, kind, name, userID, content string) error {
+	_, err := d.db.Exec(`
+		INSERT OR IGNORE INTO prompt_revisions (hash, kind, name, user_id, content)
+		VALUES (?, ?, ?, ?, ?)
+	`, hash, kind, name, userID, content)
+	return err
+}
+
+// GetPromptRevisions 获取提示词的所有历史版本（按记录时间倒序）
+func (d *Database) GetPromptRevisions(kind, name string) ([]*PromptRevision, error) {
+	rows, err := d.db.Query(`
+		SELECT hash, kind, name, user_id, content, created_at FROM prompt_revisions
+		WHERE kind = ? AND name = ? ORDER BY created_at DESC, rowid DESC
+	`, kind, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revisions []*PromptRevision
+	for rows.Next() {
+		var rev PromptRevision
+		if err := rows.Scan(&rev.Hash, &rev.Kind, &rev.Name, &rev.UserID, &rev.Content, &rev.CreatedAt); err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, &rev)
+	}
+	return revisions, rows.Err()
+}
+
+// GetPromptRevision 按内容哈希获取提示词版本，不存在时返回 nil
+func (d *Database) GetPromptRevision(hash string) (*PromptRevision, error) {
+	var rev PromptRevision
+	err := d.db.QueryRow(`
+		SELECT hash, kind, name, user_id, content, created_at FROM prompt_revisions
+		WHERE hash = ? LIMIT 1
+	`, hash).Scan(&rev.Hash, &rev.Kind, &rev.Name, &rev.UserID, &rev.Content, &rev.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rev, nil
+}
+
 // GetTraderConfig 获取交易员完整配置（包含AI模型和交易所信息）
 func (d *Database) GetTraderConfig(userID, traderID string) (*TraderRecord, *AIModelConfig, *ExchangeConfig, error) {
 	var trader TraderRecord
@@ -1466,6 +1780,16 @@ func (d *Database) GetTraderConfig(userID, traderID string) (*TraderRecord, *AIM
 			COALESCE(t.override_base_prompt, 0) as override_base_prompt,
 			COALESCE(t.system_prompt_template, 'default') as system_prompt_template,
 			COALESCE(t.is_cross_margin, 1) as is_cross_margin,
+			COALESCE(t.pending_order_max_cycles, 3) as pending_order_max_cycles,
+			COALESCE(t.take_profit_ladder, '') as take_profit_ladder,
+			COALESCE(t.breakeven_after_tp1, 0) as breakeven_after_tp1,
+			COALESCE(t.decision_mode, 'text') as decision_mode,
+			COALESCE(t.ensemble_model_ids, '') as ensemble_model_ids,
+			COALESCE(t.ensemble_vote, '') as ensemble_vote,
+			COALESCE(t.validation_rules, '') as validation_rules,
+			COALESCE(t.prompt_experiment, '') as prompt_experiment,
+			COALESCE(t.fallback_model_ids, '') as fallback_model_ids,
+			COALESCE(t.ai_safe_mode, 0) as ai_safe_mode,
 			t.created_at, t.updated_at,
 			a.id, a.user_id, a.name, a.provider, a.enabled, a.api_key,
 			COALESCE(a.custom_api_url, '') as custom_api_url,
@@ -1479,6 +1803,7 @@ func (d *Database) GetTraderConfig(userID, traderID string) (*TraderRecord, *AIM
 			COALESCE(e.lighter_wallet_addr, '') as lighter_wallet_addr,
 			COALESCE(e.lighter_private_key, '') as lighter_private_key,
 			COALESCE(e.lighter_api_key_private_key, '') as lighter_api_key_private_key,
+			COALESCE(e.passphrase, '') as passphrase,
 			e.created_at, e.updated_at
 		FROM traders t
 		JOIN ai_models a ON t.ai_model_id = a.id AND t.user_id = a.user_id
@@ -1490,7 +1815,10 @@ func (d *Database) GetTraderConfig(userID, traderID string) (*TraderRecord, *AIM
 		&trader.BTCETHLeverage, &trader.AltcoinLeverage, &trader.TradingSymbols,
 		&trader.UseCoinPool, &trader.UseOITop,
 		&trader.CustomPrompt, &trader.OverrideBasePrompt, &trader.SystemPromptTemplate,
-		&trader.IsCrossMargin,
+		&trader.IsCrossMargin, &trader.PendingOrderMaxCycles,
+		&trader.TakeProfitLadder, &trader.BreakevenAfterTP1, &trader.DecisionMode,
+		&trader.EnsembleModelIDs, &trader.EnsembleVote, &trader.ValidationRules,
+		&trader.PromptExperiment, &trader.FallbackModelIDs, &trader.AISafeMode,
 		&traderCreatedAt, &traderUpdatedAt,
 		&aiModel.ID, &aiModel.UserID, &aiModel.Name, &aiModel.Provider, &aiModel.Enabled, &aiModel.APIKey,
 		&aiModel.CustomAPIURL, &aiModel.CustomModelName,
@@ -1499,6 +1827,7 @@ func (d *Database) GetTraderConfig(userID, traderID string) (*TraderRecord, *AIM
 		&exchange.APIKey, &exchange.SecretKey, &exchange.Testnet,
 		&exchange.HyperliquidWalletAddr, &exchange.AsterUser, &exchange.AsterSigner, &exchange.AsterPrivateKey,
 		&exchange.LighterWalletAddr, &exchange.LighterPrivateKey, &exchange.LighterAPIKeyPrivateKey,
+		&exchange.Passphrase,
 		&exchangeCreatedAt, &exchangeUpdatedAt,
 	)
 
@@ -1521,6 +1850,7 @@ func (d *Database) GetTraderConfig(userID, traderID string) (*TraderRecord, *AIM
 	exchange.AsterPrivateKey = d.decryptSensitiveData(exchange.AsterPrivateKey)
 	exchange.LighterPrivateKey = d.decryptSensitiveData(exchange.LighterPrivateKey)
 	exchange.LighterAPIKeyPrivateKey = d.decryptSensitiveData(exchange.LighterAPIKeyPrivateKey)
+	exchange.Passphrase = d.decryptSensitiveData(exchange.Passphrase)
 
 	return &trader, &aiModel, &exchange, nil
 }
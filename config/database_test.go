@@ -577,6 +577,54 @@ func TestUpdateExchange_AllSensitiveFieldsUpdate(t *testing.T) {
 	}
 }
 
+// TestUpdateExchangePassphrase OKX Passphrase 加密保存，空值不覆盖
+func TestUpdateExchangePassphrase(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	userID := "test-user-009"
+	err := db.UpdateExchange(userID, "okx", true, "okx-api-key", "okx-secret-key", false, "", "", "", "", "", "")
+	if err != nil {
+		t.Fatalf("初始化失败: %v", err)
+	}
+	if err := db.UpdateExchangePassphrase(userID, "okx", "okx-passphrase"); err != nil {
+		t.Fatalf("保存 Passphrase 失败: %v", err)
+	}
+
+	// 空值不应覆盖已保存的 Passphrase
+	if err := db.UpdateExchangePassphrase(userID, "okx", ""); err != nil {
+		t.Fatalf("空 Passphrase 更新失败: %v", err)
+	}
+
+	exchanges, err := db.GetExchanges(userID)
+	if err != nil {
+		t.Fatalf("获取配置失败: %v", err)
+	}
+	var okx *ExchangeConfig
+	for _, ex := range exchanges {
+		if ex.ID == "okx" {
+			okx = ex
+		}
+	}
+	if okx == nil {
+		t.Fatal("未找到 OKX 配置")
+	}
+	if okx.Name != "OKX Futures" {
+		t.Errorf("OKX 名称不正确: %s", okx.Name)
+	}
+	if okx.Passphrase != "okx-passphrase" {
+		t.Errorf("Passphrase 不正确，期望 okx-passphrase，实际 %s", okx.Passphrase)
+	}
+
+	var stored string
+	if err := db.db.QueryRow(`SELECT passphrase FROM exchanges WHERE id = 'okx' AND user_id = ?`, userID).Scan(&stored); err != nil {
+		t.Fatalf("查询 Passphrase 失败: %v", err)
+	}
+	if db.cryptoService != nil && stored == "okx-passphrase" {
+		t.Error("Passphrase 应加密存储")
+	}
+}
+
 // setupTestDB 创建测试数据库
 func setupTestDB(t *testing.T) (*Database, func()) {
 	// 创建临时数据库文件
@@ -848,3 +896,39 @@ func TestConcurrentWritesWithWAL(t *testing.T) {
 		t.Errorf("并发写入失败次数过多: %d", errorCount)
 	}
 }
+
+// TestPromptRevisions 测试提示词版本按内容去重保存
+func TestPromptRevisions(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	for _, rev := range []*PromptRevision{
+		{Hash: "h1", Kind: PromptRevisionCustom, Name: "trader-1", UserID: "user1", Content: "v1"},
+		{Hash: "h1", Kind: PromptRevisionCustom, Name: "trader-1", UserID: "user1", Content: "v1"},
+		{Hash: "h2", Kind: PromptRevisionCustom, Name: "trader-1", UserID: "user1", Content: "v2"},
+		{Hash: "h3", Kind: PromptRevisionTemplate, Name: "default", Content: "template"},
+	} {
+		if err := db.SavePromptRevision(rev.Hash, rev.Kind, rev.Name, rev.UserID, rev.Content); err != nil {
+			t.Fatalf("保存提示词版本失败: %v", err)
+		}
+	}
+
+	revisions, err := db.GetPromptRevisions(PromptRevisionCustom, "trader-1")
+	if err != nil {
+		t.Fatalf("获取提示词版本失败: %v", err)
+	}
+	if len(revisions) != 2 || revisions[0].Hash != "h2" || revisions[1].Content != "v1" {
+		t.Fatalf("相同内容只应记录一次，且按时间倒序: %+v", revisions)
+	}
+	if revisions[0].CreatedAt.IsZero() {
+		t.Error("应记录创建时间")
+	}
+
+	rev, err := db.GetPromptRevision("h3")
+	if err != nil || rev == nil || rev.Kind != PromptRevisionTemplate || rev.Content != "template" {
+		t.Errorf("按哈希获取失败: %+v, %v", rev, err)
+	}
+	if rev, err := db.GetPromptRevision("missing"); err != nil || rev != nil {
+		t.Errorf("不存在的哈希应返回 nil: %+v, %v", rev, err)
+	}
+}
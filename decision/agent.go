@@ -0,0 +1,368 @@
+package decision
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"nofx/market"
+	"nofx/mcp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// agent 模式下 AI 可调用的只读数据工具
+const (
+	AgentToolGetKlines         = "get_klines"
+	AgentToolGetOrderBook      = "get_order_book"
+	AgentToolGetFundingHistory = "get_funding_history"
+	AgentToolGetRecentFills    = "get_recent_fills"
+)
+
+// agent 模式默认预算
+const (
+	DefaultAgentMaxTurns  = 5     // 最大对话轮数（含最后一轮强制提交决策）
+	DefaultAgentMaxTokens = 60000 // 整段对话的估算 token 上限
+)
+
+// 单次工具调用的默认条数和上限
+const (
+	agentDefaultKlines  = 50
+	agentMaxKlines      = 200
+	agentDefaultDepth   = 20
+	agentMaxDepth       = 100
+	agentDefaultFunding = 10
+	agentMaxFunding     = 50
+	agentDefaultFills   = 20
+	agentMaxFills       = 50
+)
+
+// FillInfo 成交记录（get_recent_fills 的返回项）
+type FillInfo struct {
+	Symbol      string  `json:"symbol"`
+	Side        string  `json:"side"`
+	Price       float64 `json:"price"`
+	Quantity    float64 `json:"qty"`
+	Fee         float64 `json:"fee"`
+	RealizedPnL float64 `json:"realized_pnl"`
+	Time        int64   `json:"time"` // 成交时间（毫秒）
+}
+
+// AgentToolProvider agent 模式下 AI 可调用的只读数据源。
+// 实盘由行情接口和交易所账户提供；回测由 DataFeed 提供，只能返回当前回测时刻及之前的数据。
+type AgentToolProvider interface {
+	GetKlines(symbol, interval string, limit int) ([]market.Kline, error)
+	GetOrderBook(symbol string, limit int) (*market.OrderBook, error)
+	GetFundingHistory(symbol string, limit int) ([]market.FundingRatePoint, error)
+	GetRecentFills(symbol string, limit int) ([]FillInfo, error)
+}
+
+// AgentBudget agent 模式的预算，达到轮数或 token 上限后要求模型立即提交决策
+type AgentBudget struct {
+	MaxTurns  int `json:"max_turns,omitempty"`  // 最大对话轮数
+	MaxTokens int `json:"max_tokens,omitempty"` // 对话累计估算 token 上限
+}
+
+// withDefaults 未设置的预算项使用默认值
+func (b AgentBudget) withDefaults() AgentBudget {
+	if b.MaxTurns <= 0 {
+		b.MaxTurns = DefaultAgentMaxTurns
+	}
+	if b.MaxTokens <= 0 {
+		b.MaxTokens = DefaultAgentMaxTokens
+	}
+	return b
+}
+
+// AgentTools 返回 agent 模式下的只读数据工具定义
+func AgentTools() []mcp.Tool {
+	symbol := map[string]any{"type": "string", "description": "交易对，如 BTCUSDT"}
+	limit := func(def, max int) map[string]any {
+		return map[string]any{"type": "integer", "description": fmt.Sprintf("返回条数，默认 %d，最多 %d", def, max)}
+	}
+	tool := func(name, description string, properties map[string]any, required ...string) mcp.Tool {
+		return mcp.Tool{
+			Type: "function",
+			Function: mcp.FunctionDef{
+				Name:        name,
+				Description: description,
+				Parameters:  map[string]any{"type": "object", "properties": properties, "required": required},
+			},
+		}
+	}
+
+	return []mcp.Tool{
+		tool(AgentToolGetKlines, "获取指定周期的最近 K 线（按时间升序）",
+			map[string]any{
+				"symbol":   symbol,
+				"interval": map[string]any{"type": "string", "description": "K 线周期", "enum": market.SupportedTimeframes()},
+				"limit":    limit(agentDefaultKlines, agentMaxKlines),
+			}, "symbol", "interval"),
+		tool(AgentToolGetOrderBook, "获取当前订单簿深度（买卖盘各若干档）",
+			map[string]any{"symbol": symbol, "limit": limit(agentDefaultDepth, agentMaxDepth)}, "symbol"),
+		tool(AgentToolGetFundingHistory, "获取最近几期资金费率结算记录",
+			map[string]any{"symbol": symbol, "limit": limit(agentDefaultFunding, agentMaxFunding)}, "symbol"),
+		tool(AgentToolGetRecentFills, "获取本账户最近的成交记录，symbol 为空时返回所有币种",
+			map[string]any{"symbol": symbol, "limit": limit(agentDefaultFills, agentMaxFills)}),
+	}
+}
+
+// buildAgentInstructions agent 模式下追加到 System Prompt 的输出说明（覆盖上文的 XML 输出格式）
+func buildAgentInstructions(budget AgentBudget) string {
+	var sb strings.Builder
+	sb.WriteString("# 输出方式（多轮工具调用）\n\n")
+	sb.WriteString("决策前可以调用以下只读工具补充数据，每轮可同时调用多个：\n")
+	sb.WriteString(fmt.Sprintf("- `%s`: 任意周期的 K 线\n", AgentToolGetKlines))
+	sb.WriteString(fmt.Sprintf("- `%s`: 订单簿深度\n", AgentToolGetOrderBook))
+	sb.WriteString(fmt.Sprintf("- `%s`: 资金费率历史\n", AgentToolGetFundingHistory))
+	sb.WriteString(fmt.Sprintf("- `%s`: 本账户最近成交\n\n", AgentToolGetRecentFills))
+	sb.WriteString(fmt.Sprintf("最多 %d 轮对话，只查询对本周期决策有帮助的数据。", budget.MaxTurns))
+	sb.WriteString(fmt.Sprintf("数据足够后**调用 `%s` 函数**提交决策，不要输出 <reasoning>/<decision> 标签或 JSON 代码块：\n", SubmitDecisionsToolName))
+	sb.WriteString("- `reasoning`: 思维链分析\n")
+	sb.WriteString("- `decisions`: 决策数组，字段含义与上文字段说明一致；无需操作时提交 wait 决策\n")
+	return sb.String()
+}
+
+// requestDecisionWithAgent 以多轮工具调用模式请求决策：模型可先调用只读数据工具，
+// 直到调用 submit_decisions 或用完轮数/token 预算（最后一轮强制提交决策）。完整对话记录写入 CoTTrace。
+// 未提供数据源时退化为单轮工具调用模式，接口不支持工具调用时回退到文本解析
+func requestDecisionWithAgent(mcpClient mcp.AIClient, systemPrompt, userPrompt string, tools AgentToolProvider, budget AgentBudget, validator *decisionValidator) (*FullDecision, error) {
+	if tools == nil {
+		log.Printf("⚠️  agent 模式未配置数据源，使用单轮工具调用")
+		return requestDecisionWithTools(mcpClient, systemPrompt, userPrompt, validator)
+	}
+	budget = budget.withDefaults()
+
+	messages := []mcp.Message{
+		mcp.NewSystemMessage(systemPrompt + "\n" + buildAgentInstructions(budget)),
+		mcp.NewUserMessage(userPrompt),
+	}
+	estimateTokens := tokenEstimator(mcpClient)
+	usedTokens := estimateTokens(messages[0].Content) + estimateTokens(userPrompt)
+	var transcript strings.Builder
+
+	for turn := 1; ; turn++ {
+		final := turn >= budget.MaxTurns || usedTokens >= budget.MaxTokens
+		builder := mcp.NewRequestBuilder().AddMessages(messages...)
+		if final {
+			if turn > 1 {
+				builder.AddUserMessage(fmt.Sprintf("已达到查询上限（%d 轮 / 约 %d tokens），请立即调用 %s 提交决策。", turn, usedTokens, SubmitDecisionsToolName))
+			}
+			builder.AddTool(SubmitDecisionsTool()).WithToolChoice(mcp.ToolChoiceFunction(SubmitDecisionsToolName))
+		} else {
+			for _, tool := range AgentTools() {
+				builder.AddTool(tool)
+			}
+			builder.AddTool(SubmitDecisionsTool()).WithToolChoice("auto")
+		}
+		request, err := builder.Build()
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := mcpClient.CallWithRequestFull(request)
+		if err != nil {
+			if turn == 1 && isToolUnsupportedError(err) {
+				log.Printf("⚠️  模型不支持工具调用，回退到文本解析: %v", err)
+				return requestDecisionWithText(mcpClient, systemPrompt, userPrompt, validator)
+			}
+			return nil, fmt.Errorf("调用AI API失败（第 %d 轮）: %w", turn, err)
+		}
+		usedTokens += estimateTokens(resp.Content)
+		for _, call := range resp.ToolCalls {
+			usedTokens += estimateTokens(call.Function.Arguments)
+		}
+
+		transcript.WriteString(fmt.Sprintf("【第%d轮】\n", turn))
+		if reasoning := strings.TrimSpace(resp.Reasoning); reasoning != "" {
+			transcript.WriteString("思考: " + reasoning + "\n")
+		}
+		if content := strings.TrimSpace(resp.Content); content != "" {
+			transcript.WriteString(content + "\n")
+		}
+
+		if _, found := resp.ToolCallByName(SubmitDecisionsToolName); found || len(resp.ToolCalls) == 0 || final {
+			return finishAgentDecision(resp, &transcript, validator)
+		}
+
+		messages = append(messages, mcp.NewAssistantToolCallMessage(resp.Content, resp.ToolCalls))
+		for _, call := range resp.ToolCalls {
+			result, err := executeAgentTool(tools, call)
+			if err != nil {
+				result = "错误: " + err.Error()
+			}
+			log.Printf("🔧 agent 第%d轮调用 %s %s", turn, call.Function.Name, call.Function.Arguments)
+			transcript.WriteString(fmt.Sprintf("→ %s %s\n%s\n", call.Function.Name, call.Function.Arguments, result))
+			messages = append(messages, mcp.NewToolMessage(call.ID, result))
+			usedTokens += estimateTokens(result)
+		}
+	}
+}
+
+// finishAgentDecision 解析最后一轮的响应：优先使用 submit_decisions 的参数，模型未调用时回退到文本解析
+func finishAgentDecision(resp *mcp.Response, transcript *strings.Builder, validator *decisionValidator) (*FullDecision, error) {
+	cotTrace, decisions, ok, err := parseToolCallResponse(resp)
+	if !ok {
+		log.Printf("⚠️  模型未调用 %s，回退到文本解析", SubmitDecisionsToolName)
+		decision, err := parseFullDecisionResponse(resp.Content, validator)
+		if decision != nil {
+			decision.CoTTrace = strings.TrimSpace(transcript.String())
+			decision.RawResponse = resp.Content
+		}
+		return decision, err
+	}
+
+	if cotTrace != "" && cotTrace != strings.TrimSpace(resp.Content) {
+		transcript.WriteString("【最终决策】\n" + cotTrace + "\n")
+	}
+	cotTrace = strings.TrimSpace(transcript.String())
+	call, _ := resp.ToolCallByName(SubmitDecisionsToolName)
+	rawResponse := call.Function.Arguments
+	if err != nil {
+		return &FullDecision{CoTTrace: cotTrace, Decisions: []Decision{}, RawResponse: rawResponse}, fmt.Errorf("提取决策失败: %w", err)
+	}
+
+	if err := validator.validateAll(decisions); err != nil {
+		return &FullDecision{CoTTrace: cotTrace, Decisions: decisions, RawResponse: rawResponse}, fmt.Errorf("决策验证失败: %w", err)
+	}
+	return &FullDecision{CoTTrace: cotTrace, Decisions: decisions, RawResponse: rawResponse}, nil
+}
+
+// agentToolArgs 数据工具的调用参数
+type agentToolArgs struct {
+	Symbol   string `json:"symbol"`
+	Interval string `json:"interval"`
+	Limit    int    `json:"limit"`
+}
+
+// executeAgentTool 执行一次数据工具调用，返回给模型的文本结果
+func executeAgentTool(tools AgentToolProvider, call mcp.ToolCall) (string, error) {
+	var args agentToolArgs
+	if strings.TrimSpace(call.Function.Arguments) != "" {
+		if err := json.Unmarshal([]byte(call.Function.Arguments), &args); err != nil {
+			return "", fmt.Errorf("参数格式错误: %w", err)
+		}
+	}
+	symbol := ""
+	if strings.TrimSpace(args.Symbol) != "" {
+		symbol = market.Normalize(strings.TrimSpace(args.Symbol))
+	}
+	if symbol == "" && call.Function.Name != AgentToolGetRecentFills {
+		return "", fmt.Errorf("缺少 symbol 参数")
+	}
+
+	switch call.Function.Name {
+	case AgentToolGetKlines:
+		interval, err := market.NormalizeTimeframe(args.Interval)
+		if err != nil {
+			return "", err
+		}
+		limit := clampToolLimit(args.Limit, agentDefaultKlines, agentMaxKlines)
+		klines, err := tools.GetKlines(symbol, interval, limit)
+		if err != nil {
+			return "", err
+		}
+		return formatAgentKlines(symbol, interval, klines[max(0, len(klines)-limit):]), nil
+	case AgentToolGetOrderBook:
+		book, err := tools.GetOrderBook(symbol, clampToolLimit(args.Limit, agentDefaultDepth, agentMaxDepth))
+		if err != nil {
+			return "", err
+		}
+		return formatAgentOrderBook(book), nil
+	case AgentToolGetFundingHistory:
+		limit := clampToolLimit(args.Limit, agentDefaultFunding, agentMaxFunding)
+		points, err := tools.GetFundingHistory(symbol, limit)
+		if err != nil {
+			return "", err
+		}
+		return formatAgentFunding(symbol, points[max(0, len(points)-limit):]), nil
+	case AgentToolGetRecentFills:
+		limit := clampToolLimit(args.Limit, agentDefaultFills, agentMaxFills)
+		fills, err := tools.GetRecentFills(symbol, limit)
+		if err != nil {
+			return "", err
+		}
+		return formatAgentFills(fills[max(0, len(fills)-limit):]), nil
+	default:
+		return "", fmt.Errorf("未知工具: %s", call.Function.Name)
+	}
+}
+
+// clampToolLimit 未设置时使用默认条数，超过上限时截断
+func clampToolLimit(limit, def, max int) int {
+	if limit <= 0 {
+		return def
+	}
+	return min(limit, max)
+}
+
+func formatAgentFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func formatAgentKlines(symbol, interval string, klines []market.Kline) string {
+	if len(klines) == 0 {
+		return fmt.Sprintf("%s %s 暂无 K 线数据", symbol, interval)
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s %s K 线（%d 根，时间为 UTC 收盘时间）\n", symbol, interval, len(klines)))
+	sb.WriteString("close_time,open,high,low,close,volume\n")
+	for _, k := range klines {
+		sb.WriteString(fmt.Sprintf("%s,%s,%s,%s,%s,%s\n",
+			time.UnixMilli(k.CloseTime).UTC().Format("2006-01-02 15:04"),
+			formatAgentFloat(k.Open), formatAgentFloat(k.High), formatAgentFloat(k.Low), formatAgentFloat(k.Close), formatAgentFloat(k.Volume)))
+	}
+	return sb.String()
+}
+
+func formatAgentOrderBook(book *market.OrderBook) string {
+	if book == nil || (len(book.Bids) == 0 && len(book.Asks) == 0) {
+		return "订单簿为空"
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s 订单簿（买 %d 档 / 卖 %d 档）\n", book.Symbol, len(book.Bids), len(book.Asks)))
+	if len(book.Bids) > 0 && len(book.Asks) > 0 {
+		bid, ask := book.Bids[0].Price, book.Asks[0].Price
+		sb.WriteString(fmt.Sprintf("买一 %s / 卖一 %s，价差 %.4f%%\n", formatAgentFloat(bid), formatAgentFloat(ask), (ask-bid)/bid*100))
+	}
+	writeSide := func(name string, levels []market.OrderBookLevel) {
+		notional := 0.0
+		sb.WriteString(name + " price,qty\n")
+		for _, level := range levels {
+			notional += level.Price * level.Quantity
+			sb.WriteString(fmt.Sprintf("%s,%s\n", formatAgentFloat(level.Price), formatAgentFloat(level.Quantity)))
+		}
+		sb.WriteString(fmt.Sprintf("%s累计挂单 %.2f USDT\n", name, notional))
+	}
+	writeSide("卖盘", book.Asks)
+	writeSide("买盘", book.Bids)
+	return sb.String()
+}
+
+func formatAgentFunding(symbol string, points []market.FundingRatePoint) string {
+	if len(points) == 0 {
+		return fmt.Sprintf("%s 暂无资金费率记录", symbol)
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s 资金费率历史（%d 期，UTC）\n", symbol, len(points)))
+	sb.WriteString("funding_time,rate\n")
+	for _, p := range points {
+		sb.WriteString(fmt.Sprintf("%s,%.4f%%\n", time.UnixMilli(p.FundingTime).UTC().Format("2006-01-02 15:04"), p.Rate*100))
+	}
+	return sb.String()
+}
+
+func formatAgentFills(fills []FillInfo) string {
+	if len(fills) == 0 {
+		return "暂无成交记录"
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("最近 %d 笔成交（UTC）\n", len(fills)))
+	sb.WriteString("time,symbol,side,price,qty,fee,realized_pnl\n")
+	for _, f := range fills {
+		sb.WriteString(fmt.Sprintf("%s,%s,%s,%s,%s,%s,%s\n",
+			time.UnixMilli(f.Time).UTC().Format("2006-01-02 15:04"), f.Symbol, f.Side,
+			formatAgentFloat(f.Price), formatAgentFloat(f.Quantity), formatAgentFloat(f.Fee), formatAgentFloat(f.RealizedPnL)))
+	}
+	return sb.String()
+}
@@ -0,0 +1,158 @@
+package decision
+
+import (
+	"errors"
+	"nofx/market"
+	"nofx/mcp"
+	"strings"
+	"testing"
+)
+
+// fakeAgentTools 记录工具调用并返回固定数据
+type fakeAgentTools struct {
+	calls []string
+}
+
+func (f *fakeAgentTools) GetKlines(symbol, interval string, limit int) ([]market.Kline, error) {
+	f.calls = append(f.calls, "klines:"+symbol+":"+interval)
+	klines := make([]market.Kline, 0, limit+5)
+	for i := 0; i < limit+5; i++ {
+		klines = append(klines, market.Kline{CloseTime: int64(i+1) * 3600000, Open: 100, High: 101, Low: 99, Close: 100.5, Volume: 10})
+	}
+	return klines, nil
+}
+
+func (f *fakeAgentTools) GetOrderBook(symbol string, limit int) (*market.OrderBook, error) {
+	f.calls = append(f.calls, "depth:"+symbol)
+	return nil, errors.New("回测不提供历史订单簿")
+}
+
+func (f *fakeAgentTools) GetFundingHistory(symbol string, limit int) ([]market.FundingRatePoint, error) {
+	f.calls = append(f.calls, "funding:"+symbol)
+	return []market.FundingRatePoint{{FundingTime: 28800000, Rate: 0.0001}}, nil
+}
+
+func (f *fakeAgentTools) GetRecentFills(symbol string, limit int) ([]FillInfo, error) {
+	f.calls = append(f.calls, "fills:"+symbol)
+	return nil, nil
+}
+
+func toolCall(id, name, args string) mcp.ToolCall {
+	return mcp.ToolCall{ID: id, Type: "function", Function: mcp.FunctionCall{Name: name, Arguments: args}}
+}
+
+func newAgentContext(tools AgentToolProvider) *Context {
+	ctx := newToolCallContext()
+	ctx.DecisionMode = DecisionModeAgent
+	ctx.AgentTools = tools
+	return ctx
+}
+
+func TestGetFullDecision_AgentModeLoopsUntilSubmit(t *testing.T) {
+	tools := &fakeAgentTools{}
+	client := &fakeAIClient{responses: []*mcp.Response{
+		{Content: "先看看 ETH 的 1h 走势", ToolCalls: []mcp.ToolCall{
+			toolCall("call_1", AgentToolGetKlines, `{"symbol":"eth","interval":"1H","limit":3}`),
+			toolCall("call_2", AgentToolGetOrderBook, `{"symbol":"ETHUSDT"}`),
+		}},
+		{ToolCalls: []mcp.ToolCall{toolCall("call_3", SubmitDecisionsToolName,
+			`{"reasoning":"1h 高位震荡","decisions":[{"symbol":"ETHUSDT","action":"wait","reasoning":"观望"}]}`)}},
+	}}
+
+	fd, err := GetFullDecisionWithCustomPrompt(newAgentContext(tools), client, "", false, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(fd.Decisions) != 1 || fd.Decisions[0].Action != "wait" {
+		t.Errorf("unexpected decisions: %+v", fd.Decisions)
+	}
+	if strings.Join(tools.calls, ",") != "klines:ETHUSDT:1h,depth:ETHUSDT" {
+		t.Errorf("工具参数应规范化后执行: %v", tools.calls)
+	}
+	for _, want := range []string{"【第1轮】\n先看看 ETH 的 1h 走势", "→ get_klines", "ETHUSDT 1h K 线（3 根", "错误: 回测不提供历史订单簿", "【第2轮】", "【最终决策】\n1h 高位震荡"} {
+		if !strings.Contains(fd.CoTTrace, want) {
+			t.Errorf("CoTTrace 应包含完整对话 %q:\n%s", want, fd.CoTTrace)
+		}
+	}
+
+	if len(client.requests) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(client.requests))
+	}
+	first := client.requests[0]
+	if len(first.Tools) != 5 || first.ToolChoice != "auto" {
+		t.Errorf("中间轮次应提供全部工具并由模型自行选择: %d tools, %s", len(first.Tools), first.ToolChoice)
+	}
+	second := client.requests[1].Messages
+	if len(second) != 5 || len(second[2].ToolCalls) != 2 || second[3].ToolCallID != "call_1" || second[4].ToolCallID != "call_2" {
+		t.Errorf("第二轮应回传工具调用和结果: %+v", second)
+	}
+}
+
+func TestGetFullDecision_AgentModeBudget(t *testing.T) {
+	lookup := &mcp.Response{ToolCalls: []mcp.ToolCall{toolCall("call", AgentToolGetFundingHistory, `{"symbol":"BTCUSDT"}`)}}
+	submit := &mcp.Response{ToolCalls: []mcp.ToolCall{toolCall("submit", SubmitDecisionsToolName,
+		`{"reasoning":"资金费正常","decisions":[{"symbol":"ALL","action":"wait","reasoning":"观望"}]}`)}}
+
+	t.Run("达到轮数上限", func(t *testing.T) {
+		client := &fakeAIClient{responses: []*mcp.Response{lookup, lookup}, response: submit}
+		ctx := newAgentContext(&fakeAgentTools{})
+		ctx.AgentBudget = AgentBudget{MaxTurns: 3}
+		if _, err := GetFullDecisionWithCustomPrompt(ctx, client, "", false, ""); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(client.requests) != 3 {
+			t.Fatalf("expected 3 requests, got %d", len(client.requests))
+		}
+		last := client.requests[2]
+		if len(last.Tools) != 1 || last.ToolChoice != mcp.ToolChoiceFunction(SubmitDecisionsToolName) {
+			t.Errorf("最后一轮应强制提交决策: %+v", last.Tools)
+		}
+		if !strings.Contains(last.Messages[len(last.Messages)-1].Content, "已达到查询上限") {
+			t.Errorf("最后一轮应提示模型停止查询")
+		}
+	})
+
+	t.Run("达到 token 上限", func(t *testing.T) {
+		client := &fakeAIClient{response: submit}
+		ctx := newAgentContext(&fakeAgentTools{})
+		ctx.AgentBudget = AgentBudget{MaxTokens: 1}
+		if _, err := GetFullDecisionWithCustomPrompt(ctx, client, "", false, ""); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(client.requests) != 1 || len(client.requests[0].Tools) != 1 {
+			t.Errorf("prompt 已超出预算时应直接要求提交决策")
+		}
+	})
+
+	t.Run("未配置数据源", func(t *testing.T) {
+		client := &fakeAIClient{response: submit}
+		if _, err := GetFullDecisionWithCustomPrompt(newAgentContext(nil), client, "", false, ""); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(client.requests[0].Tools) != 1 {
+			t.Errorf("没有数据源时退化为单轮工具调用")
+		}
+	})
+}
+
+func TestExecuteAgentTool_Errors(t *testing.T) {
+	tools := &fakeAgentTools{}
+	for _, call := range []mcp.ToolCall{
+		toolCall("1", AgentToolGetKlines, `{"interval":"1h"}`),
+		toolCall("2", AgentToolGetKlines, `{"symbol":"BTC","interval":"7m"}`),
+		toolCall("3", "get_news", `{"symbol":"BTC"}`),
+		toolCall("4", AgentToolGetFundingHistory, `not json`),
+	} {
+		if _, err := executeAgentTool(tools, call); err == nil {
+			t.Errorf("%s %s 应返回错误", call.Function.Name, call.Function.Arguments)
+		}
+	}
+	if len(tools.calls) != 0 {
+		t.Errorf("参数无效时不应访问数据源: %v", tools.calls)
+	}
+
+	result, err := executeAgentTool(tools, toolCall("5", AgentToolGetRecentFills, `{}`))
+	if err != nil || result != "暂无成交记录" {
+		t.Errorf("成交记录可以不指定币种: %q, %v", result, err)
+	}
+}
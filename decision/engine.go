@@ -81,18 +81,24 @@ type Context struct {
 	Positions       []PositionInfo                     `json:"positions"`
 	CandidateCoins  []CandidateCoin                    `json:"candidate_coins"`
 	PromptVariant   string                             `json:"prompt_variant,omitempty"`
+	DecisionMode    string                             `json:"-"` // 决策输出方式：text（默认）| tool_call | agent
+	AgentTools      AgentToolProvider                  `json:"-"` // agent 模式下 AI 可调用的只读数据源
+	AgentBudget     AgentBudget                        `json:"-"` // agent 模式的轮数和 token 预算（零值为默认预算）
+	OnStream        mcp.StreamHandler                  `json:"-"` // 设置时使用流式请求，实时接收 AI 的思考过程和输出
+	MarketData      market.MarketDataProvider          `json:"-"` // 行情数据源（与交易所一致），nil 时使用币安行情
 	MarketDataMap   map[string]*market.Data            `json:"-"` // 不序列化，但内部使用
 	MultiTFMarket   map[string]map[string]*market.Data `json:"-"`
 	OITopDataMap    map[string]*OITopData              `json:"-"` // OI Top数据映射
 	Performance     interface{}                        `json:"-"` // 历史表现分析（logger.PerformanceAnalysis）
 	BTCETHLeverage  int                                `json:"-"` // BTC/ETH杠杆倍数（从配置读取）
 	AltcoinLeverage int                                `json:"-"` // 山寨币杠杆倍数（从配置读取）
+	Validation      ValidationConfig                   `json:"-"` // 决策校验规则链配置（零值为默认规则）
 }
 
 // Decision AI的交易决策
 type Decision struct {
 	Symbol string `json:"symbol"`
-	Action string `json:"action"` // "open_long", "open_short", "close_long", "close_short", "update_stop_loss", "update_take_profit", "partial_close", "hold", "wait"
+	Action string `json:"action"` // "open_long", "open_short", "close_long", "close_short", "update_stop_loss", "update_take_profit", "partial_close", "set_trailing_stop", "hold", "wait"
 
 	// 开仓参数
 	Leverage        int     `json:"leverage,omitempty"`
@@ -100,17 +106,63 @@ type Decision struct {
 	StopLoss        float64 `json:"stop_loss,omitempty"`
 	TakeProfit      float64 `json:"take_profit,omitempty"`
 
+	// 下单方式（可选，默认市价单）
+	OrderType   string  `json:"order_type,omitempty"`    // "market"(默认) | "limit" | "post_only"
+	LimitPrice  float64 `json:"limit_price,omitempty"`   // 限价单价格（limit/post_only 必填）
+	TimeInForce string  `json:"time_in_force,omitempty"` // "GTC"(默认) | "IOC"，post_only 忽略此字段
+
 	// 调整参数（新增）
 	NewStopLoss     float64 `json:"new_stop_loss,omitempty"`    // 用于 update_stop_loss
 	NewTakeProfit   float64 `json:"new_take_profit,omitempty"`  // 用于 update_take_profit
 	ClosePercentage float64 `json:"close_percentage,omitempty"` // 用于 partial_close (0-100)
 
+	// 追踪止损参数（用于 set_trailing_stop，callback_rate 与 trailing_distance 二选一）
+	CallbackRate     float64 `json:"callback_rate,omitempty"`     // 回调比例（百分比，如 1.5 表示从最高/最低价回撤 1.5% 触发）
+	TrailingDistance float64 `json:"trailing_distance,omitempty"` // 回调价格距离（USDT）
+	ActivationPrice  float64 `json:"activation_price,omitempty"`  // 激活价格（可选，价格到达后才开始追踪）
+
+	// 分批止盈（开仓时可选，提供后替代单一 take_profit）
+	TakeProfitLevels  []TakeProfitLevel `json:"take_profit_levels,omitempty"`  // 按触发顺序排列，最后一档平掉全部剩余仓位
+	BreakevenAfterTP1 bool              `json:"breakeven_after_tp1,omitempty"` // 第一档止盈成交后把止损移到开仓价
+
 	// 通用参数
 	Confidence int     `json:"confidence,omitempty"` // 信心度 (0-100)
 	RiskUSD    float64 `json:"risk_usd,omitempty"`   // 最大美元风险
 	Reasoning  string  `json:"reasoning"`
 }
 
+// decisionActions 所有有效的决策动作
+var decisionActions = []string{
+	"open_long",
+	"open_short",
+	"close_long",
+	"close_short",
+	"update_stop_loss",
+	"update_take_profit",
+	"partial_close",
+	"set_trailing_stop",
+	"hold",
+	"wait",
+}
+
+// 开仓下单方式
+const (
+	OrderTypeMarket   = "market"    // 市价单（吃单）
+	OrderTypeLimit    = "limit"     // 限价单
+	OrderTypePostOnly = "post_only" // 只做Maker的限价单，会立即成交时由交易所拒绝
+)
+
+// 限价单有效方式
+const (
+	TimeInForceGTC = "GTC" // 一直有效直到成交或撤销
+	TimeInForceIOC = "IOC" // 立即成交剩余部分撤销
+)
+
+// IsLimitOrder 是否为限价开仓（limit 或 post_only）
+func (d *Decision) IsLimitOrder() bool {
+	return d.OrderType == OrderTypeLimit || d.OrderType == OrderTypePostOnly
+}
+
 // FullDecision AI的完整决策（包含思维链）
 type FullDecision struct {
 	SystemPrompt string     `json:"system_prompt"` // 系统提示词（发送给AI的系统prompt）
@@ -120,6 +172,14 @@ type FullDecision struct {
 	Timestamp    time.Time  `json:"timestamp"`
 	// AIRequestDurationMs 记录 AI API 调用耗时（毫秒）方便排查延迟问题
 	AIRequestDurationMs int64 `json:"ai_request_duration_ms,omitempty"`
+	// RawResponse AI 的原始输出（工具调用模式下为函数参数）
+	RawResponse string `json:"raw_response,omitempty"`
+	// RuleRejections 被校验规则拒绝的决策（含规则名称）
+	RuleRejections []RuleRejection `json:"rule_rejections,omitempty"`
+	// PromptTokens 估算的输入 prompt token 数（System + User）
+	PromptTokens int `json:"prompt_tokens,omitempty"`
+	// PromptTrims 超出 token 预算时对 User Prompt 做的压缩
+	PromptTrims []string `json:"prompt_trims,omitempty"`
 }
 
 // GetFullDecision 获取AI的完整交易决策（批量分析所有币种和持仓）
@@ -144,27 +204,38 @@ func GetFullDecisionWithCustomPrompt(ctx *Context, mcpClient mcp.AIClient, custo
 	}
 
 	// 2. 构建 System Prompt（固定规则）和 User Prompt（动态数据）
-	systemPrompt := buildSystemPromptWithCustom(
-		ctx.Account.TotalEquity,
-		ctx.BTCETHLeverage,
-		ctx.AltcoinLeverage,
-		customPrompt,
-		overrideBase,
-		templateName,
-		ctx.PromptVariant,
-	)
-	userPrompt := buildUserPrompt(ctx)
-
-	// 3. 调用AI API（使用 system + user prompt）
+	systemPrompt := buildSystemPromptForData(NewPromptData(ctx), customPrompt, overrideBase, templateName)
+	userPrompt, promptTokens, promptTrims := fitUserPrompt(ctx, systemPrompt, tokenEstimator(mcpClient), promptTokenBudget(mcpClient))
+
+	// 3. 调用AI API并解析响应（工具调用模式下由 submit_decisions 的参数直接给出决策）
+	var decision *FullDecision
+	var err error
+	mcpClient = withStreaming(mcpClient, ctx.OnStream)
+	validator := newContextValidator(ctx)
 	aiCallStart := time.Now()
-	aiResponse, err := mcpClient.CallWithMessages(systemPrompt, userPrompt)
-	aiCallDuration := time.Since(aiCallStart)
-	if err != nil {
-		return nil, fmt.Errorf("调用AI API失败: %w", err)
-	}
+	if ctx.DecisionMode == DecisionModeToolCall {
+		decision, err = requestDecisionWithTools(mcpClient, systemPrompt, userPrompt, validator)
+		if decision == nil && err != nil {
+			return nil, err
+		}
+	} else if ctx.DecisionMode == DecisionModeAgent {
+		decision, err = requestDecisionWithAgent(mcpClient, systemPrompt, userPrompt, ctx.AgentTools, ctx.AgentBudget, validator)
+		if decision == nil && err != nil {
+			return nil, err
+		}
+	} else {
+		aiResponse, callErr := mcpClient.CallWithMessages(systemPrompt, userPrompt)
+		if callErr != nil {
+			return nil, fmt.Errorf("调用AI API失败: %w", callErr)
+		}
 
-	// 4. 解析AI响应
-	decision, err := parseFullDecisionResponse(aiResponse, ctx.Account.TotalEquity, ctx.BTCETHLeverage, ctx.AltcoinLeverage)
+		// 4. 解析AI响应
+		decision, err = parseFullDecisionResponse(aiResponse, validator)
+		if decision != nil {
+			decision.RawResponse = aiResponse
+		}
+	}
+	aiCallDuration := time.Since(aiCallStart)
 
 	// 无论是否有错误，都要保存 SystemPrompt 和 UserPrompt（用于调试和决策未执行后的问题定位）
 	if decision != nil {
@@ -172,6 +243,9 @@ func GetFullDecisionWithCustomPrompt(ctx *Context, mcpClient mcp.AIClient, custo
 		decision.SystemPrompt = systemPrompt // 保存系统prompt
 		decision.UserPrompt = userPrompt     // 保存输入prompt
 		decision.AIRequestDurationMs = aiCallDuration.Milliseconds()
+		decision.RuleRejections = validator.rejections
+		decision.PromptTokens = promptTokens
+		decision.PromptTrims = promptTrims
 	}
 
 	if err != nil {
@@ -214,7 +288,7 @@ func fetchMarketDataForContext(ctx *Context) error {
 	}
 
 	for symbol := range symbolSet {
-		data, err := market.Get(symbol)
+		data, err := market.GetWithProvider(symbol, ctx.MarketData)
 		if err != nil {
 			// 单个币种失败不影响整体，只记录错误
 			continue
@@ -292,13 +366,19 @@ func calculateMaxCandidates(ctx *Context) int {
 
 // buildSystemPromptWithCustom 构建包含自定义内容的 System Prompt
 func buildSystemPromptWithCustom(accountEquity float64, btcEthLeverage, altcoinLeverage int, customPrompt string, overrideBase bool, templateName string, variant string) string {
+	data := newStaticPromptData(accountEquity, btcEthLeverage, altcoinLeverage, variant)
+	return buildSystemPromptForData(data, customPrompt, overrideBase, templateName)
+}
+
+// buildSystemPromptForData 使用模板数据构建包含自定义内容的 System Prompt
+func buildSystemPromptForData(data *PromptData, customPrompt string, overrideBase bool, templateName string) string {
 	// 如果覆盖基础prompt且有自定义prompt，只使用自定义prompt
 	if overrideBase && customPrompt != "" {
 		return customPrompt
 	}
 
 	// 获取基础prompt（使用指定的模板）
-	basePrompt := buildSystemPrompt(accountEquity, btcEthLeverage, altcoinLeverage, templateName, variant)
+	basePrompt := buildSystemPromptFromData(data, templateName)
 
 	// 如果没有自定义prompt，直接返回基础prompt
 	if customPrompt == "" {
@@ -319,33 +399,50 @@ func buildSystemPromptWithCustom(accountEquity float64, btcEthLeverage, altcoinL
 
 // buildSystemPrompt 构建 System Prompt（使用模板+动态部分）
 func buildSystemPrompt(accountEquity float64, btcEthLeverage, altcoinLeverage int, templateName string, variant string) string {
-	var sb strings.Builder
+	data := newStaticPromptData(accountEquity, btcEthLeverage, altcoinLeverage, variant)
+	return buildSystemPromptFromData(data, templateName)
+}
 
-	// 1. 加载提示词模板（核心交易策略部分）
+// renderStrategyTemplate 渲染核心策略模板，模板不存在或渲染失败时依次回退到 default 和内置简化版本
+func renderStrategyTemplate(data *PromptData, templateName string) string {
 	if templateName == "" {
 		templateName = "default" // 默认使用 default 模板
 	}
 
-	template, err := GetPromptTemplate(templateName)
-	if err != nil {
-		// 如果模板不存在，记录错误并使用 default
-		log.Printf("⚠️  提示词模板 '%s' 不存在，使用 default: %v", templateName, err)
-		template, err = GetPromptTemplate("default")
+	names := []string{templateName}
+	if templateName != "default" {
+		names = append(names, "default")
+	}
+	for _, name := range names {
+		template, err := GetPromptTemplate(name)
 		if err != nil {
-			// 如果连 default 都不存在，使用内置的简化版本
-			log.Printf("❌ 无法加载任何提示词模板，使用内置简化版本")
-			sb.WriteString("你是专业的加密货币交易AI。请根据市场数据做出交易决策。\n\n")
-		} else {
-			sb.WriteString(template.Content)
-			sb.WriteString("\n\n")
+			log.Printf("⚠️  提示词模板 '%s' 不可用: %v", name, err)
+			continue
 		}
-	} else {
-		sb.WriteString(template.Content)
-		sb.WriteString("\n\n")
+		content, err := template.Render(data)
+		if err != nil {
+			log.Printf("⚠️  %v", err)
+			continue
+		}
+		return content + "\n\n"
 	}
 
+	log.Printf("❌ 无法加载任何提示词模板，使用内置简化版本")
+	return "你是专业的加密货币交易AI。请根据市场数据做出交易决策。\n\n"
+}
+
+// buildSystemPromptFromData 渲染提示词模板并追加风控、输出格式等固定部分
+func buildSystemPromptFromData(data *PromptData, templateName string) string {
+	var sb strings.Builder
+	accountEquity := data.Account.TotalEquity
+	btcEthLeverage := data.Leverage.BTCETH
+	altcoinLeverage := data.Leverage.Altcoin
+
+	// 1. 渲染提示词模板（核心交易策略部分）
+	sb.WriteString(renderStrategyTemplate(data, templateName))
+
 	// 2. 交易模式变体
-	switch strings.ToLower(strings.TrimSpace(variant)) {
+	switch data.Variant {
 	case "aggressive":
 		sb.WriteString("## 模式：Aggressive（进攻型）\n- 优先捕捉趋势突破，可在信心度≥70时分批建仓\n- 允许更高仓位，但须严格设置止损并说明盈亏比\n\n")
 	case "conservative":
@@ -410,18 +507,26 @@ func buildSystemPrompt(accountEquity float64, btcEthLeverage, altcoinLeverage in
 	sb.WriteString("]\n```\n")
 	sb.WriteString("</decision>\n\n")
 	sb.WriteString("## 字段说明\n\n")
-	sb.WriteString("- `action`: open_long | open_short | close_long | close_short | update_stop_loss | update_take_profit | partial_close | hold | wait\n")
+	sb.WriteString("- `action`: open_long | open_short | close_long | close_short | update_stop_loss | update_take_profit | partial_close | set_trailing_stop | hold | wait\n")
 	sb.WriteString("- `confidence`: 0-100（开仓建议≥75）\n")
 	sb.WriteString("- 开仓时必填: leverage, position_size_usd, stop_loss, take_profit, confidence, risk_usd\n")
+	sb.WriteString("- 开仓时可选: order_type (market 默认 | limit | post_only)，limit/post_only 必须提供 limit_price；limit 可选 time_in_force (GTC 默认 | IOC)。post_only 只做Maker，未成交的挂单会在若干周期后自动撤销\n")
+	sb.WriteString(fmt.Sprintf("- 开仓时可选: take_profit_levels 分批止盈（最多%d档，[{\"price\": 价格, \"percentage\": 平仓百分比}]，按离入场价由近到远排列，最后一档平掉剩余仓位，可省略 take_profit）；breakeven_after_tp1 为 true 时第一档成交后止损移到开仓价\n", MaxTakeProfitLevels))
 	sb.WriteString("- update_stop_loss 时必填: new_stop_loss (注意是 new_stop_loss，不是 stop_loss)\n")
 	sb.WriteString("- update_take_profit 时必填: new_take_profit (注意是 new_take_profit，不是 take_profit)\n")
-	sb.WriteString("- partial_close 时必填: close_percentage (0-100)\n\n")
+	sb.WriteString("- partial_close 时必填: close_percentage (0-100)\n")
+	sb.WriteString(fmt.Sprintf("- set_trailing_stop 时必填: callback_rate (%.1f-%.0f，回调百分比) 或 trailing_distance (回调价格距离) 二选一；可选 activation_price (多单需高于当前价，空单需低于当前价)。设置后替换该持仓原有的追踪止损；同一币种同时持有多空两个方向时不会执行\n\n", MinTrailingCallbackRate, MaxTrailingCallbackRate))
 
 	return sb.String()
 }
 
 // buildUserPrompt 构建 User Prompt（动态数据）
 func buildUserPrompt(ctx *Context) string {
+	return buildUserPromptWithOptions(ctx, userPromptOptions{})
+}
+
+// buildUserPromptWithOptions 按压缩选项构建 User Prompt（零值选项为完整输出）
+func buildUserPromptWithOptions(ctx *Context, opts userPromptOptions) string {
 	var sb strings.Builder
 
 	// 系统状态
@@ -472,7 +577,7 @@ func buildUserPrompt(ctx *Context) string {
 
 			// 使用FormatMarketData输出完整市场数据
 			if marketData, ok := ctx.MarketDataMap[pos.Symbol]; ok {
-				sb.WriteString(market.Format(marketData))
+				sb.WriteString(market.Format(opts.trimMarketData(marketData)))
 				sb.WriteString("\n")
 			}
 		}
@@ -480,14 +585,18 @@ func buildUserPrompt(ctx *Context) string {
 		sb.WriteString("当前持仓: 无\n\n")
 	}
 
-	// 候选币种（完整市场数据）
-	sb.WriteString(fmt.Sprintf("## 候选币种 (%d个)\n\n", len(ctx.MarketDataMap)))
+	// 候选币种（完整市场数据，预算不足时省略排名最低的若干个）
+	candidates := displayableCandidates(ctx)
+	if opts.dropCandidates > 0 {
+		dropped := min(opts.dropCandidates, len(candidates))
+		candidates = candidates[:len(candidates)-dropped]
+		sb.WriteString(fmt.Sprintf("## 候选币种 (%d个，因长度限制省略排名最低的%d个)\n\n", len(candidates), dropped))
+	} else {
+		sb.WriteString(fmt.Sprintf("## 候选币种 (%d个)\n\n", len(ctx.MarketDataMap)))
+	}
 	displayedCount := 0
-	for _, coin := range ctx.CandidateCoins {
-		marketData, hasData := ctx.MarketDataMap[coin.Symbol]
-		if !hasData {
-			continue
-		}
+	for _, coin := range candidates {
+		marketData := ctx.MarketDataMap[coin.Symbol]
 		displayedCount++
 
 		sourceTags := ""
@@ -499,23 +608,14 @@ func buildUserPrompt(ctx *Context) string {
 
 		// 使用FormatMarketData输出完整市场数据
 		sb.WriteString(fmt.Sprintf("### %d. %s%s\n\n", displayedCount, coin.Symbol, sourceTags))
-		sb.WriteString(market.Format(marketData))
+		sb.WriteString(market.Format(opts.trimMarketData(marketData)))
 		sb.WriteString("\n")
 	}
 	sb.WriteString("\n")
 
-	// 夏普比率（直接传值，不要复杂格式化）
+	// 夏普比率（直接传值，不要复杂格式化）和历史表现
 	if ctx.Performance != nil {
-		// 直接从interface{}中提取SharpeRatio
-		type PerformanceData struct {
-			SharpeRatio float64 `json:"sharpe_ratio"`
-		}
-		var perfData PerformanceData
-		if jsonData, err := json.Marshal(ctx.Performance); err == nil {
-			if err := json.Unmarshal(jsonData, &perfData); err == nil {
-				sb.WriteString(fmt.Sprintf("## 📊 夏普比率: %.2f\n\n", perfData.SharpeRatio))
-			}
-		}
+		sb.WriteString(formatPerformanceHistory(ctx.Performance, opts.performanceBrief))
 	}
 
 	sb.WriteString("---\n\n")
@@ -525,7 +625,7 @@ func buildUserPrompt(ctx *Context) string {
 }
 
 // parseFullDecisionResponse 解析AI的完整决策响应
-func parseFullDecisionResponse(aiResponse string, accountEquity float64, btcEthLeverage, altcoinLeverage int) (*FullDecision, error) {
+func parseFullDecisionResponse(aiResponse string, validator *decisionValidator) (*FullDecision, error) {
 	// 1. 提取思维链
 	cotTrace := extractCoTTrace(aiResponse)
 
@@ -539,7 +639,7 @@ func parseFullDecisionResponse(aiResponse string, accountEquity float64, btcEthL
 	}
 
 	// 3. 验证决策
-	if err := validateDecisions(decisions, accountEquity, btcEthLeverage, altcoinLeverage); err != nil {
+	if err := validator.validateAll(decisions); err != nil {
 		return &FullDecision{
 			CoTTrace:  cotTrace,
 			Decisions: decisions,
@@ -737,16 +837,6 @@ func compactArrayOpen(s string) string {
 	return reArrayOpenSpace.ReplaceAllString(strings.TrimSpace(s), "[{")
 }
 
-// validateDecisions 验证所有决策（需要账户信息和杠杆配置）
-func validateDecisions(decisions []Decision, accountEquity float64, btcEthLeverage, altcoinLeverage int) error {
-	for i, decision := range decisions {
-		if err := validateDecision(&decision, accountEquity, btcEthLeverage, altcoinLeverage); err != nil {
-			return fmt.Errorf("决策 #%d 验证失败: %w", i+1, err)
-		}
-	}
-	return nil
-}
-
 // findMatchingBracket 查找匹配的右括号
 func findMatchingBracket(s string, start int) int {
 	if start >= len(s) || s[start] != '[' {
@@ -769,142 +859,75 @@ func findMatchingBracket(s string, start int) int {
 	return -1
 }
 
-// validateDecision 验证单个决策的有效性
+// validateDecision 使用默认规则链验证单个决策（无行情数据，市价单跳过风险回报比检查）
 func validateDecision(d *Decision, accountEquity float64, btcEthLeverage, altcoinLeverage int) error {
-	// 验证action
-	validActions := map[string]bool{
-		"open_long":          true,
-		"open_short":         true,
-		"close_long":         true,
-		"close_short":        true,
-		"update_stop_loss":   true,
-		"update_take_profit": true,
-		"partial_close":      true,
-		"hold":               true,
-		"wait":               true,
-	}
-
-	if !validActions[d.Action] {
-		return fmt.Errorf("无效的action: %s", d.Action)
-	}
-
-	// 开仓操作必须提供完整参数
-	if d.Action == "open_long" || d.Action == "open_short" {
-		// 根据币种使用配置的杠杆上限
-		maxLeverage := altcoinLeverage          // 山寨币使用配置的杠杆
-		maxPositionValue := accountEquity * 100 // 山寨币最多1.5倍账户净值
-
-		if d.Symbol == "BTCUSDT" || d.Symbol == "ETHUSDT" || d.Symbol == "SOLUSDT" || d.Symbol == "BNBUSDT" {
-			maxLeverage = btcEthLeverage           // BTC和ETH等主币使用配置的杠杆
-			maxPositionValue = accountEquity * 200 // BTC/ETH最多10倍账户净值
-		}
-
-		// ✅ Fallback 机制：杠杆超限时自动修正为上限值（而不是直接拒绝决策）
-		if d.Leverage <= 0 {
-			return fmt.Errorf("杠杆必须大于0: %d", d.Leverage)
-		}
-		if d.Leverage > maxLeverage {
-			log.Printf("⚠️  [Leverage Fallback] %s 杠杆超限 (%dx > %dx)，自动调整为上限值 %dx",
-				d.Symbol, d.Leverage, maxLeverage, maxLeverage)
-			d.Leverage = maxLeverage // 自动修正为上限值
-		}
-		if d.PositionSizeUSD <= 0 {
-			return fmt.Errorf("仓位大小必须大于0: %.2f", d.PositionSizeUSD)
-		}
-
-		// ✅ 验证最小开仓金额（防止数量格式化为 0 的错误）
-		// Binance 最小名义价值 10 USDT + 安全边际
-		const minPositionSizeGeneral = 12.0 // 10 + 20% 安全边际
-		const minPositionSizeBTCETH = 60.0  // BTC/ETH 因价格高和精度限制需要更大金额（更灵活）
-		log.Printf("⚠️  [Position Size Check] %s 开仓金额 %.2f USDT 检查（最小 %.2f USDT）",
-			d.Symbol, d.PositionSizeUSD, minPositionSizeBTCETH)
-		// if d.Symbol == "BTCUSDT" || d.Symbol == "ETHUSDT" || d.Symbol == "SOLUSDT" || d.Symbol == "BNBUSDT" {
-		// 	if d.PositionSizeUSD < minPositionSizeBTCETH {
-		// 		return fmt.Errorf("%s 开仓金额过小(%.2f USDT)，必须≥%.2f USDT（因价格高且精度限制，避免数量四舍五入为0）", d.Symbol, d.PositionSizeUSD, minPositionSizeBTCETH)
-		// 	}
-		// } else {
-		// 	if d.PositionSizeUSD < minPositionSizeGeneral {
-		// 		return fmt.Errorf("开仓金额过小(%.2f USDT)，必须≥%.2f USDT（Binance 最小名义价值要求）", d.PositionSizeUSD, minPositionSizeGeneral)
-		// 	}
-		// }
-
-		// 验证仓位价值上限（加1%容差以避免浮点数精度问题）
-		tolerance := maxPositionValue * 0.01 // 1%容差
-		if d.PositionSizeUSD > maxPositionValue+tolerance {
-			if d.Symbol == "BTCUSDT" || d.Symbol == "ETHUSDT" || d.Symbol == "SOLUSDT" || d.Symbol == "BNBUSDT" {
-				return fmt.Errorf("BTC/ETH单币种仓位价值不能超过%.0f USDT（10倍账户净值），实际: %.0f", maxPositionValue, d.PositionSizeUSD)
-			} else {
-				return fmt.Errorf("山寨币单币种仓位价值不能超过%.0f USDT（1.5倍账户净值），实际: %.0f", maxPositionValue, d.PositionSizeUSD)
-			}
-		}
-		if d.StopLoss <= 0 || d.TakeProfit <= 0 {
-			return fmt.Errorf("止损和止盈必须大于0")
-		}
-
-		// 验证止损止盈的合理性
-		if d.Action == "open_long" {
-			if d.StopLoss >= d.TakeProfit {
-				return fmt.Errorf("做多时止损价必须小于止盈价")
-			}
-		} else {
-			if d.StopLoss <= d.TakeProfit {
-				return fmt.Errorf("做空时止损价必须大于止盈价")
-			}
-		}
+	return newDecisionValidator(accountEquity, btcEthLeverage, altcoinLeverage, nil, ValidationConfig{}).validate(d)
+}
 
-		// 验证风险回报比（必须≥1:3）
-		// 计算入场价（假设当前市价）
-		var entryPrice float64
-		if d.Action == "open_long" {
-			// 做多：入场价在止损和止盈之间
-			entryPrice = d.StopLoss + (d.TakeProfit-d.StopLoss)*0.2 // 假设在20%位置入场
-		} else {
-			// 做空：入场价在止损和止盈之间
-			entryPrice = d.StopLoss - (d.StopLoss-d.TakeProfit)*0.2 // 假设在20%位置入场
-		}
+// validateOrderType 校验并规范化开仓的下单方式（order_type/limit_price/time_in_force）
+func validateOrderType(d *Decision) error {
+	d.OrderType = strings.ToLower(strings.TrimSpace(d.OrderType))
+	d.TimeInForce = strings.ToUpper(strings.TrimSpace(d.TimeInForce))
 
-		var riskPercent, rewardPercent, riskRewardRatio float64
-		if d.Action == "open_long" {
-			riskPercent = (entryPrice - d.StopLoss) / entryPrice * 100
-			rewardPercent = (d.TakeProfit - entryPrice) / entryPrice * 100
-			if riskPercent > 0 {
-				riskRewardRatio = rewardPercent / riskPercent
-			}
-		} else {
-			riskPercent = (d.StopLoss - entryPrice) / entryPrice * 100
-			rewardPercent = (entryPrice - d.TakeProfit) / entryPrice * 100
-			if riskPercent > 0 {
-				riskRewardRatio = rewardPercent / riskPercent
-			}
-		}
-
-		// 硬约束：风险回报比必须≥3.0
-		if riskRewardRatio < 3.0 {
-			return fmt.Errorf("风险回报比过低(%.2f:1)，必须≥3.0:1 [风险:%.2f%% 收益:%.2f%%] [止损:%.2f 止盈:%.2f]",
-				riskRewardRatio, riskPercent, rewardPercent, d.StopLoss, d.TakeProfit)
-		}
+	switch d.OrderType {
+	case "", OrderTypeMarket:
+		d.OrderType = OrderTypeMarket
+		d.LimitPrice = 0
+		d.TimeInForce = ""
+		return nil
+	case OrderTypeLimit, OrderTypePostOnly:
+	default:
+		return fmt.Errorf("无效的order_type: %s（可选 market/limit/post_only）", d.OrderType)
 	}
 
-	// 动态调整止损验证
-	if d.Action == "update_stop_loss" {
-		if d.NewStopLoss <= 0 {
-			return fmt.Errorf("新止损价格必须大于0: %.2f", d.NewStopLoss)
-		}
+	if d.LimitPrice <= 0 {
+		return fmt.Errorf("%s 单必须提供 limit_price", d.OrderType)
 	}
 
-	// 动态调整止盈验证
-	if d.Action == "update_take_profit" {
-		if d.NewTakeProfit <= 0 {
-			return fmt.Errorf("新止盈价格必须大于0: %.2f", d.NewTakeProfit)
+	if d.OrderType == OrderTypePostOnly {
+		d.TimeInForce = ""
+	} else {
+		switch d.TimeInForce {
+		case "":
+			d.TimeInForce = TimeInForceGTC
+		case TimeInForceGTC, TimeInForceIOC:
+		default:
+			return fmt.Errorf("无效的time_in_force: %s（可选 GTC/IOC）", d.TimeInForce)
 		}
 	}
 
-	// 部分平仓验证
-	if d.Action == "partial_close" {
-		if d.ClosePercentage <= 0 || d.ClosePercentage > 100 {
-			return fmt.Errorf("平仓百分比必须在0-100之间: %.1f", d.ClosePercentage)
-		}
+	// 挂单价必须位于止损和止盈之间
+	if d.Action == "open_long" && (d.LimitPrice <= d.StopLoss || d.LimitPrice >= d.TakeProfit) {
+		return fmt.Errorf("做多限价 %.4f 必须位于止损 %.4f 与止盈 %.4f 之间", d.LimitPrice, d.StopLoss, d.TakeProfit)
 	}
+	if d.Action == "open_short" && (d.LimitPrice >= d.StopLoss || d.LimitPrice <= d.TakeProfit) {
+		return fmt.Errorf("做空限价 %.4f 必须位于止盈 %.4f 与止损 %.4f 之间", d.LimitPrice, d.TakeProfit, d.StopLoss)
+	}
+	return nil
+}
+
+// 追踪止损回调比例范围（百分比，与币安 TRAILING_STOP_MARKET 限制一致）
+const (
+	MinTrailingCallbackRate = 0.1
+	MaxTrailingCallbackRate = 10.0
+)
 
+// validateTrailingStop 校验追踪止损参数：callback_rate 与 trailing_distance 必须且只能提供一个
+func validateTrailingStop(d *Decision) error {
+	if d.CallbackRate > 0 && d.TrailingDistance > 0 {
+		return fmt.Errorf("callback_rate 与 trailing_distance 只能提供一个")
+	}
+	if d.CallbackRate <= 0 && d.TrailingDistance <= 0 {
+		return fmt.Errorf("set_trailing_stop 必须提供 callback_rate 或 trailing_distance")
+	}
+	if d.CallbackRate > 0 && (d.CallbackRate < MinTrailingCallbackRate || d.CallbackRate > MaxTrailingCallbackRate) {
+		return fmt.Errorf("callback_rate 必须在 %.1f-%.0f 之间: %.2f", MinTrailingCallbackRate, MaxTrailingCallbackRate, d.CallbackRate)
+	}
+	if d.TrailingDistance < 0 || d.CallbackRate < 0 {
+		return fmt.Errorf("追踪止损参数不能为负数")
+	}
+	if d.ActivationPrice < 0 {
+		return fmt.Errorf("activation_price 不能为负数: %.4f", d.ActivationPrice)
+	}
 	return nil
 }
@@ -0,0 +1,234 @@
+package decision
+
+import (
+	"fmt"
+	"log"
+	"nofx/mcp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 集成决策的投票方式
+const (
+	EnsembleVoteMajority  = "majority"            // 简单多数：超过半数成员给出同一决策
+	EnsembleVoteWeighted  = "confidence_weighted" // 信心度加权：按成员信心度加权后超过半数
+	EnsembleVoteUnanimous = "unanimous"           // 开仓需全体一致，其他决策按简单多数
+)
+
+// NormalizeEnsembleVote 规范化投票方式，空值为 majority
+func NormalizeEnsembleVote(vote string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(vote)) {
+	case "", EnsembleVoteMajority:
+		return EnsembleVoteMajority, nil
+	case EnsembleVoteWeighted:
+		return EnsembleVoteWeighted, nil
+	case EnsembleVoteUnanimous:
+		return EnsembleVoteUnanimous, nil
+	default:
+		return "", fmt.Errorf("无效的投票方式: %s（可选 majority/confidence_weighted/unanimous）", vote)
+	}
+}
+
+// EnsembleMember 集成决策中的一个模型
+type EnsembleMember struct {
+	Name   string // 成员名称（用于日志和缓存键）
+	Client mcp.AIClient
+}
+
+// MemberResult 单个成员的决策结果
+type MemberResult struct {
+	Name     string
+	Decision *FullDecision // 调用失败时可能为 nil；解析失败时保留已解析的部分
+	Err      error
+}
+
+// MemberDecider 获取单个成员的决策（回测通过它接入 AI 缓存）
+type MemberDecider func(member EnsembleMember) (*FullDecision, error)
+
+// DirectMemberDecider 直接调用成员模型获取决策
+func DirectMemberDecider(ctx *Context, customPrompt string, overrideBase bool, templateName string) MemberDecider {
+	return func(member EnsembleMember) (*FullDecision, error) {
+		return GetFullDecisionWithCustomPrompt(ctx, member.Client, customPrompt, overrideBase, templateName)
+	}
+}
+
+// GetEnsembleDecision 并发调用所有成员获取决策（共用同一个 Context），并按投票方式合并。
+// 返回合并后的决策和每个成员的原始结果；全部成员失败时返回错误
+func GetEnsembleDecision(ctx *Context, members []EnsembleMember, vote string, decide MemberDecider) (*FullDecision, []MemberResult, error) {
+	if ctx == nil {
+		return nil, nil, fmt.Errorf("context is nil")
+	}
+	if len(members) == 0 {
+		return nil, nil, fmt.Errorf("集成决策没有成员")
+	}
+
+	// 先在主协程准备好市场数据，避免成员并发拉取和写入 Context
+	if len(ctx.MarketDataMap) == 0 {
+		if err := fetchMarketDataForContext(ctx); err != nil {
+			return nil, nil, fmt.Errorf("获取市场数据失败: %w", err)
+		}
+	} else if ctx.OITopDataMap == nil {
+		ctx.OITopDataMap = make(map[string]*OITopData)
+	}
+
+	start := time.Now()
+	results := make([]MemberResult, len(members))
+	var wg sync.WaitGroup
+	for i, member := range members {
+		wg.Add(1)
+		go func(i int, member EnsembleMember) {
+			defer wg.Done()
+			fd, err := decide(member)
+			results[i] = MemberResult{Name: member.Name, Decision: fd, Err: err}
+			if err != nil {
+				log.Printf("⚠️  集成成员 %s 决策失败: %v", member.Name, err)
+			}
+		}(i, member)
+	}
+	wg.Wait()
+
+	merged, err := MergeEnsembleDecisions(results, vote)
+	if merged != nil {
+		merged.AIRequestDurationMs = time.Since(start).Milliseconds()
+	}
+	return merged, results, err
+}
+
+// ensembleBallot 同一币种同一动作的投票统计
+type ensembleBallot struct {
+	voters         int
+	weight         float64
+	representative Decision // 信心度最高的成员给出的决策，作为合并后的参数
+}
+
+// MergeEnsembleDecisions 按投票方式合并成员决策。
+// 每个成员对每个 (symbol, action) 最多投一票，hold/wait 视为弃权；失败的成员不计入投票人数。
+// 通过的决策取信心度最高的成员给出的参数，同一币种同时通过开多和开空时两者都丢弃
+func MergeEnsembleDecisions(results []MemberResult, vote string) (*FullDecision, error) {
+	vote, err := NormalizeEnsembleVote(vote)
+	if err != nil {
+		return nil, err
+	}
+
+	var valid []MemberResult
+	var cot strings.Builder
+	var failures []string
+	var rejections []RuleRejection
+	for _, r := range results {
+		if r.Decision != nil {
+			rejections = append(rejections, r.Decision.RuleRejections...)
+		}
+		if r.Err != nil || r.Decision == nil {
+			errMsg := "无决策"
+			if r.Err != nil {
+				errMsg = r.Err.Error()
+			}
+			failures = append(failures, fmt.Sprintf("%s: %s", r.Name, errMsg))
+			cot.WriteString(fmt.Sprintf("【%s】调用失败: %s\n\n", r.Name, errMsg))
+			continue
+		}
+		valid = append(valid, r)
+		cot.WriteString(fmt.Sprintf("【%s】\n%s\n\n", r.Name, r.Decision.CoTTrace))
+	}
+	if len(valid) == 0 {
+		return nil, fmt.Errorf("所有集成成员决策均失败: %s", strings.Join(failures, "; "))
+	}
+
+	type ballotKey struct{ symbol, action string }
+	ballots := make(map[ballotKey]*ensembleBallot)
+	var order []ballotKey
+	for _, r := range valid {
+		seen := make(map[ballotKey]bool)
+		for _, d := range r.Decision.Decisions {
+			if d.Action == "hold" || d.Action == "wait" {
+				continue
+			}
+			key := ballotKey{d.Symbol, d.Action}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			b, ok := ballots[key]
+			if !ok {
+				b = &ensembleBallot{representative: d}
+				ballots[key] = b
+				order = append(order, key)
+			} else if d.Confidence > b.representative.Confidence {
+				b.representative = d
+			}
+			b.voters++
+			b.weight += voteWeight(d)
+		}
+	}
+
+	total := len(valid)
+	passed := make(map[ballotKey]bool)
+	for _, key := range order {
+		b := ballots[key]
+		switch {
+		case vote == EnsembleVoteWeighted:
+			passed[key] = b.weight*2 > float64(total)
+		case vote == EnsembleVoteUnanimous && isOpenAction(key.action):
+			passed[key] = b.voters == total
+		default:
+			passed[key] = b.voters*2 > total
+		}
+	}
+
+	decisions := make([]Decision, 0)
+	for _, key := range order {
+		if !passed[key] {
+			continue
+		}
+		if isOpenAction(key.action) {
+			opposite := "open_short"
+			if key.action == "open_short" {
+				opposite = "open_long"
+			}
+			if passed[ballotKey{key.symbol, opposite}] {
+				log.Printf("⚠️  集成投票 %s 同时通过开多和开空，放弃开仓", key.symbol)
+				continue
+			}
+		}
+		b := ballots[key]
+		d := b.representative
+		d.Reasoning = fmt.Sprintf("[集成投票 %d/%d] %s", b.voters, total, d.Reasoning)
+		decisions = append(decisions, d)
+	}
+	if len(decisions) == 0 {
+		decisions = append(decisions, Decision{
+			Symbol:    "ALL",
+			Action:    "wait",
+			Reasoning: fmt.Sprintf("集成投票（%s）未形成一致决策，进入等待", vote),
+		})
+	}
+
+	first := valid[0].Decision
+	return &FullDecision{
+		SystemPrompt:   first.SystemPrompt,
+		UserPrompt:     first.UserPrompt,
+		CoTTrace:       strings.TrimSpace(cot.String()),
+		Decisions:      decisions,
+		Timestamp:      time.Now(),
+		RuleRejections: rejections,
+		PromptTokens:   first.PromptTokens,
+		PromptTrims:    first.PromptTrims,
+	}, nil
+}
+
+// voteWeight 信心度加权投票中的权重，未给出信心度时按满票计算
+func voteWeight(d Decision) float64 {
+	if d.Confidence <= 0 {
+		return 1
+	}
+	if d.Confidence >= 100 {
+		return 1
+	}
+	return float64(d.Confidence) / 100
+}
+
+func isOpenAction(action string) bool {
+	return action == "open_long" || action == "open_short"
+}
@@ -0,0 +1,137 @@
+package decision
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func memberResult(name string, decisions ...Decision) MemberResult {
+	return MemberResult{Name: name, Decision: &FullDecision{CoTTrace: name + " 分析", Decisions: decisions}}
+}
+
+func TestMergeEnsembleDecisions_Majority(t *testing.T) {
+	results := []MemberResult{
+		memberResult("a", Decision{Symbol: "BTCUSDT", Action: "open_long", Confidence: 70, Reasoning: "a"}),
+		memberResult("b", Decision{Symbol: "BTCUSDT", Action: "open_long", Confidence: 90, Reasoning: "b"}, Decision{Symbol: "ETHUSDT", Action: "close_short"}),
+		memberResult("c", Decision{Symbol: "ALL", Action: "wait"}),
+	}
+
+	merged, err := MergeEnsembleDecisions(results, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(merged.Decisions) != 1 {
+		t.Fatalf("只有 BTC 开多获得多数票，实际: %+v", merged.Decisions)
+	}
+	d := merged.Decisions[0]
+	if d.Action != "open_long" || d.Confidence != 90 {
+		t.Errorf("应采用信心度最高成员的参数: %+v", d)
+	}
+	if !strings.HasPrefix(d.Reasoning, "[集成投票 2/3]") {
+		t.Errorf("理由应标注票数: %q", d.Reasoning)
+	}
+	for _, name := range []string{"【a】", "【b】", "【c】"} {
+		if !strings.Contains(merged.CoTTrace, name) {
+			t.Errorf("思维链应包含成员 %s 的输出", name)
+		}
+	}
+}
+
+func TestMergeEnsembleDecisions_ConfidenceWeighted(t *testing.T) {
+	results := []MemberResult{
+		memberResult("a", Decision{Symbol: "BTCUSDT", Action: "open_short", Confidence: 70}),
+		memberResult("b", Decision{Symbol: "BTCUSDT", Action: "open_short", Confidence: 60}),
+		memberResult("c", Decision{Symbol: "ALL", Action: "wait"}),
+	}
+
+	// 多数票通过，但加权 (0.7+0.6)/3 < 0.5
+	merged, err := MergeEnsembleDecisions(results, EnsembleVoteWeighted)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(merged.Decisions) != 1 || merged.Decisions[0].Action != "wait" {
+		t.Errorf("加权票数不足时应等待: %+v", merged.Decisions)
+	}
+
+	results[1].Decision.Decisions[0].Confidence = 90
+	merged, _ = MergeEnsembleDecisions(results, EnsembleVoteWeighted)
+	if len(merged.Decisions) != 1 || merged.Decisions[0].Action != "open_short" {
+		t.Errorf("加权票数超过半数时应通过: %+v", merged.Decisions)
+	}
+}
+
+func TestMergeEnsembleDecisions_UnanimousOpens(t *testing.T) {
+	results := []MemberResult{
+		memberResult("a", Decision{Symbol: "BTCUSDT", Action: "open_long"}, Decision{Symbol: "ETHUSDT", Action: "close_long"}),
+		memberResult("b", Decision{Symbol: "BTCUSDT", Action: "open_long"}, Decision{Symbol: "ETHUSDT", Action: "close_long"}),
+		memberResult("c", Decision{Symbol: "ETHUSDT", Action: "hold"}),
+	}
+
+	merged, err := MergeEnsembleDecisions(results, EnsembleVoteUnanimous)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(merged.Decisions) != 1 || merged.Decisions[0].Action != "close_long" {
+		t.Errorf("开仓需全体一致，平仓按多数: %+v", merged.Decisions)
+	}
+}
+
+func TestMergeEnsembleDecisions_FailedMembersAndConflicts(t *testing.T) {
+	results := []MemberResult{
+		memberResult("a", Decision{Symbol: "BTCUSDT", Action: "open_long"}, Decision{Symbol: "BTCUSDT", Action: "open_short"}),
+		{Name: "b", Err: errors.New("timeout")},
+		memberResult("c", Decision{Symbol: "BTCUSDT", Action: "open_short"}, Decision{Symbol: "BTCUSDT", Action: "open_long"}),
+	}
+
+	merged, err := MergeEnsembleDecisions(results, EnsembleVoteMajority)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(merged.Decisions) != 1 || merged.Decisions[0].Action != "wait" {
+		t.Errorf("同一币种同时通过开多和开空时应放弃: %+v", merged.Decisions)
+	}
+	if !strings.Contains(merged.CoTTrace, "【b】调用失败: timeout") {
+		t.Errorf("思维链应记录失败成员: %q", merged.CoTTrace)
+	}
+
+	if _, err := MergeEnsembleDecisions([]MemberResult{{Name: "a", Err: errors.New("x")}}, ""); err == nil {
+		t.Error("所有成员失败时应返回错误")
+	}
+	if _, err := MergeEnsembleDecisions(results, "random"); err == nil {
+		t.Error("未知投票方式应返回错误")
+	}
+}
+
+func TestGetEnsembleDecision_CallsAllMembers(t *testing.T) {
+	reply := func(action string) string {
+		return "<reasoning>r</reasoning>\n<decision>\n```json\n[{\"symbol\": \"ETHUSDT\", \"action\": \"" + action + "\", \"reasoning\": \"x\"}]\n```\n</decision>"
+	}
+	clients := []*fakeAIClient{
+		{textResponse: reply("close_long")},
+		{textResponse: reply("close_long")},
+		{textResponse: reply("hold")},
+	}
+	members := make([]EnsembleMember, 0, len(clients))
+	for i, c := range clients {
+		members = append(members, EnsembleMember{Name: string(rune('a' + i)), Client: c})
+	}
+
+	ctx := newToolCallContext()
+	ctx.DecisionMode = DecisionModeText
+	merged, results, err := GetEnsembleDecision(ctx, members, EnsembleVoteMajority, DirectMemberDecider(ctx, "", false, ""))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i, c := range clients {
+		if c.textRequests != 1 {
+			t.Errorf("成员 %d 应被调用一次，实际 %d", i, c.textRequests)
+		}
+	}
+	if len(results) != 3 || results[2].Decision.RawResponse != reply("hold") {
+		t.Errorf("应返回每个成员的原始输出: %+v", results)
+	}
+	if len(merged.Decisions) != 1 || merged.Decisions[0].Action != "close_long" || merged.UserPrompt == "" {
+		t.Errorf("unexpected merged decision: %+v", merged)
+	}
+}
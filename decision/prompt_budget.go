@@ -0,0 +1,187 @@
+package decision
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"nofx/market"
+	"nofx/mcp"
+	"strings"
+)
+
+// compressedIntradayPoints 压缩时日内序列保留的最近点数
+const compressedIntradayPoints = 3
+
+// userPromptOptions 构建 User Prompt 时的压缩选项（零值为完整输出）
+type userPromptOptions struct {
+	intradayPoints   int  // 日内序列只保留最近 N 个点，0 表示不截断
+	performanceBrief bool // 历史表现只输出摘要
+	dropCandidates   int  // 省略排名最低的 N 个候选币
+}
+
+// trimMarketData 按选项截断日内序列，返回副本，不修改原数据
+func (o userPromptOptions) trimMarketData(data *market.Data) *market.Data {
+	if o.intradayPoints <= 0 || data == nil || data.IntradaySeries == nil {
+		return data
+	}
+	last := func(values []float64) []float64 {
+		return values[max(0, len(values)-o.intradayPoints):]
+	}
+	series := *data.IntradaySeries
+	series.MidPrices = last(series.MidPrices)
+	series.EMA20Values = last(series.EMA20Values)
+	series.MACDValues = last(series.MACDValues)
+	series.RSI7Values = last(series.RSI7Values)
+	series.RSI14Values = last(series.RSI14Values)
+	series.Volume = last(series.Volume)
+
+	trimmed := *data
+	trimmed.IntradaySeries = &series
+	return &trimmed
+}
+
+// displayableCandidates 有市场数据的候选币（按候选列表顺序，即排名从高到低）
+func displayableCandidates(ctx *Context) []CandidateCoin {
+	candidates := make([]CandidateCoin, 0, len(ctx.CandidateCoins))
+	for _, coin := range ctx.CandidateCoins {
+		if _, ok := ctx.MarketDataMap[coin.Symbol]; ok {
+			candidates = append(candidates, coin)
+		}
+	}
+	return candidates
+}
+
+// performanceHistory User Prompt 中使用的历史表现字段（来自 logger.PerformanceAnalysis）
+type performanceHistory struct {
+	TotalTrades  int     `json:"total_trades"`
+	WinRate      float64 `json:"win_rate"`
+	ProfitFactor float64 `json:"profit_factor"`
+	SharpeRatio  float64 `json:"sharpe_ratio"`
+	BestSymbol   string  `json:"best_symbol"`
+	WorstSymbol  string  `json:"worst_symbol"`
+	RecentTrades []struct {
+		Symbol      string  `json:"symbol"`
+		Side        string  `json:"side"`
+		PnL         float64 `json:"pn_l"`
+		PnLPct      float64 `json:"pn_l_pct"`
+		Duration    string  `json:"duration"`
+		WasStopLoss bool    `json:"was_stop_loss"`
+	} `json:"recent_trades"`
+}
+
+// formatPerformanceHistory 输出夏普比率和历史表现，brief 时只保留一行摘要
+func formatPerformanceHistory(performance interface{}, brief bool) string {
+	var perf performanceHistory
+	jsonData, err := json.Marshal(performance)
+	if err != nil {
+		return ""
+	}
+	if err := json.Unmarshal(jsonData, &perf); err != nil {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("## 📊 夏普比率: %.2f\n\n", perf.SharpeRatio))
+	if perf.TotalTrades == 0 {
+		return sb.String()
+	}
+	if brief {
+		sb.WriteString(fmt.Sprintf("历史表现: %d笔 | 胜率%.1f%% | 盈亏比%.2f\n\n", perf.TotalTrades, perf.WinRate, perf.ProfitFactor))
+		return sb.String()
+	}
+
+	sb.WriteString("## 历史表现\n")
+	sb.WriteString(fmt.Sprintf("总交易%d笔 | 胜率%.1f%% | 盈亏比%.2f", perf.TotalTrades, perf.WinRate, perf.ProfitFactor))
+	if perf.BestSymbol != "" {
+		sb.WriteString(fmt.Sprintf(" | 最佳%s | 最差%s", perf.BestSymbol, perf.WorstSymbol))
+	}
+	sb.WriteString("\n")
+	if len(perf.RecentTrades) > 0 {
+		sb.WriteString("最近交易（新→旧）:\n")
+		for _, trade := range perf.RecentTrades {
+			stopLoss := ""
+			if trade.WasStopLoss {
+				stopLoss = " [止损]"
+			}
+			sb.WriteString(fmt.Sprintf("- %s %s %+.2f%% (%+.2f USDT) 持仓%s%s\n",
+				trade.Symbol, strings.ToUpper(trade.Side), trade.PnLPct, trade.PnL, trade.Duration, stopLoss))
+		}
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// tokenEstimator 返回按模型估算 token 的函数，客户端未实现 mcp.TokenBudgeter 时使用通用估算
+func tokenEstimator(mcpClient mcp.AIClient) func(string) int {
+	if budgeter, ok := mcpClient.(mcp.TokenBudgeter); ok {
+		return budgeter.EstimateTokens
+	}
+	return func(text string) int { return mcp.EstimateTokens("", text) }
+}
+
+// promptTokenBudget 返回客户端的输入 prompt 预算，0 表示不限制
+func promptTokenBudget(mcpClient mcp.AIClient) int {
+	if budgeter, ok := mcpClient.(mcp.TokenBudgeter); ok {
+		return max(budgeter.PromptTokenBudget(), 0)
+	}
+	return 0
+}
+
+// fitUserPrompt 构建不超过 token 预算的 User Prompt。超出预算时依次缩短日内序列、
+// 把历史表现压缩为摘要、从排名最低的候选币开始逐个省略，返回估算的 prompt 总 token 数（含 System Prompt）和压缩记录
+func fitUserPrompt(ctx *Context, systemPrompt string, estimate func(string) int, budget int) (string, int, []string) {
+	systemTokens := estimate(systemPrompt)
+	opts := userPromptOptions{}
+	userPrompt := buildUserPromptWithOptions(ctx, opts)
+	tokens := systemTokens + estimate(userPrompt)
+	if budget <= 0 || tokens <= budget {
+		return userPrompt, tokens, nil
+	}
+
+	var trims []string
+	apply := func(next userPromptOptions, note string) {
+		prompt := buildUserPromptWithOptions(ctx, next)
+		nextTokens := systemTokens + estimate(prompt)
+		opts = next
+		if nextTokens < tokens {
+			trims = append(trims, fmt.Sprintf("%s（约 %d → %d tokens）", note, tokens, nextTokens))
+		}
+		userPrompt, tokens = prompt, nextTokens
+	}
+
+	next := opts
+	next.intradayPoints = compressedIntradayPoints
+	apply(next, fmt.Sprintf("日内序列缩短为最近 %d 个点", compressedIntradayPoints))
+
+	if tokens > budget {
+		next = opts
+		next.performanceBrief = true
+		apply(next, "历史表现压缩为摘要")
+	}
+
+	if tokens > budget {
+		candidates := displayableCandidates(ctx)
+		startTokens := tokens
+		for opts.dropCandidates < len(candidates) && tokens > budget {
+			next = opts
+			next.dropCandidates++
+			userPrompt = buildUserPromptWithOptions(ctx, next)
+			tokens = systemTokens + estimate(userPrompt)
+			opts = next
+		}
+		if opts.dropCandidates > 0 {
+			dropped := make([]string, 0, opts.dropCandidates)
+			for _, coin := range candidates[len(candidates)-opts.dropCandidates:] {
+				dropped = append(dropped, coin.Symbol)
+			}
+			trims = append(trims, fmt.Sprintf("省略排名最低的 %d 个候选币: %s（约 %d → %d tokens）",
+				opts.dropCandidates, strings.Join(dropped, ","), startTokens, tokens))
+		}
+	}
+
+	if tokens > budget {
+		trims = append(trims, fmt.Sprintf("压缩后仍超出预算（约 %d > %d tokens）", tokens, budget))
+	}
+	log.Printf("✂️  Prompt 超出 token 预算 %d，已压缩: %s", budget, strings.Join(trims, "; "))
+	return userPrompt, tokens, trims
+}
@@ -0,0 +1,114 @@
+package decision
+
+import (
+	"fmt"
+	"nofx/market"
+	"strings"
+	"testing"
+)
+
+// budgetAIClient 实现 mcp.TokenBudgeter 的测试客户端
+type budgetAIClient struct {
+	fakeAIClient
+	budget int
+}
+
+func (c *budgetAIClient) EstimateTokens(text string) int { return len(text) }
+func (c *budgetAIClient) PromptTokenBudget() int         { return c.budget }
+
+func newBudgetContext(candidates int) *Context {
+	series := &market.IntradayData{
+		MidPrices:   []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+		EMA20Values: []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+		Volume:      []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+	}
+	ctx := &Context{
+		Account:       AccountInfo{TotalEquity: 1000, AvailableBalance: 1000},
+		MarketDataMap: map[string]*market.Data{},
+		Performance: map[string]any{
+			"total_trades": 3, "win_rate": 66.7, "profit_factor": 2.1, "sharpe_ratio": 0.8,
+			"recent_trades": []map[string]any{{"symbol": "BTCUSDT", "side": "long", "pn_l": 12.5, "pn_l_pct": 3.2, "duration": "2h"}},
+		},
+		BTCETHLeverage:  10,
+		AltcoinLeverage: 5,
+	}
+	for i := 1; i <= candidates; i++ {
+		symbol := fmt.Sprintf("COIN%dUSDT", i)
+		ctx.CandidateCoins = append(ctx.CandidateCoins, CandidateCoin{Symbol: symbol, Sources: []string{"ai500"}})
+		ctx.MarketDataMap[symbol] = &market.Data{Symbol: symbol, CurrentPrice: 10, IntradaySeries: series}
+	}
+	return ctx
+}
+
+func TestFitUserPrompt_WithinBudget(t *testing.T) {
+	ctx := newBudgetContext(3)
+	estimate := func(s string) int { return len(s) }
+
+	prompt, tokens, trims := fitUserPrompt(ctx, "system", estimate, 0)
+	if prompt != buildUserPrompt(ctx) || trims != nil {
+		t.Errorf("未设置预算时不压缩: %v", trims)
+	}
+	if tokens != len("system")+len(prompt) {
+		t.Errorf("应估算 system + user 的 token 数，实际 %d", tokens)
+	}
+	if !strings.Contains(prompt, "## 历史表现") || !strings.Contains(prompt, "- BTCUSDT LONG +3.20% (+12.50 USDT) 持仓2h") {
+		t.Errorf("完整 prompt 应包含历史表现和最近交易:\n%s", prompt)
+	}
+}
+
+func TestFitUserPrompt_TrimsInOrder(t *testing.T) {
+	ctx := newBudgetContext(5)
+	estimate := func(s string) int { return len(s) }
+	full := len(buildUserPrompt(ctx))
+
+	// 只需缩短日内序列即可满足预算
+	intraday := len(buildUserPromptWithOptions(ctx, userPromptOptions{intradayPoints: compressedIntradayPoints}))
+	prompt, tokens, trims := fitUserPrompt(ctx, "", estimate, intraday)
+	if len(trims) != 1 || !strings.Contains(trims[0], "日内序列缩短为最近 3 个点") || tokens > intraday {
+		t.Errorf("unexpected trims: %v (%d tokens)", trims, tokens)
+	}
+	if !strings.Contains(prompt, "Mid prices: [8.0000, 9.0000, 10.0000]") || !strings.Contains(prompt, "## 历史表现") {
+		t.Errorf("日内序列应只保留最近 3 个点，历史表现保持完整:\n%s", prompt)
+	}
+	if len(ctx.MarketDataMap["COIN1USDT"].IntradaySeries.MidPrices) != 10 {
+		t.Error("压缩不应修改原始市场数据")
+	}
+
+	// 预算很紧时继续压缩历史表现并省略排名最低的候选币
+	budget := full / 2
+	prompt, tokens, trims = fitUserPrompt(ctx, "", estimate, budget)
+	if tokens > budget || len(trims) != 3 {
+		t.Fatalf("应依次执行三步压缩: %v (%d > %d)", trims, tokens, budget)
+	}
+	if !strings.Contains(trims[1], "历史表现压缩为摘要") || !strings.Contains(trims[2], "COIN5USDT") {
+		t.Errorf("unexpected trims: %v", trims)
+	}
+	if strings.Contains(prompt, "COIN5USDT") || !strings.Contains(prompt, "COIN1USDT") || !strings.Contains(prompt, "省略排名最低的") {
+		t.Errorf("应从排名最低的候选币开始省略:\n%s", prompt)
+	}
+	if strings.Contains(prompt, "最近交易") || !strings.Contains(prompt, "历史表现: 3笔 | 胜率66.7% | 盈亏比2.10") {
+		t.Errorf("历史表现应压缩为一行摘要:\n%s", prompt)
+	}
+
+	// 无法压缩到预算内时记录超出情况
+	_, _, trims = fitUserPrompt(ctx, "", estimate, 10)
+	if !strings.Contains(trims[len(trims)-1], "压缩后仍超出预算") {
+		t.Errorf("应记录仍超出预算: %v", trims)
+	}
+}
+
+func TestGetFullDecision_RecordsPromptTokens(t *testing.T) {
+	reply := "<reasoning>观望</reasoning>\n<decision>\n```json\n[{\"symbol\": \"ALL\", \"action\": \"wait\", \"reasoning\": \"观望\"}]\n```\n</decision>"
+	client := &budgetAIClient{fakeAIClient: fakeAIClient{textResponse: reply}, budget: 1}
+
+	fd, err := GetFullDecisionWithCustomPrompt(newBudgetContext(3), client, "", false, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fd.PromptTokens != len(fd.SystemPrompt)+len(fd.UserPrompt) {
+		t.Errorf("PromptTokens 应为 system + user 的估算值，实际 %d", fd.PromptTokens)
+	}
+	if len(fd.PromptTrims) == 0 || strings.Contains(fd.UserPrompt, "COIN1USDT") {
+		t.Errorf("超出预算时应记录压缩内容: %v", fd.PromptTrims)
+	}
+}
@@ -1,18 +1,35 @@
 package decision
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
+	"text/template"
 )
 
 // PromptTemplate 系统提示词模板
 type PromptTemplate struct {
 	Name    string // 模板名称（文件名，不含扩展名）
-	Content string // 模板内容
+	Content string // 模板内容（text/template 语法，数据模型见 PromptData）
+	Hash    string // 模板原文的 SHA-256 哈希，用于追踪提示词版本
+
+	tmpl *template.Template
+}
+
+// Render 使用给定数据渲染模板
+func (t *PromptTemplate) Render(data *PromptData) (string, error) {
+	if t.tmpl == nil {
+		return t.Content, nil
+	}
+	var sb strings.Builder
+	if err := t.tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("渲染提示词模板 %s 失败: %w", t.Name, err)
+	}
+	return sb.String(), nil
 }
 
 // PromptManager 提示词管理器
@@ -45,7 +62,9 @@ func NewPromptManager() *PromptManager {
 	}
 }
 
-// LoadTemplates 从指定目录加载所有提示词模板
+// LoadTemplates 从指定目录加载所有提示词模板。
+// 每个模板都会按 text/template 解析并用示例数据试渲染，校验失败的模板不会被加载，
+// 其余模板照常加载，失败原因汇总后返回。
 func (pm *PromptManager) LoadTemplates(dir string) error {
 	pm.mu.Lock()
 	defer pm.mu.Unlock()
@@ -67,6 +86,7 @@ func (pm *PromptManager) LoadTemplates(dir string) error {
 	}
 
 	// 加载每个模板文件
+	var invalid []error
 	for _, file := range files {
 		// 读取文件内容
 		content, err := os.ReadFile(file)
@@ -79,15 +99,27 @@ func (pm *PromptManager) LoadTemplates(dir string) error {
 		fileName := filepath.Base(file)
 		templateName := strings.TrimSuffix(fileName, filepath.Ext(fileName))
 
+		tmpl, err := parsePromptTemplate(templateName, string(content))
+		if err != nil {
+			log.Printf("⚠️  提示词模板校验失败 %s: %v", file, err)
+			invalid = append(invalid, fmt.Errorf("%s: %w", fileName, err))
+			continue
+		}
+
 		// 存储模板
 		pm.templates[templateName] = &PromptTemplate{
 			Name:    templateName,
 			Content: string(content),
+			Hash:    HashPrompt(string(content)),
+			tmpl:    tmpl,
 		}
 
 		log.Printf("  📄 加载提示词模板: %s (%s)", templateName, fileName)
 	}
 
+	if len(invalid) > 0 {
+		return fmt.Errorf("%d 个提示词模板校验失败: %w", len(invalid), errors.Join(invalid...))
+	}
 	return nil
 }
 
@@ -0,0 +1,201 @@
+package decision
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// 提示词模板使用 Go text/template 语法渲染，模板中可引用 PromptData 的字段，例如：
+//
+//	账户净值 {{usd .Account.TotalEquity}}，山寨币最大 {{.Leverage.Altcoin}}x 杠杆
+//	{{range .Positions}}- {{.Symbol}} {{upper .Side}} {{pct .UnrealizedPnLPct}}{{end}}
+//	{{if eq .Market.Regime "downtrend"}}BTC 处于下跌趋势，谨慎做多{{end}}
+//
+// 不包含 {{ }} 的纯文本模板渲染结果与原文一致。
+
+// 市场状态（基于 BTC 4小时涨跌幅与 EMA20 判断）
+const (
+	MarketRegimeUptrend   = "uptrend"   // 4h涨幅 ≥ 2% 且价格在 EMA20 之上
+	MarketRegimeDowntrend = "downtrend" // 4h跌幅 ≥ 2% 且价格在 EMA20 之下
+	MarketRegimeRange     = "range"     // 其余情况视为震荡
+	MarketRegimeUnknown   = "unknown"   // 缺少 BTC 行情数据
+
+	regimeTrendThresholdPct = 2.0
+)
+
+// PromptData 提示词模板的数据模型
+type PromptData struct {
+	CurrentTime    string            `json:"current_time"`    // 当前时间
+	Variant        string            `json:"variant"`         // 交易模式变体（aggressive / conservative / scalping）
+	Account        AccountInfo       `json:"account"`         // 账户信息
+	Leverage       PromptLeverage    `json:"leverage"`        // 杠杆上限
+	Positions      []PositionInfo    `json:"positions"`       // 当前持仓
+	CandidateCoins []CandidateCoin   `json:"candidate_coins"` // 候选币种
+	Performance    PromptPerformance `json:"performance"`     // 历史表现摘要
+	Market         MarketRegime      `json:"market"`          // 市场状态（BTC）
+}
+
+// PromptLeverage 杠杆上限
+type PromptLeverage struct {
+	BTCETH  int `json:"btc_eth"` // BTC/ETH 最大杠杆
+	Altcoin int `json:"altcoin"` // 山寨币最大杠杆
+}
+
+// PromptPerformance 历史表现摘要（来自 logger.PerformanceAnalysis）
+type PromptPerformance struct {
+	Available    bool    `json:"available"` // 是否有历史表现数据
+	TotalTrades  int     `json:"total_trades"`
+	WinRate      float64 `json:"win_rate"`
+	ProfitFactor float64 `json:"profit_factor"`
+	SharpeRatio  float64 `json:"sharpe_ratio"`
+}
+
+// MarketRegime 市场状态
+type MarketRegime struct {
+	Regime    string  `json:"regime"` // uptrend | downtrend | range | unknown
+	BTCPrice  float64 `json:"btc_price"`
+	Change1h  float64 `json:"change_1h"` // 百分比
+	Change4h  float64 `json:"change_4h"` // 百分比
+	EMA20     float64 `json:"ema20"`
+	RSI7      float64 `json:"rsi7"`
+	FundingBP float64 `json:"funding_bp"` // 资金费率（基点）
+}
+
+// promptFuncs 模板辅助函数
+//
+//	usd x            → "1234.56 USDT"
+//	pct x            → "+1.23%"（带符号）
+//	fixed n x        → 保留 n 位小数
+//	mul a b / div a b → 浮点乘除（除数为 0 时返回 0）
+//	upper s / lower s → 大小写转换
+//	join sep list    → 用 sep 连接字符串列表
+var promptFuncs = template.FuncMap{
+	"usd": func(v float64) string { return fmt.Sprintf("%.2f USDT", v) },
+	"pct": func(v float64) string { return fmt.Sprintf("%+.2f%%", v) },
+	"fixed": func(n int, v float64) string {
+		return strconv.FormatFloat(v, 'f', n, 64)
+	},
+	"mul": func(a, b float64) float64 { return a * b },
+	"div": func(a, b float64) float64 {
+		if b == 0 {
+			return 0
+		}
+		return a / b
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"join": func(sep string, items []string) string {
+		return strings.Join(items, sep)
+	},
+}
+
+// parsePromptTemplate 解析模板并用示例数据试渲染一次，确保引用的字段和函数都存在
+func parsePromptTemplate(name, content string) (*template.Template, error) {
+	tmpl, err := template.New(name).Funcs(promptFuncs).Option("missingkey=error").Parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("解析模板失败: %w", err)
+	}
+	if err := tmpl.Execute(io.Discard, samplePromptData()); err != nil {
+		return nil, fmt.Errorf("渲染模板失败: %w", err)
+	}
+	return tmpl, nil
+}
+
+// samplePromptData 用于加载时校验模板的示例数据
+func samplePromptData() *PromptData {
+	return &PromptData{
+		CurrentTime: "2025-01-01 00:00:00",
+		Account:     AccountInfo{TotalEquity: 1000, AvailableBalance: 800, MarginUsed: 200, MarginUsedPct: 20, PositionCount: 1},
+		Leverage:    PromptLeverage{BTCETH: 10, Altcoin: 5},
+		Positions: []PositionInfo{
+			{Symbol: "BTCUSDT", Side: "long", EntryPrice: 95000, MarkPrice: 96000, Quantity: 0.01, Leverage: 5, UnrealizedPnLPct: 5.26},
+		},
+		CandidateCoins: []CandidateCoin{{Symbol: "ETHUSDT", Sources: []string{"ai500"}}},
+		Performance:    PromptPerformance{Available: true, TotalTrades: 10, WinRate: 60, ProfitFactor: 1.5, SharpeRatio: 0.8},
+		Market:         MarketRegime{Regime: MarketRegimeRange, BTCPrice: 96000, Change1h: 0.3, Change4h: -0.5, EMA20: 95800, RSI7: 55},
+	}
+}
+
+// NewPromptData 从交易上下文构建模板数据
+func NewPromptData(ctx *Context) *PromptData {
+	data := newStaticPromptData(ctx.Account.TotalEquity, ctx.BTCETHLeverage, ctx.AltcoinLeverage, ctx.PromptVariant)
+	data.CurrentTime = ctx.CurrentTime
+	data.Account = ctx.Account
+	data.Positions = ctx.Positions
+	data.CandidateCoins = ctx.CandidateCoins
+
+	if ctx.Performance != nil {
+		if raw, err := json.Marshal(ctx.Performance); err == nil {
+			if err := json.Unmarshal(raw, &data.Performance); err == nil {
+				data.Performance.Available = true
+			}
+		}
+	}
+
+	if btc, ok := ctx.MarketDataMap["BTCUSDT"]; ok && btc != nil {
+		data.Market = MarketRegime{
+			Regime:    classifyMarketRegime(btc.CurrentPrice, btc.CurrentEMA20, btc.PriceChange4h),
+			BTCPrice:  btc.CurrentPrice,
+			Change1h:  btc.PriceChange1h,
+			Change4h:  btc.PriceChange4h,
+			EMA20:     btc.CurrentEMA20,
+			RSI7:      btc.CurrentRSI7,
+			FundingBP: btc.FundingRate * 10000,
+		}
+	}
+	return data
+}
+
+// newStaticPromptData 仅包含净值、杠杆和变体的模板数据（无交易上下文时使用）
+func newStaticPromptData(accountEquity float64, btcEthLeverage, altcoinLeverage int, variant string) *PromptData {
+	return &PromptData{
+		CurrentTime: time.Now().Format("2006-01-02 15:04:05"),
+		Variant:     strings.ToLower(strings.TrimSpace(variant)),
+		Account:     AccountInfo{TotalEquity: accountEquity},
+		Leverage:    PromptLeverage{BTCETH: btcEthLeverage, Altcoin: altcoinLeverage},
+		Market:      MarketRegime{Regime: MarketRegimeUnknown},
+	}
+}
+
+// classifyMarketRegime 根据 4h 涨跌幅和价格相对 EMA20 的位置判断市场状态
+func classifyMarketRegime(price, ema20, change4h float64) string {
+	if price <= 0 || ema20 <= 0 {
+		return MarketRegimeUnknown
+	}
+	switch {
+	case change4h >= regimeTrendThresholdPct && price > ema20:
+		return MarketRegimeUptrend
+	case change4h <= -regimeTrendThresholdPct && price < ema20:
+		return MarketRegimeDowntrend
+	default:
+		return MarketRegimeRange
+	}
+}
+
+// RenderPromptTemplate 使用交易上下文渲染指定模板（用于预览）。上下文缺少行情数据时会先拉取。
+func RenderPromptTemplate(name string, ctx *Context) (string, *PromptData, error) {
+	tmpl, err := GetPromptTemplate(name)
+	if err != nil {
+		return "", nil, err
+	}
+	if ctx == nil {
+		return "", nil, fmt.Errorf("context is nil")
+	}
+	if len(ctx.MarketDataMap) == 0 {
+		if err := fetchMarketDataForContext(ctx); err != nil {
+			return "", nil, fmt.Errorf("获取市场数据失败: %w", err)
+		}
+	}
+
+	data := NewPromptData(ctx)
+	content, err := tmpl.Render(data)
+	if err != nil {
+		return "", data, err
+	}
+	return content, data, nil
+}
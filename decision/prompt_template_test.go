@@ -0,0 +1,123 @@
+package decision
+
+import (
+	"nofx/market"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// usePromptsDir 切换全局模板目录，测试结束后恢复
+func usePromptsDir(t *testing.T, files map[string]string) {
+	t.Helper()
+	originalDir := promptsDir
+	t.Cleanup(func() {
+		promptsDir = originalDir
+		globalPromptManager.ReloadTemplates(originalDir)
+	})
+
+	promptsDir = t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(promptsDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("创建文件失败: %v", err)
+		}
+	}
+	ReloadPromptTemplates()
+}
+
+func TestLoadTemplates_ValidatesTemplateSyntax(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"good.txt":    "净值 {{usd .Account.TotalEquity}}，山寨币 {{.Leverage.Altcoin}}x",
+		"unknown.txt": "{{.Account.Nope}}",
+		"broken.txt":  "{{if .Variant}}未闭合",
+		"nofunc.txt":  "{{money .Account.TotalEquity}}",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("创建文件失败: %v", err)
+		}
+	}
+
+	pm := NewPromptManager()
+	err := pm.LoadTemplates(dir)
+	if err == nil || !strings.Contains(err.Error(), "3 个提示词模板校验失败") {
+		t.Fatalf("应汇总校验失败的模板，实际: %v", err)
+	}
+	for _, name := range []string{"unknown.txt", "broken.txt", "nofunc.txt"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("错误信息应包含 %s: %v", name, err)
+		}
+	}
+
+	names := pm.GetAllTemplateNames()
+	if len(names) != 1 || names[0] != "good" {
+		t.Errorf("只有合法模板应被加载，实际: %v", names)
+	}
+}
+
+func TestBuildSystemPrompt_RendersContextData(t *testing.T) {
+	usePromptsDir(t, map[string]string{
+		"ctx.txt": "净值 {{usd .Account.TotalEquity}} | 杠杆 {{.Leverage.BTCETH}}/{{.Leverage.Altcoin}} | " +
+			"持仓 {{range .Positions}}{{upper .Side}} {{.Symbol}} {{pct .UnrealizedPnLPct}}{{end}} | " +
+			"候选 {{range .CandidateCoins}}{{.Symbol}}({{join \"+\" .Sources}}){{end}} | " +
+			"夏普 {{fixed 2 .Performance.SharpeRatio}} | 市场 {{.Market.Regime}} {{pct .Market.Change4h}}",
+	})
+
+	ctx := &Context{
+		Account:         AccountInfo{TotalEquity: 1234.5},
+		Positions:       []PositionInfo{{Symbol: "ETHUSDT", Side: "long", UnrealizedPnLPct: 3.5}},
+		CandidateCoins:  []CandidateCoin{{Symbol: "SOLUSDT", Sources: []string{"ai500", "oi_top"}}},
+		Performance:     map[string]any{"sharpe_ratio": 0.456, "total_trades": 12},
+		MarketDataMap:   map[string]*market.Data{"BTCUSDT": {CurrentPrice: 100000, CurrentEMA20: 98000, PriceChange4h: 2.5}},
+		BTCETHLeverage:  10,
+		AltcoinLeverage: 5,
+	}
+
+	prompt := buildSystemPromptForData(NewPromptData(ctx), "", false, "ctx")
+	want := "净值 1234.50 USDT | 杠杆 10/5 | 持仓 LONG ETHUSDT +3.50% | 候选 SOLUSDT(ai500+oi_top) | 夏普 0.46 | 市场 uptrend +2.50%"
+	if !strings.HasPrefix(prompt, want) {
+		t.Errorf("模板应使用上下文渲染\n期望前缀: %s\n实际: %s", want, prompt)
+	}
+	if !strings.Contains(prompt, "# 硬约束（风险控制）") {
+		t.Error("渲染后的模板仍应追加硬约束部分")
+	}
+}
+
+func TestRenderPromptTemplate(t *testing.T) {
+	usePromptsDir(t, map[string]string{
+		"regime.txt": "{{if eq .Market.Regime \"downtrend\"}}只做空{{else}}多空皆可{{end}}",
+	})
+
+	ctx := &Context{MarketDataMap: map[string]*market.Data{"BTCUSDT": {CurrentPrice: 90000, CurrentEMA20: 95000, PriceChange4h: -3}}}
+	content, data, err := RenderPromptTemplate("regime", ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if content != "只做空" || data.Market.Regime != MarketRegimeDowntrend {
+		t.Errorf("unexpected render: %q %+v", content, data.Market)
+	}
+
+	if _, _, err := RenderPromptTemplate("missing", ctx); err == nil {
+		t.Error("模板不存在时应返回错误")
+	}
+}
+
+func TestClassifyMarketRegime(t *testing.T) {
+	tests := []struct {
+		price, ema20, change4h float64
+		want                   string
+	}{
+		{101, 100, 2, MarketRegimeUptrend},
+		{99, 100, -2.5, MarketRegimeDowntrend},
+		{99, 100, 3, MarketRegimeRange},
+		{101, 100, 1, MarketRegimeRange},
+		{0, 100, 5, MarketRegimeUnknown},
+	}
+	for _, tt := range tests {
+		if got := classifyMarketRegime(tt.price, tt.ema20, tt.change4h); got != tt.want {
+			t.Errorf("classifyMarketRegime(%v, %v, %v) = %s, want %s", tt.price, tt.ema20, tt.change4h, got, tt.want)
+		}
+	}
+}
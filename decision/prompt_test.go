@@ -16,6 +16,7 @@ func TestBuildSystemPrompt_ContainsAllValidActions(t *testing.T) {
 		"update_stop_loss",
 		"update_take_profit",
 		"partial_close",
+		"set_trailing_stop",
 		"hold",
 		"wait",
 	}
@@ -0,0 +1,196 @@
+package decision
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"strings"
+)
+
+// 提示词 A/B 实验分组方式
+const (
+	PromptSplitCycle  = "cycle"  // 按决策周期交替：奇数周期 A 组，偶数周期 B 组
+	PromptSplitSymbol = "symbol" // 按币种固定分组：每个周期两组各自分析自己的币种
+
+	PromptArmA = "A" // 交易员当前的提示词配置
+	PromptArmB = "B" // 实验配置
+)
+
+// HashPrompt 计算提示词内容的 SHA-256 哈希（十六进制）
+func HashPrompt(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// PromptVersion 一次决策使用的提示词版本（模板 + 自定义提示词）
+type PromptVersion struct {
+	Hash         string `json:"hash"`                    // 版本哈希（由模板哈希、自定义提示词哈希和覆盖标志组合得到）
+	Arm          string `json:"arm,omitempty"`           // A/B 实验分组（未开启实验时为空）
+	Template     string `json:"template,omitempty"`      // 实际使用的模板名称
+	TemplateHash string `json:"template_hash,omitempty"` // 模板原文哈希
+	CustomHash   string `json:"custom_hash,omitempty"`   // 自定义提示词哈希
+	OverrideBase bool   `json:"override_base,omitempty"` // 是否只使用自定义提示词
+}
+
+// ResolvePromptVersion 解析给定提示词配置实际使用的版本，模板回退规则与构建 System Prompt 时一致
+func ResolvePromptVersion(arm, templateName, customPrompt string, overrideBase bool) PromptVersion {
+	version := PromptVersion{Arm: arm}
+	if customPrompt != "" {
+		version.CustomHash = HashPrompt(customPrompt)
+		version.OverrideBase = overrideBase
+	}
+	if !version.OverrideBase {
+		if tmpl := resolvePromptTemplate(templateName); tmpl != nil {
+			version.Template = tmpl.Name
+			version.TemplateHash = tmpl.Hash
+		}
+	}
+
+	key := fmt.Sprintf("%s|%s|%t", version.TemplateHash, version.CustomHash, version.OverrideBase)
+	version.Hash = HashPrompt(key)[:12]
+	return version
+}
+
+// resolvePromptTemplate 获取指定模板，不存在时回退到 default
+func resolvePromptTemplate(templateName string) *PromptTemplate {
+	if templateName == "" {
+		templateName = "default"
+	}
+	if tmpl, err := GetPromptTemplate(templateName); err == nil {
+		return tmpl
+	}
+	if tmpl, err := GetPromptTemplate("default"); err == nil {
+		return tmpl
+	}
+	return nil
+}
+
+// PromptExperiment 提示词 A/B 实验（按交易员保存为 JSON）。
+// A 组使用交易员当前的模板和自定义提示词，B 组使用这里配置的提示词。
+type PromptExperiment struct {
+	Name               string `json:"name,omitempty"`                 // 实验名称
+	Split              string `json:"split"`                          // cycle | symbol
+	Template           string `json:"template,omitempty"`             // B 组模板，空表示沿用 A 组模板
+	CustomPrompt       string `json:"custom_prompt,omitempty"`        // B 组自定义提示词
+	OverrideBasePrompt bool   `json:"override_base_prompt,omitempty"` // B 组是否只使用自定义提示词
+}
+
+// Validate 校验实验配置
+func (e *PromptExperiment) Validate() error {
+	switch e.Split {
+	case PromptSplitCycle, PromptSplitSymbol:
+	default:
+		return fmt.Errorf("无效的 A/B 分组方式: %s（可选 %s/%s）", e.Split, PromptSplitCycle, PromptSplitSymbol)
+	}
+	if e.Template == "" && e.CustomPrompt == "" {
+		return fmt.Errorf("B 组必须设置 template 或 custom_prompt")
+	}
+	if e.OverrideBasePrompt && e.CustomPrompt == "" {
+		return fmt.Errorf("override_base_prompt 需要同时设置 custom_prompt")
+	}
+	return nil
+}
+
+// ParsePromptExperiment 解析并校验交易员配置中的 A/B 实验（JSON 对象），空字符串表示不开启实验
+func ParsePromptExperiment(raw string) (*PromptExperiment, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+	var exp PromptExperiment
+	if err := json.Unmarshal([]byte(raw), &exp); err != nil {
+		return nil, fmt.Errorf("A/B 实验配置格式错误: %w", err)
+	}
+	exp.Split = strings.ToLower(strings.TrimSpace(exp.Split))
+	if exp.Split == "" {
+		exp.Split = PromptSplitCycle
+	}
+	exp.Template = strings.TrimSpace(exp.Template)
+	if err := exp.Validate(); err != nil {
+		return nil, err
+	}
+	return &exp, nil
+}
+
+// ArmForCycle 按周期编号分组（周期从 1 开始）
+func (e *PromptExperiment) ArmForCycle(cycle int) string {
+	if cycle%2 == 0 {
+		return PromptArmB
+	}
+	return PromptArmA
+}
+
+// ArmForSymbol 按币种分组，同一币种始终落在同一组
+func (e *PromptExperiment) ArmForSymbol(symbol string) string {
+	h := fnv.New32a()
+	h.Write([]byte(strings.ToUpper(symbol)))
+	if h.Sum32()%2 == 1 {
+		return PromptArmB
+	}
+	return PromptArmA
+}
+
+// SplitContextBySymbol 按币种分组拆分交易上下文：持仓和候选币按所属分组分配，账户信息两组共享。
+// 行情数据不拷贝，由各组按自己的币种重新获取。
+func (e *PromptExperiment) SplitContextBySymbol(ctx *Context) map[string]*Context {
+	split := make(map[string]*Context, 2)
+	for _, arm := range []string{PromptArmA, PromptArmB} {
+		sub := *ctx
+		sub.Positions = nil
+		sub.CandidateCoins = nil
+		sub.MarketDataMap = nil
+		sub.MultiTFMarket = nil
+		sub.OITopDataMap = nil
+		split[arm] = &sub
+	}
+	for _, pos := range ctx.Positions {
+		sub := split[e.ArmForSymbol(pos.Symbol)]
+		sub.Positions = append(sub.Positions, pos)
+	}
+	for _, coin := range ctx.CandidateCoins {
+		sub := split[e.ArmForSymbol(coin.Symbol)]
+		sub.CandidateCoins = append(sub.CandidateCoins, coin)
+	}
+	return split
+}
+
+// MergeArmDecisions 合并按币种分组的 A/B 两组决策。某一组失败时只使用另一组的决策，两组都失败时返回错误。
+func MergeArmDecisions(results []MemberResult) (*FullDecision, error) {
+	merged := &FullDecision{}
+	var (
+		cot, systemPrompts, userPrompts []string
+		errs                            []string
+	)
+	for _, r := range results {
+		if r.Decision != nil {
+			merged.RuleRejections = append(merged.RuleRejections, r.Decision.RuleRejections...)
+			merged.PromptTokens += r.Decision.PromptTokens
+			for _, trim := range r.Decision.PromptTrims {
+				merged.PromptTrims = append(merged.PromptTrims, fmt.Sprintf("%s组: %s", r.Name, trim))
+			}
+			merged.AIRequestDurationMs = max(merged.AIRequestDurationMs, r.Decision.AIRequestDurationMs)
+			systemPrompts = append(systemPrompts, fmt.Sprintf("==== %s组 ====\n%s", r.Name, r.Decision.SystemPrompt))
+			userPrompts = append(userPrompts, fmt.Sprintf("==== %s组 ====\n%s", r.Name, r.Decision.UserPrompt))
+		}
+		if r.Err != nil {
+			errs = append(errs, fmt.Sprintf("%s组: %v", r.Name, r.Err))
+			cot = append(cot, fmt.Sprintf("【%s组】调用失败: %v", r.Name, r.Err))
+			continue
+		}
+		merged.Decisions = append(merged.Decisions, r.Decision.Decisions...)
+		if merged.Timestamp.Before(r.Decision.Timestamp) {
+			merged.Timestamp = r.Decision.Timestamp
+		}
+		cot = append(cot, fmt.Sprintf("【%s组】\n%s", r.Name, r.Decision.CoTTrace))
+	}
+
+	merged.CoTTrace = strings.Join(cot, "\n\n")
+	merged.SystemPrompt = strings.Join(systemPrompts, "\n\n")
+	merged.UserPrompt = strings.Join(userPrompts, "\n\n")
+	if len(errs) == len(results) {
+		return merged, fmt.Errorf("A/B 两组决策均失败: %s", strings.Join(errs, "; "))
+	}
+	return merged, nil
+}
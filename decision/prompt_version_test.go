@@ -0,0 +1,118 @@
+package decision
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestResolvePromptVersion(t *testing.T) {
+	usePromptsDir(t, map[string]string{"default.txt": "默认策略", "trend.txt": "趋势策略"})
+
+	base := ResolvePromptVersion("", "trend", "", false)
+	if base.Template != "trend" || base.TemplateHash != HashPrompt("趋势策略") || len(base.Hash) != 12 {
+		t.Fatalf("unexpected version: %+v", base)
+	}
+	if again := ResolvePromptVersion("A", "trend", "", false); again.Hash != base.Hash {
+		t.Error("相同提示词的版本哈希应保持不变")
+	}
+	if custom := ResolvePromptVersion("", "trend", "只做 BTC", false); custom.Hash == base.Hash || custom.CustomHash != HashPrompt("只做 BTC") {
+		t.Errorf("自定义提示词应改变版本哈希: %+v", custom)
+	}
+	if fallback := ResolvePromptVersion("", "missing", "", false); fallback.Template != "default" {
+		t.Errorf("模板不存在时应回退到 default: %+v", fallback)
+	}
+	if override := ResolvePromptVersion("", "trend", "只做 BTC", true); override.Template != "" || override.TemplateHash != "" {
+		t.Errorf("覆盖基础提示词时不使用模板: %+v", override)
+	}
+}
+
+func TestParsePromptExperiment(t *testing.T) {
+	exp, err := ParsePromptExperiment(`{"template": " trend "}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if exp.Split != PromptSplitCycle || exp.Template != "trend" {
+		t.Errorf("默认按周期分组: %+v", exp)
+	}
+
+	if exp, err := ParsePromptExperiment(" "); exp != nil || err != nil {
+		t.Errorf("空配置表示不开启实验: %+v, %v", exp, err)
+	}
+	for _, raw := range []string{`{"split": "daily", "template": "x"}`, `{"split": "cycle"}`, `{"template": "x", "override_base_prompt": true}`, `[]`} {
+		if _, err := ParsePromptExperiment(raw); err == nil {
+			t.Errorf("ParsePromptExperiment(%s) 应返回错误", raw)
+		}
+	}
+}
+
+func TestPromptExperiment_Arms(t *testing.T) {
+	exp := &PromptExperiment{Split: PromptSplitSymbol, Template: "trend"}
+	if exp.ArmForCycle(1) != PromptArmA || exp.ArmForCycle(2) != PromptArmB {
+		t.Error("按周期交替：奇数周期 A 组，偶数周期 B 组")
+	}
+
+	arms := map[string]bool{}
+	for _, symbol := range []string{"BTCUSDT", "ETHUSDT", "SOLUSDT", "BNBUSDT", "XRPUSDT", "DOGEUSDT"} {
+		arm := exp.ArmForSymbol(symbol)
+		if exp.ArmForSymbol(strings.ToLower(symbol)) != arm {
+			t.Errorf("%s 的分组应与大小写无关", symbol)
+		}
+		arms[arm] = true
+	}
+	if !arms[PromptArmA] || !arms[PromptArmB] {
+		t.Errorf("币种应分布到两组: %v", arms)
+	}
+
+	ctx := &Context{
+		Account:        AccountInfo{TotalEquity: 1000},
+		Positions:      []PositionInfo{{Symbol: "BTCUSDT"}, {Symbol: "ETHUSDT"}},
+		CandidateCoins: []CandidateCoin{{Symbol: "SOLUSDT"}, {Symbol: "XRPUSDT"}, {Symbol: "DOGEUSDT"}},
+	}
+	split := exp.SplitContextBySymbol(ctx)
+	total := 0
+	for arm, sub := range split {
+		if sub.Account.TotalEquity != 1000 {
+			t.Errorf("%s 组应共享账户信息", arm)
+		}
+		for _, pos := range sub.Positions {
+			if exp.ArmForSymbol(pos.Symbol) != arm {
+				t.Errorf("%s 被分到了错误的组 %s", pos.Symbol, arm)
+			}
+		}
+		for _, coin := range sub.CandidateCoins {
+			if exp.ArmForSymbol(coin.Symbol) != arm {
+				t.Errorf("%s 被分到了错误的组 %s", coin.Symbol, arm)
+			}
+		}
+		total += len(sub.Positions) + len(sub.CandidateCoins)
+	}
+	if total != 5 || len(ctx.Positions) != 2 {
+		t.Errorf("拆分应覆盖全部币种且不修改原上下文: total=%d", total)
+	}
+}
+
+func TestMergeArmDecisions(t *testing.T) {
+	results := []MemberResult{
+		{Name: PromptArmA, Decision: &FullDecision{CoTTrace: "a", SystemPrompt: "sa", Decisions: []Decision{{Symbol: "BTCUSDT", Action: "hold"}}}},
+		{Name: PromptArmB, Decision: &FullDecision{CoTTrace: "b", SystemPrompt: "sb", Decisions: []Decision{{Symbol: "ETHUSDT", Action: "close_long"}}}},
+	}
+	merged, err := MergeArmDecisions(results)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(merged.Decisions) != 2 || !strings.Contains(merged.CoTTrace, "【B组】\nb") || !strings.Contains(merged.SystemPrompt, "==== A组 ====\nsa") {
+		t.Errorf("unexpected merge: %+v", merged)
+	}
+
+	results[1] = MemberResult{Name: PromptArmB, Err: errors.New("timeout")}
+	merged, err = MergeArmDecisions(results)
+	if err != nil || len(merged.Decisions) != 1 || !strings.Contains(merged.CoTTrace, "【B组】调用失败") {
+		t.Errorf("一组失败时应保留另一组的决策: %+v, %v", merged, err)
+	}
+
+	results[0] = MemberResult{Name: PromptArmA, Err: errors.New("timeout")}
+	if _, err := MergeArmDecisions(results); err == nil {
+		t.Error("两组都失败时应返回错误")
+	}
+}
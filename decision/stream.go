@@ -0,0 +1,61 @@
+package decision
+
+import (
+	"nofx/mcp"
+)
+
+// streamingClient 把 AI 调用改为流式请求，并把增量输出交给 onStream（用于实时展示模型的思考过程）
+type streamingClient struct {
+	mcp.AIClient
+	onStream mcp.StreamHandler
+}
+
+// withStreaming 设置了 onStream 时返回流式客户端，否则原样返回
+func withStreaming(mcpClient mcp.AIClient, onStream mcp.StreamHandler) mcp.AIClient {
+	if onStream == nil {
+		return mcpClient
+	}
+	return &streamingClient{AIClient: mcpClient, onStream: onStream}
+}
+
+// CallWithMessages 以流式请求发送 system + user prompt，思考过程以 <reasoning> 标签放在返回文本开头
+func (c *streamingClient) CallWithMessages(systemPrompt, userPrompt string) (string, error) {
+	request, err := mcp.NewRequestBuilder().
+		WithSystemPrompt(systemPrompt).
+		WithUserPrompt(userPrompt).
+		Build()
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.CallWithRequestFull(request)
+	if err != nil {
+		return "", err
+	}
+	return resp.TextWithReasoning(), nil
+}
+
+func (c *streamingClient) CallWithRequest(req *mcp.Request) (string, error) {
+	resp, err := c.CallWithRequestFull(req)
+	if err != nil {
+		return "", err
+	}
+	return resp.Content, nil
+}
+
+func (c *streamingClient) CallWithRequestFull(req *mcp.Request) (*mcp.Response, error) {
+	if req.OnChunk == nil {
+		req.Stream = true
+		req.OnChunk = c.onStream
+	}
+	return c.AIClient.CallWithRequestFull(req)
+}
+
+// EstimateTokens 使用底层客户端的 token 估算
+func (c *streamingClient) EstimateTokens(text string) int {
+	return tokenEstimator(c.AIClient)(text)
+}
+
+// PromptTokenBudget 使用底层客户端的 prompt 预算
+func (c *streamingClient) PromptTokenBudget() int {
+	return promptTokenBudget(c.AIClient)
+}
@@ -0,0 +1,41 @@
+package decision
+
+import (
+	"nofx/mcp"
+	"testing"
+)
+
+func TestGetFullDecision_StreamsTextMode(t *testing.T) {
+	client := &fakeAIClient{
+		response: &mcp.Response{
+			Reasoning: "ETH 跌破 EMA20",
+			Content:   `<decision>[{"symbol":"ETHUSDT","action":"wait","reasoning":"观望"}]</decision>`,
+		},
+	}
+	ctx := newToolCallContext()
+	ctx.DecisionMode = DecisionModeText
+	var chunks int
+	ctx.OnStream = func(chunk mcp.StreamChunk) { chunks++ }
+
+	fd, err := GetFullDecisionWithCustomPrompt(ctx, client, "", false, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if client.textRequests != 0 || len(client.requests) != 1 {
+		t.Fatalf("设置 OnStream 时文本模式应改用流式请求: text=%d requests=%d", client.textRequests, len(client.requests))
+	}
+	req := client.requests[0]
+	if !req.Stream || req.OnChunk == nil {
+		t.Errorf("请求应开启流式并带上回调: %+v", req)
+	}
+	req.OnChunk(mcp.StreamChunk{Reasoning: "x"})
+	if chunks != 1 {
+		t.Errorf("回调应转发到 ctx.OnStream")
+	}
+	if fd.CoTTrace != "ETH 跌破 EMA20" {
+		t.Errorf("流式思考过程应作为思维链: %q", fd.CoTTrace)
+	}
+	if len(fd.Decisions) != 1 || fd.Decisions[0].Action != "wait" {
+		t.Errorf("unexpected decisions: %+v", fd.Decisions)
+	}
+}
@@ -0,0 +1,150 @@
+package decision
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// MaxTakeProfitLevels 分批止盈最多档位数
+const MaxTakeProfitLevels = 5
+
+// TakeProfitLevel 分批止盈中的一档
+type TakeProfitLevel struct {
+	Price      float64 `json:"price"`
+	Percentage float64 `json:"percentage"` // 该档平仓数量占开仓数量的百分比，最后一档可省略（平掉剩余仓位）
+}
+
+// TakeProfitLadderStep 交易员配置中的分批止盈模板（不含具体价格，开仓时按 AI 给出的止盈价展开）
+type TakeProfitLadderStep struct {
+	Ratio      float64 `json:"ratio"`      // 该档位于入场价到止盈价之间的位置 (0-1]，最后一档必须为 1
+	Percentage float64 `json:"percentage"` // 平仓百分比
+}
+
+// validateTakeProfitLevels 校验分批止盈档位：价格按交易方向严格递进且位于止损（限价单为挂单价）之外，
+// 除最后一档外每档平仓百分比必须大于0，合计不超过100%
+func validateTakeProfitLevels(d *Decision) error {
+	levels := d.TakeProfitLevels
+	if len(levels) == 0 {
+		return nil
+	}
+	if len(levels) > MaxTakeProfitLevels {
+		return fmt.Errorf("分批止盈最多 %d 档，实际: %d", MaxTakeProfitLevels, len(levels))
+	}
+
+	long := d.Action == "open_long"
+	prev := d.StopLoss
+	if d.IsLimitOrder() {
+		prev = d.LimitPrice
+	}
+	for i, level := range levels {
+		if level.Price <= 0 {
+			return fmt.Errorf("第%d档止盈价必须大于0: %.4f", i+1, level.Price)
+		}
+		if long && level.Price <= prev {
+			return fmt.Errorf("做多时第%d档止盈价 %.4f 必须高于 %.4f", i+1, level.Price, prev)
+		}
+		if !long && level.Price >= prev {
+			return fmt.Errorf("做空时第%d档止盈价 %.4f 必须低于 %.4f", i+1, level.Price, prev)
+		}
+		prev = level.Price
+	}
+
+	percentages := make([]float64, len(levels))
+	for i, level := range levels {
+		percentages[i] = level.Percentage
+	}
+	return validateLadderPercentages(percentages)
+}
+
+func validateLadderPercentages(percentages []float64) error {
+	total := 0.0
+	for i, pct := range percentages {
+		last := i == len(percentages)-1
+		if pct < 0 || (!last && pct == 0) {
+			return fmt.Errorf("第%d档平仓百分比必须大于0: %.2f", i+1, pct)
+		}
+		total += pct
+		if !last && total >= 100 {
+			return fmt.Errorf("前%d档平仓百分比合计 %.2f%% 已达100%%，最后一档没有剩余仓位", i+1, total)
+		}
+	}
+	if total > 100+1e-6 {
+		return fmt.Errorf("分批止盈平仓百分比合计不能超过100%%: %.2f%%", total)
+	}
+	return nil
+}
+
+// TakeProfitLevelQuantities 按各档百分比拆分开仓数量，最后一档取剩余数量
+func TakeProfitLevelQuantities(total float64, levels []TakeProfitLevel) []float64 {
+	quantities := make([]float64, len(levels))
+	remaining := total
+	for i, level := range levels {
+		if i == len(levels)-1 {
+			quantities[i] = remaining
+			break
+		}
+		quantities[i] = total * level.Percentage / 100
+		remaining -= quantities[i]
+	}
+	return quantities
+}
+
+// ParseTakeProfitLadder 解析并校验交易员配置中的分批止盈模板（JSON 数组），空字符串表示不启用
+func ParseTakeProfitLadder(raw string) ([]TakeProfitLadderStep, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+
+	var steps []TakeProfitLadderStep
+	if err := json.Unmarshal([]byte(raw), &steps); err != nil {
+		return nil, fmt.Errorf("分批止盈配置格式错误: %w", err)
+	}
+	if len(steps) == 0 {
+		return nil, nil
+	}
+	if err := ValidateTakeProfitLadder(steps); err != nil {
+		return nil, err
+	}
+	return steps, nil
+}
+
+// ValidateTakeProfitLadder 校验分批止盈模板：ratio 严格递增且最后一档为 1，平仓百分比规则与 AI 给出的档位一致
+func ValidateTakeProfitLadder(steps []TakeProfitLadderStep) error {
+	if len(steps) == 0 {
+		return nil
+	}
+	if len(steps) > MaxTakeProfitLevels {
+		return fmt.Errorf("分批止盈最多 %d 档，实际: %d", MaxTakeProfitLevels, len(steps))
+	}
+
+	prev := 0.0
+	percentages := make([]float64, len(steps))
+	for i, step := range steps {
+		if step.Ratio <= prev || step.Ratio > 1 {
+			return fmt.Errorf("第%d档 ratio 必须递增且位于 (0, 1] 区间: %.4f", i+1, step.Ratio)
+		}
+		prev = step.Ratio
+		percentages[i] = step.Percentage
+	}
+	if prev != 1 {
+		return fmt.Errorf("最后一档 ratio 必须为 1（即 AI 给出的止盈价）: %.4f", prev)
+	}
+	return validateLadderPercentages(percentages)
+}
+
+// BuildTakeProfitLadder 按配置模板把入场价到止盈价的区间展开为分批止盈档位
+func BuildTakeProfitLadder(entryPrice, takeProfit float64, steps []TakeProfitLadderStep) []TakeProfitLevel {
+	if len(steps) == 0 || entryPrice <= 0 || takeProfit <= 0 {
+		return nil
+	}
+	levels := make([]TakeProfitLevel, 0, len(steps))
+	for _, step := range steps {
+		levels = append(levels, TakeProfitLevel{
+			Price:      entryPrice + (takeProfit-entryPrice)*step.Ratio,
+			Percentage: step.Percentage,
+		})
+	}
+	return levels
+}
@@ -0,0 +1,249 @@
+package decision
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"nofx/mcp"
+	"reflect"
+	"strings"
+)
+
+// 决策输出方式
+const (
+	DecisionModeText     = "text"      // 默认：从文本中的 <decision> JSON 提取决策
+	DecisionModeToolCall = "tool_call" // 通过 Function Calling 调用 submit_decisions 提交决策
+	DecisionModeAgent    = "agent"     // 多轮工具调用：先调用只读数据工具补充行情，再调用 submit_decisions 提交决策
+)
+
+// SubmitDecisionsToolName 工具调用模式下提交决策的函数名
+const SubmitDecisionsToolName = "submit_decisions"
+
+// NormalizeDecisionMode 规范化决策输出方式，空值为 text
+func NormalizeDecisionMode(mode string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "", DecisionModeText:
+		return DecisionModeText, nil
+	case DecisionModeToolCall:
+		return DecisionModeToolCall, nil
+	case DecisionModeAgent:
+		return DecisionModeAgent, nil
+	default:
+		return "", fmt.Errorf("无效的决策输出方式: %s（可选 text/tool_call/agent）", mode)
+	}
+}
+
+// decisionFieldHints 决策字段在 JSON Schema 中的说明和可选值
+var decisionFieldHints = map[string]struct {
+	Description string
+	Enum        []string
+}{
+	"symbol":              {Description: "交易对，如 BTCUSDT；无具体币种的 wait 可填 ALL"},
+	"action":              {Description: "决策动作", Enum: decisionActions},
+	"leverage":            {Description: "开仓杠杆倍数"},
+	"position_size_usd":   {Description: "开仓名义价值（USDT）"},
+	"stop_loss":           {Description: "开仓止损价"},
+	"take_profit":         {Description: "开仓止盈价，提供 take_profit_levels 时可省略"},
+	"order_type":          {Description: "下单方式，默认 market", Enum: []string{OrderTypeMarket, OrderTypeLimit, OrderTypePostOnly}},
+	"limit_price":         {Description: "限价单价格，limit/post_only 必填"},
+	"time_in_force":       {Description: "限价单有效方式，默认 GTC", Enum: []string{TimeInForceGTC, TimeInForceIOC}},
+	"new_stop_loss":       {Description: "update_stop_loss 的新止损价"},
+	"new_take_profit":     {Description: "update_take_profit 的新止盈价"},
+	"close_percentage":    {Description: "partial_close 的平仓百分比 (0-100]"},
+	"callback_rate":       {Description: "set_trailing_stop 的回调百分比，与 trailing_distance 二选一"},
+	"trailing_distance":   {Description: "set_trailing_stop 的回调价格距离，与 callback_rate 二选一"},
+	"activation_price":    {Description: "追踪止损激活价（可选）"},
+	"take_profit_levels":  {Description: "分批止盈档位，按离入场价由近到远排列，最后一档平掉剩余仓位"},
+	"price":               {Description: "该档止盈价"},
+	"percentage":          {Description: "该档平仓百分比，最后一档可填 0 表示平掉剩余仓位"},
+	"breakeven_after_tp1": {Description: "第一档止盈成交后把止损移到开仓价"},
+	"confidence":          {Description: "信心度 0-100"},
+	"risk_usd":            {Description: "最大美元风险"},
+	"reasoning":           {Description: "该决策的简要理由"},
+}
+
+// SubmitDecisionsTool 返回 submit_decisions 函数定义，参数 Schema 由 Decision 结构体生成
+func SubmitDecisionsTool() mcp.Tool {
+	return mcp.Tool{
+		Type: "function",
+		Function: mcp.FunctionDef{
+			Name:        SubmitDecisionsToolName,
+			Description: "提交本周期的交易决策。先在 reasoning 中给出思维链，再在 decisions 中列出所有决策；无需操作时提交 wait 决策",
+			Parameters: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"reasoning": map[string]any{
+						"type":        "string",
+						"description": "思维链分析",
+					},
+					"decisions": map[string]any{
+						"type":        "array",
+						"description": "决策列表",
+						"items":       jsonSchemaForType(reflect.TypeOf(Decision{})),
+					},
+				},
+				"required": []string{"reasoning", "decisions"},
+			},
+		},
+	}
+}
+
+// jsonSchemaForType 按 json 标签为类型生成 JSON Schema，未标记 omitempty 的字段为必填
+func jsonSchemaForType(t reflect.Type) map[string]any {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return jsonSchemaForType(t.Elem())
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": jsonSchemaForType(t.Elem())}
+	case reflect.Struct:
+		properties := make(map[string]any)
+		required := make([]string, 0)
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			tag := field.Tag.Get("json")
+			if !field.IsExported() || tag == "-" {
+				continue
+			}
+			name, opts, _ := strings.Cut(tag, ",")
+			if name == "" {
+				name = field.Name
+			}
+
+			schema := jsonSchemaForType(field.Type)
+			if hint, ok := decisionFieldHints[name]; ok {
+				if hint.Description != "" {
+					schema["description"] = hint.Description
+				}
+				if len(hint.Enum) > 0 {
+					schema["enum"] = hint.Enum
+				}
+			}
+			properties[name] = schema
+			if !strings.Contains(opts, "omitempty") {
+				required = append(required, name)
+			}
+		}
+		return map[string]any{"type": "object", "properties": properties, "required": required}
+	default:
+		return map[string]any{}
+	}
+}
+
+// buildToolCallInstructions 工具调用模式下追加到 System Prompt 的输出说明（覆盖上文的 XML 输出格式）
+func buildToolCallInstructions() string {
+	var sb strings.Builder
+	sb.WriteString("# 输出方式（工具调用）\n\n")
+	sb.WriteString(fmt.Sprintf("本次请**调用 `%s` 函数**提交决策，不要再输出 <reasoning>/<decision> 标签或 JSON 代码块：\n", SubmitDecisionsToolName))
+	sb.WriteString("- `reasoning`: 思维链分析\n")
+	sb.WriteString("- `decisions`: 决策数组，字段含义与上文字段说明一致；无需操作时提交 wait 决策\n")
+	return sb.String()
+}
+
+// submitDecisionsArgs submit_decisions 的调用参数
+type submitDecisionsArgs struct {
+	Reasoning string     `json:"reasoning"`
+	Decisions []Decision `json:"decisions"`
+}
+
+// parseToolCallResponse 从 submit_decisions 工具调用中解析思维链和决策列表。
+// 模型未调用该函数时返回 ok=false，由调用方回退到文本解析
+func parseToolCallResponse(resp *mcp.Response) (cotTrace string, decisions []Decision, ok bool, err error) {
+	call, found := resp.ToolCallByName(SubmitDecisionsToolName)
+	if !found {
+		return "", nil, false, nil
+	}
+
+	var args submitDecisionsArgs
+	if err := json.Unmarshal([]byte(call.Function.Arguments), &args); err != nil {
+		return strings.TrimSpace(resp.Content), nil, true, fmt.Errorf("解析 %s 参数失败: %w\n参数: %s", SubmitDecisionsToolName, err, call.Function.Arguments)
+	}
+
+	cotTrace = strings.TrimSpace(args.Reasoning)
+	if cotTrace == "" {
+		cotTrace = strings.TrimSpace(resp.Content)
+	}
+	if len(args.Decisions) == 0 {
+		args.Decisions = []Decision{{Symbol: "ALL", Action: "wait", Reasoning: "模型未提交任何决策，进入等待"}}
+	}
+	return cotTrace, args.Decisions, true, nil
+}
+
+// requestDecisionWithTools 以工具调用模式请求决策并解析结果，模型或接口不支持工具调用时回退到文本解析。
+// API 调用失败时返回 nil 决策；解析或验证失败时同时返回已解析的部分结果和错误
+func requestDecisionWithTools(mcpClient mcp.AIClient, systemPrompt, userPrompt string, validator *decisionValidator) (*FullDecision, error) {
+	request, err := mcp.NewRequestBuilder().
+		WithSystemPrompt(systemPrompt + "\n" + buildToolCallInstructions()).
+		WithUserPrompt(userPrompt).
+		AddTool(SubmitDecisionsTool()).
+		WithToolChoice(mcp.ToolChoiceFunction(SubmitDecisionsToolName)).
+		Build()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := mcpClient.CallWithRequestFull(request)
+	if err != nil {
+		if !isToolUnsupportedError(err) {
+			return nil, fmt.Errorf("调用AI API失败: %w", err)
+		}
+		log.Printf("⚠️  模型不支持工具调用，回退到文本解析: %v", err)
+		return requestDecisionWithText(mcpClient, systemPrompt, userPrompt, validator)
+	}
+
+	cotTrace, decisions, ok, err := parseToolCallResponse(resp)
+	if !ok {
+		log.Printf("⚠️  模型未调用 %s，回退到文本解析", SubmitDecisionsToolName)
+		decision, err := parseFullDecisionResponse(resp.TextWithReasoning(), validator)
+		if decision != nil {
+			decision.RawResponse = resp.Content
+		}
+		return decision, err
+	}
+	// 模型的扩展思考放在思维链最前面
+	if resp.Reasoning != "" {
+		cotTrace = strings.TrimSpace(resp.Reasoning + "\n\n" + cotTrace)
+	}
+	rawResponse := resp.Content
+	if call, found := resp.ToolCallByName(SubmitDecisionsToolName); found {
+		rawResponse = call.Function.Arguments
+	}
+	if err != nil {
+		return &FullDecision{CoTTrace: cotTrace, Decisions: []Decision{}, RawResponse: rawResponse}, fmt.Errorf("提取决策失败: %w", err)
+	}
+
+	if err := validator.validateAll(decisions); err != nil {
+		return &FullDecision{CoTTrace: cotTrace, Decisions: decisions, RawResponse: rawResponse}, fmt.Errorf("决策验证失败: %w", err)
+	}
+	return &FullDecision{CoTTrace: cotTrace, Decisions: decisions, RawResponse: rawResponse}, nil
+}
+
+// requestDecisionWithText 以文本模式请求决策并从 <decision> 中解析结果（工具调用不可用时的回退路径）
+func requestDecisionWithText(mcpClient mcp.AIClient, systemPrompt, userPrompt string, validator *decisionValidator) (*FullDecision, error) {
+	aiResponse, err := mcpClient.CallWithMessages(systemPrompt, userPrompt)
+	if err != nil {
+		return nil, fmt.Errorf("调用AI API失败: %w", err)
+	}
+	decision, err := parseFullDecisionResponse(aiResponse, validator)
+	if decision != nil {
+		decision.RawResponse = aiResponse
+	}
+	return decision, err
+}
+
+// isToolUnsupportedError 判断接口是否因不支持 tools/tool_choice 参数而拒绝请求
+func isToolUnsupportedError(err error) bool {
+	msg := strings.ToLower(err.Error())
+	if !strings.Contains(msg, "status 400") && !strings.Contains(msg, "status 422") && !strings.Contains(msg, "status 404") {
+		return false
+	}
+	return strings.Contains(msg, "tool") || strings.Contains(msg, "function")
+}
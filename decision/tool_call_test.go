@@ -0,0 +1,238 @@
+package decision
+
+import (
+	"errors"
+	"nofx/market"
+	"nofx/mcp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeAIClient 记录请求并返回预设响应
+type fakeAIClient struct {
+	response     *mcp.Response
+	responses    []*mcp.Response // 多轮调用时依次返回，用完后返回 response
+	requestErr   error
+	textResponse string
+
+	requests     []*mcp.Request
+	textRequests int
+}
+
+func (f *fakeAIClient) SetAPIKey(apiKey string, customURL string, customModel string) {}
+func (f *fakeAIClient) SetTimeout(timeout time.Duration)                              {}
+
+func (f *fakeAIClient) CallWithMessages(systemPrompt, userPrompt string) (string, error) {
+	f.textRequests++
+	return f.textResponse, nil
+}
+
+func (f *fakeAIClient) CallWithRequest(req *mcp.Request) (string, error) {
+	resp, err := f.CallWithRequestFull(req)
+	if err != nil {
+		return "", err
+	}
+	return resp.Content, nil
+}
+
+func (f *fakeAIClient) CallWithRequestFull(req *mcp.Request) (*mcp.Response, error) {
+	f.requests = append(f.requests, req)
+	if f.requestErr != nil {
+		return nil, f.requestErr
+	}
+	if len(f.responses) > 0 {
+		resp := f.responses[0]
+		f.responses = f.responses[1:]
+		return resp, nil
+	}
+	return f.response, nil
+}
+
+func newToolCallContext() *Context {
+	return &Context{
+		Account:         AccountInfo{TotalEquity: 1000, AvailableBalance: 1000},
+		MarketDataMap:   map[string]*market.Data{"ETHUSDT": {Symbol: "ETHUSDT", CurrentPrice: 3000}},
+		BTCETHLeverage:  10,
+		AltcoinLeverage: 5,
+		DecisionMode:    DecisionModeToolCall,
+	}
+}
+
+func TestSubmitDecisionsTool_SchemaFromDecision(t *testing.T) {
+	tool := SubmitDecisionsTool()
+	if tool.Function.Name != SubmitDecisionsToolName {
+		t.Fatalf("unexpected tool name: %s", tool.Function.Name)
+	}
+
+	properties := tool.Function.Parameters["properties"].(map[string]any)
+	items := properties["decisions"].(map[string]any)["items"].(map[string]any)
+	fields := items["properties"].(map[string]any)
+
+	for _, name := range []string{"symbol", "action", "leverage", "position_size_usd", "order_type", "callback_rate", "take_profit_levels", "reasoning"} {
+		if _, ok := fields[name]; !ok {
+			t.Errorf("schema 缺少字段 %s", name)
+		}
+	}
+
+	enum := fields["action"].(map[string]any)["enum"].([]string)
+	if len(enum) != len(decisionActions) {
+		t.Errorf("action 枚举应包含所有有效动作，实际: %v", enum)
+	}
+	if fields["leverage"].(map[string]any)["type"] != "integer" {
+		t.Errorf("leverage 应为 integer")
+	}
+
+	levels := fields["take_profit_levels"].(map[string]any)
+	levelFields := levels["items"].(map[string]any)["properties"].(map[string]any)
+	if levelFields["price"].(map[string]any)["type"] != "number" {
+		t.Errorf("take_profit_levels.price 应为 number")
+	}
+
+	required := items["required"].([]string)
+	if strings.Join(required, ",") != "symbol,action,reasoning" {
+		t.Errorf("未标记 omitempty 的字段应为必填，实际: %v", required)
+	}
+}
+
+func TestGetFullDecision_ToolCallMode(t *testing.T) {
+	client := &fakeAIClient{
+		response: &mcp.Response{
+			ToolCalls: []mcp.ToolCall{{
+				ID:   "call_1",
+				Type: "function",
+				Function: mcp.FunctionCall{
+					Name:      SubmitDecisionsToolName,
+					Arguments: `{"reasoning":"ETH 趋势转弱","decisions":[{"symbol":"ETHUSDT","action":"close_long","reasoning":"跌破EMA20"},{"symbol":"BTCUSDT","action":"wait","reasoning":"观望"}]}`,
+				},
+			}},
+		},
+	}
+
+	fd, err := GetFullDecisionWithCustomPrompt(newToolCallContext(), client, "", false, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if client.textRequests != 0 {
+		t.Errorf("工具调用成功时不应走文本请求")
+	}
+	if fd.CoTTrace != "ETH 趋势转弱" {
+		t.Errorf("unexpected cot trace: %q", fd.CoTTrace)
+	}
+	if len(fd.Decisions) != 2 || fd.Decisions[0].Action != "close_long" || fd.Decisions[1].Symbol != "BTCUSDT" {
+		t.Errorf("unexpected decisions: %+v", fd.Decisions)
+	}
+
+	if len(client.requests) != 1 {
+		t.Fatalf("expected 1 request, got %d", len(client.requests))
+	}
+	req := client.requests[0]
+	if len(req.Tools) != 1 || req.Tools[0].Function.Name != SubmitDecisionsToolName {
+		t.Errorf("请求应声明 submit_decisions 工具: %+v", req.Tools)
+	}
+	if req.ToolChoice != mcp.ToolChoiceFunction(SubmitDecisionsToolName) {
+		t.Errorf("应强制调用 submit_decisions，实际: %s", req.ToolChoice)
+	}
+	if !strings.Contains(req.Messages[0].Content, "调用 `submit_decisions` 函数") {
+		t.Errorf("system prompt 应包含工具调用说明")
+	}
+}
+
+func TestGetFullDecision_ToolCallModeWithThinking(t *testing.T) {
+	client := &fakeAIClient{
+		response: &mcp.Response{
+			Reasoning: "ETH 跌破关键支撑",
+			ToolCalls: []mcp.ToolCall{{
+				ID:       "toolu_1",
+				Type:     "function",
+				Function: mcp.FunctionCall{Name: SubmitDecisionsToolName, Arguments: `{"reasoning":"平多","decisions":[{"symbol":"ETHUSDT","action":"close_long","reasoning":"止损"}]}`},
+			}},
+		},
+	}
+
+	fd, err := GetFullDecisionWithCustomPrompt(newToolCallContext(), client, "", false, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fd.CoTTrace != "ETH 跌破关键支撑\n\n平多" {
+		t.Errorf("扩展思考应写在思维链开头: %q", fd.CoTTrace)
+	}
+}
+
+func TestGetFullDecision_ToolCallValidationError(t *testing.T) {
+	client := &fakeAIClient{
+		response: &mcp.Response{
+			ToolCalls: []mcp.ToolCall{{
+				Function: mcp.FunctionCall{
+					Name:      SubmitDecisionsToolName,
+					Arguments: `{"reasoning":"r","decisions":[{"symbol":"ETHUSDT","action":"buy","reasoning":"x"}]}`,
+				},
+			}},
+		},
+	}
+
+	fd, err := GetFullDecisionWithCustomPrompt(newToolCallContext(), client, "", false, "")
+	if err == nil || !strings.Contains(err.Error(), "无效的action") {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+	if fd == nil || fd.UserPrompt == "" || len(fd.Decisions) != 1 {
+		t.Errorf("验证失败时仍应返回已解析的决策和 prompt: %+v", fd)
+	}
+}
+
+func TestGetFullDecision_ToolCallFallsBackToText(t *testing.T) {
+	textReply := "<reasoning>观望</reasoning>\n<decision>\n```json\n[{\"symbol\": \"ETHUSDT\", \"action\": \"hold\", \"reasoning\": \"持有\"}]\n```\n</decision>"
+
+	t.Run("模型未调用工具", func(t *testing.T) {
+		client := &fakeAIClient{response: &mcp.Response{Content: textReply}}
+		fd, err := GetFullDecisionWithCustomPrompt(newToolCallContext(), client, "", false, "")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if client.textRequests != 0 {
+			t.Errorf("应直接解析本次响应的文本，不需要再次请求")
+		}
+		if len(fd.Decisions) != 1 || fd.Decisions[0].Action != "hold" || fd.CoTTrace != "观望" {
+			t.Errorf("unexpected decision: %+v", fd)
+		}
+	})
+
+	t.Run("接口不支持工具调用", func(t *testing.T) {
+		client := &fakeAIClient{
+			requestErr:   errors.New("API返回错误 (status 400): tool_choice is not supported for this model"),
+			textResponse: textReply,
+		}
+		fd, err := GetFullDecisionWithCustomPrompt(newToolCallContext(), client, "", false, "")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if client.textRequests != 1 {
+			t.Errorf("应回退到文本请求，实际文本请求次数: %d", client.textRequests)
+		}
+		if len(fd.Decisions) != 1 || fd.Decisions[0].Action != "hold" {
+			t.Errorf("unexpected decision: %+v", fd.Decisions)
+		}
+	})
+
+	t.Run("其他错误不回退", func(t *testing.T) {
+		client := &fakeAIClient{requestErr: errors.New("API返回错误 (status 500): internal error")}
+		if _, err := GetFullDecisionWithCustomPrompt(newToolCallContext(), client, "", false, ""); err == nil {
+			t.Fatal("expected error")
+		}
+		if client.textRequests != 0 {
+			t.Errorf("非工具相关错误不应回退")
+		}
+	})
+}
+
+func TestNormalizeDecisionMode(t *testing.T) {
+	for input, want := range map[string]string{"": DecisionModeText, " Tool_Call ": DecisionModeToolCall, "text": DecisionModeText, "AGENT": DecisionModeAgent} {
+		got, err := NormalizeDecisionMode(input)
+		if err != nil || got != want {
+			t.Errorf("NormalizeDecisionMode(%q) = %q, %v; want %q", input, got, err, want)
+		}
+	}
+	if _, err := NormalizeDecisionMode("json"); err == nil {
+		t.Error("expected error for unknown mode")
+	}
+}
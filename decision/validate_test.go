@@ -279,6 +279,343 @@ func TestPartialCloseValidation(t *testing.T) {
 	}
 }
 
+// TestLimitOrderValidation 测试限价/只做Maker开仓的字段验证
+func TestLimitOrderValidation(t *testing.T) {
+	base := func(action string, sl, tp float64) Decision {
+		return Decision{
+			Symbol:          "BTCUSDT",
+			Action:          action,
+			Leverage:        5,
+			PositionSizeUSD: 1000,
+			StopLoss:        sl,
+			TakeProfit:      tp,
+		}
+	}
+
+	tests := []struct {
+		name        string
+		decision    Decision
+		wantError   bool
+		errorMsg    string
+		wantType    string
+		wantTIF     string
+		wantLimitPx float64
+	}{
+		{
+			name:     "未指定order_type默认市价",
+			decision: base("open_long", 90000, 110000),
+			wantType: OrderTypeMarket,
+		},
+		{
+			name: "限价做多默认GTC",
+			decision: func() Decision {
+				d := base("open_long", 90000, 110000)
+				d.OrderType = "LIMIT"
+				d.LimitPrice = 94000
+				return d
+			}(),
+			wantType:    OrderTypeLimit,
+			wantTIF:     TimeInForceGTC,
+			wantLimitPx: 94000,
+		},
+		{
+			name: "post_only做空忽略time_in_force",
+			decision: func() Decision {
+				d := base("open_short", 4000, 3000)
+				d.OrderType = "post_only"
+				d.LimitPrice = 3800
+				d.TimeInForce = "IOC"
+				return d
+			}(),
+			wantType:    OrderTypePostOnly,
+			wantLimitPx: 3800,
+		},
+		{
+			name: "市价单清空限价字段",
+			decision: func() Decision {
+				d := base("open_long", 90000, 110000)
+				d.OrderType = "market"
+				d.LimitPrice = 95000
+				d.TimeInForce = "IOC"
+				return d
+			}(),
+			wantType: OrderTypeMarket,
+		},
+		{
+			name: "限价单缺少limit_price",
+			decision: func() Decision {
+				d := base("open_long", 90000, 110000)
+				d.OrderType = "limit"
+				return d
+			}(),
+			wantError: true,
+			errorMsg:  "必须提供 limit_price",
+		},
+		{
+			name: "做多限价高于止盈",
+			decision: func() Decision {
+				d := base("open_long", 90000, 110000)
+				d.OrderType = "limit"
+				d.LimitPrice = 120000
+				return d
+			}(),
+			wantError: true,
+			errorMsg:  "必须位于止损",
+		},
+		{
+			name: "做空限价低于止盈",
+			decision: func() Decision {
+				d := base("open_short", 4000, 3000)
+				d.OrderType = "limit"
+				d.LimitPrice = 2900
+				return d
+			}(),
+			wantError: true,
+			errorMsg:  "必须位于止盈",
+		},
+		{
+			name: "无效的time_in_force",
+			decision: func() Decision {
+				d := base("open_long", 90000, 110000)
+				d.OrderType = "limit"
+				d.LimitPrice = 94000
+				d.TimeInForce = "FOK"
+				return d
+			}(),
+			wantError: true,
+			errorMsg:  "无效的time_in_force",
+		},
+		{
+			name: "限价导致风险回报比不足",
+			decision: func() Decision {
+				d := base("open_long", 90000, 110000)
+				d.OrderType = "limit"
+				d.LimitPrice = 100000
+				return d
+			}(),
+			wantError: true,
+			errorMsg:  "风险回报比过低",
+		},
+		{
+			name: "无效的order_type",
+			decision: func() Decision {
+				d := base("open_long", 90000, 110000)
+				d.OrderType = "stop_market"
+				return d
+			}(),
+			wantError: true,
+			errorMsg:  "无效的order_type",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateDecision(&tt.decision, 1000.0, 10, 5)
+
+			if (err != nil) != tt.wantError {
+				t.Errorf("validateDecision() error = %v, wantError %v", err, tt.wantError)
+				return
+			}
+
+			if tt.wantError {
+				if tt.errorMsg != "" && !contains(err.Error(), tt.errorMsg) {
+					t.Errorf("错误信息不匹配: got %q, want to contain %q", err.Error(), tt.errorMsg)
+				}
+				return
+			}
+
+			if tt.decision.OrderType != tt.wantType {
+				t.Errorf("OrderType = %q, want %q", tt.decision.OrderType, tt.wantType)
+			}
+			if tt.decision.TimeInForce != tt.wantTIF {
+				t.Errorf("TimeInForce = %q, want %q", tt.decision.TimeInForce, tt.wantTIF)
+			}
+			if tt.decision.LimitPrice != tt.wantLimitPx {
+				t.Errorf("LimitPrice = %v, want %v", tt.decision.LimitPrice, tt.wantLimitPx)
+			}
+		})
+	}
+}
+
+// TestTrailingStopValidation 测试追踪止损参数验证
+func TestTrailingStopValidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		decision  Decision
+		wantError bool
+		errorMsg  string
+	}{
+		{
+			name:     "回调比例",
+			decision: Decision{Symbol: "BTCUSDT", Action: "set_trailing_stop", CallbackRate: 1.5},
+		},
+		{
+			name:     "回调距离加激活价",
+			decision: Decision{Symbol: "ETHUSDT", Action: "set_trailing_stop", TrailingDistance: 30, ActivationPrice: 3500},
+		},
+		{
+			name:      "缺少回调参数",
+			decision:  Decision{Symbol: "BTCUSDT", Action: "set_trailing_stop"},
+			wantError: true,
+			errorMsg:  "必须提供 callback_rate 或 trailing_distance",
+		},
+		{
+			name:      "同时提供比例和距离",
+			decision:  Decision{Symbol: "BTCUSDT", Action: "set_trailing_stop", CallbackRate: 1, TrailingDistance: 100},
+			wantError: true,
+			errorMsg:  "只能提供一个",
+		},
+		{
+			name:      "回调比例超出范围",
+			decision:  Decision{Symbol: "BTCUSDT", Action: "set_trailing_stop", CallbackRate: 15},
+			wantError: true,
+			errorMsg:  "callback_rate 必须在",
+		},
+		{
+			name:      "负数激活价",
+			decision:  Decision{Symbol: "BTCUSDT", Action: "set_trailing_stop", CallbackRate: 1, ActivationPrice: -1},
+			wantError: true,
+			errorMsg:  "activation_price 不能为负数",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateDecision(&tt.decision, 1000.0, 10, 5)
+			if (err != nil) != tt.wantError {
+				t.Errorf("validateDecision() error = %v, wantError %v", err, tt.wantError)
+				return
+			}
+			if tt.wantError && tt.errorMsg != "" && !contains(err.Error(), tt.errorMsg) {
+				t.Errorf("错误信息不匹配: got %q, want to contain %q", err.Error(), tt.errorMsg)
+			}
+		})
+	}
+}
+
+// TestTakeProfitLevelsValidation 测试分批止盈参数验证
+func TestTakeProfitLevelsValidation(t *testing.T) {
+	openLong := func(levels []TakeProfitLevel, takeProfit float64) Decision {
+		return Decision{
+			Symbol:           "BTCUSDT",
+			Action:           "open_long",
+			Leverage:         5,
+			PositionSizeUSD:  1000,
+			StopLoss:         95000,
+			TakeProfit:       takeProfit,
+			TakeProfitLevels: levels,
+		}
+	}
+
+	tests := []struct {
+		name      string
+		decision  Decision
+		wantError bool
+		errorMsg  string
+	}{
+		{
+			name: "三档止盈_省略take_profit",
+			decision: openLong([]TakeProfitLevel{
+				{Price: 102000, Percentage: 30}, {Price: 105000, Percentage: 30}, {Price: 110000},
+			}, 0),
+		},
+		{
+			name: "空单两档止盈",
+			decision: Decision{
+				Symbol: "ETHUSDT", Action: "open_short", Leverage: 5, PositionSizeUSD: 500,
+				StopLoss: 4000, BreakevenAfterTP1: true,
+				TakeProfitLevels: []TakeProfitLevel{{Price: 3600, Percentage: 50}, {Price: 3000, Percentage: 50}},
+			},
+		},
+		{
+			name:      "档位未按方向递进",
+			decision:  openLong([]TakeProfitLevel{{Price: 105000, Percentage: 50}, {Price: 102000}}, 0),
+			wantError: true,
+			errorMsg:  "第2档止盈价",
+		},
+		{
+			name:      "档位低于止损",
+			decision:  openLong([]TakeProfitLevel{{Price: 94000, Percentage: 50}, {Price: 110000}}, 0),
+			wantError: true,
+			errorMsg:  "第1档止盈价",
+		},
+		{
+			name:      "百分比合计超过100",
+			decision:  openLong([]TakeProfitLevel{{Price: 102000, Percentage: 60}, {Price: 110000, Percentage: 60}}, 0),
+			wantError: true,
+			errorMsg:  "不能超过100%",
+		},
+		{
+			name:      "中间档缺少百分比",
+			decision:  openLong([]TakeProfitLevel{{Price: 102000}, {Price: 110000}}, 0),
+			wantError: true,
+			errorMsg:  "平仓百分比必须大于0",
+		},
+		{
+			name:      "take_profit与最后一档不一致",
+			decision:  openLong([]TakeProfitLevel{{Price: 102000, Percentage: 50}, {Price: 110000}}, 108000),
+			wantError: true,
+			errorMsg:  "必须等于最后一档止盈价",
+		},
+		{
+			name: "档位超过上限",
+			decision: openLong([]TakeProfitLevel{
+				{Price: 101000, Percentage: 10}, {Price: 102000, Percentage: 10}, {Price: 103000, Percentage: 10},
+				{Price: 104000, Percentage: 10}, {Price: 105000, Percentage: 10}, {Price: 110000},
+			}, 0),
+			wantError: true,
+			errorMsg:  "最多 5 档",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateDecision(&tt.decision, 1000.0, 10, 5)
+			if (err != nil) != tt.wantError {
+				t.Errorf("validateDecision() error = %v, wantError %v", err, tt.wantError)
+				return
+			}
+			if tt.wantError && tt.errorMsg != "" && !contains(err.Error(), tt.errorMsg) {
+				t.Errorf("错误信息不匹配: got %q, want to contain %q", err.Error(), tt.errorMsg)
+			}
+		})
+	}
+}
+
+// TestTakeProfitLadderTemplate 测试交易员配置中的分批止盈模板
+func TestTakeProfitLadderTemplate(t *testing.T) {
+	steps, err := ParseTakeProfitLadder(`[{"ratio":0.5,"percentage":30},{"ratio":0.75,"percentage":30},{"ratio":1}]`)
+	if err != nil {
+		t.Fatalf("ParseTakeProfitLadder() error = %v", err)
+	}
+
+	levels := BuildTakeProfitLadder(2000, 1800, steps)
+	wantPrices := []float64{1900, 1850, 1800}
+	if len(levels) != len(wantPrices) {
+		t.Fatalf("档位数量 = %d, want %d", len(levels), len(wantPrices))
+	}
+	for i, want := range wantPrices {
+		if levels[i].Price != want {
+			t.Errorf("第%d档价格 = %.2f, want %.2f", i+1, levels[i].Price, want)
+		}
+	}
+
+	quantities := TakeProfitLevelQuantities(10, levels)
+	if quantities[0] != 3 || quantities[1] != 3 || quantities[2] != 4 {
+		t.Errorf("TakeProfitLevelQuantities() = %v, want [3 3 4]", quantities)
+	}
+
+	if steps, err := ParseTakeProfitLadder(""); err != nil || steps != nil {
+		t.Errorf("空配置应表示不启用: steps=%v err=%v", steps, err)
+	}
+	if _, err := ParseTakeProfitLadder(`[{"ratio":0.5,"percentage":50},{"ratio":0.8}]`); err == nil {
+		t.Error("最后一档 ratio 不为 1 时应报错")
+	}
+	if _, err := ParseTakeProfitLadder(`[{"ratio":0.8,"percentage":50},{"ratio":0.5,"percentage":20},{"ratio":1}]`); err == nil {
+		t.Error("ratio 未递增时应报错")
+	}
+}
+
 // contains 检查字符串是否包含子串（辅助函数）
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(substr) == 0 ||
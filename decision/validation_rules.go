@@ -0,0 +1,361 @@
+package decision
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"nofx/market"
+	"strings"
+)
+
+// 决策校验规则名称（按执行顺序排列）
+const (
+	RuleAction           = "action"             // 动作必须有效
+	RuleOpenParams       = "open_params"        // 开仓必须提供杠杆、仓位、止损止盈且方向正确
+	RuleLeverageCap      = "leverage_cap"       // 杠杆超过配置上限时修正为上限
+	RulePositionValueCap = "position_value_cap" // 单币种仓位价值不超过账户净值的倍数
+	RuleOrderType        = "order_type"         // 下单方式及限价参数
+	RuleTakeProfitLevels = "take_profit_levels" // 分批止盈档位
+	RuleRiskReward       = "risk_reward"        // 按当前价（限价单按挂单价）计算的最小风险回报比
+	RuleAdjustParams     = "adjust_params"      // 调整止损止盈、部分平仓、追踪止损的参数
+)
+
+// 校验规则默认参数
+const (
+	DefaultMajorPositionMultiple = 200.0 // 主流币单币种仓位价值上限（账户净值倍数）
+	DefaultAltPositionMultiple   = 100.0 // 山寨币单币种仓位价值上限（账户净值倍数）
+	DefaultMinRiskReward         = 3.0   // 最小风险回报比
+)
+
+// DefaultMajorCoins 默认使用 BTC/ETH 杠杆和仓位上限的主流币
+var DefaultMajorCoins = []string{"BTCUSDT", "ETHUSDT", "SOLUSDT", "BNBUSDT"}
+
+// ValidationConfig 决策校验规则链配置（按交易员保存为 JSON），零值即默认配置
+type ValidationConfig struct {
+	Disabled              []string `json:"disabled,omitempty"`                // 关闭的规则，仅 leverage_cap/position_value_cap/risk_reward 可关闭
+	MajorCoins            []string `json:"major_coins,omitempty"`             // 主流币列表，默认 BTC/ETH/SOL/BNB
+	MajorPositionMultiple float64  `json:"major_position_multiple,omitempty"` // 主流币仓位价值上限（账户净值倍数），默认 200
+	AltPositionMultiple   float64  `json:"alt_position_multiple,omitempty"`   // 山寨币仓位价值上限（账户净值倍数），默认 100
+	MinRiskReward         float64  `json:"min_risk_reward,omitempty"`         // 最小风险回报比，默认 3.0
+}
+
+// RuleRejection 被校验规则拒绝的决策，写入决策日志用于调整规则
+type RuleRejection struct {
+	Rule   string `json:"rule"`
+	Symbol string `json:"symbol"`
+	Action string `json:"action"`
+	Reason string `json:"reason"`
+}
+
+// validationRule 校验链中的一条规则
+type validationRule struct {
+	name     string
+	optional bool // 可以按交易员关闭
+	check    func(d *Decision, v *decisionValidator) error
+}
+
+// validationChain 决策校验规则链，按顺序执行，遇到第一条拒绝即停止
+var validationChain = []validationRule{
+	{name: RuleAction, check: checkAction},
+	{name: RuleOpenParams, check: checkOpenParams},
+	{name: RuleLeverageCap, optional: true, check: checkLeverageCap},
+	{name: RulePositionValueCap, optional: true, check: checkPositionValueCap},
+	{name: RuleOrderType, check: checkOrderType},
+	{name: RuleTakeProfitLevels, check: checkTakeProfitLevels},
+	{name: RuleRiskReward, optional: true, check: checkRiskReward},
+	{name: RuleAdjustParams, check: checkAdjustParams},
+}
+
+// ValidationRuleNames 返回校验链中的所有规则名称
+func ValidationRuleNames() []string {
+	names := make([]string, 0, len(validationChain))
+	for _, rule := range validationChain {
+		names = append(names, rule.name)
+	}
+	return names
+}
+
+// Validate 校验配置：关闭的规则必须存在且可关闭，倍数和风险回报比不能为负
+func (c ValidationConfig) Validate() error {
+	for _, name := range c.Disabled {
+		rule, ok := findValidationRule(name)
+		if !ok {
+			return fmt.Errorf("未知的校验规则: %s（可选 %s）", name, strings.Join(ValidationRuleNames(), "/"))
+		}
+		if !rule.optional {
+			return fmt.Errorf("校验规则 %s 不能关闭", name)
+		}
+	}
+	if c.MajorPositionMultiple < 0 || c.AltPositionMultiple < 0 {
+		return fmt.Errorf("仓位价值倍数不能为负数")
+	}
+	if c.MinRiskReward < 0 {
+		return fmt.Errorf("最小风险回报比不能为负数: %.2f", c.MinRiskReward)
+	}
+	return nil
+}
+
+// ParseValidationConfig 解析并校验交易员配置中的校验规则（JSON 对象），空字符串表示使用默认规则
+func ParseValidationConfig(raw string) (ValidationConfig, error) {
+	var cfg ValidationConfig
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return cfg, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &cfg); err != nil {
+		return ValidationConfig{}, fmt.Errorf("校验规则配置格式错误: %w", err)
+	}
+	for i, name := range cfg.Disabled {
+		cfg.Disabled[i] = strings.ToLower(strings.TrimSpace(name))
+	}
+	for i, symbol := range cfg.MajorCoins {
+		cfg.MajorCoins[i] = strings.ToUpper(strings.TrimSpace(symbol))
+	}
+	if err := cfg.Validate(); err != nil {
+		return ValidationConfig{}, err
+	}
+	return cfg, nil
+}
+
+func findValidationRule(name string) (validationRule, bool) {
+	for _, rule := range validationChain {
+		if rule.name == name {
+			return rule, true
+		}
+	}
+	return validationRule{}, false
+}
+
+// decisionValidator 按规则链校验决策，并记录被拒绝的决策
+type decisionValidator struct {
+	accountEquity   float64
+	btcEthLeverage  int
+	altcoinLeverage int
+	marketData      map[string]*market.Data // 当前价来源，缺失时跳过风险回报比检查
+	config          ValidationConfig
+
+	rejections []RuleRejection
+}
+
+func newDecisionValidator(accountEquity float64, btcEthLeverage, altcoinLeverage int, marketData map[string]*market.Data, config ValidationConfig) *decisionValidator {
+	return &decisionValidator{
+		accountEquity:   accountEquity,
+		btcEthLeverage:  btcEthLeverage,
+		altcoinLeverage: altcoinLeverage,
+		marketData:      marketData,
+		config:          config,
+	}
+}
+
+// newContextValidator 使用交易上下文中的账户、行情和校验配置创建校验器
+func newContextValidator(ctx *Context) *decisionValidator {
+	return newDecisionValidator(ctx.Account.TotalEquity, ctx.BTCETHLeverage, ctx.AltcoinLeverage, ctx.MarketDataMap, ctx.Validation)
+}
+
+func (v *decisionValidator) ruleDisabled(name string) bool {
+	for _, disabled := range v.config.Disabled {
+		if disabled == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *decisionValidator) isMajorCoin(symbol string) bool {
+	majors := v.config.MajorCoins
+	if len(majors) == 0 {
+		majors = DefaultMajorCoins
+	}
+	for _, major := range majors {
+		if symbol == major {
+			return true
+		}
+	}
+	return false
+}
+
+// validate 依次执行规则链，返回第一条拒绝（同时记录到 rejections）
+func (v *decisionValidator) validate(d *Decision) error {
+	for _, rule := range validationChain {
+		if rule.optional && v.ruleDisabled(rule.name) {
+			continue
+		}
+		if err := rule.check(d, v); err != nil {
+			v.rejections = append(v.rejections, RuleRejection{Rule: rule.name, Symbol: d.Symbol, Action: d.Action, Reason: err.Error()})
+			log.Printf("⚠️  [%s] 拒绝 %s %s: %v", rule.name, d.Symbol, d.Action, err)
+			return err
+		}
+	}
+	return nil
+}
+
+// validateAll 校验所有决策，记录每条被拒绝的决策，返回第一个错误
+func (v *decisionValidator) validateAll(decisions []Decision) error {
+	var firstErr error
+	for i, decision := range decisions {
+		if err := v.validate(&decision); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("决策 #%d 验证失败: %w", i+1, err)
+		}
+	}
+	return firstErr
+}
+
+func checkAction(d *Decision, v *decisionValidator) error {
+	for _, action := range decisionActions {
+		if d.Action == action {
+			return nil
+		}
+	}
+	return fmt.Errorf("无效的action: %s", d.Action)
+}
+
+func checkOpenParams(d *Decision, v *decisionValidator) error {
+	if !isOpenAction(d.Action) {
+		return nil
+	}
+	if d.Leverage <= 0 {
+		return fmt.Errorf("杠杆必须大于0: %d", d.Leverage)
+	}
+	if d.PositionSizeUSD <= 0 {
+		return fmt.Errorf("仓位大小必须大于0: %.2f", d.PositionSizeUSD)
+	}
+
+	// 分批止盈：take_profit 可省略，以最后一档作为最终止盈价
+	if len(d.TakeProfitLevels) > 0 {
+		final := d.TakeProfitLevels[len(d.TakeProfitLevels)-1].Price
+		if d.TakeProfit <= 0 {
+			d.TakeProfit = final
+		} else if math.Abs(d.TakeProfit-final) > 1e-9 {
+			return fmt.Errorf("take_profit %.4f 必须等于最后一档止盈价 %.4f", d.TakeProfit, final)
+		}
+	}
+	if d.StopLoss <= 0 || d.TakeProfit <= 0 {
+		return fmt.Errorf("止损和止盈必须大于0")
+	}
+
+	// 验证止损止盈的合理性
+	if d.Action == "open_long" && d.StopLoss >= d.TakeProfit {
+		return fmt.Errorf("做多时止损价必须小于止盈价")
+	}
+	if d.Action == "open_short" && d.StopLoss <= d.TakeProfit {
+		return fmt.Errorf("做空时止损价必须大于止盈价")
+	}
+	return nil
+}
+
+// checkLeverageCap 杠杆超限时自动修正为上限值（而不是直接拒绝决策）
+func checkLeverageCap(d *Decision, v *decisionValidator) error {
+	if !isOpenAction(d.Action) {
+		return nil
+	}
+	maxLeverage := v.altcoinLeverage
+	if v.isMajorCoin(d.Symbol) {
+		maxLeverage = v.btcEthLeverage
+	}
+	if d.Leverage > maxLeverage {
+		log.Printf("⚠️  [Leverage Fallback] %s 杠杆超限 (%dx > %dx)，自动调整为上限值 %dx",
+			d.Symbol, d.Leverage, maxLeverage, maxLeverage)
+		d.Leverage = maxLeverage
+	}
+	return nil
+}
+
+func checkPositionValueCap(d *Decision, v *decisionValidator) error {
+	if !isOpenAction(d.Action) {
+		return nil
+	}
+	multiple := v.config.AltPositionMultiple
+	if multiple <= 0 {
+		multiple = DefaultAltPositionMultiple
+	}
+	coinType := "山寨币"
+	if v.isMajorCoin(d.Symbol) {
+		multiple = v.config.MajorPositionMultiple
+		if multiple <= 0 {
+			multiple = DefaultMajorPositionMultiple
+		}
+		coinType = "主流币"
+	}
+
+	// 加1%容差以避免浮点数精度问题
+	maxPositionValue := v.accountEquity * multiple
+	if d.PositionSizeUSD > maxPositionValue*1.01 {
+		return fmt.Errorf("%s单币种仓位价值不能超过%.0f USDT（%.0f倍账户净值），实际: %.0f", coinType, maxPositionValue, multiple, d.PositionSizeUSD)
+	}
+	return nil
+}
+
+func checkOrderType(d *Decision, v *decisionValidator) error {
+	if !isOpenAction(d.Action) {
+		return nil
+	}
+	return validateOrderType(d)
+}
+
+func checkTakeProfitLevels(d *Decision, v *decisionValidator) error {
+	if !isOpenAction(d.Action) {
+		return nil
+	}
+	return validateTakeProfitLevels(d)
+}
+
+// checkRiskReward 以实际入场价计算风险回报比：限价单用挂单价，市价单用 MarketDataMap 中的当前价
+func checkRiskReward(d *Decision, v *decisionValidator) error {
+	if !isOpenAction(d.Action) {
+		return nil
+	}
+
+	var entryPrice float64
+	if d.IsLimitOrder() {
+		entryPrice = d.LimitPrice
+	} else if data, ok := v.marketData[d.Symbol]; ok && data != nil {
+		entryPrice = data.CurrentPrice
+	}
+	if entryPrice <= 0 {
+		log.Printf("⚠️  [%s] %s 缺少当前价，跳过风险回报比检查", RuleRiskReward, d.Symbol)
+		return nil
+	}
+
+	var riskPercent, rewardPercent, riskRewardRatio float64
+	if d.Action == "open_long" {
+		riskPercent = (entryPrice - d.StopLoss) / entryPrice * 100
+		rewardPercent = (d.TakeProfit - entryPrice) / entryPrice * 100
+	} else {
+		riskPercent = (d.StopLoss - entryPrice) / entryPrice * 100
+		rewardPercent = (entryPrice - d.TakeProfit) / entryPrice * 100
+	}
+	if riskPercent > 0 {
+		riskRewardRatio = rewardPercent / riskPercent
+	}
+
+	minRiskReward := v.config.MinRiskReward
+	if minRiskReward <= 0 {
+		minRiskReward = DefaultMinRiskReward
+	}
+	if riskRewardRatio < minRiskReward {
+		return fmt.Errorf("风险回报比过低(%.2f:1)，必须≥%.1f:1 [入场:%.4f 风险:%.2f%% 收益:%.2f%%] [止损:%.2f 止盈:%.2f]",
+			riskRewardRatio, minRiskReward, entryPrice, riskPercent, rewardPercent, d.StopLoss, d.TakeProfit)
+	}
+	return nil
+}
+
+func checkAdjustParams(d *Decision, v *decisionValidator) error {
+	switch d.Action {
+	case "update_stop_loss":
+		if d.NewStopLoss <= 0 {
+			return fmt.Errorf("新止损价格必须大于0: %.2f", d.NewStopLoss)
+		}
+	case "update_take_profit":
+		if d.NewTakeProfit <= 0 {
+			return fmt.Errorf("新止盈价格必须大于0: %.2f", d.NewTakeProfit)
+		}
+	case "partial_close":
+		if d.ClosePercentage <= 0 || d.ClosePercentage > 100 {
+			return fmt.Errorf("平仓百分比必须在0-100之间: %.1f", d.ClosePercentage)
+		}
+	case "set_trailing_stop":
+		return validateTrailingStop(d)
+	}
+	return nil
+}
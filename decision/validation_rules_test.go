@@ -0,0 +1,105 @@
+package decision
+
+import (
+	"nofx/market"
+	"strings"
+	"testing"
+)
+
+func openLong(symbol string, sl, tp float64) Decision {
+	return Decision{Symbol: symbol, Action: "open_long", Leverage: 5, PositionSizeUSD: 500, StopLoss: sl, TakeProfit: tp}
+}
+
+func TestRiskRewardRule_UsesCurrentPrice(t *testing.T) {
+	prices := map[string]*market.Data{"ETHUSDT": {Symbol: "ETHUSDT", CurrentPrice: 100}}
+
+	// 入场 100，止损 90，止盈 120：风险回报比 2:1
+	v := newDecisionValidator(1000, 10, 5, prices, ValidationConfig{})
+	d := openLong("ETHUSDT", 90, 120)
+	err := v.validate(&d)
+	if err == nil || !strings.Contains(err.Error(), "风险回报比过低(2.00:1)") {
+		t.Fatalf("应按当前价计算风险回报比，实际: %v", err)
+	}
+	if len(v.rejections) != 1 || v.rejections[0].Rule != RuleRiskReward || v.rejections[0].Symbol != "ETHUSDT" {
+		t.Errorf("拒绝应记录规则名称: %+v", v.rejections)
+	}
+
+	v = newDecisionValidator(1000, 10, 5, prices, ValidationConfig{MinRiskReward: 1.5})
+	d = openLong("ETHUSDT", 90, 120)
+	if err := v.validate(&d); err != nil {
+		t.Errorf("降低最小风险回报比后应通过: %v", err)
+	}
+
+	v = newDecisionValidator(1000, 10, 5, prices, ValidationConfig{Disabled: []string{RuleRiskReward}})
+	d = openLong("ETHUSDT", 90, 120)
+	if err := v.validate(&d); err != nil {
+		t.Errorf("关闭规则后应通过: %v", err)
+	}
+
+	// 当前价已跌破止损
+	prices["ETHUSDT"].CurrentPrice = 85
+	v = newDecisionValidator(1000, 10, 5, prices, ValidationConfig{})
+	d = openLong("ETHUSDT", 90, 200)
+	if err := v.validate(&d); err == nil {
+		t.Error("当前价位于止损另一侧时应拒绝")
+	}
+}
+
+func TestPositionRules_MajorCoinsConfigurable(t *testing.T) {
+	cfg := ValidationConfig{MajorCoins: []string{"BTCUSDT"}, AltPositionMultiple: 0.4}
+	v := newDecisionValidator(1000, 10, 3, nil, cfg)
+
+	d := openLong("SOLUSDT", 90, 200)
+	d.Leverage = 8
+	err := v.validate(&d)
+	if err == nil || !strings.Contains(err.Error(), "山寨币单币种仓位价值不能超过400") {
+		t.Fatalf("SOL 不在主流币列表时按山寨币上限校验，实际: %v", err)
+	}
+	if d.Leverage != 3 {
+		t.Errorf("SOL 应使用山寨币杠杆上限，实际 %dx", d.Leverage)
+	}
+	if v.rejections[0].Rule != RulePositionValueCap {
+		t.Errorf("unexpected rule: %s", v.rejections[0].Rule)
+	}
+
+	d = openLong("BTCUSDT", 90000, 110000)
+	d.PositionSizeUSD = 150000
+	if err := v.validate(&d); err != nil {
+		t.Errorf("主流币默认 200 倍上限内应通过: %v", err)
+	}
+}
+
+func TestParseValidationConfig(t *testing.T) {
+	cfg, err := ParseValidationConfig(`{"disabled":[" Risk_Reward "],"major_coins":["btcusdt"],"min_risk_reward":2}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Disabled[0] != RuleRiskReward || cfg.MajorCoins[0] != "BTCUSDT" || cfg.MinRiskReward != 2 {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+
+	if cfg, err := ParseValidationConfig(""); err != nil || len(cfg.Disabled) != 0 {
+		t.Errorf("空配置应使用默认规则: %+v, %v", cfg, err)
+	}
+	for _, raw := range []string{`{"disabled":["unknown"]}`, `{"disabled":["action"]}`, `{"min_risk_reward":-1}`, `[1]`} {
+		if _, err := ParseValidationConfig(raw); err == nil {
+			t.Errorf("ParseValidationConfig(%s) 应返回错误", raw)
+		}
+	}
+}
+
+func TestGetFullDecision_RecordsRuleRejections(t *testing.T) {
+	reply := "<reasoning>r</reasoning>\n<decision>\n```json\n" +
+		`[{"symbol": "ETHUSDT", "action": "open_long", "leverage": 5, "position_size_usd": 500, "stop_loss": 2900, "take_profit": 3100, "reasoning": "x"}]` +
+		"\n```\n</decision>"
+	ctx := newToolCallContext()
+	ctx.DecisionMode = DecisionModeText
+
+	fd, err := GetFullDecisionWithCustomPrompt(ctx, &fakeAIClient{textResponse: reply}, "", false, "")
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	if fd == nil || len(fd.RuleRejections) != 1 || fd.RuleRejections[0].Rule != RuleRiskReward {
+		t.Fatalf("被拒绝的决策应带上规则名称: %+v", fd)
+	}
+}
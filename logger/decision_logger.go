@@ -27,6 +27,87 @@ type DecisionRecord struct {
 	ErrorMessage   string             `json:"error_message"`   // 错误信息（如果有）
 	// AIRequestDurationMs 记录 AI API 调用耗时（毫秒），方便评估调用性能
 	AIRequestDurationMs int64 `json:"ai_request_duration_ms,omitempty"`
+	// RiskEvent 风控熔断事件（仅熔断记录有值）
+	RiskEvent *RiskEvent `json:"risk_event,omitempty"`
+	// EnsembleVote 集成决策的投票方式（仅集成决策有值）
+	EnsembleVote string `json:"ensemble_vote,omitempty"`
+	// EnsembleMembers 集成决策中每个模型的原始输出
+	EnsembleMembers []EnsembleMemberRecord `json:"ensemble_members,omitempty"`
+	// RuleRejections 被决策校验规则拒绝的决策（含规则名称，用于调整规则）
+	RuleRejections []RuleRejection `json:"rule_rejections,omitempty"`
+	// PromptVersions 本周期使用的提示词版本（A/B 实验按币种分组时每组一个）
+	PromptVersions []PromptVersion `json:"prompt_versions,omitempty"`
+	// PromptTokens 估算的输入 prompt token 数
+	PromptTokens int `json:"prompt_tokens,omitempty"`
+	// PromptTrims 超出 token 预算时对 prompt 做的压缩
+	PromptTrims []string `json:"prompt_trims,omitempty"`
+	// AIUsage 本周期所有 AI 调用的 token 用量与费用（含集成决策的各个模型）
+	AIUsage *AIUsage `json:"ai_usage,omitempty"`
+	// AIModel 实际给出回答的模型（启用故障转移时可能是备用模型）
+	AIModel string `json:"ai_model,omitempty"`
+	// SafeMode 所有AI模型不可用，本周期由安全模式规则管理持仓
+	SafeMode bool `json:"safe_mode,omitempty"`
+}
+
+// AIUsage AI 调用的 token 用量与费用
+type AIUsage struct {
+	Calls            int     `json:"calls"`             // 成功的 API 调用次数
+	PromptTokens     int     `json:"prompt_tokens"`     // 输入 token
+	CompletionTokens int     `json:"completion_tokens"` // 输出 token（含思考 token）
+	ReasoningTokens  int     `json:"reasoning_tokens"`  // 其中的思考 token
+	CostUSD          float64 `json:"cost_usd"`          // 费用（美元）
+}
+
+// Add 累加另一份用量
+func (u *AIUsage) Add(other *AIUsage) {
+	if other == nil {
+		return
+	}
+	u.Calls += other.Calls
+	u.PromptTokens += other.PromptTokens
+	u.CompletionTokens += other.CompletionTokens
+	u.ReasoningTokens += other.ReasoningTokens
+	u.CostUSD += other.CostUSD
+}
+
+// PromptVersion 决策使用的提示词版本
+type PromptVersion struct {
+	Hash         string `json:"hash"`                    // 版本哈希
+	Arm          string `json:"arm,omitempty"`           // A/B 实验分组
+	Template     string `json:"template,omitempty"`      // 模板名称
+	TemplateHash string `json:"template_hash,omitempty"` // 模板原文哈希
+	CustomHash   string `json:"custom_hash,omitempty"`   // 自定义提示词哈希
+	OverrideBase bool   `json:"override_base,omitempty"` // 是否只使用自定义提示词
+}
+
+// RuleRejection 被校验规则拒绝的决策
+type RuleRejection struct {
+	Rule   string `json:"rule"`   // 规则名称
+	Symbol string `json:"symbol"` // 币种
+	Action string `json:"action"` // 决策动作
+	Reason string `json:"reason"` // 拒绝原因
+}
+
+// EnsembleMemberRecord 集成决策中单个模型的输出
+type EnsembleMemberRecord struct {
+	Name         string `json:"name"`                    // 模型名称
+	RawResponse  string `json:"raw_response,omitempty"`  // AI 原始输出
+	CoTTrace     string `json:"cot_trace,omitempty"`     // 思维链
+	DecisionJSON string `json:"decision_json,omitempty"` // 该模型给出的决策JSON
+	Error        string `json:"error,omitempty"`         // 调用或解析失败原因
+	DurationMs   int64  `json:"duration_ms,omitempty"`   // AI 调用耗时（毫秒）
+	FromCache    bool   `json:"from_cache,omitempty"`    // 是否来自回测 AI 缓存
+}
+
+// RiskEvent 风控熔断事件
+type RiskEvent struct {
+	Metric         string    `json:"metric"`          // daily_loss / max_drawdown
+	ValuePct       float64   `json:"value_pct"`       // 触发时的日亏损/回撤百分比
+	LimitPct       float64   `json:"limit_pct"`       // 配置的上限
+	Equity         float64   `json:"equity"`          // 触发时的账户净值
+	Baseline       float64   `json:"baseline"`        // 当日起始净值或净值高水位
+	ClosePositions bool      `json:"close_positions"` // 是否平掉了所有持仓
+	PausedUntil    time.Time `json:"paused_until"`    // 暂停交易截止时间
 }
 
 // AccountSnapshot 账户状态快照
@@ -53,7 +134,7 @@ type PositionSnapshot struct {
 
 // DecisionAction 决策动作
 type DecisionAction struct {
-	Action    string    `json:"action"`    // open_long, open_short, close_long, close_short, update_stop_loss, update_take_profit, partial_close
+	Action    string    `json:"action"`    // open_long, open_short, close_long, close_short, update_stop_loss, update_take_profit, partial_close, set_trailing_stop
 	Symbol    string    `json:"symbol"`    // 币种
 	Quantity  float64   `json:"quantity"`  // 数量（部分平仓时使用）
 	Leverage  int       `json:"leverage"`  // 杠杆（开仓时）
@@ -62,6 +143,8 @@ type DecisionAction struct {
 	Timestamp time.Time `json:"timestamp"` // 执行时间
 	Success   bool      `json:"success"`   // 是否成功
 	Error     string    `json:"error"`     // 错误信息
+	// PromptHash 产生该决策的提示词版本哈希
+	PromptHash string `json:"prompt_hash,omitempty"`
 }
 
 // IDecisionLogger 决策日志记录器接口
@@ -256,6 +339,8 @@ func (l *DecisionLogger) GetStatistics() (*Statistics, error) {
 	}
 
 	stats := &Statistics{}
+	now := time.Now().UTC()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
 
 	for _, file := range files {
 		if file.IsDir() {
@@ -275,6 +360,11 @@ func (l *DecisionLogger) GetStatistics() (*Statistics, error) {
 
 		stats.TotalCycles++
 
+		stats.AIUsage.Add(record.AIUsage)
+		if record.AIUsage != nil && !record.Timestamp.Before(monthStart) {
+			stats.AIMonthCostUSD += record.AIUsage.CostUSD
+		}
+
 		for _, action := range record.Decisions {
 			if action.Success {
 				switch action.Action {
@@ -306,6 +396,10 @@ type Statistics struct {
 	FailedCycles        int `json:"failed_cycles"`
 	TotalOpenPositions  int `json:"total_open_positions"`
 	TotalClosePositions int `json:"total_close_positions"`
+	// AIUsage 保留的决策记录中 AI 调用的累计用量与费用
+	AIUsage AIUsage `json:"ai_usage"`
+	// AIMonthCostUSD 本自然月（UTC）的 AI 费用（美元）
+	AIMonthCostUSD float64 `json:"ai_month_cost_usd"`
 }
 
 // TradeOutcome 单笔交易结果
@@ -324,6 +418,8 @@ type TradeOutcome struct {
 	OpenTime      time.Time `json:"open_time"`      // 开仓时间
 	CloseTime     time.Time `json:"close_time"`     // 平仓时间
 	WasStopLoss   bool      `json:"was_stop_loss"`  // 是否止损
+	// PromptHash 开仓决策使用的提示词版本
+	PromptHash string `json:"prompt_hash,omitempty"`
 }
 
 // PerformanceAnalysis 交易表现分析
@@ -340,6 +436,22 @@ type PerformanceAnalysis struct {
 	SymbolStats   map[string]*SymbolPerformance `json:"symbol_stats"`   // 各币种表现
 	BestSymbol    string                        `json:"best_symbol"`    // 表现最好的币种
 	WorstSymbol   string                        `json:"worst_symbol"`   // 表现最差的币种
+	// PromptStats 按提示词版本统计的表现（版本哈希 -> 统计），用于对比 A/B 实验
+	PromptStats map[string]*PromptPerformance `json:"prompt_stats,omitempty"`
+}
+
+// PromptPerformance 单个提示词版本的表现统计
+type PromptPerformance struct {
+	PromptHash    string  `json:"prompt_hash"`   // 提示词版本哈希
+	Arm           string  `json:"arm,omitempty"` // A/B 实验分组
+	Template      string  `json:"template,omitempty"`
+	TotalTrades   int     `json:"total_trades"`   // 交易次数
+	WinningTrades int     `json:"winning_trades"` // 盈利次数
+	LosingTrades  int     `json:"losing_trades"`  // 亏损次数
+	WinRate       float64 `json:"win_rate"`       // 胜率
+	TotalPnL      float64 `json:"total_pn_l"`     // 总盈亏
+	AvgPnL        float64 `json:"avg_pn_l"`       // 平均盈亏
+	ProfitFactor  float64 `json:"profit_factor"`  // 盈亏比
 }
 
 // SymbolPerformance 币种表现统计
@@ -416,6 +528,7 @@ func (l *DecisionLogger) AnalyzePerformance(lookbackCycles int) (*PerformanceAna
 						"accumulatedPnL":     0.0,
 						"partialCloseCount":  0,
 						"partialCloseVolume": 0.0,
+						"promptHash":         actionPromptHash(record, action),
 					}
 
 				case "close_long", "close_short", "auto_close_long", "auto_close_short":
@@ -513,6 +626,7 @@ func (l *DecisionLogger) AnalyzePerformance(lookbackCycles int) (*PerformanceAna
 						"accumulatedPnL":     0.0,             // 🔧 BUG FIX：累積部分平倉盈虧
 						"partialCloseCount":  0,               // 🔧 BUG FIX：部分平倉次數
 						"partialCloseVolume": 0.0,             // 🔧 BUG FIX：部分平倉總量
+						"promptHash":         actionPromptHash(record, action),
 					}
 				}
 
@@ -524,6 +638,7 @@ func (l *DecisionLogger) AnalyzePerformance(lookbackCycles int) (*PerformanceAna
 					side := openPos["side"].(string)
 					quantity := openPos["quantity"].(float64)
 					leverage := openPos["leverage"].(int)
+					promptHash, _ := openPos["promptHash"].(string)
 
 					// 🔧 BUG FIX：取得追蹤字段（若不存在則初始化）
 					remainingQty, _ := openPos["remainingQuantity"].(float64)
@@ -586,6 +701,7 @@ func (l *DecisionLogger) AnalyzePerformance(lookbackCycles int) (*PerformanceAna
 								Duration:      action.Timestamp.Sub(openTime).String(),
 								OpenTime:      openTime,
 								CloseTime:     action.Timestamp,
+								PromptHash:    promptHash,
 							}
 
 							analysis.RecentTrades = append(analysis.RecentTrades, outcome)
@@ -646,6 +762,7 @@ func (l *DecisionLogger) AnalyzePerformance(lookbackCycles int) (*PerformanceAna
 							Duration:      action.Timestamp.Sub(openTime).String(),
 							OpenTime:      openTime,
 							CloseTime:     action.Timestamp,
+							PromptHash:    promptHash,
 						}
 
 						analysis.RecentTrades = append(analysis.RecentTrades, outcome)
@@ -727,6 +844,9 @@ func (l *DecisionLogger) AnalyzePerformance(lookbackCycles int) (*PerformanceAna
 		}
 	}
 
+	// 按提示词版本统计（需在截取最近交易之前）
+	analysis.PromptStats = buildPromptStats(analysis.RecentTrades, records)
+
 	// 只保留最近的交易（倒序：最新的在前）
 	if len(analysis.RecentTrades) > 10 {
 		// 反转数组，让最新的在前
@@ -747,6 +867,65 @@ func (l *DecisionLogger) AnalyzePerformance(lookbackCycles int) (*PerformanceAna
 	return analysis, nil
 }
 
+// actionPromptHash 获取决策动作对应的提示词版本，旧记录没有逐条标记时使用周期唯一的版本
+func actionPromptHash(record *DecisionRecord, action DecisionAction) string {
+	if action.PromptHash != "" {
+		return action.PromptHash
+	}
+	if len(record.PromptVersions) == 1 {
+		return record.PromptVersions[0].Hash
+	}
+	return ""
+}
+
+// buildPromptStats 按开仓时的提示词版本汇总交易表现，没有版本信息的交易不参与统计
+func buildPromptStats(trades []TradeOutcome, records []*DecisionRecord) map[string]*PromptPerformance {
+	stats := make(map[string]*PromptPerformance)
+	grossWin := make(map[string]float64)
+	grossLoss := make(map[string]float64)
+	for _, trade := range trades {
+		if trade.PromptHash == "" {
+			continue
+		}
+		ps, exists := stats[trade.PromptHash]
+		if !exists {
+			ps = &PromptPerformance{PromptHash: trade.PromptHash}
+			stats[trade.PromptHash] = ps
+		}
+		ps.TotalTrades++
+		ps.TotalPnL += trade.PnL
+		if trade.PnL > 0 {
+			ps.WinningTrades++
+			grossWin[trade.PromptHash] += trade.PnL
+		} else if trade.PnL < 0 {
+			ps.LosingTrades++
+			grossLoss[trade.PromptHash] -= trade.PnL
+		}
+	}
+	if len(stats) == 0 {
+		return nil
+	}
+
+	for _, record := range records {
+		for _, version := range record.PromptVersions {
+			if ps, ok := stats[version.Hash]; ok {
+				ps.Arm = version.Arm
+				ps.Template = version.Template
+			}
+		}
+	}
+	for hash, ps := range stats {
+		ps.WinRate = float64(ps.WinningTrades) / float64(ps.TotalTrades) * 100
+		ps.AvgPnL = ps.TotalPnL / float64(ps.TotalTrades)
+		if grossLoss[hash] > 0 {
+			ps.ProfitFactor = grossWin[hash] / grossLoss[hash]
+		} else if grossWin[hash] > 0 {
+			ps.ProfitFactor = 999.0
+		}
+	}
+	return stats
+}
+
 // calculateSharpeRatio 计算夏普比率
 // 基于账户净值的变化计算风险调整后收益
 func (l *DecisionLogger) calculateSharpeRatio(records []*DecisionRecord) float64 {
@@ -40,6 +40,14 @@ type ConfigFile struct {
 	MaxDailyLoss       float64               `json:"max_daily_loss"`
 	MaxDrawdown        float64               `json:"max_drawdown"`
 	StopTradingMinutes int                   `json:"stop_trading_minutes"`
+	RiskClosePositions bool                  `json:"risk_close_positions"`
+	DailyResetTimezone string                `json:"daily_reset_timezone"`
+	AIMonthlySpendCap  float64               `json:"ai_monthly_spend_cap"`
+	AIModelPrices      json.RawMessage       `json:"ai_model_prices"`
+	AIRateLimits       json.RawMessage       `json:"ai_rate_limits"`
+	MarketDataFallback string                `json:"market_data_fallback"`
+	MaxSlippagePct     float64               `json:"max_slippage_pct"`
+	LiquidityAction    string                `json:"liquidity_action"`
 	Leverage           config.LeverageConfig `json:"leverage"`
 	JWTSecret          string                `json:"jwt_secret"`
 	DataKLineTime      string                `json:"data_k_line_time"`
@@ -87,6 +95,34 @@ func syncConfigToDatabase(database *config.Database, configFile *ConfigFile) err
 		"max_daily_loss":       fmt.Sprintf("%.1f", configFile.MaxDailyLoss),
 		"max_drawdown":         fmt.Sprintf("%.1f", configFile.MaxDrawdown),
 		"stop_trading_minutes": strconv.Itoa(configFile.StopTradingMinutes),
+		"risk_close_positions": fmt.Sprintf("%t", configFile.RiskClosePositions),
+		"ai_monthly_spend_cap": fmt.Sprintf("%.2f", configFile.AIMonthlySpendCap),
+		"max_slippage_pct":     fmt.Sprintf("%.2f", configFile.MaxSlippagePct),
+	}
+
+	// 日盈亏重置时区未配置时保留数据库中的值（默认 UTC）
+	if configFile.DailyResetTimezone != "" {
+		configs["daily_reset_timezone"] = configFile.DailyResetTimezone
+	}
+
+	// 同步AI模型价格表（JSON格式，未配置时使用内置参考价）
+	if len(configFile.AIModelPrices) > 0 && string(configFile.AIModelPrices) != "null" {
+		configs["ai_model_prices"] = string(configFile.AIModelPrices)
+	}
+
+	// 行情备用数据源未配置时保留数据库中的值（默认 binance）
+	if configFile.MarketDataFallback != "" {
+		configs["market_data_fallback"] = configFile.MarketDataFallback
+	}
+
+	// 滑点超限处理方式未配置时保留数据库中的值（默认 shrink）
+	if configFile.LiquidityAction != "" {
+		configs["liquidity_action"] = configFile.LiquidityAction
+	}
+
+	// 同步AI请求限流配置（JSON格式，按提供商配置，未配置时不限流）
+	if len(configFile.AIRateLimits) > 0 && string(configFile.AIRateLimits) != "null" {
+		configs["ai_rate_limits"] = string(configFile.AIRateLimits)
 	}
 
 	// 同步default_coins（转换为JSON字符串存储）
@@ -271,6 +307,24 @@ func main() {
 		log.Printf("✓ 已配置OI Top API")
 	}
 
+	// 设置AI模型价格表（用于统计token费用）
+	modelPricesJSON, _ := database.GetSystemConfig("ai_model_prices")
+	if prices, err := mcp.ParseModelPrices(modelPricesJSON); err != nil {
+		log.Printf("⚠️  解析ai_model_prices配置失败: %v，使用内置参考价", err)
+	} else if len(prices) > 0 {
+		mcp.SetModelPrices(prices)
+		log.Printf("✓ 已加载自定义AI模型价格（共%d个）", len(prices))
+	}
+
+	// 设置AI请求限流（同一提供商+API Key 的所有交易员和回测共享）
+	rateLimitsJSON, _ := database.GetSystemConfig("ai_rate_limits")
+	if limits, err := mcp.ParseRateLimits(rateLimitsJSON); err != nil {
+		log.Printf("⚠️  解析ai_rate_limits配置失败: %v，不限流", err)
+	} else if len(limits) > 0 {
+		mcp.SetRateLimits(limits)
+		log.Printf("✓ 已加载AI请求限流配置（共%d个提供商）", len(limits))
+	}
+
 	// 创建TraderManager 与 BacktestManager
 	cfgForAI, cfgErr := config.LoadConfig("config.json")
 	if cfgErr != nil {
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"nofx/config"
+	"nofx/decision"
 	"nofx/trader"
 	"sort"
 	"strconv"
@@ -236,10 +237,20 @@ func (tm *TraderManager) addTraderFromDB(traderCfg *config.TraderRecord, aiModel
 		MaxDrawdown:           maxDrawdown,
 		StopTradingTime:       time.Duration(stopTradingMinutes) * time.Minute,
 		IsCrossMargin:         traderCfg.IsCrossMargin,
+		PendingOrderMaxCycles: traderCfg.PendingOrderMaxCycles,
+		TakeProfitLadder:      parseTakeProfitLadder(traderCfg),
+		BreakevenAfterTP1:     traderCfg.BreakevenAfterTP1,
+		DecisionMode:          traderCfg.DecisionMode,
+		ValidationConfig:      parseValidationConfig(traderCfg),
+		PromptExperiment:      parsePromptExperiment(traderCfg),
 		DefaultCoins:          defaultCoins,
 		TradingCoins:          tradingCoins,
 		SystemPromptTemplate:  traderCfg.SystemPromptTemplate, // 系统提示词模板
 	}
+	applyRiskControlOptions(&traderConfig, database)
+	applyMarketDataOptions(&traderConfig, database)
+	applyEnsembleOptions(&traderConfig, traderCfg, database)
+	applyFallbackOptions(&traderConfig, traderCfg, database)
 
 	// 根据交易所类型设置API密钥
 	if exchangeCfg.ID == "binance" {
@@ -248,6 +259,16 @@ func (tm *TraderManager) addTraderFromDB(traderCfg *config.TraderRecord, aiModel
 	} else if exchangeCfg.ID == "bybit" {
 		traderConfig.BybitAPIKey = exchangeCfg.APIKey
 		traderConfig.BybitSecretKey = exchangeCfg.SecretKey
+	} else if exchangeCfg.ID == "okx" {
+		traderConfig.OKXAPIKey = exchangeCfg.APIKey
+		traderConfig.OKXSecretKey = exchangeCfg.SecretKey
+		traderConfig.OKXPassphrase = exchangeCfg.Passphrase
+		traderConfig.OKXTestnet = exchangeCfg.Testnet
+	} else if exchangeCfg.ID == "bitget" {
+		traderConfig.BitgetAPIKey = exchangeCfg.APIKey
+		traderConfig.BitgetSecretKey = exchangeCfg.SecretKey
+		traderConfig.BitgetPassphrase = exchangeCfg.Passphrase
+		traderConfig.BitgetTestnet = exchangeCfg.Testnet
 	} else if exchangeCfg.ID == "hyperliquid" {
 		traderConfig.HyperliquidPrivateKey = exchangeCfg.APIKey // hyperliquid用APIKey存储private key
 		traderConfig.HyperliquidWalletAddr = exchangeCfg.HyperliquidWalletAddr
@@ -266,6 +287,10 @@ func (tm *TraderManager) addTraderFromDB(traderCfg *config.TraderRecord, aiModel
 		traderConfig.QwenKey = aiModelCfg.APIKey
 	} else if aiModelCfg.Provider == "deepseek" {
 		traderConfig.DeepSeekKey = aiModelCfg.APIKey
+	} else if aiModelCfg.Provider == "anthropic" {
+		traderConfig.AnthropicKey = aiModelCfg.APIKey
+	} else if aiModelCfg.Provider == "gemini" {
+		traderConfig.GeminiKey = aiModelCfg.APIKey
 	}
 
 	// 创建trader实例
@@ -350,9 +375,19 @@ func (tm *TraderManager) AddTraderFromDB(traderCfg *config.TraderRecord, aiModel
 		MaxDrawdown:           maxDrawdown,
 		StopTradingTime:       time.Duration(stopTradingMinutes) * time.Minute,
 		IsCrossMargin:         traderCfg.IsCrossMargin,
+		PendingOrderMaxCycles: traderCfg.PendingOrderMaxCycles,
+		TakeProfitLadder:      parseTakeProfitLadder(traderCfg),
+		BreakevenAfterTP1:     traderCfg.BreakevenAfterTP1,
+		DecisionMode:          traderCfg.DecisionMode,
+		ValidationConfig:      parseValidationConfig(traderCfg),
+		PromptExperiment:      parsePromptExperiment(traderCfg),
 		DefaultCoins:          defaultCoins,
 		TradingCoins:          tradingCoins,
 	}
+	applyRiskControlOptions(&traderConfig, database)
+	applyMarketDataOptions(&traderConfig, database)
+	applyEnsembleOptions(&traderConfig, traderCfg, database)
+	applyFallbackOptions(&traderConfig, traderCfg, database)
 
 	// 根据交易所类型设置API密钥
 	if exchangeCfg.ID == "binance" {
@@ -361,6 +396,16 @@ func (tm *TraderManager) AddTraderFromDB(traderCfg *config.TraderRecord, aiModel
 	} else if exchangeCfg.ID == "bybit" {
 		traderConfig.BybitAPIKey = exchangeCfg.APIKey
 		traderConfig.BybitSecretKey = exchangeCfg.SecretKey
+	} else if exchangeCfg.ID == "okx" {
+		traderConfig.OKXAPIKey = exchangeCfg.APIKey
+		traderConfig.OKXSecretKey = exchangeCfg.SecretKey
+		traderConfig.OKXPassphrase = exchangeCfg.Passphrase
+		traderConfig.OKXTestnet = exchangeCfg.Testnet
+	} else if exchangeCfg.ID == "bitget" {
+		traderConfig.BitgetAPIKey = exchangeCfg.APIKey
+		traderConfig.BitgetSecretKey = exchangeCfg.SecretKey
+		traderConfig.BitgetPassphrase = exchangeCfg.Passphrase
+		traderConfig.BitgetTestnet = exchangeCfg.Testnet
 	} else if exchangeCfg.ID == "hyperliquid" {
 		traderConfig.HyperliquidPrivateKey = exchangeCfg.APIKey // hyperliquid用APIKey存储private key
 		traderConfig.HyperliquidWalletAddr = exchangeCfg.HyperliquidWalletAddr
@@ -379,6 +424,10 @@ func (tm *TraderManager) AddTraderFromDB(traderCfg *config.TraderRecord, aiModel
 		traderConfig.QwenKey = aiModelCfg.APIKey
 	} else if aiModelCfg.Provider == "deepseek" {
 		traderConfig.DeepSeekKey = aiModelCfg.APIKey
+	} else if aiModelCfg.Provider == "anthropic" {
+		traderConfig.AnthropicKey = aiModelCfg.APIKey
+	} else if aiModelCfg.Provider == "gemini" {
+		traderConfig.GeminiKey = aiModelCfg.APIKey
 	}
 
 	// 创建trader实例
@@ -1040,27 +1089,37 @@ func (tm *TraderManager) loadSingleTrader(traderCfg *config.TraderRecord, aiMode
 
 	// 构建AutoTraderConfig
 	traderConfig := trader.AutoTraderConfig{
-		ID:                   traderCfg.ID,
-		Name:                 traderCfg.Name,
-		AIModel:              aiModelCfg.Provider, // 使用provider作为模型标识
-		Exchange:             exchangeCfg.ID,      // 使用exchange ID
-		InitialBalance:       traderCfg.InitialBalance,
-		BTCETHLeverage:       traderCfg.BTCETHLeverage,
-		AltcoinLeverage:      traderCfg.AltcoinLeverage,
-		ScanInterval:         time.Duration(traderCfg.ScanIntervalMinutes) * time.Minute,
-		CoinPoolAPIURL:       effectiveCoinPoolURL,
-		CustomAPIURL:         aiModelCfg.CustomAPIURL,    // 自定义API URL
-		CustomModelName:      aiModelCfg.CustomModelName, // 自定义模型名称
-		UseQwen:              aiModelCfg.Provider == "qwen",
-		MaxDailyLoss:         maxDailyLoss,
-		MaxDrawdown:          maxDrawdown,
-		StopTradingTime:      time.Duration(stopTradingMinutes) * time.Minute,
-		IsCrossMargin:        traderCfg.IsCrossMargin,
-		DefaultCoins:         defaultCoins,
-		TradingCoins:         tradingCoins,
-		SystemPromptTemplate: traderCfg.SystemPromptTemplate, // 系统提示词模板
-		HyperliquidTestnet:   exchangeCfg.Testnet,            // Hyperliquid测试网
+		ID:                    traderCfg.ID,
+		Name:                  traderCfg.Name,
+		AIModel:               aiModelCfg.Provider, // 使用provider作为模型标识
+		Exchange:              exchangeCfg.ID,      // 使用exchange ID
+		InitialBalance:        traderCfg.InitialBalance,
+		BTCETHLeverage:        traderCfg.BTCETHLeverage,
+		AltcoinLeverage:       traderCfg.AltcoinLeverage,
+		ScanInterval:          time.Duration(traderCfg.ScanIntervalMinutes) * time.Minute,
+		CoinPoolAPIURL:        effectiveCoinPoolURL,
+		CustomAPIURL:          aiModelCfg.CustomAPIURL,    // 自定义API URL
+		CustomModelName:       aiModelCfg.CustomModelName, // 自定义模型名称
+		UseQwen:               aiModelCfg.Provider == "qwen",
+		MaxDailyLoss:          maxDailyLoss,
+		MaxDrawdown:           maxDrawdown,
+		StopTradingTime:       time.Duration(stopTradingMinutes) * time.Minute,
+		IsCrossMargin:         traderCfg.IsCrossMargin,
+		PendingOrderMaxCycles: traderCfg.PendingOrderMaxCycles,
+		TakeProfitLadder:      parseTakeProfitLadder(traderCfg),
+		BreakevenAfterTP1:     traderCfg.BreakevenAfterTP1,
+		DecisionMode:          traderCfg.DecisionMode,
+		ValidationConfig:      parseValidationConfig(traderCfg),
+		PromptExperiment:      parsePromptExperiment(traderCfg),
+		DefaultCoins:          defaultCoins,
+		TradingCoins:          tradingCoins,
+		SystemPromptTemplate:  traderCfg.SystemPromptTemplate, // 系统提示词模板
+		HyperliquidTestnet:    exchangeCfg.Testnet,            // Hyperliquid测试网
 	}
+	applyRiskControlOptions(&traderConfig, database)
+	applyMarketDataOptions(&traderConfig, database)
+	applyEnsembleOptions(&traderConfig, traderCfg, database)
+	applyFallbackOptions(&traderConfig, traderCfg, database)
 
 	// 根据交易所类型设置API密钥
 	if exchangeCfg.ID == "binance" {
@@ -1069,6 +1128,16 @@ func (tm *TraderManager) loadSingleTrader(traderCfg *config.TraderRecord, aiMode
 	} else if exchangeCfg.ID == "bybit" {
 		traderConfig.BybitAPIKey = exchangeCfg.APIKey
 		traderConfig.BybitSecretKey = exchangeCfg.SecretKey
+	} else if exchangeCfg.ID == "okx" {
+		traderConfig.OKXAPIKey = exchangeCfg.APIKey
+		traderConfig.OKXSecretKey = exchangeCfg.SecretKey
+		traderConfig.OKXPassphrase = exchangeCfg.Passphrase
+		traderConfig.OKXTestnet = exchangeCfg.Testnet
+	} else if exchangeCfg.ID == "bitget" {
+		traderConfig.BitgetAPIKey = exchangeCfg.APIKey
+		traderConfig.BitgetSecretKey = exchangeCfg.SecretKey
+		traderConfig.BitgetPassphrase = exchangeCfg.Passphrase
+		traderConfig.BitgetTestnet = exchangeCfg.Testnet
 	} else if exchangeCfg.ID == "hyperliquid" {
 		traderConfig.HyperliquidPrivateKey = exchangeCfg.APIKey // hyperliquid用APIKey存储private key
 		traderConfig.HyperliquidWalletAddr = exchangeCfg.HyperliquidWalletAddr
@@ -1087,6 +1156,10 @@ func (tm *TraderManager) loadSingleTrader(traderCfg *config.TraderRecord, aiMode
 		traderConfig.QwenKey = aiModelCfg.APIKey
 	} else if aiModelCfg.Provider == "deepseek" {
 		traderConfig.DeepSeekKey = aiModelCfg.APIKey
+	} else if aiModelCfg.Provider == "anthropic" {
+		traderConfig.AnthropicKey = aiModelCfg.APIKey
+	} else if aiModelCfg.Provider == "gemini" {
+		traderConfig.GeminiKey = aiModelCfg.APIKey
 	}
 
 	// 创建trader实例
@@ -1122,3 +1195,112 @@ func (tm *TraderManager) RemoveTrader(traderID string) {
 		log.Printf("✓ Trader %s 已从内存中移除", traderID)
 	}
 }
+
+// applyRiskControlOptions 读取风控熔断的系统配置：触发时是否平掉所有持仓、日盈亏重置时区
+func applyRiskControlOptions(traderConfig *trader.AutoTraderConfig, database *config.Database) {
+	if database == nil {
+		return
+	}
+	closePositionsStr, _ := database.GetSystemConfig("risk_close_positions")
+	timezone, _ := database.GetSystemConfig("daily_reset_timezone")
+	spendCapStr, _ := database.GetSystemConfig("ai_monthly_spend_cap")
+	traderConfig.RiskClosePositions = closePositionsStr == "true"
+	traderConfig.DailyResetTimezone = strings.TrimSpace(timezone)
+	if spendCap, err := strconv.ParseFloat(strings.TrimSpace(spendCapStr), 64); err == nil && spendCap > 0 {
+		traderConfig.MonthlyAISpendCap = spendCap
+	}
+}
+
+// applyMarketDataOptions 读取行情数据源的系统配置：交易所专用行情失败时是否改用币安行情，以及开仓的流动性检查
+func applyMarketDataOptions(traderConfig *trader.AutoTraderConfig, database *config.Database) {
+	if database == nil {
+		return
+	}
+	fallback, _ := database.GetSystemConfig("market_data_fallback")
+	maxSlippageStr, _ := database.GetSystemConfig("max_slippage_pct")
+	liquidityAction, _ := database.GetSystemConfig("liquidity_action")
+	traderConfig.MarketDataFallback = strings.TrimSpace(fallback)
+	if maxSlippage, err := strconv.ParseFloat(strings.TrimSpace(maxSlippageStr), 64); err == nil && maxSlippage > 0 {
+		traderConfig.MaxSlippagePct = maxSlippage
+	}
+	traderConfig.LiquidityAction = strings.TrimSpace(liquidityAction)
+}
+
+// applyEnsembleOptions 解析交易员的集成决策模型
+func applyEnsembleOptions(traderConfig *trader.AutoTraderConfig, traderCfg *config.TraderRecord, database *config.Database) {
+	traderConfig.EnsembleModels = resolveModelConfigs(traderCfg, traderCfg.EnsembleModelIDs, "集成决策", database)
+	traderConfig.EnsembleVote = traderCfg.EnsembleVote
+}
+
+// applyFallbackOptions 解析交易员的故障转移备用模型（保持配置顺序）和安全模式开关
+func applyFallbackOptions(traderConfig *trader.AutoTraderConfig, traderCfg *config.TraderRecord, database *config.Database) {
+	traderConfig.FallbackModels = resolveModelConfigs(traderCfg, traderCfg.FallbackModelIDs, "备用", database)
+	traderConfig.AISafeMode = traderCfg.AISafeMode
+}
+
+// resolveModelConfigs 按ID（逗号分隔）查找用户的AI模型，跳过主模型以及未启用或不存在的模型
+func resolveModelConfigs(traderCfg *config.TraderRecord, ids string, purpose string, database *config.Database) []trader.EnsembleModelConfig {
+	if database == nil || strings.TrimSpace(ids) == "" {
+		return nil
+	}
+	aiModels, err := database.GetAIModels(traderCfg.UserID)
+	if err != nil {
+		log.Printf("⚠️ 交易员 %s 获取%s模型失败，仅使用主模型: %v", traderCfg.Name, purpose, err)
+		return nil
+	}
+	modelsByID := make(map[string]*config.AIModelConfig, len(aiModels))
+	for _, model := range aiModels {
+		modelsByID[model.ID] = model
+	}
+
+	var models []trader.EnsembleModelConfig
+	for _, id := range strings.Split(ids, ",") {
+		id = strings.TrimSpace(id)
+		if id == "" || id == traderCfg.AIModelID {
+			continue
+		}
+		model, ok := modelsByID[id]
+		if !ok || !model.Enabled {
+			log.Printf("⚠️ 交易员 %s 的%s模型 %s 不存在或未启用，已跳过", traderCfg.Name, purpose, id)
+			continue
+		}
+		models = append(models, trader.EnsembleModelConfig{
+			Name:            model.Name,
+			Provider:        model.Provider,
+			APIKey:          model.APIKey,
+			CustomAPIURL:    model.CustomAPIURL,
+			CustomModelName: model.CustomModelName,
+		})
+	}
+	return models
+}
+
+// parseValidationConfig 解析交易员的决策校验规则配置，配置无效时记录警告并使用默认规则
+func parseValidationConfig(traderCfg *config.TraderRecord) decision.ValidationConfig {
+	cfg, err := decision.ParseValidationConfig(traderCfg.ValidationRules)
+	if err != nil {
+		log.Printf("⚠️ 交易员 %s 的决策校验规则配置无效，使用默认规则: %v", traderCfg.Name, err)
+		return decision.ValidationConfig{}
+	}
+	return cfg
+}
+
+// parsePromptExperiment 解析交易员的提示词 A/B 实验配置，配置无效时记录警告并不开启实验
+func parsePromptExperiment(traderCfg *config.TraderRecord) *decision.PromptExperiment {
+	exp, err := decision.ParsePromptExperiment(traderCfg.PromptExperiment)
+	if err != nil {
+		log.Printf("⚠️ 交易员 %s 的提示词 A/B 实验配置无效，已忽略: %v", traderCfg.Name, err)
+		return nil
+	}
+	return exp
+}
+
+// parseTakeProfitLadder 解析交易员的分批止盈模板，配置无效时记录警告并不启用
+func parseTakeProfitLadder(traderCfg *config.TraderRecord) []decision.TakeProfitLadderStep {
+	steps, err := decision.ParseTakeProfitLadder(traderCfg.TakeProfitLadder)
+	if err != nil {
+		log.Printf("⚠️ 交易员 %s 的分批止盈配置无效，已忽略: %v", traderCfg.Name, err)
+		return nil
+	}
+	return steps
+}
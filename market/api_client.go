@@ -180,3 +180,85 @@ func (c *APIClient) GetCurrentPrice(symbol string) (float64, error) {
 
 	return price, nil
 }
+
+// depthLimits 币安深度接口支持的档位数
+var depthLimits = []int{5, 10, 20, 50, 100, 500, 1000}
+
+// GetOrderBook 获取订单簿深度（limit 向上取到接口支持的档位数，返回时截断到 limit 档）
+func (c *APIClient) GetOrderBook(symbol string, limit int) (*OrderBook, error) {
+	apiLimit := depthLimits[len(depthLimits)-1]
+	for _, l := range depthLimits {
+		if l >= limit {
+			apiLimit = l
+			break
+		}
+	}
+
+	url := fmt.Sprintf("%s/fapi/v1/depth", baseURL)
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	q := req.URL.Query()
+	q.Add("symbol", symbol)
+	q.Add("limit", strconv.Itoa(apiLimit))
+	req.URL.RawQuery = q.Encode()
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("获取订单簿失败 (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	book, err := parseOrderBook(symbol, body)
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 {
+		book.Bids = book.Bids[:min(limit, len(book.Bids))]
+		book.Asks = book.Asks[:min(limit, len(book.Asks))]
+	}
+	return book, nil
+}
+
+// parseOrderBook 解析币安深度接口响应
+func parseOrderBook(symbol string, body []byte) (*OrderBook, error) {
+	var raw struct {
+		TransactionTime int64       `json:"T"`
+		Bids            [][2]string `json:"bids"`
+		Asks            [][2]string `json:"asks"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("解析订单簿失败: %w", err)
+	}
+
+	return &OrderBook{
+		Symbol: symbol,
+		Bids:   parseOrderBookLevels(raw.Bids),
+		Asks:   parseOrderBookLevels(raw.Asks),
+		Time:   raw.TransactionTime,
+	}, nil
+}
+
+// parseOrderBookLevels 解析 [价格, 数量] 字符串档位，跳过无法解析的档位
+func parseOrderBookLevels(levels [][2]string) []OrderBookLevel {
+	result := make([]OrderBookLevel, 0, len(levels))
+	for _, level := range levels {
+		price, err1 := strconv.ParseFloat(level[0], 64)
+		qty, err2 := strconv.ParseFloat(level[1], 64)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		result = append(result, OrderBookLevel{Price: price, Quantity: qty})
+	}
+	return result
+}
@@ -0,0 +1,30 @@
+package market
+
+import "testing"
+
+func TestParseOrderBook(t *testing.T) {
+	body := []byte(`{"lastUpdateId":1027024,"E":1589436922972,"T":1589436922959,` +
+		`"bids":[["4.00000000","431.00000000"],["3.99000000","bad"]],` +
+		`"asks":[["4.00000200","12.00000000"]]}`)
+
+	book, err := parseOrderBook("BTCUSDT", body)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if book.Symbol != "BTCUSDT" || book.Time != 1589436922959 {
+		t.Errorf("unexpected book header: %+v", book)
+	}
+	if len(book.Bids) != 1 || book.Bids[0].Price != 4 || book.Bids[0].Quantity != 431 {
+		t.Errorf("无法解析的档位应被跳过: %+v", book.Bids)
+	}
+	if len(book.Asks) != 1 || book.Asks[0].Price != 4.000002 {
+		t.Errorf("unexpected asks: %+v", book.Asks)
+	}
+
+	if _, err := parseOrderBook("BTCUSDT", []byte(`{"code":-1121}`)); err != nil {
+		t.Errorf("缺少档位时返回空订单簿: %v", err)
+	}
+	if _, err := parseOrderBook("BTCUSDT", []byte(`not json`)); err == nil {
+		t.Error("非法响应应返回错误")
+	}
+}
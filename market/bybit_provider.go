@@ -0,0 +1,247 @@
+package market
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	bybitBaseURL = "https://api.bybit.com"
+	// bybitMaxKlineLimit kline 接口单次请求的最大K线数
+	bybitMaxKlineLimit = 1000
+)
+
+// bybitIntervals 时间周期到 Bybit kline interval 参数的映射
+var bybitIntervals = map[string]string{
+	"1m":  "1",
+	"3m":  "3",
+	"5m":  "5",
+	"15m": "15",
+	"30m": "30",
+	"1h":  "60",
+	"2h":  "120",
+	"4h":  "240",
+	"6h":  "360",
+	"12h": "720",
+	"1d":  "D",
+}
+
+// bybitTicker Bybit 永续合约的行情快照
+type bybitTicker struct {
+	LastPrice    string `json:"lastPrice"`
+	MarkPrice    string `json:"markPrice"`
+	FundingRate  string `json:"fundingRate"`
+	OpenInterest string `json:"openInterest"`
+}
+
+// BybitProvider Bybit USDT 永续行情（v5 market 接口）
+type BybitProvider struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewBybitProvider 创建 Bybit 行情数据源
+func NewBybitProvider() *BybitProvider {
+	return &BybitProvider{baseURL: bybitBaseURL, client: NewAPIClient().client}
+}
+
+func (p *BybitProvider) Name() string { return ProviderBybit }
+
+// get 调用 v5 market 接口并解析 result 字段
+func (p *BybitProvider) get(path string, params url.Values, result any) error {
+	resp, err := p.client.Get(p.baseURL + path + "?" + params.Encode())
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("bybit api returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var envelope struct {
+		RetCode int             `json:"retCode"`
+		RetMsg  string          `json:"retMsg"`
+		Result  json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return err
+	}
+	if envelope.RetCode != 0 {
+		return fmt.Errorf("bybit api error %d: %s", envelope.RetCode, envelope.RetMsg)
+	}
+	return json.Unmarshal(envelope.Result, result)
+}
+
+func (p *BybitProvider) GetKlines(symbol, interval string, limit int) ([]Kline, error) {
+	tf, err := NormalizeTimeframe(interval)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = defaultKlineLimit
+	}
+
+	var result struct {
+		List [][]string `json:"list"` // [startTime, open, high, low, close, volume, turnover]，按时间倒序
+	}
+	params := url.Values{
+		"category": {"linear"},
+		"symbol":   {strings.ToUpper(symbol)},
+		"interval": {bybitIntervals[tf]},
+		"limit":    {strconv.Itoa(min(limit, bybitMaxKlineLimit))},
+	}
+	if err := p.get("/v5/market/kline", params, &result); err != nil {
+		return nil, err
+	}
+	if len(result.List) == 0 {
+		return nil, fmt.Errorf("bybit 没有 %s 的K线数据", symbol)
+	}
+
+	return parseBybitKlines(result.List, supportedTimeframes[tf]), nil
+}
+
+// GetKlinesRange 分页拉取 [start, end] 内的历史K线，按时间升序排列。
+// Bybit 返回区间内最新的 limit 根K线，因此从 end 向前翻页
+func (p *BybitProvider) GetKlinesRange(symbol, interval string, start, end time.Time) ([]Kline, error) {
+	tf, err := NormalizeTimeframe(interval)
+	if err != nil {
+		return nil, err
+	}
+	if !end.After(start) {
+		return nil, fmt.Errorf("end time must be after start time")
+	}
+
+	var pages [][]Kline
+	startMs := start.UnixMilli()
+	for cursor := end.UnixMilli(); cursor >= startMs; {
+		var result struct {
+			List [][]string `json:"list"`
+		}
+		params := url.Values{
+			"category": {"linear"},
+			"symbol":   {strings.ToUpper(symbol)},
+			"interval": {bybitIntervals[tf]},
+			"start":    {strconv.FormatInt(startMs, 10)},
+			"end":      {strconv.FormatInt(cursor, 10)},
+			"limit":    {strconv.Itoa(bybitMaxKlineLimit)},
+		}
+		if err := p.get("/v5/market/kline", params, &result); err != nil {
+			return nil, err
+		}
+		batch := parseBybitKlines(result.List, supportedTimeframes[tf])
+		if len(batch) == 0 {
+			break
+		}
+		pages = append(pages, batch)
+		cursor = batch[0].OpenTime - 1
+		if len(result.List) < bybitMaxKlineLimit {
+			break
+		}
+	}
+
+	var all []Kline
+	for i := len(pages) - 1; i >= 0; i-- {
+		all = append(all, pages[i]...)
+	}
+	return all, nil
+}
+
+// parseBybitKlines 解析 Bybit 按时间倒序返回的K线行 [startTime, open, high, low, close, volume, turnover]，返回升序结果
+func parseBybitKlines(rows [][]string, duration time.Duration) []Kline {
+	klines := make([]Kline, 0, len(rows))
+	for i := len(rows) - 1; i >= 0; i-- {
+		row := rows[i]
+		if len(row) < 7 {
+			continue
+		}
+		openTime, _ := strconv.ParseInt(row[0], 10, 64)
+		k := Kline{OpenTime: openTime, CloseTime: openTime + duration.Milliseconds() - 1}
+		k.Open, _ = parseFloat(row[1])
+		k.High, _ = parseFloat(row[2])
+		k.Low, _ = parseFloat(row[3])
+		k.Close, _ = parseFloat(row[4])
+		k.Volume, _ = parseFloat(row[5])
+		k.QuoteVolume, _ = parseFloat(row[6])
+		klines = append(klines, k)
+	}
+	return klines
+}
+
+func (p *BybitProvider) GetCurrentPrice(symbol string) (float64, error) {
+	ticker, err := p.ticker(symbol)
+	if err != nil {
+		return 0, err
+	}
+	return parseFloat(ticker.LastPrice)
+}
+
+func (p *BybitProvider) GetFundingRate(symbol string) (float64, error) {
+	ticker, err := p.ticker(symbol)
+	if err != nil {
+		return 0, err
+	}
+	return parseFloat(ticker.FundingRate)
+}
+
+func (p *BybitProvider) GetOpenInterest(symbol string) (*OIData, error) {
+	ticker, err := p.ticker(symbol)
+	if err != nil {
+		return nil, err
+	}
+	oi, err := parseFloat(ticker.OpenInterest)
+	if err != nil {
+		return nil, err
+	}
+	return &OIData{Latest: oi, Average: oi * 0.999}, nil
+}
+
+func (p *BybitProvider) GetOrderBook(symbol string, limit int) (*OrderBook, error) {
+	if limit <= 0 {
+		limit = depthStreamLevels
+	}
+	var result struct {
+		Bids [][2]string `json:"b"`
+		Asks [][2]string `json:"a"`
+		Time int64       `json:"ts"`
+	}
+	params := url.Values{
+		"category": {"linear"},
+		"symbol":   {strings.ToUpper(symbol)},
+		"limit":    {strconv.Itoa(min(limit, 500))},
+	}
+	if err := p.get("/v5/market/orderbook", params, &result); err != nil {
+		return nil, err
+	}
+	return &OrderBook{
+		Symbol: strings.ToUpper(symbol),
+		Bids:   parseOrderBookLevels(result.Bids),
+		Asks:   parseOrderBookLevels(result.Asks),
+		Time:   result.Time,
+	}, nil
+}
+
+// ticker 获取单个合约的行情快照（最新价、资金费率、持仓量）
+func (p *BybitProvider) ticker(symbol string) (*bybitTicker, error) {
+	var result struct {
+		List []bybitTicker `json:"list"`
+	}
+	params := url.Values{"category": {"linear"}, "symbol": {strings.ToUpper(symbol)}}
+	if err := p.get("/v5/market/tickers", params, &result); err != nil {
+		return nil, err
+	}
+	if len(result.List) == 0 {
+		return nil, fmt.Errorf("bybit 未上线 %s", symbol)
+	}
+	return &result.List[0], nil
+}
@@ -52,6 +52,18 @@ func (c *CombinedStreamsClient) Connect() error {
 
 // BatchSubscribeKlines 批量订阅K线
 func (c *CombinedStreamsClient) BatchSubscribeKlines(symbols []string, interval string) error {
+	return c.batchSubscribe(symbols, func(symbol string) string {
+		return fmt.Sprintf("%s@kline_%s", strings.ToLower(symbol), interval)
+	})
+}
+
+// BatchSubscribeDepth 批量订阅部分深度流（前 20 档）
+func (c *CombinedStreamsClient) BatchSubscribeDepth(symbols []string) error {
+	return c.batchSubscribe(symbols, depthStreamName)
+}
+
+// batchSubscribe 按 batchSize 分批订阅每个交易对的流
+func (c *CombinedStreamsClient) batchSubscribe(symbols []string, streamName func(symbol string) string) error {
 	// 将symbols分批处理
 	batches := c.splitIntoBatches(symbols, c.batchSize)
 
@@ -60,7 +72,7 @@ func (c *CombinedStreamsClient) BatchSubscribeKlines(symbols []string, interval
 
 		streams := make([]string, len(batch))
 		for j, symbol := range batch {
-			streams[j] = fmt.Sprintf("%s@kline_%s", strings.ToLower(symbol), interval)
+			streams[j] = streamName(symbol)
 		}
 
 		if err := c.subscribeStreams(streams); err != nil {
@@ -24,14 +24,26 @@ var (
 	frCacheTTL     = 1 * time.Hour
 )
 
-// Get 获取指定代币的市场数据
+// Get 获取指定代币的市场数据（币安行情）
+//
+// 禁止内联：交易员的单元测试通过 gomonkey 替换该函数
+//
+//go:noinline
 func Get(symbol string) (*Data, error) {
+	return GetWithProvider(symbol, defaultProvider)
+}
+
+// GetWithProvider 从指定行情数据源获取代币的市场数据，provider 为 nil 时使用币安行情
+func GetWithProvider(symbol string, provider MarketDataProvider) (*Data, error) {
+	if provider == nil {
+		provider = defaultProvider
+	}
 	var klines3m, klines4h []Kline
 	var err error
 	// 标准化symbol
 	symbol = Normalize(symbol)
 	// 获取3分钟K线数据 (最近10个)
-	klines3m, err = WSMonitorCli.GetCurrentKlines(symbol, "3m") // 多获取一些用于计算
+	klines3m, err = provider.GetKlines(symbol, "3m", defaultKlineLimit) // 多获取一些用于计算
 	if err != nil {
 		return nil, fmt.Errorf("获取3分钟K线失败: %v", err)
 	}
@@ -43,7 +55,7 @@ func Get(symbol string) (*Data, error) {
 	}
 
 	// 获取4小时K线数据 (最近10个)
-	klines4h, err = WSMonitorCli.GetCurrentKlines(symbol, "4h") // 多获取用于计算指标
+	klines4h, err = provider.GetKlines(symbol, "4h", defaultKlineLimit) // 多获取用于计算指标
 	if err != nil {
 		return nil, fmt.Errorf("获取4小时K线失败: %v", err)
 	}
@@ -82,14 +94,20 @@ func Get(symbol string) (*Data, error) {
 	}
 
 	// 获取OI数据
-	oiData, err := getOpenInterestData(symbol)
+	oiData, err := provider.GetOpenInterest(symbol)
 	if err != nil {
 		// OI失败不影响整体,使用默认值
 		oiData = &OIData{Latest: 0, Average: 0}
 	}
 
 	// 获取Funding Rate
-	fundingRate, _ := getFundingRate(symbol)
+	fundingRate, _ := provider.GetFundingRate(symbol)
+
+	// 获取订单簿流动性特征（失败不影响整体）
+	var liquidity *LiquidityData
+	if book, err := provider.GetOrderBook(symbol, depthStreamLevels); err == nil {
+		liquidity = AnalyzeOrderBook(book, LiquidityDepthLevels, LiquidityReferenceNotional)
+	}
 
 	// 计算日内系列数据
 	intradayData := calculateIntradaySeries(klines3m)
@@ -109,6 +127,7 @@ func Get(symbol string) (*Data, error) {
 		FundingRate:       fundingRate,
 		IntradaySeries:    intradayData,
 		LongerTermContext: longerTermData,
+		Liquidity:         liquidity,
 	}, nil
 }
 
@@ -430,6 +449,14 @@ func Format(data *Data) string {
 
 	sb.WriteString(fmt.Sprintf("Funding Rate: %.2e\n\n", data.FundingRate))
 
+	if data.Liquidity != nil {
+		liq := data.Liquidity
+		sb.WriteString(fmt.Sprintf("Order book: spread = %.2f bps, top %d levels depth: bids $%.0f vs. asks $%.0f, imbalance = %.3f\n\n",
+			liq.SpreadBps, liq.DepthLevels, liq.BidDepthUSD, liq.AskDepthUSD, liq.Imbalance))
+		sb.WriteString(fmt.Sprintf("Estimated slippage for a $%.0f market order: buy %s, sell %s\n\n",
+			liq.ReferenceNotional, formatSlippage(liq.BuySlippage), formatSlippage(liq.SellSlippage)))
+	}
+
 	if data.IntradaySeries != nil {
 		sb.WriteString("Intraday series (3‑minute intervals, oldest → latest):\n\n")
 
@@ -484,6 +511,14 @@ func Format(data *Data) string {
 	return sb.String()
 }
 
+// formatSlippage 格式化滑点估算，订单簿深度不足以全部成交时标注可成交金额
+func formatSlippage(est SlippageEstimate) string {
+	if est.Complete {
+		return fmt.Sprintf("%.3f%%", est.SlippagePct)
+	}
+	return fmt.Sprintf(">%.3f%% (book only fills $%.0f)", est.SlippagePct, est.FilledUSD)
+}
+
 // formatPriceWithDynamicPrecision 根据价格区间动态选择精度
 // 这样可以完美支持从超低价 meme coin (< 0.0001) 到 BTC/ETH 的所有币种
 func formatPriceWithDynamicPrecision(price float64) string {
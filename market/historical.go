@@ -11,10 +11,30 @@ import (
 const (
 	binanceFuturesKlinesURL = "https://fapi.binance.com/fapi/v1/klines"
 	binanceMaxKlineLimit    = 1500
+	binanceMaxFundingLimit  = 1000
 )
 
-// GetKlinesRange 拉取指定时间范围内的 K 线序列（闭区间），返回按时间升序排列的数据。
-func GetKlinesRange(symbol string, timeframe string, start, end time.Time) ([]Kline, error) {
+// binanceFundingRateHistoryURL 历史资金费率接口（测试时替换为本地服务）
+var binanceFundingRateHistoryURL = "https://fapi.binance.com/fapi/v1/fundingRate"
+
+// FundingRatePoint 表示一次历史资金费结算。
+type FundingRatePoint struct {
+	FundingTime int64   `json:"fundingTime"` // 结算时间（毫秒）
+	Rate        float64 `json:"fundingRate"` // 本期资金费率（正数表示多头支付空头）
+	MarkPrice   float64 `json:"markPrice"`   // 结算时的标记价格（可能为0）
+}
+
+// GetKlinesRange 通过行情数据源拉取指定时间范围内的 K 线序列（闭区间），返回按时间升序排列的数据。
+// provider 为 nil 或不支持历史区间查询时使用币安历史K线
+func GetKlinesRange(provider MarketDataProvider, symbol string, timeframe string, start, end time.Time) ([]Kline, error) {
+	if historical, ok := provider.(HistoricalKlineProvider); ok {
+		return historical.GetKlinesRange(symbol, timeframe, start, end)
+	}
+	return getBinanceKlinesRange(symbol, timeframe, start, end)
+}
+
+// getBinanceKlinesRange 分页拉取币安指定时间范围内的 K 线序列（闭区间），返回按时间升序排列的数据。
+func getBinanceKlinesRange(symbol string, timeframe string, start, end time.Time) ([]Kline, error) {
 	symbol = Normalize(symbol)
 	normTF, err := NormalizeTimeframe(timeframe)
 	if err != nil {
@@ -102,3 +122,77 @@ func GetKlinesRange(symbol string, timeframe string, start, end time.Time) ([]Kl
 
 	return all, nil
 }
+
+// GetFundingRateHistory 拉取指定时间范围内的历史资金费率，返回按结算时间升序排列的数据。
+func GetFundingRateHistory(symbol string, start, end time.Time) ([]FundingRatePoint, error) {
+	symbol = Normalize(symbol)
+	if !end.After(start) {
+		return nil, fmt.Errorf("end time must be after start time")
+	}
+
+	startMs := start.UnixMilli()
+	endMs := end.UnixMilli()
+
+	var all []FundingRatePoint
+	cursor := startMs
+
+	client := &http.Client{Timeout: 15 * time.Second}
+
+	for cursor < endMs {
+		req, err := http.NewRequest("GET", binanceFundingRateHistoryURL, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		q := req.URL.Query()
+		q.Set("symbol", symbol)
+		q.Set("limit", fmt.Sprintf("%d", binanceMaxFundingLimit))
+		q.Set("startTime", fmt.Sprintf("%d", cursor))
+		q.Set("endTime", fmt.Sprintf("%d", endMs))
+		req.URL.RawQuery = q.Encode()
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("binance funding rate api returned status %d: %s", resp.StatusCode, string(body))
+		}
+
+		var raw []struct {
+			FundingTime int64  `json:"fundingTime"`
+			FundingRate string `json:"fundingRate"`
+			MarkPrice   string `json:"markPrice"`
+		}
+		if err := json.Unmarshal(body, &raw); err != nil {
+			return nil, err
+		}
+		if len(raw) == 0 {
+			break
+		}
+
+		for _, item := range raw {
+			rate, _ := parseFloat(item.FundingRate)
+			mark, _ := parseFloat(item.MarkPrice)
+			all = append(all, FundingRatePoint{
+				FundingTime: item.FundingTime,
+				Rate:        rate,
+				MarkPrice:   mark,
+			})
+		}
+
+		cursor = raw[len(raw)-1].FundingTime + 1
+
+		if len(raw) < binanceMaxFundingLimit {
+			break
+		}
+	}
+
+	return all, nil
+}
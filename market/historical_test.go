@@ -0,0 +1,86 @@
+package market
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestGetFundingRateHistory_Paging(t *testing.T) {
+	const interval = int64(8 * time.Hour / time.Millisecond)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	total := binanceMaxFundingLimit + 5
+
+	var cursors []int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("symbol") != "BTCUSDT" {
+			t.Errorf("unexpected symbol %s", q.Get("symbol"))
+		}
+		cursor, _ := strconv.ParseInt(q.Get("startTime"), 10, 64)
+		endTime, _ := strconv.ParseInt(q.Get("endTime"), 10, 64)
+		limit, _ := strconv.Atoi(q.Get("limit"))
+		cursors = append(cursors, cursor)
+
+		type point struct {
+			FundingTime int64  `json:"fundingTime"`
+			FundingRate string `json:"fundingRate"`
+			MarkPrice   string `json:"markPrice"`
+		}
+		page := make([]point, 0, limit)
+		for i := 0; i < total && len(page) < limit; i++ {
+			ts := start.UnixMilli() + int64(i)*interval
+			if ts >= cursor && ts <= endTime {
+				page = append(page, point{FundingTime: ts, FundingRate: "0.0001", MarkPrice: "100"})
+			}
+		}
+		json.NewEncoder(w).Encode(page)
+	}))
+	defer server.Close()
+
+	original := binanceFundingRateHistoryURL
+	binanceFundingRateHistoryURL = server.URL
+	defer func() { binanceFundingRateHistoryURL = original }()
+
+	end := start.Add(time.Duration(total) * 8 * time.Hour)
+	points, err := GetFundingRateHistory("btcusdt", start, end)
+	if err != nil {
+		t.Fatalf("GetFundingRateHistory: %v", err)
+	}
+	if len(points) != total {
+		t.Fatalf("expected %d points across pages, got %d", total, len(points))
+	}
+	for i := 1; i < len(points); i++ {
+		if points[i].FundingTime <= points[i-1].FundingTime {
+			t.Fatalf("points should be strictly ascending at %d", i)
+		}
+	}
+	if points[0].Rate != 0.0001 || points[0].MarkPrice != 100 {
+		t.Errorf("unexpected point: %+v", points[0])
+	}
+	if len(cursors) != 2 || cursors[1] != points[binanceMaxFundingLimit-1].FundingTime+1 {
+		t.Errorf("second page should start right after the last point of the first page: %v", cursors)
+	}
+}
+
+func TestGetFundingRateHistory_Errors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"code":-1121,"msg":"Invalid symbol."}`, http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	original := binanceFundingRateHistoryURL
+	binanceFundingRateHistoryURL = server.URL
+	defer func() { binanceFundingRateHistoryURL = original }()
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := GetFundingRateHistory("XXXUSDT", start, start.Add(time.Hour)); err == nil {
+		t.Error("non-200 response should return an error")
+	}
+	if _, err := GetFundingRateHistory("BTCUSDT", start, start); err == nil {
+		t.Error("empty range should return an error")
+	}
+}
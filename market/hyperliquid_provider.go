@@ -0,0 +1,291 @@
+package market
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	hyperliquidInfoURL = "https://api.hyperliquid.xyz/info"
+	// hyperliquidCtxCacheTTL 资产上下文（资金费率、持仓量、标记价格）的缓存时长，一次请求覆盖所有币种
+	hyperliquidCtxCacheTTL = 30 * time.Second
+	// hyperliquidMaxCandles candleSnapshot 单次请求返回的最大K线数
+	hyperliquidMaxCandles = 5000
+)
+
+// hyperliquidAssetCtx Hyperliquid 单个永续合约的实时数据
+type hyperliquidAssetCtx struct {
+	Funding      float64 // 每小时资金费率
+	OpenInterest float64 // 持仓量（币）
+	MarkPrice    float64
+	MidPrice     float64
+}
+
+// HyperliquidProvider Hyperliquid 永续行情（info 接口）
+type HyperliquidProvider struct {
+	url    string
+	client *http.Client
+
+	mu        sync.Mutex
+	ctxs      map[string]hyperliquidAssetCtx // 币种 -> 资产上下文
+	fetchedAt time.Time
+}
+
+// NewHyperliquidProvider 创建 Hyperliquid 行情数据源
+func NewHyperliquidProvider() *HyperliquidProvider {
+	return &HyperliquidProvider{url: hyperliquidInfoURL, client: NewAPIClient().client}
+}
+
+func (p *HyperliquidProvider) Name() string { return ProviderHyperliquid }
+
+// hyperliquidCoin 把 BTCUSDT 转换为 Hyperliquid 的币种名 BTC
+func hyperliquidCoin(symbol string) string {
+	symbol = strings.ToUpper(symbol)
+	for _, quote := range []string{"USDT", "USDC"} {
+		if coin, ok := strings.CutSuffix(symbol, quote); ok {
+			return coin
+		}
+	}
+	return symbol
+}
+
+// post 调用 info 接口
+func (p *HyperliquidProvider) post(payload any, out any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	resp, err := p.client.Post(p.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("hyperliquid info api returned status %d: %s", resp.StatusCode, string(data))
+	}
+	return json.Unmarshal(data, out)
+}
+
+func (p *HyperliquidProvider) GetKlines(symbol, interval string, limit int) ([]Kline, error) {
+	tf, err := TFDuration(interval)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = defaultKlineLimit
+	}
+	end := time.Now()
+	start := end.Add(-time.Duration(limit) * tf)
+
+	klines, err := p.candleSnapshot(symbol, interval, start.UnixMilli(), end.UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+	if len(klines) == 0 {
+		return nil, fmt.Errorf("hyperliquid 没有 %s 的K线数据", symbol)
+	}
+	return klines[max(0, len(klines)-limit):], nil
+}
+
+// GetKlinesRange 分页拉取 [start, end] 内的历史K线，按时间升序排列
+func (p *HyperliquidProvider) GetKlinesRange(symbol, interval string, start, end time.Time) ([]Kline, error) {
+	if _, err := TFDuration(interval); err != nil {
+		return nil, err
+	}
+	if !end.After(start) {
+		return nil, fmt.Errorf("end time must be after start time")
+	}
+
+	var all []Kline
+	endMs := end.UnixMilli()
+	for cursor := start.UnixMilli(); cursor < endMs; {
+		batch, err := p.candleSnapshot(symbol, interval, cursor, endMs)
+		if err != nil {
+			return nil, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+		all = append(all, batch...)
+		cursor = batch[len(batch)-1].CloseTime + 1
+		if len(batch) < hyperliquidMaxCandles {
+			break
+		}
+	}
+	return all, nil
+}
+
+// candleSnapshot 调用 candleSnapshot 接口获取 [startMs, endMs] 内的K线，按时间升序排列
+func (p *HyperliquidProvider) candleSnapshot(symbol, interval string, startMs, endMs int64) ([]Kline, error) {
+	var candles []struct {
+		OpenTime  int64  `json:"t"`
+		CloseTime int64  `json:"T"`
+		Open      string `json:"o"`
+		High      string `json:"h"`
+		Low       string `json:"l"`
+		Close     string `json:"c"`
+		Volume    string `json:"v"`
+		Trades    int    `json:"n"`
+	}
+	err := p.post(map[string]any{
+		"type": "candleSnapshot",
+		"req": map[string]any{
+			"coin":      hyperliquidCoin(symbol),
+			"interval":  MustNormalizeTimeframe(interval),
+			"startTime": startMs,
+			"endTime":   endMs,
+		},
+	}, &candles)
+	if err != nil {
+		return nil, err
+	}
+
+	klines := make([]Kline, 0, len(candles))
+	for _, c := range candles {
+		k := Kline{OpenTime: c.OpenTime, CloseTime: c.CloseTime, Trades: c.Trades}
+		k.Open, _ = parseFloat(c.Open)
+		k.High, _ = parseFloat(c.High)
+		k.Low, _ = parseFloat(c.Low)
+		k.Close, _ = parseFloat(c.Close)
+		k.Volume, _ = parseFloat(c.Volume)
+		k.QuoteVolume = k.Volume * k.Close
+		klines = append(klines, k)
+	}
+	return klines, nil
+}
+
+func (p *HyperliquidProvider) GetCurrentPrice(symbol string) (float64, error) {
+	ctx, err := p.assetCtx(symbol)
+	if err != nil {
+		return 0, err
+	}
+	if ctx.MidPrice > 0 {
+		return ctx.MidPrice, nil
+	}
+	return ctx.MarkPrice, nil
+}
+
+// GetFundingRate 返回 Hyperliquid 的每小时资金费率（币安为每 8 小时结算一次）
+func (p *HyperliquidProvider) GetFundingRate(symbol string) (float64, error) {
+	ctx, err := p.assetCtx(symbol)
+	if err != nil {
+		return 0, err
+	}
+	return ctx.Funding, nil
+}
+
+func (p *HyperliquidProvider) GetOpenInterest(symbol string) (*OIData, error) {
+	ctx, err := p.assetCtx(symbol)
+	if err != nil {
+		return nil, err
+	}
+	return &OIData{Latest: ctx.OpenInterest, Average: ctx.OpenInterest * 0.999}, nil
+}
+
+func (p *HyperliquidProvider) GetOrderBook(symbol string, limit int) (*OrderBook, error) {
+	var snapshot struct {
+		Time   int64 `json:"time"`
+		Levels [][]struct {
+			Px string `json:"px"`
+			Sz string `json:"sz"`
+		} `json:"levels"` // [买盘, 卖盘]
+	}
+	if err := p.post(map[string]string{"type": "l2Book", "coin": hyperliquidCoin(symbol)}, &snapshot); err != nil {
+		return nil, err
+	}
+	if len(snapshot.Levels) < 2 {
+		return nil, fmt.Errorf("hyperliquid 没有 %s 的订单簿", symbol)
+	}
+
+	book := &OrderBook{Symbol: strings.ToUpper(symbol), Time: snapshot.Time}
+	for side, levels := range snapshot.Levels[:2] {
+		parsed := make([][2]string, 0, len(levels))
+		for _, level := range levels {
+			parsed = append(parsed, [2]string{level.Px, level.Sz})
+		}
+		if side == 0 {
+			book.Bids = parseOrderBookLevels(parsed)
+		} else {
+			book.Asks = parseOrderBookLevels(parsed)
+		}
+	}
+	if limit > 0 {
+		book.Bids = book.Bids[:min(limit, len(book.Bids))]
+		book.Asks = book.Asks[:min(limit, len(book.Asks))]
+	}
+	return book, nil
+}
+
+// assetCtx 返回币种的资产上下文（所有币种一次获取并缓存）
+func (p *HyperliquidProvider) assetCtx(symbol string) (hyperliquidAssetCtx, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.ctxs == nil || time.Since(p.fetchedAt) >= hyperliquidCtxCacheTTL {
+		ctxs, err := p.fetchAssetCtxs()
+		if err != nil {
+			return hyperliquidAssetCtx{}, err
+		}
+		p.ctxs, p.fetchedAt = ctxs, time.Now()
+	}
+
+	ctx, ok := p.ctxs[hyperliquidCoin(symbol)]
+	if !ok {
+		return hyperliquidAssetCtx{}, fmt.Errorf("hyperliquid 未上线 %s", symbol)
+	}
+	return ctx, nil
+}
+
+// fetchAssetCtxs 调用 metaAndAssetCtxs：返回 [meta, assetCtxs]，两者按下标对应
+func (p *HyperliquidProvider) fetchAssetCtxs() (map[string]hyperliquidAssetCtx, error) {
+	var raw []json.RawMessage
+	if err := p.post(map[string]string{"type": "metaAndAssetCtxs"}, &raw); err != nil {
+		return nil, err
+	}
+	if len(raw) < 2 {
+		return nil, fmt.Errorf("hyperliquid metaAndAssetCtxs 响应格式错误")
+	}
+
+	var meta struct {
+		Universe []struct {
+			Name string `json:"name"`
+		} `json:"universe"`
+	}
+	var assetCtxs []struct {
+		Funding      string `json:"funding"`
+		OpenInterest string `json:"openInterest"`
+		MarkPx       string `json:"markPx"`
+		MidPx        string `json:"midPx"`
+	}
+	if err := json.Unmarshal(raw[0], &meta); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(raw[1], &assetCtxs); err != nil {
+		return nil, err
+	}
+
+	ctxs := make(map[string]hyperliquidAssetCtx, len(meta.Universe))
+	for i, asset := range meta.Universe {
+		if i >= len(assetCtxs) {
+			break
+		}
+		var ctx hyperliquidAssetCtx
+		ctx.Funding, _ = parseFloat(assetCtxs[i].Funding)
+		ctx.OpenInterest, _ = parseFloat(assetCtxs[i].OpenInterest)
+		ctx.MarkPrice, _ = parseFloat(assetCtxs[i].MarkPx)
+		ctx.MidPrice, _ = parseFloat(assetCtxs[i].MidPx)
+		ctxs[strings.ToUpper(asset.Name)] = ctx
+	}
+	return ctxs, nil
+}
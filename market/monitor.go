@@ -2,6 +2,7 @@ package market
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
@@ -18,6 +19,7 @@ type WSMonitor struct {
 	klineDataMap3m sync.Map // 存储每个交易对的K线历史数据
 	klineDataMap4h sync.Map // 存储每个交易对的K线历史数据
 	tickerDataMap  sync.Map // 存储每个交易对的ticker数据
+	orderBooks     sync.Map // 存储每个交易对的本地订单簿 (*LocalOrderBook)
 	batchSize      int
 	filterSymbols  sync.Map // 使用sync.Map来存储需要监控的币种和其状态
 	symbolStats    sync.Map // 存储币种统计信息
@@ -163,6 +165,13 @@ func (m *WSMonitor) subscribeAll() error {
 			return err
 		}
 	}
+	for _, symbol := range m.symbols {
+		m.subscribeDepth(symbol)
+	}
+	if err := m.combinedClient.BatchSubscribeDepth(m.symbols); err != nil {
+		log.Printf("❌ 订阅深度失败: %v", err)
+		return err
+	}
 	log.Println("所有交易对订阅完成")
 	return nil
 }
@@ -178,6 +187,35 @@ func (m *WSMonitor) handleKlineData(symbol string, ch <-chan []byte, _time strin
 	}
 }
 
+// subscribeDepth 注册深度流监听并创建本地订单簿，已注册时返回 nil
+func (m *WSMonitor) subscribeDepth(symbol string) []string {
+	symbol = strings.ToUpper(symbol)
+	book := NewLocalOrderBook(symbol)
+	if _, loaded := m.orderBooks.LoadOrStore(symbol, book); loaded {
+		return nil
+	}
+	stream := depthStreamName(symbol)
+	ch := m.combinedClient.AddSubscriber(stream, 100)
+	go m.handleDepthData(book, ch)
+	return []string{stream}
+}
+
+func (m *WSMonitor) handleDepthData(book *LocalOrderBook, ch <-chan []byte) {
+	for data := range ch {
+		var depthData DepthWSData
+		if err := json.Unmarshal(data, &depthData); err != nil {
+			log.Printf("解析深度数据失败: %v", err)
+			continue
+		}
+		switch err := book.apply(depthData, time.Now()); {
+		case errors.Is(err, errCrossedBook):
+			log.Printf("⚠️  %s 深度推送异常，已忽略: %v", book.symbol, err)
+		case errors.Is(err, errDepthSequenceGap):
+			log.Printf("⚠️  %s %v，期间使用 REST 订单簿", book.symbol, err)
+		}
+	}
+}
+
 func (m *WSMonitor) getKlineDataMap(_time string) *sync.Map {
 	var klineDataMap *sync.Map
 	if _time == "3m" {
@@ -267,6 +305,25 @@ func (m *WSMonitor) GetCurrentKlines(symbol string, duration string) ([]Kline, e
 	return result, nil
 }
 
+// GetOrderBook 返回本地订单簿的前 limit 档。未订阅的交易对动态订阅深度流；
+// 本地订单簿未就绪、已过期或档位不足时使用 REST 快照
+func (m *WSMonitor) GetOrderBook(symbol string, limit int) (*OrderBook, error) {
+	symbol = strings.ToUpper(symbol)
+	if value, exists := m.orderBooks.Load(symbol); exists {
+		if limit <= depthStreamLevels {
+			if book := value.(*LocalOrderBook).Snapshot(limit, time.Now()); book != nil {
+				return book, nil
+			}
+		}
+	} else if subStr := m.subscribeDepth(symbol); len(subStr) > 0 {
+		log.Printf("动态订阅流: %v", subStr)
+		if err := m.combinedClient.subscribeStreams(subStr); err != nil {
+			log.Printf("警告: 动态订阅%s深度失败: %v (使用API数据)", symbol, err)
+		}
+	}
+	return NewAPIClient().GetOrderBook(symbol, limit)
+}
+
 func (m *WSMonitor) Close() {
 	m.wsClient.Close()
 	close(m.alertsChan)
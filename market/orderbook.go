@@ -0,0 +1,251 @@
+package market
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// depthStreamLevels 部分深度流的档位数（币安支持 5/10/20）
+	depthStreamLevels = 20
+	// depthStreamSpeed 部分深度流的推送间隔
+	depthStreamSpeed = "500ms"
+	// orderBookStaleAfter 本地订单簿超过该时间未更新视为过期，改用 REST 快照
+	orderBookStaleAfter = 5 * time.Second
+)
+
+// 订单簿微观结构特征的默认参数
+const (
+	// LiquidityDepthLevels 统计挂单深度使用的档位数
+	LiquidityDepthLevels = 10
+	// LiquidityReferenceNotional 估算滑点使用的参考名义金额（USDT）
+	LiquidityReferenceNotional = 10000.0
+)
+
+var (
+	errStaleDepthUpdate = errors.New("深度推送序号早于本地订单簿")
+	errCrossedBook      = errors.New("买一价不低于卖一价")
+	errDepthSequenceGap = errors.New("深度推送序号不连续，等待重新同步")
+)
+
+// depthStreamName 部分深度流名称，如 btcusdt@depth20@500ms
+func depthStreamName(symbol string) string {
+	return fmt.Sprintf("%s@depth%d@%s", strings.ToLower(symbol), depthStreamLevels, depthStreamSpeed)
+}
+
+// LocalOrderBook 由部分深度流维护的本地订单簿。
+// 每条推送都是前 N 档的完整快照：按 u 序号丢弃乱序/重复推送；pu 与上一条 u 不连续时记录一次序列缺口并清空订单簿，
+// 直到收到与之连续的下一条推送才重新可用，期间 Snapshot 返回 nil，调用方改用 REST 快照
+type LocalOrderBook struct {
+	mu           sync.RWMutex
+	symbol       string
+	bids         []OrderBookLevel
+	asks         []OrderBookLevel
+	lastUpdateID int64
+	eventTime    int64
+	updatedAt    time.Time
+	gaps         int
+}
+
+// NewLocalOrderBook 创建空的本地订单簿
+func NewLocalOrderBook(symbol string) *LocalOrderBook {
+	return &LocalOrderBook{symbol: strings.ToUpper(symbol)}
+}
+
+// apply 应用一条深度推送
+func (b *LocalOrderBook) apply(update DepthWSData, now time.Time) error {
+	bids := parseOrderBookLevels(update.Bids)
+	asks := parseOrderBookLevels(update.Asks)
+	if len(bids) > 0 && len(asks) > 0 && bids[0].Price >= asks[0].Price {
+		return errCrossedBook
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if update.FinalUpdateID <= b.lastUpdateID {
+		return errStaleDepthUpdate
+	}
+	if b.lastUpdateID != 0 && update.PrevUpdateID != b.lastUpdateID {
+		b.gaps++
+		b.bids, b.asks = nil, nil
+		b.lastUpdateID = update.FinalUpdateID
+		b.updatedAt = time.Time{}
+		return errDepthSequenceGap
+	}
+
+	b.bids, b.asks = bids, asks
+	b.lastUpdateID = update.FinalUpdateID
+	b.eventTime = update.TransactionTime
+	b.updatedAt = now
+	return nil
+}
+
+// Snapshot 返回前 limit 档的副本；尚未收到推送、序列缺口后尚未重新同步或已过期时返回 nil
+func (b *LocalOrderBook) Snapshot(limit int, now time.Time) *OrderBook {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.updatedAt.IsZero() || now.Sub(b.updatedAt) > orderBookStaleAfter {
+		return nil
+	}
+	if limit <= 0 {
+		limit = depthStreamLevels
+	}
+	return &OrderBook{
+		Symbol: b.symbol,
+		Bids:   append([]OrderBookLevel(nil), b.bids[:min(limit, len(b.bids))]...),
+		Asks:   append([]OrderBookLevel(nil), b.asks[:min(limit, len(b.asks))]...),
+		Time:   b.eventTime,
+	}
+}
+
+// Gaps 返回检测到的序列缺口次数
+func (b *LocalOrderBook) Gaps() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.gaps
+}
+
+// SlippageEstimate 按订单簿逐档吃单的市价成交估算
+type SlippageEstimate struct {
+	AvgPrice    float64 // 预计成交均价
+	SlippagePct float64 // 成交均价相对中间价的不利偏离（%）
+	FilledUSD   float64 // 订单簿可成交的名义金额（USDT）
+	Complete    bool    // 订单簿深度是否足以全部成交（否则 SlippagePct 为下限）
+}
+
+// LiquidityData 订单簿微观结构特征
+type LiquidityData struct {
+	BestBid           float64
+	BestAsk           float64
+	SpreadBps         float64          // 买卖价差（基点，相对中间价）
+	DepthLevels       int              // 统计挂单深度的档位数
+	BidDepthUSD       float64          // 前 DepthLevels 档买盘挂单金额（USDT）
+	AskDepthUSD       float64          // 前 DepthLevels 档卖盘挂单金额（USDT）
+	Imbalance         float64          // 盘口失衡度 (买-卖)/(买+卖)，-1~1，正值表示买盘更厚
+	ReferenceNotional float64          // 估算滑点的名义金额（USDT）
+	BuySlippage       SlippageEstimate // 市价买入 ReferenceNotional 的估算
+	SellSlippage      SlippageEstimate // 市价卖出 ReferenceNotional 的估算
+}
+
+// midPrice 返回买一卖一的中间价，任一侧为空时返回 0
+func (book *OrderBook) midPrice() float64 {
+	if book == nil || len(book.Bids) == 0 || len(book.Asks) == 0 {
+		return 0
+	}
+	return (book.Bids[0].Price + book.Asks[0].Price) / 2
+}
+
+// sideLevels 返回吃单方向的档位：买入吃卖盘，卖出吃买盘
+func (book *OrderBook) sideLevels(isBuy bool) []OrderBookLevel {
+	if isBuy {
+		return book.Asks
+	}
+	return book.Bids
+}
+
+// AnalyzeOrderBook 计算价差、前 levels 档挂单金额、盘口失衡度和 notional 金额的双向滑点；订单簿任一侧为空时返回 nil
+func AnalyzeOrderBook(book *OrderBook, levels int, notional float64) *LiquidityData {
+	mid := book.midPrice()
+	if mid <= 0 {
+		return nil
+	}
+
+	depthUSD := func(side []OrderBookLevel) float64 {
+		total := 0.0
+		for _, level := range side[:min(levels, len(side))] {
+			total += level.Price * level.Quantity
+		}
+		return total
+	}
+
+	data := &LiquidityData{
+		BestBid:           book.Bids[0].Price,
+		BestAsk:           book.Asks[0].Price,
+		SpreadBps:         (book.Asks[0].Price - book.Bids[0].Price) / mid * 10000,
+		DepthLevels:       levels,
+		BidDepthUSD:       depthUSD(book.Bids),
+		AskDepthUSD:       depthUSD(book.Asks),
+		ReferenceNotional: notional,
+		BuySlippage:       EstimateSlippage(book, true, notional),
+		SellSlippage:      EstimateSlippage(book, false, notional),
+	}
+	if total := data.BidDepthUSD + data.AskDepthUSD; total > 0 {
+		data.Imbalance = (data.BidDepthUSD - data.AskDepthUSD) / total
+	}
+	return data
+}
+
+// EstimateSlippage 估算市价买入（isBuy）或卖出 notional USDT 的成交均价和滑点
+func EstimateSlippage(book *OrderBook, isBuy bool, notional float64) SlippageEstimate {
+	mid := book.midPrice()
+	if mid <= 0 || notional <= 0 {
+		return SlippageEstimate{}
+	}
+
+	var cost, qty float64
+	for _, level := range book.sideLevels(isBuy) {
+		take := min(level.Price*level.Quantity, notional-cost)
+		cost += take
+		qty += take / level.Price
+		if cost >= notional {
+			break
+		}
+	}
+	if qty == 0 {
+		return SlippageEstimate{}
+	}
+
+	avg := cost / qty
+	slippage := (avg - mid) / mid * 100
+	if !isBuy {
+		slippage = -slippage
+	}
+	return SlippageEstimate{
+		AvgPrice:    avg,
+		SlippagePct: slippage,
+		FilledUSD:   cost,
+		Complete:    cost >= notional*(1-1e-9),
+	}
+}
+
+// MaxNotionalWithinSlippage 返回市价成交均价滑点不超过 maxSlippagePct（%，相对中间价）的最大名义金额（USDT）。
+// 订单簿全部吃完仍未超限时返回订单簿总金额
+func MaxNotionalWithinSlippage(book *OrderBook, isBuy bool, maxSlippagePct float64) float64 {
+	mid := book.midPrice()
+	if mid <= 0 {
+		return 0
+	}
+
+	// 成交均价的上限（买入）或下限（卖出）
+	limit := mid * (1 + maxSlippagePct/100)
+	if !isBuy {
+		limit = mid * (1 - maxSlippagePct/100)
+	}
+
+	var cost, qty float64
+	for _, level := range book.sideLevels(isBuy) {
+		worse := level.Price > limit
+		if !isBuy {
+			worse = level.Price < limit
+		}
+		if !worse {
+			cost += level.Price * level.Quantity
+			qty += level.Quantity
+			continue
+		}
+		// 该档价格劣于上限：只吃到均价恰好等于上限为止，(cost + p·q) / (qty + q) = limit
+		take := (limit*qty - cost) / (level.Price - limit)
+		if take < level.Quantity {
+			cost += level.Price * max(take, 0)
+			break
+		}
+		cost += level.Price * level.Quantity
+		qty += level.Quantity
+	}
+	return cost
+}
@@ -0,0 +1,132 @@
+package market
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testOrderBook() *OrderBook {
+	return &OrderBook{
+		Symbol: "BTCUSDT",
+		Bids:   []OrderBookLevel{{Price: 100, Quantity: 1}, {Price: 99, Quantity: 2}},
+		Asks:   []OrderBookLevel{{Price: 101, Quantity: 1}, {Price: 102, Quantity: 2}},
+	}
+}
+
+func TestLocalOrderBook_SequenceChecks(t *testing.T) {
+	now := time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)
+	book := NewLocalOrderBook("btcusdt")
+
+	if book.Snapshot(5, now) != nil {
+		t.Error("empty book should not return a snapshot")
+	}
+
+	update := DepthWSData{
+		FinalUpdateID: 10,
+		Bids:          [][2]string{{"100", "1"}, {"99", "2"}},
+		Asks:          [][2]string{{"101", "1"}},
+	}
+	if err := book.apply(update, now); err != nil {
+		t.Fatalf("first update: %v", err)
+	}
+
+	stale := update
+	stale.FinalUpdateID, stale.Bids = 9, [][2]string{{"50", "1"}}
+	if err := book.apply(stale, now); !errors.Is(err, errStaleDepthUpdate) {
+		t.Errorf("out-of-order update should be dropped, got %v", err)
+	}
+
+	crossed := update
+	crossed.FinalUpdateID, crossed.PrevUpdateID, crossed.Bids = 11, 10, [][2]string{{"102", "1"}}
+	if err := book.apply(crossed, now); !errors.Is(err, errCrossedBook) {
+		t.Errorf("crossed book should be rejected, got %v", err)
+	}
+
+	gapped := update
+	gapped.FinalUpdateID, gapped.PrevUpdateID = 20, 15
+	if err := book.apply(gapped, now); !errors.Is(err, errDepthSequenceGap) || book.Gaps() != 1 {
+		t.Errorf("gap should be counted and reported: err=%v gaps=%d", err, book.Gaps())
+	}
+	if book.Snapshot(1, now) != nil {
+		t.Error("book should not return a snapshot until it resyncs after a gap")
+	}
+
+	resynced := update
+	resynced.FinalUpdateID, resynced.PrevUpdateID = 21, 20
+	if err := book.apply(resynced, now); err != nil {
+		t.Fatalf("continuous update after a gap should resync: %v", err)
+	}
+
+	snapshot := book.Snapshot(1, now.Add(time.Second))
+	if snapshot == nil || snapshot.Symbol != "BTCUSDT" || len(snapshot.Bids) != 1 || snapshot.Bids[0].Price != 100 {
+		t.Errorf("unexpected snapshot: %+v", snapshot)
+	}
+	if book.Snapshot(1, now.Add(orderBookStaleAfter+time.Second)) != nil {
+		t.Error("stale book should not return a snapshot")
+	}
+}
+
+func TestAnalyzeOrderBook(t *testing.T) {
+	liq := AnalyzeOrderBook(testOrderBook(), 10, 202)
+	if liq == nil {
+		t.Fatal("expected liquidity data")
+	}
+	if math.Abs(liq.SpreadBps-1/100.5*10000) > 1e-9 {
+		t.Errorf("SpreadBps = %v", liq.SpreadBps)
+	}
+	if liq.BidDepthUSD != 298 || liq.AskDepthUSD != 305 {
+		t.Errorf("depth = %v / %v", liq.BidDepthUSD, liq.AskDepthUSD)
+	}
+	if math.Abs(liq.Imbalance-(298.0-305.0)/603.0) > 1e-9 {
+		t.Errorf("Imbalance = %v", liq.Imbalance)
+	}
+	if buy := liq.BuySlippage; !buy.Complete || buy.AvgPrice <= 101 || buy.AvgPrice >= 102 || buy.SlippagePct <= 0 {
+		t.Errorf("unexpected buy estimate: %+v", buy)
+	}
+	if sell := liq.SellSlippage; !sell.Complete || sell.SlippagePct <= 0 {
+		t.Errorf("sell slippage should be positive: %+v", sell)
+	}
+
+	if AnalyzeOrderBook(&OrderBook{Bids: testOrderBook().Bids}, 10, 100) != nil {
+		t.Error("one-sided book should return nil")
+	}
+}
+
+func TestEstimateSlippage_ThinBook(t *testing.T) {
+	est := EstimateSlippage(testOrderBook(), true, 1000)
+	if est.Complete || est.FilledUSD != 305 {
+		t.Errorf("thin book should only partially fill: %+v", est)
+	}
+}
+
+func TestMaxNotionalWithinSlippage(t *testing.T) {
+	book := testOrderBook()
+	pct := 1 / 100.5 * 100 // 均价上限 101.5 / 下限 99.5
+
+	if got := MaxNotionalWithinSlippage(book, true, pct); math.Abs(got-203) > 1e-6 {
+		t.Errorf("buy: got %v, want 203", got)
+	}
+	if got := MaxNotionalWithinSlippage(book, false, pct); math.Abs(got-199) > 1e-6 {
+		t.Errorf("sell: got %v, want 199", got)
+	}
+	if got := MaxNotionalWithinSlippage(book, true, 50); got != 305 {
+		t.Errorf("loose limit should take the whole book, got %v", got)
+	}
+	if got := MaxNotionalWithinSlippage(book, true, 0.1); got != 0 {
+		t.Errorf("limit inside the spread should allow nothing, got %v", got)
+	}
+}
+
+func TestFormat_Liquidity(t *testing.T) {
+	data := &Data{Symbol: "BTCUSDT", CurrentPrice: 100.5, Liquidity: AnalyzeOrderBook(testOrderBook(), 10, 1000)}
+	out := Format(data)
+	if !strings.Contains(out, "spread = 99.50 bps") || !strings.Contains(out, "bids $298 vs. asks $305") {
+		t.Errorf("missing order book line:\n%s", out)
+	}
+	if !strings.Contains(out, "book only fills $305") {
+		t.Errorf("thin book should be flagged:\n%s", out)
+	}
+}
@@ -0,0 +1,217 @@
+package market
+
+import (
+	"errors"
+	"log"
+	"slices"
+	"strings"
+	"time"
+)
+
+// MarketDataProvider 行情数据源：K线、最新价、资金费率和持仓量。
+// 每个交易员使用与其交易所一致的数据源，避免按币安价格在其他交易所下单
+type MarketDataProvider interface {
+	// Name 数据源名称（用于日志）
+	Name() string
+	// GetKlines 获取最近 limit 根K线，按时间升序排列
+	GetKlines(symbol, interval string, limit int) ([]Kline, error)
+	// GetCurrentPrice 获取最新成交价
+	GetCurrentPrice(symbol string) (float64, error)
+	// GetFundingRate 获取当前资金费率
+	GetFundingRate(symbol string) (float64, error)
+	// GetOpenInterest 获取持仓量（以币为单位）
+	GetOpenInterest(symbol string) (*OIData, error)
+	// GetOrderBook 获取买卖盘各前 limit 档的订单簿
+	GetOrderBook(symbol string, limit int) (*OrderBook, error)
+}
+
+// HistoricalKlineProvider 支持按时间范围拉取历史K线的行情数据源（回测使用）
+type HistoricalKlineProvider interface {
+	// GetKlinesRange 获取 [start, end] 内的K线，按时间升序排列
+	GetKlinesRange(symbol, interval string, start, end time.Time) ([]Kline, error)
+}
+
+const (
+	// ProviderBinance 币安 USDT 永续行情
+	ProviderBinance = "binance"
+	// ProviderHyperliquid Hyperliquid 永续行情
+	ProviderHyperliquid = "hyperliquid"
+	// ProviderBybit Bybit USDT 永续行情
+	ProviderBybit = "bybit"
+	// FallbackNone 不使用备用数据源
+	FallbackNone = "none"
+)
+
+// errEmptyMarketData 数据源返回了空数据
+var errEmptyMarketData = errors.New("返回数据为空")
+
+// defaultKlineLimit 通过 REST 接口获取K线时的数量（与 WebSocket 缓存初始化数量一致）
+const defaultKlineLimit = 100
+
+// BinanceProvider 币安行情：已订阅周期（3m/4h）的K线和订单簿读取 WSMonitorCli 的 WebSocket 缓存，其余数据走 REST 接口
+type BinanceProvider struct {
+	api *APIClient
+}
+
+// NewBinanceProvider 创建币安行情数据源
+func NewBinanceProvider() *BinanceProvider {
+	return &BinanceProvider{api: NewAPIClient()}
+}
+
+func (p *BinanceProvider) Name() string { return ProviderBinance }
+
+func (p *BinanceProvider) GetKlines(symbol, interval string, limit int) ([]Kline, error) {
+	if WSMonitorCli != nil && slices.Contains(subKlineTime, interval) {
+		klines, err := WSMonitorCli.GetCurrentKlines(symbol, interval)
+		if err != nil || limit <= 0 {
+			return klines, err
+		}
+		return klines[max(0, len(klines)-limit):], nil
+	}
+	if limit <= 0 {
+		limit = defaultKlineLimit
+	}
+	return p.api.GetKlines(symbol, interval, limit)
+}
+
+func (p *BinanceProvider) GetKlinesRange(symbol, interval string, start, end time.Time) ([]Kline, error) {
+	return getBinanceKlinesRange(symbol, interval, start, end)
+}
+
+func (p *BinanceProvider) GetCurrentPrice(symbol string) (float64, error) {
+	return p.api.GetCurrentPrice(symbol)
+}
+
+func (p *BinanceProvider) GetFundingRate(symbol string) (float64, error) {
+	return getFundingRate(symbol)
+}
+
+func (p *BinanceProvider) GetOpenInterest(symbol string) (*OIData, error) {
+	return getOpenInterestData(symbol)
+}
+
+func (p *BinanceProvider) GetOrderBook(symbol string, limit int) (*OrderBook, error) {
+	if WSMonitorCli != nil {
+		return WSMonitorCli.GetOrderBook(symbol, limit)
+	}
+	return p.api.GetOrderBook(symbol, limit)
+}
+
+// defaultProvider 未指定数据源时使用的币安行情
+var defaultProvider MarketDataProvider = NewBinanceProvider()
+
+// DefaultProvider 返回默认的币安行情数据源
+func DefaultProvider() MarketDataProvider {
+	return defaultProvider
+}
+
+// ProviderForExchange 返回与交易所匹配的行情数据源。没有专用数据源的交易所使用币安行情；
+// fallback 为 "binance"（默认）时，专用数据源请求失败会改用币安行情，为 "none" 时不回退
+func ProviderForExchange(exchange string, fallback string) MarketDataProvider {
+	var primary MarketDataProvider
+	switch strings.ToLower(exchange) {
+	case ProviderHyperliquid:
+		primary = NewHyperliquidProvider()
+	case ProviderBybit:
+		primary = NewBybitProvider()
+	default:
+		return defaultProvider
+	}
+
+	if strings.EqualFold(fallback, FallbackNone) {
+		return primary
+	}
+	return WithFallback(primary, defaultProvider)
+}
+
+// fallbackProvider 主数据源失败时改用备用数据源
+type fallbackProvider struct {
+	primary  MarketDataProvider
+	fallback MarketDataProvider
+}
+
+// WithFallback 组合主数据源和备用数据源，主数据源的每个请求失败时改用备用数据源
+func WithFallback(primary, fallback MarketDataProvider) MarketDataProvider {
+	return &fallbackProvider{primary: primary, fallback: fallback}
+}
+
+func (p *fallbackProvider) Name() string {
+	return p.primary.Name() + "+" + p.fallback.Name()
+}
+
+func (p *fallbackProvider) warn(what, symbol string, err error) {
+	log.Printf("⚠️  %s 获取%s %s失败，改用 %s 行情: %v", p.primary.Name(), symbol, what, p.fallback.Name(), err)
+}
+
+func (p *fallbackProvider) GetKlines(symbol, interval string, limit int) ([]Kline, error) {
+	klines, err := p.primary.GetKlines(symbol, interval, limit)
+	if err == nil && len(klines) > 0 {
+		return klines, nil
+	}
+	if err == nil {
+		err = errEmptyMarketData
+	}
+	p.warn(interval+"K线", symbol, err)
+	return p.fallback.GetKlines(symbol, interval, limit)
+}
+
+// GetKlinesRange 主数据源不支持历史区间查询、请求失败或返回为空时改用备用数据源
+func (p *fallbackProvider) GetKlinesRange(symbol, interval string, start, end time.Time) ([]Kline, error) {
+	var err error
+	if historical, ok := p.primary.(HistoricalKlineProvider); ok {
+		var klines []Kline
+		klines, err = historical.GetKlinesRange(symbol, interval, start, end)
+		if err == nil && len(klines) > 0 {
+			return klines, nil
+		}
+		if err == nil {
+			err = errEmptyMarketData
+		}
+	} else {
+		err = errors.New("不支持历史K线查询")
+	}
+	p.warn(interval+"历史K线", symbol, err)
+	return GetKlinesRange(p.fallback, symbol, interval, start, end)
+}
+
+func (p *fallbackProvider) GetCurrentPrice(symbol string) (float64, error) {
+	price, err := p.primary.GetCurrentPrice(symbol)
+	if err == nil && price > 0 {
+		return price, nil
+	}
+	if err == nil {
+		err = errEmptyMarketData
+	}
+	p.warn("最新价", symbol, err)
+	return p.fallback.GetCurrentPrice(symbol)
+}
+
+func (p *fallbackProvider) GetFundingRate(symbol string) (float64, error) {
+	rate, err := p.primary.GetFundingRate(symbol)
+	if err == nil {
+		return rate, nil
+	}
+	p.warn("资金费率", symbol, err)
+	return p.fallback.GetFundingRate(symbol)
+}
+
+func (p *fallbackProvider) GetOpenInterest(symbol string) (*OIData, error) {
+	oi, err := p.primary.GetOpenInterest(symbol)
+	if err == nil {
+		return oi, nil
+	}
+	p.warn("持仓量", symbol, err)
+	return p.fallback.GetOpenInterest(symbol)
+}
+
+func (p *fallbackProvider) GetOrderBook(symbol string, limit int) (*OrderBook, error) {
+	book, err := p.primary.GetOrderBook(symbol, limit)
+	if err == nil && len(book.Bids) > 0 && len(book.Asks) > 0 {
+		return book, nil
+	}
+	if err == nil {
+		err = errEmptyMarketData
+	}
+	p.warn("订单簿", symbol, err)
+	return p.fallback.GetOrderBook(symbol, limit)
+}
@@ -0,0 +1,325 @@
+package market
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestHyperliquidProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Type string `json:"type"`
+			Req  struct {
+				Coin     string `json:"coin"`
+				Interval string `json:"interval"`
+			} `json:"req"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		switch req.Type {
+		case "candleSnapshot":
+			if req.Req.Coin != "BTC" || req.Req.Interval != "3m" {
+				t.Errorf("unexpected candle request: %+v", req.Req)
+			}
+			w.Write([]byte(`[
+				{"t":1000,"T":1179,"o":"100","h":"102","l":"99","c":"101","v":"5","n":3},
+				{"t":1180,"T":1359,"o":"101","h":"103","l":"100","c":"102","v":"6","n":4}
+			]`))
+		case "l2Book":
+			w.Write([]byte(`{"coin":"BTC","time":1700000000000,"levels":[
+				[{"px":"101.9","sz":"2","n":1},{"px":"101.8","sz":"3","n":2}],
+				[{"px":"102.1","sz":"1.5","n":1}]
+			]}`))
+		case "metaAndAssetCtxs":
+			w.Write([]byte(`[
+				{"universe":[{"name":"BTC"},{"name":"ETH"}]},
+				[{"funding":"0.0000125","openInterest":"1500.5","markPx":"101.9","midPx":"102.1"},
+				 {"funding":"-0.00001","openInterest":"20000","markPx":"3000","midPx":"3001"}]
+			]`))
+		default:
+			t.Errorf("unexpected request type %s", req.Type)
+		}
+	}))
+	defer server.Close()
+
+	p := &HyperliquidProvider{url: server.URL, client: server.Client()}
+
+	klines, err := p.GetKlines("BTCUSDT", "3m", 1)
+	if err != nil {
+		t.Fatalf("GetKlines: %v", err)
+	}
+	if len(klines) != 1 || klines[0].OpenTime != 1180 || klines[0].Close != 102 || klines[0].Volume != 6 {
+		t.Errorf("should keep the latest candle only: %+v", klines)
+	}
+
+	price, err := p.GetCurrentPrice("BTCUSDT")
+	if err != nil || price != 102.1 {
+		t.Errorf("GetCurrentPrice = %v, %v", price, err)
+	}
+	rate, err := p.GetFundingRate("ETHUSDT")
+	if err != nil || rate != -0.00001 {
+		t.Errorf("GetFundingRate = %v, %v", rate, err)
+	}
+	oi, err := p.GetOpenInterest("BTCUSDT")
+	if err != nil || oi.Latest != 1500.5 {
+		t.Errorf("GetOpenInterest = %+v, %v", oi, err)
+	}
+	book, err := p.GetOrderBook("BTCUSDT", 1)
+	if err != nil {
+		t.Fatalf("GetOrderBook: %v", err)
+	}
+	if len(book.Bids) != 1 || book.Bids[0].Price != 101.9 || book.Asks[0].Quantity != 1.5 || book.Time != 1700000000000 {
+		t.Errorf("unexpected order book: %+v", book)
+	}
+	if _, err := p.GetCurrentPrice("DOGEUSDT"); err == nil {
+		t.Error("unlisted coin should return an error")
+	}
+}
+
+func TestBybitProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("category") != "linear" || q.Get("symbol") != "BTCUSDT" {
+			t.Errorf("unexpected query: %s", r.URL.RawQuery)
+		}
+		switch r.URL.Path {
+		case "/v5/market/kline":
+			if q.Get("interval") != "240" {
+				t.Errorf("4h should map to interval 240, got %s", q.Get("interval"))
+			}
+			// Bybit 按时间倒序返回
+			w.Write([]byte(`{"retCode":0,"retMsg":"OK","result":{"list":[
+				["14400000","101","103","100","102","6","612"],
+				["0","100","102","99","101","5","505"]
+			]}}`))
+		case "/v5/market/orderbook":
+			w.Write([]byte(`{"retCode":0,"retMsg":"OK","result":{"s":"BTCUSDT","ts":1700000000000,
+				"b":[["102.4","3"]],"a":[["102.6","4"],["102.7","5"]]}}`))
+		case "/v5/market/tickers":
+			w.Write([]byte(`{"retCode":0,"retMsg":"OK","result":{"list":[
+				{"lastPrice":"102.5","markPrice":"102.4","fundingRate":"0.0001","openInterest":"2500"}
+			]}}`))
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	p := &BybitProvider{baseURL: server.URL, client: server.Client()}
+
+	klines, err := p.GetKlines("BTCUSDT", "4h", 100)
+	if err != nil {
+		t.Fatalf("GetKlines: %v", err)
+	}
+	if len(klines) != 2 || klines[0].OpenTime != 0 || klines[1].Close != 102 {
+		t.Errorf("klines should be ascending: %+v", klines)
+	}
+	if klines[0].CloseTime != 14400000-1 || klines[1].QuoteVolume != 612 {
+		t.Errorf("unexpected kline fields: %+v", klines[0])
+	}
+
+	book, err := p.GetOrderBook("BTCUSDT", 20)
+	if err != nil || len(book.Asks) != 2 || book.Bids[0].Price != 102.4 || book.Time != 1700000000000 {
+		t.Errorf("GetOrderBook = %+v, %v", book, err)
+	}
+
+	price, err := p.GetCurrentPrice("BTCUSDT")
+	if err != nil || price != 102.5 {
+		t.Errorf("GetCurrentPrice = %v, %v", price, err)
+	}
+	rate, err := p.GetFundingRate("BTCUSDT")
+	if err != nil || rate != 0.0001 {
+		t.Errorf("GetFundingRate = %v, %v", rate, err)
+	}
+	oi, err := p.GetOpenInterest("BTCUSDT")
+	if err != nil || oi.Latest != 2500 {
+		t.Errorf("GetOpenInterest = %+v, %v", oi, err)
+	}
+}
+
+// stubProvider 返回固定数据的行情数据源
+type stubProvider struct {
+	name  string
+	price float64
+	err   error
+}
+
+func (s *stubProvider) Name() string { return s.name }
+func (s *stubProvider) GetKlines(symbol, interval string, limit int) ([]Kline, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return []Kline{{Close: s.price}}, nil
+}
+func (s *stubProvider) GetCurrentPrice(symbol string) (float64, error) { return s.price, s.err }
+func (s *stubProvider) GetFundingRate(symbol string) (float64, error)  { return 0.0001, s.err }
+func (s *stubProvider) GetOpenInterest(symbol string) (*OIData, error) {
+	return &OIData{Latest: s.price}, s.err
+}
+func (s *stubProvider) GetOrderBook(symbol string, limit int) (*OrderBook, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &OrderBook{Bids: []OrderBookLevel{{Price: s.price - 1, Quantity: 1}}, Asks: []OrderBookLevel{{Price: s.price + 1, Quantity: 1}}}, nil
+}
+
+func TestWithFallback(t *testing.T) {
+	primary := &stubProvider{name: "hyperliquid", err: errors.New("timeout")}
+	p := WithFallback(primary, &stubProvider{name: "binance", price: 100})
+
+	if price, err := p.GetCurrentPrice("BTCUSDT"); err != nil || price != 100 {
+		t.Errorf("should fall back to binance: %v, %v", price, err)
+	}
+	if klines, err := p.GetKlines("BTCUSDT", "3m", 10); err != nil || klines[0].Close != 100 {
+		t.Errorf("klines should fall back: %v, %v", klines, err)
+	}
+	if book, err := p.GetOrderBook("BTCUSDT", 10); err != nil || book.Asks[0].Price != 101 {
+		t.Errorf("order book should fall back: %+v, %v", book, err)
+	}
+
+	primary.err, primary.price = nil, 105
+	if price, _ := p.GetCurrentPrice("BTCUSDT"); price != 105 {
+		t.Errorf("healthy primary should be used, got %v", price)
+	}
+	if p.Name() != "hyperliquid+binance" {
+		t.Errorf("unexpected name %s", p.Name())
+	}
+}
+
+func TestProviderForExchange(t *testing.T) {
+	cases := map[string]string{
+		"binance":     "binance",
+		"okx":         "binance",
+		"lighter":     "binance",
+		"hyperliquid": "hyperliquid+binance",
+		"bybit":       "bybit+binance",
+	}
+	for exchange, want := range cases {
+		if got := ProviderForExchange(exchange, "").Name(); got != want {
+			t.Errorf("%s: got %s, want %s", exchange, got, want)
+		}
+	}
+	if got := ProviderForExchange("hyperliquid", FallbackNone).Name(); got != "hyperliquid" {
+		t.Errorf("fallback none should not wrap, got %s", got)
+	}
+}
+
+func TestBybitProvider_GetKlinesRange(t *testing.T) {
+	const minute = int64(60_000)
+	total := bybitMaxKlineLimit + 200
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		q := r.URL.Query()
+		start, _ := strconv.ParseInt(q.Get("start"), 10, 64)
+		end, _ := strconv.ParseInt(q.Get("end"), 10, 64)
+		limit, _ := strconv.Atoi(q.Get("limit"))
+
+		// 返回区间内最新的 limit 根，按时间倒序
+		var rows [][]string
+		for i := total - 1; i >= 0 && len(rows) < limit; i-- {
+			openTime := int64(i) * minute
+			if openTime >= start && openTime <= end {
+				ts := strconv.FormatInt(openTime, 10)
+				rows = append(rows, []string{ts, "100", "101", "99", "100", "1", "100"})
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"retCode": 0, "result": map[string]any{"list": rows}})
+	}))
+	defer server.Close()
+
+	p := &BybitProvider{baseURL: server.URL, client: server.Client()}
+	klines, err := p.GetKlinesRange("BTCUSDT", "1m", time.UnixMilli(0), time.UnixMilli(int64(total-1)*minute))
+	if err != nil {
+		t.Fatalf("GetKlinesRange: %v", err)
+	}
+	if len(klines) != total || requests != 2 {
+		t.Fatalf("expected %d klines in 2 pages, got %d in %d", total, len(klines), requests)
+	}
+	for i, k := range klines {
+		if k.OpenTime != int64(i)*minute {
+			t.Fatalf("klines should be ascending without gaps, index %d at %d", i, k.OpenTime)
+		}
+	}
+}
+
+func TestHyperliquidProvider_GetKlinesRange(t *testing.T) {
+	const minute = int64(60_000)
+	total := hyperliquidMaxCandles + 3
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		var req struct {
+			Req struct {
+				StartTime int64 `json:"startTime"`
+				EndTime   int64 `json:"endTime"`
+			} `json:"req"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		var candles []map[string]any
+		for i := 0; i < total && len(candles) < hyperliquidMaxCandles; i++ {
+			openTime := int64(i) * minute
+			if openTime >= req.Req.StartTime && openTime <= req.Req.EndTime {
+				candles = append(candles, map[string]any{"t": openTime, "T": openTime + minute - 1, "o": "1", "h": "1", "l": "1", "c": "1", "v": "1"})
+			}
+		}
+		json.NewEncoder(w).Encode(candles)
+	}))
+	defer server.Close()
+
+	p := &HyperliquidProvider{url: server.URL, client: server.Client()}
+	klines, err := p.GetKlinesRange("BTCUSDT", "1m", time.UnixMilli(0), time.UnixMilli(int64(total)*minute))
+	if err != nil {
+		t.Fatalf("GetKlinesRange: %v", err)
+	}
+	if len(klines) != total || requests != 2 || klines[total-1].OpenTime != int64(total-1)*minute {
+		t.Errorf("expected %d klines in 2 pages, got %d in %d", total, len(klines), requests)
+	}
+}
+
+// rangeStubProvider 支持历史区间查询的 stubProvider
+type rangeStubProvider struct {
+	stubProvider
+}
+
+func (s *rangeStubProvider) GetKlinesRange(symbol, interval string, start, end time.Time) ([]Kline, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return []Kline{{OpenTime: start.UnixMilli(), Close: s.price}}, nil
+}
+
+func TestGetKlinesRange_UsesProviderWithFallback(t *testing.T) {
+	start, end := time.UnixMilli(1000), time.UnixMilli(2000)
+	binance := &rangeStubProvider{stubProvider{name: "binance", price: 100}}
+
+	klines, err := GetKlinesRange(&rangeStubProvider{stubProvider{name: "bybit", price: 50}}, "BTCUSDT", "1m", start, end)
+	if err != nil || len(klines) != 1 || klines[0].Close != 50 {
+		t.Errorf("should use the exchange provider: %+v, %v", klines, err)
+	}
+
+	failing := WithFallback(&rangeStubProvider{stubProvider{name: "bybit", err: errors.New("timeout")}}, binance)
+	klines, err = GetKlinesRange(failing, "BTCUSDT", "1m", start, end)
+	if err != nil || len(klines) != 1 || klines[0].Close != 100 {
+		t.Errorf("failed range request should fall back to binance: %+v, %v", klines, err)
+	}
+
+	unsupported := WithFallback(&stubProvider{name: "hyperliquid", price: 50}, binance)
+	klines, err = GetKlinesRange(unsupported, "BTCUSDT", "1m", start, end)
+	if err != nil || len(klines) != 1 || klines[0].Close != 100 {
+		t.Errorf("provider without range support should fall back to binance: %+v, %v", klines, err)
+	}
+
+	if _, err := GetKlinesRange(&rangeStubProvider{stubProvider{name: "bybit", err: errors.New("timeout")}}, "BTCUSDT", "1m", start, end); err == nil {
+		t.Error("provider without fallback should return the error")
+	}
+}
@@ -15,6 +15,7 @@ type Data struct {
 	FundingRate       float64
 	IntradaySeries    *IntradayData
 	LongerTermContext *LongerTermData
+	Liquidity         *LiquidityData // 订单簿流动性（获取失败时为 nil）
 }
 
 // OIData Open Interest数据
@@ -77,6 +78,20 @@ type Kline struct {
 
 type KlineResponse []interface{}
 
+// OrderBook 订单簿深度快照
+type OrderBook struct {
+	Symbol string           `json:"symbol"`
+	Bids   []OrderBookLevel `json:"bids"` // 买盘，价格从高到低
+	Asks   []OrderBookLevel `json:"asks"` // 卖盘，价格从低到高
+	Time   int64            `json:"time"` // 撮合引擎时间（毫秒）
+}
+
+// OrderBookLevel 订单簿中的一档挂单
+type OrderBookLevel struct {
+	Price    float64 `json:"price"`
+	Quantity float64 `json:"qty"`
+}
+
 type PriceTicker struct {
 	Symbol string `json:"symbol"`
 	Price  string `json:"price"`
@@ -47,6 +47,19 @@ type KlineWSData struct {
 	} `json:"k"`
 }
 
+// DepthWSData 部分深度流推送（前 N 档完整快照）
+type DepthWSData struct {
+	EventType       string      `json:"e"`
+	EventTime       int64       `json:"E"`
+	TransactionTime int64       `json:"T"`
+	Symbol          string      `json:"s"`
+	FirstUpdateID   int64       `json:"U"`
+	FinalUpdateID   int64       `json:"u"`
+	PrevUpdateID    int64       `json:"pu"` // 上一条推送的 u
+	Bids            [][2]string `json:"b"`
+	Asks            [][2]string `json:"a"`
+}
+
 type TickerWSData struct {
 	EventType          string `json:"e"`
 	EventTime          int64  `json:"E"`
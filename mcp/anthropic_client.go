@@ -0,0 +1,379 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	ProviderAnthropic       = "anthropic"
+	DefaultAnthropicBaseURL = "https://api.anthropic.com/v1"
+	DefaultAnthropicModel   = "claude-sonnet-4-5"
+
+	// AnthropicAPIVersion Messages API 版本（anthropic-version 请求头）
+	AnthropicAPIVersion = "2023-06-01"
+)
+
+// AnthropicClient Anthropic Messages API 客户端（非 OpenAI 兼容格式）
+//
+// 复用 Client 的重试和日志流程，通过 hooks 重写请求体构建、URL、认证头和响应解析：
+// - system prompt 放在顶层 system 字段
+// - 工具调用使用 tool_use / tool_result 内容块
+// - 开启扩展思考（WithThinkingBudget）时，思考内容写入 Response.Reasoning
+type AnthropicClient struct {
+	*Client
+}
+
+// NewAnthropicClient 创建 Anthropic 客户端
+func NewAnthropicClient() AIClient {
+	return NewAnthropicClientWithOptions()
+}
+
+// NewAnthropicClientWithOptions 创建 Anthropic 客户端（支持选项模式）
+//
+// 使用示例：
+//
+//	client := mcp.NewAnthropicClientWithOptions(
+//	    mcp.WithAPIKey("sk-ant-xxx"),
+//	    mcp.WithThinkingBudget(4000),
+//	)
+func NewAnthropicClientWithOptions(opts ...ClientOption) AIClient {
+	anthropicOpts := []ClientOption{
+		WithProvider(ProviderAnthropic),
+		WithModel(DefaultAnthropicModel),
+		WithBaseURL(DefaultAnthropicBaseURL),
+	}
+
+	// 用户选项优先级更高
+	allOpts := append(anthropicOpts, opts...)
+
+	baseClient := NewClient(allOpts...).(*Client)
+	anthropicClient := &AnthropicClient{
+		Client: baseClient,
+	}
+
+	// hooks 指向 AnthropicClient，使 Client 的调用流程分派到 Messages API 的实现
+	baseClient.hooks = anthropicClient
+
+	return anthropicClient
+}
+
+func (ac *AnthropicClient) SetAPIKey(apiKey string, customURL string, customModel string) {
+	ac.APIKey = apiKey
+
+	if len(apiKey) > 8 {
+		ac.logger.Infof("🔧 [MCP] Anthropic API Key: %s...%s", apiKey[:4], apiKey[len(apiKey)-4:])
+	}
+	if customURL != "" {
+		// 与自定义 API 一致：以 # 结尾表示使用完整 URL
+		if strings.HasSuffix(customURL, "#") {
+			ac.BaseURL = strings.TrimSuffix(customURL, "#")
+			ac.UseFullURL = true
+		} else {
+			ac.BaseURL = customURL
+		}
+		ac.logger.Infof("🔧 [MCP] Anthropic 使用自定义 BaseURL: %s", customURL)
+	} else {
+		ac.logger.Infof("🔧 [MCP] Anthropic 使用默认 BaseURL: %s", ac.BaseURL)
+	}
+	if customModel != "" {
+		ac.Model = customModel
+		ac.logger.Infof("🔧 [MCP] Anthropic 使用自定义 Model: %s", customModel)
+	} else {
+		ac.logger.Infof("🔧 [MCP] Anthropic 使用默认 Model: %s", ac.Model)
+	}
+}
+
+func (ac *AnthropicClient) setAuthHeader(reqHeaders http.Header) {
+	reqHeaders.Set("x-api-key", ac.APIKey)
+	reqHeaders.Set("anthropic-version", AnthropicAPIVersion)
+}
+
+func (ac *AnthropicClient) buildUrl() string {
+	if ac.UseFullURL {
+		return ac.BaseURL
+	}
+	return fmt.Sprintf("%s/messages", strings.TrimSuffix(ac.BaseURL, "/"))
+}
+
+// isRetryableError 在通用网络错误之外，服务过载（529 overloaded_error）也重试
+func (ac *AnthropicClient) isRetryableError(err error) bool {
+	if strings.Contains(err.Error(), "overloaded_error") {
+		return true
+	}
+	return ac.Client.isRetryableError(err)
+}
+
+// PromptTokenBudget 输入 prompt 的 token 预算，开启扩展思考时额外扣除思考预算
+func (ac *AnthropicClient) PromptTokenBudget() int {
+	if ac.config != nil && ac.config.PromptTokenBudget > 0 {
+		return ac.config.PromptTokenBudget
+	}
+	return ContextWindow(ac.Model) - ac.maxTokens(ac.MaxTokens, true)
+}
+
+// thinkingBudget 返回配置的扩展思考预算，0 表示不开启
+func (ac *AnthropicClient) thinkingBudget() int {
+	if ac.config == nil {
+		return 0
+	}
+	return max(ac.config.ThinkingBudget, 0)
+}
+
+// maxTokens 返回请求的 max_tokens：开启扩展思考时思考预算计入 max_tokens，需要额外加上
+func (ac *AnthropicClient) maxTokens(outputTokens int, thinking bool) int {
+	if thinking && ac.thinkingBudget() > 0 {
+		return outputTokens + ac.thinkingBudget()
+	}
+	return outputTokens
+}
+
+func (ac *AnthropicClient) buildMCPRequestBody(systemPrompt, userPrompt string) map[string]any {
+	messages := []Message{}
+	if systemPrompt != "" {
+		messages = append(messages, NewSystemMessage(systemPrompt))
+	}
+	messages = append(messages, NewUserMessage(userPrompt))
+	return ac.buildRequestBodyFromRequest(&Request{Model: ac.Model, Messages: messages})
+}
+
+// anthropicMessage Messages API 的一条消息（content 统一使用内容块数组）
+type anthropicMessage struct {
+	Role    string           `json:"role"`
+	Content []map[string]any `json:"content"`
+}
+
+// buildRequestBodyFromRequest 把 Request 转换为 Messages API 请求体：
+// system 消息合并到顶层 system 字段，tool 消息转换为 user 角色的 tool_result 内容块，
+// 连续相同角色的消息合并为一条
+func (ac *AnthropicClient) buildRequestBodyFromRequest(req *Request) map[string]any {
+	var systemParts []string
+	var messages []anthropicMessage
+	hasToolResult := false
+
+	appendBlocks := func(role string, blocks ...map[string]any) {
+		if len(blocks) == 0 {
+			return
+		}
+		if n := len(messages); n > 0 && messages[n-1].Role == role {
+			messages[n-1].Content = append(messages[n-1].Content, blocks...)
+			return
+		}
+		messages = append(messages, anthropicMessage{Role: role, Content: blocks})
+	}
+
+	for _, msg := range req.Messages {
+		switch msg.Role {
+		case "system":
+			if msg.Content != "" {
+				systemParts = append(systemParts, msg.Content)
+			}
+		case "tool":
+			hasToolResult = true
+			appendBlocks("user", map[string]any{
+				"type":        "tool_result",
+				"tool_use_id": msg.ToolCallID,
+				"content":     msg.Content,
+			})
+		case "assistant":
+			blocks := []map[string]any{}
+			if msg.Content != "" {
+				blocks = append(blocks, map[string]any{"type": "text", "text": msg.Content})
+			}
+			for _, call := range msg.ToolCalls {
+				blocks = append(blocks, map[string]any{
+					"type":  "tool_use",
+					"id":    call.ID,
+					"name":  call.Function.Name,
+					"input": toolUseInput(call.Function.Arguments),
+				})
+			}
+			appendBlocks("assistant", blocks...)
+		default:
+			if msg.Content != "" {
+				appendBlocks("user", map[string]any{"type": "text", "text": msg.Content})
+			}
+		}
+	}
+
+	model := req.Model
+	if model == "" {
+		model = ac.Model
+	}
+
+	// 回传工具结果时不开启扩展思考：Message 不保存思考块的签名，无法按 API 要求原样回传
+	thinking := ac.thinkingBudget() > 0 && !hasToolResult
+
+	outputTokens := ac.MaxTokens
+	if req.MaxTokens != nil {
+		outputTokens = *req.MaxTokens
+	}
+
+	requestBody := map[string]any{
+		"model":      model,
+		"messages":   messages,
+		"max_tokens": ac.maxTokens(outputTokens, thinking),
+	}
+	if len(systemParts) > 0 {
+		requestBody["system"] = strings.Join(systemParts, "\n\n")
+	}
+
+	if thinking {
+		// 扩展思考不支持自定义 temperature / top_p
+		requestBody["thinking"] = map[string]any{
+			"type":          "enabled",
+			"budget_tokens": ac.thinkingBudget(),
+		}
+	} else {
+		if req.Temperature != nil {
+			requestBody["temperature"] = *req.Temperature
+		} else {
+			requestBody["temperature"] = ac.config.Temperature
+		}
+		if req.TopP != nil {
+			requestBody["top_p"] = *req.TopP
+		}
+	}
+
+	if len(req.Stop) > 0 {
+		requestBody["stop_sequences"] = req.Stop
+	}
+
+	if len(req.Tools) > 0 {
+		tools := make([]map[string]any, 0, len(req.Tools))
+		for _, tool := range req.Tools {
+			schema := tool.Function.Parameters
+			if schema == nil {
+				schema = map[string]any{"type": "object", "properties": map[string]any{}}
+			}
+			entry := map[string]any{
+				"name":         tool.Function.Name,
+				"input_schema": schema,
+			}
+			if tool.Function.Description != "" {
+				entry["description"] = tool.Function.Description
+			}
+			tools = append(tools, entry)
+		}
+		requestBody["tools"] = tools
+
+		if choice := anthropicToolChoice(req.ToolChoice, thinking); choice != nil {
+			requestBody["tool_choice"] = choice
+		}
+	}
+
+	return requestBody
+}
+
+// toolUseInput 把 OpenAI 格式的 JSON 字符串参数转换为 tool_use 的 input 对象
+func toolUseInput(arguments string) json.RawMessage {
+	if strings.TrimSpace(arguments) == "" || !json.Valid([]byte(arguments)) {
+		return json.RawMessage("{}")
+	}
+	return json.RawMessage(arguments)
+}
+
+// anthropicToolChoice 把 OpenAI 格式的 tool_choice 转换为 Messages API 格式。
+// 扩展思考只支持 auto / none，此时强制调用指定函数降级为 auto
+func anthropicToolChoice(toolChoice string, thinking bool) map[string]any {
+	toolChoice = strings.TrimSpace(toolChoice)
+	switch toolChoice {
+	case "":
+		return nil
+	case "auto":
+		return map[string]any{"type": "auto"}
+	case "none":
+		return map[string]any{"type": "none"}
+	case "required":
+		if thinking {
+			return map[string]any{"type": "auto"}
+		}
+		return map[string]any{"type": "any"}
+	}
+
+	var choice struct {
+		Function struct {
+			Name string `json:"name"`
+		} `json:"function"`
+	}
+	if err := json.Unmarshal([]byte(toolChoice), &choice); err != nil || choice.Function.Name == "" {
+		return map[string]any{"type": "auto"}
+	}
+	if thinking {
+		return map[string]any{"type": "auto"}
+	}
+	return map[string]any{"type": "tool", "name": choice.Function.Name}
+}
+
+// parseMCPResponse 返回文本内容，有扩展思考时以 <reasoning> 标签放在开头
+func (ac *AnthropicClient) parseMCPResponse(body []byte) (string, error) {
+	resp, err := ac.parseMCPFullResponse(body)
+	if err != nil {
+		return "", err
+	}
+	return resp.TextWithReasoning(), nil
+}
+
+// parseMCPFullResponse 解析 Messages API 响应：text 块拼接为 Content，thinking 块拼接为 Reasoning，
+// tool_use 块转换为 OpenAI 格式的 ToolCall
+func (ac *AnthropicClient) parseMCPFullResponse(body []byte) (*Response, error) {
+	var result struct {
+		Content []struct {
+			Type     string          `json:"type"`
+			Text     string          `json:"text"`
+			Thinking string          `json:"thinking"`
+			ID       string          `json:"id"`
+			Name     string          `json:"name"`
+			Input    json.RawMessage `json:"input"`
+		} `json:"content"`
+		StopReason string `json:"stop_reason"`
+		Usage      struct {
+			InputTokens              int `json:"input_tokens"`
+			OutputTokens             int `json:"output_tokens"` // 含 thinking 块的 token
+			CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+			CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+		} `json:"usage"`
+	}
+
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+
+	if len(result.Content) == 0 {
+		return nil, fmt.Errorf("API返回空响应 (stop_reason: %s)", result.StopReason)
+	}
+
+	usage := result.Usage
+	var text, reasoning []string
+	resp := &Response{Usage: Usage{
+		PromptTokens:     usage.InputTokens + usage.CacheCreationInputTokens + usage.CacheReadInputTokens,
+		CompletionTokens: usage.OutputTokens,
+	}}
+	for _, block := range result.Content {
+		switch block.Type {
+		case "text":
+			text = append(text, block.Text)
+		case "thinking":
+			reasoning = append(reasoning, block.Thinking)
+		case "tool_use":
+			arguments := "{}"
+			if len(block.Input) > 0 {
+				arguments = string(block.Input)
+			}
+			resp.ToolCalls = append(resp.ToolCalls, ToolCall{
+				ID:       block.ID,
+				Type:     "function",
+				Function: FunctionCall{Name: block.Name, Arguments: arguments},
+			})
+		}
+	}
+	resp.Content = strings.Join(text, "")
+	resp.Reasoning = strings.TrimSpace(strings.Join(reasoning, "\n\n"))
+	// Messages API 不单独返回思考 token（已计入 output_tokens），按思考文本估算
+	if resp.Reasoning != "" {
+		resp.Usage.ReasoningTokens = min(EstimateTokens(ac.Model, resp.Reasoning), resp.Usage.CompletionTokens)
+	}
+	return resp, nil
+}
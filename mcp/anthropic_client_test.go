@@ -0,0 +1,214 @@
+package mcp
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newAnthropicTestServer 模拟 Messages API，记录收到的请求体并返回固定响应
+func newAnthropicTestServer(t *testing.T, response string, captured *map[string]any) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		if r.Header.Get("x-api-key") != "sk-ant-test-key" {
+			t.Errorf("x-api-key header not set: %q", r.Header.Get("x-api-key"))
+		}
+		if r.Header.Get("anthropic-version") != AnthropicAPIVersion {
+			t.Errorf("anthropic-version header not set: %q", r.Header.Get("anthropic-version"))
+		}
+		if r.Header.Get("Authorization") != "" {
+			t.Error("Authorization header should not be sent")
+		}
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, captured); err != nil {
+			t.Errorf("invalid request body: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(response))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newAnthropicTestClient(baseURL string, opts ...ClientOption) AIClient {
+	opts = append([]ClientOption{WithLogger(NewMockLogger()), WithMaxTokens(1000)}, opts...)
+	client := NewAnthropicClientWithOptions(opts...)
+	client.SetAPIKey("sk-ant-test-key", baseURL+"/v1", "")
+	return client
+}
+
+func TestNewAnthropicClient_Default(t *testing.T) {
+	client := NewAnthropicClient().(*AnthropicClient)
+
+	if client.Provider != ProviderAnthropic {
+		t.Errorf("Provider should be '%s', got '%s'", ProviderAnthropic, client.Provider)
+	}
+	if client.Model != DefaultAnthropicModel {
+		t.Errorf("Model should be '%s', got '%s'", DefaultAnthropicModel, client.Model)
+	}
+	if client.buildUrl() != DefaultAnthropicBaseURL+"/messages" {
+		t.Errorf("unexpected URL: %s", client.buildUrl())
+	}
+	if client.hooks != client {
+		t.Error("hooks should point to AnthropicClient for polymorphism")
+	}
+}
+
+func TestAnthropicClient_CallWithMessages(t *testing.T) {
+	var captured map[string]any
+	server := newAnthropicTestServer(t, `{
+		"content": [{"type": "text", "text": "Claude response"}],
+		"stop_reason": "end_turn"
+	}`, &captured)
+
+	result, err := newAnthropicTestClient(server.URL).CallWithMessages("system prompt", "user prompt")
+	if err != nil {
+		t.Fatalf("should not error: %v", err)
+	}
+	if result != "Claude response" {
+		t.Errorf("expected 'Claude response', got '%s'", result)
+	}
+
+	if captured["system"] != "system prompt" {
+		t.Errorf("system prompt should be a top-level field, got %v", captured["system"])
+	}
+	if captured["max_tokens"] != float64(1000) {
+		t.Errorf("max_tokens should be 1000, got %v", captured["max_tokens"])
+	}
+	messages := captured["messages"].([]any)
+	if len(messages) != 1 || messages[0].(map[string]any)["role"] != "user" {
+		t.Errorf("system message should not be sent in messages: %v", messages)
+	}
+}
+
+func TestAnthropicClient_CallWithRequestFull_Tools(t *testing.T) {
+	var captured map[string]any
+	server := newAnthropicTestServer(t, `{
+		"content": [
+			{"type": "text", "text": "查询完毕"},
+			{"type": "tool_use", "id": "toolu_2", "name": "submit_decisions", "input": {"decisions": []}}
+		],
+		"stop_reason": "tool_use"
+	}`, &captured)
+
+	req, err := NewRequestBuilder().
+		WithSystemPrompt("你是交易员").
+		WithUserPrompt("分析 BTC").
+		AddMessages(
+			NewAssistantToolCallMessage("", []ToolCall{{ID: "toolu_1", Type: "function", Function: FunctionCall{Name: "get_klines", Arguments: `{"symbol":"BTCUSDT"}`}}}),
+			NewToolMessage("toolu_1", "klines..."),
+		).
+		AddFunction("submit_decisions", "提交决策", map[string]any{"type": "object"}).
+		WithToolChoice(ToolChoiceFunction("submit_decisions")).
+		WithStopSequences([]string{"</decision>"}).
+		Build()
+	if err != nil {
+		t.Fatalf("build request: %v", err)
+	}
+
+	resp, err := newAnthropicTestClient(server.URL).CallWithRequestFull(req)
+	if err != nil {
+		t.Fatalf("should not error: %v", err)
+	}
+	if resp.Content != "查询完毕" {
+		t.Errorf("unexpected content: %q", resp.Content)
+	}
+	call, ok := resp.ToolCallByName("submit_decisions")
+	if !ok || call.ID != "toolu_2" || call.Function.Arguments != `{"decisions": []}` {
+		t.Errorf("tool_use should be converted to ToolCall: %+v", resp.ToolCalls)
+	}
+
+	messages := captured["messages"].([]any)
+	if len(messages) != 3 {
+		t.Fatalf("expected user, assistant and tool_result messages, got %d: %v", len(messages), messages)
+	}
+	assistant := messages[1].(map[string]any)
+	toolUse := assistant["content"].([]any)[0].(map[string]any)
+	if assistant["role"] != "assistant" || toolUse["type"] != "tool_use" || toolUse["input"].(map[string]any)["symbol"] != "BTCUSDT" {
+		t.Errorf("assistant tool call should become a tool_use block: %v", assistant)
+	}
+	toolResult := messages[2].(map[string]any)["content"].([]any)[0].(map[string]any)
+	if messages[2].(map[string]any)["role"] != "user" || toolResult["type"] != "tool_result" || toolResult["tool_use_id"] != "toolu_1" {
+		t.Errorf("tool message should become a user tool_result block: %v", messages[2])
+	}
+
+	tools := captured["tools"].([]any)
+	if tool := tools[0].(map[string]any); tool["name"] != "submit_decisions" || tool["input_schema"] == nil {
+		t.Errorf("tools should use input_schema: %v", tool)
+	}
+	if choice := captured["tool_choice"].(map[string]any); choice["type"] != "tool" || choice["name"] != "submit_decisions" {
+		t.Errorf("forced function should map to tool choice: %v", choice)
+	}
+	if stop := captured["stop_sequences"].([]any); len(stop) != 1 || stop[0] != "</decision>" {
+		t.Errorf("stop should map to stop_sequences: %v", captured["stop_sequences"])
+	}
+}
+
+func TestAnthropicClient_Thinking(t *testing.T) {
+	var captured map[string]any
+	server := newAnthropicTestServer(t, `{
+		"content": [
+			{"type": "thinking", "thinking": "BTC 趋势向上", "signature": "sig"},
+			{"type": "text", "text": "<decision>[]</decision>"}
+		],
+		"stop_reason": "end_turn"
+	}`, &captured)
+
+	client := newAnthropicTestClient(server.URL, WithThinkingBudget(2000))
+	req, _ := NewRequestBuilder().
+		WithUserPrompt("分析 BTC").
+		AddFunction("submit_decisions", "提交决策", nil).
+		WithToolChoice(ToolChoiceFunction("submit_decisions")).
+		Build()
+	resp, err := client.CallWithRequestFull(req)
+	if err != nil {
+		t.Fatalf("should not error: %v", err)
+	}
+	if resp.Reasoning != "BTC 趋势向上" {
+		t.Errorf("thinking should map to Reasoning, got %q", resp.Reasoning)
+	}
+	if !strings.HasPrefix(resp.TextWithReasoning(), "<reasoning>\nBTC 趋势向上\n</reasoning>") {
+		t.Errorf("unexpected text: %q", resp.TextWithReasoning())
+	}
+
+	thinking := captured["thinking"].(map[string]any)
+	if thinking["type"] != "enabled" || thinking["budget_tokens"] != float64(2000) {
+		t.Errorf("unexpected thinking config: %v", thinking)
+	}
+	if captured["max_tokens"] != float64(3000) {
+		t.Errorf("max_tokens should include thinking budget, got %v", captured["max_tokens"])
+	}
+	if _, ok := captured["temperature"]; ok {
+		t.Error("temperature should not be sent with extended thinking")
+	}
+	if choice := captured["tool_choice"].(map[string]any); choice["type"] != "auto" {
+		t.Errorf("forced tool choice should fall back to auto with thinking: %v", choice)
+	}
+
+	text, err := client.CallWithMessages("", "分析 BTC")
+	if err != nil {
+		t.Fatalf("should not error: %v", err)
+	}
+	if !strings.Contains(text, "<reasoning>\nBTC 趋势向上\n</reasoning>") || !strings.HasSuffix(text, "<decision>[]</decision>") {
+		t.Errorf("CallWithMessages should prepend thinking in <reasoning>: %q", text)
+	}
+}
+
+func TestAnthropicClient_ErrorResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"type":"error","error":{"type":"invalid_request_error","message":"max_tokens: field required"}}`))
+	}))
+	defer server.Close()
+
+	_, err := newAnthropicTestClient(server.URL).CallWithMessages("", "hi")
+	if err == nil || !strings.Contains(err.Error(), "status 400") {
+		t.Errorf("expected status 400 error, got %v", err)
+	}
+}
@@ -30,6 +30,7 @@ var (
 		"no such host",
 		"stream error",   // HTTP/2 stream 错误
 		"INTERNAL_ERROR", // 服务端内部错误
+		"status 429",     // 提供商限流（重试前按 Retry-After 排队）
 	}
 )
 
@@ -130,6 +131,17 @@ func (client *Client) SetAPIKey(apiKey, apiURL, customModel string) {
 	client.Model = customModel
 }
 
+// Clone 返回独立的客户端副本：配置单独拷贝，hooks 指向副本自身（修改副本不影响原客户端）
+func (client *Client) Clone() *Client {
+	cp := *client
+	if client.config != nil {
+		cfg := *client.config
+		cp.config = &cfg
+	}
+	cp.hooks = &cp
+	return &cp
+}
+
 func (client *Client) SetTimeout(timeout time.Duration) {
 	client.httpClient.Timeout = timeout
 }
@@ -235,6 +247,30 @@ func (client *Client) parseMCPResponse(body []byte) (string, error) {
 	return result.Choices[0].Message.Content, nil
 }
 
+// parseMCPFullResponse 解析响应中的文本内容和工具调用
+func (client *Client) parseMCPFullResponse(body []byte) (*Response, error) {
+	var result struct {
+		Choices []struct {
+			Message struct {
+				Content   string     `json:"content"`
+				ToolCalls []ToolCall `json:"tool_calls"`
+			} `json:"message"`
+		} `json:"choices"`
+		Usage *openAIUsage `json:"usage"`
+	}
+
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+
+	if len(result.Choices) == 0 {
+		return nil, fmt.Errorf("API返回空响应")
+	}
+
+	message := result.Choices[0].Message
+	return &Response{Content: message.Content, ToolCalls: message.ToolCalls, Usage: result.Usage.toUsage()}, nil
+}
+
 func (client *Client) buildUrl() string {
 	if client.UseFullURL {
 		return client.BaseURL
@@ -285,12 +321,17 @@ func (client *Client) call(systemPrompt, userPrompt string) (string, error) {
 		return "", fmt.Errorf("创建请求失败: %w", err)
 	}
 
-	// Step 5: 发送 HTTP 请求（固定逻辑）
+	// Step 5: 按 提供商+API Key 限流排队后发送 HTTP 请求（固定逻辑）
+	ticket := client.acquireRateLimit(estimateRequestTokens(client.Model, client.MaxTokens, systemPrompt, userPrompt))
+	usedTokens := 0
+	defer func() { ticket.done(usedTokens) }()
+
 	resp, err := client.httpClient.Do(req)
 	if err != nil {
 		return "", fmt.Errorf("发送请求失败: %w", err)
 	}
 	defer resp.Body.Close()
+	client.handleRateLimited(resp)
 
 	// Step 6: 读取响应体（固定逻辑）
 	body, err := io.ReadAll(resp.Body)
@@ -309,6 +350,12 @@ func (client *Client) call(systemPrompt, userPrompt string) (string, error) {
 		return "", fmt.Errorf("fail to parse AI server response: %w", err)
 	}
 
+	// Step 9: 统计 token 用量（文本已解析成功，用量解析失败不影响结果）
+	if full, err := client.hooks.parseMCPFullResponse(body); err == nil {
+		client.recordUsage(client.Model, full)
+		usedTokens = full.Usage.TotalTokens()
+	}
+
 	return result, nil
 }
 
@@ -339,7 +386,7 @@ func (client *Client) isRetryableError(err error) bool {
 // - 多轮对话历史
 // - 精细参数控制（temperature、top_p、penalties 等）
 // - Function Calling / Tools
-// - 流式响应（未来支持）
+// - 流式响应（WithStreamHandler 接收增量输出）
 //
 // 使用示例：
 //
@@ -350,8 +397,24 @@ func (client *Client) isRetryableError(err error) bool {
 //	    Build()
 //	result, err := client.CallWithRequest(request)
 func (client *Client) CallWithRequest(req *Request) (string, error) {
+	resp, err := client.CallWithRequestFull(req)
+	if err != nil {
+		return "", err
+	}
+	return resp.Content, nil
+}
+
+// CallWithRequestFull 使用 Request 对象调用 AI API，返回包含工具调用的完整响应
+//
+// 与 CallWithRequest 使用相同的重试流程，适用于 Function Calling 场景：
+//
+//	resp, err := client.CallWithRequestFull(request)
+//	if call, ok := resp.ToolCallByName("submit_decisions"); ok {
+//	    json.Unmarshal([]byte(call.Function.Arguments), &args)
+//	}
+func (client *Client) CallWithRequestFull(req *Request) (*Response, error) {
 	if client.APIKey == "" {
-		return "", fmt.Errorf("AI API密钥未设置，请先调用 SetAPIKey")
+		return nil, fmt.Errorf("AI API密钥未设置，请先调用 SetAPIKey")
 	}
 
 	// 如果 Request 中没有设置 Model，使用 Client 的 Model
@@ -380,7 +443,7 @@ func (client *Client) CallWithRequest(req *Request) (string, error) {
 		lastErr = err
 		// 判断是否可重试
 		if !client.hooks.isRetryableError(err) {
-			return "", err
+			return nil, err
 		}
 
 		// 重试前等待
@@ -391,22 +454,24 @@ func (client *Client) CallWithRequest(req *Request) (string, error) {
 		}
 	}
 
-	return "", fmt.Errorf("重试%d次后仍然失败: %w", maxRetries, lastErr)
+	return nil, fmt.Errorf("重试%d次后仍然失败: %w", maxRetries, lastErr)
 }
 
 // callWithRequest 单次调用 AI API（使用 Request 对象）
-func (client *Client) callWithRequest(req *Request) (string, error) {
+func (client *Client) callWithRequest(req *Request) (*Response, error) {
 	// 打印当前 AI 配置
 	client.logger.Infof("📡 [%s] Request AI Server with Builder: BaseURL: %s", client.String(), client.BaseURL)
 	client.logger.Debugf("[%s] Messages count: %d", client.String(), len(req.Messages))
 
 	// 构建请求体（从 Request 对象）
-	requestBody := client.buildRequestBodyFromRequest(req)
+	requestBody := client.hooks.buildRequestBodyFromRequest(req)
+	// 只有请求体声明了 stream 才按 SSE 解析（不支持流式的提供商不会设置该字段）
+	stream, _ := requestBody["stream"].(bool)
 
 	// 序列化请求体
 	jsonData, err := client.hooks.marshalRequestBody(requestBody)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	// 构建 URL
@@ -416,31 +481,55 @@ func (client *Client) callWithRequest(req *Request) (string, error) {
 	// 创建 HTTP 请求
 	httpReq, err := client.hooks.buildRequest(url, jsonData)
 	if err != nil {
-		return "", fmt.Errorf("创建请求失败: %w", err)
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+
+	// 按 提供商+API Key 限流排队
+	ticket := client.acquireRateLimit(estimateRequestTokens(req.Model, client.MaxTokens, requestTexts(req)...))
+	usedTokens := 0
+	defer func() { ticket.done(usedTokens) }()
+
+	if stream {
+		result, err := client.callStream(httpReq, req.OnChunk)
+		if err != nil {
+			return nil, err
+		}
+		client.recordUsage(req.Model, result)
+		usedTokens = result.Usage.TotalTokens()
+		return result, nil
 	}
 
 	// 发送 HTTP 请求
 	resp, err := client.httpClient.Do(httpReq)
 	if err != nil {
-		return "", fmt.Errorf("发送请求失败: %w", err)
+		return nil, fmt.Errorf("发送请求失败: %w", err)
 	}
 	defer resp.Body.Close()
+	client.handleRateLimited(resp)
 
 	// 读取响应体
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("读取响应失败: %w", err)
+		return nil, fmt.Errorf("读取响应失败: %w", err)
 	}
 
 	// 检查 HTTP 状态码
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("API返回错误 (status %d): %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("API返回错误 (status %d): %s", resp.StatusCode, string(body))
 	}
 
-	// 解析响应
-	result, err := client.hooks.parseMCPResponse(body)
+	// 解析响应（保留工具调用）
+	result, err := client.hooks.parseMCPFullResponse(body)
 	if err != nil {
-		return "", fmt.Errorf("fail to parse AI server response: %w", err)
+		return nil, fmt.Errorf("fail to parse AI server response: %w", err)
+	}
+
+	client.recordUsage(req.Model, result)
+	usedTokens = result.Usage.TotalTokens()
+
+	// 请求了流式输出但提供商不支持时，一次性回调完整内容
+	if req.OnChunk != nil {
+		req.OnChunk(StreamChunk{Content: result.Content, Reasoning: result.Reasoning})
 	}
 
 	return result, nil
@@ -449,12 +538,19 @@ func (client *Client) callWithRequest(req *Request) (string, error) {
 // buildRequestBodyFromRequest 从 Request 对象构建请求体
 func (client *Client) buildRequestBodyFromRequest(req *Request) map[string]any {
 	// 转换 Message 为 API 格式
-	messages := make([]map[string]string, 0, len(req.Messages))
+	messages := make([]map[string]any, 0, len(req.Messages))
 	for _, msg := range req.Messages {
-		messages = append(messages, map[string]string{
+		message := map[string]any{
 			"role":    msg.Role,
 			"content": msg.Content,
-		})
+		}
+		if len(msg.ToolCalls) > 0 {
+			message["tool_calls"] = msg.ToolCalls
+		}
+		if msg.ToolCallID != "" {
+			message["tool_call_id"] = msg.ToolCallID
+		}
+		messages = append(messages, message)
 	}
 
 	// 构建基础请求体
@@ -499,11 +595,22 @@ func (client *Client) buildRequestBodyFromRequest(req *Request) map[string]any {
 	}
 
 	if req.ToolChoice != "" {
-		requestBody["tool_choice"] = req.ToolChoice
+		// 指定函数时 tool_choice 是 JSON 对象，需要原样传递
+		if strings.HasPrefix(strings.TrimSpace(req.ToolChoice), "{") {
+			requestBody["tool_choice"] = json.RawMessage(req.ToolChoice)
+		} else {
+			requestBody["tool_choice"] = req.ToolChoice
+		}
+	}
+
+	if req.ResponseFormat != "" {
+		requestBody["response_format"] = map[string]string{"type": req.ResponseFormat}
 	}
 
 	if req.Stream {
 		requestBody["stream"] = true
+		// 流式响应默认不返回 usage，需要显式要求在最后一个数据块中附带
+		requestBody["stream_options"] = map[string]bool{"include_usage": true}
 	}
 
 	return requestBody
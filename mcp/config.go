@@ -28,6 +28,18 @@ type Config struct {
 	// 超时配置
 	Timeout time.Duration
 
+	// PromptTokenBudget 输入 prompt 的 token 预算，0 表示按模型上下文窗口减去 MaxTokens 计算
+	PromptTokenBudget int
+
+	// ThinkingBudget 扩展思考的 token 预算（Anthropic / Gemini），0 表示不开启
+	ThinkingBudget int
+
+	// StreamIdleTimeout 流式响应的空闲超时（流式请求不使用总超时）
+	StreamIdleTimeout time.Duration
+
+	// OnUsage 每次调用成功后回调 token 用量（用于费用统计）
+	OnUsage UsageHandler
+
 	// 依赖注入
 	Logger     Logger
 	HTTPClient *http.Client
@@ -44,6 +56,15 @@ func DefaultConfig() *Config {
 		Timeout:        DefaultTimeout,
 		RetryableErrors: retryableErrors,
 
+		// prompt 预算（0 表示按模型上下文窗口计算）
+		PromptTokenBudget: getEnvInt("AI_PROMPT_TOKEN_BUDGET", 0),
+
+		// 扩展思考（0 表示不开启）
+		ThinkingBudget: getEnvInt("AI_THINKING_BUDGET", 0),
+
+		// 流式空闲超时（秒）
+		StreamIdleTimeout: time.Duration(getEnvInt("AI_STREAM_IDLE_TIMEOUT", int(DefaultStreamIdleTimeout/time.Second))) * time.Second,
+
 		// 默认依赖
 		Logger:     &defaultLogger{},
 		HTTPClient: &http.Client{Timeout: DefaultTimeout},
@@ -0,0 +1,260 @@
+package mcp
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrAllProvidersUnavailable 故障转移链中所有模型都调用失败或处于熔断冷却中
+var ErrAllProvidersUnavailable = errors.New("所有AI模型均不可用")
+
+const (
+	// DefaultFailureThreshold 连续失败多少次后熔断
+	DefaultFailureThreshold = 3
+	// DefaultBreakerCooldown 熔断后跳过该模型的冷却时长
+	DefaultBreakerCooldown = 10 * time.Minute
+)
+
+// FailoverMember 故障转移链中的一个模型
+type FailoverMember struct {
+	Name   string
+	Client AIClient
+}
+
+// FailoverStatus 故障转移链中单个模型的熔断状态（用于API）
+type FailoverStatus struct {
+	Name                string    `json:"name"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	OpenUntil           time.Time `json:"open_until,omitempty"` // 熔断冷却截止时间
+	Available           bool      `json:"available"`            // 当前是否会被调用
+	LastError           string    `json:"last_error,omitempty"`
+}
+
+// breakerState 单个模型的熔断器状态
+type breakerState struct {
+	failures  int
+	openUntil time.Time
+	lastError string
+}
+
+// FailoverClient 按顺序调用多个模型的客户端：调用失败时转到下一个模型，
+// 连续失败达到阈值的模型熔断一段时间，冷却期内直接跳过。冷却结束后只给一次机会，再失败立即重新熔断
+type FailoverClient struct {
+	FailureThreshold int           // 连续失败多少次后熔断
+	Cooldown         time.Duration // 熔断冷却时长
+
+	members []FailoverMember
+	logger  Logger
+	now     func() time.Time
+
+	mu       sync.Mutex
+	states   []breakerState
+	answered []string // 上次 TakeAnswered 之后给出回答的模型
+}
+
+// NewFailoverClient 创建故障转移客户端，第一个成员为主模型
+func NewFailoverClient(members ...FailoverMember) *FailoverClient {
+	return &FailoverClient{
+		FailureThreshold: DefaultFailureThreshold,
+		Cooldown:         DefaultBreakerCooldown,
+		members:          members,
+		logger:           &defaultLogger{},
+		now:              time.Now,
+		states:           make([]breakerState, len(members)),
+	}
+}
+
+// SetLogger 设置日志器
+func (fc *FailoverClient) SetLogger(logger Logger) {
+	fc.logger = logger
+}
+
+// Members 返回故障转移链中的模型
+func (fc *FailoverClient) Members() []FailoverMember {
+	return fc.members
+}
+
+// SetAPIKey 设置主模型的 API Key
+func (fc *FailoverClient) SetAPIKey(apiKey string, customURL string, customModel string) {
+	if len(fc.members) > 0 {
+		fc.members[0].Client.SetAPIKey(apiKey, customURL, customModel)
+	}
+}
+
+// SetTimeout 设置所有模型的超时
+func (fc *FailoverClient) SetTimeout(timeout time.Duration) {
+	for _, m := range fc.members {
+		m.Client.SetTimeout(timeout)
+	}
+}
+
+// SetUsageHandler 设置所有模型的用量回调
+func (fc *FailoverClient) SetUsageHandler(handler UsageHandler) {
+	for _, m := range fc.members {
+		if reporter, ok := m.Client.(UsageReporter); ok {
+			reporter.SetUsageHandler(handler)
+		}
+	}
+}
+
+func (fc *FailoverClient) CallWithMessages(systemPrompt, userPrompt string) (string, error) {
+	var result string
+	err := fc.do(func(client AIClient) error {
+		var err error
+		result, err = client.CallWithMessages(systemPrompt, userPrompt)
+		return err
+	})
+	return result, err
+}
+
+func (fc *FailoverClient) CallWithRequest(req *Request) (string, error) {
+	resp, err := fc.CallWithRequestFull(req)
+	if err != nil {
+		return "", err
+	}
+	return resp.Content, nil
+}
+
+// CallWithRequestFull 依次尝试各模型。每个模型使用请求的副本，避免前一个模型填入的 Model 被带到下一个模型
+func (fc *FailoverClient) CallWithRequestFull(req *Request) (*Response, error) {
+	var result *Response
+	err := fc.do(func(client AIClient) error {
+		attempt := *req
+		var err error
+		result, err = client.CallWithRequestFull(&attempt)
+		return err
+	})
+	return result, err
+}
+
+// EstimateTokens 使用主模型的 token 估算
+func (fc *FailoverClient) EstimateTokens(text string) int {
+	if len(fc.members) > 0 {
+		if budgeter, ok := fc.members[0].Client.(TokenBudgeter); ok {
+			return budgeter.EstimateTokens(text)
+		}
+	}
+	return EstimateTokens("", text)
+}
+
+// PromptTokenBudget 取所有模型中最小的 prompt 预算，保证切换模型后 prompt 仍然放得下
+func (fc *FailoverClient) PromptTokenBudget() int {
+	budget := 0
+	for _, m := range fc.members {
+		budgeter, ok := m.Client.(TokenBudgeter)
+		if !ok {
+			continue
+		}
+		if b := budgeter.PromptTokenBudget(); b > 0 && (budget == 0 || b < budget) {
+			budget = b
+		}
+	}
+	if budget == 0 {
+		return DefaultContextWindow
+	}
+	return budget
+}
+
+// TakeAnswered 返回上次调用之后实际给出回答的模型（按首次回答的顺序去重）并清空
+func (fc *FailoverClient) TakeAnswered() []string {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	answered := fc.answered
+	fc.answered = nil
+	return answered
+}
+
+// Status 返回各模型的熔断状态
+func (fc *FailoverClient) Status() []FailoverStatus {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	now := fc.now()
+	statuses := make([]FailoverStatus, len(fc.members))
+	for i, m := range fc.members {
+		state := fc.states[i]
+		statuses[i] = FailoverStatus{
+			Name:                m.Name,
+			ConsecutiveFailures: state.failures,
+			OpenUntil:           state.openUntil,
+			Available:           !now.Before(state.openUntil),
+			LastError:           state.lastError,
+		}
+	}
+	return statuses
+}
+
+// do 按顺序调用未熔断的模型，直到有一个成功
+func (fc *FailoverClient) do(call func(client AIClient) error) error {
+	var failures []string
+	for i, m := range fc.members {
+		if remaining := fc.openRemaining(i); remaining > 0 {
+			failures = append(failures, fmt.Sprintf("%s: 熔断中（剩余 %s）", m.Name, remaining.Round(time.Second)))
+			continue
+		}
+
+		err := call(m.Client)
+		if err == nil {
+			fc.recordSuccess(i)
+			if i > 0 {
+				fc.logger.Warnf("🔀 [故障转移] 由备用模型 %s 给出回答", m.Name)
+			}
+			return nil
+		}
+
+		failures = append(failures, fmt.Sprintf("%s: %v", m.Name, err))
+		fc.recordFailure(i, err)
+		if i+1 < len(fc.members) {
+			fc.logger.Warnf("🔀 [故障转移] %s 调用失败，转到下一个模型: %v", m.Name, err)
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrAllProvidersUnavailable, strings.Join(failures, "; "))
+}
+
+// openRemaining 返回模型剩余的熔断冷却时间（0 表示可以调用）
+func (fc *FailoverClient) openRemaining(i int) time.Duration {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	if remaining := fc.states[i].openUntil.Sub(fc.now()); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+func (fc *FailoverClient) recordSuccess(i int) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	if fc.states[i].failures >= fc.threshold() {
+		fc.logger.Infof("✓ [故障转移] %s 已恢复", fc.members[i].Name)
+	}
+	fc.states[i] = breakerState{}
+
+	name := fc.members[i].Name
+	for _, answered := range fc.answered {
+		if answered == name {
+			return
+		}
+	}
+	fc.answered = append(fc.answered, name)
+}
+
+func (fc *FailoverClient) recordFailure(i int, err error) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	state := &fc.states[i]
+	state.failures++
+	state.lastError = err.Error()
+	if state.failures >= fc.threshold() {
+		state.openUntil = fc.now().Add(fc.Cooldown)
+		fc.logger.Warnf("⛔ [故障转移] %s 连续失败 %d 次，熔断 %v", fc.members[i].Name, state.failures, fc.Cooldown)
+	}
+}
+
+func (fc *FailoverClient) threshold() int {
+	if fc.FailureThreshold > 0 {
+		return fc.FailureThreshold
+	}
+	return DefaultFailureThreshold
+}
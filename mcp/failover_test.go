@@ -0,0 +1,145 @@
+package mcp
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stubAIClient 按预设结果应答的 AIClient
+type stubAIClient struct {
+	model string
+	err   error
+	calls int
+	usage UsageHandler
+	seen  []string // 收到的请求 Model
+}
+
+func (s *stubAIClient) SetAPIKey(apiKey string, customURL string, customModel string) {}
+func (s *stubAIClient) SetTimeout(timeout time.Duration)                              {}
+func (s *stubAIClient) SetUsageHandler(handler UsageHandler)                          { s.usage = handler }
+
+func (s *stubAIClient) CallWithMessages(systemPrompt, userPrompt string) (string, error) {
+	resp, err := s.CallWithRequestFull(&Request{})
+	if err != nil {
+		return "", err
+	}
+	return resp.Content, nil
+}
+
+func (s *stubAIClient) CallWithRequest(req *Request) (string, error) {
+	resp, err := s.CallWithRequestFull(req)
+	if err != nil {
+		return "", err
+	}
+	return resp.Content, nil
+}
+
+func (s *stubAIClient) CallWithRequestFull(req *Request) (*Response, error) {
+	s.calls++
+	s.seen = append(s.seen, req.Model)
+	if req.Model == "" {
+		req.Model = s.model
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &Response{Content: "answer from " + s.model}, nil
+}
+
+func TestFailoverClient_FallsBackAndTripsBreaker(t *testing.T) {
+	primary := &stubAIClient{model: "deepseek-chat", err: errors.New("503 service unavailable")}
+	backup := &stubAIClient{model: "qwen3-max"}
+	fc := NewFailoverClient(FailoverMember{Name: "deepseek", Client: primary}, FailoverMember{Name: "qwen", Client: backup})
+	fc.SetLogger(NewMockLogger())
+	fc.FailureThreshold = 2
+	fc.Cooldown = time.Minute
+	now := time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)
+	fc.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		resp, err := fc.CallWithRequestFull(NewRequestBuilder().WithUserPrompt("分析").MustBuild())
+		if err != nil {
+			t.Fatalf("backup should answer: %v", err)
+		}
+		if resp.Content != "answer from qwen3-max" {
+			t.Errorf("unexpected content: %q", resp.Content)
+		}
+	}
+	if backup.seen[0] != "" {
+		t.Errorf("backup should not receive the primary model name: %v", backup.seen)
+	}
+	if answered := fc.TakeAnswered(); len(answered) != 1 || answered[0] != "qwen" {
+		t.Errorf("answered should list the backup once: %v", answered)
+	}
+
+	// 熔断后冷却期内不再调用主模型
+	if _, err := fc.CallWithMessages("system", "user"); err != nil {
+		t.Fatalf("should not error: %v", err)
+	}
+	if primary.calls != 2 {
+		t.Errorf("tripped primary should be skipped, got %d calls", primary.calls)
+	}
+	if status := fc.Status(); status[0].Available || status[0].ConsecutiveFailures != 2 || !status[1].Available {
+		t.Errorf("unexpected status: %+v", status)
+	}
+
+	// 冷却结束且恢复后重新由主模型回答
+	now = now.Add(2 * time.Minute)
+	primary.err = nil
+	if _, err := fc.CallWithMessages("system", "user"); err != nil {
+		t.Fatalf("should not error: %v", err)
+	}
+	if answered := fc.TakeAnswered(); len(answered) != 2 || answered[1] != "deepseek" {
+		t.Errorf("primary should answer after cool-down: %v", answered)
+	}
+	if fc.Status()[0].ConsecutiveFailures != 0 {
+		t.Error("success should reset the breaker")
+	}
+}
+
+func TestFailoverClient_AllUnavailable(t *testing.T) {
+	primary := &stubAIClient{model: "deepseek-chat", err: errors.New("timeout")}
+	backup := &stubAIClient{model: "qwen3-max", err: errors.New("401 unauthorized")}
+	fc := NewFailoverClient(FailoverMember{Name: "deepseek", Client: primary}, FailoverMember{Name: "qwen", Client: backup})
+	fc.SetLogger(NewMockLogger())
+	fc.FailureThreshold = 1
+
+	_, err := fc.CallWithMessages("system", "user")
+	if !errors.Is(err, ErrAllProvidersUnavailable) {
+		t.Fatalf("expected ErrAllProvidersUnavailable, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "deepseek: timeout") || !strings.Contains(err.Error(), "qwen: 401 unauthorized") {
+		t.Errorf("error should include every member's failure: %v", err)
+	}
+
+	// 全部熔断时不再发起请求
+	_, err = fc.CallWithMessages("system", "user")
+	if !errors.Is(err, ErrAllProvidersUnavailable) || !strings.Contains(err.Error(), "熔断中") {
+		t.Errorf("expected breaker error, got %v", err)
+	}
+	if primary.calls != 1 || backup.calls != 1 {
+		t.Errorf("open breakers should not be called: %d %d", primary.calls, backup.calls)
+	}
+}
+
+func TestFailoverClient_ForwardsUsageHandlerAndBudget(t *testing.T) {
+	primary := NewClient(WithLogger(NewMockLogger()), WithPromptTokenBudget(50000))
+	backup := NewClient(WithLogger(NewMockLogger()), WithPromptTokenBudget(20000))
+	stub := &stubAIClient{}
+	fc := NewFailoverClient(
+		FailoverMember{Name: "a", Client: primary},
+		FailoverMember{Name: "b", Client: backup},
+		FailoverMember{Name: "c", Client: stub},
+	)
+
+	var meter UsageMeter
+	fc.SetUsageHandler(meter.Record)
+	if stub.usage == nil {
+		t.Error("usage handler should be forwarded to every member")
+	}
+	if fc.PromptTokenBudget() != 20000 {
+		t.Errorf("budget should be the smallest member budget, got %d", fc.PromptTokenBudget())
+	}
+}
@@ -0,0 +1,368 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	ProviderGemini       = "gemini"
+	DefaultGeminiBaseURL = "https://generativelanguage.googleapis.com/v1beta"
+	DefaultGeminiModel   = "gemini-2.5-flash"
+)
+
+// geminiRetryableErrors Gemini 的可重试错误状态（限流、服务不可用、超时、内部错误）
+var geminiRetryableErrors = []string{
+	"RESOURCE_EXHAUSTED",
+	"UNAVAILABLE",
+	"DEADLINE_EXCEEDED",
+	"\"INTERNAL\"",
+	"status 429",
+	"status 500",
+	"status 503",
+	"status 504",
+}
+
+// GeminiClient Google Gemini generateContent API 客户端
+//
+// 复用 Client 的重试和日志流程，通过 hooks 重写请求体构建、URL、认证头和响应解析：
+// - system prompt 放在 systemInstruction
+// - 工具调用使用 functionCall / functionResponse，assistant 角色对应 model
+// - Request.ResponseFormat 为 JSON 时设置 responseMimeType
+type GeminiClient struct {
+	*Client
+}
+
+// NewGeminiClient 创建 Gemini 客户端
+func NewGeminiClient() AIClient {
+	return NewGeminiClientWithOptions()
+}
+
+// NewGeminiClientWithOptions 创建 Gemini 客户端（支持选项模式）
+//
+// 使用示例：
+//
+//	client := mcp.NewGeminiClientWithOptions(
+//	    mcp.WithAPIKey("AIza-xxx"),
+//	    mcp.WithModel("gemini-2.5-pro"),
+//	)
+func NewGeminiClientWithOptions(opts ...ClientOption) AIClient {
+	geminiOpts := []ClientOption{
+		WithProvider(ProviderGemini),
+		WithModel(DefaultGeminiModel),
+		WithBaseURL(DefaultGeminiBaseURL),
+	}
+
+	// 用户选项优先级更高
+	allOpts := append(geminiOpts, opts...)
+
+	baseClient := NewClient(allOpts...).(*Client)
+	geminiClient := &GeminiClient{
+		Client: baseClient,
+	}
+
+	// hooks 指向 GeminiClient，使 Client 的调用流程分派到 generateContent 的实现
+	baseClient.hooks = geminiClient
+
+	return geminiClient
+}
+
+func (gc *GeminiClient) SetAPIKey(apiKey string, customURL string, customModel string) {
+	gc.APIKey = apiKey
+
+	if len(apiKey) > 8 {
+		gc.logger.Infof("🔧 [MCP] Gemini API Key: %s...%s", apiKey[:4], apiKey[len(apiKey)-4:])
+	}
+	if customURL != "" {
+		// 与自定义 API 一致：以 # 结尾表示使用完整 URL
+		if strings.HasSuffix(customURL, "#") {
+			gc.BaseURL = strings.TrimSuffix(customURL, "#")
+			gc.UseFullURL = true
+		} else {
+			gc.BaseURL = customURL
+		}
+		gc.logger.Infof("🔧 [MCP] Gemini 使用自定义 BaseURL: %s", customURL)
+	} else {
+		gc.logger.Infof("🔧 [MCP] Gemini 使用默认 BaseURL: %s", gc.BaseURL)
+	}
+	if customModel != "" {
+		gc.Model = customModel
+		gc.logger.Infof("🔧 [MCP] Gemini 使用自定义 Model: %s", customModel)
+	} else {
+		gc.logger.Infof("🔧 [MCP] Gemini 使用默认 Model: %s", gc.Model)
+	}
+}
+
+func (gc *GeminiClient) setAuthHeader(reqHeaders http.Header) {
+	reqHeaders.Set("x-goog-api-key", gc.APIKey)
+}
+
+// buildUrl 模型名是 URL 的一部分：{BaseURL}/models/{model}:generateContent
+func (gc *GeminiClient) buildUrl() string {
+	if gc.UseFullURL {
+		return gc.BaseURL
+	}
+	model := strings.TrimPrefix(gc.Model, "models/")
+	return fmt.Sprintf("%s/models/%s:generateContent", strings.TrimSuffix(gc.BaseURL, "/"), model)
+}
+
+// isRetryableError 在通用网络错误之外，限流、服务不可用等 Gemini 错误码也重试
+func (gc *GeminiClient) isRetryableError(err error) bool {
+	errStr := err.Error()
+	for _, retryable := range geminiRetryableErrors {
+		if strings.Contains(errStr, retryable) {
+			return true
+		}
+	}
+	return gc.Client.isRetryableError(err)
+}
+
+func (gc *GeminiClient) buildMCPRequestBody(systemPrompt, userPrompt string) map[string]any {
+	messages := []Message{}
+	if systemPrompt != "" {
+		messages = append(messages, NewSystemMessage(systemPrompt))
+	}
+	messages = append(messages, NewUserMessage(userPrompt))
+	return gc.buildRequestBodyFromRequest(&Request{Model: gc.Model, Messages: messages})
+}
+
+// geminiContent generateContent 的一条消息
+type geminiContent struct {
+	Role  string           `json:"role"`
+	Parts []map[string]any `json:"parts"`
+}
+
+// buildRequestBodyFromRequest 把 Request 转换为 generateContent 请求体：
+// system 消息合并到 systemInstruction，assistant 的工具调用转换为 functionCall，
+// tool 消息按 ToolCallID 找到函数名后转换为 functionResponse（Gemini 按函数名和顺序对应调用结果），
+// 连续相同角色的消息合并为一条
+func (gc *GeminiClient) buildRequestBodyFromRequest(req *Request) map[string]any {
+	var systemParts []string
+	var contents []geminiContent
+	toolNames := make(map[string]string)
+
+	appendParts := func(role string, parts ...map[string]any) {
+		if len(parts) == 0 {
+			return
+		}
+		if n := len(contents); n > 0 && contents[n-1].Role == role {
+			contents[n-1].Parts = append(contents[n-1].Parts, parts...)
+			return
+		}
+		contents = append(contents, geminiContent{Role: role, Parts: parts})
+	}
+
+	for _, msg := range req.Messages {
+		switch msg.Role {
+		case "system":
+			if msg.Content != "" {
+				systemParts = append(systemParts, msg.Content)
+			}
+		case "assistant":
+			parts := []map[string]any{}
+			if msg.Content != "" {
+				parts = append(parts, map[string]any{"text": msg.Content})
+			}
+			for _, call := range msg.ToolCalls {
+				toolNames[call.ID] = call.Function.Name
+				parts = append(parts, map[string]any{
+					"functionCall": map[string]any{
+						"name": call.Function.Name,
+						"args": toolUseInput(call.Function.Arguments),
+					},
+				})
+			}
+			appendParts("model", parts...)
+		case "tool":
+			appendParts("user", map[string]any{
+				"functionResponse": map[string]any{
+					"name":     toolNames[msg.ToolCallID],
+					"response": map[string]any{"result": msg.Content},
+				},
+			})
+		default:
+			if msg.Content != "" {
+				appendParts("user", map[string]any{"text": msg.Content})
+			}
+		}
+	}
+
+	generationConfig := map[string]any{}
+	if req.Temperature != nil {
+		generationConfig["temperature"] = *req.Temperature
+	} else {
+		generationConfig["temperature"] = gc.config.Temperature
+	}
+	if req.MaxTokens != nil {
+		generationConfig["maxOutputTokens"] = *req.MaxTokens
+	} else {
+		generationConfig["maxOutputTokens"] = gc.MaxTokens
+	}
+	if req.TopP != nil {
+		generationConfig["topP"] = *req.TopP
+	}
+	if req.FrequencyPenalty != nil {
+		generationConfig["frequencyPenalty"] = *req.FrequencyPenalty
+	}
+	if req.PresencePenalty != nil {
+		generationConfig["presencePenalty"] = *req.PresencePenalty
+	}
+	if len(req.Stop) > 0 {
+		generationConfig["stopSequences"] = req.Stop
+	}
+	// JSON 输出模式不能与函数调用同时使用，声明了工具时忽略
+	if req.ResponseFormat == ResponseFormatJSON && len(req.Tools) == 0 {
+		generationConfig["responseMimeType"] = "application/json"
+	}
+	if gc.config != nil && gc.config.ThinkingBudget > 0 {
+		generationConfig["thinkingConfig"] = map[string]any{
+			"thinkingBudget":  gc.config.ThinkingBudget,
+			"includeThoughts": true,
+		}
+	}
+
+	requestBody := map[string]any{
+		"contents":         contents,
+		"generationConfig": generationConfig,
+	}
+	if len(systemParts) > 0 {
+		requestBody["systemInstruction"] = map[string]any{
+			"parts": []map[string]any{{"text": strings.Join(systemParts, "\n\n")}},
+		}
+	}
+
+	if len(req.Tools) > 0 {
+		declarations := make([]map[string]any, 0, len(req.Tools))
+		for _, tool := range req.Tools {
+			declaration := map[string]any{"name": tool.Function.Name}
+			if tool.Function.Description != "" {
+				declaration["description"] = tool.Function.Description
+			}
+			if tool.Function.Parameters != nil {
+				declaration["parameters"] = tool.Function.Parameters
+			}
+			declarations = append(declarations, declaration)
+		}
+		requestBody["tools"] = []map[string]any{{"functionDeclarations": declarations}}
+
+		if config := geminiFunctionCallingConfig(req.ToolChoice); config != nil {
+			requestBody["toolConfig"] = map[string]any{"functionCallingConfig": config}
+		}
+	}
+
+	return requestBody
+}
+
+// geminiFunctionCallingConfig 把 OpenAI 格式的 tool_choice 转换为 functionCallingConfig
+func geminiFunctionCallingConfig(toolChoice string) map[string]any {
+	toolChoice = strings.TrimSpace(toolChoice)
+	switch toolChoice {
+	case "":
+		return nil
+	case "auto":
+		return map[string]any{"mode": "AUTO"}
+	case "none":
+		return map[string]any{"mode": "NONE"}
+	case "required":
+		return map[string]any{"mode": "ANY"}
+	}
+
+	var choice struct {
+		Function struct {
+			Name string `json:"name"`
+		} `json:"function"`
+	}
+	if err := json.Unmarshal([]byte(toolChoice), &choice); err != nil || choice.Function.Name == "" {
+		return map[string]any{"mode": "AUTO"}
+	}
+	return map[string]any{"mode": "ANY", "allowedFunctionNames": []string{choice.Function.Name}}
+}
+
+// parseMCPResponse 返回文本内容，有思考摘要时以 <reasoning> 标签放在开头
+func (gc *GeminiClient) parseMCPResponse(body []byte) (string, error) {
+	resp, err := gc.parseMCPFullResponse(body)
+	if err != nil {
+		return "", err
+	}
+	return resp.TextWithReasoning(), nil
+}
+
+// parseMCPFullResponse 解析第一个候选结果：text 拼接为 Content，thought 为 Reasoning，
+// functionCall 转换为 OpenAI 格式的 ToolCall（Gemini 未返回 id 时按序号生成）
+func (gc *GeminiClient) parseMCPFullResponse(body []byte) (*Response, error) {
+	var result struct {
+		Candidates []struct {
+			Content struct {
+				Parts []struct {
+					Text         string `json:"text"`
+					Thought      bool   `json:"thought"`
+					FunctionCall *struct {
+						ID   string          `json:"id"`
+						Name string          `json:"name"`
+						Args json.RawMessage `json:"args"`
+					} `json:"functionCall"`
+				} `json:"parts"`
+			} `json:"content"`
+			FinishReason string `json:"finishReason"`
+		} `json:"candidates"`
+		PromptFeedback struct {
+			BlockReason string `json:"blockReason"`
+		} `json:"promptFeedback"`
+		UsageMetadata struct {
+			PromptTokenCount     int `json:"promptTokenCount"`
+			CandidatesTokenCount int `json:"candidatesTokenCount"`
+			ThoughtsTokenCount   int `json:"thoughtsTokenCount"` // 思考 token 不计入 candidatesTokenCount
+		} `json:"usageMetadata"`
+	}
+
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+
+	if len(result.Candidates) == 0 {
+		if result.PromptFeedback.BlockReason != "" {
+			return nil, fmt.Errorf("请求被拦截 (blockReason: %s)", result.PromptFeedback.BlockReason)
+		}
+		return nil, fmt.Errorf("API返回空响应")
+	}
+
+	candidate := result.Candidates[0]
+	if len(candidate.Content.Parts) == 0 {
+		return nil, fmt.Errorf("API返回空响应 (finishReason: %s)", candidate.FinishReason)
+	}
+
+	usage := result.UsageMetadata
+	var text, reasoning []string
+	resp := &Response{Usage: Usage{
+		PromptTokens:     usage.PromptTokenCount,
+		CompletionTokens: usage.CandidatesTokenCount + usage.ThoughtsTokenCount,
+		ReasoningTokens:  usage.ThoughtsTokenCount,
+	}}
+	for _, part := range candidate.Content.Parts {
+		switch {
+		case part.FunctionCall != nil:
+			id := part.FunctionCall.ID
+			if id == "" {
+				id = fmt.Sprintf("call_%d", len(resp.ToolCalls)+1)
+			}
+			arguments := "{}"
+			if len(part.FunctionCall.Args) > 0 && string(part.FunctionCall.Args) != "null" {
+				arguments = string(part.FunctionCall.Args)
+			}
+			resp.ToolCalls = append(resp.ToolCalls, ToolCall{
+				ID:       id,
+				Type:     "function",
+				Function: FunctionCall{Name: part.FunctionCall.Name, Arguments: arguments},
+			})
+		case part.Thought:
+			reasoning = append(reasoning, part.Text)
+		default:
+			text = append(text, part.Text)
+		}
+	}
+	resp.Content = strings.Join(text, "")
+	resp.Reasoning = strings.TrimSpace(strings.Join(reasoning, "\n\n"))
+	return resp, nil
+}
@@ -0,0 +1,207 @@
+package mcp
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newGeminiTestServer 模拟 generateContent API，记录收到的请求体并返回固定响应
+func newGeminiTestServer(t *testing.T, response string, captured *map[string]any) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1beta/models/gemini-2.5-pro:generateContent" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		if r.Header.Get("x-goog-api-key") != "AIza-test-key" {
+			t.Errorf("x-goog-api-key header not set: %q", r.Header.Get("x-goog-api-key"))
+		}
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, captured); err != nil {
+			t.Errorf("invalid request body: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(response))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newGeminiTestClient(baseURL string) AIClient {
+	client := NewGeminiClientWithOptions(WithLogger(NewMockLogger()), WithMaxTokens(1000), WithRetryWaitBase(time.Millisecond))
+	client.SetAPIKey("AIza-test-key", baseURL+"/v1beta", "gemini-2.5-pro")
+	return client
+}
+
+func TestNewGeminiClient_Default(t *testing.T) {
+	client := NewGeminiClient().(*GeminiClient)
+
+	if client.Provider != ProviderGemini {
+		t.Errorf("Provider should be '%s', got '%s'", ProviderGemini, client.Provider)
+	}
+	if client.buildUrl() != DefaultGeminiBaseURL+"/models/"+DefaultGeminiModel+":generateContent" {
+		t.Errorf("unexpected URL: %s", client.buildUrl())
+	}
+	if client.hooks != client {
+		t.Error("hooks should point to GeminiClient for polymorphism")
+	}
+}
+
+func TestGeminiClient_CallWithMessages(t *testing.T) {
+	var captured map[string]any
+	server := newGeminiTestServer(t, `{
+		"candidates": [{"content": {"role": "model", "parts": [{"text": "Gemini "}, {"text": "response"}]}, "finishReason": "STOP"}]
+	}`, &captured)
+
+	result, err := newGeminiTestClient(server.URL).CallWithMessages("system prompt", "user prompt")
+	if err != nil {
+		t.Fatalf("should not error: %v", err)
+	}
+	if result != "Gemini response" {
+		t.Errorf("expected 'Gemini response', got '%s'", result)
+	}
+
+	instruction := captured["systemInstruction"].(map[string]any)["parts"].([]any)[0].(map[string]any)
+	if instruction["text"] != "system prompt" {
+		t.Errorf("system prompt should map to systemInstruction: %v", captured["systemInstruction"])
+	}
+	contents := captured["contents"].([]any)
+	if len(contents) != 1 || contents[0].(map[string]any)["role"] != "user" {
+		t.Errorf("system message should not be sent in contents: %v", contents)
+	}
+	if config := captured["generationConfig"].(map[string]any); config["maxOutputTokens"] != float64(1000) {
+		t.Errorf("max tokens should map to maxOutputTokens: %v", config)
+	}
+}
+
+func TestGeminiClient_CallWithRequestFull_Tools(t *testing.T) {
+	var captured map[string]any
+	server := newGeminiTestServer(t, `{
+		"candidates": [{"content": {"role": "model", "parts": [
+			{"text": "提交决策"},
+			{"functionCall": {"name": "submit_decisions", "args": {"decisions": []}}}
+		]}, "finishReason": "STOP"}]
+	}`, &captured)
+
+	req, err := NewRequestBuilder().
+		WithSystemPrompt("你是交易员").
+		WithUserPrompt("分析 BTC").
+		AddMessages(
+			NewAssistantToolCallMessage("", []ToolCall{{ID: "call_1", Type: "function", Function: FunctionCall{Name: "get_klines", Arguments: `{"symbol":"BTCUSDT"}`}}}),
+			NewToolMessage("call_1", "klines..."),
+		).
+		AddFunction("submit_decisions", "提交决策", map[string]any{"type": "object"}).
+		WithToolChoice(ToolChoiceFunction("submit_decisions")).
+		WithStopSequences([]string{"</decision>"}).
+		WithJSONResponse().
+		Build()
+	if err != nil {
+		t.Fatalf("build request: %v", err)
+	}
+
+	resp, err := newGeminiTestClient(server.URL).CallWithRequestFull(req)
+	if err != nil {
+		t.Fatalf("should not error: %v", err)
+	}
+	call, ok := resp.ToolCallByName("submit_decisions")
+	if !ok || call.ID == "" || call.Function.Arguments != `{"decisions": []}` {
+		t.Errorf("functionCall should be converted to ToolCall: %+v", resp.ToolCalls)
+	}
+	if resp.Content != "提交决策" {
+		t.Errorf("unexpected content: %q", resp.Content)
+	}
+
+	contents := captured["contents"].([]any)
+	if len(contents) != 3 {
+		t.Fatalf("expected user, model and functionResponse contents, got %d: %v", len(contents), contents)
+	}
+	model := contents[1].(map[string]any)
+	functionCall := model["parts"].([]any)[0].(map[string]any)["functionCall"].(map[string]any)
+	if model["role"] != "model" || functionCall["name"] != "get_klines" || functionCall["args"].(map[string]any)["symbol"] != "BTCUSDT" {
+		t.Errorf("assistant tool call should become a model functionCall: %v", model)
+	}
+	functionResponse := contents[2].(map[string]any)["parts"].([]any)[0].(map[string]any)["functionResponse"].(map[string]any)
+	if functionResponse["name"] != "get_klines" || functionResponse["response"].(map[string]any)["result"] != "klines..." {
+		t.Errorf("tool message should become a functionResponse with the function name: %v", functionResponse)
+	}
+
+	declarations := captured["tools"].([]any)[0].(map[string]any)["functionDeclarations"].([]any)
+	if declarations[0].(map[string]any)["name"] != "submit_decisions" {
+		t.Errorf("tools should map to functionDeclarations: %v", declarations)
+	}
+	callingConfig := captured["toolConfig"].(map[string]any)["functionCallingConfig"].(map[string]any)
+	if callingConfig["mode"] != "ANY" || callingConfig["allowedFunctionNames"].([]any)[0] != "submit_decisions" {
+		t.Errorf("forced function should map to mode ANY: %v", callingConfig)
+	}
+	config := captured["generationConfig"].(map[string]any)
+	if config["stopSequences"].([]any)[0] != "</decision>" {
+		t.Errorf("stop should map to stopSequences: %v", config)
+	}
+	if _, ok := config["responseMimeType"]; ok {
+		t.Error("JSON mode should be skipped when tools are declared")
+	}
+}
+
+func TestGeminiClient_JSONResponseMode(t *testing.T) {
+	var captured map[string]any
+	server := newGeminiTestServer(t, `{"candidates": [{"content": {"parts": [{"text": "{\"ok\":true}"}]}}]}`, &captured)
+
+	req := NewRequestBuilder().WithUserPrompt("返回 JSON").WithJSONResponse().MustBuild()
+	if _, err := newGeminiTestClient(server.URL).CallWithRequest(req); err != nil {
+		t.Fatalf("should not error: %v", err)
+	}
+	if config := captured["generationConfig"].(map[string]any); config["responseMimeType"] != "application/json" {
+		t.Errorf("JSON mode should set responseMimeType: %v", config)
+	}
+}
+
+func TestGeminiClient_BlockedResponse(t *testing.T) {
+	var captured map[string]any
+	server := newGeminiTestServer(t, `{"promptFeedback": {"blockReason": "SAFETY"}}`, &captured)
+
+	_, err := newGeminiTestClient(server.URL).CallWithMessages("", "hi")
+	if err == nil || !strings.Contains(err.Error(), "SAFETY") {
+		t.Errorf("expected blocked error, got %v", err)
+	}
+}
+
+func TestGeminiClient_RetryableErrors(t *testing.T) {
+	client := NewGeminiClient().(*GeminiClient)
+
+	retryable := []string{
+		`API返回错误 (status 429): {"error": {"code": 429, "status": "RESOURCE_EXHAUSTED"}}`,
+		`API返回错误 (status 503): {"error": {"code": 503, "status": "UNAVAILABLE"}}`,
+		`API返回错误 (status 500): {"error": {"code": 500, "status": "INTERNAL"}}`,
+		"发送请求失败: connection reset by peer",
+	}
+	for _, msg := range retryable {
+		if !client.isRetryableError(errors.New(msg)) {
+			t.Errorf("should retry: %s", msg)
+		}
+	}
+	if client.isRetryableError(errors.New(`API返回错误 (status 400): {"error": {"code": 400, "status": "INVALID_ARGUMENT"}}`)) {
+		t.Error("INVALID_ARGUMENT should not be retried")
+	}
+
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"error": {"code": 503, "status": "UNAVAILABLE"}}`))
+			return
+		}
+		w.Write([]byte(`{"candidates": [{"content": {"parts": [{"text": "ok"}]}}]}`))
+	}))
+	defer server.Close()
+
+	result, err := newGeminiTestClient(server.URL).CallWithMessages("", "hi")
+	if err != nil || result != "ok" || attempts != 2 {
+		t.Errorf("UNAVAILABLE should be retried: result=%q err=%v attempts=%d", result, err, attempts)
+	}
+}
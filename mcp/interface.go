@@ -10,7 +10,19 @@ type AIClient interface {
 	SetAPIKey(apiKey string, customURL string, customModel string)
 	SetTimeout(timeout time.Duration)
 	CallWithMessages(systemPrompt, userPrompt string) (string, error)
-	CallWithRequest(req *Request) (string, error) // 构建器模式 API（支持高级功能）
+	CallWithRequest(req *Request) (string, error)        // 构建器模式 API（支持高级功能）
+	CallWithRequestFull(req *Request) (*Response, error) // 返回完整响应（含工具调用）
+}
+
+// TokenBudgeter 可选接口：按模型估算 token 数并给出输入 prompt 的预算（Client 及各派生客户端均已实现）
+type TokenBudgeter interface {
+	EstimateTokens(text string) int
+	PromptTokenBudget() int
+}
+
+// UsageReporter 可选接口：设置 token 用量回调（Client 及各派生客户端均已实现）
+type UsageReporter interface {
+	SetUsageHandler(handler UsageHandler)
 }
 
 // clientHooks 内部钩子接口（用于子类重写特定步骤）
@@ -26,5 +38,7 @@ type clientHooks interface {
 	setAuthHeader(reqHeaders http.Header)
 	marshalRequestBody(requestBody map[string]any) ([]byte, error)
 	parseMCPResponse(body []byte) (string, error)
+	buildRequestBodyFromRequest(req *Request) map[string]any
+	parseMCPFullResponse(body []byte) (*Response, error)
 	isRetryableError(err error) bool
 }
@@ -68,6 +68,16 @@ func WithRetryWaitBase(waitTime time.Duration) ClientOption {
 	}
 }
 
+// WithStreamIdleTimeout 设置流式响应的空闲超时：超过该时长未收到数据才中断
+//
+// 使用示例：
+//   client := mcp.NewClient(mcp.WithStreamIdleTimeout(90 * time.Second))
+func WithStreamIdleTimeout(timeout time.Duration) ClientOption {
+	return func(c *Config) {
+		c.StreamIdleTimeout = timeout
+	}
+}
+
 // ============================================================
 // AI 参数选项
 // ============================================================
@@ -92,6 +102,37 @@ func WithTemperature(temperature float64) ClientOption {
 	}
 }
 
+// WithPromptTokenBudget 设置输入 prompt 的 token 预算，超出时由调用方压缩 prompt
+//
+// 使用示例：
+//   client := mcp.NewClient(mcp.WithPromptTokenBudget(24000))
+func WithPromptTokenBudget(budget int) ClientOption {
+	return func(c *Config) {
+		c.PromptTokenBudget = budget
+	}
+}
+
+// WithThinkingBudget 开启扩展思考并设置思考的 token 预算（Anthropic / Gemini 生效），思考内容写入 Response.Reasoning
+//
+// 使用示例：
+//   client := mcp.NewAnthropicClientWithOptions(mcp.WithThinkingBudget(4000))
+func WithThinkingBudget(budget int) ClientOption {
+	return func(c *Config) {
+		c.ThinkingBudget = budget
+	}
+}
+
+// WithUsageHandler 设置 token 用量回调，每次调用成功后回调本次用量与费用
+//
+// 使用示例：
+//   var meter mcp.UsageMeter
+//   client := mcp.NewClient(mcp.WithUsageHandler(meter.Record))
+func WithUsageHandler(handler UsageHandler) ClientOption {
+	return func(c *Config) {
+		c.OnUsage = handler
+	}
+}
+
 // ============================================================
 // Provider 配置选项
 // ============================================================
@@ -148,6 +189,32 @@ func WithDeepSeekConfig(apiKey string) ClientOption {
 	}
 }
 
+// WithAnthropicConfig 设置 Anthropic 配置（需配合 NewAnthropicClientWithOptions 使用 Messages API）
+//
+// 使用示例：
+//   client := mcp.NewAnthropicClientWithOptions(mcp.WithAnthropicConfig("sk-ant-xxx"))
+func WithAnthropicConfig(apiKey string) ClientOption {
+	return func(c *Config) {
+		c.Provider = ProviderAnthropic
+		c.APIKey = apiKey
+		c.BaseURL = DefaultAnthropicBaseURL
+		c.Model = DefaultAnthropicModel
+	}
+}
+
+// WithGeminiConfig 设置 Gemini 配置（需配合 NewGeminiClientWithOptions 使用 generateContent API）
+//
+// 使用示例：
+//   client := mcp.NewGeminiClientWithOptions(mcp.WithGeminiConfig("AIza-xxx"))
+func WithGeminiConfig(apiKey string) ClientOption {
+	return func(c *Config) {
+		c.Provider = ProviderGemini
+		c.APIKey = apiKey
+		c.BaseURL = DefaultGeminiBaseURL
+		c.Model = DefaultGeminiModel
+	}
+}
+
 // WithQwenConfig 设置 Qwen 配置
 //
 // 使用示例：
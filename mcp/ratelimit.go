@@ -0,0 +1,394 @@
+package mcp
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// rateLimitWindow 请求数和 token 数的统计窗口
+	rateLimitWindow = time.Minute
+	// concurrencyPollInterval 并发数达到上限时重新检查的间隔
+	concurrencyPollInterval = 250 * time.Millisecond
+	// maxRateLimitJitter 排队等待时附加的最大随机抖动，避免同时排队的请求在同一时刻一起放行
+	maxRateLimitJitter = 2 * time.Second
+	// defaultRateLimitKey 未单独配置的提供商使用的限流配置键
+	defaultRateLimitKey = "default"
+)
+
+// RateLimit 单个 提供商+API Key 的限流配置（0 表示不限制）
+type RateLimit struct {
+	RequestsPerMinute int `json:"requests_per_minute"`
+	TokensPerMinute   int `json:"tokens_per_minute"` // 按输入估算 + max_tokens 预占，响应后按实际用量修正
+	MaxConcurrent     int `json:"max_concurrent"`
+}
+
+// RateLimiterStats 限流器的排队与等待统计（用于API）
+type RateLimiterStats struct {
+	Key                string    `json:"key"` // 提供商:API Key 指纹（不包含原始 Key）
+	Limit              RateLimit `json:"limit"`
+	QueueDepth         int       `json:"queue_depth"` // 正在排队的请求数
+	InFlight           int       `json:"in_flight"`   // 正在进行的请求数
+	RequestsLastMinute int       `json:"requests_last_minute"`
+	TokensLastMinute   int       `json:"tokens_last_minute"`
+	WaitedRequests     int64     `json:"waited_requests"` // 需要排队的请求总数
+	AvgWaitMs          int64     `json:"avg_wait_ms"`
+	MaxWaitMs          int64     `json:"max_wait_ms"`
+	LastWaitMs         int64     `json:"last_wait_ms"`
+	BlockedUntil       time.Time `json:"blocked_until,omitempty"` // 提供商 Retry-After 要求的暂停截止时间
+}
+
+// rateEntry 统计窗口中的一次请求
+type rateEntry struct {
+	at     time.Time
+	tokens int
+}
+
+// RateLimiter 同一 提供商+API Key 的请求限流器，进程内所有交易员和回测共享
+type RateLimiter struct {
+	key string
+
+	now    func() time.Time
+	sleep  func(time.Duration)
+	jitter func(time.Duration) time.Duration
+
+	mu           sync.Mutex
+	limit        RateLimit
+	window       []*rateEntry // 统计窗口内的请求，按时间排序
+	inFlight     int
+	waiting      int
+	blockedUntil time.Time
+
+	waitedRequests int64
+	totalWait      time.Duration
+	maxWait        time.Duration
+	lastWait       time.Duration
+}
+
+// newRateLimiter 创建限流器
+func newRateLimiter(key string, limit RateLimit) *RateLimiter {
+	return &RateLimiter{
+		key:    key,
+		limit:  limit,
+		now:    time.Now,
+		sleep:  time.Sleep,
+		jitter: rateLimitJitter,
+	}
+}
+
+// rateLimitJitter 返回 [0, min(wait/4, maxRateLimitJitter)) 的随机抖动
+func rateLimitJitter(wait time.Duration) time.Duration {
+	limit := min(wait/4, maxRateLimitJitter)
+	if limit <= 0 {
+		return 0
+	}
+	return rand.N(limit)
+}
+
+// rateTicket 一次已放行的请求，请求结束后必须调用 done
+type rateTicket struct {
+	limiter *RateLimiter
+	entry   *rateEntry
+}
+
+// done 结束请求：释放并发名额，usedTokens > 0 时用实际用量替换预占的 token 数
+func (t *rateTicket) done(usedTokens int) {
+	if t == nil {
+		return
+	}
+	t.limiter.mu.Lock()
+	defer t.limiter.mu.Unlock()
+	t.limiter.inFlight--
+	if usedTokens > 0 {
+		t.entry.tokens = usedTokens
+	}
+}
+
+// acquire 等待直到请求数、token 数和并发数都在限制内，返回放行凭证和排队时长
+func (l *RateLimiter) acquire(tokens int) (*rateTicket, time.Duration) {
+	start := l.now()
+	queued := false
+	for {
+		l.mu.Lock()
+		now := l.now()
+		wait := l.delayLocked(tokens, now)
+		if wait <= 0 {
+			entry := &rateEntry{at: now, tokens: tokens}
+			l.window = append(l.window, entry)
+			l.inFlight++
+			waited := time.Duration(0)
+			if queued {
+				l.waiting--
+				waited = now.Sub(start)
+				l.waitedRequests++
+				l.totalWait += waited
+				l.maxWait = max(l.maxWait, waited)
+				l.lastWait = waited
+			}
+			l.mu.Unlock()
+			return &rateTicket{limiter: l, entry: entry}, waited
+		}
+		if !queued {
+			queued = true
+			l.waiting++
+		}
+		l.mu.Unlock()
+
+		l.sleep(wait + l.jitter(wait))
+	}
+}
+
+// delayLocked 计算还需要等待多久才能放行（<= 0 表示可以立即发送）
+func (l *RateLimiter) delayLocked(tokens int, now time.Time) time.Duration {
+	l.pruneLocked(now)
+
+	if now.Before(l.blockedUntil) {
+		return l.blockedUntil.Sub(now)
+	}
+	if l.limit.MaxConcurrent > 0 && l.inFlight >= l.limit.MaxConcurrent {
+		return concurrencyPollInterval
+	}
+	if rpm := l.limit.RequestsPerMinute; rpm > 0 && len(l.window) >= rpm {
+		return l.window[len(l.window)-rpm].at.Add(rateLimitWindow).Sub(now)
+	}
+	if tpm := l.limit.TokensPerMinute; tpm > 0 {
+		used := 0
+		for _, e := range l.window {
+			used += e.tokens
+		}
+		// 单次请求超过 TPM 时只要求窗口为空，避免永远无法放行
+		for i := 0; i < len(l.window) && used+tokens > tpm; i++ {
+			used -= l.window[i].tokens
+			if used+tokens <= tpm || i == len(l.window)-1 {
+				return l.window[i].at.Add(rateLimitWindow).Sub(now)
+			}
+		}
+	}
+	return 0
+}
+
+// pruneLocked 移除统计窗口之外的请求
+func (l *RateLimiter) pruneLocked(now time.Time) {
+	cutoff := now.Add(-rateLimitWindow)
+	i := 0
+	for i < len(l.window) && !l.window[i].at.After(cutoff) {
+		i++
+	}
+	l.window = l.window[i:]
+}
+
+// block 按提供商返回的 Retry-After 暂停该 Key 的所有请求
+func (l *RateLimiter) block(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until := l.now().Add(d); until.After(l.blockedUntil) {
+		l.blockedUntil = until
+	}
+}
+
+// setLimit 更新限流配置（对排队中的请求立即生效）
+func (l *RateLimiter) setLimit(limit RateLimit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limit = limit
+}
+
+// Stats 返回限流器的当前统计
+func (l *RateLimiter) Stats() RateLimiterStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	l.pruneLocked(now)
+
+	stats := RateLimiterStats{
+		Key:                l.key,
+		Limit:              l.limit,
+		QueueDepth:         l.waiting,
+		InFlight:           l.inFlight,
+		RequestsLastMinute: len(l.window),
+		WaitedRequests:     l.waitedRequests,
+		MaxWaitMs:          l.maxWait.Milliseconds(),
+		LastWaitMs:         l.lastWait.Milliseconds(),
+	}
+	for _, e := range l.window {
+		stats.TokensLastMinute += e.tokens
+	}
+	if l.waitedRequests > 0 {
+		stats.AvgWaitMs = (l.totalWait / time.Duration(l.waitedRequests)).Milliseconds()
+	}
+	if now.Before(l.blockedUntil) {
+		stats.BlockedUntil = l.blockedUntil
+	}
+	return stats
+}
+
+var (
+	rateLimiters   = map[string]*RateLimiter{}
+	rateLimits     map[string]RateLimit // 提供商 -> 限流配置（"default" 为未单独配置的提供商）
+	rateLimitersMu sync.Mutex
+)
+
+// SetRateLimits 设置各提供商的限流配置（键为提供商，"default" 适用于其他提供商），传 nil 清空。
+// 已创建的限流器立即使用新配置
+func SetRateLimits(limits map[string]RateLimit) {
+	rateLimitersMu.Lock()
+	defer rateLimitersMu.Unlock()
+	rateLimits = limits
+	for key, limiter := range rateLimiters {
+		provider, _, _ := strings.Cut(key, ":")
+		limiter.setLimit(rateLimitForLocked(provider))
+	}
+}
+
+// ParseRateLimits 解析 JSON 格式的限流配置，如 {"deepseek":{"requests_per_minute":30,"max_concurrent":2}}
+func ParseRateLimits(raw string) (map[string]RateLimit, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	var limits map[string]RateLimit
+	if err := json.Unmarshal([]byte(raw), &limits); err != nil {
+		return nil, fmt.Errorf("解析AI限流配置失败: %w", err)
+	}
+	for provider, limit := range limits {
+		if limit.RequestsPerMinute < 0 || limit.TokensPerMinute < 0 || limit.MaxConcurrent < 0 {
+			return nil, fmt.Errorf("提供商 %s 的限流配置不能为负数", provider)
+		}
+	}
+	return limits, nil
+}
+
+// rateLimitProvider 未设置提供商的客户端按 custom 统计
+func rateLimitProvider(provider string) string {
+	if provider == "" {
+		return "custom"
+	}
+	return provider
+}
+
+func rateLimitForLocked(provider string) RateLimit {
+	if limit, ok := rateLimits[provider]; ok {
+		return limit
+	}
+	return rateLimits[defaultRateLimitKey]
+}
+
+// rateLimiterKey 生成 提供商:API Key 指纹，统计和日志中不出现原始 Key
+func rateLimiterKey(provider, apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return provider + ":" + hex.EncodeToString(sum[:4])
+}
+
+// rateLimiterFor 返回 提供商+API Key 对应的共享限流器
+func rateLimiterFor(provider, apiKey string) *RateLimiter {
+	provider = rateLimitProvider(provider)
+	key := rateLimiterKey(provider, apiKey)
+	rateLimitersMu.Lock()
+	defer rateLimitersMu.Unlock()
+	limiter, ok := rateLimiters[key]
+	if !ok {
+		limiter = newRateLimiter(key, rateLimitForLocked(provider))
+		rateLimiters[key] = limiter
+	}
+	return limiter
+}
+
+// RateLimitStats 返回客户端所用 提供商+API Key 的限流统计
+func (client *Client) RateLimitStats() RateLimiterStats {
+	return rateLimiterFor(client.Provider, client.APIKey).Stats()
+}
+
+// RateLimitReporter 可以报告限流统计的客户端
+type RateLimitReporter interface {
+	RateLimitStats() RateLimiterStats
+}
+
+// CollectRateLimitStats 汇总多个客户端的限流统计（展开故障转移链，同一 Key 只出现一次）
+func CollectRateLimitStats(clients ...AIClient) []RateLimiterStats {
+	seen := make(map[string]bool)
+	var stats []RateLimiterStats
+	var collect func(client AIClient)
+	collect = func(client AIClient) {
+		switch c := client.(type) {
+		case *FailoverClient:
+			for _, m := range c.Members() {
+				collect(m.Client)
+			}
+		case RateLimitReporter:
+			s := c.RateLimitStats()
+			if !seen[s.Key] {
+				seen[s.Key] = true
+				stats = append(stats, s)
+			}
+		}
+	}
+	for _, client := range clients {
+		collect(client)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Key < stats[j].Key })
+	return stats
+}
+
+// acquireRateLimit 发送请求前按 提供商+API Key 排队（同一进程内所有交易员和回测共享）
+func (client *Client) acquireRateLimit(estimatedTokens int) *rateTicket {
+	ticket, waited := rateLimiterFor(client.Provider, client.APIKey).acquire(estimatedTokens)
+	if waited > 0 {
+		client.logger.Infof("⏳ [%s] AI 请求限流排队 %v", client.String(), waited.Round(time.Millisecond))
+	}
+	return ticket
+}
+
+// handleRateLimited 提供商返回 429 时按 Retry-After 暂停该 Key 的所有请求（重试会排队到暂停结束）
+func (client *Client) handleRateLimited(resp *http.Response) {
+	if resp.StatusCode != http.StatusTooManyRequests {
+		return
+	}
+	if d := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); d > 0 {
+		client.logger.Warnf("⏸ [%s] 触发提供商限流，%v 后再发送请求", client.String(), d.Round(time.Second))
+		rateLimiterFor(client.Provider, client.APIKey).block(d)
+	}
+}
+
+// parseRetryAfter 解析 Retry-After（秒数或 HTTP 日期），无法解析时返回 0
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		return time.Duration(seconds * float64(time.Second))
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+// estimateRequestTokens 估算一次请求预占的 token 数：输入估算 + 最大输出
+func estimateRequestTokens(model string, maxTokens int, texts ...string) int {
+	tokens := maxTokens
+	for _, text := range texts {
+		tokens += EstimateTokens(model, text)
+	}
+	return tokens
+}
+
+// requestTexts 返回请求中所有消息的文本（用于估算 token）
+func requestTexts(req *Request) []string {
+	texts := make([]string, 0, len(req.Messages))
+	for _, msg := range req.Messages {
+		texts = append(texts, msg.Content)
+	}
+	return texts
+}
@@ -0,0 +1,142 @@
+package mcp
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeClockLimiter 使用可控时钟的限流器，sleep 直接推进时钟
+func fakeClockLimiter(limit RateLimit) (*RateLimiter, *time.Time) {
+	now := time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)
+	l := newRateLimiter("test:0000", limit)
+	l.now = func() time.Time { return now }
+	l.sleep = func(d time.Duration) { now = now.Add(d) }
+	l.jitter = func(time.Duration) time.Duration { return 0 }
+	return l, &now
+}
+
+func TestRateLimiter_RequestsPerMinute(t *testing.T) {
+	l, now := fakeClockLimiter(RateLimit{RequestsPerMinute: 2})
+	start := *now
+
+	for i := 0; i < 2; i++ {
+		ticket, waited := l.acquire(0)
+		ticket.done(0)
+		if waited != 0 {
+			t.Fatalf("request %d should not wait, waited %v", i, waited)
+		}
+	}
+	ticket, waited := l.acquire(0)
+	ticket.done(0)
+	if waited != time.Minute || now.Sub(start) != time.Minute {
+		t.Errorf("third request should wait for the window, waited %v", waited)
+	}
+
+	stats := l.Stats()
+	if stats.WaitedRequests != 1 || stats.MaxWaitMs != time.Minute.Milliseconds() || stats.QueueDepth != 0 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestRateLimiter_TokensPerMinuteUsesActualUsage(t *testing.T) {
+	l, _ := fakeClockLimiter(RateLimit{TokensPerMinute: 10000})
+
+	ticket, _ := l.acquire(8000)
+	ticket.done(3000) // 实际用量小于预占时释放额度
+	if _, waited := l.acquire(6000); waited != 0 {
+		t.Errorf("actual usage should free reserved tokens, waited %v", waited)
+	}
+	if _, waited := l.acquire(5000); waited != time.Minute {
+		t.Errorf("over the token budget should wait, waited %v", waited)
+	}
+
+	// 单次请求超过 TPM 时等窗口清空后放行
+	if _, waited := l.acquire(20000); waited != time.Minute {
+		t.Errorf("oversized request should run once the window is empty, waited %v", waited)
+	}
+}
+
+func TestRateLimiter_MaxConcurrentAndRetryAfter(t *testing.T) {
+	l, now := fakeClockLimiter(RateLimit{MaxConcurrent: 1})
+
+	first, _ := l.acquire(0)
+	if l.delayLocked(0, *now) != concurrencyPollInterval {
+		t.Error("second request should queue while the first is in flight")
+	}
+	first.done(0)
+
+	l.block(30 * time.Second)
+	if stats := l.Stats(); stats.BlockedUntil.IsZero() {
+		t.Error("stats should expose the Retry-After pause")
+	}
+	if _, waited := l.acquire(0); waited != 30*time.Second {
+		t.Errorf("should wait for Retry-After, waited %v", waited)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)
+	if d := parseRetryAfter("20", now); d != 20*time.Second {
+		t.Errorf("seconds: got %v", d)
+	}
+	if d := parseRetryAfter(now.Add(45*time.Second).Format(http.TimeFormat), now); d != 45*time.Second {
+		t.Errorf("http date: got %v", d)
+	}
+	if d := parseRetryAfter("soon", now); d != 0 {
+		t.Errorf("invalid value: got %v", d)
+	}
+}
+
+func TestParseRateLimits(t *testing.T) {
+	limits, err := ParseRateLimits(`{"deepseek":{"requests_per_minute":30,"max_concurrent":2},"default":{"tokens_per_minute":100000}}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if limits["deepseek"].RequestsPerMinute != 30 || limits["default"].TokensPerMinute != 100000 {
+		t.Errorf("unexpected limits: %+v", limits)
+	}
+	if _, err := ParseRateLimits(`{"qwen":{"requests_per_minute":-1}}`); err == nil {
+		t.Error("negative limits should be rejected")
+	}
+}
+
+func TestClient_RateLimitedResponseBlocksSharedKey(t *testing.T) {
+	mockHTTP := NewMockHTTPClient()
+	mockHTTP.ResponseFunc = func(req *http.Request) (*http.Response, error) {
+		header := http.Header{}
+		header.Set("Retry-After", "120")
+		return &http.Response{
+			StatusCode: http.StatusTooManyRequests,
+			Header:     header,
+			Body:       io.NopCloser(strings.NewReader(`{"error":"rate limited"}`)),
+		}, nil
+	}
+
+	apiKey := "sk-ratelimit-" + t.Name()
+	client := NewClient(
+		WithHTTPClient(mockHTTP.ToHTTPClient()),
+		WithLogger(NewMockLogger()),
+		WithProvider(ProviderDeepSeek),
+		WithAPIKey(apiKey),
+		WithMaxRetries(1),
+	)
+	if _, err := client.CallWithMessages("system", "user"); err == nil || !strings.Contains(err.Error(), "status 429") {
+		t.Fatalf("expected 429 error, got %v", err)
+	}
+
+	// 同一提供商+Key 的其他客户端（如回测克隆的客户端）共享暂停状态
+	other := NewClient(WithLogger(NewMockLogger()), WithProvider(ProviderDeepSeek), WithAPIKey(apiKey))
+	stats := CollectRateLimitStats(client, NewFailoverClient(FailoverMember{Name: "other", Client: other}))
+	if len(stats) != 1 {
+		t.Fatalf("clients sharing a key should share one limiter, got %d", len(stats))
+	}
+	if stats[0].BlockedUntil.IsZero() || stats[0].InFlight != 0 || stats[0].RequestsLastMinute != 1 {
+		t.Errorf("unexpected stats: %+v", stats[0])
+	}
+	if strings.Contains(stats[0].Key, apiKey) {
+		t.Error("stats must not expose the raw API key")
+	}
+}
@@ -2,8 +2,12 @@ package mcp
 
 // Message 表示一条对话消息
 type Message struct {
-	Role    string `json:"role"`    // "system", "user", "assistant"
+	Role    string `json:"role"`    // "system", "user", "assistant", "tool"
 	Content string `json:"content"` // 消息内容
+
+	// 多轮工具调用
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`   // assistant 消息中模型发起的工具调用
+	ToolCallID string     `json:"tool_call_id,omitempty"` // tool 消息对应的工具调用 ID
 }
 
 // Tool 表示 AI 可以调用的工具/函数
@@ -19,6 +23,51 @@ type FunctionDef struct {
 	Parameters  map[string]any `json:"parameters,omitempty"`  // 参数 schema (JSON Schema)
 }
 
+// ToolCall AI 返回的工具调用
+type ToolCall struct {
+	ID       string       `json:"id"`
+	Type     string       `json:"type"`     // 通常为 "function"
+	Function FunctionCall `json:"function"` // 调用的函数及参数
+}
+
+// FunctionCall 工具调用中的函数名和参数
+type FunctionCall struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"` // JSON 字符串形式的参数
+}
+
+// Response AI API 的完整响应（文本内容 + 工具调用）
+type Response struct {
+	Content   string     `json:"content"`
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	Reasoning string     `json:"reasoning,omitempty"` // 模型的扩展思考内容（Anthropic extended thinking）
+	Usage     Usage      `json:"usage"`               // 本次调用的 token 用量与费用
+}
+
+// TextWithReasoning 返回文本内容，有扩展思考时放在开头的 <reasoning> 标签中，便于按文本格式提取思维链
+func (r *Response) TextWithReasoning() string {
+	if r == nil {
+		return ""
+	}
+	if r.Reasoning == "" {
+		return r.Content
+	}
+	return "<reasoning>\n" + r.Reasoning + "\n</reasoning>\n\n" + r.Content
+}
+
+// ToolCallByName 返回第一个调用指定函数的工具调用
+func (r *Response) ToolCallByName(name string) (*ToolCall, bool) {
+	if r == nil {
+		return nil, false
+	}
+	for i := range r.ToolCalls {
+		if r.ToolCalls[i].Function.Name == name {
+			return &r.ToolCalls[i], true
+		}
+	}
+	return nil, false
+}
+
 // Request AI API 请求（支持高级功能）
 type Request struct {
 	// 基础字段
@@ -37,6 +86,20 @@ type Request struct {
 	// 高级功能
 	Tools      []Tool `json:"tools,omitempty"`       // 可用工具列表
 	ToolChoice string `json:"tool_choice,omitempty"` // 工具选择策略 ("auto", "none", {"type": "function", "function": {"name": "xxx"}})
+
+	// ResponseFormat 输出格式，ResponseFormatJSON 表示只输出 JSON 对象（空为普通文本）
+	ResponseFormat string `json:"response_format,omitempty"`
+
+	// OnChunk 流式响应的增量回调（Stream 为 true 时生效）
+	OnChunk StreamHandler `json:"-"`
+}
+
+// ResponseFormatJSON JSON 输出模式
+const ResponseFormatJSON = "json_object"
+
+// ToolChoiceFunction 返回强制调用指定函数的 tool_choice 值
+func ToolChoiceFunction(name string) string {
+	return `{"type":"function","function":{"name":"` + name + `"}}`
 }
 
 // NewMessage 创建一条消息
@@ -70,3 +133,21 @@ func NewAssistantMessage(content string) Message {
 		Content: content,
 	}
 }
+
+// NewAssistantToolCallMessage 创建包含工具调用的助手消息（多轮工具调用时回传给模型）
+func NewAssistantToolCallMessage(content string, toolCalls []ToolCall) Message {
+	return Message{
+		Role:      "assistant",
+		Content:   content,
+		ToolCalls: toolCalls,
+	}
+}
+
+// NewToolMessage 创建工具执行结果消息
+func NewToolMessage(toolCallID, content string) Message {
+	return Message{
+		Role:       "tool",
+		Content:    content,
+		ToolCallID: toolCallID,
+	}
+}
@@ -17,6 +17,8 @@ type RequestBuilder struct {
 	stop             []string
 	tools            []Tool
 	toolChoice       string
+	responseFormat   string
+	onChunk          StreamHandler
 }
 
 // NewRequestBuilder 创建请求构建器
@@ -50,6 +52,15 @@ func (b *RequestBuilder) WithStream(stream bool) *RequestBuilder {
 	return b
 }
 
+// WithStreamHandler 开启流式响应并设置增量回调（handler 为 nil 时不改变流式设置）
+func (b *RequestBuilder) WithStreamHandler(handler StreamHandler) *RequestBuilder {
+	if handler != nil {
+		b.stream = true
+		b.onChunk = handler
+	}
+	return b
+}
+
 // ============================================================
 // 消息构建方法
 // ============================================================
@@ -217,6 +228,12 @@ func (b *RequestBuilder) WithToolChoice(choice string) *RequestBuilder {
 	return b
 }
 
+// WithJSONResponse 要求模型只输出 JSON 对象（OpenAI response_format / Gemini responseMimeType）
+func (b *RequestBuilder) WithJSONResponse() *RequestBuilder {
+	b.responseFormat = ResponseFormatJSON
+	return b
+}
+
 // ============================================================
 // 构建方法
 // ============================================================
@@ -236,6 +253,9 @@ func (b *RequestBuilder) Build() (*Request, error) {
 		Stop:       b.stop,
 		Tools:      b.tools,
 		ToolChoice: b.toolChoice,
+
+		ResponseFormat: b.responseFormat,
+		OnChunk:        b.onChunk,
 	}
 
 	// 只设置非 nil 的可选参数（避免发送 0 值覆盖服务端默认值）
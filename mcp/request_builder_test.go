@@ -476,3 +476,118 @@ func TestClient_CallWithRequest_UsesClientModel(t *testing.T) {
 		t.Errorf("expected model %s, got %v", DefaultDeepSeekModel, body["model"])
 	}
 }
+
+func TestClient_CallWithRequestFull_ToolCalls(t *testing.T) {
+	mockHTTP := NewMockHTTPClient()
+	mockHTTP.Response = `{"choices":[{"message":{"content":"","tool_calls":[{"id":"call_1","type":"function","function":{"name":"get_weather","arguments":"{\"location\":\"Beijing\"}"}}]}}]}`
+	mockLogger := NewMockLogger()
+
+	client := NewClient(
+		WithHTTPClient(mockHTTP.ToHTTPClient()),
+		WithLogger(mockLogger),
+		WithAPIKey("sk-test-key"),
+	)
+
+	request := NewRequestBuilder().
+		WithUserPrompt("What's the weather in Beijing?").
+		AddFunction("get_weather", "Get weather", map[string]any{"type": "object"}).
+		WithToolChoice(ToolChoiceFunction("get_weather")).
+		MustBuild()
+
+	resp, err := client.CallWithRequestFull(request)
+	if err != nil {
+		t.Fatalf("should not error: %v", err)
+	}
+
+	call, ok := resp.ToolCallByName("get_weather")
+	if !ok {
+		t.Fatal("expected get_weather tool call")
+	}
+	if call.ID != "call_1" || call.Function.Arguments != `{"location":"Beijing"}` {
+		t.Errorf("unexpected tool call: %+v", call)
+	}
+	if _, ok := resp.ToolCallByName("other"); ok {
+		t.Error("should not find unknown tool call")
+	}
+
+	// 指定函数时 tool_choice 应以 JSON 对象发送
+	var body map[string]interface{}
+	json.NewDecoder(mockHTTP.GetRequests()[0].Body).Decode(&body)
+	toolChoice, ok := body["tool_choice"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("tool_choice should be an object, got %T", body["tool_choice"])
+	}
+	function, _ := toolChoice["function"].(map[string]interface{})
+	if function["name"] != "get_weather" {
+		t.Errorf("unexpected tool_choice: %v", toolChoice)
+	}
+}
+
+func TestClient_CallWithRequest_ToolResultMessages(t *testing.T) {
+	mockHTTP := NewMockHTTPClient()
+	mockHTTP.SetSuccessResponse("It is sunny")
+	mockLogger := NewMockLogger()
+
+	client := NewClient(
+		WithHTTPClient(mockHTTP.ToHTTPClient()),
+		WithLogger(mockLogger),
+		WithAPIKey("sk-test-key"),
+	)
+
+	calls := []ToolCall{{ID: "call_1", Type: "function", Function: FunctionCall{Name: "get_weather", Arguments: `{"location":"Beijing"}`}}}
+	request := NewRequestBuilder().
+		WithUserPrompt("What's the weather in Beijing?").
+		AddMessages(NewAssistantToolCallMessage("", calls), NewToolMessage("call_1", "sunny")).
+		AddFunction("get_weather", "Get weather", map[string]any{"type": "object"}).
+		MustBuild()
+
+	if _, err := client.CallWithRequest(request); err != nil {
+		t.Fatalf("should not error: %v", err)
+	}
+
+	// 工具调用和工具结果需要按 OpenAI 格式回传
+	var body map[string]interface{}
+	json.NewDecoder(mockHTTP.GetRequests()[0].Body).Decode(&body)
+	messages := body["messages"].([]interface{})
+	if len(messages) != 3 {
+		t.Fatalf("expected 3 messages, got %d", len(messages))
+	}
+	assistant := messages[1].(map[string]interface{})
+	toolCalls, ok := assistant["tool_calls"].([]interface{})
+	if !ok || len(toolCalls) != 1 {
+		t.Errorf("assistant message should carry tool_calls: %v", assistant)
+	}
+	tool := messages[2].(map[string]interface{})
+	if tool["role"] != "tool" || tool["tool_call_id"] != "call_1" || tool["content"] != "sunny" {
+		t.Errorf("unexpected tool message: %v", tool)
+	}
+	if _, ok := messages[0].(map[string]interface{})["tool_call_id"]; ok {
+		t.Error("user message should not carry tool_call_id")
+	}
+}
+
+func TestClient_CallWithRequest_JSONResponse(t *testing.T) {
+	mockHTTP := NewMockHTTPClient()
+	mockHTTP.SetSuccessResponse("ok")
+
+	client := NewClient(
+		WithHTTPClient(mockHTTP.ToHTTPClient()),
+		WithLogger(NewMockLogger()),
+		WithAPIKey("sk-test-key"),
+	)
+
+	request := NewRequestBuilder().WithUserPrompt("Return JSON").WithJSONResponse().MustBuild()
+	if request.ResponseFormat != ResponseFormatJSON {
+		t.Fatalf("expected response format %s, got %q", ResponseFormatJSON, request.ResponseFormat)
+	}
+	if _, err := client.CallWithRequest(request); err != nil {
+		t.Fatalf("should not error: %v", err)
+	}
+
+	var body map[string]interface{}
+	json.NewDecoder(mockHTTP.GetRequests()[0].Body).Decode(&body)
+	format, ok := body["response_format"].(map[string]interface{})
+	if !ok || format["type"] != ResponseFormatJSON {
+		t.Errorf("expected response_format json_object, got %v", body["response_format"])
+	}
+}
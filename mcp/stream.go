@@ -0,0 +1,237 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultStreamIdleTimeout 流式响应的默认空闲超时：超过该时长没有收到任何数据才中断请求
+const DefaultStreamIdleTimeout = 60 * time.Second
+
+// StreamChunk 流式响应中的一个增量片段
+type StreamChunk struct {
+	Content   string `json:"content,omitempty"`   // 正文增量
+	Reasoning string `json:"reasoning,omitempty"` // 思考过程增量（reasoning_content）
+}
+
+// StreamHandler 流式响应的增量回调（在请求所在的 goroutine 中同步调用）
+type StreamHandler func(chunk StreamChunk)
+
+// streamDelta OpenAI 兼容流式响应中的一个 SSE 数据块
+type streamDelta struct {
+	Choices []struct {
+		Delta struct {
+			Content          string `json:"content"`
+			ReasoningContent string `json:"reasoning_content"` // DeepSeek / Qwen
+			Reasoning        string `json:"reasoning"`         // OpenRouter 等
+			ToolCalls        []struct {
+				Index    int    `json:"index"`
+				ID       string `json:"id"`
+				Type     string `json:"type"`
+				Function struct {
+					Name      string `json:"name"`
+					Arguments string `json:"arguments"`
+				} `json:"function"`
+			} `json:"tool_calls"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+	} `json:"error"`
+	Usage *openAIUsage `json:"usage"` // 只在最后一个数据块中出现（需要 stream_options.include_usage）
+}
+
+// streamAccumulator 把增量片段拼接为完整响应
+type streamAccumulator struct {
+	content   strings.Builder
+	reasoning strings.Builder
+	toolCalls map[int]*ToolCall
+	usage     Usage
+}
+
+// add 累积一个数据块并回调增量内容
+func (acc *streamAccumulator) add(delta *streamDelta, onChunk StreamHandler) {
+	if delta.Usage != nil {
+		acc.usage = delta.Usage.toUsage()
+	}
+	for _, choice := range delta.Choices {
+		reasoning := choice.Delta.ReasoningContent + choice.Delta.Reasoning
+		acc.content.WriteString(choice.Delta.Content)
+		acc.reasoning.WriteString(reasoning)
+		if onChunk != nil && (choice.Delta.Content != "" || reasoning != "") {
+			onChunk(StreamChunk{Content: choice.Delta.Content, Reasoning: reasoning})
+		}
+
+		// 工具调用按 index 分片下发：首片带 id 和函数名，后续只追加参数
+		for _, tc := range choice.Delta.ToolCalls {
+			if acc.toolCalls == nil {
+				acc.toolCalls = make(map[int]*ToolCall)
+			}
+			call, ok := acc.toolCalls[tc.Index]
+			if !ok {
+				call = &ToolCall{Type: "function"}
+				acc.toolCalls[tc.Index] = call
+			}
+			if tc.ID != "" {
+				call.ID = tc.ID
+			}
+			if tc.Type != "" {
+				call.Type = tc.Type
+			}
+			call.Function.Name += tc.Function.Name
+			call.Function.Arguments += tc.Function.Arguments
+		}
+	}
+}
+
+// response 返回累积的完整响应
+func (acc *streamAccumulator) response() *Response {
+	resp := &Response{
+		Content:   acc.content.String(),
+		Reasoning: strings.TrimSpace(acc.reasoning.String()),
+		Usage:     acc.usage,
+	}
+	indexes := make([]int, 0, len(acc.toolCalls))
+	for index := range acc.toolCalls {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	for _, index := range indexes {
+		resp.ToolCalls = append(resp.ToolCalls, *acc.toolCalls[index])
+	}
+	return resp
+}
+
+// streamIdleTimeout 返回配置的流式空闲超时
+func (client *Client) streamIdleTimeout() time.Duration {
+	if client.config != nil && client.config.StreamIdleTimeout > 0 {
+		return client.config.StreamIdleTimeout
+	}
+	return DefaultStreamIdleTimeout
+}
+
+// callStream 发送流式请求并解析 SSE 响应。
+// 不使用 HTTP 客户端的总超时，改为空闲超时：只要持续收到数据，慢速推理模型可以运行任意时长
+func (client *Client) callStream(httpReq *http.Request, onChunk StreamHandler) (*Response, error) {
+	idleTimeout := client.streamIdleTimeout()
+	ctx, cancel := context.WithCancel(httpReq.Context())
+	defer cancel()
+
+	var idle atomic.Bool
+	timer := time.AfterFunc(idleTimeout, func() {
+		idle.Store(true)
+		cancel()
+	})
+	defer timer.Stop()
+	idleErr := func(err error) error {
+		if idle.Load() {
+			return fmt.Errorf("流式响应空闲超时（%v 内未收到数据, idle timeout）", idleTimeout)
+		}
+		return err
+	}
+
+	streamClient := *client.httpClient
+	streamClient.Timeout = 0
+
+	resp, err := streamClient.Do(httpReq.WithContext(ctx))
+	if err != nil {
+		return nil, idleErr(fmt.Errorf("发送请求失败: %w", err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		client.handleRateLimited(resp)
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("API返回错误 (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	reader := bufio.NewReader(resp.Body)
+
+	// 服务端忽略 stream 参数时返回普通 JSON，按非流式响应解析并一次性回调
+	if first, err := reader.Peek(1); err == nil && first[0] == '{' {
+		body, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, idleErr(fmt.Errorf("读取响应失败: %w", err))
+		}
+		result, err := client.hooks.parseMCPFullResponse(body)
+		if err != nil {
+			return nil, fmt.Errorf("fail to parse AI server response: %w", err)
+		}
+		if onChunk != nil {
+			onChunk(StreamChunk{Content: result.Content, Reasoning: result.Reasoning})
+		}
+		return result, nil
+	}
+
+	acc := &streamAccumulator{}
+	err = readSSE(reader, func() { timer.Reset(idleTimeout) }, func(data []byte) (bool, error) {
+		if string(data) == "[DONE]" {
+			return true, nil
+		}
+		var delta streamDelta
+		if err := json.Unmarshal(data, &delta); err != nil {
+			return false, fmt.Errorf("解析流式数据失败: %w: %s", err, string(data))
+		}
+		if delta.Error != nil {
+			return false, fmt.Errorf("API返回错误 (stream): %s %s", delta.Error.Type, delta.Error.Message)
+		}
+		acc.add(&delta, onChunk)
+		return false, nil
+	})
+	if err != nil {
+		return nil, idleErr(err)
+	}
+	return acc.response(), nil
+}
+
+// readSSE 逐行读取 SSE 流，把每个事件的 data 交给 handle，handle 返回 done=true 时结束。
+// 每收到一行数据调用一次 touch（用于重置空闲计时器）
+func readSSE(reader *bufio.Reader, touch func(), handle func(data []byte) (done bool, err error)) error {
+	var data [][]byte
+	dispatch := func() (bool, error) {
+		if len(data) == 0 {
+			return false, nil
+		}
+		payload := bytes.Join(data, []byte("\n"))
+		data = data[:0]
+		return handle(payload)
+	}
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			touch()
+		}
+		line = bytes.TrimRight(line, "\r\n")
+
+		switch {
+		case len(line) == 0:
+			// 空行表示一个事件结束
+			if done, herr := dispatch(); herr != nil || done {
+				return herr
+			}
+		case line[0] == ':':
+			// 注释行（服务端心跳）
+		case bytes.HasPrefix(line, []byte("data:")):
+			data = append(data, bytes.TrimPrefix(bytes.TrimPrefix(line, []byte("data:")), []byte(" ")))
+		}
+
+		if err == io.EOF {
+			_, herr := dispatch()
+			return herr
+		}
+		if err != nil {
+			return fmt.Errorf("读取流式响应失败: %w", err)
+		}
+	}
+}
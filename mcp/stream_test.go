@@ -0,0 +1,143 @@
+package mcp
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newSSEServer 按顺序推送 SSE 事件，每个事件之间等待 delay
+func newSSEServer(t *testing.T, events []string, delay time.Duration) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher := w.(http.Flusher)
+		for _, event := range events {
+			fmt.Fprintf(w, "%s\n\n", event)
+			flusher.Flush()
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newStreamTestClient(url string, opts ...ClientOption) AIClient {
+	opts = append([]ClientOption{
+		WithLogger(NewMockLogger()),
+		WithAPIKey("sk-test-key"),
+		WithBaseURL(url),
+		WithProvider(ProviderCustom),
+		WithRetryWaitBase(time.Millisecond),
+	}, opts...)
+	return NewClient(opts...)
+}
+
+func TestClient_Stream_ReasoningAndContent(t *testing.T) {
+	server := newSSEServer(t, []string{
+		": keep-alive",
+		`data: {"choices":[{"delta":{"role":"assistant","reasoning_content":"先看 BTC"}}]}`,
+		`data: {"choices":[{"delta":{"reasoning_content":" 趋势"}}]}`,
+		`data: {"choices":[{"delta":{"content":"<decision>"}}]}`,
+		`data: {"choices":[{"delta":{"content":"[]</decision>"}}]}`,
+		`data: [DONE]`,
+	}, 0)
+
+	var chunks []StreamChunk
+	req := NewRequestBuilder().
+		WithUserPrompt("分析").
+		WithStreamHandler(func(chunk StreamChunk) { chunks = append(chunks, chunk) }).
+		MustBuild()
+
+	resp, err := newStreamTestClient(server.URL).CallWithRequestFull(req)
+	if err != nil {
+		t.Fatalf("should not error: %v", err)
+	}
+	if resp.Content != "<decision>[]</decision>" || resp.Reasoning != "先看 BTC 趋势" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	if len(chunks) != 4 || chunks[0].Reasoning != "先看 BTC" || chunks[3].Content != "[]</decision>" {
+		t.Errorf("handler should receive each delta: %+v", chunks)
+	}
+}
+
+func TestClient_Stream_ToolCalls(t *testing.T) {
+	server := newSSEServer(t, []string{
+		`data: {"choices":[{"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"submit_decisions","arguments":""}}]}}]}`,
+		`data: {"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"decisions\":"}}]}}]}`,
+		`data: {"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"[]}"}}]}}]}`,
+		`data: [DONE]`,
+	}, 0)
+
+	req := NewRequestBuilder().WithUserPrompt("分析").WithStream(true).MustBuild()
+	resp, err := newStreamTestClient(server.URL).CallWithRequestFull(req)
+	if err != nil {
+		t.Fatalf("should not error: %v", err)
+	}
+	call, ok := resp.ToolCallByName("submit_decisions")
+	if !ok || call.ID != "call_1" || call.Function.Arguments != `{"decisions":[]}` {
+		t.Errorf("tool call deltas should be merged: %+v", resp.ToolCalls)
+	}
+}
+
+func TestClient_Stream_IdleTimeout(t *testing.T) {
+	server := newSSEServer(t, []string{
+		`data: {"choices":[{"delta":{"content":"a"}}]}`,
+		`data: {"choices":[{"delta":{"content":"b"}}]}`,
+		`data: {"choices":[{"delta":{"content":"c"}}]}`,
+		`data: [DONE]`,
+	}, 60*time.Millisecond)
+
+	// 总耗时超过空闲超时，但每段间隔都在空闲超时内，应正常完成
+	req := NewRequestBuilder().WithUserPrompt("分析").WithStream(true).MustBuild()
+	resp, err := newStreamTestClient(server.URL, WithStreamIdleTimeout(100*time.Millisecond), WithTimeout(50*time.Millisecond)).CallWithRequestFull(req)
+	if err != nil {
+		t.Fatalf("should not error while data keeps arriving: %v", err)
+	}
+	if resp.Content != "abc" {
+		t.Errorf("unexpected content: %q", resp.Content)
+	}
+
+	slow := newSSEServer(t, []string{`data: {"choices":[{"delta":{"content":"a"}}]}`, `data: [DONE]`}, 300*time.Millisecond)
+	req = NewRequestBuilder().WithUserPrompt("分析").WithStream(true).MustBuild()
+	_, err = newStreamTestClient(slow.URL, WithStreamIdleTimeout(50*time.Millisecond), WithMaxRetries(1)).CallWithRequestFull(req)
+	if err == nil || !strings.Contains(err.Error(), "idle timeout") {
+		t.Errorf("expected idle timeout error, got %v", err)
+	}
+}
+
+func TestClient_Stream_ServerIgnoresStream(t *testing.T) {
+	mockHTTP := NewMockHTTPClient()
+	mockHTTP.SetSuccessResponse("完整响应")
+	client := NewClient(WithHTTPClient(mockHTTP.ToHTTPClient()), WithLogger(NewMockLogger()), WithAPIKey("sk-test-key"))
+
+	var chunks []StreamChunk
+	req := NewRequestBuilder().
+		WithUserPrompt("分析").
+		WithStreamHandler(func(chunk StreamChunk) { chunks = append(chunks, chunk) }).
+		MustBuild()
+	resp, err := client.CallWithRequestFull(req)
+	if err != nil {
+		t.Fatalf("should not error: %v", err)
+	}
+	if resp.Content != "完整响应" || len(chunks) != 1 || chunks[0].Content != "完整响应" {
+		t.Errorf("plain JSON response should be delivered in one chunk: %+v %+v", resp, chunks)
+	}
+}
+
+func TestClient_Stream_ErrorEvent(t *testing.T) {
+	server := newSSEServer(t, []string{`data: {"error":{"type":"invalid_request_error","message":"context too long"}}`}, 0)
+
+	req := NewRequestBuilder().WithUserPrompt("分析").WithStream(true).MustBuild()
+	_, err := newStreamTestClient(server.URL).CallWithRequestFull(req)
+	if err == nil || !strings.Contains(err.Error(), "context too long") {
+		t.Errorf("expected stream error, got %v", err)
+	}
+}
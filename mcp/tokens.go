@@ -0,0 +1,80 @@
+package mcp
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultContextWindow 未知模型的上下文窗口（token）
+const DefaultContextWindow = 32000
+
+// tokenProfile 模型的 token 估算参数
+type tokenProfile struct {
+	prefix        string  // 模型名前缀（小写）
+	contextWindow int     // 上下文窗口（token）
+	asciiPerToken float64 // 平均每个 token 对应的 ASCII 字符数
+	otherPerToken float64 // 平均每个 token 对应的中文等非 ASCII 字符数
+}
+
+// tokenProfiles 按前缀匹配模型，更具体的前缀放在前面
+var tokenProfiles = []tokenProfile{
+	{prefix: "deepseek", contextWindow: 128000, asciiPerToken: 3.3, otherPerToken: 1.6},
+	{prefix: "qwen3-max", contextWindow: 262144, asciiPerToken: 3.5, otherPerToken: 1.5},
+	{prefix: "qwen", contextWindow: 131072, asciiPerToken: 3.5, otherPerToken: 1.5},
+	{prefix: "gpt-4.1", contextWindow: 1047576, asciiPerToken: 4, otherPerToken: 1},
+	{prefix: "gpt-5", contextWindow: 400000, asciiPerToken: 4, otherPerToken: 1},
+	{prefix: "gpt-4o", contextWindow: 128000, asciiPerToken: 4, otherPerToken: 1},
+	{prefix: "gpt", contextWindow: 128000, asciiPerToken: 4, otherPerToken: 1},
+	{prefix: "claude", contextWindow: 200000, asciiPerToken: 3.5, otherPerToken: 0.9},
+	{prefix: "gemini", contextWindow: 1048576, asciiPerToken: 4, otherPerToken: 1},
+}
+
+// defaultTokenProfile 未知模型按保守参数估算
+var defaultTokenProfile = tokenProfile{contextWindow: DefaultContextWindow, asciiPerToken: 3.5, otherPerToken: 1}
+
+// profileFor 返回模型的 token 估算参数
+func profileFor(model string) tokenProfile {
+	// 兼容 OpenRouter 等 "vendor/model" 形式的模型名
+	model = normalizeModelName(model)
+	for _, p := range tokenProfiles {
+		if strings.HasPrefix(model, p.prefix) {
+			return p
+		}
+	}
+	return defaultTokenProfile
+}
+
+// EstimateTokens 按模型粗略估算文本的 token 数（按字符数换算，用于预算控制，不用于计费）
+func EstimateTokens(model, text string) int {
+	if text == "" {
+		return 0
+	}
+	p := profileFor(model)
+	ascii := 0
+	for i := 0; i < len(text); i++ {
+		if text[i] < utf8.RuneSelf {
+			ascii++
+		}
+	}
+	other := utf8.RuneCountInString(text) - ascii
+	return int(float64(ascii)/p.asciiPerToken+float64(other)/p.otherPerToken) + 1
+}
+
+// ContextWindow 返回模型的上下文窗口大小（token）
+func ContextWindow(model string) int {
+	return profileFor(model).contextWindow
+}
+
+// EstimateTokens 按当前模型估算文本的 token 数
+func (client *Client) EstimateTokens(text string) int {
+	return EstimateTokens(client.Model, text)
+}
+
+// PromptTokenBudget 输入 prompt（system + user）的 token 预算：
+// 优先使用配置的 PromptTokenBudget，否则为模型上下文窗口减去为输出预留的 MaxTokens
+func (client *Client) PromptTokenBudget() int {
+	if client.config != nil && client.config.PromptTokenBudget > 0 {
+		return client.config.PromptTokenBudget
+	}
+	return ContextWindow(client.Model) - client.MaxTokens
+}
@@ -0,0 +1,51 @@
+package mcp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEstimateTokens_PerModel(t *testing.T) {
+	english := strings.Repeat("abcd", 100)
+	chinese := strings.Repeat("中文", 100)
+
+	if got := EstimateTokens("gpt-4o", english); got < 95 || got > 105 {
+		t.Errorf("gpt-4o 400 个 ASCII 字符约 100 tokens，实际 %d", got)
+	}
+	if EstimateTokens("deepseek-chat", english) <= EstimateTokens("gpt-4o", english) {
+		t.Error("DeepSeek 的英文 token 密度应高于 GPT")
+	}
+	if EstimateTokens("deepseek-chat", chinese) >= EstimateTokens("gpt-4o", chinese) {
+		t.Error("DeepSeek 的中文 token 密度应低于 GPT")
+	}
+	if EstimateTokens("openai/gpt-4o", english) != EstimateTokens("gpt-4o", english) {
+		t.Error("带厂商前缀的模型名应按模型本身匹配")
+	}
+	if EstimateTokens("gpt-4o", "") != 0 {
+		t.Error("空文本应为 0 token")
+	}
+}
+
+func TestClient_PromptTokenBudget(t *testing.T) {
+	client := NewClient(WithProvider(ProviderCustom), WithModel("unknown-model"), WithMaxTokens(2000)).(*Client)
+	client.config.PromptTokenBudget = 0
+	if got := client.PromptTokenBudget(); got != DefaultContextWindow-2000 {
+		t.Errorf("未配置预算时应为上下文窗口减去 MaxTokens，实际 %d", got)
+	}
+
+	client.SetAPIKey("sk-test", "https://example.com/v1", "qwen3-max")
+	if got := client.PromptTokenBudget(); got != 262144-2000 {
+		t.Errorf("切换模型后应按新模型的上下文窗口计算，实际 %d", got)
+	}
+
+	budgeted := NewClient(WithPromptTokenBudget(8000))
+	if got := budgeted.(TokenBudgeter).PromptTokenBudget(); got != 8000 {
+		t.Errorf("应优先使用配置的预算，实际 %d", got)
+	}
+
+	for _, c := range []AIClient{NewDeepSeekClientWithOptions(), NewQwenClientWithOptions()} {
+		if _, ok := c.(TokenBudgeter); !ok {
+			t.Errorf("%T 应实现 TokenBudgeter", c)
+		}
+	}
+}
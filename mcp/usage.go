@@ -0,0 +1,202 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Usage AI 调用的 token 用量与费用
+type Usage struct {
+	Calls            int     `json:"calls"`             // 成功的 API 调用次数
+	PromptTokens     int     `json:"prompt_tokens"`     // 输入 token
+	CompletionTokens int     `json:"completion_tokens"` // 输出 token（含思考 token）
+	ReasoningTokens  int     `json:"reasoning_tokens"`  // 其中的思考 token
+	CostUSD          float64 `json:"cost_usd"`          // 按价格表计算的费用（美元），未配置价格的模型为 0
+}
+
+// Add 累加另一份用量
+func (u *Usage) Add(other Usage) {
+	u.Calls += other.Calls
+	u.PromptTokens += other.PromptTokens
+	u.CompletionTokens += other.CompletionTokens
+	u.ReasoningTokens += other.ReasoningTokens
+	u.CostUSD += other.CostUSD
+}
+
+// TotalTokens 输入与输出 token 之和
+func (u Usage) TotalTokens() int {
+	return u.PromptTokens + u.CompletionTokens
+}
+
+// UsageHandler 每次 API 调用成功后回调本次用量（可能在多个 goroutine 中并发调用）
+type UsageHandler func(usage Usage)
+
+// UsageMeter 线程安全的用量累加器，Record 可直接作为 UsageHandler 使用
+type UsageMeter struct {
+	mu    sync.Mutex
+	usage Usage
+}
+
+// Record 累加一次调用的用量
+func (m *UsageMeter) Record(usage Usage) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.usage.Add(usage)
+}
+
+// Take 返回累计用量并清零（用于按决策周期统计）
+func (m *UsageMeter) Take() Usage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	usage := m.usage
+	m.usage = Usage{}
+	return usage
+}
+
+// ModelPrice 模型价格（美元 / 百万 token）
+type ModelPrice struct {
+	Input  float64 `json:"input"`  // 输入价格
+	Output float64 `json:"output"` // 输出价格（思考 token 按输出计费）
+}
+
+// Cost 计算一次调用的费用
+func (p ModelPrice) Cost(usage Usage) float64 {
+	return (float64(usage.PromptTokens)*p.Input + float64(usage.CompletionTokens)*p.Output) / 1e6
+}
+
+// modelPrice 按模型名前缀匹配的价格
+type modelPrice struct {
+	prefix string
+	price  ModelPrice
+}
+
+// defaultModelPrices 各提供商的参考价格，按前缀匹配，更具体的前缀放在前面。
+// 实际价格以提供商账单为准，可通过 SetModelPrices 覆盖
+var defaultModelPrices = []modelPrice{
+	{prefix: "deepseek", price: ModelPrice{Input: 0.28, Output: 0.42}},
+	{prefix: "qwen3-max", price: ModelPrice{Input: 1.2, Output: 6}},
+	{prefix: "qwen", price: ModelPrice{Input: 0.4, Output: 1.2}},
+	{prefix: "gpt-4.1", price: ModelPrice{Input: 2, Output: 8}},
+	{prefix: "gpt-5", price: ModelPrice{Input: 1.25, Output: 10}},
+	{prefix: "gpt-4o", price: ModelPrice{Input: 2.5, Output: 10}},
+	{prefix: "claude-opus", price: ModelPrice{Input: 15, Output: 75}},
+	{prefix: "claude-haiku", price: ModelPrice{Input: 1, Output: 5}},
+	{prefix: "claude", price: ModelPrice{Input: 3, Output: 15}},
+	{prefix: "gemini-2.5-pro", price: ModelPrice{Input: 1.25, Output: 10}},
+	{prefix: "gemini", price: ModelPrice{Input: 0.3, Output: 2.5}},
+}
+
+var (
+	customModelPrices   []modelPrice
+	customModelPricesMu sync.RWMutex
+)
+
+// SetModelPrices 设置自定义价格表（键为模型名前缀，优先于内置参考价，最长前缀优先），传 nil 清空
+func SetModelPrices(prices map[string]ModelPrice) {
+	list := make([]modelPrice, 0, len(prices))
+	for prefix, price := range prices {
+		prefix = normalizeModelName(prefix)
+		if prefix == "" {
+			continue
+		}
+		list = append(list, modelPrice{prefix: prefix, price: price})
+	}
+	sort.Slice(list, func(i, j int) bool { return len(list[i].prefix) > len(list[j].prefix) })
+
+	customModelPricesMu.Lock()
+	defer customModelPricesMu.Unlock()
+	customModelPrices = list
+}
+
+// ParseModelPrices 解析 JSON 格式的价格表，如 {"deepseek-chat":{"input":0.28,"output":0.42}}
+func ParseModelPrices(raw string) (map[string]ModelPrice, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	var prices map[string]ModelPrice
+	if err := json.Unmarshal([]byte(raw), &prices); err != nil {
+		return nil, fmt.Errorf("解析模型价格表失败: %w", err)
+	}
+	for model, price := range prices {
+		if price.Input < 0 || price.Output < 0 {
+			return nil, fmt.Errorf("模型 %s 的价格不能为负数", model)
+		}
+	}
+	return prices, nil
+}
+
+// PriceFor 返回模型的价格，未知模型返回 false
+func PriceFor(model string) (ModelPrice, bool) {
+	model = normalizeModelName(model)
+	if model == "" {
+		return ModelPrice{}, false
+	}
+
+	customModelPricesMu.RLock()
+	defer customModelPricesMu.RUnlock()
+	for _, p := range customModelPrices {
+		if strings.HasPrefix(model, p.prefix) {
+			return p.price, true
+		}
+	}
+	for _, p := range defaultModelPrices {
+		if strings.HasPrefix(model, p.prefix) {
+			return p.price, true
+		}
+	}
+	return ModelPrice{}, false
+}
+
+// normalizeModelName 小写并去掉 OpenRouter 等 "vendor/model" 形式的前缀
+func normalizeModelName(model string) string {
+	model = strings.ToLower(strings.TrimSpace(model))
+	if idx := strings.LastIndex(model, "/"); idx >= 0 {
+		model = model[idx+1:]
+	}
+	return model
+}
+
+// openAIUsage OpenAI 兼容响应中的 usage 字段
+type openAIUsage struct {
+	PromptTokens            int `json:"prompt_tokens"`
+	CompletionTokens        int `json:"completion_tokens"`
+	CompletionTokensDetails struct {
+		ReasoningTokens int `json:"reasoning_tokens"`
+	} `json:"completion_tokens_details"`
+}
+
+func (u *openAIUsage) toUsage() Usage {
+	if u == nil {
+		return Usage{}
+	}
+	return Usage{
+		PromptTokens:     u.PromptTokens,
+		CompletionTokens: u.CompletionTokens,
+		ReasoningTokens:  u.CompletionTokensDetails.ReasoningTokens,
+	}
+}
+
+// SetUsageHandler 设置用量回调（nil 表示不统计）
+func (client *Client) SetUsageHandler(handler UsageHandler) {
+	client.config.OnUsage = handler
+}
+
+// recordUsage 按模型价格计算本次调用的费用，并回调用量处理器
+func (client *Client) recordUsage(model string, resp *Response) {
+	if resp == nil {
+		return
+	}
+	if model == "" {
+		model = client.Model
+	}
+	resp.Usage.Calls = 1
+	if price, ok := PriceFor(model); ok {
+		resp.Usage.CostUSD = price.Cost(resp.Usage)
+	}
+	if client.config.OnUsage != nil {
+		client.config.OnUsage(resp.Usage)
+	}
+}
@@ -0,0 +1,128 @@
+package mcp
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPriceFor(t *testing.T) {
+	t.Cleanup(func() { SetModelPrices(nil) })
+
+	price, ok := PriceFor("deepseek-chat")
+	if !ok || price.Input != 0.28 {
+		t.Errorf("deepseek should use the built-in price: %+v %v", price, ok)
+	}
+	if price, ok := PriceFor("anthropic/claude-opus-4-1"); !ok || price.Output != 75 {
+		t.Errorf("vendor prefix should be ignored and the longest prefix should win: %+v %v", price, ok)
+	}
+	if _, ok := PriceFor("my-local-llm"); ok {
+		t.Error("unknown model should have no price")
+	}
+
+	prices, err := ParseModelPrices(`{"deepseek":{"input":1,"output":2},"my-local":{"input":0,"output":0.5}}`)
+	if err != nil {
+		t.Fatalf("should parse: %v", err)
+	}
+	SetModelPrices(prices)
+	if price, _ := PriceFor("deepseek-reasoner"); price.Input != 1 || price.Output != 2 {
+		t.Errorf("custom price should override the built-in one: %+v", price)
+	}
+	if price, ok := PriceFor("my-local-llm"); !ok || price.Output != 0.5 {
+		t.Errorf("custom price should apply to unknown models: %+v %v", price, ok)
+	}
+
+	if _, err := ParseModelPrices(`{"x":{"input":-1}}`); err == nil {
+		t.Error("negative price should be rejected")
+	}
+	if _, err := ParseModelPrices(`not json`); err == nil {
+		t.Error("invalid JSON should be rejected")
+	}
+}
+
+func TestClient_RecordsUsage(t *testing.T) {
+	mockHTTP := NewMockHTTPClient()
+	mockHTTP.Response = `{
+		"choices": [{"message": {"content": "ok"}}],
+		"usage": {"prompt_tokens": 1000000, "completion_tokens": 2000, "completion_tokens_details": {"reasoning_tokens": 500}}
+	}`
+
+	var meter UsageMeter
+	client := NewClient(
+		WithHTTPClient(mockHTTP.ToHTTPClient()),
+		WithLogger(NewMockLogger()),
+		WithDeepSeekConfig("sk-test-key"),
+		WithUsageHandler(meter.Record),
+	)
+
+	resp, err := client.CallWithRequestFull(NewRequestBuilder().WithUserPrompt("分析").MustBuild())
+	if err != nil {
+		t.Fatalf("should not error: %v", err)
+	}
+	want := Usage{Calls: 1, PromptTokens: 1000000, CompletionTokens: 2000, ReasoningTokens: 500}
+	price, _ := PriceFor(DefaultDeepSeekModel)
+	want.CostUSD = price.Cost(want)
+	if resp.Usage != want {
+		t.Errorf("unexpected usage: %+v, want %+v", resp.Usage, want)
+	}
+
+	// 文本接口同样统计用量
+	if _, err := client.CallWithMessages("system", "user"); err != nil {
+		t.Fatalf("should not error: %v", err)
+	}
+
+	total := meter.Take()
+	if total.Calls != 2 || total.PromptTokens != 2000000 || math.Abs(total.CostUSD-2*want.CostUSD) > 1e-9 {
+		t.Errorf("meter should accumulate both calls: %+v", total)
+	}
+	if meter.Take() != (Usage{}) {
+		t.Error("Take should reset the meter")
+	}
+}
+
+func TestClient_Stream_Usage(t *testing.T) {
+	server := newSSEServer(t, []string{
+		`data: {"choices":[{"delta":{"content":"ok"}}]}`,
+		`data: {"choices":[],"usage":{"prompt_tokens":120,"completion_tokens":30}}`,
+		`data: [DONE]`,
+	}, 0)
+
+	var meter UsageMeter
+	req := NewRequestBuilder().WithUserPrompt("分析").WithStream(true).MustBuild()
+	resp, err := newStreamTestClient(server.URL, WithUsageHandler(meter.Record)).CallWithRequestFull(req)
+	if err != nil {
+		t.Fatalf("should not error: %v", err)
+	}
+	if resp.Usage.PromptTokens != 120 || resp.Usage.CompletionTokens != 30 || resp.Usage.Calls != 1 {
+		t.Errorf("usage from the final chunk should be kept: %+v", resp.Usage)
+	}
+	if meter.Take().PromptTokens != 120 {
+		t.Error("stream usage should be reported to the handler")
+	}
+}
+
+func TestProviderUsageParsing(t *testing.T) {
+	anthropic := NewAnthropicClient().(*AnthropicClient)
+	resp, err := anthropic.parseMCPFullResponse([]byte(`{
+		"content": [{"type": "thinking", "thinking": "先看趋势"}, {"type": "text", "text": "ok"}],
+		"usage": {"input_tokens": 100, "cache_read_input_tokens": 50, "output_tokens": 40}
+	}`))
+	if err != nil {
+		t.Fatalf("should not error: %v", err)
+	}
+	if resp.Usage.PromptTokens != 150 || resp.Usage.CompletionTokens != 40 || resp.Usage.ReasoningTokens == 0 {
+		t.Errorf("unexpected anthropic usage: %+v", resp.Usage)
+	}
+
+	gemini := NewGeminiClient().(*GeminiClient)
+	resp, err = gemini.parseMCPFullResponse([]byte(`{
+		"candidates": [{"content": {"parts": [{"text": "ok"}]}}],
+		"usageMetadata": {"promptTokenCount": 200, "candidatesTokenCount": 20, "thoughtsTokenCount": 80}
+	}`))
+	if err != nil {
+		t.Fatalf("should not error: %v", err)
+	}
+	want := Usage{PromptTokens: 200, CompletionTokens: 100, ReasoningTokens: 80}
+	if resp.Usage != want {
+		t.Errorf("thoughts should count as output tokens: %+v", resp.Usage)
+	}
+}
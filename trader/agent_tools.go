@@ -0,0 +1,66 @@
+package trader
+
+import (
+	"nofx/decision"
+	"nofx/market"
+	"sort"
+	"time"
+)
+
+// fundingInterval 币安永续合约资金费结算间隔
+const fundingInterval = 8 * time.Hour
+
+// liveAgentTools agent 决策模式的实盘数据源：K 线来自交易员的行情数据源，订单簿和资金费率历史来自币安公共行情接口，
+// 成交记录来自交易所账户
+type liveAgentTools struct {
+	trader     Trader
+	marketData market.MarketDataProvider
+	api        *market.APIClient
+}
+
+// newLiveAgentTools 创建 agent 数据源，marketData 为 nil 时使用币安行情
+func newLiveAgentTools(trader Trader, marketData market.MarketDataProvider) *liveAgentTools {
+	if marketData == nil {
+		marketData = market.DefaultProvider()
+	}
+	return &liveAgentTools{trader: trader, marketData: marketData, api: market.NewAPIClient()}
+}
+
+func (t *liveAgentTools) GetKlines(symbol, interval string, limit int) ([]market.Kline, error) {
+	return t.marketData.GetKlines(symbol, interval, limit)
+}
+
+func (t *liveAgentTools) GetOrderBook(symbol string, limit int) (*market.OrderBook, error) {
+	return t.api.GetOrderBook(symbol, limit)
+}
+
+func (t *liveAgentTools) GetFundingHistory(symbol string, limit int) ([]market.FundingRatePoint, error) {
+	end := time.Now()
+	points, err := market.GetFundingRateHistory(symbol, end.Add(-time.Duration(limit+1)*fundingInterval), end)
+	if err != nil {
+		return nil, err
+	}
+	return points[max(0, len(points)-limit):], nil
+}
+
+func (t *liveAgentTools) GetRecentFills(symbol string, limit int) ([]decision.FillInfo, error) {
+	fills, err := FetchFills(t.trader, symbol, limit)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(fills, func(i, j int) bool { return fills[i].Time.Before(fills[j].Time) })
+
+	result := make([]decision.FillInfo, 0, len(fills))
+	for _, f := range fills {
+		result = append(result, decision.FillInfo{
+			Symbol:      f.Symbol,
+			Side:        f.Side,
+			Price:       f.Price,
+			Quantity:    f.Quantity,
+			Fee:         f.Fee,
+			RealizedPnL: f.RealizedPnL,
+			Time:        f.Time.UnixMilli(),
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,32 @@
+package trader
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLiveAgentTools_GetRecentFills(t *testing.T) {
+	prices := map[string]float64{"BTCUSDT": 100, "ETHUSDT": 10}
+	pt := newTestPaperTrader(t, nil, prices)
+	_, err := pt.OpenLong("BTCUSDT", 1, 5)
+	require.NoError(t, err)
+	_, err = pt.OpenShort("ETHUSDT", 2, 5)
+	require.NoError(t, err)
+	prices["BTCUSDT"] = 110
+	_, err = pt.CloseLong("BTCUSDT", 0)
+	require.NoError(t, err)
+
+	tools := newLiveAgentTools(pt, nil)
+	fills, err := tools.GetRecentFills("BTCUSDT", 10)
+	require.NoError(t, err)
+	require.Len(t, fills, 2)
+	assert.Equal(t, "BTCUSDT", fills[0].Symbol)
+	assert.LessOrEqual(t, fills[0].Time, fills[1].Time, "成交记录应按时间升序返回")
+	assert.Greater(t, fills[0].RealizedPnL+fills[1].RealizedPnL, 0.0, "平仓成交应带已实现盈亏")
+
+	all, err := tools.GetRecentFills("", 10)
+	require.NoError(t, err)
+	assert.Len(t, all, 3, "未指定币种时返回所有币种")
+}
@@ -0,0 +1,77 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"nofx/decision"
+	"nofx/mcp"
+	"strings"
+	"time"
+)
+
+const (
+	safeModeMaxLossPct    = 10.0 // 安全模式下亏损达到该收益率（百分比）的持仓直接平仓
+	safeModeProfitLockPct = 5.0  // 安全模式下盈利达到该收益率的持仓设置追踪止损锁定利润
+	safeModeCallbackRate  = 2.0  // 安全模式设置追踪止损的回调比例（百分比）
+)
+
+// buildFailoverClient 把主模型和备用模型组成故障转移链。未配置备用模型且未开启安全模式时返回 nil，直接使用主模型
+func buildFailoverClient(config AutoTraderConfig, primary mcp.AIClient) *mcp.FailoverClient {
+	if len(config.FallbackModels) == 0 && !config.AISafeMode {
+		return nil
+	}
+
+	members := []mcp.FailoverMember{{Name: config.AIModel, Client: primary}}
+	for _, model := range config.FallbackModels {
+		name := model.Name
+		if name == "" {
+			name = model.Provider
+		}
+		members = append(members, mcp.FailoverMember{Name: name, Client: newEnsembleClient(model)})
+	}
+
+	names := make([]string, 0, len(members))
+	for _, m := range members {
+		names = append(names, m.Name)
+	}
+	log.Printf("🔀 [%s] 启用AI故障转移: %s（安全模式: %v）", config.Name, strings.Join(names, " → "), config.AISafeMode)
+	return mcp.NewFailoverClient(members...)
+}
+
+// answeredModel 返回本周期实际给出回答的模型（故障转移时可能是备用模型，多个时用逗号分隔）
+func (at *AutoTrader) answeredModel() string {
+	if at.failover == nil {
+		return at.aiModel
+	}
+	return strings.Join(at.failover.TakeAnswered(), ",")
+}
+
+// safeModeDecision 所有AI模型都不可用时的规则化决策：不开新仓，只管理现有持仓。
+// 亏损超过 safeModeMaxLossPct 的持仓平仓，盈利超过 safeModeProfitLockPct 且没有追踪止损的持仓设置追踪止损
+func (at *AutoTrader) safeModeDecision(ctx *decision.Context, cause error) *decision.FullDecision {
+	var decisions []decision.Decision
+	for _, pos := range ctx.Positions {
+		switch {
+		case pos.UnrealizedPnLPct <= -safeModeMaxLossPct:
+			decisions = append(decisions, decision.Decision{
+				Symbol:    pos.Symbol,
+				Action:    "close_" + pos.Side,
+				Reasoning: fmt.Sprintf("安全模式：亏损 %.2f%% 超过 %.0f%%，平仓控制风险", pos.UnrealizedPnLPct, safeModeMaxLossPct),
+			})
+		case pos.UnrealizedPnLPct >= safeModeProfitLockPct && !at.hasTrailingStop(pos.Symbol, pos.Side):
+			decisions = append(decisions, decision.Decision{
+				Symbol:       pos.Symbol,
+				Action:       "set_trailing_stop",
+				CallbackRate: safeModeCallbackRate,
+				Reasoning:    fmt.Sprintf("安全模式：盈利 %.2f%%，设置 %.1f%% 追踪止损锁定利润", pos.UnrealizedPnLPct, safeModeCallbackRate),
+			})
+		}
+	}
+
+	return &decision.FullDecision{
+		CoTTrace: fmt.Sprintf("所有AI模型均不可用，进入安全模式：不开新仓，只按规则管理 %d 个现有持仓。\n原因: %v",
+			len(ctx.Positions), cause),
+		Decisions: decisions,
+		Timestamp: time.Now(),
+	}
+}
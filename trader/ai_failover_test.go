@@ -0,0 +1,53 @@
+package trader
+
+import (
+	"errors"
+	"nofx/decision"
+	"nofx/mcp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildFailoverClient(t *testing.T) {
+	primary := mcp.New()
+	assert.Nil(t, buildFailoverClient(AutoTraderConfig{AIModel: "deepseek"}, primary), "未配置备用模型且未开启安全模式时不启用")
+
+	fc := buildFailoverClient(AutoTraderConfig{
+		AIModel:        "deepseek",
+		FallbackModels: []EnsembleModelConfig{{Provider: "qwen"}, {Name: "claude", Provider: mcp.ProviderAnthropic}},
+	}, primary)
+	if assert.NotNil(t, fc) {
+		members := fc.Members()
+		assert.Equal(t, []string{"deepseek", "qwen", "claude"}, []string{members[0].Name, members[1].Name, members[2].Name})
+		assert.Same(t, primary, members[0].Client, "主模型应排在第一位")
+	}
+
+	assert.NotNil(t, buildFailoverClient(AutoTraderConfig{AIModel: "deepseek", AISafeMode: true}, primary),
+		"只开启安全模式时也需要故障转移链识别所有模型不可用")
+}
+
+func TestAutoTrader_SafeModeDecision(t *testing.T) {
+	at := &AutoTrader{name: "test", trailingStops: map[string]*TrailingStop{
+		pendingOrderKey("SOLUSDT", "long"): {Symbol: "SOLUSDT", Side: "long"},
+	}}
+	ctx := &decision.Context{Positions: []decision.PositionInfo{
+		{Symbol: "BTCUSDT", Side: "long", UnrealizedPnLPct: -12},
+		{Symbol: "ETHUSDT", Side: "short", UnrealizedPnLPct: 8},
+		{Symbol: "SOLUSDT", Side: "long", UnrealizedPnLPct: 15},
+		{Symbol: "BNBUSDT", Side: "long", UnrealizedPnLPct: 1},
+	}}
+
+	full := at.safeModeDecision(ctx, errors.New("all down"))
+	if assert.Len(t, full.Decisions, 2) {
+		assert.Equal(t, "BTCUSDT", full.Decisions[0].Symbol)
+		assert.Equal(t, "close_long", full.Decisions[0].Action)
+		assert.Equal(t, "ETHUSDT", full.Decisions[1].Symbol)
+		assert.Equal(t, "set_trailing_stop", full.Decisions[1].Action)
+		assert.Equal(t, safeModeCallbackRate, full.Decisions[1].CallbackRate)
+	}
+	for _, d := range full.Decisions {
+		assert.NotContains(t, []string{"open_long", "open_short"}, d.Action, "安全模式不开新仓")
+	}
+	assert.Contains(t, full.CoTTrace, "all down")
+}
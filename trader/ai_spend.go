@@ -0,0 +1,99 @@
+package trader
+
+import (
+	"log"
+	"nofx/logger"
+	"nofx/mcp"
+	"sync"
+	"time"
+)
+
+// aiSpendTracker 按自然月（UTC）累计 AI 费用，跨月后自动清零（零值可用）
+type aiSpendTracker struct {
+	mu         sync.Mutex
+	monthStart time.Time
+	cost       float64
+}
+
+// monthStartOf 返回 now 所在自然月的起点（UTC）
+func monthStartOf(now time.Time) time.Time {
+	now = now.UTC()
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// rollLocked 跨过月初时清零
+func (t *aiSpendTracker) rollLocked(now time.Time) {
+	if start := monthStartOf(now); !start.Equal(t.monthStart) {
+		t.monthStart = start
+		t.cost = 0
+	}
+}
+
+// restore 用决策记录中统计的本月费用恢复累计值（重启后继续生效）
+func (t *aiSpendTracker) restore(cost float64, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rollLocked(now)
+	t.cost = cost
+}
+
+// add 累加一次费用，返回本月累计费用
+func (t *aiSpendTracker) add(cost float64, now time.Time) float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rollLocked(now)
+	t.cost += cost
+	return t.cost
+}
+
+// monthCost 返回本月累计费用
+func (t *aiSpendTracker) monthCost(now time.Time) float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rollLocked(now)
+	return t.cost
+}
+
+// attachUsageMeter 让主模型和集成成员的每次 AI 调用都把用量记入 aiUsage，
+// 并从决策记录中恢复本月已花费的费用
+func (at *AutoTrader) attachUsageMeter() {
+	clients := []mcp.AIClient{at.mcpClient}
+	for _, member := range at.ensembleMembers {
+		clients = append(clients, member.Client)
+	}
+	for _, client := range clients {
+		if reporter, ok := client.(mcp.UsageReporter); ok {
+			reporter.SetUsageHandler(at.aiUsage.Record)
+		}
+	}
+
+	if at.decisionLogger == nil {
+		return
+	}
+	if stats, err := at.decisionLogger.GetStatistics(); err == nil && stats != nil {
+		at.aiSpend.restore(stats.AIMonthCostUSD, time.Now())
+	}
+}
+
+// aiSpendCapReached 判断本月 AI 费用是否已达到上限，同时返回本月已花费的费用
+func (at *AutoTrader) aiSpendCapReached(now time.Time) (bool, float64) {
+	spent := at.aiSpend.monthCost(now)
+	return at.config.MonthlyAISpendCap > 0 && spent >= at.config.MonthlyAISpendCap, spent
+}
+
+// recordAIUsage 把本周期的 AI 用量写入决策记录并累计到本月费用
+func (at *AutoTrader) recordAIUsage(record *logger.DecisionRecord, now time.Time) {
+	usage := at.aiUsage.Take()
+	if usage.Calls == 0 {
+		return
+	}
+	aiUsage := logger.AIUsage(usage)
+	record.AIUsage = &aiUsage
+
+	spent := at.aiSpend.add(usage.CostUSD, now)
+	log.Printf("💵 AI 用量: %d 次调用 | 输入 %d / 输出 %d token（思考 %d）| $%.4f，本月累计 $%.2f",
+		usage.Calls, usage.PromptTokens, usage.CompletionTokens, usage.ReasoningTokens, usage.CostUSD, spent)
+	if capUSD := at.config.MonthlyAISpendCap; capUSD > 0 && spent >= capUSD {
+		log.Printf("⚠️ [%s] 本月 AI 费用 $%.2f 已达上限 $%.2f，下个周期起暂停决策", at.name, spent, capUSD)
+	}
+}
@@ -0,0 +1,51 @@
+package trader
+
+import (
+	"nofx/logger"
+	"nofx/mcp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAISpendTracker_RollsOverMonthly(t *testing.T) {
+	var tracker aiSpendTracker
+	oct := time.Date(2025, 10, 31, 23, 0, 0, 0, time.UTC)
+
+	tracker.restore(4, oct)
+	assert.InDelta(t, 5.5, tracker.add(1.5, oct), 1e-9)
+	assert.InDelta(t, 0, tracker.monthCost(oct.Add(2*time.Hour)), 1e-9, "新的自然月应重新计费")
+}
+
+func TestAutoTrader_RecordAIUsageAndSpendCap(t *testing.T) {
+	at := &AutoTrader{name: "test", config: AutoTraderConfig{MonthlyAISpendCap: 1}}
+	now := time.Date(2025, 10, 15, 12, 0, 0, 0, time.UTC)
+
+	record := &logger.DecisionRecord{}
+	at.recordAIUsage(record, now)
+	assert.Nil(t, record.AIUsage, "没有 AI 调用时不记录用量")
+
+	at.aiUsage.Record(mcp.Usage{Calls: 1, PromptTokens: 1000, CompletionTokens: 200, CostUSD: 0.6})
+	at.aiUsage.Record(mcp.Usage{Calls: 1, PromptTokens: 500, CompletionTokens: 100, CostUSD: 0.3})
+	at.recordAIUsage(record, now)
+	if assert.NotNil(t, record.AIUsage) {
+		assert.Equal(t, 2, record.AIUsage.Calls)
+		assert.Equal(t, 1500, record.AIUsage.PromptTokens)
+	}
+	reached, spent := at.aiSpendCapReached(now)
+	assert.False(t, reached)
+	assert.InDelta(t, 0.9, spent, 1e-9)
+
+	at.aiUsage.Record(mcp.Usage{Calls: 1, CostUSD: 0.2})
+	at.recordAIUsage(&logger.DecisionRecord{}, now)
+	reached, _ = at.aiSpendCapReached(now)
+	assert.True(t, reached, "本月费用达到上限后应暂停")
+
+	reached, _ = at.aiSpendCapReached(now.AddDate(0, 1, 0))
+	assert.False(t, reached, "下个月恢复决策")
+
+	at.config.MonthlyAISpendCap = 0
+	reached, _ = at.aiSpendCapReached(now)
+	assert.False(t, reached, "未设置上限时不暂停")
+}
@@ -736,6 +736,124 @@ func (t *AsterTrader) OpenShort(symbol string, quantity float64, leverage int) (
 	return result, nil
 }
 
+// OpenLimitOrder 限价开仓（timeInForce: GTC/IOC/GTX）
+func (t *AsterTrader) OpenLimitOrder(symbol, side string, quantity float64, leverage int, price float64, timeInForce string) (map[string]interface{}, error) {
+	// 检查该代币是否在半小时内已平仓
+	t.closeTimeMu.RLock()
+	closeTime, exists := t.lastCloseTime[symbol]
+	t.closeTimeMu.RUnlock()
+	if exists && time.Since(closeTime) < 30*time.Minute {
+		return nil, fmt.Errorf("❌ %s 在 %.0f 分钟前刚平仓，拒绝开仓。请等待至少 30 分钟后再试",
+			symbol, time.Since(closeTime).Minutes())
+	}
+
+	if err := t.SetLeverage(symbol, leverage); err != nil {
+		return nil, fmt.Errorf("设置杠杆失败: %w", err)
+	}
+
+	formattedPrice, err := t.formatPrice(symbol, price)
+	if err != nil {
+		return nil, err
+	}
+	formattedQty, err := t.formatQuantity(symbol, quantity)
+	if err != nil {
+		return nil, err
+	}
+	prec, err := t.getPrecision(symbol)
+	if err != nil {
+		return nil, err
+	}
+	priceStr := t.formatFloatWithPrecision(formattedPrice, prec.PricePrecision)
+	qtyStr := t.formatFloatWithPrecision(formattedQty, prec.QuantityPrecision)
+
+	orderSide := "BUY"
+	if side == "short" {
+		orderSide = "SELL"
+	}
+	if timeInForce == "" {
+		timeInForce = TimeInForceGTC
+	}
+
+	params := map[string]interface{}{
+		"symbol":       symbol,
+		"positionSide": "BOTH",
+		"type":         "LIMIT",
+		"side":         orderSide,
+		"timeInForce":  timeInForce,
+		"quantity":     qtyStr,
+		"price":        priceStr,
+	}
+
+	body, err := t.request("POST", "/fapi/v3/order", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+
+	log.Printf("✓ 限价单已提交: %s %s 数量: %s 价格: %s (%s)", symbol, side, qtyStr, priceStr, timeInForce)
+	return result, nil
+}
+
+// GetOrder 查询订单状态
+func (t *AsterTrader) GetOrder(symbol, orderID string) (map[string]interface{}, error) {
+	id, err := strconv.ParseInt(orderID, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("无效的订单ID: %s", orderID)
+	}
+
+	body, err := t.request("GET", "/fapi/v3/order", map[string]interface{}{
+		"symbol":  symbol,
+		"orderId": id,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("查询订单失败: %w", err)
+	}
+
+	var order map[string]interface{}
+	if err := json.Unmarshal(body, &order); err != nil {
+		return nil, fmt.Errorf("解析订单数据失败: %w", err)
+	}
+
+	status, _ := order["status"].(string)
+	origQty, _ := SafeFloat64(order, "origQty")
+	executedQty, _ := SafeFloat64(order, "executedQty")
+	price, _ := SafeFloat64(order, "avgPrice")
+	if price == 0 {
+		price, _ = SafeFloat64(order, "price")
+	}
+
+	return map[string]interface{}{
+		"orderId":     id,
+		"symbol":      symbol,
+		"status":      NormalizeOrderStatus(status),
+		"price":       price,
+		"qty":         origQty,
+		"executedQty": executedQty,
+	}, nil
+}
+
+// CancelOrder 取消单个订单
+func (t *AsterTrader) CancelOrder(symbol, orderID string) error {
+	id, err := strconv.ParseInt(orderID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("无效的订单ID: %s", orderID)
+	}
+
+	if _, err := t.request("DELETE", "/fapi/v1/order", map[string]interface{}{
+		"symbol":  symbol,
+		"orderId": id,
+	}); err != nil {
+		return fmt.Errorf("取消订单失败: %w", err)
+	}
+
+	log.Printf("  ✓ 已取消订单 %s (%s)", orderID, symbol)
+	return nil
+}
+
 // CloseLong 平多单
 func (t *AsterTrader) CloseLong(symbol string, quantity float64) (map[string]interface{}, error) {
 	// 如果数量为0，获取当前持仓数量
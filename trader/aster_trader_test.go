@@ -237,6 +237,17 @@ func TestAsterTrader_CommonInterface(t *testing.T) {
 	suite.RunAllTests()
 }
 
+// TestAsterTrader_DomainContract 通过 mock 服务器走真实解析路径校验领域模型契约
+// Aster 适配器尚未实现 GetTradeHistory，成交记录不在校验范围内
+func TestAsterTrader_DomainContract(t *testing.T) {
+	suite := NewAsterTraderTestSuite(t)
+	defer suite.Cleanup()
+
+	AssertDomainContract(t, DomainContractCase{
+		Exchange: "aster", Trader: suite.Trader, Symbol: "BTCUSDT", Quantity: 0.01, Price: 49000,
+	})
+}
+
 // ============================================================
 // 三、Aster 特定功能的单元测试
 // ============================================================
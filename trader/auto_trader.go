@@ -2,6 +2,7 @@ package trader
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"math"
@@ -10,7 +11,6 @@ import (
 	"nofx/market"
 	"nofx/mcp"
 	"nofx/pool"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -21,10 +21,10 @@ type AutoTraderConfig struct {
 	// Trader标识
 	ID      string // Trader唯一标识（用于日志目录等）
 	Name    string // Trader显示名称
-	AIModel string // AI模型: "qwen" 或 "deepseek"
+	AIModel string // AI模型: "qwen"、"deepseek"、"anthropic"、"gemini" 或 "custom"
 
 	// 交易平台选择
-	Exchange string // "binance", "bybit", "hyperliquid", "aster", "lighter" 或 "gate"
+	Exchange string // "binance", "bybit", "okx", "bitget", "hyperliquid", "aster", "lighter", "gate" 或 "paper"
 
 	// 币安API配置
 	BinanceAPIKey    string
@@ -34,6 +34,18 @@ type AutoTraderConfig struct {
 	BybitAPIKey    string
 	BybitSecretKey string
 
+	// OKX API配置
+	OKXAPIKey     string
+	OKXSecretKey  string
+	OKXPassphrase string
+	OKXTestnet    bool // 使用OKX模拟盘
+
+	// Bitget API配置
+	BitgetAPIKey     string
+	BitgetSecretKey  string
+	BitgetPassphrase string
+	BitgetTestnet    bool // 使用Bitget模拟盘
+
 	// Hyperliquid配置
 	HyperliquidPrivateKey string
 	HyperliquidWalletAddr string
@@ -57,9 +69,11 @@ type AutoTraderConfig struct {
 	CoinPoolAPIURL string
 
 	// AI配置
-	UseQwen     bool
-	DeepSeekKey string
-	QwenKey     string
+	UseQwen      bool
+	DeepSeekKey  string
+	QwenKey      string
+	AnthropicKey string
+	GeminiKey    string
 
 	// 自定义AI API配置
 	CustomAPIURL    string
@@ -76,54 +90,112 @@ type AutoTraderConfig struct {
 	BTCETHLeverage  int // BTC和ETH的杠杆倍数
 	AltcoinLeverage int // 山寨币的杠杆倍数
 
-	// 风险控制（仅作为提示，AI可自主决定）
-	MaxDailyLoss    float64       // 最大日亏损百分比（提示）
-	MaxDrawdown     float64       // 最大回撤百分比（提示）
-	StopTradingTime time.Duration // 触发风控后暂停时长
+	// 风险控制（硬性熔断，超限后暂停交易）
+	MaxDailyLoss       float64       // 最大日亏损百分比（相对当日起始净值，含未实现盈亏），0 表示不限制
+	MaxDrawdown        float64       // 最大回撤百分比（相对净值高水位），0 表示不限制
+	StopTradingTime    time.Duration // 触发风控后暂停时长
+	RiskClosePositions bool          // 触发风控时平掉所有持仓
+	DailyResetTimezone string        // 日盈亏重置使用的时区（IANA 名称，如 "Asia/Shanghai"），默认 UTC
+
+	// AI 费用控制
+	MonthlyAISpendCap float64 // 每月（UTC 自然月）AI 调用费用上限（美元），达到后暂停决策至下月，0 表示不限制
 
 	// 仓位模式
 	IsCrossMargin bool // true=全仓模式, false=逐仓模式
 
+	// 行情数据源
+	MarketDataFallback string // 交易所专用行情失败时的备用数据源："binance"（默认）| "none"
+
+	// 流动性检查（按订单簿估算市价开仓滑点）
+	MaxSlippagePct  float64 // 市价开仓的最大预计滑点（%，相对中间价），0 表示不检查
+	LiquidityAction string  // 超过滑点上限时的处理："shrink"（默认，缩小仓位）| "reject"（拒绝开仓，订单簿不可用时同样拒绝）
+
 	// 币种配置
 	DefaultCoins []string // 默认币种列表（从数据库获取）
 	TradingCoins []string // 实际交易币种列表
 
 	// 系统提示词模板
 	SystemPromptTemplate string // 系统提示词模板名称（如 "default", "aggressive"）
+
+	// 限价单配置
+	PendingOrderMaxCycles int // 限价开仓单最多挂单的决策周期数，超过后自动撤单（默认3）
+
+	// 分批止盈配置
+	TakeProfitLadder  []decision.TakeProfitLadderStep // 分批止盈模板，AI 未给出 take_profit_levels 时使用
+	BreakevenAfterTP1 bool                            // 第一档止盈成交后把止损移到开仓价
+
+	// 决策输出方式
+	DecisionMode string // "text"（默认，解析文本中的JSON）| "tool_call"（通过 submit_decisions 函数调用提交）| "agent"（先调用只读数据工具再提交）
+
+	// 多模型集成决策（为空时只使用主模型）
+	EnsembleModels []EnsembleModelConfig // 参与投票的其他模型，主模型始终参与
+	EnsembleVote   string                // 投票方式: majority（默认）| confidence_weighted | unanimous
+
+	// AI 故障转移（主模型连续失败时按顺序转到备用模型）
+	FallbackModels []EnsembleModelConfig // 备用模型，按尝试顺序排列
+	AISafeMode     bool                  // 所有模型都不可用时进入安全模式，只管理现有持仓
+
+	// 决策校验规则链（零值为默认规则）
+	ValidationConfig decision.ValidationConfig
+
+	// 提示词 A/B 实验（nil 表示不开启）
+	PromptExperiment *decision.PromptExperiment
 }
 
 // AutoTrader 自动交易器
 type AutoTrader struct {
-	id                    string // Trader唯一标识
-	name                  string // Trader显示名称
-	aiModel               string // AI模型名称
-	exchange              string // 交易平台名称
-	config                AutoTraderConfig
-	trader                Trader // 使用Trader接口（支持多平台）
-	mcpClient             mcp.AIClient
-	decisionLogger        logger.IDecisionLogger // 决策日志记录器
-	initialBalance        float64
-	dailyPnL              float64
-	customPrompt          string   // 自定义交易策略prompt
-	overrideBasePrompt    bool     // 是否覆盖基础prompt
-	systemPromptTemplate  string   // 系统提示词模板名称
-	defaultCoins          []string // 默认币种列表（从数据库获取）
-	tradingCoins          []string // 实际交易币种列表
-	lastResetTime         time.Time
-	stopUntil             time.Time
-	isRunning             bool
-	startTime             time.Time            // 系统启动时间
-	callCount             int                  // AI调用次数
-	positionFirstSeenTime map[string]int64     // 持仓首次出现时间 (symbol_side -> timestamp毫秒)
-	stopMonitorCh         chan struct{}        // 用于停止监控goroutine
-	monitorWg             sync.WaitGroup       // 用于等待监控goroutine结束
-	peakPnLCache          map[string]float64   // 最高收益缓存 (symbol -> 峰值盈亏百分比)
-	peakPnLCacheMutex     sync.RWMutex         // 缓存读写锁
-	lastBalanceSyncTime   time.Time            // 上次余额同步时间
-	database              interface{}          // 数据库引用（用于自动更新余额）
-	userID                string               // 用户ID
-	lastCloseTime         map[string]time.Time // 记录每个代币的最后平仓时间 (symbol -> timestamp)
-	lastCloseTimeMutex    sync.RWMutex         // 平仓时间缓存读写锁
+	id                     string // Trader唯一标识
+	name                   string // Trader显示名称
+	aiModel                string // AI模型名称
+	exchange               string // 交易平台名称
+	config                 AutoTraderConfig
+	trader                 Trader // 使用Trader接口（支持多平台）
+	mcpClient              mcp.AIClient
+	decisionLogger         logger.IDecisionLogger // 决策日志记录器
+	initialBalance         float64
+	dailyPnL               float64
+	customPrompt           string   // 自定义交易策略prompt
+	overrideBasePrompt     bool     // 是否覆盖基础prompt
+	systemPromptTemplate   string   // 系统提示词模板名称
+	defaultCoins           []string // 默认币种列表（从数据库获取）
+	tradingCoins           []string // 实际交易币种列表
+	lastResetTime          time.Time
+	stopUntil              time.Time
+	isRunning              bool
+	startTime              time.Time                    // 系统启动时间
+	callCount              int                          // AI调用次数
+	positionFirstSeenTime  map[string]int64             // 持仓首次出现时间 (symbol_side -> timestamp毫秒)
+	positionFirstSeenMutex sync.Mutex                   // 持仓首次出现时间读写锁
+	stopMonitorCh          chan struct{}                // 用于停止监控goroutine
+	monitorWg              sync.WaitGroup               // 用于等待监控goroutine结束
+	peakPnLCache           map[string]float64           // 最高收益缓存 (symbol -> 峰值盈亏百分比)
+	peakPnLCacheMutex      sync.RWMutex                 // 缓存读写锁
+	lastBalanceSyncTime    time.Time                    // 上次余额同步时间
+	database               interface{}                  // 数据库引用（用于自动更新余额）
+	userID                 string                       // 用户ID
+	lastCloseTime          map[string]time.Time         // 记录每个代币的最后平仓时间 (symbol -> timestamp)
+	lastCloseTimeMutex     sync.RWMutex                 // 平仓时间缓存读写锁
+	pendingOrders          map[string]*pendingOrder     // 未成交的限价开仓单 (symbol_side -> 订单)
+	pendingOrdersMutex     sync.Mutex                   // 限价单读写锁
+	trailingStops          map[string]*TrailingStop     // 追踪止损 (symbol_side -> 追踪止损)
+	trailingStopsMutex     sync.Mutex                   // 追踪止损读写锁
+	trailingStopStore      TrailingStopStore            // 追踪止损持久化（重启后恢复）
+	pendingOrderStore      PendingOrderStore            // 限价挂单持久化（重启后继续跟踪成交）
+	takeProfitLadderStore  TakeProfitLadderStore        // 分批止盈持久化（重启后继续识别档位成交）
+	takeProfitLadders      map[string]*takeProfitLadder // 分批止盈 (symbol_side -> 档位状态)
+	takeProfitLaddersMutex sync.Mutex                   // 分批止盈读写锁
+	dayStartEquity         float64                      // 当日起始净值（日亏损熔断基准）
+	equityHighWaterMark    float64                      // 净值高水位（最大回撤熔断基准）
+	riskLocation           *time.Location               // 日盈亏重置时区
+	riskMutex              sync.Mutex                   // 保护日盈亏、高水位、交易日起点和暂停截止时间
+	ensembleMembers        []decision.EnsembleMember    // 集成决策成员（含主模型），为空时只用主模型决策
+	failover               *mcp.FailoverClient          // AI 故障转移链（含主模型），未启用时为 nil
+	marketData             market.MarketDataProvider    // 与交易所一致的行情数据源（K线、价格、资金费率、持仓量）
+	promptRevisionStore    PromptRevisionStore          // 提示词版本持久化
+	savedPromptRevisions   map[string]bool              // 已保存的提示词版本哈希
+	liveThinking           liveThinkingBuffer           // 当前周期 AI 的流式输出（实时展示思考过程）
+	aiUsage                mcp.UsageMeter               // 当前周期所有 AI 调用的 token 用量与费用
+	aiSpend                aiSpendTracker               // 本月累计 AI 费用（月度费用上限）
 }
 
 // NewAutoTrader 创建自动交易器
@@ -159,6 +231,24 @@ func NewAutoTrader(config AutoTraderConfig, database interface{}, userID string)
 		} else {
 			log.Printf("🤖 [%s] 使用阿里云Qwen AI", config.Name)
 		}
+	} else if config.AIModel == mcp.ProviderAnthropic {
+		// 使用Anthropic Messages API (支持自定义URL和Model)
+		mcpClient = mcp.NewAnthropicClient()
+		mcpClient.SetAPIKey(config.AnthropicKey, config.CustomAPIURL, config.CustomModelName)
+		if config.CustomAPIURL != "" || config.CustomModelName != "" {
+			log.Printf("🤖 [%s] 使用Anthropic Claude (自定义URL: %s, 模型: %s)", config.Name, config.CustomAPIURL, config.CustomModelName)
+		} else {
+			log.Printf("🤖 [%s] 使用Anthropic Claude", config.Name)
+		}
+	} else if config.AIModel == mcp.ProviderGemini {
+		// 使用Google Gemini (支持自定义URL和Model)
+		mcpClient = mcp.NewGeminiClient()
+		mcpClient.SetAPIKey(config.GeminiKey, config.CustomAPIURL, config.CustomModelName)
+		if config.CustomAPIURL != "" || config.CustomModelName != "" {
+			log.Printf("🤖 [%s] 使用Google Gemini (自定义URL: %s, 模型: %s)", config.Name, config.CustomAPIURL, config.CustomModelName)
+		} else {
+			log.Printf("🤖 [%s] 使用Google Gemini", config.Name)
+		}
 	} else {
 		// 默认使用DeepSeek (支持自定义URL和Model)
 		mcpClient = mcp.NewDeepSeekClient()
@@ -198,6 +288,12 @@ func NewAutoTrader(config AutoTraderConfig, database interface{}, userID string)
 	case "bybit":
 		log.Printf("🏦 [%s] 使用Bybit合约交易", config.Name)
 		trader = NewBybitTrader(config.BybitAPIKey, config.BybitSecretKey)
+	case "okx":
+		log.Printf("🏦 [%s] 使用OKX合约交易", config.Name)
+		trader = NewOKXTrader(config.OKXAPIKey, config.OKXSecretKey, config.OKXPassphrase, config.OKXTestnet)
+	case "bitget":
+		log.Printf("🏦 [%s] 使用Bitget合约交易", config.Name)
+		trader = NewBitgetTrader(config.BitgetAPIKey, config.BitgetSecretKey, config.BitgetPassphrase, config.BitgetTestnet)
 	case "hyperliquid":
 		log.Printf("🏦 [%s] 使用Hyperliquid交易", config.Name)
 		trader, err = NewHyperliquidTrader(config.HyperliquidPrivateKey, config.HyperliquidWalletAddr, config.HyperliquidTestnet)
@@ -236,6 +332,13 @@ func NewAutoTrader(config AutoTraderConfig, database interface{}, userID string)
 	case "gate":
 		log.Printf("🏦 [%s] 使用Gate.io合约交易", config.Name)
 		trader = NewGateFuturesTrader(config.GateAPIKey, config.GateSecretKey, userID)
+	case "paper":
+		log.Printf("🏦 [%s] 使用模拟盘交易（实时行情撮合，不下真实订单）", config.Name)
+		store, _ := database.(PaperStateStore)
+		trader, err = NewPaperTrader(config.ID, userID, config.InitialBalance, store)
+		if err != nil {
+			return nil, fmt.Errorf("初始化模拟盘交易器失败: %w", err)
+		}
 	default:
 		return nil, fmt.Errorf("不支持的交易平台: %s", config.Exchange)
 	}
@@ -256,7 +359,18 @@ func NewAutoTrader(config AutoTraderConfig, database interface{}, userID string)
 		systemPromptTemplate = "adaptive"
 	}
 
-	return &AutoTrader{
+	trailingStopStore, _ := database.(TrailingStopStore)
+	pendingOrderStore, _ := database.(PendingOrderStore)
+	takeProfitLadderStore, _ := database.(TakeProfitLadderStore)
+	riskLocation := loadRiskLocation(config.DailyResetTimezone)
+
+	// 配置了备用模型时由故障转移链代替主模型（集成决策中的主模型同样使用故障转移链）
+	failover := buildFailoverClient(config, mcpClient)
+	if failover != nil {
+		mcpClient = failover
+	}
+
+	at := &AutoTrader{
 		id:                    config.ID,
 		name:                  config.Name,
 		aiModel:               config.AIModel,
@@ -269,7 +383,8 @@ func NewAutoTrader(config AutoTraderConfig, database interface{}, userID string)
 		systemPromptTemplate:  systemPromptTemplate,
 		defaultCoins:          config.DefaultCoins,
 		tradingCoins:          config.TradingCoins,
-		lastResetTime:         time.Now(),
+		riskLocation:          riskLocation,
+		lastResetTime:         tradingDayStart(time.Now(), riskLocation),
 		startTime:             time.Now(),
 		callCount:             0,
 		isRunning:             false,
@@ -281,9 +396,27 @@ func NewAutoTrader(config AutoTraderConfig, database interface{}, userID string)
 		lastBalanceSyncTime:   time.Now(), // 初始化为当前时间
 		lastCloseTime:         make(map[string]time.Time),
 		lastCloseTimeMutex:    sync.RWMutex{},
+		pendingOrders:         make(map[string]*pendingOrder),
+		trailingStops:         make(map[string]*TrailingStop),
+		trailingStopStore:     trailingStopStore,
+		pendingOrderStore:     pendingOrderStore,
+		takeProfitLadderStore: takeProfitLadderStore,
+		takeProfitLadders:     make(map[string]*takeProfitLadder),
+		failover:              failover,
+		marketData:            market.ProviderForExchange(config.Exchange, config.MarketDataFallback),
 		database:              database,
 		userID:                userID,
-	}, nil
+	}
+	at.ensembleMembers = buildEnsembleMembers(config, mcpClient)
+	log.Printf("📈 [%s] 行情数据源: %s", config.Name, at.marketData.Name())
+	at.attachUsageMeter()
+	at.promptRevisionStore, _ = database.(PromptRevisionStore)
+	at.savedPromptRevisions = make(map[string]bool)
+	at.loadTrailingStops()
+	at.loadPendingOrders()
+	at.loadTakeProfitLadders()
+
+	return at, nil
 }
 
 // Run 运行自动交易主循环
@@ -302,6 +435,22 @@ func (at *AutoTrader) Run() error {
 	// 启动回撤监控
 	at.startDrawdownMonitor()
 
+	// 日亏损和最大回撤熔断
+	at.startRiskMonitor()
+
+	// 模拟盘需要本地监控止盈止损
+	if paper, ok := at.trader.(*PaperTrader); ok {
+		at.startPaperTriggerMonitor(paper)
+	}
+
+	// 不支持原生追踪止损的交易所由本地轮询价格模拟
+	if _, ok := at.trader.(NativeTrailingStopper); !ok {
+		at.startTrailingStopMonitor()
+	}
+
+	// 分批止盈：识别档位成交，为剩余仓位重新设置保护
+	at.startTakeProfitLadderMonitor()
+
 	ticker := time.NewTicker(at.config.ScanInterval)
 	defer ticker.Stop()
 
@@ -350,9 +499,12 @@ func (at *AutoTrader) runCycle() error {
 		Success:      true,
 	}
 
-	// 1. 检查是否需要停止交易
-	if time.Now().Before(at.stopUntil) {
-		remaining := at.stopUntil.Sub(time.Now())
+	// 跟踪未成交的限价单：成交后补设止盈止损，超时撤单（风控或费用暂停期间同样处理）
+	at.processPendingOrders()
+
+	// 1. 检查日亏损/最大回撤（跨过交易日零点时重置日盈亏），超限或暂停中则停止交易
+	if at.checkRiskLimits() {
+		remaining := time.Until(at.riskPausedUntil())
 		log.Printf("⏸ 风险控制：暂停交易中，剩余 %.0f 分钟", remaining.Minutes())
 		record.Success = false
 		record.ErrorMessage = fmt.Sprintf("风险控制暂停中，剩余 %.0f 分钟", remaining.Minutes())
@@ -360,11 +512,13 @@ func (at *AutoTrader) runCycle() error {
 		return nil
 	}
 
-	// 2. 重置日盈亏（每天重置）
-	if time.Since(at.lastResetTime) > 24*time.Hour {
-		at.dailyPnL = 0
-		at.lastResetTime = time.Now()
-		log.Println("📅 日盈亏已重置")
+	// 2. 检查本月 AI 费用，达到上限后暂停决策（不再调用 AI）
+	if reached, spent := at.aiSpendCapReached(time.Now()); reached {
+		log.Printf("⏸ AI 费用控制：本月已花费 $%.2f，达到上限 $%.2f，暂停决策至下月", spent, at.config.MonthlyAISpendCap)
+		record.Success = false
+		record.ErrorMessage = fmt.Sprintf("AI 月度费用 $%.2f 已达上限 $%.2f，暂停决策至下月", spent, at.config.MonthlyAISpendCap)
+		at.decisionLogger.LogDecision(record)
+		return nil
 	}
 
 	// 3. 收集交易上下文
@@ -376,7 +530,11 @@ func (at *AutoTrader) runCycle() error {
 		return fmt.Errorf("构建交易上下文失败: %w", err)
 	}
 
+	// 清理已平仓持仓的追踪止损
+	at.pruneTrailingStops(ctx.Positions)
+
 	// 4. 检查并执行收益率策略：如果收益率超过80%，然后降到40%以下则立即平仓
+	// 已设置追踪止损的持仓由追踪止损负责保护利润，不再适用该规则
 	for _, pos := range ctx.Positions {
 		// 更新峰值收益率缓存
 		posKey := pos.Symbol + "_" + pos.Side
@@ -389,7 +547,7 @@ func (at *AutoTrader) runCycle() error {
 		at.peakPnLCacheMutex.Unlock()
 
 		// 检查策略条件：峰值超过80%，当前低于40%
-		if peakPnlPct >= 80.0 && pos.UnrealizedPnLPct < 40.0 {
+		if peakPnlPct >= 80.0 && pos.UnrealizedPnLPct < 40.0 && !at.hasTrailingStop(pos.Symbol, pos.Side) {
 			log.Printf("⚠️  触发收益率保护策略：持仓 %s %s 峰值收益率 %.2f%%，当前降到 %.2f%%，执行自动平仓",
 				pos.Symbol, pos.Side, peakPnlPct, pos.UnrealizedPnLPct)
 
@@ -455,7 +613,18 @@ func (at *AutoTrader) runCycle() error {
 
 	// 5. 调用AI获取完整决策
 	log.Printf("🤖 正在请求AI分析并决策... [模板: %s]", at.systemPromptTemplate)
-	decision, err := decision.GetFullDecisionWithCustomPrompt(ctx, at.mcpClient, at.customPrompt, at.overrideBasePrompt, at.systemPromptTemplate)
+	at.liveThinking.start(at.callCount)
+	decision, err := at.requestDecision(ctx, record)
+	at.liveThinking.finish()
+	at.recordAIUsage(record, time.Now())
+	record.AIModel = at.answeredModel()
+
+	if err != nil && at.config.AISafeMode && errors.Is(err, mcp.ErrAllProvidersUnavailable) {
+		log.Printf("⚠️ [%s] 所有AI模型不可用，进入安全模式: %v", at.name, err)
+		record.SafeMode = true
+		record.ExecutionLog = append(record.ExecutionLog, "⚠️ 所有AI模型不可用，进入安全模式：只管理现有持仓")
+		decision, err = at.safeModeDecision(ctx, err), nil
+	}
 
 	if decision != nil && decision.AIRequestDurationMs > 0 {
 		record.AIRequestDurationMs = decision.AIRequestDurationMs
@@ -473,6 +642,11 @@ func (at *AutoTrader) runCycle() error {
 			decisionJSON, _ := json.MarshalIndent(decision.Decisions, "", "  ")
 			record.DecisionJSON = string(decisionJSON)
 		}
+		for _, rejection := range decision.RuleRejections {
+			record.RuleRejections = append(record.RuleRejections, logger.RuleRejection(rejection))
+		}
+		record.PromptTokens = decision.PromptTokens
+		record.PromptTrims = decision.PromptTrims
 	}
 
 	if err != nil {
@@ -548,6 +722,7 @@ func (at *AutoTrader) runCycle() error {
 			Timestamp: time.Now(),
 			Success:   false,
 		}
+		actionRecord.PromptHash = at.promptHashForSymbol(record, d.Symbol)
 
 		if err := at.executeDecisionWithRecord(&d, &actionRecord); err != nil {
 			log.Printf("❌ 执行决策失败 (%s %s): %v", d.Symbol, d.Action, err)
@@ -574,31 +749,17 @@ func (at *AutoTrader) runCycle() error {
 // buildTradingContext 构建交易上下文
 func (at *AutoTrader) buildTradingContext() (*decision.Context, error) {
 	// 1. 获取账户信息
-	balance, err := at.trader.GetBalance()
+	balance, err := FetchBalance(at.trader)
 	if err != nil {
 		return nil, fmt.Errorf("获取账户余额失败: %w", err)
 	}
-
-	// 获取账户字段
-	totalWalletBalance := 0.0
-	totalUnrealizedProfit := 0.0
-	availableBalance := 0.0
-
-	if wallet, ok := balance["totalWalletBalance"].(float64); ok {
-		totalWalletBalance = wallet
-	}
-	if unrealized, ok := balance["totalUnrealizedProfit"].(float64); ok {
-		totalUnrealizedProfit = unrealized
-	}
-	if avail, ok := balance["availableBalance"].(float64); ok {
-		availableBalance = avail
-	}
+	availableBalance := balance.AvailableBalance
 
 	// Total Equity = 钱包余额 + 未实现盈亏
-	totalEquity := totalWalletBalance + totalUnrealizedProfit
+	totalEquity := balance.TotalEquity()
 
 	// 2. 获取持仓信息
-	positions, err := at.trader.GetPositions()
+	positions, err := FetchPositions(at.trader)
 	if err != nil {
 		return nil, fmt.Errorf("获取持仓失败: %w", err)
 	}
@@ -609,28 +770,20 @@ func (at *AutoTrader) buildTradingContext() (*decision.Context, error) {
 	// 当前持仓的key集合（用于清理已平仓的记录）
 	currentPositionKeys := make(map[string]bool)
 
+	// FetchPositions 已跳过数量为0的持仓，防止"幽灵持仓"传递给AI
 	for _, pos := range positions {
-		symbol := pos["symbol"].(string)
-		side := pos["side"].(string)
-		entryPrice := pos["entryPrice"].(float64)
-		markPrice := pos["markPrice"].(float64)
-		quantity := pos["positionAmt"].(float64)
-		if quantity < 0 {
-			quantity = -quantity // 空仓数量为负，转为正数
-		}
-
-		// 跳过已平仓的持仓（quantity = 0），防止"幽灵持仓"传递给AI
-		if quantity == 0 {
-			continue
-		}
-
-		unrealizedPnl := pos["unRealizedProfit"].(float64)
-		liquidationPrice := pos["liquidationPrice"].(float64)
+		symbol := pos.Symbol
+		side := pos.Side
+		entryPrice := pos.EntryPrice
+		markPrice := pos.MarkPrice
+		quantity := pos.Quantity
+		unrealizedPnl := pos.UnrealizedPnL
+		liquidationPrice := pos.LiquidationPrice
 
 		// 计算占用保证金（估算）
 		leverage := 10 // 默认值，实际应该从持仓信息获取
-		if lev, ok := pos["leverage"].(float64); ok {
-			leverage = int(lev)
+		if pos.Leverage > 0 {
+			leverage = pos.Leverage
 		}
 		marginUsed := (quantity * markPrice) / float64(leverage)
 		totalMarginUsed += marginUsed
@@ -641,11 +794,13 @@ func (at *AutoTrader) buildTradingContext() (*decision.Context, error) {
 		// 跟踪持仓首次出现时间
 		posKey := symbol + "_" + side
 		currentPositionKeys[posKey] = true
+		at.positionFirstSeenMutex.Lock()
 		if _, exists := at.positionFirstSeenTime[posKey]; !exists {
 			// 新持仓，记录当前时间
 			at.positionFirstSeenTime[posKey] = time.Now().UnixMilli()
 		}
 		updateTime := at.positionFirstSeenTime[posKey]
+		at.positionFirstSeenMutex.Unlock()
 
 		// 获取该持仓的历史最高收益率
 		at.peakPnLCacheMutex.RLock()
@@ -669,11 +824,13 @@ func (at *AutoTrader) buildTradingContext() (*decision.Context, error) {
 	}
 
 	// 清理已平仓的持仓记录
+	at.positionFirstSeenMutex.Lock()
 	for key := range at.positionFirstSeenTime {
 		if !currentPositionKeys[key] {
 			delete(at.positionFirstSeenTime, key)
 		}
 	}
+	at.positionFirstSeenMutex.Unlock()
 
 	// 3. 获取交易员的候选币种池
 	candidateCoins, err := at.getCandidateCoins()
@@ -712,7 +869,7 @@ func (at *AutoTrader) buildTradingContext() (*decision.Context, error) {
 		Account: decision.AccountInfo{
 			TotalEquity:      totalEquity,
 			AvailableBalance: availableBalance,
-			UnrealizedPnL:    totalUnrealizedProfit,
+			UnrealizedPnL:    balance.TotalUnrealizedProfit,
 			TotalPnL:         totalPnL,
 			TotalPnLPct:      totalPnLPct,
 			MarginUsed:       totalMarginUsed,
@@ -722,11 +879,25 @@ func (at *AutoTrader) buildTradingContext() (*decision.Context, error) {
 		Positions:      positionInfos,
 		CandidateCoins: candidateCoins,
 		Performance:    performance, // 添加历史表现分析
+		DecisionMode:   at.config.DecisionMode,
+		Validation:     at.config.ValidationConfig,
+		MarketData:     at.marketData,
+	}
+	if ctx.DecisionMode == decision.DecisionModeAgent {
+		ctx.AgentTools = newLiveAgentTools(at.trader, at.marketData)
 	}
 
 	return ctx, nil
 }
 
+// getMarketData 从交易员的行情数据源获取市场数据（币安行情直接使用 market.Get）
+func (at *AutoTrader) getMarketData(symbol string) (*market.Data, error) {
+	if at.marketData == nil || at.marketData == market.DefaultProvider() {
+		return market.Get(symbol)
+	}
+	return market.GetWithProvider(symbol, at.marketData)
+}
+
 // executeDecisionWithRecord 执行AI决策并记录详细信息
 func (at *AutoTrader) executeDecisionWithRecord(decision *decision.Decision, actionRecord *logger.DecisionAction) error {
 	switch decision.Action {
@@ -744,6 +915,8 @@ func (at *AutoTrader) executeDecisionWithRecord(decision *decision.Decision, act
 		return at.executeUpdateTakeProfitWithRecord(decision, actionRecord)
 	case "partial_close":
 		return at.executePartialCloseWithRecord(decision, actionRecord)
+	case "set_trailing_stop":
+		return at.executeSetTrailingStopWithRecord(decision, actionRecord)
 	case "hold", "wait":
 		// 无需执行，仅记录
 		return nil
@@ -752,6 +925,13 @@ func (at *AutoTrader) executeDecisionWithRecord(decision *decision.Decision, act
 	}
 }
 
+// markPositionOpened 记录持仓开仓时间 (posKey: symbol_side)
+func (at *AutoTrader) markPositionOpened(posKey string) {
+	at.positionFirstSeenMutex.Lock()
+	at.positionFirstSeenTime[posKey] = time.Now().UnixMilli()
+	at.positionFirstSeenMutex.Unlock()
+}
+
 // executeOpenLongWithRecord 执行开多仓并记录详细信息
 func (at *AutoTrader) executeOpenLongWithRecord(decision *decision.Decision, actionRecord *logger.DecisionAction) error {
 	log.Printf("  📈 开多仓: %s", decision.Symbol)
@@ -769,37 +949,47 @@ func (at *AutoTrader) executeOpenLongWithRecord(decision *decision.Decision, act
 	}
 
 	// ⚠️ 关键：检查是否已有同币种同方向持仓，如果有则拒绝开仓（防止仓位叠加超限）
-	positions, err := at.trader.GetPositions()
-	if err == nil {
-		for _, pos := range positions {
-			if pos["symbol"] == decision.Symbol && pos["side"] == "long" {
-				return fmt.Errorf("❌ %s 已有多仓，拒绝开仓以防止仓位叠加超限。如需换仓，请先给出 close_long 决策", decision.Symbol)
-			}
-		}
+	// 持仓数据不完整时无法确认是否已有持仓，拒绝开仓而不是跳过检查
+	positions, err := FetchPositions(at.trader)
+	if err != nil {
+		return fmt.Errorf("❌ 获取持仓失败，拒绝开仓: %w", err)
+	}
+	if _, found := FindPosition(positions, decision.Symbol, "long"); found {
+		return fmt.Errorf("❌ %s 已有多仓，拒绝开仓以防止仓位叠加超限。如需换仓，请先给出 close_long 决策", decision.Symbol)
 	}
 
 	// 获取当前价格
-	marketData, err := market.Get(decision.Symbol)
+	marketData, err := at.getMarketData(decision.Symbol)
 	if err != nil {
 		return err
 	}
 
-	// 计算数量
-	quantity := decision.PositionSizeUSD / marketData.CurrentPrice
+	// 流动性检查：市价单预计滑点超限时缩小仓位或拒绝开仓
+	if !decision.IsLimitOrder() {
+		positionSize, err := at.checkLiquidity(decision.Symbol, true, decision.PositionSizeUSD)
+		if err != nil {
+			return err
+		}
+		decision.PositionSizeUSD = positionSize
+	}
+
+	// 计算数量（限价单按挂单价计算）
+	entryPrice := marketData.CurrentPrice
+	if decision.IsLimitOrder() {
+		entryPrice = decision.LimitPrice
+	}
+	quantity := decision.PositionSizeUSD / entryPrice
 	actionRecord.Quantity = quantity
-	actionRecord.Price = marketData.CurrentPrice
+	actionRecord.Price = entryPrice
 
 	// ⚠️ 保证金验证：防止保证金不足错误（code=-2019）
 	requiredMargin := decision.PositionSizeUSD / float64(decision.Leverage)
 
-	balance, err := at.trader.GetBalance()
+	balance, err := FetchBalance(at.trader)
 	if err != nil {
 		return fmt.Errorf("获取账户余额失败: %w", err)
 	}
-	availableBalance := 0.0
-	if avail, ok := balance["availableBalance"].(float64); ok {
-		availableBalance = avail
-	}
+	availableBalance := balance.AvailableBalance
 
 	// 手续费估算（Taker费率 0.04%）
 	estimatedFee := decision.PositionSizeUSD * 0.0004
@@ -816,6 +1006,11 @@ func (at *AutoTrader) executeOpenLongWithRecord(decision *decision.Decision, act
 		// 继续执行，不影响交易
 	}
 
+	// 限价单：挂单后由 processPendingOrders 跟踪成交
+	if decision.IsLimitOrder() {
+		return at.placeLimitOrder(decision, "long", quantity, actionRecord)
+	}
+
 	// 开仓
 	order, err := at.trader.OpenLong(decision.Symbol, quantity, decision.Leverage)
 	if err != nil {
@@ -823,14 +1018,13 @@ func (at *AutoTrader) executeOpenLongWithRecord(decision *decision.Decision, act
 	}
 
 	// 记录订单ID
-	if orderID, ok := order["orderId"].(int64); ok {
-		actionRecord.OrderID = orderID
-	}
+	orderResult := OrderResultFromMap(order)
+	actionRecord.OrderID = orderResult.NumericID()
 
 	// 获取实际成交价格
 	var actualPrice float64
-	if price, ok := order["price"].(float64); ok {
-		actualPrice = price
+	if orderResult.Price > 0 {
+		actualPrice = orderResult.Price
 	} else {
 		// 如果没有实际成交价格，使用最新市场价格
 		log.Printf("  ⚠ 订单中没有实际成交价格，使用最新市场价格")
@@ -840,18 +1034,20 @@ func (at *AutoTrader) executeOpenLongWithRecord(decision *decision.Decision, act
 	// 更新actionRecord.Price为实际成交价格
 	actionRecord.Price = actualPrice
 
-	log.Printf("  ✓ 开仓成功，订单ID: %v, 数量: %.4f, 成交价格: %.4f", order["orderId"], quantity, actualPrice)
+	log.Printf("  ✓ 开仓成功，订单ID: %v, 数量: %.4f, 成交价格: %.4f", orderResult.OrderID, quantity, actualPrice)
 
 	// 记录开仓时间
-	posKey := decision.Symbol + "_long"
-	at.positionFirstSeenTime[posKey] = time.Now().UnixMilli()
+	at.markPositionOpened(decision.Symbol + "_long")
 
 	// 设置止损止盈
 	if err := at.trader.SetStopLoss(decision.Symbol, "LONG", quantity, decision.StopLoss); err != nil {
 		log.Printf("  ⚠ 设置止损失败: %v", err)
 	}
-	if err := at.trader.SetTakeProfit(decision.Symbol, "LONG", quantity, decision.TakeProfit); err != nil {
-		log.Printf("  ⚠ 设置止盈失败: %v", err)
+	levels := at.takeProfitLevels(decision, actualPrice)
+	if !at.armTakeProfitLadder(decision.Symbol, "long", actualPrice, quantity, decision.StopLoss, levels, at.breakevenAfterTP1(decision)) {
+		if err := at.trader.SetTakeProfit(decision.Symbol, "LONG", quantity, decision.TakeProfit); err != nil {
+			log.Printf("  ⚠ 设置止盈失败: %v", err)
+		}
 	}
 
 	// 发送Telegram通知
@@ -870,7 +1066,7 @@ func (at *AutoTrader) executeOpenLongWithRecord(decision *decision.Decision, act
 		decision.Leverage,
 		decision.StopLoss,
 		decision.TakeProfit,
-		order["orderId"],
+		orderResult.OrderID,
 		time.Now().Format("2006-01-02 15:04:05"))
 	logger.SendTelegramMessage(tgMessage)
 
@@ -894,37 +1090,47 @@ func (at *AutoTrader) executeOpenShortWithRecord(decision *decision.Decision, ac
 	}
 
 	// ⚠️ 关键：检查是否已有同币种同方向持仓，如果有则拒绝开仓（防止仓位叠加超限）
-	positions, err := at.trader.GetPositions()
-	if err == nil {
-		for _, pos := range positions {
-			if pos["symbol"] == decision.Symbol && pos["side"] == "short" {
-				return fmt.Errorf("❌ %s 已有空仓，拒绝开仓以防止仓位叠加超限。如需换仓，请先给出 close_short 决策", decision.Symbol)
-			}
-		}
+	// 持仓数据不完整时无法确认是否已有持仓，拒绝开仓而不是跳过检查
+	positions, err := FetchPositions(at.trader)
+	if err != nil {
+		return fmt.Errorf("❌ 获取持仓失败，拒绝开仓: %w", err)
+	}
+	if _, found := FindPosition(positions, decision.Symbol, "short"); found {
+		return fmt.Errorf("❌ %s 已有空仓，拒绝开仓以防止仓位叠加超限。如需换仓，请先给出 close_short 决策", decision.Symbol)
 	}
 
 	// 获取当前价格
-	marketData, err := market.Get(decision.Symbol)
+	marketData, err := at.getMarketData(decision.Symbol)
 	if err != nil {
 		return err
 	}
 
-	// 计算数量
-	quantity := decision.PositionSizeUSD / marketData.CurrentPrice
+	// 流动性检查：市价单预计滑点超限时缩小仓位或拒绝开仓
+	if !decision.IsLimitOrder() {
+		positionSize, err := at.checkLiquidity(decision.Symbol, false, decision.PositionSizeUSD)
+		if err != nil {
+			return err
+		}
+		decision.PositionSizeUSD = positionSize
+	}
+
+	// 计算数量（限价单按挂单价计算）
+	entryPrice := marketData.CurrentPrice
+	if decision.IsLimitOrder() {
+		entryPrice = decision.LimitPrice
+	}
+	quantity := decision.PositionSizeUSD / entryPrice
 	actionRecord.Quantity = quantity
-	actionRecord.Price = marketData.CurrentPrice
+	actionRecord.Price = entryPrice
 
 	// ⚠️ 保证金验证：防止保证金不足错误（code=-2019）
 	requiredMargin := decision.PositionSizeUSD / float64(decision.Leverage)
 
-	balance, err := at.trader.GetBalance()
+	balance, err := FetchBalance(at.trader)
 	if err != nil {
 		return fmt.Errorf("获取账户余额失败: %w", err)
 	}
-	availableBalance := 0.0
-	if avail, ok := balance["availableBalance"].(float64); ok {
-		availableBalance = avail
-	}
+	availableBalance := balance.AvailableBalance
 
 	// 手续费估算（Taker费率 0.04%）
 	estimatedFee := decision.PositionSizeUSD * 0.0004
@@ -941,6 +1147,11 @@ func (at *AutoTrader) executeOpenShortWithRecord(decision *decision.Decision, ac
 		// 继续执行，不影响交易
 	}
 
+	// 限价单：挂单后由 processPendingOrders 跟踪成交
+	if decision.IsLimitOrder() {
+		return at.placeLimitOrder(decision, "short", quantity, actionRecord)
+	}
+
 	// 开仓
 	order, err := at.trader.OpenShort(decision.Symbol, quantity, decision.Leverage)
 	if err != nil {
@@ -948,14 +1159,13 @@ func (at *AutoTrader) executeOpenShortWithRecord(decision *decision.Decision, ac
 	}
 
 	// 记录订单ID
-	if orderID, ok := order["orderId"].(int64); ok {
-		actionRecord.OrderID = orderID
-	}
+	orderResult := OrderResultFromMap(order)
+	actionRecord.OrderID = orderResult.NumericID()
 
 	// 获取实际成交价格
 	var actualPrice float64
-	if price, ok := order["price"].(float64); ok {
-		actualPrice = price
+	if orderResult.Price > 0 {
+		actualPrice = orderResult.Price
 	} else {
 		// 如果没有实际成交价格，使用最新市场价格
 		log.Printf("  ⚠ 订单中没有实际成交价格，使用最新市场价格")
@@ -965,18 +1175,20 @@ func (at *AutoTrader) executeOpenShortWithRecord(decision *decision.Decision, ac
 	// 更新actionRecord.Price为实际成交价格
 	actionRecord.Price = actualPrice
 
-	log.Printf("  ✓ 开仓成功，订单ID: %v, 数量: %.4f, 成交价格: %.4f", order["orderId"], quantity, actualPrice)
+	log.Printf("  ✓ 开仓成功，订单ID: %v, 数量: %.4f, 成交价格: %.4f", orderResult.OrderID, quantity, actualPrice)
 
 	// 记录开仓时间
-	posKey := decision.Symbol + "_short"
-	at.positionFirstSeenTime[posKey] = time.Now().UnixMilli()
+	at.markPositionOpened(decision.Symbol + "_short")
 
 	// 设置止损止盈
 	if err := at.trader.SetStopLoss(decision.Symbol, "SHORT", quantity, decision.StopLoss); err != nil {
 		log.Printf("  ⚠ 设置止损失败: %v", err)
 	}
-	if err := at.trader.SetTakeProfit(decision.Symbol, "SHORT", quantity, decision.TakeProfit); err != nil {
-		log.Printf("  ⚠ 设置止盈失败: %v", err)
+	levels := at.takeProfitLevels(decision, actualPrice)
+	if !at.armTakeProfitLadder(decision.Symbol, "short", actualPrice, quantity, decision.StopLoss, levels, at.breakevenAfterTP1(decision)) {
+		if err := at.trader.SetTakeProfit(decision.Symbol, "SHORT", quantity, decision.TakeProfit); err != nil {
+			log.Printf("  ⚠ 设置止盈失败: %v", err)
+		}
 	}
 
 	// 发送Telegram通知
@@ -995,7 +1207,7 @@ func (at *AutoTrader) executeOpenShortWithRecord(decision *decision.Decision, ac
 		decision.Leverage,
 		decision.StopLoss,
 		decision.TakeProfit,
-		order["orderId"],
+		orderResult.OrderID,
 		time.Now().Format("2006-01-02 15:04:05"))
 	logger.SendTelegramMessage(tgMessage)
 
@@ -1007,31 +1219,19 @@ func (at *AutoTrader) executeCloseLongWithRecord(decision *decision.Decision, ac
 	log.Printf("  🔄 平多仓: %s", decision.Symbol)
 
 	// 获取当前价格
-	marketData, err := market.Get(decision.Symbol)
+	marketData, err := at.getMarketData(decision.Symbol)
 	if err != nil {
 		return err
 	}
 	actionRecord.Price = marketData.CurrentPrice
 
 	// 获取平仓前的持仓信息，用于计算盈亏和收益率
-	positions, _ := at.trader.GetPositions()
-	var entryPrice, unrealizedPnl, leverage, positionAmt float64
-	var found bool
-	for _, pos := range positions {
-		if pos["symbol"] == decision.Symbol && pos["side"] == "long" {
-			entryPrice, _ = pos["entryPrice"].(float64)
-			unrealizedPnl, _ = pos["unRealizedProfit"].(float64)
-			positionAmt, _ = pos["positionAmt"].(float64)
-			// 获取杠杆倍数
-			if lev, ok := pos["leverage"].(float64); ok {
-				leverage = lev
-			} else if levStr, ok := pos["leverage"].(string); ok {
-				leverage, _ = strconv.ParseFloat(levStr, 64)
-			}
-			found = true
-			break
-		}
-	}
+	positions, _ := FetchPositions(at.trader)
+	pos, found := FindPosition(positions, decision.Symbol, "long")
+	entryPrice := pos.EntryPrice
+	unrealizedPnl := pos.UnrealizedPnL
+	positionAmt := pos.Quantity
+	leverage := float64(pos.Leverage)
 
 	if !found {
 		return fmt.Errorf("未找到 %s 的多仓持仓", decision.Symbol)
@@ -1075,9 +1275,8 @@ func (at *AutoTrader) executeCloseLongWithRecord(decision *decision.Decision, ac
 	}
 
 	// 记录订单ID
-	if orderID, ok := order["orderId"].(int64); ok {
-		actionRecord.OrderID = orderID
-	}
+	orderResult := OrderResultFromMap(order)
+	actionRecord.OrderID = orderResult.NumericID()
 
 	log.Printf("  ✓ 平仓成功")
 
@@ -1095,7 +1294,7 @@ func (at *AutoTrader) executeCloseLongWithRecord(decision *decision.Decision, ac
 		entryPrice,
 		unrealizedPnl,
 		pnlPercentage,
-		order["orderId"],
+		orderResult.OrderID,
 		time.Now().Format("2006-01-02 15:04:05"))
 	logger.SendTelegramMessage(tgMessage)
 
@@ -1114,31 +1313,19 @@ func (at *AutoTrader) executeCloseShortWithRecord(decision *decision.Decision, a
 	log.Printf("  🔄 平空仓: %s", decision.Symbol)
 
 	// 获取当前价格
-	marketData, err := market.Get(decision.Symbol)
+	marketData, err := at.getMarketData(decision.Symbol)
 	if err != nil {
 		return err
 	}
 	actionRecord.Price = marketData.CurrentPrice
 
 	// 获取平仓前的持仓信息，用于计算盈亏和收益率
-	positions, _ := at.trader.GetPositions()
-	var entryPrice, unrealizedPnl, leverage, positionAmt float64
-	var found bool
-	for _, pos := range positions {
-		if pos["symbol"] == decision.Symbol && pos["side"] == "short" {
-			entryPrice, _ = pos["entryPrice"].(float64)
-			unrealizedPnl, _ = pos["unRealizedProfit"].(float64)
-			positionAmt, _ = pos["positionAmt"].(float64)
-			// 获取杠杆倍数
-			if lev, ok := pos["leverage"].(float64); ok {
-				leverage = lev
-			} else if levStr, ok := pos["leverage"].(string); ok {
-				leverage, _ = strconv.ParseFloat(levStr, 64)
-			}
-			found = true
-			break
-		}
-	}
+	positions, _ := FetchPositions(at.trader)
+	pos, found := FindPosition(positions, decision.Symbol, "short")
+	entryPrice := pos.EntryPrice
+	unrealizedPnl := pos.UnrealizedPnL
+	positionAmt := pos.Quantity
+	leverage := float64(pos.Leverage)
 
 	if !found {
 		return fmt.Errorf("未找到 %s 的空仓持仓", decision.Symbol)
@@ -1182,9 +1369,8 @@ func (at *AutoTrader) executeCloseShortWithRecord(decision *decision.Decision, a
 	}
 
 	// 记录订单ID
-	if orderID, ok := order["orderId"].(int64); ok {
-		actionRecord.OrderID = orderID
-	}
+	orderResult := OrderResultFromMap(order)
+	actionRecord.OrderID = orderResult.NumericID()
 
 	log.Printf("  ✓ 平仓成功")
 
@@ -1202,7 +1388,7 @@ func (at *AutoTrader) executeCloseShortWithRecord(decision *decision.Decision, a
 		entryPrice,
 		unrealizedPnl,
 		pnlPercentage,
-		order["orderId"],
+		orderResult.OrderID,
 		time.Now().Format("2006-01-02 15:04:05"))
 	logger.SendTelegramMessage(tgMessage)
 
@@ -1221,25 +1407,23 @@ func (at *AutoTrader) executeUpdateStopLossWithRecord(decision *decision.Decisio
 	log.Printf("  🎯 调整止损: %s → %.2f", decision.Symbol, decision.NewStopLoss)
 
 	// 获取当前价格
-	marketData, err := market.Get(decision.Symbol)
+	marketData, err := at.getMarketData(decision.Symbol)
 	if err != nil {
 		return err
 	}
 	actionRecord.Price = marketData.CurrentPrice
 
 	// 获取当前持仓
-	positions, err := at.trader.GetPositions()
+	positions, err := FetchPositions(at.trader)
 	if err != nil {
 		return fmt.Errorf("获取持仓失败: %w", err)
 	}
 
 	// 查找目标持仓
-	var targetPosition map[string]interface{}
-	for _, pos := range positions {
-		symbol, _ := pos["symbol"].(string)
-		posAmt, _ := pos["positionAmt"].(float64)
-		if symbol == decision.Symbol && posAmt != 0 {
-			targetPosition = pos
+	var targetPosition *Position
+	for i := range positions {
+		if positions[i].Symbol == decision.Symbol {
+			targetPosition = &positions[i]
 			break
 		}
 	}
@@ -1249,9 +1433,8 @@ func (at *AutoTrader) executeUpdateStopLossWithRecord(decision *decision.Decisio
 	}
 
 	// 获取持仓方向和数量
-	side, _ := targetPosition["side"].(string)
-	positionSide := strings.ToUpper(side)
-	positionAmt, _ := targetPosition["positionAmt"].(float64)
+	positionSide := strings.ToUpper(targetPosition.Side)
+	positionAmt := targetPosition.Quantity
 
 	// 验证新止损价格合理性
 	if positionSide == "LONG" && decision.NewStopLoss >= marketData.CurrentPrice {
@@ -1265,12 +1448,9 @@ func (at *AutoTrader) executeUpdateStopLossWithRecord(decision *decision.Decisio
 	var hasOppositePosition bool
 	oppositeSide := ""
 	for _, pos := range positions {
-		symbol, _ := pos["symbol"].(string)
-		posSide, _ := pos["side"].(string)
-		posAmt, _ := pos["positionAmt"].(float64)
-		if symbol == decision.Symbol && posAmt != 0 && strings.ToUpper(posSide) != positionSide {
+		if pos.Symbol == decision.Symbol && strings.ToUpper(pos.Side) != positionSide {
 			hasOppositePosition = true
-			oppositeSide = strings.ToUpper(posSide)
+			oppositeSide = strings.ToUpper(pos.Side)
 			break
 		}
 	}
@@ -1296,6 +1476,8 @@ func (at *AutoTrader) executeUpdateStopLossWithRecord(decision *decision.Decisio
 		return fmt.Errorf("修改止损失败: %w", err)
 	}
 
+	at.updateLadderStopLoss(decision.Symbol, targetPosition.Side, decision.NewStopLoss)
+
 	log.Printf("  ✓ 止损已调整: %.2f (当前价格: %.2f)", decision.NewStopLoss, marketData.CurrentPrice)
 	return nil
 }
@@ -1305,25 +1487,23 @@ func (at *AutoTrader) executeUpdateTakeProfitWithRecord(decision *decision.Decis
 	log.Printf("  🎯 调整止盈: %s → %.2f", decision.Symbol, decision.NewTakeProfit)
 
 	// 获取当前价格
-	marketData, err := market.Get(decision.Symbol)
+	marketData, err := at.getMarketData(decision.Symbol)
 	if err != nil {
 		return err
 	}
 	actionRecord.Price = marketData.CurrentPrice
 
 	// 获取当前持仓
-	positions, err := at.trader.GetPositions()
+	positions, err := FetchPositions(at.trader)
 	if err != nil {
 		return fmt.Errorf("获取持仓失败: %w", err)
 	}
 
 	// 查找目标持仓
-	var targetPosition map[string]interface{}
-	for _, pos := range positions {
-		symbol, _ := pos["symbol"].(string)
-		posAmt, _ := pos["positionAmt"].(float64)
-		if symbol == decision.Symbol && posAmt != 0 {
-			targetPosition = pos
+	var targetPosition *Position
+	for i := range positions {
+		if positions[i].Symbol == decision.Symbol {
+			targetPosition = &positions[i]
 			break
 		}
 	}
@@ -1333,9 +1513,8 @@ func (at *AutoTrader) executeUpdateTakeProfitWithRecord(decision *decision.Decis
 	}
 
 	// 获取持仓方向和数量
-	side, _ := targetPosition["side"].(string)
-	positionSide := strings.ToUpper(side)
-	positionAmt, _ := targetPosition["positionAmt"].(float64)
+	positionSide := strings.ToUpper(targetPosition.Side)
+	positionAmt := targetPosition.Quantity
 
 	// 验证新止盈价格合理性
 	if positionSide == "LONG" && decision.NewTakeProfit <= marketData.CurrentPrice {
@@ -1349,12 +1528,9 @@ func (at *AutoTrader) executeUpdateTakeProfitWithRecord(decision *decision.Decis
 	var hasOppositePosition bool
 	oppositeSide := ""
 	for _, pos := range positions {
-		symbol, _ := pos["symbol"].(string)
-		posSide, _ := pos["side"].(string)
-		posAmt, _ := pos["positionAmt"].(float64)
-		if symbol == decision.Symbol && posAmt != 0 && strings.ToUpper(posSide) != positionSide {
+		if pos.Symbol == decision.Symbol && strings.ToUpper(pos.Side) != positionSide {
 			hasOppositePosition = true
-			oppositeSide = strings.ToUpper(posSide)
+			oppositeSide = strings.ToUpper(pos.Side)
 			break
 		}
 	}
@@ -1380,6 +1556,9 @@ func (at *AutoTrader) executeUpdateTakeProfitWithRecord(decision *decision.Decis
 		return fmt.Errorf("修改止盈失败: %w", err)
 	}
 
+	// 单一止盈替换了原有的分批止盈档位
+	at.removeTakeProfitLadder(decision.Symbol, targetPosition.Side)
+
 	log.Printf("  ✓ 止盈已调整: %.2f (当前价格: %.2f)", decision.NewTakeProfit, marketData.CurrentPrice)
 	return nil
 }
@@ -1394,25 +1573,23 @@ func (at *AutoTrader) executePartialCloseWithRecord(decision *decision.Decision,
 	}
 
 	// 获取当前价格
-	marketData, err := market.Get(decision.Symbol)
+	marketData, err := at.getMarketData(decision.Symbol)
 	if err != nil {
 		return err
 	}
 	actionRecord.Price = marketData.CurrentPrice
 
 	// 获取当前持仓
-	positions, err := at.trader.GetPositions()
+	positions, err := FetchPositions(at.trader)
 	if err != nil {
 		return fmt.Errorf("获取持仓失败: %w", err)
 	}
 
 	// 查找目标持仓
-	var targetPosition map[string]interface{}
-	for _, pos := range positions {
-		symbol, _ := pos["symbol"].(string)
-		posAmt, _ := pos["positionAmt"].(float64)
-		if symbol == decision.Symbol && posAmt != 0 {
-			targetPosition = pos
+	var targetPosition *Position
+	for i := range positions {
+		if positions[i].Symbol == decision.Symbol {
+			targetPosition = &positions[i]
 			break
 		}
 	}
@@ -1422,9 +1599,8 @@ func (at *AutoTrader) executePartialCloseWithRecord(decision *decision.Decision,
 	}
 
 	// 获取持仓方向和数量
-	side, _ := targetPosition["side"].(string)
-	positionSide := strings.ToUpper(side)
-	positionAmt, _ := targetPosition["positionAmt"].(float64)
+	positionSide := strings.ToUpper(targetPosition.Side)
+	positionAmt := targetPosition.Quantity
 
 	// 计算平仓数量
 	totalQuantity := math.Abs(positionAmt)
@@ -1432,8 +1608,8 @@ func (at *AutoTrader) executePartialCloseWithRecord(decision *decision.Decision,
 	actionRecord.Quantity = closeQuantity
 
 	// ✅ Layer 2: 最小仓位检查（防止产生小额剩余）
-	markPrice, ok := targetPosition["markPrice"].(float64)
-	if !ok || markPrice <= 0 {
+	markPrice := targetPosition.MarkPrice
+	if markPrice <= 0 {
 		return fmt.Errorf("无法解析当前价格，无法执行最小仓位检查")
 	}
 
@@ -1475,13 +1651,18 @@ func (at *AutoTrader) executePartialCloseWithRecord(decision *decision.Decision,
 	}
 
 	// 记录订单ID
-	if orderID, ok := order["orderId"].(int64); ok {
-		actionRecord.OrderID = orderID
-	}
+	orderResult := OrderResultFromMap(order)
+	actionRecord.OrderID = orderResult.NumericID()
 
 	log.Printf("  ✓ 部分平仓成功: 平仓 %.4f (%.1f%%), 剩余 %.4f",
 		closeQuantity, decision.ClosePercentage, remainingQuantity)
 
+	// 手动减仓后无法再按持仓数量识别档位成交，停止跟踪分批止盈
+	if _, ok := at.getTakeProfitLadder(decision.Symbol, targetPosition.Side); ok {
+		log.Printf("  ℹ 部分平仓后停止跟踪 %s 的分批止盈，剩余仓位按本次决策的止盈止损保护", decision.Symbol)
+		at.removeTakeProfitLadder(decision.Symbol, targetPosition.Side)
+	}
+
 	// ✅ Step 4: 恢复止盈止损（防止剩余仓位裸奔）
 	// 重要：币安等交易所在部分平仓后会自动取消原有的 TP/SL 订单（因为数量不匹配）
 	// 如果 AI 提供了新的止损止盈价格，则为剩余仓位重新设置保护
@@ -1551,11 +1732,25 @@ func (at *AutoTrader) GetSystemPromptTemplate() string {
 	return at.systemPromptTemplate
 }
 
+// BuildTradingContext 构建当前交易上下文（只读，用于提示词模板预览等场景）
+func (at *AutoTrader) BuildTradingContext() (*decision.Context, error) {
+	return at.buildTradingContext()
+}
+
 // GetDecisionLogger 获取决策日志记录器
 func (at *AutoTrader) GetDecisionLogger() logger.IDecisionLogger {
 	return at.decisionLogger
 }
 
+// aiRateLimitStats 返回本交易员所用AI模型（含备用和集成成员）的共享限流统计：排队数和等待时长
+func (at *AutoTrader) aiRateLimitStats() []mcp.RateLimiterStats {
+	clients := []mcp.AIClient{at.mcpClient}
+	for _, member := range at.ensembleMembers {
+		clients = append(clients, member.Client)
+	}
+	return mcp.CollectRateLimitStats(clients...)
+}
+
 // GetStatus 获取系统状态（用于API）
 func (at *AutoTrader) GetStatus() map[string]interface{} {
 	aiProvider := "DeepSeek"
@@ -1563,7 +1758,13 @@ func (at *AutoTrader) GetStatus() map[string]interface{} {
 		aiProvider = "Qwen"
 	}
 
-	return map[string]interface{}{
+	at.riskMutex.Lock()
+	stopUntil, lastResetTime := at.stopUntil, at.lastResetTime
+	dailyPnL, equityPeak := at.dailyPnL, at.equityHighWaterMark
+	at.riskMutex.Unlock()
+	aiSpendPaused, aiMonthCost := at.aiSpendCapReached(time.Now())
+
+	status := map[string]interface{}{
 		"trader_id":       at.id,
 		"trader_name":     at.name,
 		"ai_model":        at.aiModel,
@@ -1574,39 +1775,48 @@ func (at *AutoTrader) GetStatus() map[string]interface{} {
 		"call_count":      at.callCount,
 		"initial_balance": at.initialBalance,
 		"scan_interval":   at.config.ScanInterval.String(),
-		"stop_until":      at.stopUntil.Format(time.RFC3339),
-		"last_reset_time": at.lastResetTime.Format(time.RFC3339),
+		"stop_until":      stopUntil.Format(time.RFC3339),
+		"last_reset_time": lastResetTime.Format(time.RFC3339),
 		"ai_provider":     aiProvider,
+		"risk_paused":     time.Now().Before(stopUntil),
+		"daily_pnl":       dailyPnL,
+		"equity_peak":     equityPeak,
+
+		"ai_month_cost_usd":    aiMonthCost,
+		"ai_monthly_spend_cap": at.config.MonthlyAISpendCap,
+		"ai_spend_paused":      aiSpendPaused,
+	}
+	if rateLimits := at.aiRateLimitStats(); len(rateLimits) > 0 {
+		status["ai_rate_limits"] = rateLimits
+	}
+	if at.failover != nil {
+		status["ai_failover"] = at.failover.Status()
+		status["ai_safe_mode"] = at.config.AISafeMode
 	}
+	if at.config.MaxSlippagePct > 0 {
+		status["max_slippage_pct"] = at.config.MaxSlippagePct
+		status["liquidity_action"] = at.liquidityAction()
+	}
+	return status
 }
 
 // GetAccountInfo 获取账户信息（用于API）
 func (at *AutoTrader) GetAccountInfo() (map[string]interface{}, error) {
-	balance, err := at.trader.GetBalance()
+	balance, err := FetchBalance(at.trader)
 	if err != nil {
 		return nil, fmt.Errorf("获取余额失败: %w", err)
 	}
 
 	// 获取账户字段
-	totalWalletBalance := 0.0
-	totalUnrealizedProfit := 0.0
-	availableBalance := 0.0
-
-	if wallet, ok := balance["totalWalletBalance"].(float64); ok {
-		totalWalletBalance = wallet
-	}
-	if unrealized, ok := balance["totalUnrealizedProfit"].(float64); ok {
-		totalUnrealizedProfit = unrealized
-	}
-	if avail, ok := balance["availableBalance"].(float64); ok {
-		availableBalance = avail
-	}
+	totalWalletBalance := balance.TotalWalletBalance
+	totalUnrealizedProfit := balance.TotalUnrealizedProfit
+	availableBalance := balance.AvailableBalance
 
 	// Total Equity = 钱包余额 + 未实现盈亏
-	totalEquity := totalWalletBalance + totalUnrealizedProfit
+	totalEquity := balance.TotalEquity()
 
 	// 获取持仓计算总保证金
-	positions, err := at.trader.GetPositions()
+	positions, err := FetchPositions(at.trader)
 	if err != nil {
 		return nil, fmt.Errorf("获取持仓失败: %w", err)
 	}
@@ -1614,19 +1824,13 @@ func (at *AutoTrader) GetAccountInfo() (map[string]interface{}, error) {
 	totalMarginUsed := 0.0
 	totalUnrealizedPnLCalculated := 0.0
 	for _, pos := range positions {
-		markPrice := pos["markPrice"].(float64)
-		quantity := pos["positionAmt"].(float64)
-		if quantity < 0 {
-			quantity = -quantity
-		}
-		unrealizedPnl := pos["unRealizedProfit"].(float64)
-		totalUnrealizedPnLCalculated += unrealizedPnl
+		totalUnrealizedPnLCalculated += pos.UnrealizedPnL
 
 		leverage := 10
-		if lev, ok := pos["leverage"].(float64); ok {
-			leverage = int(lev)
+		if pos.Leverage > 0 {
+			leverage = pos.Leverage
 		}
-		marginUsed := (quantity * markPrice) / float64(leverage)
+		marginUsed := (pos.Quantity * pos.MarkPrice) / float64(leverage)
 		totalMarginUsed += marginUsed
 	}
 
@@ -1650,6 +1854,10 @@ func (at *AutoTrader) GetAccountInfo() (map[string]interface{}, error) {
 		marginUsedPct = (totalMarginUsed / totalEquity) * 100
 	}
 
+	at.riskMutex.Lock()
+	dailyPnL := at.dailyPnL
+	at.riskMutex.Unlock()
+
 	return map[string]interface{}{
 		// 核心字段
 		"total_equity":      totalEquity,           // 账户净值 = wallet + unrealized
@@ -1661,7 +1869,7 @@ func (at *AutoTrader) GetAccountInfo() (map[string]interface{}, error) {
 		"total_pnl":       totalPnL,          // 总盈亏 = equity - initial
 		"total_pnl_pct":   totalPnLPct,       // 总盈亏百分比
 		"initial_balance": at.initialBalance, // 初始余额
-		"daily_pnl":       at.dailyPnL,       // 日盈亏
+		"daily_pnl":       dailyPnL,          // 日盈亏
 
 		// 持仓信息
 		"position_count":  len(positions),  // 持仓数量
@@ -1672,27 +1880,24 @@ func (at *AutoTrader) GetAccountInfo() (map[string]interface{}, error) {
 
 // GetPositions 获取持仓列表（用于API）
 func (at *AutoTrader) GetPositions() ([]map[string]interface{}, error) {
-	positions, err := at.trader.GetPositions()
+	positions, err := FetchPositions(at.trader)
 	if err != nil {
 		return nil, fmt.Errorf("获取持仓失败: %w", err)
 	}
 
 	var result []map[string]interface{}
 	for _, pos := range positions {
-		symbol := pos["symbol"].(string)
-		side := pos["side"].(string)
-		entryPrice := pos["entryPrice"].(float64)
-		markPrice := pos["markPrice"].(float64)
-		quantity := pos["positionAmt"].(float64)
-		if quantity < 0 {
-			quantity = -quantity
-		}
-		unrealizedPnl := pos["unRealizedProfit"].(float64)
-		liquidationPrice := pos["liquidationPrice"].(float64)
+		symbol := pos.Symbol
+		side := pos.Side
+		entryPrice := pos.EntryPrice
+		markPrice := pos.MarkPrice
+		quantity := pos.Quantity
+		unrealizedPnl := pos.UnrealizedPnL
+		liquidationPrice := pos.LiquidationPrice
 
 		leverage := 10
-		if lev, ok := pos["leverage"].(float64); ok {
-			leverage = int(lev)
+		if pos.Leverage > 0 {
+			leverage = pos.Leverage
 		}
 
 		// 计算占用保证金
@@ -1701,7 +1906,7 @@ func (at *AutoTrader) GetPositions() ([]map[string]interface{}, error) {
 		// 计算盈亏百分比（基于保证金）
 		pnlPct := calculatePnLPercentage(unrealizedPnl, marginUsed)
 
-		result = append(result, map[string]interface{}{
+		item := map[string]interface{}{
 			"symbol":             symbol,
 			"side":               side,
 			"entry_price":        entryPrice,
@@ -1712,7 +1917,16 @@ func (at *AutoTrader) GetPositions() ([]map[string]interface{}, error) {
 			"unrealized_pnl_pct": pnlPct,
 			"liquidation_price":  liquidationPrice,
 			"margin_used":        marginUsed,
-		})
+		}
+		if stop, ok := at.getTrailingStop(symbol, side); ok {
+			item["trailing_stop"] = stop.ToMap()
+		}
+		if ladder, ok := at.getTakeProfitLadder(symbol, side); ok {
+			at.takeProfitLaddersMutex.Lock()
+			item["take_profit_ladder"] = ladder.ToMap()
+			at.takeProfitLaddersMutex.Unlock()
+		}
+		result = append(result, item)
 	}
 
 	return result, nil
@@ -1754,7 +1968,7 @@ func sortDecisionsByPriority(decisions []decision.Decision) []decision.Decision
 		switch action {
 		case "close_long", "close_short", "partial_close":
 			return 1 // 最高优先级：先平仓（包括部分平仓）
-		case "update_stop_loss", "update_take_profit":
+		case "update_stop_loss", "update_take_profit", "set_trailing_stop":
 			return 2 // 调整持仓止盈止损
 		case "open_long", "open_short":
 			return 3 // 次优先级：后开仓
@@ -1875,29 +2089,48 @@ func (at *AutoTrader) startDrawdownMonitor() {
 	}()
 }
 
+// startPaperTriggerMonitor 启动模拟盘止盈止损监控（交易所侧条件单由本地价格轮询代替）
+func (at *AutoTrader) startPaperTriggerMonitor(paper *PaperTrader) {
+	at.monitorWg.Add(1)
+	go func() {
+		defer at.monitorWg.Done()
+
+		ticker := time.NewTicker(paperTriggerInterval)
+		defer ticker.Stop()
+
+		log.Printf("📄 启动模拟盘止盈止损监控（每%v检查一次）", paperTriggerInterval)
+
+		for {
+			select {
+			case <-ticker.C:
+				paper.CheckTriggers()
+			case <-at.stopMonitorCh:
+				log.Println("⏹ 停止模拟盘止盈止损监控")
+				return
+			}
+		}
+	}()
+}
+
 // 检查持仓回撤情况
 func (at *AutoTrader) checkPositionDrawdown() {
 	// 获取当前持仓
-	positions, err := at.trader.GetPositions()
+	positions, err := FetchPositions(at.trader)
 	if err != nil {
 		log.Printf("❌ 回撤监控：获取持仓失败: %v", err)
 		return
 	}
 
 	for _, pos := range positions {
-		symbol := pos["symbol"].(string)
-		side := pos["side"].(string)
-		entryPrice := pos["entryPrice"].(float64)
-		markPrice := pos["markPrice"].(float64)
-		quantity := pos["positionAmt"].(float64)
-		if quantity < 0 {
-			quantity = -quantity // 空仓数量为负，转为正数
-		}
+		symbol := pos.Symbol
+		side := pos.Side
+		entryPrice := pos.EntryPrice
+		markPrice := pos.MarkPrice
 
 		// 计算当前盈亏百分比
 		leverage := 10 // 默认值
-		if lev, ok := pos["leverage"].(float64); ok {
-			leverage = int(lev)
+		if pos.Leverage > 0 {
+			leverage = pos.Leverage
 		}
 
 		var currentPnLPct float64
@@ -1912,6 +2145,11 @@ func (at *AutoTrader) checkPositionDrawdown() {
 			currentPnLPct = 0.0
 		}
 
+		// 已设置追踪止损的持仓由追踪止损保护利润
+		if at.hasTrailingStop(symbol, side) {
+			continue
+		}
+
 		// 构造持仓唯一标识（区分多空）
 		posKey := symbol + "_" + side
 
@@ -1964,13 +2202,13 @@ func (at *AutoTrader) emergencyClosePosition(symbol, side string) error {
 		if err != nil {
 			return err
 		}
-		log.Printf("✅ 紧急平多仓成功，订单ID: %v", order["orderId"])
+		log.Printf("✅ 紧急平多仓成功，订单ID: %v", OrderResultFromMap(order).OrderID)
 	case "short":
 		order, err := at.trader.CloseShort(symbol, 0) // 0 = 全部平仓
 		if err != nil {
 			return err
 		}
-		log.Printf("✅ 紧急平空仓成功，订单ID: %v", order["orderId"])
+		log.Printf("✅ 紧急平空仓成功，订单ID: %v", OrderResultFromMap(order).OrderID)
 	default:
 		return fmt.Errorf("未知的持仓方向: %s", side)
 	}
@@ -482,7 +482,7 @@ func (s *AutoTraderTestSuite) TestExecuteOpenPosition() {
 
 			s.mockTrader.balance["availableBalance"] = tt.availBalance
 			if tt.existingSide != "" {
-				s.mockTrader.positions = []map[string]interface{}{{"symbol": "BTCUSDT", "side": tt.existingSide}}
+				s.mockTrader.positions = []map[string]interface{}{{"symbol": "BTCUSDT", "side": tt.existingSide, "positionAmt": 0.02, "entryPrice": 50000.0, "markPrice": 50000.0}}
 			} else {
 				s.mockTrader.positions = []map[string]interface{}{}
 			}
@@ -688,7 +688,7 @@ func (s *AutoTraderTestSuite) TestExecuteUpdateStopOrTakeProfit() {
 
 			if tt.hasPosition {
 				s.mockTrader.positions = []map[string]interface{}{
-					{"symbol": tt.symbol, "side": tt.side, "positionAmt": 0.1},
+					{"symbol": tt.symbol, "side": tt.side, "positionAmt": 0.1, "entryPrice": tt.currentPrice, "markPrice": tt.currentPrice},
 				}
 			} else {
 				s.mockTrader.positions = []map[string]interface{}{}
@@ -983,6 +983,10 @@ type MockTrader struct {
 	shouldFailOpenLong   bool
 	shouldFailCloseLong  bool
 	shouldFailCloseShort bool
+	orders               map[string]map[string]interface{} // 限价单 (orderId -> 订单)
+	canceledOrders       []string
+	stopLosses           map[string]float64 // symbol -> 最近一次设置的止损价
+	stopLossQuantities   []float64          // 每次设置止损的数量（按调用顺序）
 }
 
 func (m *MockTrader) GetBalance() (map[string]interface{}, error) {
@@ -1026,6 +1030,40 @@ func (m *MockTrader) OpenShort(symbol string, quantity float64, leverage int) (m
 	}, nil
 }
 
+func (m *MockTrader) OpenLimitOrder(symbol, side string, quantity float64, leverage int, price float64, timeInForce string) (map[string]interface{}, error) {
+	if m.orders == nil {
+		m.orders = make(map[string]map[string]interface{})
+	}
+	orderID := fmt.Sprintf("%d", 200000+len(m.orders))
+	m.orders[orderID] = map[string]interface{}{
+		"orderId":     orderID,
+		"symbol":      symbol,
+		"status":      OrderStatusNew,
+		"price":       price,
+		"qty":         quantity,
+		"executedQty": 0.0,
+	}
+	return m.orders[orderID], nil
+}
+
+func (m *MockTrader) GetOrder(symbol, orderID string) (map[string]interface{}, error) {
+	order, ok := m.orders[orderID]
+	if !ok {
+		return nil, errors.New("order not found")
+	}
+	return order, nil
+}
+
+func (m *MockTrader) CancelOrder(symbol, orderID string) error {
+	order, ok := m.orders[orderID]
+	if !ok {
+		return errors.New("order not found")
+	}
+	order["status"] = OrderStatusCanceled
+	m.canceledOrders = append(m.canceledOrders, orderID)
+	return nil
+}
+
 func (m *MockTrader) CloseLong(symbol string, quantity float64) (map[string]interface{}, error) {
 	if m.shouldFailCloseLong {
 		return nil, errors.New("failed to close long")
@@ -1059,6 +1097,11 @@ func (m *MockTrader) GetMarketPrice(symbol string) (float64, error) {
 }
 
 func (m *MockTrader) SetStopLoss(symbol string, positionSide string, quantity, stopPrice float64) error {
+	if m.stopLosses == nil {
+		m.stopLosses = make(map[string]float64)
+	}
+	m.stopLosses[symbol] = stopPrice
+	m.stopLossQuantities = append(m.stopLossQuantities, quantity)
 	return nil
 }
 
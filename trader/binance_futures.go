@@ -6,9 +6,11 @@ import (
 	"encoding/hex"
 	"fmt"
 	"log"
+	"math"
 	"nofx/hook"
 	"nofx/logger"
 	"nofx/market"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -53,6 +55,39 @@ type StopLossTakeProfitCondition struct {
 	StopLossPrice   float64 `json:"stop_loss_price"`
 	TakeProfitPrice float64 `json:"take_profit_price"`
 	Active          bool    `json:"active"`
+
+	// 分批止盈档位（按触发顺序排列，TakeProfitPrice 指向下一档），为空时 TakeProfitPrice 作用于整个持仓
+	TakeProfitLevels []LocalTakeProfitLevel `json:"take_profit_levels,omitempty"`
+}
+
+// LocalTakeProfitLevel 本地分批止盈中的一档
+type LocalTakeProfitLevel struct {
+	Price    float64 `json:"price"`
+	Quantity float64 `json:"quantity"`
+}
+
+// addTakeProfitLevel 追加一档分批止盈（同价位覆盖数量），已有的单一止盈先转为覆盖剩余仓位的一档
+func (c *StopLossTakeProfitCondition) addTakeProfitLevel(price, quantity float64) {
+	if len(c.TakeProfitLevels) == 0 && c.TakeProfitPrice > 0 {
+		c.TakeProfitLevels = append(c.TakeProfitLevels, LocalTakeProfitLevel{Price: c.TakeProfitPrice, Quantity: c.Quantity})
+	}
+	replaced := false
+	for i := range c.TakeProfitLevels {
+		if c.TakeProfitLevels[i].Price == price {
+			c.TakeProfitLevels[i].Quantity = quantity
+			replaced = true
+		}
+	}
+	if !replaced {
+		c.TakeProfitLevels = append(c.TakeProfitLevels, LocalTakeProfitLevel{Price: price, Quantity: quantity})
+	}
+	sort.SliceStable(c.TakeProfitLevels, func(i, j int) bool {
+		if c.PositionSide == "SHORT" {
+			return c.TakeProfitLevels[i].Price > c.TakeProfitLevels[j].Price
+		}
+		return c.TakeProfitLevels[i].Price < c.TakeProfitLevels[j].Price
+	})
+	c.TakeProfitPrice = c.TakeProfitLevels[0].Price
 }
 
 // FuturesTrader 币安合约交易器
@@ -662,6 +697,7 @@ func (t *FuturesTrader) CancelTakeProfitOrders(symbol string) error {
 			// 仅重置止盈价格，不取消止损
 			if cond.TakeProfitPrice > 0 {
 				cond.TakeProfitPrice = 0
+				cond.TakeProfitLevels = nil
 				canceledCount++
 				log.Printf("  ✓ 已取消 %s %s 的本地止盈单", cond.Symbol, cond.PositionSide)
 			}
@@ -703,6 +739,7 @@ func (t *FuturesTrader) CancelStopOrders(symbol string) error {
 			// 重置止盈止损价格
 			cond.StopLossPrice = 0
 			cond.TakeProfitPrice = 0
+			cond.TakeProfitLevels = nil
 			cond.Active = false
 			canceledCount++
 			log.Printf("  ✓ 已取消 %s %s 的本地止盈止损单", cond.Symbol, cond.PositionSide)
@@ -765,8 +802,12 @@ func (t *FuturesTrader) SetStopLoss(symbol string, positionSide string, quantity
 		}
 		t.slTpConditions[key] = cond
 	}
-	// 更新止损价格
+	// 更新止损价格（同时刷新持仓数量，平仓后重新设置时条件需重新生效）
 	cond.StopLossPrice = stopPrice
+	if quantity > 0 {
+		cond.Quantity = quantity
+	}
+	cond.Active = true
 	t.slTpMutex.Unlock()
 
 	logger.Infof("✅ 本地止损已设置: %s %s, 数量: %.4f, 止损价格: %.4f",
@@ -775,7 +816,7 @@ func (t *FuturesTrader) SetStopLoss(symbol string, positionSide string, quantity
 }
 
 // SetTakeProfit 设置止盈单
-// 使用本地维护的止盈止损条件来实现
+// 使用本地维护的止盈止损条件来实现；数量小于持仓时作为分批止盈的一档追加
 func (t *FuturesTrader) SetTakeProfit(symbol string, positionSide string, quantity, takeProfitPrice float64) error {
 	// 生成唯一键
 	key := symbol + "_" + positionSide
@@ -794,7 +835,13 @@ func (t *FuturesTrader) SetTakeProfit(symbol string, positionSide string, quanti
 		t.slTpConditions[key] = cond
 	}
 	// 更新止盈价格
-	cond.TakeProfitPrice = takeProfitPrice
+	if quantity > 0 && quantity < cond.Quantity*(1-1e-6) {
+		cond.addTakeProfitLevel(takeProfitPrice, quantity)
+	} else {
+		cond.TakeProfitLevels = nil
+		cond.TakeProfitPrice = takeProfitPrice
+	}
+	cond.Active = true
 	t.slTpMutex.Unlock()
 
 	logger.Infof("✅ 本地止盈已设置: %s %s, 数量: %.4f, 止盈价格: %.4f",
@@ -961,7 +1008,11 @@ func (t *FuturesTrader) checkStopLossTakeProfit() {
 				// 触发止盈
 				log.Printf("🚨 触发止盈: %s %s, 当前价格: %.4f, 止盈价格: %.4f, 杠杆: %d倍, 盈亏率: %.2f%%",
 					cond.Symbol, cond.PositionSide, price, cond.TakeProfitPrice, leverage, pnlPct)
-				t.executeTakeProfit(cond)
+				if len(cond.TakeProfitLevels) > 1 {
+					t.executeTakeProfitLevel(cond)
+				} else {
+					t.executeTakeProfit(cond)
+				}
 			}
 		}
 	}
@@ -1013,6 +1064,38 @@ func (t *FuturesTrader) executeTakeProfit(cond *StopLossTakeProfitCondition) {
 	log.Printf("✅ 止盈执行成功: %s %s, 平仓数量: %.4f", cond.Symbol, cond.PositionSide, cond.Quantity)
 }
 
+// executeTakeProfitLevel 执行分批止盈的一档：只平掉该档数量，并为剩余仓位恢复止损与后续档位
+func (t *FuturesTrader) executeTakeProfitLevel(cond *StopLossTakeProfitCondition) {
+	t.slTpMutex.RLock()
+	level := cond.TakeProfitLevels[0]
+	rest := append([]LocalTakeProfitLevel(nil), cond.TakeProfitLevels[1:]...)
+	stopLoss := cond.StopLossPrice
+	t.slTpMutex.RUnlock()
+
+	var err error
+	if cond.PositionSide == "LONG" {
+		_, err = t.CloseLong(cond.Symbol, level.Quantity)
+	} else {
+		_, err = t.CloseShort(cond.Symbol, level.Quantity)
+	}
+
+	if err != nil {
+		log.Printf("❌ 执行分批止盈失败: %v", err)
+		return
+	}
+
+	// 平仓会清除该币种的本地止盈止损条件，这里恢复剩余仓位的保护
+	t.slTpMutex.Lock()
+	cond.Quantity -= level.Quantity
+	cond.StopLossPrice = stopLoss
+	cond.TakeProfitLevels = rest
+	cond.TakeProfitPrice = rest[0].Price
+	cond.Active = true
+	t.slTpMutex.Unlock()
+
+	log.Printf("✅ 分批止盈执行成功: %s %s, 平仓数量: %.4f, 剩余 %d 档", cond.Symbol, cond.PositionSide, level.Quantity, len(rest))
+}
+
 // GetMinNotional 获取最小名义价值（Binance要求）
 func (t *FuturesTrader) GetMinNotional(symbol string) float64 {
 	// 使用保守的默认值 10 USDT，确保订单能够通过交易所验证
@@ -1119,6 +1202,212 @@ func (t *FuturesTrader) FormatQuantity(symbol string, quantity float64) (string,
 	return fmt.Sprintf(format, quantity), nil
 }
 
+// FormatPrice 按 PRICE_FILTER 的 tickSize 格式化价格
+func (t *FuturesTrader) FormatPrice(symbol string, price float64) (string, error) {
+	exchangeInfo, err := t.client.NewExchangeInfoService().Do(context.Background())
+	if err != nil {
+		return "", fmt.Errorf("获取交易规则失败: %w", err)
+	}
+
+	for _, s := range exchangeInfo.Symbols {
+		if s.Symbol != symbol {
+			continue
+		}
+		for _, filter := range s.Filters {
+			if filter["filterType"] != "PRICE_FILTER" {
+				continue
+			}
+			tickSizeStr, _ := filter["tickSize"].(string)
+			tickSize, err := strconv.ParseFloat(tickSizeStr, 64)
+			if err != nil || tickSize <= 0 {
+				break
+			}
+			precision := calculatePrecision(tickSizeStr)
+			rounded := math.Round(price/tickSize) * tickSize
+			return strconv.FormatFloat(rounded, 'f', precision, 64), nil
+		}
+	}
+
+	log.Printf("  ⚠ %s 未找到价格精度信息，使用默认精度4", symbol)
+	return strconv.FormatFloat(price, 'f', 4, 64), nil
+}
+
+// OpenLimitOrder 限价开仓（side: long/short，timeInForce: GTC/IOC/GTX）
+// 与 OpenLong/OpenShort 不同，这里不会清理已有委托单，以免撤掉其他挂单
+func (t *FuturesTrader) OpenLimitOrder(symbol, side string, quantity float64, leverage int, price float64, timeInForce string) (map[string]interface{}, error) {
+	if err := t.SetLeverage(symbol, leverage); err != nil {
+		return nil, err
+	}
+
+	quantityStr, err := t.FormatQuantity(symbol, quantity)
+	if err != nil {
+		return nil, err
+	}
+	quantityFloat, parseErr := strconv.ParseFloat(quantityStr, 64)
+	if parseErr != nil || quantityFloat <= 0 {
+		return nil, fmt.Errorf("开仓数量过小，格式化后为 0 (原始: %.8f → 格式化: %s)", quantity, quantityStr)
+	}
+
+	priceStr, err := t.FormatPrice(symbol, price)
+	if err != nil {
+		return nil, err
+	}
+
+	orderSide, positionSide := futures.SideTypeBuy, futures.PositionSideTypeLong
+	if side == "short" {
+		orderSide, positionSide = futures.SideTypeSell, futures.PositionSideTypeShort
+	}
+	if timeInForce == "" {
+		timeInForce = TimeInForceGTC
+	}
+
+	order, err := t.client.NewCreateOrderService().
+		Symbol(symbol).
+		Side(orderSide).
+		PositionSide(positionSide).
+		Type(futures.OrderTypeLimit).
+		TimeInForce(futures.TimeInForceType(timeInForce)).
+		Quantity(quantityStr).
+		Price(priceStr).
+		NewClientOrderID(getBrOrderID()).
+		Do(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("限价开仓失败: %w", err)
+	}
+
+	log.Printf("✓ 限价单已提交: %s %s 数量: %s 价格: %s (%s)", symbol, side, quantityStr, priceStr, timeInForce)
+
+	executedQty, _ := strconv.ParseFloat(order.ExecutedQuantity, 64)
+	avgPrice, _ := strconv.ParseFloat(order.AvgPrice, 64)
+	if avgPrice == 0 {
+		avgPrice, _ = strconv.ParseFloat(priceStr, 64)
+	}
+
+	return map[string]interface{}{
+		"orderId":     order.OrderID,
+		"symbol":      order.Symbol,
+		"status":      string(order.Status),
+		"price":       avgPrice,
+		"qty":         quantityFloat,
+		"executedQty": executedQty,
+	}, nil
+}
+
+// GetOrder 查询订单状态
+func (t *FuturesTrader) GetOrder(symbol, orderID string) (map[string]interface{}, error) {
+	id, err := strconv.ParseInt(orderID, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("无效的订单ID: %s", orderID)
+	}
+
+	order, err := t.client.NewGetOrderService().
+		Symbol(symbol).
+		OrderID(id).
+		Do(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("查询订单失败: %w", err)
+	}
+
+	origQty, _ := strconv.ParseFloat(order.OrigQuantity, 64)
+	executedQty, _ := strconv.ParseFloat(order.ExecutedQuantity, 64)
+	price, _ := strconv.ParseFloat(order.AvgPrice, 64)
+	if price == 0 {
+		price, _ = strconv.ParseFloat(order.Price, 64)
+	}
+
+	return map[string]interface{}{
+		"orderId":     order.OrderID,
+		"symbol":      order.Symbol,
+		"status":      NormalizeOrderStatus(string(order.Status)),
+		"price":       price,
+		"qty":         origQty,
+		"executedQty": executedQty,
+	}, nil
+}
+
+// CancelOrder 取消单个订单
+func (t *FuturesTrader) CancelOrder(symbol, orderID string) error {
+	id, err := strconv.ParseInt(orderID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("无效的订单ID: %s", orderID)
+	}
+
+	_, err = t.client.NewCancelOrderService().
+		Symbol(symbol).
+		OrderID(id).
+		Do(context.Background())
+	if err != nil {
+		return fmt.Errorf("取消订单失败: %w", err)
+	}
+
+	log.Printf("  ✓ 已取消订单 %s (%s)", orderID, symbol)
+	return nil
+}
+
+// SetTrailingStop 设置原生追踪止损单（TRAILING_STOP_MARKET）
+// 币安只支持回调比例，按价格距离设置时换算为当前价格的百分比
+func (t *FuturesTrader) SetTrailingStop(symbol, positionSide string, quantity, callbackRate, distance, activationPrice float64) (map[string]interface{}, error) {
+	if callbackRate <= 0 {
+		price, err := t.GetMarketPrice(symbol)
+		if err != nil {
+			return nil, err
+		}
+		callbackRate = distance / price * 100
+	}
+	// 回调比例精度为0.1%，范围 0.1%-10%
+	callbackRate = math.Round(callbackRate*10) / 10
+	if callbackRate < 0.1 || callbackRate > 10 {
+		return nil, fmt.Errorf("币安追踪止损回调比例必须在0.1%%-10%%之间: %.2f%%", callbackRate)
+	}
+
+	quantityStr, err := t.FormatQuantity(symbol, quantity)
+	if err != nil {
+		return nil, err
+	}
+
+	side := futures.SideTypeSell
+	posSide := futures.PositionSideTypeLong
+	if positionSide == "SHORT" {
+		side = futures.SideTypeBuy
+		posSide = futures.PositionSideTypeShort
+	}
+
+	service := t.client.NewCreateOrderService().
+		Symbol(symbol).
+		Side(side).
+		PositionSide(posSide).
+		Type(futures.OrderTypeTrailingStopMarket).
+		Quantity(quantityStr).
+		CallbackRate(strconv.FormatFloat(callbackRate, 'f', 1, 64)).
+		WorkingType(futures.WorkingTypeContractPrice).
+		NewClientOrderID(getBrOrderID())
+	if activationPrice > 0 {
+		activationStr, err := t.FormatPrice(symbol, activationPrice)
+		if err != nil {
+			return nil, err
+		}
+		service = service.ActivationPrice(activationStr)
+	}
+
+	order, err := service.Do(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("设置追踪止损失败: %w", err)
+	}
+
+	log.Printf("  ✓ 追踪止损单已设置: %s %s 回调 %.1f%% 数量: %s", symbol, positionSide, callbackRate, quantityStr)
+
+	result := make(map[string]interface{})
+	result["orderId"] = order.OrderID
+	result["symbol"] = order.Symbol
+	result["status"] = order.Status
+	return result, nil
+}
+
+// CancelTrailingStop 取消追踪止损单
+func (t *FuturesTrader) CancelTrailingStop(symbol, positionSide, orderID string) error {
+	return t.CancelOrder(symbol, orderID)
+}
+
 // GetTradeHistory 获取交易历史记录
 func (t *FuturesTrader) GetTradeHistory(symbol string, limit int) ([]map[string]interface{}, error) {
 	if limit <= 0 {
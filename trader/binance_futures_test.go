@@ -299,6 +299,7 @@ func (s *BinanceFuturesTestSuite) Cleanup() {
 // TestFuturesTrader_InterfaceCompliance 测试接口兼容性
 func TestFuturesTrader_InterfaceCompliance(t *testing.T) {
 	var _ Trader = (*FuturesTrader)(nil)
+	var _ NativeTrailingStopper = (*FuturesTrader)(nil)
 }
 
 // TestFuturesTrader_CommonInterface 使用测试套件运行所有通用接口测试
@@ -311,6 +312,17 @@ func TestFuturesTrader_CommonInterface(t *testing.T) {
 	suite.RunAllTests()
 }
 
+// TestFuturesTrader_DomainContract 通过 mock 服务器走真实解析路径校验领域模型契约
+// 币安适配器尚未实现 GetTradeHistory，成交记录不在校验范围内
+func TestFuturesTrader_DomainContract(t *testing.T) {
+	suite := NewBinanceFuturesTestSuite(t)
+	defer suite.Cleanup()
+
+	AssertDomainContract(t, DomainContractCase{
+		Exchange: "binance", Trader: suite.Trader, Symbol: "BTCUSDT", Quantity: 0.01, Price: 49000,
+	})
+}
+
 // ============================================================
 // 三、币安合约特定功能的单元测试
 // ============================================================
@@ -0,0 +1,913 @@
+package trader
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Bitget USDT 本位合约固定参数
+const (
+	bitgetProductType = "USDT-FUTURES"
+	bitgetMarginCoin  = "USDT"
+)
+
+// Bitget 账户持仓模式
+const (
+	bitgetPosModeHedge  = "hedge_mode"   // 双向持仓：下单需带 tradeSide
+	bitgetPosModeOneWay = "one_way_mode" // 单向持仓：平仓单使用 reduceOnly
+)
+
+// bitgetBatchLimit Bitget 批量撤单接口单次最多处理的订单数
+const bitgetBatchLimit = 50
+
+// BitgetTrader Bitget USDT 本位合约交易器（v2 REST API）
+// Bitget 下单数量以币为单位，按合约的 sizeMultiplier 步长和 pricePlace 精度格式化
+type BitgetTrader struct {
+	apiKey     string
+	secretKey  string
+	passphrase string
+	demo       bool // 模拟盘（请求头 paptrading: 1）
+	baseURL    string
+	client     *http.Client
+
+	// 合约信息缓存（数量步长、价格精度）
+	contracts      map[string]*bitgetContract
+	contractsMutex sync.RWMutex
+
+	// 账户持仓模式，首次下单时查询
+	posMode      string
+	posModeMutex sync.Mutex
+
+	// 各币种保证金模式（crossed/isolated），下单时需要作为 marginMode 传入
+	marginModes      map[string]string
+	marginModesMutex sync.RWMutex
+}
+
+// bitgetContract 合约信息
+type bitgetContract struct {
+	Symbol         string
+	SizeMultiplier float64 // 下单数量步长
+	MinTradeNum    float64 // 最小下单数量
+	VolumePlace    int     // 数量小数位
+	PricePlace     int     // 价格小数位
+	PriceEndStep   float64 // 价格末位步长，价格步长 = PriceEndStep * 10^-PricePlace
+}
+
+// bitgetResponse Bitget v2 统一响应结构，data 可能是对象或数组
+type bitgetResponse struct {
+	Code string          `json:"code"`
+	Msg  string          `json:"msg"`
+	Data json.RawMessage `json:"data"`
+}
+
+// NewBitgetTrader 创建 Bitget 交易器，demo 为 true 时使用模拟盘
+func NewBitgetTrader(apiKey, secretKey, passphrase string, demo bool) *BitgetTrader {
+	trader := &BitgetTrader{
+		apiKey:      apiKey,
+		secretKey:   secretKey,
+		passphrase:  passphrase,
+		demo:        demo,
+		baseURL:     "https://api.bitget.com",
+		client:      &http.Client{Timeout: 10 * time.Second},
+		contracts:   make(map[string]*bitgetContract),
+		marginModes: make(map[string]string),
+	}
+
+	log.Printf("🔵 [Bitget] 交易器已初始化 (模拟盘: %v)", demo)
+	return trader
+}
+
+// sign 生成签名：Base64(HMAC-SHA256(timestamp + method + requestPath + body))
+func (t *BitgetTrader) sign(timestamp, method, requestPath, body string) string {
+	mac := hmac.New(sha256.New, []byte(t.secretKey))
+	mac.Write([]byte(timestamp + method + requestPath + body))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// request 发送请求，成功时把 data 解析到 out（out 为 nil 时忽略），公共行情接口不签名
+func (t *BitgetTrader) request(method, path string, query url.Values, body interface{}, out interface{}) error {
+	requestPath := path
+	if len(query) > 0 {
+		requestPath += "?" + query.Encode()
+	}
+
+	bodyStr := ""
+	if body != nil {
+		bodyBytes, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		bodyStr = string(bodyBytes)
+	}
+
+	req, err := http.NewRequest(method, t.baseURL+requestPath, strings.NewReader(bodyStr))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("locale", "zh-CN")
+	if t.demo {
+		req.Header.Set("paptrading", "1")
+	}
+	if !strings.HasPrefix(path, "/api/v2/mix/market/") {
+		timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+		req.Header.Set("ACCESS-KEY", t.apiKey)
+		req.Header.Set("ACCESS-SIGN", t.sign(timestamp, method, requestPath, bodyStr))
+		req.Header.Set("ACCESS-TIMESTAMP", timestamp)
+		req.Header.Set("ACCESS-PASSPHRASE", t.passphrase)
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	var result bitgetResponse
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return fmt.Errorf("解析 Bitget 响应失败 (HTTP %d): %s", resp.StatusCode, string(respBody))
+	}
+	if result.Code != "00000" {
+		return fmt.Errorf("Bitget API 错误 %s: %s", result.Code, result.Msg)
+	}
+
+	if out == nil || len(result.Data) == 0 || string(result.Data) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(result.Data, out); err != nil {
+		return fmt.Errorf("解析 Bitget 数据失败: %w", err)
+	}
+	return nil
+}
+
+// getContract 获取合约信息（带缓存）
+func (t *BitgetTrader) getContract(symbol string) (*bitgetContract, error) {
+	symbol = strings.ToUpper(symbol)
+
+	t.contractsMutex.RLock()
+	contract, ok := t.contracts[symbol]
+	t.contractsMutex.RUnlock()
+	if ok {
+		return contract, nil
+	}
+
+	var data []map[string]interface{}
+	if err := t.request("GET", "/api/v2/mix/market/contracts", url.Values{
+		"productType": {bitgetProductType},
+		"symbol":      {symbol},
+	}, nil, &data); err != nil {
+		return nil, fmt.Errorf("获取 %s 合约信息失败: %w", symbol, err)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("未找到合约 %s", symbol)
+	}
+
+	contract = &bitgetContract{Symbol: symbol}
+	contract.SizeMultiplier, _ = SafeFloat64(data[0], "sizeMultiplier")
+	contract.MinTradeNum, _ = SafeFloat64(data[0], "minTradeNum")
+	contract.PriceEndStep, _ = SafeFloat64(data[0], "priceEndStep")
+	volumePlace, _ := SafeFloat64(data[0], "volumePlace")
+	pricePlace, _ := SafeFloat64(data[0], "pricePlace")
+	contract.VolumePlace = int(volumePlace)
+	contract.PricePlace = int(pricePlace)
+	if contract.SizeMultiplier <= 0 {
+		return nil, fmt.Errorf("合约 %s 信息不完整: %v", symbol, data[0])
+	}
+
+	t.contractsMutex.Lock()
+	t.contracts[symbol] = contract
+	t.contractsMutex.Unlock()
+	return contract, nil
+}
+
+// roundQuantity 按数量步长向下取整
+func (c *bitgetContract) roundQuantity(quantity float64) float64 {
+	steps := math.Floor(quantity/c.SizeMultiplier + 1e-9)
+	scale := math.Pow(10, float64(c.VolumePlace))
+	return math.Round(steps*c.SizeMultiplier*scale) / scale
+}
+
+// formatSize 返回下单用的数量字符串，不足最小下单量时返回错误
+func (c *bitgetContract) formatSize(quantity float64) (string, error) {
+	size := c.roundQuantity(quantity)
+	if size <= 0 || size < c.MinTradeNum {
+		return "", fmt.Errorf("%s 数量 %v 不足最小下单量 %v", c.Symbol, quantity, c.MinTradeNum)
+	}
+	return strconv.FormatFloat(size, 'f', c.VolumePlace, 64), nil
+}
+
+// formatPrice 按价格精度和末位步长格式化价格
+func (c *bitgetContract) formatPrice(price float64) string {
+	tick := math.Pow(10, -float64(c.PricePlace))
+	if c.PriceEndStep > 0 {
+		tick *= c.PriceEndStep
+	}
+	return strconv.FormatFloat(math.Round(price/tick)*tick, 'f', c.PricePlace, 64)
+}
+
+// loadAccount 查询单个币种的账户配置，记录持仓模式和保证金模式
+func (t *BitgetTrader) loadAccount(symbol string) error {
+	var data map[string]interface{}
+	if err := t.request("GET", "/api/v2/mix/account/account", url.Values{
+		"symbol":      {symbol},
+		"productType": {bitgetProductType},
+		"marginCoin":  {bitgetMarginCoin},
+	}, nil, &data); err != nil {
+		return fmt.Errorf("获取 Bitget 账户配置失败: %w", err)
+	}
+
+	mode, _ := SafeString(data, "posMode")
+	if mode != bitgetPosModeHedge && mode != bitgetPosModeOneWay {
+		return fmt.Errorf("未知的 Bitget 持仓模式: %s", mode)
+	}
+
+	t.posModeMutex.Lock()
+	if t.posMode == "" {
+		log.Printf("  ✓ [Bitget] 账户持仓模式: %s", mode)
+	}
+	t.posMode = mode
+	t.posModeMutex.Unlock()
+
+	if marginMode, _ := SafeString(data, "marginMode"); marginMode == "crossed" || marginMode == "isolated" {
+		t.marginModesMutex.Lock()
+		if _, ok := t.marginModes[symbol]; !ok {
+			t.marginModes[symbol] = marginMode
+		}
+		t.marginModesMutex.Unlock()
+	}
+	return nil
+}
+
+// positionMode 获取账户持仓模式（hedge_mode / one_way_mode）
+func (t *BitgetTrader) positionMode(symbol string) (string, error) {
+	t.posModeMutex.Lock()
+	mode := t.posMode
+	t.posModeMutex.Unlock()
+	if mode != "" {
+		return mode, nil
+	}
+
+	if err := t.loadAccount(symbol); err != nil {
+		return "", err
+	}
+	t.posModeMutex.Lock()
+	defer t.posModeMutex.Unlock()
+	return t.posMode, nil
+}
+
+// marginMode 返回币种的保证金模式，未设置时按账户当前配置，查询失败默认全仓
+func (t *BitgetTrader) marginMode(symbol string) string {
+	t.marginModesMutex.RLock()
+	mode, ok := t.marginModes[symbol]
+	t.marginModesMutex.RUnlock()
+	if ok {
+		return mode
+	}
+
+	if err := t.loadAccount(symbol); err != nil {
+		log.Printf("  ⚠ [Bitget] %v", err)
+	}
+	t.marginModesMutex.RLock()
+	defer t.marginModesMutex.RUnlock()
+	if mode, ok := t.marginModes[symbol]; ok {
+		return mode
+	}
+	return "crossed"
+}
+
+// holdSide 返回止盈止损计划的持仓方向：双向持仓为 long/short，单向持仓为 buy/sell
+func (t *BitgetTrader) holdSide(symbol, side string) (string, error) {
+	mode, err := t.positionMode(symbol)
+	if err != nil {
+		return "", err
+	}
+	if mode == bitgetPosModeHedge {
+		return side, nil
+	}
+	if side == "short" {
+		return "sell", nil
+	}
+	return "buy", nil
+}
+
+// placeOrder 下普通订单，side 为仓位方向（long/short），返回统一格式的订单结果。
+// 双向持仓模式下 Bitget 的 side 表示仓位方向，开平由 tradeSide 区分（平多为 buy+close）；
+// 单向持仓模式下 side 为买卖方向，平仓单带 reduceOnly
+func (t *BitgetTrader) placeOrder(symbol, side, orderType, force string, quantity, price float64, reduce bool) (map[string]interface{}, error) {
+	symbol = strings.ToUpper(symbol)
+	contract, err := t.getContract(symbol)
+	if err != nil {
+		return nil, err
+	}
+	size, err := contract.formatSize(quantity)
+	if err != nil {
+		return nil, err
+	}
+	mode, err := t.positionMode(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	body := map[string]interface{}{
+		"symbol":      symbol,
+		"productType": bitgetProductType,
+		"marginMode":  t.marginMode(symbol),
+		"marginCoin":  bitgetMarginCoin,
+		"size":        size,
+		"orderType":   orderType,
+	}
+	if mode == bitgetPosModeHedge {
+		body["side"] = "buy"
+		if side == "short" {
+			body["side"] = "sell"
+		}
+		body["tradeSide"] = "open"
+		if reduce {
+			body["tradeSide"] = "close"
+		}
+	} else {
+		buy := side == "long"
+		if reduce {
+			buy = !buy
+			body["reduceOnly"] = "YES"
+		}
+		body["side"] = "sell"
+		if buy {
+			body["side"] = "buy"
+		}
+	}
+	if orderType == "limit" {
+		body["price"] = contract.formatPrice(price)
+		body["force"] = force
+	}
+
+	var data map[string]interface{}
+	if err := t.request("POST", "/api/v2/mix/order/place-order", nil, body, &data); err != nil {
+		return nil, err
+	}
+	orderID, _ := SafeString(data, "orderId")
+	qty, _ := strconv.ParseFloat(size, 64)
+
+	return map[string]interface{}{
+		"orderId": orderID,
+		"symbol":  symbol,
+		"status":  OrderStatusNew,
+		"price":   price,
+		"qty":     qty,
+	}, nil
+}
+
+// GetBalance 获取账户余额（USDT）
+func (t *BitgetTrader) GetBalance() (map[string]interface{}, error) {
+	var data []map[string]interface{}
+	if err := t.request("GET", "/api/v2/mix/account/accounts", url.Values{"productType": {bitgetProductType}}, nil, &data); err != nil {
+		return nil, fmt.Errorf("获取 Bitget 余额失败: %w", err)
+	}
+
+	var equity, availableBalance, unrealizedPnL float64
+	for _, account := range data {
+		if account["marginCoin"] != bitgetMarginCoin {
+			continue
+		}
+		equity, _ = SafeFloat64(account, "accountEquity")
+		unrealizedPnL, _ = SafeFloat64(account, "unrealizedPL")
+		availableBalance, _ = SafeFloat64(account, "crossedMaxAvailable")
+		if availableBalance == 0 {
+			availableBalance, _ = SafeFloat64(account, "available")
+		}
+	}
+
+	// accountEquity 包含未实现盈亏，钱包余额需扣除
+	return map[string]interface{}{
+		"totalWalletBalance":    equity - unrealizedPnL,
+		"availableBalance":      availableBalance,
+		"totalUnrealizedProfit": unrealizedPnL,
+	}, nil
+}
+
+// GetPositions 获取所有 USDT 本位合约持仓（空仓数量为负数）
+func (t *BitgetTrader) GetPositions() ([]map[string]interface{}, error) {
+	var data []map[string]interface{}
+	if err := t.request("GET", "/api/v2/mix/position/all-position", url.Values{
+		"productType": {bitgetProductType},
+		"marginCoin":  {bitgetMarginCoin},
+	}, nil, &data); err != nil {
+		return nil, fmt.Errorf("获取 Bitget 持仓失败: %w", err)
+	}
+
+	var positions []map[string]interface{}
+	for _, pos := range data {
+		total, _ := SafeFloat64(pos, "total")
+		if total == 0 {
+			continue
+		}
+
+		symbol, _ := SafeString(pos, "symbol")
+		side, _ := SafeString(pos, "holdSide")
+		positionAmt := total
+		if side == "short" {
+			positionAmt = -total
+		}
+
+		entryPrice, _ := SafeFloat64(pos, "openPriceAvg")
+		markPrice, _ := SafeFloat64(pos, "markPrice")
+		unrealizedPnL, _ := SafeFloat64(pos, "unrealizedPL")
+		leverage, _ := SafeFloat64(pos, "leverage")
+		liquidationPrice, _ := SafeFloat64(pos, "liquidationPrice")
+
+		positions = append(positions, map[string]interface{}{
+			"symbol":           symbol,
+			"side":             side,
+			"positionAmt":      positionAmt,
+			"entryPrice":       entryPrice,
+			"markPrice":        markPrice,
+			"unRealizedProfit": unrealizedPnL,
+			"liquidationPrice": liquidationPrice,
+			"leverage":         leverage,
+		})
+	}
+
+	return positions, nil
+}
+
+// OpenLong 市价开多仓
+func (t *BitgetTrader) OpenLong(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+	// 开仓前先取消该币种的挂单，防止残留挂单导致仓位叠加
+	if err := t.CancelAllOrders(symbol); err != nil {
+		log.Printf("  ⚠ [Bitget] 取消挂单失败(继续开仓): %v", err)
+	}
+	if err := t.SetLeverage(symbol, leverage); err != nil {
+		return nil, fmt.Errorf("设置杠杆失败: %w", err)
+	}
+
+	order, err := t.placeOrder(symbol, "long", "market", "", quantity, 0, false)
+	if err != nil {
+		return nil, fmt.Errorf("Bitget 开多失败: %w", err)
+	}
+
+	log.Printf("✓ [Bitget] 开多仓成功: %s 数量: %v", symbol, order["qty"])
+	return order, nil
+}
+
+// OpenShort 市价开空仓
+func (t *BitgetTrader) OpenShort(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+	// 开仓前先取消该币种的挂单，防止残留挂单导致仓位叠加
+	if err := t.CancelAllOrders(symbol); err != nil {
+		log.Printf("  ⚠ [Bitget] 取消挂单失败(继续开仓): %v", err)
+	}
+	if err := t.SetLeverage(symbol, leverage); err != nil {
+		return nil, fmt.Errorf("设置杠杆失败: %w", err)
+	}
+
+	order, err := t.placeOrder(symbol, "short", "market", "", quantity, 0, false)
+	if err != nil {
+		return nil, fmt.Errorf("Bitget 开空失败: %w", err)
+	}
+
+	log.Printf("✓ [Bitget] 开空仓成功: %s 数量: %v", symbol, order["qty"])
+	return order, nil
+}
+
+// OpenLimitOrder 限价开仓（timeInForce: GTC/IOC/GTX，分别对应 Bitget 的 gtc/ioc/post_only）
+func (t *BitgetTrader) OpenLimitOrder(symbol, side string, quantity float64, leverage int, price float64, timeInForce string) (map[string]interface{}, error) {
+	if err := t.SetLeverage(symbol, leverage); err != nil {
+		return nil, fmt.Errorf("设置杠杆失败: %w", err)
+	}
+
+	force := "gtc"
+	switch timeInForce {
+	case TimeInForceIOC:
+		force = "ioc"
+	case TimeInForcePostOnly:
+		force = "post_only"
+	}
+
+	order, err := t.placeOrder(symbol, side, "limit", force, quantity, price, false)
+	if err != nil {
+		return nil, fmt.Errorf("Bitget 限价开仓失败: %w", err)
+	}
+
+	log.Printf("  ✓ [Bitget] 限价单已提交: %s %s %v @ %.4f (%s)", symbol, side, order["qty"], price, force)
+	return order, nil
+}
+
+// GetOrder 查询订单状态
+func (t *BitgetTrader) GetOrder(symbol, orderID string) (map[string]interface{}, error) {
+	var order map[string]interface{}
+	if err := t.request("GET", "/api/v2/mix/order/detail", url.Values{
+		"symbol":      {strings.ToUpper(symbol)},
+		"productType": {bitgetProductType},
+		"orderId":     {orderID},
+	}, nil, &order); err != nil {
+		return nil, fmt.Errorf("查询订单失败: %w", err)
+	}
+	if order == nil {
+		return nil, fmt.Errorf("订单不存在: %s", orderID)
+	}
+
+	state, _ := SafeString(order, "state")
+	size, _ := SafeFloat64(order, "size")
+	filled, _ := SafeFloat64(order, "baseVolume")
+	price, _ := SafeFloat64(order, "priceAvg")
+	if price == 0 {
+		price, _ = SafeFloat64(order, "price")
+	}
+
+	return map[string]interface{}{
+		"orderId":     orderID,
+		"symbol":      symbol,
+		"status":      NormalizeOrderStatus(state),
+		"price":       price,
+		"qty":         size,
+		"executedQty": filled,
+	}, nil
+}
+
+// CancelOrder 取消单个订单
+func (t *BitgetTrader) CancelOrder(symbol, orderID string) error {
+	if err := t.request("POST", "/api/v2/mix/order/cancel-order", nil, map[string]interface{}{
+		"symbol":      strings.ToUpper(symbol),
+		"productType": bitgetProductType,
+		"marginCoin":  bitgetMarginCoin,
+		"orderId":     orderID,
+	}, nil); err != nil {
+		return fmt.Errorf("取消订单失败: %w", err)
+	}
+
+	log.Printf("  ✓ [Bitget] 已取消订单 %s (%s)", orderID, symbol)
+	return nil
+}
+
+// closePosition 市价平仓，quantity 为 0 时平掉全部持仓并撤销剩余的止盈止损单
+func (t *BitgetTrader) closePosition(symbol, side string, quantity float64) (map[string]interface{}, error) {
+	closeAll := quantity == 0
+	if closeAll {
+		positions, err := FetchPositions(t)
+		if err != nil {
+			return nil, err
+		}
+		if pos, ok := FindPosition(positions, symbol, side); ok {
+			quantity = pos.Quantity
+		}
+	}
+
+	sideLabel := "多仓"
+	if side == "short" {
+		sideLabel = "空仓"
+	}
+	if quantity <= 0 {
+		return nil, fmt.Errorf("没有找到 %s 的%s", symbol, sideLabel)
+	}
+
+	order, err := t.placeOrder(symbol, side, "market", "", quantity, 0, true)
+	if err != nil {
+		return nil, fmt.Errorf("Bitget 平%s失败: %w", sideLabel, err)
+	}
+	log.Printf("✓ [Bitget] 平%s成功: %s 数量: %v", sideLabel, symbol, order["qty"])
+
+	if closeAll {
+		if err := t.CancelStopOrders(symbol); err != nil {
+			log.Printf("  ⚠ [Bitget] 取消止盈止损单失败: %v", err)
+		}
+	}
+	return order, nil
+}
+
+// CloseLong 平多仓（quantity=0 表示全部平仓）
+func (t *BitgetTrader) CloseLong(symbol string, quantity float64) (map[string]interface{}, error) {
+	return t.closePosition(symbol, "long", quantity)
+}
+
+// CloseShort 平空仓（quantity=0 表示全部平仓）
+func (t *BitgetTrader) CloseShort(symbol string, quantity float64) (map[string]interface{}, error) {
+	return t.closePosition(symbol, "short", quantity)
+}
+
+// SetLeverage 设置杠杆（双向持仓逐仓需分别设置多空方向）
+func (t *BitgetTrader) SetLeverage(symbol string, leverage int) error {
+	symbol = strings.ToUpper(symbol)
+	holdSides := []string{""}
+	if t.marginMode(symbol) == "isolated" {
+		mode, err := t.positionMode(symbol)
+		if err != nil {
+			return err
+		}
+		if mode == bitgetPosModeHedge {
+			holdSides = []string{"long", "short"}
+		}
+	}
+
+	for _, holdSide := range holdSides {
+		body := map[string]interface{}{
+			"symbol":      symbol,
+			"productType": bitgetProductType,
+			"marginCoin":  bitgetMarginCoin,
+			"leverage":    strconv.Itoa(leverage),
+		}
+		if holdSide != "" {
+			body["holdSide"] = holdSide
+		}
+		if err := t.request("POST", "/api/v2/mix/account/set-leverage", nil, body, nil); err != nil {
+			return fmt.Errorf("设置杠杆失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// SetMarginMode 设置保证金模式
+// Bitget 在有持仓或挂单时不允许切换，此时沿用账户当前模式继续交易
+func (t *BitgetTrader) SetMarginMode(symbol string, isCrossMargin bool) error {
+	symbol = strings.ToUpper(symbol)
+	mode := "isolated"
+	marginModeStr := "逐仓"
+	if isCrossMargin {
+		mode = "crossed"
+		marginModeStr = "全仓"
+	}
+
+	err := t.request("POST", "/api/v2/mix/account/set-margin-mode", nil, map[string]interface{}{
+		"symbol":      symbol,
+		"productType": bitgetProductType,
+		"marginCoin":  bitgetMarginCoin,
+		"marginMode":  mode,
+	}, nil)
+	if err != nil {
+		if strings.Contains(err.Error(), "40920") || strings.Contains(strings.ToLower(err.Error()), "exist") {
+			log.Printf("  ⚠️ %s 有持仓或挂单，无法更改仓位模式，继续使用当前模式", symbol)
+			// 清除记录，下次下单时重新查询账户当前模式
+			t.marginModesMutex.Lock()
+			delete(t.marginModes, symbol)
+			t.marginModesMutex.Unlock()
+			return nil
+		}
+		return fmt.Errorf("设置仓位模式失败: %w", err)
+	}
+
+	t.marginModesMutex.Lock()
+	t.marginModes[symbol] = mode
+	t.marginModesMutex.Unlock()
+	log.Printf("  ✓ %s 仓位模式已设置为 %s", symbol, marginModeStr)
+	return nil
+}
+
+// GetMarketPrice 获取最新成交价
+func (t *BitgetTrader) GetMarketPrice(symbol string) (float64, error) {
+	var data []map[string]interface{}
+	if err := t.request("GET", "/api/v2/mix/market/ticker", url.Values{
+		"symbol":      {strings.ToUpper(symbol)},
+		"productType": {bitgetProductType},
+	}, nil, &data); err != nil {
+		return 0, fmt.Errorf("获取市场价格失败: %w", err)
+	}
+	if len(data) == 0 {
+		return 0, fmt.Errorf("未找到 %s 的价格数据", symbol)
+	}
+
+	price, err := SafeFloat64(data[0], "lastPr")
+	if err != nil || price <= 0 {
+		return 0, fmt.Errorf("解析 %s 价格失败: %v", symbol, data[0]["lastPr"])
+	}
+	return price, nil
+}
+
+// placeTPSLOrder 下持仓止盈止损计划（触发后市价平仓）。
+// 指定数量时使用部分止盈止损（profit_plan/loss_plan），可同时挂多档；数量为 0 时使用整仓止盈止损（pos_profit/pos_loss）
+func (t *BitgetTrader) placeTPSLOrder(symbol, positionSide, planType string, quantity, triggerPrice float64) error {
+	symbol = strings.ToUpper(symbol)
+	contract, err := t.getContract(symbol)
+	if err != nil {
+		return err
+	}
+	side := "long"
+	if strings.ToUpper(positionSide) == "SHORT" {
+		side = "short"
+	}
+	holdSide, err := t.holdSide(symbol, side)
+	if err != nil {
+		return err
+	}
+
+	body := map[string]interface{}{
+		"symbol":       symbol,
+		"productType":  bitgetProductType,
+		"marginCoin":   bitgetMarginCoin,
+		"planType":     "pos_" + planType,
+		"triggerPrice": contract.formatPrice(triggerPrice),
+		"triggerType":  "fill_price",
+		"holdSide":     holdSide,
+	}
+	if quantity > 0 {
+		size, err := contract.formatSize(quantity)
+		if err != nil {
+			return err
+		}
+		body["planType"] = planType + "_plan"
+		body["size"] = size
+		body["executePrice"] = "0"
+	}
+
+	return t.request("POST", "/api/v2/mix/order/place-tpsl-order", nil, body, nil)
+}
+
+// SetStopLoss 设置止损单
+func (t *BitgetTrader) SetStopLoss(symbol string, positionSide string, quantity, stopPrice float64) error {
+	if err := t.placeTPSLOrder(symbol, positionSide, "loss", quantity, stopPrice); err != nil {
+		return fmt.Errorf("设置止损失败: %w", err)
+	}
+
+	log.Printf("  ✓ [Bitget] 止损单已设置: %s @ %.4f", symbol, stopPrice)
+	return nil
+}
+
+// SetTakeProfit 设置止盈单
+func (t *BitgetTrader) SetTakeProfit(symbol string, positionSide string, quantity, takeProfitPrice float64) error {
+	if err := t.placeTPSLOrder(symbol, positionSide, "profit", quantity, takeProfitPrice); err != nil {
+		return fmt.Errorf("设置止盈失败: %w", err)
+	}
+
+	log.Printf("  ✓ [Bitget] 止盈单已设置: %s @ %.4f", symbol, takeProfitPrice)
+	return nil
+}
+
+// bitgetOrderList 挂单/计划委托查询结果
+type bitgetOrderList struct {
+	EntrustedList []map[string]interface{} `json:"entrustedList"`
+}
+
+// cancelPlanOrders 撤销该币种中满足条件的止盈止损计划
+func (t *BitgetTrader) cancelPlanOrders(symbol string, match func(planType string) bool) error {
+	symbol = strings.ToUpper(symbol)
+	var data bitgetOrderList
+	if err := t.request("GET", "/api/v2/mix/order/orders-plan-pending", url.Values{
+		"symbol":      {symbol},
+		"productType": {bitgetProductType},
+		"planType":    {"profit_loss"},
+	}, nil, &data); err != nil {
+		return fmt.Errorf("获取止盈止损计划失败: %w", err)
+	}
+
+	var orderIDs []map[string]interface{}
+	for _, order := range data.EntrustedList {
+		planType, _ := SafeString(order, "planType")
+		if !match(planType) {
+			continue
+		}
+		orderID, _ := SafeString(order, "orderId")
+		orderIDs = append(orderIDs, map[string]interface{}{"orderId": orderID})
+	}
+
+	for start := 0; start < len(orderIDs); start += bitgetBatchLimit {
+		end := start + bitgetBatchLimit
+		if end > len(orderIDs) {
+			end = len(orderIDs)
+		}
+		if err := t.request("POST", "/api/v2/mix/order/cancel-plan-order", nil, map[string]interface{}{
+			"symbol":      symbol,
+			"productType": bitgetProductType,
+			"marginCoin":  bitgetMarginCoin,
+			"planType":    "profit_loss",
+			"orderIdList": orderIDs[start:end],
+		}, nil); err != nil {
+			return fmt.Errorf("撤销止盈止损计划失败: %w", err)
+		}
+	}
+	if len(orderIDs) > 0 {
+		log.Printf("  ✓ [Bitget] 已撤销 %d 个止盈止损计划", len(orderIDs))
+	}
+	return nil
+}
+
+// CancelStopLossOrders 取消止损单
+func (t *BitgetTrader) CancelStopLossOrders(symbol string) error {
+	return t.cancelPlanOrders(symbol, func(planType string) bool {
+		return planType == "loss_plan" || planType == "pos_loss"
+	})
+}
+
+// CancelTakeProfitOrders 取消止盈单
+func (t *BitgetTrader) CancelTakeProfitOrders(symbol string) error {
+	return t.cancelPlanOrders(symbol, func(planType string) bool {
+		return planType == "profit_plan" || planType == "pos_profit"
+	})
+}
+
+// CancelStopOrders 取消所有止盈止损单
+func (t *BitgetTrader) CancelStopOrders(symbol string) error {
+	return t.cancelPlanOrders(symbol, func(string) bool { return true })
+}
+
+// CancelAllOrders 取消该币种所有挂单（普通委托和止盈止损单）
+func (t *BitgetTrader) CancelAllOrders(symbol string) error {
+	symbol = strings.ToUpper(symbol)
+	var data bitgetOrderList
+	if err := t.request("GET", "/api/v2/mix/order/orders-pending", url.Values{
+		"symbol":      {symbol},
+		"productType": {bitgetProductType},
+	}, nil, &data); err != nil {
+		return fmt.Errorf("获取挂单失败: %w", err)
+	}
+
+	orderIDs := make([]map[string]interface{}, 0, len(data.EntrustedList))
+	for _, order := range data.EntrustedList {
+		orderID, _ := SafeString(order, "orderId")
+		orderIDs = append(orderIDs, map[string]interface{}{"orderId": orderID})
+	}
+	for start := 0; start < len(orderIDs); start += bitgetBatchLimit {
+		end := start + bitgetBatchLimit
+		if end > len(orderIDs) {
+			end = len(orderIDs)
+		}
+		if err := t.request("POST", "/api/v2/mix/order/batch-cancel-orders", nil, map[string]interface{}{
+			"symbol":      symbol,
+			"productType": bitgetProductType,
+			"marginCoin":  bitgetMarginCoin,
+			"orderIdList": orderIDs[start:end],
+		}, nil); err != nil {
+			return fmt.Errorf("批量撤单失败: %w", err)
+		}
+	}
+
+	return t.CancelStopOrders(symbol)
+}
+
+// FormatQuantity 按合约数量步长格式化
+func (t *BitgetTrader) FormatQuantity(symbol string, quantity float64) (string, error) {
+	contract, err := t.getContract(symbol)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatFloat(contract.roundQuantity(quantity), 'f', contract.VolumePlace, 64), nil
+}
+
+// GetTradeHistory 获取成交记录（Bitget 单次最多返回 100 条）
+func (t *BitgetTrader) GetTradeHistory(symbol string, limit int) ([]map[string]interface{}, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 100
+	}
+
+	query := url.Values{
+		"productType": {bitgetProductType},
+		"limit":       {strconv.Itoa(limit)},
+	}
+	if symbol != "" {
+		query.Set("symbol", strings.ToUpper(symbol))
+	}
+
+	var data struct {
+		FillList []map[string]interface{} `json:"fillList"`
+	}
+	if err := t.request("GET", "/api/v2/mix/order/fill-history", query, nil, &data); err != nil {
+		return nil, fmt.Errorf("获取 Bitget 成交记录失败: %w", err)
+	}
+
+	trades := make([]map[string]interface{}, 0, len(data.FillList))
+	for _, fill := range data.FillList {
+		orderID, _ := SafeString(fill, "orderId")
+		fillSymbol, _ := SafeString(fill, "symbol")
+		side, _ := SafeString(fill, "side")
+		price, _ := SafeFloat64(fill, "price")
+		qty, _ := SafeFloat64(fill, "baseVolume")
+		pnl, _ := SafeFloat64(fill, "profit")
+		ts, _ := SafeFloat64(fill, "cTime")
+
+		// Bitget 手续费为负数表示扣除
+		fee := 0.0
+		feeDetail, _ := fill["feeDetail"].([]interface{})
+		for _, item := range feeDetail {
+			if detail, ok := item.(map[string]interface{}); ok {
+				totalFee, _ := SafeFloat64(detail, "totalFee")
+				fee -= totalFee
+			}
+		}
+
+		trades = append(trades, map[string]interface{}{
+			"orderId":     orderID,
+			"symbol":      fillSymbol,
+			"side":        side,
+			"price":       price,
+			"qty":         qty,
+			"fee":         fee,
+			"realizedPnl": pnl,
+			"time":        ts,
+		})
+	}
+
+	return trades, nil
+}
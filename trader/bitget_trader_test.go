@@ -0,0 +1,462 @@
+package trader
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ============================================================
+// 一、Bitget v2 REST API mock
+// ============================================================
+
+const (
+	bitgetTestAPIKey     = "bitget_test_api_key"
+	bitgetTestSecretKey  = "bitget_test_secret_key"
+	bitgetTestPassphrase = "bitget_test_passphrase"
+)
+
+// bitgetMockExchange 模拟 Bitget v2 接口，记录下单/止盈止损计划/撤单请求体
+type bitgetMockExchange struct {
+	posMode       string
+	marginLocked  bool // 有持仓时不允许切换保证金模式
+	mu            sync.Mutex
+	orders        []map[string]interface{}
+	planOrders    []map[string]interface{}
+	canceledPlans []map[string]interface{}
+	leverages     []map[string]interface{}
+	marginModes   []map[string]interface{}
+}
+
+func (m *bitgetMockExchange) record(list *[]map[string]interface{}, body []byte) {
+	var payload map[string]interface{}
+	json.Unmarshal(body, &payload)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	*list = append(*list, payload)
+}
+
+// bitgetTestContracts BTC 数量步长 0.001、价格 1 位小数；ETH 数量步长 0.001、价格步长 0.05
+var bitgetTestContracts = map[string]map[string]interface{}{
+	"BTCUSDT": {"symbol": "BTCUSDT", "sizeMultiplier": "0.001", "minTradeNum": "0.001", "volumePlace": "3", "pricePlace": "1", "priceEndStep": "1"},
+	"ETHUSDT": {"symbol": "ETHUSDT", "sizeMultiplier": "0.001", "minTradeNum": "0.001", "volumePlace": "3", "pricePlace": "2", "priceEndStep": "5"},
+}
+
+var bitgetTestTickers = map[string]string{
+	"BTCUSDT": "50000.1",
+	"ETHUSDT": "3000.25",
+}
+
+func newBitgetMockServer(t *testing.T, mock *bitgetMockExchange) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		path := r.URL.Path
+		query := r.URL.Query()
+		ok := func(data interface{}) map[string]interface{} {
+			return map[string]interface{}{"code": "00000", "msg": "success", "requestTime": 1700000000000, "data": data}
+		}
+		fail := func(code, msg string) map[string]interface{} {
+			return map[string]interface{}{"code": code, "msg": msg, "requestTime": 1700000000000, "data": nil}
+		}
+		var respBody interface{}
+
+		// 私有接口校验签名
+		if !strings.HasPrefix(path, "/api/v2/mix/market/") {
+			mac := hmac.New(sha256.New, []byte(bitgetTestSecretKey))
+			mac.Write([]byte(r.Header.Get("ACCESS-TIMESTAMP") + r.Method + r.URL.RequestURI() + string(body)))
+			expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+			if r.Header.Get("ACCESS-SIGN") != expected || r.Header.Get("ACCESS-KEY") != bitgetTestAPIKey ||
+				r.Header.Get("ACCESS-PASSPHRASE") != bitgetTestPassphrase {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(fail("40009", "sign signature error"))
+				return
+			}
+		}
+
+		switch {
+		case path == "/api/v2/mix/market/contracts":
+			if contract, found := bitgetTestContracts[query.Get("symbol")]; found {
+				respBody = ok([]interface{}{contract})
+			} else {
+				respBody = fail("40034", "Parameter symbol does not exist")
+			}
+
+		case path == "/api/v2/mix/market/ticker":
+			if last, found := bitgetTestTickers[query.Get("symbol")]; found {
+				respBody = ok([]interface{}{map[string]interface{}{"symbol": query.Get("symbol"), "lastPr": last}})
+			} else {
+				respBody = fail("40034", "Parameter symbol does not exist")
+			}
+
+		case path == "/api/v2/mix/account/account":
+			respBody = ok(map[string]interface{}{
+				"marginCoin": "USDT", "posMode": mock.posMode, "marginMode": "crossed", "crossedMarginLeverage": "10",
+			})
+
+		case path == "/api/v2/mix/account/accounts":
+			respBody = ok([]interface{}{
+				map[string]interface{}{
+					"marginCoin": "USDT", "available": "10000", "accountEquity": "10100.5",
+					"unrealizedPL": "100.5", "crossedMaxAvailable": "8000",
+				},
+			})
+
+		case path == "/api/v2/mix/position/all-position":
+			respBody = ok([]interface{}{
+				map[string]interface{}{
+					"symbol": "BTCUSDT", "holdSide": "long", "total": "0.1", "openPriceAvg": "49000",
+					"markPrice": "50000", "unrealizedPL": "100", "leverage": "10", "liquidationPrice": "45000", "marginMode": "crossed",
+				},
+				map[string]interface{}{
+					// 已平仓的空仓数量为 0，应被忽略
+					"symbol": "ETHUSDT", "holdSide": "short", "total": "0", "openPriceAvg": "3000",
+				},
+			})
+
+		case path == "/api/v2/mix/account/set-leverage":
+			mock.record(&mock.leverages, body)
+			respBody = ok(map[string]interface{}{"symbol": "BTCUSDT", "marginCoin": "USDT"})
+
+		case path == "/api/v2/mix/account/set-margin-mode":
+			mock.record(&mock.marginModes, body)
+			if mock.marginLocked {
+				respBody = fail("40920", "Position or order exists, the position mode and margin mode cannot be adjusted")
+			} else {
+				respBody = ok(map[string]interface{}{"symbol": "BTCUSDT", "marginMode": "isolated"})
+			}
+
+		case path == "/api/v2/mix/order/place-order":
+			mock.record(&mock.orders, body)
+			respBody = ok(map[string]interface{}{"orderId": "1100000000000000001", "clientOid": ""})
+
+		case path == "/api/v2/mix/order/detail":
+			respBody = ok(map[string]interface{}{
+				"symbol": query.Get("symbol"), "orderId": query.Get("orderId"), "state": "partially_filled",
+				"size": "0.02", "baseVolume": "0.01", "priceAvg": "49900", "price": "49900",
+			})
+
+		case path == "/api/v2/mix/order/orders-pending":
+			respBody = ok(map[string]interface{}{
+				"entrustedList": []interface{}{map[string]interface{}{"orderId": "1200000000000000001", "status": "live"}},
+			})
+
+		case path == "/api/v2/mix/order/cancel-order", path == "/api/v2/mix/order/batch-cancel-orders":
+			respBody = ok(map[string]interface{}{"successList": []interface{}{}, "failureList": []interface{}{}})
+
+		case path == "/api/v2/mix/order/place-tpsl-order":
+			mock.record(&mock.planOrders, body)
+			respBody = ok(map[string]interface{}{"orderId": "1300000000000000001"})
+
+		case path == "/api/v2/mix/order/orders-plan-pending":
+			respBody = ok(map[string]interface{}{
+				"entrustedList": []interface{}{
+					map[string]interface{}{"orderId": "1400000000000000001", "planType": "loss_plan", "symbol": query.Get("symbol")},
+					map[string]interface{}{"orderId": "1400000000000000002", "planType": "pos_profit", "symbol": query.Get("symbol")},
+				},
+			})
+
+		case path == "/api/v2/mix/order/cancel-plan-order":
+			mock.record(&mock.canceledPlans, body)
+			respBody = ok(map[string]interface{}{"successList": []interface{}{}, "failureList": []interface{}{}})
+
+		case path == "/api/v2/mix/order/fill-history":
+			respBody = ok(map[string]interface{}{
+				"fillList": []interface{}{
+					map[string]interface{}{
+						"tradeId": "123", "symbol": "ETHUSDT", "orderId": "1100000000000000001", "side": "sell",
+						"price": "3010.5", "baseVolume": "0.3", "profit": "2.4", "tradeSide": "close",
+						"feeDetail": []interface{}{map[string]interface{}{"feeCoin": "USDT", "totalFee": "-0.45"}},
+						"cTime":     "1700000000000",
+					},
+				},
+				"endId": "123",
+			})
+
+		default:
+			t.Logf("未处理的 Bitget mock 请求: %s %s", r.Method, path)
+			respBody = fail("40404", "Request URL NOT FOUND")
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(respBody)
+	}))
+}
+
+// newTestBitgetTrader 创建指向 mock 服务器的 Bitget 交易器
+func newTestBitgetTrader(server *httptest.Server) *BitgetTrader {
+	trader := NewBitgetTrader(bitgetTestAPIKey, bitgetTestSecretKey, bitgetTestPassphrase, false)
+	trader.baseURL = server.URL
+	trader.client = server.Client()
+	return trader
+}
+
+// ============================================================
+// 二、BitgetTraderTestSuite - 继承 base test suite
+// ============================================================
+
+// BitgetTraderTestSuite Bitget交易器测试套件
+type BitgetTraderTestSuite struct {
+	*TraderTestSuite
+	mockServer *httptest.Server
+}
+
+// NewBitgetTraderTestSuite 创建 Bitget 测试套件
+func NewBitgetTraderTestSuite(t *testing.T, posMode string) *BitgetTraderTestSuite {
+	mockServer := newBitgetMockServer(t, &bitgetMockExchange{posMode: posMode})
+	return &BitgetTraderTestSuite{
+		TraderTestSuite: NewTraderTestSuite(t, newTestBitgetTrader(mockServer)),
+		mockServer:      mockServer,
+	}
+}
+
+// Cleanup 清理资源
+func (s *BitgetTraderTestSuite) Cleanup() {
+	if s.mockServer != nil {
+		s.mockServer.Close()
+	}
+	s.TraderTestSuite.Cleanup()
+}
+
+// TestBitgetTrader_InterfaceCompliance 测试接口兼容性
+func TestBitgetTrader_InterfaceCompliance(t *testing.T) {
+	var _ Trader = (*BitgetTrader)(nil)
+}
+
+// TestBitgetTrader_CommonInterface 双向持仓和单向持仓模式下运行通用测试
+func TestBitgetTrader_CommonInterface(t *testing.T) {
+	for _, posMode := range []string{bitgetPosModeHedge, bitgetPosModeOneWay} {
+		t.Run(posMode, func(t *testing.T) {
+			suite := NewBitgetTraderTestSuite(t, posMode)
+			defer suite.Cleanup()
+			suite.RunAllTests()
+		})
+	}
+}
+
+// TestBitgetTrader_DomainContract 通过 mock 服务器走真实解析路径校验领域模型契约
+func TestBitgetTrader_DomainContract(t *testing.T) {
+	for _, posMode := range []string{bitgetPosModeHedge, bitgetPosModeOneWay} {
+		t.Run(posMode, func(t *testing.T) {
+			server := newBitgetMockServer(t, &bitgetMockExchange{posMode: posMode})
+			defer server.Close()
+			AssertDomainContract(t, DomainContractCase{
+				Exchange: "bitget", Trader: newTestBitgetTrader(server), Symbol: "BTCUSDT",
+				Quantity: 0.1, Price: 49000, HasTradeHistory: true,
+			})
+		})
+	}
+}
+
+// ============================================================
+// 三、Bitget 特定功能的单元测试
+// ============================================================
+
+func TestBitgetTrader_RejectsInvalidSignature(t *testing.T) {
+	server := newBitgetMockServer(t, &bitgetMockExchange{posMode: bitgetPosModeOneWay})
+	defer server.Close()
+
+	trader := newTestBitgetTrader(server)
+	trader.secretKey = "wrong_secret"
+	_, err := trader.GetBalance()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "40009")
+
+	trader = newTestBitgetTrader(server)
+	trader.passphrase = "wrong_passphrase"
+	_, err = trader.GetPositions()
+	assert.Error(t, err)
+
+	// 公共行情接口不需要签名
+	price, err := trader.GetMarketPrice("ETHUSDT")
+	require.NoError(t, err)
+	assert.Equal(t, 3000.25, price)
+}
+
+func TestBitgetTrader_AccountAndPrecision(t *testing.T) {
+	mock := &bitgetMockExchange{posMode: bitgetPosModeOneWay}
+	server := newBitgetMockServer(t, mock)
+	defer server.Close()
+	trader := newTestBitgetTrader(server)
+
+	balance, err := FetchBalance(trader)
+	require.NoError(t, err)
+	assert.InDelta(t, 10000.0, balance.TotalWalletBalance, 1e-9, "accountEquity 扣除未实现盈亏")
+	assert.Equal(t, 8000.0, balance.AvailableBalance)
+
+	positions, err := FetchPositions(trader)
+	require.NoError(t, err)
+	require.Len(t, positions, 1, "数量为 0 的持仓应被忽略")
+	assert.Equal(t, "BTCUSDT", positions[0].Symbol)
+	assert.InDelta(t, 0.1, positions[0].Quantity, 1e-12)
+	assert.Equal(t, 45000.0, positions[0].LiquidationPrice)
+
+	// 按数量步长向下取整
+	qty, err := trader.FormatQuantity("BTCUSDT", 0.012345)
+	require.NoError(t, err)
+	assert.Equal(t, "0.012", qty)
+
+	// 价格按末位步长取整：ETH 价格步长 0.05
+	_, err = trader.OpenLimitOrder("ETHUSDT", "short", 0.4567, 3, 2950.123, TimeInForceGTC)
+	require.NoError(t, err)
+	require.Len(t, mock.orders, 1)
+	assert.Equal(t, "2950.10", mock.orders[0]["price"])
+	assert.Equal(t, "0.456", mock.orders[0]["size"])
+	assert.Equal(t, "gtc", mock.orders[0]["force"])
+	assert.Equal(t, "sell", mock.orders[0]["side"])
+	assert.Equal(t, "crossed", mock.orders[0]["marginMode"], "未设置时沿用账户当前保证金模式")
+
+	// 不足最小下单量
+	_, err = trader.OpenLong("BTCUSDT", 0.0005, 5)
+	assert.Error(t, err)
+
+	// 成交记录：手续费转为正数
+	fills, err := FetchFills(trader, "ETHUSDT", 10)
+	require.NoError(t, err)
+	require.Len(t, fills, 1)
+	assert.Equal(t, "ETHUSDT", fills[0].Symbol)
+	assert.InDelta(t, 0.3, fills[0].Quantity, 1e-12)
+	assert.InDelta(t, 0.45, fills[0].Fee, 1e-12)
+	assert.Equal(t, 2.4, fills[0].RealizedPnL)
+	assert.Equal(t, int64(1700000000000), fills[0].Time.UnixMilli())
+
+	// 订单查询
+	raw, err := trader.GetOrder("BTCUSDT", "1100000000000000001")
+	require.NoError(t, err)
+	result := OrderResultFromMap(raw)
+	assert.Equal(t, OrderStatusPartiallyFilled, result.Status)
+	assert.InDelta(t, 0.02, result.Quantity, 1e-12)
+	assert.InDelta(t, 0.01, result.FilledQuantity, 1e-12)
+}
+
+func TestBitgetTrader_PositionModes(t *testing.T) {
+	t.Run("双向持仓使用tradeSide", func(t *testing.T) {
+		mock := &bitgetMockExchange{posMode: bitgetPosModeHedge}
+		server := newBitgetMockServer(t, mock)
+		defer server.Close()
+		trader := newTestBitgetTrader(server)
+
+		_, err := trader.OpenShort("BTCUSDT", 0.02, 10)
+		require.NoError(t, err)
+		_, err = trader.CloseLong("BTCUSDT", 0)
+		require.NoError(t, err)
+
+		require.Len(t, mock.orders, 2)
+		assert.Equal(t, "sell", mock.orders[0]["side"])
+		assert.Equal(t, "open", mock.orders[0]["tradeSide"])
+		assert.Equal(t, "market", mock.orders[0]["orderType"])
+		// 双向持仓平多：side 为仓位方向 buy，tradeSide 为 close
+		assert.Equal(t, "buy", mock.orders[1]["side"])
+		assert.Equal(t, "close", mock.orders[1]["tradeSide"])
+		assert.Equal(t, "0.100", mock.orders[1]["size"], "全部平仓时按持仓数量下单")
+		assert.Nil(t, mock.orders[1]["reduceOnly"])
+
+		// 全部平仓后撤销剩余止盈止损计划
+		require.NotEmpty(t, mock.canceledPlans)
+	})
+
+	t.Run("单向持仓平仓使用reduceOnly", func(t *testing.T) {
+		mock := &bitgetMockExchange{posMode: bitgetPosModeOneWay}
+		server := newBitgetMockServer(t, mock)
+		defer server.Close()
+		trader := newTestBitgetTrader(server)
+
+		_, err := trader.CloseLong("BTCUSDT", 0.05)
+		require.NoError(t, err)
+		require.Len(t, mock.orders, 1)
+		assert.Nil(t, mock.orders[0]["tradeSide"])
+		assert.Equal(t, "sell", mock.orders[0]["side"])
+		assert.Equal(t, "YES", mock.orders[0]["reduceOnly"])
+		assert.Equal(t, "0.050", mock.orders[0]["size"])
+
+		_, err = trader.CloseShort("BTCUSDT", 0)
+		assert.Error(t, err, "BTC 只有多仓，没有空仓可平")
+	})
+
+	t.Run("逐仓双向持仓分别设置多空杠杆", func(t *testing.T) {
+		mock := &bitgetMockExchange{posMode: bitgetPosModeHedge}
+		server := newBitgetMockServer(t, mock)
+		defer server.Close()
+		trader := newTestBitgetTrader(server)
+
+		require.NoError(t, trader.SetMarginMode("ETHUSDT", false))
+		_, err := trader.OpenLimitOrder("ETHUSDT", "long", 0.5, 3, 2950, TimeInForcePostOnly)
+		require.NoError(t, err)
+
+		require.Len(t, mock.marginModes, 1)
+		assert.Equal(t, "isolated", mock.marginModes[0]["marginMode"])
+		require.Len(t, mock.leverages, 2)
+		assert.Equal(t, "long", mock.leverages[0]["holdSide"])
+		assert.Equal(t, "short", mock.leverages[1]["holdSide"])
+		assert.Equal(t, "3", mock.leverages[0]["leverage"])
+
+		require.Len(t, mock.orders, 1)
+		assert.Equal(t, "post_only", mock.orders[0]["force"])
+		assert.Equal(t, "isolated", mock.orders[0]["marginMode"])
+		assert.Equal(t, "0.500", mock.orders[0]["size"])
+	})
+
+	t.Run("有持仓时无法切换保证金模式", func(t *testing.T) {
+		mock := &bitgetMockExchange{posMode: bitgetPosModeOneWay, marginLocked: true}
+		server := newBitgetMockServer(t, mock)
+		defer server.Close()
+		trader := newTestBitgetTrader(server)
+
+		require.NoError(t, trader.SetMarginMode("BTCUSDT", false), "切换失败不应阻止交易")
+		_, err := trader.OpenLong("BTCUSDT", 0.01, 5)
+		require.NoError(t, err)
+		require.Len(t, mock.orders, 1)
+		assert.Equal(t, "crossed", mock.orders[0]["marginMode"], "沿用账户当前保证金模式")
+	})
+}
+
+func TestBitgetTrader_TPSLPlans(t *testing.T) {
+	mock := &bitgetMockExchange{posMode: bitgetPosModeOneWay}
+	server := newBitgetMockServer(t, mock)
+	defer server.Close()
+	trader := newTestBitgetTrader(server)
+
+	require.NoError(t, trader.SetStopLoss("BTCUSDT", "LONG", 0.1, 45000.04))
+	require.NoError(t, trader.SetTakeProfit("BTCUSDT", "SHORT", 0.05, 44000))
+	require.NoError(t, trader.SetTakeProfit("BTCUSDT", "LONG", 0, 55000))
+	require.Len(t, mock.planOrders, 3)
+
+	sl := mock.planOrders[0]
+	assert.Equal(t, "loss_plan", sl["planType"])
+	assert.Equal(t, "45000.0", sl["triggerPrice"])
+	assert.Equal(t, "0.100", sl["size"])
+	assert.Equal(t, "buy", sl["holdSide"], "单向持仓的多仓 holdSide 为 buy")
+	assert.Equal(t, "0", sl["executePrice"])
+
+	tp := mock.planOrders[1]
+	assert.Equal(t, "profit_plan", tp["planType"])
+	assert.Equal(t, "sell", tp["holdSide"])
+	assert.Equal(t, "0.050", tp["size"])
+
+	// 数量为 0 时使用整仓止盈
+	posTP := mock.planOrders[2]
+	assert.Equal(t, "pos_profit", posTP["planType"])
+	assert.Nil(t, posTP["size"])
+
+	// 只撤销止损计划
+	require.NoError(t, trader.CancelStopLossOrders("BTCUSDT"))
+	require.Len(t, mock.canceledPlans, 1)
+	ids := mock.canceledPlans[0]["orderIdList"].([]interface{})
+	require.Len(t, ids, 1)
+	assert.Equal(t, "1400000000000000001", ids[0].(map[string]interface{})["orderId"])
+
+	require.NoError(t, trader.CancelTakeProfitOrders("BTCUSDT"))
+	ids = mock.canceledPlans[1]["orderIdList"].([]interface{})
+	assert.Equal(t, "1400000000000000002", ids[0].(map[string]interface{})["orderId"])
+}
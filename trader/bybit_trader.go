@@ -104,22 +104,34 @@ func (t *BybitTrader) GetBalance() (map[string]interface{}, error) {
 
 	list, _ := resultData["list"].([]interface{})
 
-	var totalEquity, availableBalance float64 = 0, 0
+	var totalEquity, walletBalance, unrealizedPnL, availableBalance float64 = 0, 0, 0, 0
 
 	if len(list) > 0 {
 		account, _ := list[0].(map[string]interface{})
 		if equityStr, ok := account["totalEquity"].(string); ok {
 			totalEquity, _ = strconv.ParseFloat(equityStr, 64)
 		}
+		if walletStr, ok := account["totalWalletBalance"].(string); ok {
+			walletBalance, _ = strconv.ParseFloat(walletStr, 64)
+		}
+		if uplStr, ok := account["totalPerpUPL"].(string); ok {
+			unrealizedPnL, _ = strconv.ParseFloat(uplStr, 64)
+		}
 		if availStr, ok := account["totalAvailableBalance"].(string); ok {
 			availableBalance, _ = strconv.ParseFloat(availStr, 64)
 		}
 	}
+	// 旧版响应没有 totalWalletBalance 时用净值扣除未实现盈亏
+	if walletBalance == 0 {
+		walletBalance = totalEquity - unrealizedPnL
+	}
 
 	balance := map[string]interface{}{
-		"totalEquity":      totalEquity,
-		"availableBalance": availableBalance,
-		"balance":          totalEquity, // 兼容其他交易所格式
+		"totalEquity":           totalEquity,
+		"totalWalletBalance":    walletBalance,
+		"totalUnrealizedProfit": unrealizedPnL,
+		"availableBalance":      availableBalance,
+		"balance":               totalEquity, // 兼容其他交易所格式
 	}
 
 	// 更新缓存
@@ -183,6 +195,12 @@ func (t *BybitTrader) GetPositions() ([]map[string]interface{}, error) {
 		entryPriceStr, _ := pos["avgPrice"].(string)
 		entryPrice, _ := strconv.ParseFloat(entryPriceStr, 64)
 
+		markPriceStr, _ := pos["markPrice"].(string)
+		markPrice, _ := strconv.ParseFloat(markPriceStr, 64)
+
+		liqPriceStr, _ := pos["liqPrice"].(string)
+		liqPrice, _ := strconv.ParseFloat(liqPriceStr, 64)
+
 		unrealisedPnlStr, _ := pos["unrealisedPnl"].(string)
 		unrealisedPnl, _ := strconv.ParseFloat(unrealisedPnlStr, 64)
 
@@ -200,12 +218,14 @@ func (t *BybitTrader) GetPositions() ([]map[string]interface{}, error) {
 		}
 
 		position := map[string]interface{}{
-			"symbol":        pos["symbol"],
-			"side":          side,
-			"positionAmt":   positionAmt,
-			"entryPrice":    entryPrice,
-			"unrealizedPnL": unrealisedPnl,
-			"leverage":      int(leverage),
+			"symbol":           pos["symbol"],
+			"side":             side,
+			"positionAmt":      positionAmt,
+			"entryPrice":       entryPrice,
+			"markPrice":        markPrice,
+			"unRealizedProfit": unrealisedPnl,
+			"liquidationPrice": liqPrice,
+			"leverage":         int(leverage),
 		}
 
 		positions = append(positions, position)
@@ -274,6 +294,116 @@ func (t *BybitTrader) OpenShort(symbol string, quantity float64, leverage int) (
 	return t.parseOrderResult(result)
 }
 
+// OpenLimitOrder 限价开仓（timeInForce: GTC/IOC/GTX，GTX 对应 Bybit 的 PostOnly）
+func (t *BybitTrader) OpenLimitOrder(symbol, side string, quantity float64, leverage int, price float64, timeInForce string) (map[string]interface{}, error) {
+	if err := t.SetLeverage(symbol, leverage); err != nil {
+		log.Printf("⚠️ [Bybit] 设置杠杆失败: %v", err)
+	}
+
+	orderSide := "Buy"
+	if side == "short" {
+		orderSide = "Sell"
+	}
+
+	tif := "GTC"
+	switch timeInForce {
+	case TimeInForceIOC:
+		tif = "IOC"
+	case TimeInForcePostOnly:
+		tif = "PostOnly"
+	}
+
+	params := map[string]interface{}{
+		"category":    "linear",
+		"symbol":      symbol,
+		"side":        orderSide,
+		"orderType":   "Limit",
+		"qty":         fmt.Sprintf("%v", quantity),
+		"price":       fmt.Sprintf("%v", price),
+		"timeInForce": tif,
+		"positionIdx": 0, // 单向持仓模式
+	}
+
+	result, err := t.client.NewUtaBybitServiceWithParams(params).PlaceOrder(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("Bybit 限价开仓失败: %w", err)
+	}
+
+	order, err := t.parseOrderResult(result)
+	if err != nil {
+		return nil, err
+	}
+	order["symbol"] = symbol
+	order["price"] = price
+	order["qty"] = quantity
+
+	log.Printf("  ✓ [Bybit] 限价单已提交: %s %s %.4f @ %.4f (%s)", symbol, side, quantity, price, tif)
+	return order, nil
+}
+
+// GetOrder 查询订单状态（先查活动订单，查不到再查历史订单）
+func (t *BybitTrader) GetOrder(symbol, orderID string) (map[string]interface{}, error) {
+	params := map[string]interface{}{
+		"category": "linear",
+		"symbol":   symbol,
+		"orderId":  orderID,
+	}
+
+	result, err := t.client.NewUtaBybitServiceWithParams(params).GetOpenOrders(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("查询订单失败: %w", err)
+	}
+	order := firstBybitOrder(result)
+
+	if order == nil {
+		result, err = t.client.NewUtaBybitServiceWithParams(params).GetOrderHistory(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("查询历史订单失败: %w", err)
+		}
+		order = firstBybitOrder(result)
+	}
+	if order == nil {
+		return nil, fmt.Errorf("订单不存在: %s", orderID)
+	}
+
+	status, _ := order["orderStatus"].(string)
+	qty, _ := SafeFloat64(order, "qty")
+	filled, _ := SafeFloat64(order, "cumExecQty")
+	price, _ := SafeFloat64(order, "avgPrice")
+	if price == 0 {
+		price, _ = SafeFloat64(order, "price")
+	}
+
+	return map[string]interface{}{
+		"orderId":     orderID,
+		"symbol":      symbol,
+		"status":      NormalizeOrderStatus(status),
+		"price":       price,
+		"qty":         qty,
+		"executedQty": filled,
+	}, nil
+}
+
+// CancelOrder 取消单个订单
+func (t *BybitTrader) CancelOrder(symbol, orderID string) error {
+	params := map[string]interface{}{
+		"category": "linear",
+		"symbol":   symbol,
+		"orderId":  orderID,
+	}
+
+	result, err := t.client.NewUtaBybitServiceWithParams(params).CancelOrder(context.Background())
+	if err != nil {
+		return fmt.Errorf("取消订单失败: %w", err)
+	}
+	if result.RetCode != 0 {
+		return fmt.Errorf("取消订单失败: %s", result.RetMsg)
+	}
+
+	log.Printf("  ✓ [Bybit] 已取消订单 %s (%s)", orderID, symbol)
+	return nil
+}
+
 // CloseLong 平多仓
 func (t *BybitTrader) CloseLong(symbol string, quantity float64) (map[string]interface{}, error) {
 	// 如果 quantity = 0，获取当前持仓数量
@@ -532,6 +662,66 @@ func (t *BybitTrader) SetTakeProfit(symbol string, positionSide string, quantity
 	return nil
 }
 
+// SetTrailingStop 通过 trading-stop 接口设置持仓的原生追踪止损
+// Bybit 只支持价格距离，按回调比例设置时换算为当前价格的距离
+func (t *BybitTrader) SetTrailingStop(symbol, positionSide string, quantity, callbackRate, distance, activationPrice float64) (map[string]interface{}, error) {
+	if distance <= 0 {
+		price, err := t.GetMarketPrice(symbol)
+		if err != nil {
+			return nil, err
+		}
+		distance = roundSignificant(price*callbackRate/100, 4)
+	}
+	if distance <= 0 {
+		return nil, fmt.Errorf("无效的追踪止损距离: %v", distance)
+	}
+
+	params := map[string]interface{}{
+		"category":     "linear",
+		"symbol":       symbol,
+		"tpslMode":     "Full",
+		"trailingStop": strconv.FormatFloat(distance, 'f', -1, 64),
+		"positionIdx":  0, // 单向持仓模式
+	}
+	if activationPrice > 0 {
+		params["activePrice"] = fmt.Sprintf("%v", activationPrice)
+	}
+
+	result, err := t.client.NewUtaBybitServiceWithParams(params).SetPositionTradingStop(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("设置追踪止损失败: %w", err)
+	}
+	if result.RetCode != 0 {
+		return nil, fmt.Errorf("设置追踪止损失败: %s", result.RetMsg)
+	}
+
+	log.Printf("  ✓ [Bybit] 追踪止损已设置: %s %s 距离 %v", symbol, positionSide, distance)
+	return map[string]interface{}{
+		"symbol": symbol,
+		"status": "NEW",
+	}, nil
+}
+
+// CancelTrailingStop 取消持仓的追踪止损（trailingStop 设为 0）
+func (t *BybitTrader) CancelTrailingStop(symbol, positionSide, orderID string) error {
+	params := map[string]interface{}{
+		"category":     "linear",
+		"symbol":       symbol,
+		"tpslMode":     "Full",
+		"trailingStop": "0",
+		"positionIdx":  0,
+	}
+
+	result, err := t.client.NewUtaBybitServiceWithParams(params).SetPositionTradingStop(context.Background())
+	if err != nil {
+		return fmt.Errorf("取消追踪止损失败: %w", err)
+	}
+	if result.RetCode != 0 {
+		return fmt.Errorf("取消追踪止损失败: %s", result.RetMsg)
+	}
+	return nil
+}
+
 // CancelStopLossOrders 取消止损单
 func (t *BybitTrader) CancelStopLossOrders(symbol string) error {
 	return t.cancelConditionalOrders(symbol, "StopLoss")
@@ -615,6 +805,23 @@ func (t *BybitTrader) parseOrderResult(result *bybit.ServerResponse) (map[string
 	}, nil
 }
 
+// firstBybitOrder 取出订单查询结果 list 中的第一条
+func firstBybitOrder(result *bybit.ServerResponse) map[string]interface{} {
+	if result == nil || result.RetCode != 0 {
+		return nil
+	}
+	resultData, ok := result.Result.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	list, _ := resultData["list"].([]interface{})
+	if len(list) == 0 {
+		return nil
+	}
+	order, _ := list[0].(map[string]interface{})
+	return order
+}
+
 func (t *BybitTrader) cancelConditionalOrders(symbol string, orderType string) error {
 	// 先获取所有条件单
 	params := map[string]interface{}{
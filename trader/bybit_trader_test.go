@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	bybit "github.com/bybit-exchange/bybit.go.api"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -92,6 +93,7 @@ func (s *BybitTraderTestSuite) Cleanup() {
 // TestBybitTrader_InterfaceCompliance 测试接口兼容性
 func TestBybitTrader_InterfaceCompliance(t *testing.T) {
 	var _ Trader = (*BybitTrader)(nil)
+	var _ NativeTrailingStopper = (*BybitTrader)(nil)
 }
 
 // ============================================================
@@ -354,6 +356,72 @@ func TestBybitTrader_CacheDuration(t *testing.T) {
 // 四、Mock 服务器集成测试
 // ============================================================
 
+// newBybitMockServer 模拟 Bybit v5 统一账户接口（余额、持仓、杠杆、下单）
+func newBybitMockServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ok := func(result interface{}) map[string]interface{} {
+			return map[string]interface{}{"retCode": 0, "retMsg": "OK", "result": result}
+		}
+		var respBody interface{}
+
+		switch r.URL.Path {
+		case "/v5/account/wallet-balance":
+			respBody = ok(map[string]interface{}{
+				"list": []map[string]interface{}{
+					{
+						"accountType":           "UNIFIED",
+						"totalEquity":           "10100.50",
+						"totalWalletBalance":    "10000.00",
+						"totalPerpUPL":          "100.50",
+						"totalAvailableBalance": "8000.00",
+					},
+				},
+			})
+		case "/v5/position/list":
+			respBody = ok(map[string]interface{}{
+				"list": []map[string]interface{}{
+					{
+						"symbol": "BTCUSDT", "side": "Buy", "size": "0.5", "avgPrice": "50000.00", "markPrice": "50500.00",
+						"unrealisedPnl": "250.00", "liqPrice": "45000.00", "leverage": "10", "positionIdx": 0,
+					},
+					{
+						// 已平仓记录数量为 0，应被忽略
+						"symbol": "ETHUSDT", "side": "", "size": "0", "avgPrice": "0", "positionIdx": 0,
+					},
+				},
+			})
+		case "/v5/position/set-leverage":
+			respBody = ok(map[string]interface{}{})
+		case "/v5/order/create":
+			respBody = ok(map[string]interface{}{"orderId": "1234567890", "orderLinkId": ""})
+		default:
+			t.Logf("未处理的 Bybit mock 请求: %s %s", r.Method, r.URL.Path)
+			respBody = map[string]interface{}{"retCode": 10001, "retMsg": "unknown path", "result": map[string]interface{}{}}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(respBody)
+	}))
+}
+
+// newTestBybitTrader 创建指向 mock 服务器的 Bybit 交易器
+func newTestBybitTrader(server *httptest.Server) *BybitTrader {
+	client := bybit.NewBybitHttpClient("test_api_key", "test_secret_key", bybit.WithBaseURL(server.URL))
+	client.HTTPClient = server.Client()
+	return &BybitTrader{client: client, cacheDuration: 15 * time.Second}
+}
+
+// TestBybitTrader_DomainContract 通过 mock 服务器走真实解析路径校验领域模型契约
+// Bybit 适配器尚未实现 GetTradeHistory，成交记录不在校验范围内
+func TestBybitTrader_DomainContract(t *testing.T) {
+	server := newBybitMockServer(t)
+	defer server.Close()
+
+	AssertDomainContract(t, DomainContractCase{
+		Exchange: "bybit", Trader: newTestBybitTrader(server), Symbol: "BTCUSDT", Quantity: 0.01, Price: 49000,
+	})
+}
+
 // TestBybitTrader_MockServerGetBalance 测试通过 Mock 服务器获取余额
 func TestBybitTrader_MockServerGetBalance(t *testing.T) {
 	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,108 @@
+package trader
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"nofx/decision"
+	"nofx/logger"
+	"nofx/mcp"
+)
+
+// EnsembleModelConfig 参与集成决策的模型配置
+type EnsembleModelConfig struct {
+	Name            string // 显示名称（用于日志），为空时使用 Provider
+	Provider        string // "deepseek" | "qwen" | "anthropic" | "gemini" | "custom"
+	APIKey          string
+	CustomAPIURL    string
+	CustomModelName string
+}
+
+// newEnsembleClient 按提供商创建 AI 客户端
+func newEnsembleClient(model EnsembleModelConfig) mcp.AIClient {
+	var client mcp.AIClient
+	switch model.Provider {
+	case "qwen":
+		client = mcp.NewQwenClient()
+	case "deepseek":
+		client = mcp.NewDeepSeekClient()
+	case mcp.ProviderAnthropic:
+		client = mcp.NewAnthropicClient()
+	case mcp.ProviderGemini:
+		client = mcp.NewGeminiClient()
+	default:
+		client = mcp.New()
+	}
+	client.SetAPIKey(model.APIKey, model.CustomAPIURL, model.CustomModelName)
+	return client
+}
+
+// buildEnsembleMembers 根据配置构建集成决策成员，主模型排在第一位。未配置其他模型时返回 nil
+func buildEnsembleMembers(config AutoTraderConfig, primary mcp.AIClient) []decision.EnsembleMember {
+	if len(config.EnsembleModels) == 0 {
+		return nil
+	}
+
+	members := []decision.EnsembleMember{{Name: config.AIModel, Client: primary}}
+	for _, model := range config.EnsembleModels {
+		name := model.Name
+		if name == "" {
+			name = model.Provider
+		}
+		members = append(members, decision.EnsembleMember{Name: name, Client: newEnsembleClient(model)})
+	}
+
+	names := make([]string, 0, len(members))
+	for _, m := range members {
+		names = append(names, m.Name)
+	}
+	log.Printf("🗳️ [%s] 启用多模型集成决策: %v（投票方式: %s）", config.Name, names, config.EnsembleVote)
+	return members
+}
+
+// requestDecisionWithPrompt 使用指定提示词获取 AI 决策。配置了集成模型时并发请求所有成员并投票合并，成员输出写入 record
+func (at *AutoTrader) requestDecisionWithPrompt(ctx *decision.Context, record *logger.DecisionRecord, prompt promptSettings) (*decision.FullDecision, error) {
+	if len(at.ensembleMembers) == 0 {
+		// 单模型时流式接收输出，供前端实时展示思考过程（集成决策并发请求多个模型，不做流式展示）
+		ctx.OnStream = at.liveThinking.append
+		return decision.GetFullDecisionWithCustomPrompt(ctx, at.mcpClient, prompt.customPrompt, prompt.overrideBase, prompt.template)
+	}
+
+	vote, err := decision.NormalizeEnsembleVote(at.config.EnsembleVote)
+	if err != nil {
+		return nil, err
+	}
+	decider := decision.DirectMemberDecider(ctx, prompt.customPrompt, prompt.overrideBase, prompt.template)
+	merged, results, err := decision.GetEnsembleDecision(ctx, at.ensembleMembers, vote, decider)
+
+	record.EnsembleVote = vote
+	record.EnsembleMembers = append(record.EnsembleMembers, ensembleMemberRecords(results)...)
+	for _, m := range record.EnsembleMembers {
+		if m.Error != "" {
+			record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("集成成员 %s 失败: %s", m.Name, m.Error))
+		}
+	}
+	return merged, err
+}
+
+// ensembleMemberRecords 把成员结果转换为决策日志记录
+func ensembleMemberRecords(results []decision.MemberResult) []logger.EnsembleMemberRecord {
+	records := make([]logger.EnsembleMemberRecord, 0, len(results))
+	for _, r := range results {
+		rec := logger.EnsembleMemberRecord{Name: r.Name}
+		if r.Err != nil {
+			rec.Error = r.Err.Error()
+		}
+		if r.Decision != nil {
+			rec.RawResponse = r.Decision.RawResponse
+			rec.CoTTrace = r.Decision.CoTTrace
+			rec.DurationMs = r.Decision.AIRequestDurationMs
+			if len(r.Decision.Decisions) > 0 {
+				decisionJSON, _ := json.MarshalIndent(r.Decision.Decisions, "", "  ")
+				rec.DecisionJSON = string(decisionJSON)
+			}
+		}
+		records = append(records, rec)
+	}
+	return records
+}
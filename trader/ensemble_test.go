@@ -0,0 +1,48 @@
+package trader
+
+import (
+	"errors"
+	"nofx/decision"
+	"nofx/mcp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildEnsembleMembers(t *testing.T) {
+	primary := mcp.NewDeepSeekClient()
+	assert.Nil(t, buildEnsembleMembers(AutoTraderConfig{AIModel: "deepseek"}, primary), "未配置集成模型时只用主模型")
+
+	members := buildEnsembleMembers(AutoTraderConfig{
+		AIModel: "deepseek",
+		EnsembleModels: []EnsembleModelConfig{
+			{Name: "Qwen", Provider: "qwen", APIKey: "k1"},
+			{Provider: "custom", APIKey: "k2", CustomAPIURL: "https://example.com/v1", CustomModelName: "m"},
+		},
+	}, primary)
+	require.Len(t, members, 3)
+	assert.Equal(t, "deepseek", members[0].Name)
+	assert.Same(t, primary, members[0].Client, "主模型排在第一位")
+	assert.Equal(t, "Qwen", members[1].Name)
+	assert.IsType(t, &mcp.QwenClient{}, members[1].Client)
+	assert.Equal(t, "custom", members[2].Name, "未设置名称时使用提供商")
+}
+
+func TestEnsembleMemberRecords(t *testing.T) {
+	records := ensembleMemberRecords([]decision.MemberResult{
+		{Name: "a", Decision: &decision.FullDecision{
+			RawResponse:         "raw",
+			CoTTrace:            "cot",
+			AIRequestDurationMs: 120,
+			Decisions:           []decision.Decision{{Symbol: "BTCUSDT", Action: "wait"}},
+		}},
+		{Name: "b", Err: errors.New("timeout")},
+	})
+
+	require.Len(t, records, 2)
+	assert.Equal(t, "raw", records[0].RawResponse)
+	assert.Equal(t, int64(120), records[0].DurationMs)
+	assert.Contains(t, records[0].DecisionJSON, `"action": "wait"`)
+	assert.Equal(t, "timeout", records[1].Error)
+}
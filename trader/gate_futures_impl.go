@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
+	"math"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -510,6 +511,121 @@ func (t *GateFuturesTraderImpl) RawPlaceOrder(req map[string]interface{}) (map[s
 	return result, nil
 }
 
+// OpenLimitOrder 限价开仓（quantity 为合约张数，timeInForce: GTC/IOC/GTX，GTX 对应 Gate.io 的 poc）
+func (t *GateFuturesTraderImpl) OpenLimitOrder(symbol, side string, quantity float64, leverage int, price float64, timeInForce string) (map[string]interface{}, error) {
+	gateSymbol := toGateContract(symbol)
+
+	if err := t.SetLeverage(gateSymbol, leverage); err != nil {
+		return nil, err
+	}
+
+	tif := "gtc"
+	switch timeInForce {
+	case TimeInForceIOC:
+		tif = "ioc"
+	case TimeInForcePostOnly:
+		tif = "poc"
+	}
+
+	orderSide := "buy"
+	if side == "short" {
+		orderSide = "sell"
+	}
+
+	gateReq := map[string]interface{}{
+		"contract":    gateSymbol,
+		"type":        "limit",
+		"text":        "t-auto",
+		"tif":         tif,
+		"side":        orderSide,
+		"size":        int64(quantity),
+		"price":       strconv.FormatFloat(price, 'f', -1, 64),
+		"reduce_only": false,
+	}
+
+	body, err := t.sendRequest("POST", "/orders", gateReq)
+	if err != nil {
+		return nil, fmt.Errorf("OpenLimitOrder error: %w", err)
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("JSON unmarshal error: %w", err)
+	}
+
+	return gateOrderToMap(symbol, result), nil
+}
+
+// GetOrder 查询订单状态
+func (t *GateFuturesTraderImpl) GetOrder(symbol, orderID string) (map[string]interface{}, error) {
+	body, err := t.sendRequest("GET", "/orders/"+orderID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("GetOrder error: %w", err)
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("JSON unmarshal error: %w", err)
+	}
+
+	return gateOrderToMap(symbol, result), nil
+}
+
+// CancelOrder 取消单个订单
+func (t *GateFuturesTraderImpl) CancelOrder(symbol, orderID string) error {
+	if _, err := t.sendRequest("DELETE", "/orders/"+orderID, nil); err != nil {
+		return fmt.Errorf("CancelOrder error: %w", err)
+	}
+	return nil
+}
+
+// gateOrderToMap 将 Gate.io 订单转换为统一格式
+// Gate.io 使用 status(open/finished) + finish_as 表示订单状态，left 为未成交张数
+func gateOrderToMap(symbol string, order map[string]interface{}) map[string]interface{} {
+	size := math.Abs(convertToFloat64(order["size"]))
+	left := math.Abs(convertToFloat64(order["left"]))
+	filled := size - left
+
+	status, _ := order["status"].(string)
+	finishAs, _ := order["finish_as"].(string)
+
+	normalized := OrderStatusNew
+	switch {
+	case status == "open" && filled > 0:
+		normalized = OrderStatusPartiallyFilled
+	case status == "open":
+		normalized = OrderStatusNew
+	case finishAs == "filled" || (status == "finished" && left == 0):
+		normalized = OrderStatusFilled
+	case finishAs == "poc" || finishAs == "stp":
+		normalized = OrderStatusRejected
+	default:
+		normalized = OrderStatusCanceled
+	}
+
+	price := convertToFloat64(order["fill_price"])
+	if price == 0 {
+		price = convertToFloat64(order["price"])
+	}
+
+	return map[string]interface{}{
+		"orderId":     int64(convertToFloat64(order["id"])),
+		"symbol":      symbol,
+		"status":      normalized,
+		"price":       price,
+		"qty":         size,
+		"executedQty": filled,
+	}
+}
+
+// toGateContract 将 ETHUSDT 转换为 Gate.io 的 ETH_USDT 格式
+func toGateContract(symbol string) string {
+	if strings.Contains(symbol, "_") || !strings.HasSuffix(symbol, "USDT") {
+		return symbol
+	}
+	return strings.TrimSuffix(symbol, "USDT") + "_USDT"
+}
+
 // OpenLong 开多仓
 func (t *GateFuturesTraderImpl) OpenLong(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
 	// 转换为Gate.io格式
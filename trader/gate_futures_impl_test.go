@@ -0,0 +1,52 @@
+package trader
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newGateMockServer 模拟 Gate.io USDT 合约接口，返回录制的原始响应
+func newGateMockServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NotEmpty(t, r.Header.Get("KEY"), "请求缺少 KEY 头")
+		assert.NotEmpty(t, r.Header.Get("SIGN"), "请求缺少 SIGN 头")
+
+		var payload string
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/accounts":
+			payload = `{"total":"812.0","unrealised_pnl":"12.0","currency":"USDT","available":"600.0","cross_margin_balance":"800.0","cross_unrealised_pnl":"12.0","position_margin":"188.0","order_margin":"0"}`
+		case r.Method == http.MethodGet && r.URL.Path == "/positions":
+			payload = `[{"contract":"BTC_USDT","size":-3,"leverage":"10","entry_price":"61000","mark_price":"60800","liq_price":"66000","unrealised_pnl":"6.0","mode":"single"},` +
+				`{"contract":"ETH_USDT","size":0,"leverage":"5","entry_price":"0","mark_price":"3000","liq_price":"0","unrealised_pnl":"0","mode":"single"}]`
+		case r.Method == http.MethodPost && r.URL.Path == "/positions/BTC_USDT/leverage":
+			payload = `{"contract":"BTC_USDT","size":-3,"leverage":"10"}`
+		case r.Method == http.MethodPost && r.URL.Path == "/orders":
+			payload = `{"id":5550002,"contract":"BTC_USDT","size":1,"left":1,"price":"49000","fill_price":"0","status":"open","tif":"gtc","text":"t-auto","create_time":1700000100.5}`
+		case r.Method == http.MethodGet && r.URL.Path == "/orders":
+			payload = `[{"id":5550001,"contract":"BTC_USDT","size":-3,"left":0,"price":"61000","fill_price":"60990","status":"finished","finish_as":"filled","create_time":1700000000}]`
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(payload))
+	}))
+}
+
+// TestGateFuturesTrader_DomainContract 通过 mock 服务器走真实解析路径校验领域模型契约
+func TestGateFuturesTrader_DomainContract(t *testing.T) {
+	server := newGateMockServer(t)
+	defer server.Close()
+
+	trader := NewGateFuturesTrader("test_key", "test_secret", "")
+	trader.baseURL = server.URL
+	trader.client = server.Client()
+
+	AssertDomainContract(t, DomainContractCase{
+		Exchange: "gate", Trader: trader, Symbol: "BTC_USDT",
+		Quantity: 1, Price: 49000, HasTradeHistory: true,
+	})
+}
@@ -2,6 +2,7 @@ package trader
 
 import (
 	"fmt"
+	"math"
 	"strconv"
 )
 
@@ -74,3 +75,12 @@ func SafeInt(data map[string]interface{}, key string) (int, error) {
 		return 0, fmt.Errorf("value for key '%s' is not an integer (type: %T)", key, v)
 	}
 }
+
+// roundSignificant 将数值四舍五入到指定的有效数字位数
+func roundSignificant(value float64, digits int) float64 {
+	if value == 0 || digits <= 0 {
+		return value
+	}
+	scale := math.Pow(10, float64(digits)-math.Ceil(math.Log10(math.Abs(value))))
+	return math.Round(value*scale) / scale
+}
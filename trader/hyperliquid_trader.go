@@ -487,6 +487,115 @@ func (t *HyperliquidTrader) OpenShort(symbol string, quantity float64, leverage
 	return result, nil
 }
 
+// OpenLimitOrder 限价开仓（timeInForce: GTC/IOC/GTX，GTX 对应 Hyperliquid 的 Alo）
+func (t *HyperliquidTrader) OpenLimitOrder(symbol, side string, quantity float64, leverage int, price float64, timeInForce string) (map[string]interface{}, error) {
+	if err := t.SetLeverage(symbol, leverage); err != nil {
+		return nil, err
+	}
+
+	coin := convertSymbolToHyperliquid(symbol)
+	roundedQuantity := t.roundToSzDecimals(coin, quantity)
+	roundedPrice := t.roundPriceToSigfigs(price)
+
+	tif := hyperliquid.TifGtc
+	switch timeInForce {
+	case TimeInForceIOC:
+		tif = hyperliquid.TifIoc
+	case TimeInForcePostOnly:
+		tif = hyperliquid.TifAlo
+	}
+
+	order := hyperliquid.CreateOrderRequest{
+		Coin:  coin,
+		IsBuy: side == "long",
+		Size:  roundedQuantity,
+		Price: roundedPrice,
+		OrderType: hyperliquid.OrderType{
+			Limit: &hyperliquid.LimitOrderType{
+				Tif: tif,
+			},
+		},
+		ReduceOnly: false,
+	}
+
+	status, err := t.exchange.Order(t.ctx, order, nil)
+	if err != nil {
+		return nil, fmt.Errorf("限价开仓失败: %w", err)
+	}
+	if status.Error != nil {
+		return nil, fmt.Errorf("限价开仓被拒绝: %s", *status.Error)
+	}
+
+	result := map[string]interface{}{
+		"symbol": symbol,
+		"price":  roundedPrice,
+		"qty":    roundedQuantity,
+	}
+	switch {
+	case status.Filled != nil:
+		result["orderId"] = int64(status.Filled.Oid)
+		result["status"] = OrderStatusFilled
+		result["executedQty"], _ = strconv.ParseFloat(status.Filled.TotalSz, 64)
+		if avgPx, err := strconv.ParseFloat(status.Filled.AvgPx, 64); err == nil {
+			result["price"] = avgPx
+		}
+	case status.Resting != nil:
+		result["orderId"] = status.Resting.Oid
+		result["status"] = OrderStatusNew
+		result["executedQty"] = 0.0
+	default:
+		return nil, fmt.Errorf("限价开仓返回未知状态: %s", status.String())
+	}
+
+	log.Printf("✓ 限价单已提交: %s %s 数量: %.4f 价格: %.4f (%s)", symbol, side, roundedQuantity, roundedPrice, tif)
+	return result, nil
+}
+
+// GetOrder 查询订单状态（已成交数量 = 原始数量 - 剩余数量）
+func (t *HyperliquidTrader) GetOrder(symbol, orderID string) (map[string]interface{}, error) {
+	oid, err := strconv.ParseInt(orderID, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("无效的订单ID: %s", orderID)
+	}
+
+	res, err := t.exchange.Info().QueryOrderByOid(t.ctx, t.walletAddr, oid)
+	if err != nil {
+		return nil, fmt.Errorf("查询订单失败: %w", err)
+	}
+	if res.Status != hyperliquid.OrderQueryStatusSuccess {
+		return nil, fmt.Errorf("订单不存在: %s", orderID)
+	}
+
+	origSz, _ := strconv.ParseFloat(res.Order.Order.OrigSz, 64)
+	remaining, _ := strconv.ParseFloat(res.Order.Order.Sz, 64)
+	limitPx, _ := strconv.ParseFloat(res.Order.Order.LimitPx, 64)
+
+	return map[string]interface{}{
+		"orderId":     oid,
+		"symbol":      symbol,
+		"status":      NormalizeOrderStatus(string(res.Order.Status)),
+		"price":       limitPx,
+		"qty":         origSz,
+		"executedQty": origSz - remaining,
+	}, nil
+}
+
+// CancelOrder 取消单个订单
+func (t *HyperliquidTrader) CancelOrder(symbol, orderID string) error {
+	oid, err := strconv.ParseInt(orderID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("无效的订单ID: %s", orderID)
+	}
+
+	coin := convertSymbolToHyperliquid(symbol)
+	if _, err := t.exchange.Cancel(t.ctx, coin, oid); err != nil {
+		return fmt.Errorf("取消订单失败: %w", err)
+	}
+
+	log.Printf("  ✓ 已取消订单 %s (%s)", orderID, symbol)
+	return nil
+}
+
 // CloseLong 平多仓
 func (t *HyperliquidTrader) CloseLong(symbol string, quantity float64) (map[string]interface{}, error) {
 	// 如果数量为0，获取当前持仓数量
@@ -265,6 +265,17 @@ func TestHyperliquidTrader_CommonInterface(t *testing.T) {
 // 三、Hyperliquid 特定功能的单元测试
 // ============================================================
 
+// TestHyperliquidTrader_DomainContract 通过 mock 服务器走真实解析路径校验领域模型契约
+// Hyperliquid 适配器尚未实现 GetTradeHistory，成交记录不在校验范围内
+func TestHyperliquidTrader_DomainContract(t *testing.T) {
+	suite := NewHyperliquidTestSuite(t)
+	defer suite.Cleanup()
+
+	AssertDomainContract(t, DomainContractCase{
+		Exchange: "hyperliquid", Trader: suite.Trader, Symbol: "BTCUSDT", Quantity: 0.01, Price: 49000,
+	})
+}
+
 // TestNewHyperliquidTrader 测试创建 Hyperliquid 交易器
 func TestNewHyperliquidTrader(t *testing.T) {
 	tests := []struct {
@@ -15,6 +15,15 @@ type Trader interface {
 	// OpenShort 开空仓
 	OpenShort(symbol string, quantity float64, leverage int) (map[string]interface{}, error)
 
+	// OpenLimitOrder 限价开仓（side: long/short，timeInForce: GTC/IOC/GTX，GTX 表示只做Maker）
+	OpenLimitOrder(symbol, side string, quantity float64, leverage int, price float64, timeInForce string) (map[string]interface{}, error)
+
+	// GetOrder 查询订单状态（status 统一为 NEW/PARTIALLY_FILLED/FILLED/CANCELED/REJECTED/EXPIRED）
+	GetOrder(symbol, orderID string) (map[string]interface{}, error)
+
+	// CancelOrder 取消单个订单
+	CancelOrder(symbol, orderID string) error
+
 	// CloseLong 平多仓（quantity=0表示全部平仓）
 	CloseLong(symbol string, quantity float64) (map[string]interface{}, error)
 
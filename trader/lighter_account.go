@@ -16,8 +16,8 @@ type AccountBalance struct {
 	MaintenanceMargin float64 `json:"maintenance_margin"` // 维持保证金
 }
 
-// Position 持仓信息
-type Position struct {
+// LighterPosition LIGHTER 持仓信息（API 原始结构）
+type LighterPosition struct {
 	Symbol           string  `json:"symbol"`            // 交易对
 	Side             string  `json:"side"`              // "long" 或 "short"
 	Size             float64 `json:"size"`              // 持仓大小
@@ -91,7 +91,7 @@ func (t *LighterTrader) GetAccountBalance() (*AccountBalance, error) {
 }
 
 // GetPositionsRaw 获取所有持仓（返回原始类型）
-func (t *LighterTrader) GetPositionsRaw(symbol string) ([]Position, error) {
+func (t *LighterTrader) GetPositionsRaw(symbol string) ([]LighterPosition, error) {
 	if err := t.ensureAuthToken(); err != nil {
 		return nil, fmt.Errorf("认证令牌无效: %w", err)
 	}
@@ -130,7 +130,7 @@ func (t *LighterTrader) GetPositionsRaw(symbol string) ([]Position, error) {
 		return nil, fmt.Errorf("获取持仓失败 (status %d): %s", resp.StatusCode, string(body))
 	}
 
-	var positions []Position
+	var positions []LighterPosition
 	if err := json.Unmarshal(body, &positions); err != nil {
 		return nil, fmt.Errorf("解析持仓响应失败: %w", err)
 	}
@@ -164,7 +164,7 @@ func (t *LighterTrader) GetPositions() ([]map[string]interface{}, error) {
 }
 
 // GetPosition 获取指定币种的持仓
-func (t *LighterTrader) GetPosition(symbol string) (*Position, error) {
+func (t *LighterTrader) GetPosition(symbol string) (*LighterPosition, error) {
 	positions, err := t.GetPositionsRaw(symbol)
 	if err != nil {
 		return nil, err
@@ -23,7 +23,9 @@ type CreateOrderRequest struct {
 
 // OrderResponse 订单响应
 type OrderResponse struct {
-	OrderID      string  `json:"order_id"`
+	OrderID          string  `json:"order_id"`
+	OrderIndex       int64   `json:"order_index"`        // 交易所分配的訂單索引（撤單/查詢使用）
+	ClientOrderIndex int64   `json:"client_order_index"` // 下單時提交的客戶端訂單索引
 	Symbol       string  `json:"symbol"`
 	Side         string  `json:"side"`
 	OrderType    string  `json:"order_type"`
@@ -73,6 +75,68 @@ func (t *LighterTrader) CreateOrderWithOptions(symbol, side string, quantity, pr
 	return orderResp.OrderID, nil
 }
 
+// OpenLimitOrder 限价开仓（实现 Trader 接口，timeInForce: GTC/IOC/GTX，GTX 对应 PostOnly）
+func (t *LighterTrader) OpenLimitOrder(symbol, side string, quantity float64, leverage int, price float64, timeInForce string) (map[string]interface{}, error) {
+	if err := t.ensureAuthToken(); err != nil {
+		return nil, fmt.Errorf("认证令牌无效: %w", err)
+	}
+
+	orderSide := "buy"
+	if side == "short" {
+		orderSide = "sell"
+	}
+
+	req := CreateOrderRequest{
+		Symbol:      symbol,
+		Side:        orderSide,
+		OrderType:   "limit",
+		Quantity:    quantity,
+		Price:       price,
+		TimeInForce: TimeInForceGTC,
+	}
+	switch timeInForce {
+	case TimeInForceIOC:
+		req.TimeInForce = TimeInForceIOC
+	case TimeInForcePostOnly:
+		req.PostOnly = true
+	}
+
+	orderResp, err := t.sendOrder(req)
+	if err != nil {
+		return nil, fmt.Errorf("限价开仓失败: %w", err)
+	}
+
+	log.Printf("✓ LIGHTER限价单已创建 - ID: %s, Symbol: %s, Side: %s, Qty: %.4f, Price: %.4f",
+		orderResp.OrderID, symbol, orderSide, quantity, price)
+
+	return lighterOrderToMap(symbol, orderResp), nil
+}
+
+// GetOrder 查询订单状态（实现 Trader 接口）
+func (t *LighterTrader) GetOrder(symbol, orderID string) (map[string]interface{}, error) {
+	order, err := t.GetOrderStatus(orderID)
+	if err != nil {
+		return nil, err
+	}
+	return lighterOrderToMap(symbol, order), nil
+}
+
+// lighterOrderToMap 将 LIGHTER 订单转换为统一的订单 map
+func lighterOrderToMap(symbol string, order *OrderResponse) map[string]interface{} {
+	status := NormalizeOrderStatus(order.Status)
+	if status == OrderStatusNew && order.FilledQty > 0 {
+		status = OrderStatusPartiallyFilled
+	}
+	return map[string]interface{}{
+		"orderId":     order.OrderID,
+		"symbol":      symbol,
+		"status":      status,
+		"price":       order.Price,
+		"qty":         order.Quantity,
+		"executedQty": order.FilledQty,
+	}
+}
+
 // sendOrder 发送订单到LIGHTER API
 func (t *LighterTrader) sendOrder(orderReq CreateOrderRequest) (*OrderResponse, error) {
 	endpoint := fmt.Sprintf("%s/api/v1/order", t.baseURL)
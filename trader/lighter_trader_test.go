@@ -6,9 +6,11 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // ============================================================
@@ -259,3 +261,119 @@ func TestLighterTrader_HelperFunctions(t *testing.T) {
 
 	t.Logf("✅ Helper functions working correctly")
 }
+
+// ============================================================
+// LIGHTER V2 订单查询测试
+// ============================================================
+
+// newLighterV2OrderServer 模拟活跃订单和已结束订单接口
+func newLighterV2OrderServer(active, inactive []OrderResponse) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var orders []OrderResponse
+		switch r.URL.Path {
+		case "/api/v1/accountActiveOrders":
+			orders = active
+		case "/api/v1/accountInactiveOrders":
+			orders = inactive
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"code": 200, "data": orders})
+	}))
+}
+
+func newMockLighterV2Trader(server *httptest.Server) *LighterTraderV2 {
+	return &LighterTraderV2{
+		client:         server.Client(),
+		baseURL:        server.URL,
+		authToken:      "test_token",
+		tokenExpiry:    time.Now().Add(time.Hour),
+		marketIndexMap: map[string]uint8{"BTC": 0},
+	}
+}
+
+// TestLighterTraderV2_GetOrder 按订单索引查询，找不到时返回错误而不是猜测状态
+func TestLighterTraderV2_GetOrder(t *testing.T) {
+	server := newLighterV2OrderServer(
+		[]OrderResponse{{OrderID: "11", OrderIndex: 11, ClientOrderIndex: 1001, Status: "open", Quantity: 2, FilledQty: 0.5, Price: 60000}},
+		[]OrderResponse{
+			{OrderID: "12", OrderIndex: 12, ClientOrderIndex: 1002, Status: "filled", Quantity: 1, FilledQty: 1},
+			{OrderID: "13", OrderIndex: 13, ClientOrderIndex: 1003, Status: "canceled-post-only", Quantity: 1},
+		},
+	)
+	defer server.Close()
+	trader := newMockLighterV2Trader(server)
+
+	tests := []struct {
+		orderID    string
+		wantStatus string
+	}{
+		{"11", OrderStatusPartiallyFilled},
+		{"12", OrderStatusFilled},
+		{"13", OrderStatusCanceled},
+	}
+	for _, tt := range tests {
+		order, err := trader.GetOrder("BTC", tt.orderID)
+		require.NoError(t, err, tt.orderID)
+		assert.Equal(t, tt.wantStatus, order["status"], tt.orderID)
+	}
+
+	_, err := trader.GetOrder("BTC", "99")
+	assert.Error(t, err, "订单不存在时应返回错误")
+	_, err = trader.GetOrder("BTC", "0xdeadbeef")
+	assert.Error(t, err, "交易哈希不是有效的订单索引")
+}
+
+// TestLighterTraderV2_ResolveOrderIndex 提交后按客户端订单索引找到交易所订单索引
+func TestLighterTraderV2_ResolveOrderIndex(t *testing.T) {
+	server := newLighterV2OrderServer(
+		[]OrderResponse{{OrderID: "21", OrderIndex: 21, ClientOrderIndex: 2001, Status: "open"}},
+		[]OrderResponse{{OrderID: "22", OrderIndex: 22, ClientOrderIndex: 2002, Status: "canceled"}},
+	)
+	defer server.Close()
+	trader := newMockLighterV2Trader(server)
+
+	originalInterval := lighterOrderResolveInterval
+	lighterOrderResolveInterval = time.Millisecond
+	defer func() { lighterOrderResolveInterval = originalInterval }()
+
+	order, err := trader.resolveOrderIndex("BTC", 2001)
+	require.NoError(t, err)
+	assert.Equal(t, int64(21), order.OrderIndex)
+
+	order, err = trader.resolveOrderIndex("BTC", 2002)
+	require.NoError(t, err, "立即结束的订单应在已结束订单中找到")
+	assert.Equal(t, int64(22), order.OrderIndex)
+
+	_, err = trader.resolveOrderIndex("BTC", 9999)
+	assert.Error(t, err)
+}
+
+// TestLighterTraderV2_DomainContract 余额和持仓通过真实解析路径转换为领域模型
+// 下单需要 TxClient 本地签名，mock 服务器无法覆盖，跳过限价单校验
+func TestLighterTraderV2_DomainContract(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload string
+		switch r.URL.Path {
+		case "/api/v1/account/0/balance":
+			payload = `{"total_equity":1050.0,"available_balance":900.0,"margin_used":150.0,"unrealized_pnl":50.0,"maintenance_margin":20.0}`
+		case "/api/v1/account/0/positions":
+			payload = `[{"symbol":"BTC","side":"long","size":0.1,"entry_price":60000.0,"mark_price":60500.0,"liquidation_price":52000.0,"unrealized_pnl":50.0,"leverage":5.0,"margin_used":1200.0}]`
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(payload))
+	}))
+	defer server.Close()
+
+	AssertDomainContract(t, DomainContractCase{
+		Exchange:       "lighter",
+		Trader:         newMockLighterV2Trader(server),
+		Symbol:         "BTC",
+		SkipLimitOrder: true,
+	})
+}
@@ -93,7 +93,7 @@ func (t *LighterTraderV2) GetPositions() ([]map[string]interface{}, error) {
 }
 
 // GetPositionsRaw 獲取所有持倉（返回原始類型）
-func (t *LighterTraderV2) GetPositionsRaw(symbol string) ([]Position, error) {
+func (t *LighterTraderV2) GetPositionsRaw(symbol string) ([]LighterPosition, error) {
 	if err := t.ensureAuthToken(); err != nil {
 		return nil, fmt.Errorf("認證令牌無效: %w", err)
 	}
@@ -130,7 +130,7 @@ func (t *LighterTraderV2) GetPositionsRaw(symbol string) ([]Position, error) {
 		return nil, fmt.Errorf("獲取持倉失敗 (status %d): %s", resp.StatusCode, string(body))
 	}
 
-	var positions []Position
+	var positions []LighterPosition
 	if err := json.Unmarshal(body, &positions); err != nil {
 		return nil, fmt.Errorf("解析持倉響應失敗: %w", err)
 	}
@@ -139,7 +139,7 @@ func (t *LighterTraderV2) GetPositionsRaw(symbol string) ([]Position, error) {
 }
 
 // GetPosition 獲取指定幣種的持倉
-func (t *LighterTraderV2) GetPosition(symbol string) (*Position, error) {
+func (t *LighterTraderV2) GetPosition(symbol string) (*LighterPosition, error) {
 	positions, err := t.GetPositionsRaw(symbol)
 	if err != nil {
 		return nil, err
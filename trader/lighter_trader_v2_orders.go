@@ -8,6 +8,7 @@ import (
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/elliottech/lighter-go/types"
 )
@@ -136,6 +137,25 @@ func (t *LighterTraderV2) CancelStopOrders(symbol string) error {
 
 // GetActiveOrders 獲取活躍訂單
 func (t *LighterTraderV2) GetActiveOrders(symbol string) ([]OrderResponse, error) {
+	orders, err := t.fetchAccountOrders("accountActiveOrders", symbol, "")
+	if err != nil {
+		return nil, fmt.Errorf("獲取活躍訂單失敗: %w", err)
+	}
+	log.Printf("✓ LIGHTER - 獲取到 %d 個活躍訂單", len(orders))
+	return orders, nil
+}
+
+// GetInactiveOrders 獲取最近已結束的訂單（成交、撤銷、過期）
+func (t *LighterTraderV2) GetInactiveOrders(symbol string, limit int) ([]OrderResponse, error) {
+	orders, err := t.fetchAccountOrders("accountInactiveOrders", symbol, fmt.Sprintf("&limit=%d", limit))
+	if err != nil {
+		return nil, fmt.Errorf("獲取歷史訂單失敗: %w", err)
+	}
+	return orders, nil
+}
+
+// fetchAccountOrders 請求賬戶訂單列表接口（accountActiveOrders / accountInactiveOrders）
+func (t *LighterTraderV2) fetchAccountOrders(path, symbol, extraQuery string) ([]OrderResponse, error) {
 	if err := t.ensureAuthToken(); err != nil {
 		return nil, fmt.Errorf("認證令牌無效: %w", err)
 	}
@@ -147,8 +167,8 @@ func (t *LighterTraderV2) GetActiveOrders(symbol string) ([]OrderResponse, error
 	}
 
 	// 構建請求 URL
-	endpoint := fmt.Sprintf("%s/api/v1/accountActiveOrders?account_index=%d&market_id=%d",
-		t.baseURL, t.accountIndex, marketIndex)
+	endpoint := fmt.Sprintf("%s/api/v1/%s?account_index=%d&market_id=%d%s",
+		t.baseURL, path, t.accountIndex, marketIndex, extraQuery)
 
 	// 發送 GET 請求
 	req, err := http.NewRequest("GET", endpoint, nil)
@@ -173,9 +193,9 @@ func (t *LighterTraderV2) GetActiveOrders(symbol string) ([]OrderResponse, error
 
 	// 解析響應
 	var apiResp struct {
-		Code    int              `json:"code"`
-		Message string           `json:"message"`
-		Data    []OrderResponse  `json:"data"`
+		Code    int             `json:"code"`
+		Message string          `json:"message"`
+		Data    []OrderResponse `json:"data"`
 	}
 
 	if err := json.Unmarshal(body, &apiResp); err != nil {
@@ -183,13 +203,79 @@ func (t *LighterTraderV2) GetActiveOrders(symbol string) ([]OrderResponse, error
 	}
 
 	if apiResp.Code != 200 {
-		return nil, fmt.Errorf("獲取活躍訂單失敗 (code %d): %s", apiResp.Code, apiResp.Message)
+		return nil, fmt.Errorf("code %d: %s", apiResp.Code, apiResp.Message)
 	}
 
-	log.Printf("✓ LIGHTER - 獲取到 %d 個活躍訂單", len(apiResp.Data))
 	return apiResp.Data, nil
 }
 
+// lighterOrderLookupLimit 查找已結束訂單時獲取的歷史訂單數量
+const lighterOrderLookupLimit = 100
+
+// findOrder 依次在活躍訂單和最近已結束訂單中查找滿足條件的訂單，未找到時返回 nil
+func (t *LighterTraderV2) findOrder(symbol string, match func(OrderResponse) bool) (*OrderResponse, error) {
+	active, err := t.GetActiveOrders(symbol)
+	if err != nil {
+		return nil, err
+	}
+	for i := range active {
+		if match(active[i]) {
+			return &active[i], nil
+		}
+	}
+
+	inactive, err := t.GetInactiveOrders(symbol, lighterOrderLookupLimit)
+	if err != nil {
+		return nil, err
+	}
+	for i := range inactive {
+		if match(inactive[i]) {
+			return &inactive[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// lighterOrderStatus 將 LIGHTER 訂單狀態統一為 OrderStatus* 常量
+// LIGHTER 的撤單狀態帶原因後綴（如 canceled-post-only、canceled-expired）
+func lighterOrderStatus(order OrderResponse) string {
+	status := strings.ToLower(order.Status)
+	switch {
+	case strings.HasPrefix(status, "cancel"):
+		return OrderStatusCanceled
+	case status == "open" && order.FilledQty > 0:
+		return OrderStatusPartiallyFilled
+	}
+	return NormalizeOrderStatus(order.Status)
+}
+
+// GetOrder 查詢訂單狀態（實現 Trader 接口）
+// orderID 為交易所分配的訂單索引；LIGHTER 沒有單筆訂單查詢接口，依次在活躍訂單和最近已結束訂單中查找，
+// 都找不到時返回錯誤，而不是根據持倉猜測訂單狀態
+func (t *LighterTraderV2) GetOrder(symbol, orderID string) (map[string]interface{}, error) {
+	orderIndex, err := strconv.ParseInt(orderID, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("無效的訂單ID %q: %w", orderID, err)
+	}
+
+	order, err := t.findOrder(symbol, func(o OrderResponse) bool { return o.OrderIndex == orderIndex })
+	if err != nil {
+		return nil, err
+	}
+	if order == nil {
+		return nil, fmt.Errorf("未找到 %s 的訂單 %s", symbol, orderID)
+	}
+
+	return map[string]interface{}{
+		"orderId":     orderID,
+		"symbol":      symbol,
+		"status":      lighterOrderStatus(*order),
+		"price":       order.Price,
+		"qty":         order.Quantity,
+		"executedQty": order.FilledQty,
+	}, nil
+}
+
 // CancelOrder 取消單個訂單
 func (t *LighterTraderV2) CancelOrder(symbol, orderID string) error {
 	if t.txClient == nil {
@@ -7,9 +7,11 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/elliottech/lighter-go/types"
+	"github.com/elliottech/lighter-go/types/txtypes"
 )
 
 // OpenLong 開多倉（實現 Trader 接口）
@@ -210,7 +212,120 @@ func (t *LighterTraderV2) CreateOrder(symbol string, isAsk bool, quantity float6
 		OrderExpiry:      time.Now().Add(24 * 28 * time.Hour).UnixMilli(), // 28天後過期
 	}
 
-	// 使用SDK簽名交易（nonce會自動獲取）
+	orderResp, err := t.signAndSubmitOrder(txReq)
+	if err != nil {
+		return nil, err
+	}
+
+	side := "buy"
+	if isAsk {
+		side = "sell"
+	}
+	log.Printf("✓ LIGHTER訂單已創建: %s %s qty=%.4f", symbol, side, quantity)
+
+	return orderResp, nil
+}
+
+// OpenLimitOrder 限價開倉（實現 Trader 接口，timeInForce: GTC/IOC/GTX）
+func (t *LighterTraderV2) OpenLimitOrder(symbol, side string, quantity float64, leverage int, price float64, timeInForce string) (map[string]interface{}, error) {
+	if t.txClient == nil {
+		return nil, fmt.Errorf("TxClient 未初始化，請先設置 API Key")
+	}
+
+	log.Printf("📝 LIGHTER 限價開倉: %s %s qty=%.4f @ %.2f (%s)", symbol, side, quantity, price, timeInForce)
+
+	if err := t.SetLeverage(symbol, leverage); err != nil {
+		log.Printf("⚠️  設置杠杆失敗: %v", err)
+	}
+
+	marketIndex, err := t.getMarketIndex(symbol)
+	if err != nil {
+		return nil, fmt.Errorf("獲取市場索引失敗: %w", err)
+	}
+
+	// IOC 訂單不允許設置過期時間，GTT/PostOnly 必須設置
+	tif := uint8(txtypes.GoodTillTime)
+	expiry := time.Now().Add(24 * 28 * time.Hour).UnixMilli()
+	switch timeInForce {
+	case TimeInForceIOC:
+		tif = txtypes.ImmediateOrCancel
+		expiry = txtypes.NilOrderExpiry
+	case TimeInForcePostOnly:
+		tif = txtypes.PostOnly
+	}
+
+	clientOrderIndex := time.Now().UnixNano()
+	txReq := &types.CreateOrderTxReq{
+		MarketIndex:      marketIndex,
+		ClientOrderIndex: clientOrderIndex,
+		BaseAmount:       int64(quantity * 1e8),
+		Price:            uint32(price * 1e2),
+		IsAsk:            boolToUint8(side == "short"),
+		Type:             txtypes.LimitOrder,
+		TimeInForce:      tif,
+		ReduceOnly:       0,
+		TriggerPrice:     0,
+		OrderExpiry:      expiry,
+	}
+
+	orderResp, err := t.signAndSubmitOrder(txReq)
+	if err != nil {
+		return nil, fmt.Errorf("限價開倉失敗: %w", err)
+	}
+
+	// sendTx 只返回交易哈希，需要按客戶端訂單索引找到交易所分配的訂單索引，後續查詢和撤單都依賴它
+	order, err := t.resolveOrderIndex(symbol, clientOrderIndex)
+	if err != nil {
+		// 訂單可能已掛出（GTT 28 天有效），無法跟蹤時成交後不會設置止盈止損，因此按客戶端訂單索引撤單（撤單接口同時接受客戶端訂單索引）
+		if cancelErr := t.CancelOrder(symbol, strconv.FormatInt(clientOrderIndex, 10)); cancelErr != nil {
+			return nil, fmt.Errorf("限價單已提交 (tx_hash: %v)，但無法確認訂單索引: %w；按客戶端訂單索引 %d 撤單也失敗: %v", orderResp["tx_hash"], err, clientOrderIndex, cancelErr)
+		}
+		return nil, fmt.Errorf("限價單已提交 (tx_hash: %v)，但無法確認訂單索引，已按客戶端訂單索引 %d 撤單: %w", orderResp["tx_hash"], clientOrderIndex, err)
+	}
+
+	log.Printf("✓ LIGHTER 限價單已提交: %s %s @ %.2f (訂單索引: %d)", symbol, side, price, order.OrderIndex)
+
+	return map[string]interface{}{
+		"orderId":     strconv.FormatInt(order.OrderIndex, 10),
+		"symbol":      symbol,
+		"side":        side,
+		"status":      lighterOrderStatus(*order),
+		"price":       price,
+		"qty":         quantity,
+		"executedQty": order.FilledQty,
+	}, nil
+}
+
+// 提交訂單後等待其出現在訂單列表中的重試次數和間隔
+var (
+	lighterOrderResolveAttempts = 5
+	lighterOrderResolveInterval = 500 * time.Millisecond
+)
+
+// resolveOrderIndex 按客戶端訂單索引查找剛提交的訂單（IOC 等訂單可能已立即結束，因此同時查找已結束訂單）
+func (t *LighterTraderV2) resolveOrderIndex(symbol string, clientOrderIndex int64) (*OrderResponse, error) {
+	var lastErr error
+	for attempt := 0; attempt < lighterOrderResolveAttempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(lighterOrderResolveInterval)
+		}
+		order, err := t.findOrder(symbol, func(o OrderResponse) bool { return o.ClientOrderIndex == clientOrderIndex })
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if order != nil {
+			return order, nil
+		}
+	}
+	if lastErr != nil {
+		return nil, lastErr
+	}
+	return nil, fmt.Errorf("客戶端訂單索引 %d 未出現在訂單列表中", clientOrderIndex)
+}
+
+// signAndSubmitOrder 使用SDK簽名並提交訂單（nonce會自動獲取）
+func (t *LighterTraderV2) signAndSubmitOrder(txReq *types.CreateOrderTxReq) (map[string]interface{}, error) {
 	nonce := int64(-1) // -1表示自動獲取
 	tx, err := t.txClient.GetCreateOrderTransaction(txReq, &types.TransactOpts{
 		Nonce: &nonce,
@@ -230,13 +345,6 @@ func (t *LighterTraderV2) CreateOrder(symbol string, isAsk bool, quantity float6
 	if err != nil {
 		return nil, fmt.Errorf("提交訂單失敗: %w", err)
 	}
-
-	side := "buy"
-	if isAsk {
-		side = "sell"
-	}
-	log.Printf("✓ LIGHTER訂單已創建: %s %s qty=%.4f", symbol, side, quantity)
-
 	return orderResp, nil
 }
 
@@ -0,0 +1,75 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"nofx/market"
+	"strings"
+)
+
+const (
+	// LiquidityActionShrink 预计滑点超限时缩小仓位至上限内
+	LiquidityActionShrink = "shrink"
+	// LiquidityActionReject 预计滑点超限时拒绝开仓
+	LiquidityActionReject = "reject"
+
+	// minLiquidityPositionUSD 缩小后的仓位低于该值时直接拒绝开仓（与部分平仓的最小持仓价值一致）
+	minLiquidityPositionUSD = 10.0
+	// liquidityCheckLevels 估算滑点时获取的订单簿档位数
+	liquidityCheckLevels = 100
+)
+
+// checkLiquidity 按订单簿估算市价开仓 positionSizeUSD 的滑点，超过 MaxSlippagePct 时按 LiquidityAction
+// 缩小仓位或拒绝开仓，返回允许的仓位金额。订单簿获取失败或为空时无法估算滑点：
+// reject 模式拒绝开仓，shrink 模式无从缩小，记录告警后按原仓位开仓
+func (at *AutoTrader) checkLiquidity(symbol string, isBuy bool, positionSizeUSD float64) (float64, error) {
+	maxSlippage := at.config.MaxSlippagePct
+	if maxSlippage <= 0 || at.marketData == nil {
+		return positionSizeUSD, nil
+	}
+
+	book, err := at.marketData.GetOrderBook(symbol, liquidityCheckLevels)
+	if err != nil {
+		return at.unknownLiquidity(symbol, positionSizeUSD, fmt.Sprintf("获取 %s 订单簿失败: %v", symbol, err))
+	}
+
+	est := market.EstimateSlippage(book, isBuy, positionSizeUSD)
+	if est.FilledUSD == 0 {
+		return at.unknownLiquidity(symbol, positionSizeUSD, fmt.Sprintf("%s 订单簿为空", symbol))
+	}
+	if est.Complete && est.SlippagePct <= maxSlippage {
+		return positionSizeUSD, nil
+	}
+
+	allowed := market.MaxNotionalWithinSlippage(book, isBuy, maxSlippage)
+	reason := fmt.Sprintf("%s 订单簿流动性不足: %.2f USDT 市价单预计滑点 %.3f%%", symbol, positionSizeUSD, est.SlippagePct)
+	if !est.Complete {
+		reason = fmt.Sprintf("%s 订单簿流动性不足: 前 %d 档仅能成交 %.2f / %.2f USDT", symbol, liquidityCheckLevels, est.FilledUSD, positionSizeUSD)
+	}
+
+	if at.liquidityAction() == LiquidityActionReject {
+		return 0, fmt.Errorf("❌ %s，超过上限 %.2f%%，拒绝开仓", reason, maxSlippage)
+	}
+	if allowed < minLiquidityPositionUSD {
+		return 0, fmt.Errorf("❌ %s，滑点 %.2f%% 内仅能成交 %.2f USDT，拒绝开仓", reason, maxSlippage, allowed)
+	}
+	log.Printf("  ⚠️ %s，仓位从 %.2f 缩小到 %.2f USDT（滑点上限 %.2f%%）", reason, positionSizeUSD, allowed, maxSlippage)
+	return allowed, nil
+}
+
+// unknownLiquidity 处理无法估算滑点的情况：reject 模式拒绝开仓，shrink 模式按原仓位开仓
+func (at *AutoTrader) unknownLiquidity(symbol string, positionSizeUSD float64, reason string) (float64, error) {
+	if at.liquidityAction() == LiquidityActionReject {
+		return 0, fmt.Errorf("❌ %s，无法估算滑点，拒绝开仓", reason)
+	}
+	log.Printf("  ⚠️ %s，无法估算滑点，按原仓位 %.2f USDT 开仓", reason, positionSizeUSD)
+	return positionSizeUSD, nil
+}
+
+// liquidityAction 返回滑点超限时的处理方式，未配置或无法识别时缩小仓位
+func (at *AutoTrader) liquidityAction() string {
+	if strings.EqualFold(strings.TrimSpace(at.config.LiquidityAction), LiquidityActionReject) {
+		return LiquidityActionReject
+	}
+	return LiquidityActionShrink
+}
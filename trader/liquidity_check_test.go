@@ -0,0 +1,77 @@
+package trader
+
+import (
+	"errors"
+	"nofx/market"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// bookProvider 只提供固定订单簿的行情数据源
+type bookProvider struct {
+	book *market.OrderBook
+	err  error
+}
+
+func (p *bookProvider) Name() string { return "book" }
+func (p *bookProvider) GetKlines(symbol, interval string, limit int) ([]market.Kline, error) {
+	return nil, errors.New("not implemented")
+}
+func (p *bookProvider) GetCurrentPrice(symbol string) (float64, error) { return 0, nil }
+func (p *bookProvider) GetFundingRate(symbol string) (float64, error)  { return 0, nil }
+func (p *bookProvider) GetOpenInterest(symbol string) (*market.OIData, error) {
+	return &market.OIData{}, nil
+}
+func (p *bookProvider) GetOrderBook(symbol string, limit int) (*market.OrderBook, error) {
+	return p.book, p.err
+}
+
+func TestAutoTrader_CheckLiquidity(t *testing.T) {
+	// 中间价 100.5：卖盘 101 × 100 + 102 × 100
+	book := &market.OrderBook{
+		Bids: []market.OrderBookLevel{{Price: 100, Quantity: 100}},
+		Asks: []market.OrderBookLevel{{Price: 101, Quantity: 100}, {Price: 102, Quantity: 100}},
+	}
+	at := &AutoTrader{marketData: &bookProvider{book: book}}
+
+	size, err := at.checkLiquidity("BTCUSDT", true, 50000)
+	assert.NoError(t, err)
+	assert.Equal(t, 50000.0, size, "未设置滑点上限时不检查")
+
+	at.config.MaxSlippagePct = 0.8
+	size, err = at.checkLiquidity("BTCUSDT", true, 5000)
+	assert.NoError(t, err)
+	assert.Equal(t, 5000.0, size, "滑点在上限内时保持原仓位")
+
+	size, err = at.checkLiquidity("BTCUSDT", true, 20000)
+	assert.NoError(t, err)
+	assert.Greater(t, size, 10100.0, "应保留第一档全部流动性")
+	assert.Less(t, size, 20000.0, "超限时缩小仓位")
+	est := market.EstimateSlippage(book, true, size)
+	assert.InDelta(t, 0.8, est.SlippagePct, 1e-6, "缩小后的仓位滑点恰好等于上限")
+
+	_, err = at.checkLiquidity("BTCUSDT", true, 50000)
+	assert.NoError(t, err, "订单簿深度不足时缩小到可成交金额")
+
+	at.config.MaxSlippagePct = 0.1
+	_, err = at.checkLiquidity("BTCUSDT", true, 5000)
+	assert.Error(t, err, "上限内可成交金额低于最小仓位时拒绝")
+
+	at.config.MaxSlippagePct, at.config.LiquidityAction = 0.8, LiquidityActionReject
+	_, err = at.checkLiquidity("BTCUSDT", true, 20000)
+	assert.Error(t, err, "reject 模式下超限直接拒绝")
+
+	at.marketData = &bookProvider{err: errors.New("timeout")}
+	_, err = at.checkLiquidity("BTCUSDT", false, 20000)
+	assert.Error(t, err, "reject 模式下订单簿获取失败时拒绝开仓")
+
+	at.marketData = &bookProvider{book: &market.OrderBook{}}
+	_, err = at.checkLiquidity("BTCUSDT", false, 20000)
+	assert.Error(t, err, "reject 模式下订单簿为空时拒绝开仓")
+
+	at.config.LiquidityAction = LiquidityActionShrink
+	size, err = at.checkLiquidity("BTCUSDT", false, 20000)
+	assert.NoError(t, err, "shrink 模式下无法估算滑点时按原仓位开仓")
+	assert.Equal(t, 20000.0, size)
+}
@@ -0,0 +1,82 @@
+package trader
+
+import (
+	"nofx/mcp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LiveThinking 当前（或最近一次）决策周期中 AI 的流式输出，用于前端实时展示思考过程
+type LiveThinking struct {
+	CycleNumber int       `json:"cycle_number"`
+	StartedAt   time.Time `json:"started_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	Reasoning   string    `json:"reasoning"` // 思考过程（reasoning_content）
+	Content     string    `json:"content"`   // 正文输出
+	Done        bool      `json:"done"`      // 本周期 AI 调用是否已结束
+}
+
+// liveThinkingBuffer 累积流式输出，并在每次更新时通知等待者（零值可用）
+type liveThinkingBuffer struct {
+	mu        sync.Mutex
+	current   LiveThinking
+	reasoning strings.Builder
+	content   strings.Builder
+	updated   chan struct{} // 每次更新时关闭并替换
+}
+
+// start 开始新的决策周期，清空上一周期的输出
+func (b *liveThinkingBuffer) start(cycleNumber int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	b.current = LiveThinking{CycleNumber: cycleNumber, StartedAt: now, UpdatedAt: now}
+	b.reasoning.Reset()
+	b.content.Reset()
+	b.notifyLocked()
+}
+
+// append 追加一个流式片段（作为 decision.Context.OnStream 使用）
+func (b *liveThinkingBuffer) append(chunk mcp.StreamChunk) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.reasoning.WriteString(chunk.Reasoning)
+	b.content.WriteString(chunk.Content)
+	b.current.UpdatedAt = time.Now()
+	b.notifyLocked()
+}
+
+// finish 标记本周期 AI 调用结束
+func (b *liveThinkingBuffer) finish() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.current.Done = true
+	b.current.UpdatedAt = time.Now()
+	b.notifyLocked()
+}
+
+// snapshot 返回当前输出，以及下一次更新时会被关闭的通知 channel
+func (b *liveThinkingBuffer) snapshot() (LiveThinking, <-chan struct{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.updated == nil {
+		b.updated = make(chan struct{})
+	}
+	snap := b.current
+	snap.Reasoning = b.reasoning.String()
+	snap.Content = b.content.String()
+	return snap, b.updated
+}
+
+func (b *liveThinkingBuffer) notifyLocked() {
+	if b.updated != nil {
+		close(b.updated)
+	}
+	b.updated = make(chan struct{})
+}
+
+// GetLiveThinking 获取当前决策周期的 AI 流式输出，以及下一次更新时会被关闭的通知 channel（用于API）
+func (at *AutoTrader) GetLiveThinking() (LiveThinking, <-chan struct{}) {
+	return at.liveThinking.snapshot()
+}
@@ -0,0 +1,39 @@
+package trader
+
+import (
+	"nofx/mcp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLiveThinkingBuffer(t *testing.T) {
+	var buf liveThinkingBuffer
+
+	buf.start(1)
+	snap, updated := buf.snapshot()
+	assert.Equal(t, 1, snap.CycleNumber)
+	assert.False(t, snap.Done)
+
+	buf.append(mcp.StreamChunk{Reasoning: "先看 BTC"})
+	buf.append(mcp.StreamChunk{Reasoning: " 趋势", Content: "<decision>"})
+	select {
+	case <-updated:
+	default:
+		t.Fatal("追加输出后应通知等待者")
+	}
+
+	snap, _ = buf.snapshot()
+	assert.Equal(t, "先看 BTC 趋势", snap.Reasoning)
+	assert.Equal(t, "<decision>", snap.Content)
+
+	buf.finish()
+	snap, _ = buf.snapshot()
+	assert.True(t, snap.Done)
+
+	buf.start(2)
+	snap, _ = buf.snapshot()
+	assert.Equal(t, 2, snap.CycleNumber)
+	assert.Empty(t, snap.Reasoning, "新周期应清空上一周期的输出")
+	assert.False(t, snap.Done)
+}
@@ -0,0 +1,908 @@
+package trader
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OKX 账户持仓模式
+const (
+	okxPosModeHedge = "long_short_mode" // 双向持仓：下单需带 posSide
+	okxPosModeNet   = "net_mode"        // 单向持仓：平仓单使用 reduceOnly
+)
+
+// okxBatchLimit OKX 批量撤单接口单次最多处理的订单数
+const okxBatchLimit = 20
+
+// OKXTrader OKX USDT 永续合约交易器（v5 REST API）
+// OKX 下单数量以合约张数为单位，对外统一使用币数量，内部按合约面值 ctVal 换算
+type OKXTrader struct {
+	apiKey     string
+	secretKey  string
+	passphrase string
+	simulated  bool // 模拟盘（请求头 x-simulated-trading: 1）
+	baseURL    string
+	client     *http.Client
+
+	// 合约信息缓存（面值、张数步长、价格精度）
+	instruments      map[string]*okxInstrument
+	instrumentsMutex sync.RWMutex
+
+	// 账户持仓模式，首次下单时查询
+	posMode      string
+	posModeMutex sync.Mutex
+
+	// 各币种保证金模式（cross/isolated），OKX 没有单独的切换接口，下单时作为 tdMode 传入
+	marginModes      map[string]string
+	marginModesMutex sync.RWMutex
+}
+
+// okxInstrument 永续合约信息
+type okxInstrument struct {
+	InstID string
+	CtVal  float64 // 每张合约对应的币数量
+	LotSz  float64 // 下单张数步长
+	MinSz  float64 // 最小下单张数
+	TickSz float64 // 价格步长
+}
+
+// okxResponse OKX v5 统一响应结构
+type okxResponse struct {
+	Code string                   `json:"code"`
+	Msg  string                   `json:"msg"`
+	Data []map[string]interface{} `json:"data"`
+}
+
+// NewOKXTrader 创建 OKX 交易器，simulated 为 true 时使用模拟盘
+func NewOKXTrader(apiKey, secretKey, passphrase string, simulated bool) *OKXTrader {
+	trader := &OKXTrader{
+		apiKey:      apiKey,
+		secretKey:   secretKey,
+		passphrase:  passphrase,
+		simulated:   simulated,
+		baseURL:     "https://www.okx.com",
+		client:      &http.Client{Timeout: 10 * time.Second},
+		instruments: make(map[string]*okxInstrument),
+		marginModes: make(map[string]string),
+	}
+
+	log.Printf("🟢 [OKX] 交易器已初始化 (模拟盘: %v)", simulated)
+	return trader
+}
+
+// okxInstID 将 BTCUSDT 转换为 OKX 永续合约ID BTC-USDT-SWAP
+func okxInstID(symbol string) string {
+	symbol = strings.ToUpper(symbol)
+	if strings.HasSuffix(symbol, "-SWAP") {
+		return symbol
+	}
+	return strings.TrimSuffix(symbol, "USDT") + "-USDT-SWAP"
+}
+
+// okxSymbol 将 OKX 合约ID BTC-USDT-SWAP 转换为 BTCUSDT
+func okxSymbol(instID string) string {
+	return strings.ReplaceAll(strings.TrimSuffix(instID, "-SWAP"), "-", "")
+}
+
+// okxDecimals 返回步长的小数位数，如 0.01 -> 2
+func okxDecimals(step float64) int {
+	s := strconv.FormatFloat(step, 'f', -1, 64)
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		return len(s) - i - 1
+	}
+	return 0
+}
+
+// sign 生成签名：Base64(HMAC-SHA256(timestamp + method + requestPath + body))
+func (t *OKXTrader) sign(timestamp, method, requestPath, body string) string {
+	mac := hmac.New(sha256.New, []byte(t.secretKey))
+	mac.Write([]byte(timestamp + method + requestPath + body))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// request 发送请求并返回 data 数组，公共行情接口不签名
+func (t *OKXTrader) request(method, path string, query url.Values, body interface{}) ([]map[string]interface{}, error) {
+	requestPath := path
+	if len(query) > 0 {
+		requestPath += "?" + query.Encode()
+	}
+
+	bodyStr := ""
+	if body != nil {
+		bodyBytes, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		bodyStr = string(bodyBytes)
+	}
+
+	req, err := http.NewRequest(method, t.baseURL+requestPath, strings.NewReader(bodyStr))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if t.simulated {
+		req.Header.Set("x-simulated-trading", "1")
+	}
+	if !strings.HasPrefix(path, "/api/v5/public/") && !strings.HasPrefix(path, "/api/v5/market/") {
+		timestamp := time.Now().UTC().Format("2006-01-02T15:04:05.000Z")
+		req.Header.Set("OK-ACCESS-KEY", t.apiKey)
+		req.Header.Set("OK-ACCESS-SIGN", t.sign(timestamp, method, requestPath, bodyStr))
+		req.Header.Set("OK-ACCESS-TIMESTAMP", timestamp)
+		req.Header.Set("OK-ACCESS-PASSPHRASE", t.passphrase)
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var result okxResponse
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("解析 OKX 响应失败 (HTTP %d): %s", resp.StatusCode, string(respBody))
+	}
+	if result.Code != "0" {
+		// 下单/撤单类接口的具体错误在 data[].sCode/sMsg 中
+		for _, item := range result.Data {
+			if sMsg, _ := SafeString(item, "sMsg"); sMsg != "" {
+				sCode, _ := SafeString(item, "sCode")
+				return nil, fmt.Errorf("OKX API 错误 %s: %s", sCode, sMsg)
+			}
+		}
+		return nil, fmt.Errorf("OKX API 错误 %s: %s", result.Code, result.Msg)
+	}
+
+	return result.Data, nil
+}
+
+// getInstrument 获取合约信息（带缓存）
+func (t *OKXTrader) getInstrument(symbol string) (*okxInstrument, error) {
+	instID := okxInstID(symbol)
+
+	t.instrumentsMutex.RLock()
+	inst, ok := t.instruments[instID]
+	t.instrumentsMutex.RUnlock()
+	if ok {
+		return inst, nil
+	}
+
+	data, err := t.request("GET", "/api/v5/public/instruments", url.Values{
+		"instType": {"SWAP"},
+		"instId":   {instID},
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("获取 %s 合约信息失败: %w", instID, err)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("未找到合约 %s", instID)
+	}
+
+	inst = &okxInstrument{InstID: instID}
+	inst.CtVal, _ = SafeFloat64(data[0], "ctVal")
+	inst.LotSz, _ = SafeFloat64(data[0], "lotSz")
+	inst.MinSz, _ = SafeFloat64(data[0], "minSz")
+	inst.TickSz, _ = SafeFloat64(data[0], "tickSz")
+	if inst.CtVal <= 0 || inst.LotSz <= 0 {
+		return nil, fmt.Errorf("合约 %s 信息不完整: %v", instID, data[0])
+	}
+
+	t.instrumentsMutex.Lock()
+	t.instruments[instID] = inst
+	t.instrumentsMutex.Unlock()
+	return inst, nil
+}
+
+// toContracts 币数量换算为合约张数（按步长向下取整）
+func (inst *okxInstrument) toContracts(quantity float64) float64 {
+	steps := math.Floor(quantity/inst.CtVal/inst.LotSz + 1e-9)
+	return steps * inst.LotSz
+}
+
+// toQuantity 合约张数换算为币数量
+func (inst *okxInstrument) toQuantity(contracts float64) float64 {
+	scale := math.Pow(10, float64(okxDecimals(inst.CtVal)+okxDecimals(inst.LotSz)))
+	return math.Round(contracts*inst.CtVal*scale) / scale
+}
+
+// contractSize 返回下单用的张数字符串，不足最小下单量时返回错误
+func (inst *okxInstrument) contractSize(quantity float64) (string, error) {
+	contracts := inst.toContracts(quantity)
+	if contracts <= 0 || contracts < inst.MinSz {
+		return "", fmt.Errorf("%s 数量 %v 不足最小下单量 %v 张（每张 %v）", inst.InstID, quantity, inst.MinSz, inst.CtVal)
+	}
+	return strconv.FormatFloat(contracts, 'f', okxDecimals(inst.LotSz), 64), nil
+}
+
+// formatPrice 按价格步长格式化价格
+func (inst *okxInstrument) formatPrice(price float64) string {
+	if inst.TickSz <= 0 {
+		return strconv.FormatFloat(price, 'f', -1, 64)
+	}
+	return strconv.FormatFloat(math.Round(price/inst.TickSz)*inst.TickSz, 'f', okxDecimals(inst.TickSz), 64)
+}
+
+// positionMode 获取账户持仓模式（long_short_mode / net_mode）
+func (t *OKXTrader) positionMode() (string, error) {
+	t.posModeMutex.Lock()
+	defer t.posModeMutex.Unlock()
+
+	if t.posMode != "" {
+		return t.posMode, nil
+	}
+
+	data, err := t.request("GET", "/api/v5/account/config", nil, nil)
+	if err != nil {
+		return "", fmt.Errorf("获取 OKX 账户配置失败: %w", err)
+	}
+	if len(data) == 0 {
+		return "", fmt.Errorf("OKX 账户配置为空")
+	}
+	mode, _ := SafeString(data[0], "posMode")
+	if mode != okxPosModeHedge && mode != okxPosModeNet {
+		return "", fmt.Errorf("未知的 OKX 持仓模式: %s", mode)
+	}
+
+	t.posMode = mode
+	log.Printf("  ✓ [OKX] 账户持仓模式: %s", mode)
+	return mode, nil
+}
+
+// tdMode 返回币种的保证金模式，未设置时默认全仓
+func (t *OKXTrader) tdMode(symbol string) string {
+	t.marginModesMutex.RLock()
+	defer t.marginModesMutex.RUnlock()
+	if mode, ok := t.marginModes[symbol]; ok {
+		return mode
+	}
+	return "cross"
+}
+
+// orderBody 构建下单参数：双向持仓带 posSide，单向持仓的平仓/止盈止损单带 reduceOnly
+func (t *OKXTrader) orderBody(symbol, posSide string, quantity float64, reduce bool) (map[string]interface{}, *okxInstrument, error) {
+	inst, err := t.getInstrument(symbol)
+	if err != nil {
+		return nil, nil, err
+	}
+	sz, err := inst.contractSize(quantity)
+	if err != nil {
+		return nil, nil, err
+	}
+	mode, err := t.positionMode()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	body := map[string]interface{}{
+		"instId": inst.InstID,
+		"tdMode": t.tdMode(symbol),
+		"sz":     sz,
+	}
+	if mode == okxPosModeHedge {
+		body["posSide"] = posSide
+	} else if reduce {
+		body["reduceOnly"] = true
+	}
+	return body, inst, nil
+}
+
+// placeOrder 下普通订单（ordType: market/limit/ioc/post_only），返回统一格式的订单结果
+func (t *OKXTrader) placeOrder(symbol, side, posSide, ordType string, quantity, price float64, reduce bool) (map[string]interface{}, error) {
+	body, inst, err := t.orderBody(symbol, posSide, quantity, reduce)
+	if err != nil {
+		return nil, err
+	}
+	body["side"] = side
+	body["ordType"] = ordType
+	if price > 0 {
+		body["px"] = inst.formatPrice(price)
+	}
+
+	data, err := t.request("POST", "/api/v5/trade/order", nil, body)
+	if err != nil {
+		return nil, err
+	}
+
+	orderID := ""
+	if len(data) > 0 {
+		orderID, _ = SafeString(data[0], "ordId")
+	}
+	contracts, _ := strconv.ParseFloat(body["sz"].(string), 64)
+
+	return map[string]interface{}{
+		"orderId": orderID,
+		"symbol":  symbol,
+		"status":  OrderStatusNew,
+		"price":   price,
+		"qty":     inst.toQuantity(contracts),
+	}, nil
+}
+
+// GetBalance 获取账户余额（USDT）
+func (t *OKXTrader) GetBalance() (map[string]interface{}, error) {
+	data, err := t.request("GET", "/api/v5/account/balance", url.Values{"ccy": {"USDT"}}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("获取 OKX 余额失败: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("OKX 余额返回为空")
+	}
+
+	var walletBalance, availableBalance, unrealizedPnL float64
+	details, _ := data[0]["details"].([]interface{})
+	for _, item := range details {
+		detail, ok := item.(map[string]interface{})
+		if !ok || detail["ccy"] != "USDT" {
+			continue
+		}
+		walletBalance, _ = SafeFloat64(detail, "cashBal")
+		availableBalance, _ = SafeFloat64(detail, "availBal")
+		if availableBalance == 0 {
+			availableBalance, _ = SafeFloat64(detail, "availEq")
+		}
+		unrealizedPnL, _ = SafeFloat64(detail, "upl")
+	}
+
+	return map[string]interface{}{
+		"totalWalletBalance":    walletBalance,
+		"availableBalance":      availableBalance,
+		"totalUnrealizedProfit": unrealizedPnL,
+	}, nil
+}
+
+// GetPositions 获取所有 USDT 永续持仓，数量换算为币数量（空仓为负数）
+func (t *OKXTrader) GetPositions() ([]map[string]interface{}, error) {
+	data, err := t.request("GET", "/api/v5/account/positions", url.Values{"instType": {"SWAP"}}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("获取 OKX 持仓失败: %w", err)
+	}
+
+	var positions []map[string]interface{}
+	for _, pos := range data {
+		contracts, _ := SafeFloat64(pos, "pos")
+		instID, _ := SafeString(pos, "instId")
+		if contracts == 0 || !strings.HasSuffix(instID, "-USDT-SWAP") {
+			continue
+		}
+
+		symbol := okxSymbol(instID)
+		inst, err := t.getInstrument(symbol)
+		if err != nil {
+			log.Printf("⚠️ [OKX] %v", err)
+			continue
+		}
+
+		// 双向持仓模式 posSide 为 long/short，单向持仓模式为 net，方向由张数符号决定
+		side, _ := SafeString(pos, "posSide")
+		if side != "long" && side != "short" {
+			side = "long"
+			if contracts < 0 {
+				side = "short"
+			}
+		}
+		quantity := inst.toQuantity(math.Abs(contracts))
+		positionAmt := quantity
+		if side == "short" {
+			positionAmt = -quantity
+		}
+
+		entryPrice, _ := SafeFloat64(pos, "avgPx")
+		markPrice, _ := SafeFloat64(pos, "markPx")
+		unrealizedPnL, _ := SafeFloat64(pos, "upl")
+		leverage, _ := SafeFloat64(pos, "lever")
+		liquidationPrice, _ := SafeFloat64(pos, "liqPx")
+
+		positions = append(positions, map[string]interface{}{
+			"symbol":           symbol,
+			"side":             side,
+			"positionAmt":      positionAmt,
+			"entryPrice":       entryPrice,
+			"markPrice":        markPrice,
+			"unRealizedProfit": unrealizedPnL,
+			"liquidationPrice": liquidationPrice,
+			"leverage":         leverage,
+		})
+	}
+
+	return positions, nil
+}
+
+// OpenLong 市价开多仓
+func (t *OKXTrader) OpenLong(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+	// 开仓前先取消该币种的挂单，防止残留挂单导致仓位叠加
+	if err := t.CancelAllOrders(symbol); err != nil {
+		log.Printf("  ⚠ [OKX] 取消挂单失败(继续开仓): %v", err)
+	}
+	if err := t.SetLeverage(symbol, leverage); err != nil {
+		return nil, fmt.Errorf("设置杠杆失败: %w", err)
+	}
+
+	order, err := t.placeOrder(symbol, "buy", "long", "market", quantity, 0, false)
+	if err != nil {
+		return nil, fmt.Errorf("OKX 开多失败: %w", err)
+	}
+
+	log.Printf("✓ [OKX] 开多仓成功: %s 数量: %v", symbol, order["qty"])
+	return order, nil
+}
+
+// OpenShort 市价开空仓
+func (t *OKXTrader) OpenShort(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+	// 开仓前先取消该币种的挂单，防止残留挂单导致仓位叠加
+	if err := t.CancelAllOrders(symbol); err != nil {
+		log.Printf("  ⚠ [OKX] 取消挂单失败(继续开仓): %v", err)
+	}
+	if err := t.SetLeverage(symbol, leverage); err != nil {
+		return nil, fmt.Errorf("设置杠杆失败: %w", err)
+	}
+
+	order, err := t.placeOrder(symbol, "sell", "short", "market", quantity, 0, false)
+	if err != nil {
+		return nil, fmt.Errorf("OKX 开空失败: %w", err)
+	}
+
+	log.Printf("✓ [OKX] 开空仓成功: %s 数量: %v", symbol, order["qty"])
+	return order, nil
+}
+
+// OpenLimitOrder 限价开仓（timeInForce: GTC/IOC/GTX，分别对应 OKX 的 limit/ioc/post_only）
+func (t *OKXTrader) OpenLimitOrder(symbol, side string, quantity float64, leverage int, price float64, timeInForce string) (map[string]interface{}, error) {
+	if err := t.SetLeverage(symbol, leverage); err != nil {
+		return nil, fmt.Errorf("设置杠杆失败: %w", err)
+	}
+
+	orderSide := "buy"
+	if side == "short" {
+		orderSide = "sell"
+	}
+
+	ordType := "limit"
+	switch timeInForce {
+	case TimeInForceIOC:
+		ordType = "ioc"
+	case TimeInForcePostOnly:
+		ordType = "post_only"
+	}
+
+	order, err := t.placeOrder(symbol, orderSide, side, ordType, quantity, price, false)
+	if err != nil {
+		return nil, fmt.Errorf("OKX 限价开仓失败: %w", err)
+	}
+
+	log.Printf("  ✓ [OKX] 限价单已提交: %s %s %v @ %.4f (%s)", symbol, side, order["qty"], price, ordType)
+	return order, nil
+}
+
+// GetOrder 查询订单状态
+func (t *OKXTrader) GetOrder(symbol, orderID string) (map[string]interface{}, error) {
+	inst, err := t.getInstrument(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := t.request("GET", "/api/v5/trade/order", url.Values{
+		"instId": {inst.InstID},
+		"ordId":  {orderID},
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("查询订单失败: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("订单不存在: %s", orderID)
+	}
+
+	order := data[0]
+	state, _ := SafeString(order, "state")
+	size, _ := SafeFloat64(order, "sz")
+	filled, _ := SafeFloat64(order, "accFillSz")
+	price, _ := SafeFloat64(order, "avgPx")
+	if price == 0 {
+		price, _ = SafeFloat64(order, "px")
+	}
+
+	return map[string]interface{}{
+		"orderId":     orderID,
+		"symbol":      symbol,
+		"status":      NormalizeOrderStatus(state),
+		"price":       price,
+		"qty":         inst.toQuantity(size),
+		"executedQty": inst.toQuantity(filled),
+	}, nil
+}
+
+// CancelOrder 取消单个订单
+func (t *OKXTrader) CancelOrder(symbol, orderID string) error {
+	if _, err := t.request("POST", "/api/v5/trade/cancel-order", nil, map[string]interface{}{
+		"instId": okxInstID(symbol),
+		"ordId":  orderID,
+	}); err != nil {
+		return fmt.Errorf("取消订单失败: %w", err)
+	}
+
+	log.Printf("  ✓ [OKX] 已取消订单 %s (%s)", orderID, symbol)
+	return nil
+}
+
+// closePosition 市价平仓，quantity 为 0 时平掉全部持仓并撤销剩余的止盈止损单
+func (t *OKXTrader) closePosition(symbol, side string, quantity float64) (map[string]interface{}, error) {
+	closeAll := quantity == 0
+	if closeAll {
+		positions, err := FetchPositions(t)
+		if err != nil {
+			return nil, err
+		}
+		if pos, ok := FindPosition(positions, symbol, side); ok {
+			quantity = pos.Quantity
+		}
+	}
+
+	sideLabel := "多仓"
+	orderSide := "sell"
+	if side == "short" {
+		sideLabel = "空仓"
+		orderSide = "buy"
+	}
+	if quantity <= 0 {
+		return nil, fmt.Errorf("没有找到 %s 的%s", symbol, sideLabel)
+	}
+
+	order, err := t.placeOrder(symbol, orderSide, side, "market", quantity, 0, true)
+	if err != nil {
+		return nil, fmt.Errorf("OKX 平%s失败: %w", sideLabel, err)
+	}
+	log.Printf("✓ [OKX] 平%s成功: %s 数量: %v", sideLabel, symbol, order["qty"])
+
+	if closeAll {
+		if err := t.CancelStopOrders(symbol); err != nil {
+			log.Printf("  ⚠ [OKX] 取消止盈止损单失败: %v", err)
+		}
+	}
+	return order, nil
+}
+
+// CloseLong 平多仓（quantity=0 表示全部平仓）
+func (t *OKXTrader) CloseLong(symbol string, quantity float64) (map[string]interface{}, error) {
+	return t.closePosition(symbol, "long", quantity)
+}
+
+// CloseShort 平空仓（quantity=0 表示全部平仓）
+func (t *OKXTrader) CloseShort(symbol string, quantity float64) (map[string]interface{}, error) {
+	return t.closePosition(symbol, "short", quantity)
+}
+
+// SetLeverage 设置杠杆（按当前保证金模式设置，双向持仓逐仓需分别设置多空方向）
+func (t *OKXTrader) SetLeverage(symbol string, leverage int) error {
+	mgnMode := t.tdMode(symbol)
+	posSides := []string{""}
+	if mgnMode == "isolated" {
+		mode, err := t.positionMode()
+		if err != nil {
+			return err
+		}
+		if mode == okxPosModeHedge {
+			posSides = []string{"long", "short"}
+		}
+	}
+
+	for _, posSide := range posSides {
+		body := map[string]interface{}{
+			"instId":  okxInstID(symbol),
+			"lever":   strconv.Itoa(leverage),
+			"mgnMode": mgnMode,
+		}
+		if posSide != "" {
+			body["posSide"] = posSide
+		}
+		if _, err := t.request("POST", "/api/v5/account/set-leverage", nil, body); err != nil {
+			return fmt.Errorf("设置杠杆失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// SetMarginMode 设置保证金模式
+// OKX 的全仓/逐仓由每笔订单的 tdMode 决定，这里只记录下来供后续下单和设置杠杆使用
+func (t *OKXTrader) SetMarginMode(symbol string, isCrossMargin bool) error {
+	mode := "isolated"
+	if isCrossMargin {
+		mode = "cross"
+	}
+
+	t.marginModesMutex.Lock()
+	t.marginModes[symbol] = mode
+	t.marginModesMutex.Unlock()
+	return nil
+}
+
+// GetMarketPrice 获取最新成交价
+func (t *OKXTrader) GetMarketPrice(symbol string) (float64, error) {
+	data, err := t.request("GET", "/api/v5/market/ticker", url.Values{"instId": {okxInstID(symbol)}}, nil)
+	if err != nil {
+		return 0, fmt.Errorf("获取市场价格失败: %w", err)
+	}
+	if len(data) == 0 {
+		return 0, fmt.Errorf("未找到 %s 的价格数据", symbol)
+	}
+
+	price, err := SafeFloat64(data[0], "last")
+	if err != nil || price <= 0 {
+		return 0, fmt.Errorf("解析 %s 价格失败: %v", symbol, data[0]["last"])
+	}
+	return price, nil
+}
+
+// placeAlgoOrder 下策略委托（条件单/移动止损），返回 algoId
+func (t *OKXTrader) placeAlgoOrder(symbol, positionSide string, quantity float64, params map[string]interface{}) (string, error) {
+	posSide := "long"
+	side := "sell"
+	if strings.ToUpper(positionSide) == "SHORT" {
+		posSide = "short"
+		side = "buy"
+	}
+
+	body, _, err := t.orderBody(symbol, posSide, quantity, true)
+	if err != nil {
+		return "", err
+	}
+	body["side"] = side
+	for k, v := range params {
+		body[k] = v
+	}
+
+	data, err := t.request("POST", "/api/v5/trade/order-algo", nil, body)
+	if err != nil {
+		return "", err
+	}
+	algoID := ""
+	if len(data) > 0 {
+		algoID, _ = SafeString(data[0], "algoId")
+	}
+	return algoID, nil
+}
+
+// SetStopLoss 设置止损单（条件单，触发后市价平仓）
+func (t *OKXTrader) SetStopLoss(symbol string, positionSide string, quantity, stopPrice float64) error {
+	inst, err := t.getInstrument(symbol)
+	if err != nil {
+		return err
+	}
+
+	if _, err := t.placeAlgoOrder(symbol, positionSide, quantity, map[string]interface{}{
+		"ordType":         "conditional",
+		"slTriggerPx":     inst.formatPrice(stopPrice),
+		"slOrdPx":         "-1",
+		"slTriggerPxType": "last",
+	}); err != nil {
+		return fmt.Errorf("设置止损失败: %w", err)
+	}
+
+	log.Printf("  ✓ [OKX] 止损单已设置: %s @ %.4f", symbol, stopPrice)
+	return nil
+}
+
+// SetTakeProfit 设置止盈单（条件单，触发后市价平仓）
+func (t *OKXTrader) SetTakeProfit(symbol string, positionSide string, quantity, takeProfitPrice float64) error {
+	inst, err := t.getInstrument(symbol)
+	if err != nil {
+		return err
+	}
+
+	if _, err := t.placeAlgoOrder(symbol, positionSide, quantity, map[string]interface{}{
+		"ordType":         "conditional",
+		"tpTriggerPx":     inst.formatPrice(takeProfitPrice),
+		"tpOrdPx":         "-1",
+		"tpTriggerPxType": "last",
+	}); err != nil {
+		return fmt.Errorf("设置止盈失败: %w", err)
+	}
+
+	log.Printf("  ✓ [OKX] 止盈单已设置: %s @ %.4f", symbol, takeProfitPrice)
+	return nil
+}
+
+// SetTrailingStop 通过移动止盈止损委托（move_order_stop）设置原生追踪止损
+func (t *OKXTrader) SetTrailingStop(symbol, positionSide string, quantity, callbackRate, distance, activationPrice float64) (map[string]interface{}, error) {
+	params := map[string]interface{}{"ordType": "move_order_stop"}
+	if distance > 0 {
+		params["callbackSpread"] = strconv.FormatFloat(distance, 'f', -1, 64)
+	} else if callbackRate > 0 {
+		params["callbackRatio"] = strconv.FormatFloat(callbackRate/100, 'f', -1, 64)
+	} else {
+		return nil, fmt.Errorf("追踪止损需要回调比例或价格距离")
+	}
+	if activationPrice > 0 {
+		params["activePx"] = strconv.FormatFloat(activationPrice, 'f', -1, 64)
+	}
+
+	algoID, err := t.placeAlgoOrder(symbol, positionSide, quantity, params)
+	if err != nil {
+		return nil, fmt.Errorf("设置追踪止损失败: %w", err)
+	}
+
+	log.Printf("  ✓ [OKX] 追踪止损已设置: %s %s (algoId: %s)", symbol, positionSide, algoID)
+	return map[string]interface{}{
+		"orderId": algoID,
+		"symbol":  symbol,
+		"status":  OrderStatusNew,
+	}, nil
+}
+
+// CancelTrailingStop 取消追踪止损，orderID 为空时取消该币种所有移动止损委托
+func (t *OKXTrader) CancelTrailingStop(symbol, positionSide, orderID string) error {
+	if orderID != "" {
+		return t.cancelAlgos([]map[string]interface{}{{"algoId": orderID, "instId": okxInstID(symbol)}})
+	}
+	return t.cancelAlgoOrders(symbol, "move_order_stop", func(map[string]interface{}) bool { return true })
+}
+
+// cancelAlgoOrders 撤销该币种中满足条件的策略委托
+func (t *OKXTrader) cancelAlgoOrders(symbol, ordType string, match func(order map[string]interface{}) bool) error {
+	instID := okxInstID(symbol)
+	data, err := t.request("GET", "/api/v5/trade/orders-algo-pending", url.Values{
+		"ordType":  {ordType},
+		"instType": {"SWAP"},
+		"instId":   {instID},
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("获取策略委托失败: %w", err)
+	}
+
+	var algos []map[string]interface{}
+	for _, order := range data {
+		if !match(order) {
+			continue
+		}
+		algoID, _ := SafeString(order, "algoId")
+		algos = append(algos, map[string]interface{}{"algoId": algoID, "instId": instID})
+	}
+	return t.cancelAlgos(algos)
+}
+
+// cancelAlgos 批量撤销策略委托
+func (t *OKXTrader) cancelAlgos(algos []map[string]interface{}) error {
+	for start := 0; start < len(algos); start += okxBatchLimit {
+		end := start + okxBatchLimit
+		if end > len(algos) {
+			end = len(algos)
+		}
+		if _, err := t.request("POST", "/api/v5/trade/cancel-algos", nil, algos[start:end]); err != nil {
+			return fmt.Errorf("撤销策略委托失败: %w", err)
+		}
+	}
+	if len(algos) > 0 {
+		log.Printf("  ✓ [OKX] 已撤销 %d 个策略委托", len(algos))
+	}
+	return nil
+}
+
+// okxHasTrigger 判断条件单是否设置了指定的触发价（slTriggerPx/tpTriggerPx）
+func okxHasTrigger(order map[string]interface{}, key string) bool {
+	price, _ := SafeFloat64(order, key)
+	return price > 0
+}
+
+// CancelStopLossOrders 取消止损单
+func (t *OKXTrader) CancelStopLossOrders(symbol string) error {
+	return t.cancelAlgoOrders(symbol, "conditional", func(order map[string]interface{}) bool {
+		return okxHasTrigger(order, "slTriggerPx")
+	})
+}
+
+// CancelTakeProfitOrders 取消止盈单
+func (t *OKXTrader) CancelTakeProfitOrders(symbol string) error {
+	return t.cancelAlgoOrders(symbol, "conditional", func(order map[string]interface{}) bool {
+		return okxHasTrigger(order, "tpTriggerPx")
+	})
+}
+
+// CancelStopOrders 取消所有止盈止损单
+func (t *OKXTrader) CancelStopOrders(symbol string) error {
+	return t.cancelAlgoOrders(symbol, "conditional", func(map[string]interface{}) bool { return true })
+}
+
+// CancelAllOrders 取消该币种所有挂单（普通委托和止盈止损单）
+func (t *OKXTrader) CancelAllOrders(symbol string) error {
+	instID := okxInstID(symbol)
+	data, err := t.request("GET", "/api/v5/trade/orders-pending", url.Values{
+		"instType": {"SWAP"},
+		"instId":   {instID},
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("获取挂单失败: %w", err)
+	}
+
+	orders := make([]map[string]interface{}, 0, len(data))
+	for _, order := range data {
+		orderID, _ := SafeString(order, "ordId")
+		orders = append(orders, map[string]interface{}{"instId": instID, "ordId": orderID})
+	}
+	for start := 0; start < len(orders); start += okxBatchLimit {
+		end := start + okxBatchLimit
+		if end > len(orders) {
+			end = len(orders)
+		}
+		if _, err := t.request("POST", "/api/v5/trade/cancel-batch-orders", nil, orders[start:end]); err != nil {
+			return fmt.Errorf("批量撤单失败: %w", err)
+		}
+	}
+
+	return t.CancelStopOrders(symbol)
+}
+
+// FormatQuantity 按合约面值和张数步长格式化币数量
+func (t *OKXTrader) FormatQuantity(symbol string, quantity float64) (string, error) {
+	inst, err := t.getInstrument(symbol)
+	if err != nil {
+		return "", err
+	}
+	decimals := okxDecimals(inst.CtVal) + okxDecimals(inst.LotSz)
+	return strconv.FormatFloat(inst.toQuantity(inst.toContracts(quantity)), 'f', decimals, 64), nil
+}
+
+// GetTradeHistory 获取成交记录（近三个月，OKX 单次最多返回 100 条）
+func (t *OKXTrader) GetTradeHistory(symbol string, limit int) ([]map[string]interface{}, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 100
+	}
+
+	query := url.Values{
+		"instType": {"SWAP"},
+		"limit":    {strconv.Itoa(limit)},
+	}
+	if symbol != "" {
+		query.Set("instId", okxInstID(symbol))
+	}
+
+	data, err := t.request("GET", "/api/v5/trade/fills-history", query, nil)
+	if err != nil {
+		return nil, fmt.Errorf("获取 OKX 成交记录失败: %w", err)
+	}
+
+	trades := make([]map[string]interface{}, 0, len(data))
+	for _, fill := range data {
+		instID, _ := SafeString(fill, "instId")
+		inst, err := t.getInstrument(okxSymbol(instID))
+		if err != nil {
+			log.Printf("⚠️ [OKX] %v", err)
+			continue
+		}
+
+		orderID, _ := SafeString(fill, "ordId")
+		side, _ := SafeString(fill, "side")
+		price, _ := SafeFloat64(fill, "fillPx")
+		contracts, _ := SafeFloat64(fill, "fillSz")
+		fee, _ := SafeFloat64(fill, "fee") // OKX 手续费为负数表示扣除
+		pnl, _ := SafeFloat64(fill, "fillPnl")
+		ts, _ := SafeFloat64(fill, "ts")
+
+		trades = append(trades, map[string]interface{}{
+			"orderId":     orderID,
+			"symbol":      okxSymbol(instID),
+			"side":        side,
+			"price":       price,
+			"qty":         inst.toQuantity(contracts),
+			"fee":         -fee,
+			"realizedPnl": pnl,
+			"time":        ts,
+		})
+	}
+
+	return trades, nil
+}
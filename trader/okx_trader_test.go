@@ -0,0 +1,439 @@
+package trader
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ============================================================
+// 一、OKX v5 REST API mock
+// ============================================================
+
+const (
+	okxTestAPIKey     = "okx_test_api_key"
+	okxTestSecretKey  = "okx_test_secret_key"
+	okxTestPassphrase = "okx_test_passphrase"
+)
+
+// okxMockExchange 模拟 OKX v5 接口，记录下单/策略委托/撤单请求体
+type okxMockExchange struct {
+	posMode string
+
+	mu            sync.Mutex
+	orders        []map[string]interface{}
+	algoOrders    []map[string]interface{}
+	canceledAlgos []map[string]interface{}
+	leverages     []map[string]interface{}
+}
+
+func (m *okxMockExchange) record(list *[]map[string]interface{}, body []byte) {
+	var payload interface{}
+	json.Unmarshal(body, &payload)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	switch v := payload.(type) {
+	case map[string]interface{}:
+		*list = append(*list, v)
+	case []interface{}:
+		for _, item := range v {
+			if obj, ok := item.(map[string]interface{}); ok {
+				*list = append(*list, obj)
+			}
+		}
+	}
+}
+
+// okxTestInstruments BTC 每张 0.01 BTC，ETH 每张 0.1 ETH
+var okxTestInstruments = map[string]map[string]interface{}{
+	"BTC-USDT-SWAP": {"instId": "BTC-USDT-SWAP", "ctVal": "0.01", "lotSz": "0.01", "minSz": "0.01", "tickSz": "0.1", "state": "live"},
+	"ETH-USDT-SWAP": {"instId": "ETH-USDT-SWAP", "ctVal": "0.1", "lotSz": "0.01", "minSz": "0.01", "tickSz": "0.01", "state": "live"},
+}
+
+var okxTestTickers = map[string]string{
+	"BTC-USDT-SWAP": "50000.1",
+	"ETH-USDT-SWAP": "3000.25",
+}
+
+func newOKXMockServer(t *testing.T, mock *okxMockExchange) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		path := r.URL.Path
+		query := r.URL.Query()
+		ok := func(data ...map[string]interface{}) map[string]interface{} {
+			if data == nil {
+				data = []map[string]interface{}{}
+			}
+			return map[string]interface{}{"code": "0", "msg": "", "data": data}
+		}
+		var respBody interface{}
+
+		// 私有接口校验签名
+		if !strings.HasPrefix(path, "/api/v5/public/") && !strings.HasPrefix(path, "/api/v5/market/") {
+			mac := hmac.New(sha256.New, []byte(okxTestSecretKey))
+			mac.Write([]byte(r.Header.Get("OK-ACCESS-TIMESTAMP") + r.Method + r.URL.RequestURI() + string(body)))
+			expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+			if r.Header.Get("OK-ACCESS-SIGN") != expected || r.Header.Get("OK-ACCESS-KEY") != okxTestAPIKey ||
+				r.Header.Get("OK-ACCESS-PASSPHRASE") != okxTestPassphrase {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusUnauthorized)
+				json.NewEncoder(w).Encode(map[string]interface{}{"code": "50113", "msg": "Invalid Sign", "data": []interface{}{}})
+				return
+			}
+		}
+
+		switch {
+		case path == "/api/v5/public/instruments":
+			if inst, found := okxTestInstruments[query.Get("instId")]; found {
+				respBody = ok(inst)
+			} else {
+				respBody = map[string]interface{}{"code": "51001", "msg": "Instrument ID does not exist", "data": []interface{}{}}
+			}
+
+		case path == "/api/v5/market/ticker":
+			if last, found := okxTestTickers[query.Get("instId")]; found {
+				respBody = ok(map[string]interface{}{"instId": query.Get("instId"), "last": last})
+			} else {
+				respBody = map[string]interface{}{"code": "51001", "msg": "Instrument ID does not exist", "data": []interface{}{}}
+			}
+
+		case path == "/api/v5/account/config":
+			respBody = ok(map[string]interface{}{"posMode": mock.posMode, "acctLv": "2"})
+
+		case path == "/api/v5/account/balance":
+			respBody = ok(map[string]interface{}{
+				"totalEq": "10100.5",
+				"details": []map[string]interface{}{
+					{"ccy": "USDT", "eq": "10100.5", "cashBal": "10000", "availBal": "8000", "upl": "100.5"},
+				},
+			})
+
+		case path == "/api/v5/account/positions":
+			posSide, pos := "long", "10"
+			if mock.posMode == okxPosModeNet {
+				posSide = "net"
+			}
+			respBody = ok(
+				map[string]interface{}{
+					"instId": "BTC-USDT-SWAP", "posSide": posSide, "pos": pos, "avgPx": "49000",
+					"markPx": "50000", "upl": "100", "lever": "10", "liqPx": "45000", "mgnMode": "cross",
+				},
+				map[string]interface{}{
+					// 币本位合约不在 USDT 永续范围内，应被忽略
+					"instId": "BTC-USD-SWAP", "posSide": posSide, "pos": "5", "avgPx": "49000",
+				},
+			)
+
+		case path == "/api/v5/account/set-leverage":
+			mock.record(&mock.leverages, body)
+			respBody = ok()
+
+		case path == "/api/v5/trade/order" && r.Method == http.MethodPost:
+			mock.record(&mock.orders, body)
+			respBody = ok(map[string]interface{}{"ordId": "612345678901", "clOrdId": "", "sCode": "0", "sMsg": ""})
+
+		case path == "/api/v5/trade/order" && r.Method == http.MethodGet:
+			respBody = ok(map[string]interface{}{
+				"instId": query.Get("instId"), "ordId": query.Get("ordId"), "state": "partially_filled",
+				"sz": "2", "accFillSz": "1", "avgPx": "49900", "px": "49900",
+			})
+
+		case path == "/api/v5/trade/orders-pending":
+			respBody = ok(map[string]interface{}{"instId": query.Get("instId"), "ordId": "700000000001", "state": "live"})
+
+		case path == "/api/v5/trade/cancel-order", path == "/api/v5/trade/cancel-batch-orders":
+			respBody = ok(map[string]interface{}{"ordId": "700000000001", "sCode": "0", "sMsg": ""})
+
+		case path == "/api/v5/trade/order-algo":
+			mock.record(&mock.algoOrders, body)
+			respBody = ok(map[string]interface{}{"algoId": "800000000001", "sCode": "0", "sMsg": ""})
+
+		case path == "/api/v5/trade/orders-algo-pending":
+			instID := query.Get("instId")
+			if query.Get("ordType") == "move_order_stop" {
+				respBody = ok(map[string]interface{}{"algoId": "900000000003", "instId": instID, "ordType": "move_order_stop"})
+			} else {
+				respBody = ok(
+					map[string]interface{}{"algoId": "900000000001", "instId": instID, "ordType": "conditional", "slTriggerPx": "45000", "tpTriggerPx": ""},
+					map[string]interface{}{"algoId": "900000000002", "instId": instID, "ordType": "conditional", "slTriggerPx": "", "tpTriggerPx": "55000"},
+				)
+			}
+
+		case path == "/api/v5/trade/cancel-algos":
+			mock.record(&mock.canceledAlgos, body)
+			respBody = ok()
+
+		case path == "/api/v5/trade/fills-history":
+			respBody = ok(map[string]interface{}{
+				"instId": "ETH-USDT-SWAP", "tradeId": "123", "ordId": "612345678901", "side": "sell", "posSide": "long",
+				"fillPx": "3010.5", "fillSz": "3", "fee": "-0.45", "fillPnl": "2.4", "ts": "1700000000000",
+			})
+
+		default:
+			t.Logf("未处理的 OKX mock 请求: %s %s", r.Method, path)
+			respBody = map[string]interface{}{"code": "50000", "msg": "unknown path", "data": []interface{}{}}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(respBody)
+	}))
+}
+
+// newTestOKXTrader 创建指向 mock 服务器的 OKX 交易器
+func newTestOKXTrader(server *httptest.Server) *OKXTrader {
+	trader := NewOKXTrader(okxTestAPIKey, okxTestSecretKey, okxTestPassphrase, false)
+	trader.baseURL = server.URL
+	trader.client = server.Client()
+	return trader
+}
+
+// ============================================================
+// 二、OKXTraderTestSuite - 继承 base test suite
+// ============================================================
+
+// OKXTraderTestSuite OKX交易器测试套件
+type OKXTraderTestSuite struct {
+	*TraderTestSuite
+	mockServer *httptest.Server
+}
+
+// NewOKXTraderTestSuite 创建 OKX 测试套件
+func NewOKXTraderTestSuite(t *testing.T, posMode string) *OKXTraderTestSuite {
+	mockServer := newOKXMockServer(t, &okxMockExchange{posMode: posMode})
+	return &OKXTraderTestSuite{
+		TraderTestSuite: NewTraderTestSuite(t, newTestOKXTrader(mockServer)),
+		mockServer:      mockServer,
+	}
+}
+
+// Cleanup 清理资源
+func (s *OKXTraderTestSuite) Cleanup() {
+	if s.mockServer != nil {
+		s.mockServer.Close()
+	}
+	s.TraderTestSuite.Cleanup()
+}
+
+// TestOKXTrader_InterfaceCompliance 测试接口兼容性
+func TestOKXTrader_InterfaceCompliance(t *testing.T) {
+	var _ Trader = (*OKXTrader)(nil)
+	var _ NativeTrailingStopper = (*OKXTrader)(nil)
+}
+
+// TestOKXTrader_CommonInterface 双向持仓和单向持仓模式下运行通用测试
+func TestOKXTrader_CommonInterface(t *testing.T) {
+	for _, posMode := range []string{okxPosModeHedge, okxPosModeNet} {
+		t.Run(posMode, func(t *testing.T) {
+			suite := NewOKXTraderTestSuite(t, posMode)
+			defer suite.Cleanup()
+			suite.RunAllTests()
+		})
+	}
+}
+
+// TestOKXTrader_DomainContract 通过 mock 服务器走真实解析路径校验领域模型契约
+func TestOKXTrader_DomainContract(t *testing.T) {
+	for _, posMode := range []string{okxPosModeHedge, okxPosModeNet} {
+		t.Run(posMode, func(t *testing.T) {
+			server := newOKXMockServer(t, &okxMockExchange{posMode: posMode})
+			defer server.Close()
+			AssertDomainContract(t, DomainContractCase{
+				Exchange: "okx", Trader: newTestOKXTrader(server), Symbol: "BTCUSDT",
+				Quantity: 0.1, Price: 49000, HasTradeHistory: true,
+			})
+		})
+	}
+}
+
+// ============================================================
+// 三、OKX 特定功能的单元测试
+// ============================================================
+
+func TestOKXTrader_SymbolConversion(t *testing.T) {
+	assert.Equal(t, "BTC-USDT-SWAP", okxInstID("BTCUSDT"))
+	assert.Equal(t, "1000PEPE-USDT-SWAP", okxInstID("1000pepeusdt"))
+	assert.Equal(t, "ETH-USDT-SWAP", okxInstID("ETH-USDT-SWAP"))
+	assert.Equal(t, "BTCUSDT", okxSymbol("BTC-USDT-SWAP"))
+}
+
+func TestOKXTrader_RejectsInvalidSignature(t *testing.T) {
+	server := newOKXMockServer(t, &okxMockExchange{posMode: okxPosModeNet})
+	defer server.Close()
+
+	trader := newTestOKXTrader(server)
+	trader.secretKey = "wrong_secret"
+	_, err := trader.GetBalance()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "50113")
+
+	trader = newTestOKXTrader(server)
+	trader.passphrase = "wrong_passphrase"
+	_, err = trader.GetPositions()
+	assert.Error(t, err)
+}
+
+func TestOKXTrader_ContractSizeConversion(t *testing.T) {
+	mock := &okxMockExchange{posMode: okxPosModeNet}
+	server := newOKXMockServer(t, mock)
+	defer server.Close()
+	trader := newTestOKXTrader(server)
+
+	// 0.004 ETH = 0.04 张（每张 0.1 ETH）
+	order, err := trader.OpenLong("ETHUSDT", 0.004, 5)
+	require.NoError(t, err)
+	assert.Equal(t, "612345678901", order["orderId"])
+	assert.InDelta(t, 0.004, order["qty"].(float64), 1e-12)
+	require.Len(t, mock.orders, 1)
+	assert.Equal(t, "0.04", mock.orders[0]["sz"])
+	assert.Equal(t, "ETH-USDT-SWAP", mock.orders[0]["instId"])
+
+	// 持仓张数换算回币数量：10 张 BTC = 0.1 BTC
+	positions, err := FetchPositions(trader)
+	require.NoError(t, err)
+	require.Len(t, positions, 1, "币本位合约持仓应被忽略")
+	assert.Equal(t, "BTCUSDT", positions[0].Symbol)
+	assert.InDelta(t, 0.1, positions[0].Quantity, 1e-12)
+	assert.Equal(t, 45000.0, positions[0].LiquidationPrice)
+
+	// 按张数步长向下取整
+	qty, err := trader.FormatQuantity("BTCUSDT", 0.012345)
+	require.NoError(t, err)
+	assert.Equal(t, "0.0123", qty)
+
+	// 不足最小下单量
+	_, err = trader.OpenShort("BTCUSDT", 0.00005, 5)
+	assert.Error(t, err)
+
+	// 成交记录：3 张 ETH = 0.3 ETH，手续费转为正数
+	fills, err := FetchFills(trader, "ETHUSDT", 10)
+	require.NoError(t, err)
+	require.Len(t, fills, 1)
+	assert.Equal(t, "ETHUSDT", fills[0].Symbol)
+	assert.InDelta(t, 0.3, fills[0].Quantity, 1e-12)
+	assert.InDelta(t, 0.45, fills[0].Fee, 1e-12)
+	assert.Equal(t, 2.4, fills[0].RealizedPnL)
+	assert.Equal(t, int64(1700000000000), fills[0].Time.UnixMilli())
+
+	// 订单查询：live/partially_filled 统一为 OrderStatus*
+	raw, err := trader.GetOrder("BTCUSDT", "612345678901")
+	require.NoError(t, err)
+	result := OrderResultFromMap(raw)
+	assert.Equal(t, OrderStatusPartiallyFilled, result.Status)
+	assert.InDelta(t, 0.02, result.Quantity, 1e-12)
+	assert.InDelta(t, 0.01, result.FilledQuantity, 1e-12)
+	assert.Equal(t, OrderStatusNew, NormalizeOrderStatus("live"))
+}
+
+func TestOKXTrader_PositionModes(t *testing.T) {
+	t.Run("双向持仓带posSide", func(t *testing.T) {
+		mock := &okxMockExchange{posMode: okxPosModeHedge}
+		server := newOKXMockServer(t, mock)
+		defer server.Close()
+		trader := newTestOKXTrader(server)
+
+		_, err := trader.OpenShort("BTCUSDT", 0.02, 10)
+		require.NoError(t, err)
+		_, err = trader.CloseLong("BTCUSDT", 0)
+		require.NoError(t, err)
+
+		require.Len(t, mock.orders, 2)
+		assert.Equal(t, "sell", mock.orders[0]["side"])
+		assert.Equal(t, "short", mock.orders[0]["posSide"])
+		assert.Equal(t, "sell", mock.orders[1]["side"])
+		assert.Equal(t, "long", mock.orders[1]["posSide"])
+		assert.Equal(t, "10.00", mock.orders[1]["sz"], "全部平仓时按持仓张数下单")
+		assert.Nil(t, mock.orders[1]["reduceOnly"])
+	})
+
+	t.Run("单向持仓平仓使用reduceOnly", func(t *testing.T) {
+		mock := &okxMockExchange{posMode: okxPosModeNet}
+		server := newOKXMockServer(t, mock)
+		defer server.Close()
+		trader := newTestOKXTrader(server)
+
+		_, err := trader.CloseLong("BTCUSDT", 0.05)
+		require.NoError(t, err)
+		require.Len(t, mock.orders, 1)
+		assert.Nil(t, mock.orders[0]["posSide"])
+		assert.Equal(t, true, mock.orders[0]["reduceOnly"])
+		assert.Equal(t, "5.00", mock.orders[0]["sz"])
+
+		_, err = trader.CloseShort("BTCUSDT", 0)
+		assert.Error(t, err, "单向持仓模式下 BTC 为多仓，没有空仓可平")
+	})
+
+	t.Run("逐仓双向持仓分别设置多空杠杆", func(t *testing.T) {
+		mock := &okxMockExchange{posMode: okxPosModeHedge}
+		server := newOKXMockServer(t, mock)
+		defer server.Close()
+		trader := newTestOKXTrader(server)
+
+		require.NoError(t, trader.SetMarginMode("ETHUSDT", false))
+		_, err := trader.OpenLimitOrder("ETHUSDT", "long", 0.5, 3, 2950.123, TimeInForcePostOnly)
+		require.NoError(t, err)
+
+		require.Len(t, mock.leverages, 2)
+		assert.Equal(t, "isolated", mock.leverages[0]["mgnMode"])
+		assert.Equal(t, "long", mock.leverages[0]["posSide"])
+		assert.Equal(t, "short", mock.leverages[1]["posSide"])
+
+		require.Len(t, mock.orders, 1)
+		assert.Equal(t, "post_only", mock.orders[0]["ordType"])
+		assert.Equal(t, "2950.12", mock.orders[0]["px"])
+		assert.Equal(t, "isolated", mock.orders[0]["tdMode"])
+		assert.Equal(t, "5.00", mock.orders[0]["sz"])
+	})
+}
+
+func TestOKXTrader_AlgoOrders(t *testing.T) {
+	mock := &okxMockExchange{posMode: okxPosModeNet}
+	server := newOKXMockServer(t, mock)
+	defer server.Close()
+	trader := newTestOKXTrader(server)
+
+	require.NoError(t, trader.SetStopLoss("BTCUSDT", "LONG", 0.1, 45000.04))
+	require.NoError(t, trader.SetTakeProfit("BTCUSDT", "SHORT", 0.1, 44000))
+	require.Len(t, mock.algoOrders, 2)
+
+	sl := mock.algoOrders[0]
+	assert.Equal(t, "conditional", sl["ordType"])
+	assert.Equal(t, "sell", sl["side"])
+	assert.Equal(t, "45000.0", sl["slTriggerPx"])
+	assert.Equal(t, "-1", sl["slOrdPx"])
+	assert.Equal(t, "10.00", sl["sz"])
+	assert.Equal(t, true, sl["reduceOnly"])
+
+	tp := mock.algoOrders[1]
+	assert.Equal(t, "buy", tp["side"])
+	assert.Equal(t, "44000.0", tp["tpTriggerPx"])
+	assert.Nil(t, tp["slTriggerPx"])
+
+	// 只撤销止损条件单
+	require.NoError(t, trader.CancelStopLossOrders("BTCUSDT"))
+	require.Len(t, mock.canceledAlgos, 1)
+	assert.Equal(t, "900000000001", mock.canceledAlgos[0]["algoId"])
+	assert.Equal(t, "BTC-USDT-SWAP", mock.canceledAlgos[0]["instId"])
+
+	// 原生追踪止损使用 move_order_stop
+	order, err := trader.SetTrailingStop("BTCUSDT", "LONG", 0.1, 2, 0, 52000)
+	require.NoError(t, err)
+	assert.Equal(t, "800000000001", order["orderId"])
+	trailing := mock.algoOrders[2]
+	assert.Equal(t, "move_order_stop", trailing["ordType"])
+	assert.Equal(t, "0.02", trailing["callbackRatio"])
+	assert.Equal(t, "52000", trailing["activePx"])
+
+	require.NoError(t, trader.CancelTrailingStop("BTCUSDT", "LONG", ""))
+	assert.Equal(t, "900000000003", mock.canceledAlgos[len(mock.canceledAlgos)-1]["algoId"])
+}
@@ -0,0 +1,772 @@
+package trader
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"nofx/backtest"
+	"nofx/market"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	paperFeeBps            = 4.0 // 模拟盘手续费（基点），对齐主流交易所 taker 费率
+	paperSlippageBps       = 2.0 // 模拟盘市价单滑点（基点）
+	paperDefaultLeverage   = 5   // 未设置杠杆时的默认值
+	paperTradeHistoryLimit = 500 // 持久化的成交记录上限
+	paperOrderHistoryLimit = 100 // 持久化的已结束限价单上限
+	paperQuantityPrecision = 1e6 // 数量保留6位小数
+	paperTriggerInterval   = 10 * time.Second
+)
+
+// PaperStateStore 模拟盘账户状态持久化接口（config.Database 已实现）
+type PaperStateStore interface {
+	GetPaperAccountState(traderID string) (string, error)
+	SavePaperAccountState(traderID, userID, state string) error
+}
+
+// paperTrade 模拟盘成交记录
+type paperTrade struct {
+	OrderID     int64   `json:"order_id"`
+	Symbol      string  `json:"symbol"`
+	Side        string  `json:"side"`
+	Action      string  `json:"action"` // open_long / close_short / stop_loss / take_profit / liquidated ...
+	Quantity    float64 `json:"quantity"`
+	Price       float64 `json:"price"`
+	Fee         float64 `json:"fee"`
+	RealizedPnL float64 `json:"realized_pnl"`
+	Time        int64   `json:"time"`
+}
+
+// paperOrder 模拟盘限价单，挂单期间按最新价格检查是否成交
+type paperOrder struct {
+	OrderID  int64   `json:"order_id"`
+	Symbol   string  `json:"symbol"`
+	Side     string  `json:"side"`
+	Quantity float64 `json:"quantity"`
+	Price    float64 `json:"price"`
+	Leverage int     `json:"leverage"`
+	Status   string  `json:"status"`
+	FillTime int64   `json:"fill_time,omitempty"`
+	Time     int64   `json:"time"`
+}
+
+// paperAccountState 持久化到数据库的模拟盘状态
+type paperAccountState struct {
+	Cash        float64                     `json:"cash"`
+	RealizedPnL float64                     `json:"realized_pnl"`
+	FundingPnL  float64                     `json:"funding_pnl"`
+	Positions   []backtest.PositionSnapshot `json:"positions"`
+	Leverage    map[string]int              `json:"leverage,omitempty"`
+	NextOrderID int64                       `json:"next_order_id"`
+	Trades      []paperTrade                `json:"trades,omitempty"`
+	Orders      []paperOrder                `json:"orders,omitempty"`
+}
+
+// PaperTrader 模拟盘交易器
+// 复用回测账户的手续费、滑点与强平模型，按实时行情成交市价单，止盈止损在本地触发
+type PaperTrader struct {
+	traderID    string
+	userID      string
+	account     *backtest.BacktestAccount
+	store       PaperStateStore
+	priceFunc   func(symbol string) (float64, error)
+	leverage    map[string]int
+	trades      []paperTrade
+	orders      []paperOrder
+	nextOrderID int64
+	mu          sync.Mutex
+}
+
+// NewPaperTrader 创建模拟盘交易器，store 不为空时从数据库恢复账户状态
+func NewPaperTrader(traderID, userID string, initialBalance float64, store PaperStateStore) (*PaperTrader, error) {
+	if initialBalance <= 0 {
+		return nil, fmt.Errorf("模拟盘初始资金必须大于0")
+	}
+
+	t := &PaperTrader{
+		traderID:    traderID,
+		userID:      userID,
+		account:     backtest.NewBacktestAccount(initialBalance, paperFeeBps, paperSlippageBps),
+		store:       store,
+		priceFunc:   paperMarketPrice,
+		leverage:    make(map[string]int),
+		nextOrderID: 1,
+	}
+
+	if err := t.load(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// paperMarketPrice 优先使用 WSMonitor 缓存的最新K线收盘价，缓存不可用时回退到REST接口
+func paperMarketPrice(symbol string) (float64, error) {
+	if market.WSMonitorCli != nil {
+		if klines, err := market.WSMonitorCli.GetCurrentKlines(symbol, "3m"); err == nil && len(klines) > 0 {
+			if price := klines[len(klines)-1].Close; price > 0 {
+				return price, nil
+			}
+		}
+	}
+	return market.NewAPIClient().GetCurrentPrice(symbol)
+}
+
+// load 从数据库恢复账户状态
+func (t *PaperTrader) load() error {
+	if t.store == nil {
+		return nil
+	}
+	raw, err := t.store.GetPaperAccountState(t.traderID)
+	if err != nil {
+		return fmt.Errorf("读取模拟盘状态失败: %w", err)
+	}
+	if raw == "" {
+		return nil
+	}
+
+	var state paperAccountState
+	if err := json.Unmarshal([]byte(raw), &state); err != nil {
+		return fmt.Errorf("解析模拟盘状态失败: %w", err)
+	}
+	t.account.RestoreFromSnapshots(state.Cash, state.RealizedPnL, state.FundingPnL, state.Positions)
+	if state.Leverage != nil {
+		t.leverage = state.Leverage
+	}
+	if state.NextOrderID > 0 {
+		t.nextOrderID = state.NextOrderID
+	}
+	t.trades = state.Trades
+	t.orders = state.Orders
+	log.Printf("📄 [模拟盘] %s 已恢复账户状态: 现金 %.2f, 持仓 %d 个", t.traderID, state.Cash, len(state.Positions))
+	return nil
+}
+
+// saveLocked 持久化账户状态（调用方需持有锁）
+func (t *PaperTrader) saveLocked() {
+	if t.store == nil {
+		return
+	}
+	if len(t.trades) > paperTradeHistoryLimit {
+		t.trades = t.trades[len(t.trades)-paperTradeHistoryLimit:]
+	}
+	t.pruneOrdersLocked()
+	state := paperAccountState{
+		Cash:        t.account.Cash(),
+		RealizedPnL: t.account.RealizedPnL(),
+		FundingPnL:  t.account.FundingPnL(),
+		Positions:   t.account.PositionSnapshots(),
+		Leverage:    t.leverage,
+		NextOrderID: t.nextOrderID,
+		Trades:      t.trades,
+		Orders:      t.orders,
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		log.Printf("⚠️ [模拟盘] 序列化账户状态失败: %v", err)
+		return
+	}
+	if err := t.store.SavePaperAccountState(t.traderID, t.userID, string(data)); err != nil {
+		log.Printf("⚠️ [模拟盘] 保存账户状态失败: %v", err)
+	}
+}
+
+// recordTradeLocked 记录成交并返回订单ID（调用方需持有锁）
+func (t *PaperTrader) recordTradeLocked(trade paperTrade) int64 {
+	trade.OrderID = t.nextOrderID
+	trade.Time = time.Now().UnixMilli()
+	t.nextOrderID++
+	t.trades = append(t.trades, trade)
+	return trade.OrderID
+}
+
+// priceMapLocked 获取所有持仓及挂单币种的最新价格，获取失败的持仓币种使用开仓均价（调用方需持有锁）
+func (t *PaperTrader) priceMapLocked() map[string]float64 {
+	priceMap := make(map[string]float64)
+	for _, order := range t.orders {
+		if order.Status != OrderStatusNew {
+			continue
+		}
+		if _, ok := priceMap[order.Symbol]; ok {
+			continue
+		}
+		// 挂单币种取价失败时不填充，避免用错误价格误成交
+		if price, err := t.priceFunc(order.Symbol); err == nil && price > 0 {
+			priceMap[order.Symbol] = price
+		}
+	}
+	for _, pos := range t.account.PositionSnapshots() {
+		if _, ok := priceMap[pos.Symbol]; ok {
+			continue
+		}
+		price, err := t.priceFunc(pos.Symbol)
+		if err != nil || price <= 0 {
+			log.Printf("⚠️ [模拟盘] 获取 %s 价格失败，使用开仓均价: %v", pos.Symbol, err)
+			price = pos.AvgPrice
+		}
+		priceMap[pos.Symbol] = price
+	}
+	return priceMap
+}
+
+// CheckTriggers 按最新价格撮合限价挂单，并检查强平与止盈止损，触发即本地平仓
+func (t *PaperTrader) CheckTriggers() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.checkTriggersLocked()
+}
+
+func (t *PaperTrader) checkTriggersLocked() map[string]float64 {
+	priceMap := t.priceMapLocked()
+	if len(priceMap) == 0 {
+		return priceMap
+	}
+
+	filledOrders := t.fillPendingOrdersLocked(priceMap)
+
+	fills, err := t.account.ApplyPriceTriggers(priceMap, backtest.ProtectivePriorityStopLoss)
+	if err != nil {
+		log.Printf("⚠️ [模拟盘] 检查止盈止损失败: %v", err)
+	}
+	for _, fill := range fills {
+		t.recordTradeLocked(paperTrade{
+			Symbol:      fill.Symbol,
+			Side:        fill.Side,
+			Action:      fill.Kind,
+			Quantity:    fill.Quantity,
+			Price:       fill.Price,
+			Fee:         fill.Fee,
+			RealizedPnL: fill.RealizedPnL,
+		})
+		log.Printf("🎯 [模拟盘] %s %s 触发%s @ %.4f (盈亏 %+.2f)",
+			fill.Symbol, strings.ToUpper(fill.Side), paperTriggerLabel(fill.Kind), fill.Price, fill.RealizedPnL)
+	}
+	if len(fills) > 0 || filledOrders > 0 {
+		t.saveLocked()
+	}
+	return priceMap
+}
+
+// fillPendingOrdersLocked 价格触及限价的挂单按限价成交（Maker，不计滑点），返回成交笔数（调用方需持有锁）
+func (t *PaperTrader) fillPendingOrdersLocked(priceMap map[string]float64) int {
+	filled := 0
+	for i := range t.orders {
+		order := &t.orders[i]
+		if order.Status != OrderStatusNew {
+			continue
+		}
+		price, ok := priceMap[order.Symbol]
+		if !ok || !paperLimitCrossed(order.Side, price, order.Price) {
+			continue
+		}
+
+		_, fee, execPrice, err := t.account.OpenLimit(order.Symbol, order.Side, order.Quantity, order.Leverage, order.Price, time.Now().UnixMilli())
+		if err != nil {
+			order.Status = OrderStatusRejected
+			log.Printf("⚠️ [模拟盘] 限价单 #%d 成交失败: %v", order.OrderID, err)
+			continue
+		}
+		order.Status = OrderStatusFilled
+		order.FillTime = time.Now().UnixMilli()
+		t.trades = append(t.trades, paperTrade{
+			OrderID:  order.OrderID,
+			Symbol:   order.Symbol,
+			Side:     order.Side,
+			Action:   "open_" + order.Side,
+			Quantity: order.Quantity,
+			Price:    execPrice,
+			Fee:      fee,
+			Time:     order.FillTime,
+		})
+		filled++
+		log.Printf("📄 [模拟盘] 限价单 #%d 成交: 开%s %s 数量 %.6f @ %.4f",
+			order.OrderID, paperSideLabel(order.Side), order.Symbol, order.Quantity, execPrice)
+	}
+	return filled
+}
+
+// paperLimitCrossed 当前价格是否已触及限价（多单价格跌至限价以下，空单价格涨至限价以上）
+func paperLimitCrossed(side string, price, limitPrice float64) bool {
+	if side == "long" {
+		return price <= limitPrice
+	}
+	return price >= limitPrice
+}
+
+// pruneOrdersLocked 保留所有挂单，已结束的限价单只保留最近的 paperOrderHistoryLimit 条（调用方需持有锁）
+func (t *PaperTrader) pruneOrdersLocked() {
+	finished := 0
+	for _, order := range t.orders {
+		if order.Status != OrderStatusNew {
+			finished++
+		}
+	}
+	if finished <= paperOrderHistoryLimit {
+		return
+	}
+	drop := finished - paperOrderHistoryLimit
+	kept := t.orders[:0]
+	for _, order := range t.orders {
+		if order.Status != OrderStatusNew && drop > 0 {
+			drop--
+			continue
+		}
+		kept = append(kept, order)
+	}
+	t.orders = kept
+}
+
+func paperTriggerLabel(kind string) string {
+	switch kind {
+	case "stop_loss":
+		return "止损"
+	case "take_profit":
+		return "止盈"
+	default:
+		return "强平"
+	}
+}
+
+// GetBalance 获取账户余额
+func (t *PaperTrader) GetBalance() (map[string]interface{}, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	priceMap := t.checkTriggersLocked()
+	equity, unrealized, _ := t.account.TotalEquity(priceMap)
+
+	return map[string]interface{}{
+		"totalWalletBalance":    equity - unrealized,
+		"availableBalance":      t.account.Cash(),
+		"totalUnrealizedProfit": unrealized,
+	}, nil
+}
+
+// GetPositions 获取所有持仓
+func (t *PaperTrader) GetPositions() ([]map[string]interface{}, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	priceMap := t.checkTriggersLocked()
+	_, _, perSymbol := t.account.TotalEquity(priceMap)
+
+	var result []map[string]interface{}
+	for _, pos := range t.account.PositionSnapshots() {
+		result = append(result, map[string]interface{}{
+			"symbol":           pos.Symbol,
+			"side":             pos.Side,
+			"positionAmt":      pos.Quantity,
+			"entryPrice":       pos.AvgPrice,
+			"markPrice":        priceMap[pos.Symbol],
+			"unRealizedProfit": perSymbol[pos.Symbol+":"+pos.Side],
+			"leverage":         float64(pos.Leverage),
+			"liquidationPrice": pos.LiquidationPrice,
+		})
+	}
+	return result, nil
+}
+
+// OpenLong 开多仓
+func (t *PaperTrader) OpenLong(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+	return t.open(symbol, "long", quantity, leverage)
+}
+
+// OpenShort 开空仓
+func (t *PaperTrader) OpenShort(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+	return t.open(symbol, "short", quantity, leverage)
+}
+
+// CloseLong 平多仓（quantity=0表示全部平仓）
+func (t *PaperTrader) CloseLong(symbol string, quantity float64) (map[string]interface{}, error) {
+	return t.close(symbol, "long", quantity)
+}
+
+// CloseShort 平空仓（quantity=0表示全部平仓）
+func (t *PaperTrader) CloseShort(symbol string, quantity float64) (map[string]interface{}, error) {
+	return t.close(symbol, "short", quantity)
+}
+
+func (t *PaperTrader) open(symbol, side string, quantity float64, leverage int) (map[string]interface{}, error) {
+	symbol = market.Normalize(symbol)
+	price, err := t.priceFunc(symbol)
+	if err != nil {
+		return nil, fmt.Errorf("获取 %s 价格失败: %w", symbol, err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if leverage <= 0 {
+		leverage = t.leverage[symbol]
+	}
+	if leverage <= 0 {
+		leverage = paperDefaultLeverage
+	}
+
+	_, fee, execPrice, err := t.account.Open(symbol, side, quantity, leverage, price, time.Now().UnixMilli())
+	if err != nil {
+		return nil, fmt.Errorf("模拟盘开仓失败: %w", err)
+	}
+	orderID := t.recordTradeLocked(paperTrade{
+		Symbol:   symbol,
+		Side:     side,
+		Action:   "open_" + side,
+		Quantity: quantity,
+		Price:    execPrice,
+		Fee:      fee,
+	})
+	t.saveLocked()
+
+	log.Printf("📄 [模拟盘] 开%s %s 数量 %.6f @ %.4f (手续费 %.4f)", paperSideLabel(side), symbol, quantity, execPrice, fee)
+	return map[string]interface{}{
+		"orderId": orderID,
+		"symbol":  symbol,
+		"status":  "FILLED",
+		"price":   execPrice,
+	}, nil
+}
+
+func (t *PaperTrader) close(symbol, side string, quantity float64) (map[string]interface{}, error) {
+	symbol = market.Normalize(symbol)
+	price, err := t.priceFunc(symbol)
+	if err != nil {
+		return nil, fmt.Errorf("获取 %s 价格失败: %w", symbol, err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// 平仓前保护单仍可能先被触发
+	t.checkTriggersLocked()
+
+	var held float64
+	for _, pos := range t.account.PositionSnapshots() {
+		if pos.Symbol == symbol && pos.Side == side {
+			held = pos.Quantity
+		}
+	}
+	if held <= 0 {
+		return nil, fmt.Errorf("没有找到 %s 的%s仓", symbol, paperSideLabel(side))
+	}
+	if quantity <= 0 || quantity > held {
+		quantity = held
+	}
+
+	realized, fee, execPrice, err := t.account.Close(symbol, side, quantity, price)
+	if err != nil {
+		return nil, fmt.Errorf("模拟盘平仓失败: %w", err)
+	}
+	orderID := t.recordTradeLocked(paperTrade{
+		Symbol:      symbol,
+		Side:        side,
+		Action:      "close_" + side,
+		Quantity:    quantity,
+		Price:       execPrice,
+		Fee:         fee,
+		RealizedPnL: realized - fee,
+	})
+	t.saveLocked()
+
+	log.Printf("📄 [模拟盘] 平%s %s 数量 %.6f @ %.4f (盈亏 %+.2f)", paperSideLabel(side), symbol, quantity, execPrice, realized-fee)
+	return map[string]interface{}{
+		"orderId": orderID,
+		"symbol":  symbol,
+		"status":  "FILLED",
+		"price":   execPrice,
+	}, nil
+}
+
+// OpenLimitOrder 限价开仓
+// 价格已触及限价时按市价立即成交（post_only 则直接拒绝），IOC 未成交部分立即撤销，其余挂单等待 CheckTriggers 撮合
+func (t *PaperTrader) OpenLimitOrder(symbol, side string, quantity float64, leverage int, price float64, timeInForce string) (map[string]interface{}, error) {
+	if quantity <= 0 || price <= 0 {
+		return nil, fmt.Errorf("限价单数量和价格必须大于0")
+	}
+	symbol = market.Normalize(symbol)
+	marketPrice, err := t.priceFunc(symbol)
+	if err != nil {
+		return nil, fmt.Errorf("获取 %s 价格失败: %w", symbol, err)
+	}
+
+	if paperLimitCrossed(side, marketPrice, price) {
+		if timeInForce == TimeInForcePostOnly {
+			return nil, fmt.Errorf("只做Maker订单会立即成交，已拒绝: %s 限价 %.4f 当前价 %.4f", symbol, price, marketPrice)
+		}
+		// 可立即成交的限价单按市价吃单成交
+		result, err := t.open(symbol, side, quantity, leverage)
+		if err != nil {
+			return nil, err
+		}
+		result["qty"] = quantity
+		result["executedQty"] = quantity
+		return result, nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if leverage <= 0 {
+		leverage = t.leverage[symbol]
+	}
+	if leverage <= 0 {
+		leverage = paperDefaultLeverage
+	}
+
+	order := paperOrder{
+		OrderID:  t.nextOrderID,
+		Symbol:   symbol,
+		Side:     side,
+		Quantity: quantity,
+		Price:    price,
+		Leverage: leverage,
+		Status:   OrderStatusNew,
+		Time:     time.Now().UnixMilli(),
+	}
+	t.nextOrderID++
+	if timeInForce == TimeInForceIOC {
+		order.Status = OrderStatusExpired
+	}
+	t.orders = append(t.orders, order)
+	t.saveLocked()
+
+	log.Printf("📄 [模拟盘] 限价单 #%d: 开%s %s 数量 %.6f @ %.4f (%s)", order.OrderID, paperSideLabel(side), symbol, quantity, price, order.Status)
+	return paperOrderToMap(order), nil
+}
+
+// GetOrder 查询订单状态（市价单查询成交记录，限价单查询本地挂单）
+func (t *PaperTrader) GetOrder(symbol, orderID string) (map[string]interface{}, error) {
+	id, err := strconv.ParseInt(orderID, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("无效的订单ID: %s", orderID)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.checkTriggersLocked()
+	for _, order := range t.orders {
+		if order.OrderID == id {
+			return paperOrderToMap(order), nil
+		}
+	}
+	for _, trade := range t.trades {
+		if trade.OrderID == id {
+			return map[string]interface{}{
+				"orderId":     trade.OrderID,
+				"symbol":      trade.Symbol,
+				"status":      OrderStatusFilled,
+				"price":       trade.Price,
+				"qty":         trade.Quantity,
+				"executedQty": trade.Quantity,
+			}, nil
+		}
+	}
+	return nil, fmt.Errorf("订单不存在: %s", orderID)
+}
+
+// CancelOrder 取消单个限价挂单
+func (t *PaperTrader) CancelOrder(symbol, orderID string) error {
+	id, err := strconv.ParseInt(orderID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("无效的订单ID: %s", orderID)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for i := range t.orders {
+		if t.orders[i].OrderID != id {
+			continue
+		}
+		if t.orders[i].Status != OrderStatusNew {
+			return fmt.Errorf("订单 %s 已结束（%s），无法取消", orderID, t.orders[i].Status)
+		}
+		t.orders[i].Status = OrderStatusCanceled
+		t.saveLocked()
+		log.Printf("📄 [模拟盘] 已取消限价单 #%d", id)
+		return nil
+	}
+	return fmt.Errorf("订单不存在: %s", orderID)
+}
+
+func paperOrderToMap(order paperOrder) map[string]interface{} {
+	executed := 0.0
+	if order.Status == OrderStatusFilled {
+		executed = order.Quantity
+	}
+	return map[string]interface{}{
+		"orderId":     order.OrderID,
+		"symbol":      order.Symbol,
+		"status":      order.Status,
+		"price":       order.Price,
+		"qty":         order.Quantity,
+		"executedQty": executed,
+	}
+}
+
+func paperSideLabel(side string) string {
+	if side == "long" {
+		return "多"
+	}
+	return "空"
+}
+
+// SetLeverage 设置杠杆（仅记录，下一次开仓生效）
+func (t *PaperTrader) SetLeverage(symbol string, leverage int) error {
+	if leverage <= 0 {
+		return fmt.Errorf("杠杆必须大于0")
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.leverage[market.Normalize(symbol)] = leverage
+	return nil
+}
+
+// SetMarginMode 设置仓位模式（模拟盘统一按逐仓保证金计算，忽略该设置）
+func (t *PaperTrader) SetMarginMode(symbol string, isCrossMargin bool) error {
+	return nil
+}
+
+// GetMarketPrice 获取市场价格
+func (t *PaperTrader) GetMarketPrice(symbol string) (float64, error) {
+	return t.priceFunc(market.Normalize(symbol))
+}
+
+// SetStopLoss 设置止损单（本地保存，作用于整个持仓）
+func (t *PaperTrader) SetStopLoss(symbol string, positionSide string, quantity, stopPrice float64) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := t.account.SetStopLoss(market.Normalize(symbol), strings.ToLower(positionSide), stopPrice); err != nil {
+		return fmt.Errorf("设置止损失败: %w", err)
+	}
+	t.saveLocked()
+	return nil
+}
+
+// SetTakeProfit 设置止盈单（本地保存）。数量小于持仓时作为分批止盈的一档追加，否则作用于整个持仓
+func (t *PaperTrader) SetTakeProfit(symbol string, positionSide string, quantity, takeProfitPrice float64) error {
+	symbol = market.Normalize(symbol)
+	side := strings.ToLower(positionSide)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	partial := false
+	for _, pos := range t.account.PositionSnapshots() {
+		if pos.Symbol == symbol && pos.Side == side {
+			partial = quantity > 0 && quantity < pos.Quantity*(1-1e-6)
+		}
+	}
+
+	var err error
+	if partial {
+		err = t.account.AddTakeProfitLevel(symbol, side, takeProfitPrice, quantity)
+	} else {
+		err = t.account.SetTakeProfit(symbol, side, takeProfitPrice)
+	}
+	if err != nil {
+		return fmt.Errorf("设置止盈失败: %w", err)
+	}
+	t.saveLocked()
+	return nil
+}
+
+// CancelStopLossOrders 仅取消止损单
+func (t *PaperTrader) CancelStopLossOrders(symbol string) error {
+	return t.clearProtective(symbol, true, false)
+}
+
+// CancelTakeProfitOrders 仅取消止盈单
+func (t *PaperTrader) CancelTakeProfitOrders(symbol string) error {
+	return t.clearProtective(symbol, false, true)
+}
+
+// CancelAllOrders 取消该币种的所有挂单（本地止盈止损单与限价挂单）
+func (t *PaperTrader) CancelAllOrders(symbol string) error {
+	normalized := market.Normalize(symbol)
+	t.mu.Lock()
+	canceled := false
+	for i := range t.orders {
+		if t.orders[i].Symbol == normalized && t.orders[i].Status == OrderStatusNew {
+			t.orders[i].Status = OrderStatusCanceled
+			canceled = true
+		}
+	}
+	if canceled {
+		t.saveLocked()
+	}
+	t.mu.Unlock()
+	return t.clearProtective(symbol, true, true)
+}
+
+// CancelStopOrders 取消该币种的止盈/止损单
+func (t *PaperTrader) CancelStopOrders(symbol string) error {
+	return t.clearProtective(symbol, true, true)
+}
+
+func (t *PaperTrader) clearProtective(symbol string, stopLoss, takeProfit bool) error {
+	symbol = market.Normalize(symbol)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	changed := false
+	for _, pos := range t.account.PositionSnapshots() {
+		if pos.Symbol != symbol {
+			continue
+		}
+		if stopLoss && pos.StopLoss > 0 {
+			_ = t.account.SetStopLoss(symbol, pos.Side, 0)
+			changed = true
+		}
+		if takeProfit && pos.TakeProfit > 0 {
+			_ = t.account.SetTakeProfit(symbol, pos.Side, 0)
+			changed = true
+		}
+	}
+	if changed {
+		t.saveLocked()
+	}
+	return nil
+}
+
+// FormatQuantity 格式化数量到正确的精度（模拟盘保留6位小数）
+func (t *PaperTrader) FormatQuantity(symbol string, quantity float64) (string, error) {
+	rounded := math.Floor(quantity*paperQuantityPrecision) / paperQuantityPrecision
+	return strconv.FormatFloat(rounded, 'f', -1, 64), nil
+}
+
+// GetTradeHistory 获取交易历史记录（symbol为空时返回全部币种）
+func (t *PaperTrader) GetTradeHistory(symbol string, limit int) ([]map[string]interface{}, error) {
+	if limit <= 0 {
+		limit = 500 // 默认获取500条
+	}
+	if symbol != "" {
+		symbol = market.Normalize(symbol)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	result := []map[string]interface{}{}
+	for i := len(t.trades) - 1; i >= 0 && len(result) < limit; i-- {
+		trade := t.trades[i]
+		if symbol != "" && trade.Symbol != symbol {
+			continue
+		}
+		result = append(result, map[string]interface{}{
+			"orderId":     trade.OrderID,
+			"symbol":      trade.Symbol,
+			"side":        trade.Side,
+			"action":      trade.Action,
+			"qty":         trade.Quantity,
+			"price":       trade.Price,
+			"fee":         trade.Fee,
+			"realizedPnl": trade.RealizedPnL,
+			"time":        trade.Time,
+		})
+	}
+	return result, nil
+}
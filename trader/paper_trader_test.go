@@ -0,0 +1,193 @@
+package trader
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryPaperStore 内存版模拟盘状态存储
+type memoryPaperStore struct {
+	states map[string]string
+}
+
+func (s *memoryPaperStore) GetPaperAccountState(traderID string) (string, error) {
+	return s.states[traderID], nil
+}
+
+func (s *memoryPaperStore) SavePaperAccountState(traderID, userID, state string) error {
+	s.states[traderID] = state
+	return nil
+}
+
+func newTestPaperTrader(t *testing.T, store PaperStateStore, prices map[string]float64) *PaperTrader {
+	pt, err := NewPaperTrader("paper_test", "default", 1000, store)
+	require.NoError(t, err)
+	pt.priceFunc = func(symbol string) (float64, error) {
+		return prices[symbol], nil
+	}
+	return pt
+}
+
+func TestPaperTrader_OpenAndClose(t *testing.T) {
+	prices := map[string]float64{"BTCUSDT": 100}
+	pt := newTestPaperTrader(t, nil, prices)
+
+	order, err := pt.OpenLong("BTC", 5, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), order["orderId"])
+	assert.Greater(t, order["price"].(float64), 100.0, "开多应计入滑点")
+
+	positions, err := pt.GetPositions()
+	require.NoError(t, err)
+	require.Len(t, positions, 1)
+	assert.Equal(t, "long", positions[0]["side"])
+	assert.Equal(t, 5.0, positions[0]["positionAmt"])
+
+	prices["BTCUSDT"] = 110
+	_, err = pt.CloseLong("BTCUSDT", 0)
+	require.NoError(t, err)
+
+	balance, err := pt.GetBalance()
+	require.NoError(t, err)
+	assert.Greater(t, balance["totalWalletBalance"].(float64), 1000.0)
+	assert.Less(t, balance["totalWalletBalance"].(float64), 1050.0, "应扣除手续费与滑点")
+
+	history, err := pt.GetTradeHistory("BTCUSDT", 10)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, "close_long", history[0]["action"])
+}
+
+func TestPaperTrader_StopLossTriggersLocally(t *testing.T) {
+	prices := map[string]float64{"ETHUSDT": 2000}
+	pt := newTestPaperTrader(t, nil, prices)
+
+	_, err := pt.OpenShort("ETHUSDT", 1, 5)
+	require.NoError(t, err)
+	require.NoError(t, pt.SetStopLoss("ETHUSDT", "SHORT", 1, 2100))
+	require.NoError(t, pt.SetTakeProfit("ETHUSDT", "SHORT", 1, 1800))
+
+	prices["ETHUSDT"] = 2050
+	pt.CheckTriggers()
+	positions, _ := pt.GetPositions()
+	assert.Len(t, positions, 1, "未触及止损价不应平仓")
+
+	prices["ETHUSDT"] = 2120
+	pt.CheckTriggers()
+	positions, _ = pt.GetPositions()
+	assert.Len(t, positions, 0)
+
+	history, _ := pt.GetTradeHistory("", 1)
+	require.Len(t, history, 1)
+	assert.Equal(t, "stop_loss", history[0]["action"])
+	assert.Less(t, history[0]["realizedPnl"].(float64), 0.0)
+}
+
+func TestPaperTrader_CancelStopLossKeepsTakeProfit(t *testing.T) {
+	prices := map[string]float64{"SOLUSDT": 100}
+	pt := newTestPaperTrader(t, nil, prices)
+
+	_, err := pt.OpenLong("SOLUSDT", 2, 5)
+	require.NoError(t, err)
+	require.NoError(t, pt.SetStopLoss("SOLUSDT", "LONG", 2, 95))
+	require.NoError(t, pt.SetTakeProfit("SOLUSDT", "LONG", 2, 120))
+	require.NoError(t, pt.CancelStopLossOrders("SOLUSDT"))
+
+	prices["SOLUSDT"] = 90
+	pt.CheckTriggers()
+	positions, _ := pt.GetPositions()
+	require.Len(t, positions, 1, "止损已取消，不应触发")
+
+	prices["SOLUSDT"] = 121
+	pt.CheckTriggers()
+	positions, _ = pt.GetPositions()
+	assert.Len(t, positions, 0)
+}
+
+func TestPaperTrader_RestoresStateFromStore(t *testing.T) {
+	store := &memoryPaperStore{states: make(map[string]string)}
+	prices := map[string]float64{"BTCUSDT": 100}
+	pt := newTestPaperTrader(t, store, prices)
+
+	_, err := pt.OpenLong("BTCUSDT", 3, 10)
+	require.NoError(t, err)
+	require.NoError(t, pt.SetStopLoss("BTCUSDT", "LONG", 3, 90))
+
+	restored := newTestPaperTrader(t, store, prices)
+	positions, err := restored.GetPositions()
+	require.NoError(t, err)
+	require.Len(t, positions, 1)
+	assert.Equal(t, 3.0, positions[0]["positionAmt"])
+
+	order, err := restored.CloseLong("BTCUSDT", 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), order["orderId"], "订单号应在重启后延续")
+
+	prices["BTCUSDT"] = 80
+	_, err = restored.OpenLong("BTCUSDT", 1, 10)
+	require.NoError(t, err)
+	pt2 := newTestPaperTrader(t, store, prices)
+	positions, _ = pt2.GetPositions()
+	assert.Len(t, positions, 1, "新仓位未设置止损，不应被旧止损触发")
+}
+
+func TestPaperTrader_LimitOrderFillsWhenPriceCrosses(t *testing.T) {
+	store := &memoryPaperStore{states: make(map[string]string)}
+	prices := map[string]float64{"BTCUSDT": 100}
+	pt := newTestPaperTrader(t, store, prices)
+
+	order, err := pt.OpenLimitOrder("BTCUSDT", "long", 2, 5, 95, TimeInForceGTC)
+	require.NoError(t, err)
+	assert.Equal(t, OrderStatusNew, order["status"])
+	orderID := OrderResultFromMap(order).OrderID
+
+	prices["BTCUSDT"] = 97
+	pt.CheckTriggers()
+	positions, _ := pt.GetPositions()
+	assert.Len(t, positions, 0, "未触及限价不应成交")
+
+	// 挂单应在重启后恢复
+	restored := newTestPaperTrader(t, store, prices)
+	prices["BTCUSDT"] = 94
+	restored.CheckTriggers()
+
+	positions, _ = restored.GetPositions()
+	require.Len(t, positions, 1)
+	assert.Equal(t, 95.0, positions[0]["entryPrice"], "限价单应按限价成交且不计滑点")
+
+	status, err := restored.GetOrder("BTCUSDT", orderID)
+	require.NoError(t, err)
+	assert.Equal(t, OrderStatusFilled, status["status"])
+	assert.Equal(t, 2.0, status["executedQty"])
+}
+
+func TestPaperTrader_PostOnlyRejectedWhenMarketable(t *testing.T) {
+	prices := map[string]float64{"ETHUSDT": 2000}
+	pt := newTestPaperTrader(t, nil, prices)
+
+	_, err := pt.OpenLimitOrder("ETHUSDT", "short", 1, 5, 1990, TimeInForcePostOnly)
+	assert.Error(t, err, "会立即成交的只做Maker订单应被拒绝")
+
+	order, err := pt.OpenLimitOrder("ETHUSDT", "short", 1, 5, 1990, TimeInForceGTC)
+	require.NoError(t, err)
+	assert.Equal(t, "FILLED", order["status"], "可立即成交的普通限价单应直接成交")
+}
+
+func TestPaperTrader_CancelLimitOrder(t *testing.T) {
+	prices := map[string]float64{"SOLUSDT": 100}
+	pt := newTestPaperTrader(t, nil, prices)
+
+	order, err := pt.OpenLimitOrder("SOLUSDT", "long", 1, 5, 90, TimeInForceGTC)
+	require.NoError(t, err)
+	orderID := OrderResultFromMap(order).OrderID
+
+	require.NoError(t, pt.CancelOrder("SOLUSDT", orderID))
+	assert.Error(t, pt.CancelOrder("SOLUSDT", orderID), "已撤销的订单不能再次撤销")
+
+	prices["SOLUSDT"] = 80
+	pt.CheckTriggers()
+	positions, _ := pt.GetPositions()
+	assert.Len(t, positions, 0, "已撤销的限价单不应成交")
+}
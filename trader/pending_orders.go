@@ -0,0 +1,353 @@
+package trader
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"nofx/decision"
+	"nofx/logger"
+	"strings"
+	"time"
+)
+
+// defaultPendingOrderMaxCycles 限价单默认最多挂单的决策周期数
+const defaultPendingOrderMaxCycles = 3
+
+// PendingOrderStore 限价挂单状态持久化（由 config.Database 实现），用于重启后继续跟踪成交
+type PendingOrderStore interface {
+	GetPendingOrderState(traderID string) (string, error)
+	SavePendingOrderState(traderID, userID, state string) error
+}
+
+// pendingOrder 已提交但尚未完全成交的限价开仓单
+// 止盈止损需要在成交后才能设置，因此在这里暂存决策中的价格
+type pendingOrder struct {
+	OrderID     string  `json:"order_id"`
+	Symbol      string  `json:"symbol"`
+	Side        string  `json:"side"` // long/short
+	Quantity    float64 `json:"quantity"`
+	LimitPrice  float64 `json:"limit_price"`
+	StopLoss    float64 `json:"stop_loss"`
+	TakeProfit  float64 `json:"take_profit"`
+	Leverage    int     `json:"leverage"`
+	PlacedCycle int     `json:"-"` // 挂单时的决策周期编号（重启后从恢复时的周期重新计数）
+
+	TakeProfitLevels  []decision.TakeProfitLevel `json:"take_profit_levels,omitempty"` // 分批止盈档位（按挂单价展开）
+	BreakevenAfterTP1 bool                       `json:"breakeven_after_tp1,omitempty"`
+	ProtectedQuantity float64                    `json:"protected_quantity"` // 已设置止盈止损的成交数量（部分成交时逐次累加）
+}
+
+func pendingOrderKey(symbol, side string) string {
+	return symbol + "_" + side
+}
+
+// pendingOrderMaxCycles 限价单最多挂单的决策周期数
+func (at *AutoTrader) pendingOrderMaxCycles() int {
+	if at.config.PendingOrderMaxCycles > 0 {
+		return at.config.PendingOrderMaxCycles
+	}
+	return defaultPendingOrderMaxCycles
+}
+
+// placeLimitOrder 提交限价开仓单，立即成交则直接设置止盈止损，否则加入挂单跟踪
+func (at *AutoTrader) placeLimitOrder(d *decision.Decision, side string, quantity float64, actionRecord *logger.DecisionAction) error {
+	key := pendingOrderKey(d.Symbol, side)
+
+	at.pendingOrdersMutex.Lock()
+	existing, exists := at.pendingOrders[key]
+	at.pendingOrdersMutex.Unlock()
+	if exists {
+		return fmt.Errorf("❌ %s 已有未成交的%s限价单（订单ID: %s），拒绝重复挂单", d.Symbol, side, existing.OrderID)
+	}
+
+	timeInForce := TimeInForceGTC
+	if d.OrderType == decision.OrderTypePostOnly {
+		timeInForce = TimeInForcePostOnly
+	} else if d.TimeInForce == decision.TimeInForceIOC {
+		timeInForce = TimeInForceIOC
+	}
+
+	order, err := at.trader.OpenLimitOrder(d.Symbol, side, quantity, d.Leverage, d.LimitPrice, timeInForce)
+	if err != nil {
+		return err
+	}
+
+	result := OrderResultFromMap(order)
+	actionRecord.OrderID = result.NumericID()
+	actionRecord.Price = d.LimitPrice
+
+	pending := &pendingOrder{
+		OrderID:     result.OrderID,
+		Symbol:      d.Symbol,
+		Side:        side,
+		Quantity:    quantity,
+		LimitPrice:  d.LimitPrice,
+		StopLoss:    d.StopLoss,
+		TakeProfit:  d.TakeProfit,
+		Leverage:    d.Leverage,
+		PlacedCycle: at.callCount,
+
+		TakeProfitLevels:  at.takeProfitLevels(d, d.LimitPrice),
+		BreakevenAfterTP1: at.breakevenAfterTP1(d),
+	}
+
+	status := NormalizeOrderStatus(result.Status)
+	switch {
+	case status == OrderStatusFilled:
+		if result.Price > 0 {
+			actionRecord.Price = result.Price
+		}
+		log.Printf("  ✓ 限价单立即成交，订单ID: %s, 数量: %.4f", result.OrderID, quantity)
+		at.protectFilledOrder(pending, filledQuantity(result, quantity))
+		return nil
+	case result.IsFinal():
+		// IOC 未能全部成交等情况：保护已成交部分
+		if result.FilledQuantity > 0 {
+			at.protectFilledOrder(pending, result.FilledQuantity)
+			return nil
+		}
+		return fmt.Errorf("限价单未成交，状态: %s", status)
+	case result.OrderID == "" || result.OrderID == "0":
+		return fmt.Errorf("限价单已提交但交易所未返回订单ID，无法跟踪成交")
+	}
+
+	// 挂单时已部分成交：先保护已成交部分，剩余部分继续跟踪
+	at.protectNewFills(pending, result.FilledQuantity)
+
+	at.pendingOrdersMutex.Lock()
+	at.pendingOrders[key] = pending
+	at.savePendingOrdersLocked()
+	at.pendingOrdersMutex.Unlock()
+
+	log.Printf("  📝 限价单已挂出，订单ID: %s, %s %s 数量: %.4f @ %.4f（最多等待 %d 个周期）",
+		result.OrderID, d.Symbol, side, quantity, d.LimitPrice, at.pendingOrderMaxCycles())
+	return nil
+}
+
+// processPendingOrders 检查所有挂单：成交则设置止盈止损，已结束则移除，超过挂单周期则撤单
+func (at *AutoTrader) processPendingOrders() {
+	at.pendingOrdersMutex.Lock()
+	orders := make(map[string]*pendingOrder, len(at.pendingOrders))
+	for key, order := range at.pendingOrders {
+		orders[key] = order
+	}
+	at.pendingOrdersMutex.Unlock()
+
+	if len(orders) == 0 {
+		return
+	}
+
+	maxCycles := at.pendingOrderMaxCycles()
+	for key, order := range orders {
+		age := at.callCount - order.PlacedCycle
+
+		raw, err := at.trader.GetOrder(order.Symbol, order.OrderID)
+		if err != nil {
+			log.Printf("  ⚠ 查询限价单 %s (%s) 失败: %v", order.OrderID, order.Symbol, err)
+			if age >= maxCycles {
+				if err := at.trader.CancelOrder(order.Symbol, order.OrderID); err != nil {
+					log.Printf("  ⚠ 撤销超时限价单 %s 失败: %v", order.OrderID, err)
+				}
+				at.removePendingOrder(key)
+			}
+			continue
+		}
+
+		result := OrderResultFromMap(raw)
+		status := NormalizeOrderStatus(result.Status)
+
+		switch {
+		case status == OrderStatusFilled:
+			log.Printf("  ✓ 限价单 %s 已成交: %s %s", order.OrderID, order.Symbol, order.Side)
+			at.protectNewFills(order, filledQuantity(result, order.Quantity))
+			at.removePendingOrder(key)
+
+		case result.IsFinal():
+			log.Printf("  ℹ 限价单 %s 已结束（%s），已成交 %.4f", order.OrderID, status, result.FilledQuantity)
+			at.protectNewFills(order, result.FilledQuantity)
+			at.removePendingOrder(key)
+
+		case age >= maxCycles:
+			log.Printf("  ⏰ 限价单 %s 挂单 %d 个周期仍未成交，撤单", order.OrderID, age)
+			if err := at.trader.CancelOrder(order.Symbol, order.OrderID); err != nil {
+				log.Printf("  ⚠ 撤销限价单 %s 失败: %v", order.OrderID, err)
+				continue
+			}
+			// 撤单前可能已部分成交，需要为尚未保护的已成交部分设置止盈止损
+			at.protectNewFills(order, result.FilledQuantity)
+			at.removePendingOrder(key)
+
+		case status == OrderStatusPartiallyFilled:
+			log.Printf("  ⏳ 限价单 %s 部分成交 %.4f/%.4f（已挂 %d/%d 个周期）",
+				order.OrderID, result.FilledQuantity, order.Quantity, age, maxCycles)
+			if at.protectNewFills(order, result.FilledQuantity) {
+				at.pendingOrdersMutex.Lock()
+				at.savePendingOrdersLocked()
+				at.pendingOrdersMutex.Unlock()
+			}
+
+		default:
+			log.Printf("  ⏳ 限价单 %s 等待成交（%s，已挂 %d/%d 个周期）", order.OrderID, status, age, maxCycles)
+		}
+	}
+}
+
+// cancelPendingOrders 撤销所有未成交的限价开仓单（风控熔断时调用），撤单前已成交的部分补设止盈止损，返回撤销的挂单数
+func (at *AutoTrader) cancelPendingOrders() int {
+	at.pendingOrdersMutex.Lock()
+	orders := make(map[string]*pendingOrder, len(at.pendingOrders))
+	for key, order := range at.pendingOrders {
+		orders[key] = order
+	}
+	at.pendingOrdersMutex.Unlock()
+
+	canceled := 0
+	for key, order := range orders {
+		if err := at.trader.CancelOrder(order.Symbol, order.OrderID); err != nil {
+			log.Printf("  ⚠ 撤销限价单 %s 失败: %v", order.OrderID, err)
+		} else {
+			canceled++
+		}
+		if raw, err := at.trader.GetOrder(order.Symbol, order.OrderID); err != nil {
+			log.Printf("  ⚠ 查询限价单 %s (%s) 失败: %v", order.OrderID, order.Symbol, err)
+		} else {
+			at.protectNewFills(order, OrderResultFromMap(raw).FilledQuantity)
+		}
+		at.removePendingOrder(key)
+	}
+	return canceled
+}
+
+func (at *AutoTrader) removePendingOrder(key string) {
+	at.pendingOrdersMutex.Lock()
+	delete(at.pendingOrders, key)
+	at.savePendingOrdersLocked()
+	at.pendingOrdersMutex.Unlock()
+}
+
+// loadPendingOrders 从数据库恢复未成交的限价单，下一个决策周期继续查询成交状态
+func (at *AutoTrader) loadPendingOrders() {
+	if at.pendingOrderStore == nil {
+		return
+	}
+	state, err := at.pendingOrderStore.GetPendingOrderState(at.id)
+	if err != nil {
+		log.Printf("⚠️ [%s] 读取限价挂单状态失败: %v", at.name, err)
+		return
+	}
+	if state == "" {
+		return
+	}
+
+	var orders []*pendingOrder
+	if err := json.Unmarshal([]byte(state), &orders); err != nil {
+		log.Printf("⚠️ [%s] 解析限价挂单状态失败: %v", at.name, err)
+		return
+	}
+
+	at.pendingOrdersMutex.Lock()
+	for _, order := range orders {
+		order.PlacedCycle = at.callCount
+		at.pendingOrders[pendingOrderKey(order.Symbol, order.Side)] = order
+	}
+	at.pendingOrdersMutex.Unlock()
+	log.Printf("🔁 [%s] 已恢复 %d 个未成交限价单", at.name, len(orders))
+}
+
+// savePendingOrdersLocked 持久化未成交的限价单（调用方需持有 pendingOrdersMutex）
+func (at *AutoTrader) savePendingOrdersLocked() {
+	if at.pendingOrderStore == nil {
+		return
+	}
+	orders := make([]*pendingOrder, 0, len(at.pendingOrders))
+	for _, order := range at.pendingOrders {
+		orders = append(orders, order)
+	}
+	data, err := json.Marshal(orders)
+	if err != nil {
+		log.Printf("⚠️ [%s] 序列化限价挂单状态失败: %v", at.name, err)
+		return
+	}
+	if err := at.pendingOrderStore.SavePendingOrderState(at.id, at.userID, string(data)); err != nil {
+		log.Printf("⚠️ [%s] 保存限价挂单状态失败: %v", at.name, err)
+	}
+}
+
+// protectNewFills 为累计成交数量中尚未设置止盈止损的部分设置保护，返回是否有新成交
+func (at *AutoTrader) protectNewFills(order *pendingOrder, totalFilled float64) bool {
+	newly := totalFilled - order.ProtectedQuantity
+	if newly <= 0 {
+		return false
+	}
+	at.protectFilledOrder(order, newly)
+	return true
+}
+
+// protectFilledOrder 限价单成交后按决策中的价格设置止盈止损，quantity 为本次新成交的数量。
+// 部分成交时后续每次成交都撤销已有止盈止损，按累计成交数量重新设置，保证先前成交的部分仍受保护
+func (at *AutoTrader) protectFilledOrder(order *pendingOrder, quantity float64) {
+	positionSide := strings.ToUpper(order.Side)
+	firstFill := order.ProtectedQuantity == 0
+	order.ProtectedQuantity += quantity
+
+	if firstFill {
+		at.markPositionOpened(pendingOrderKey(order.Symbol, order.Side))
+		if err := at.trader.SetStopLoss(order.Symbol, positionSide, quantity, order.StopLoss); err != nil {
+			log.Printf("  ⚠ 设置止损失败: %v", err)
+		}
+		if !at.armTakeProfitLadder(order.Symbol, order.Side, order.LimitPrice, quantity, order.StopLoss, order.TakeProfitLevels, order.BreakevenAfterTP1) {
+			if err := at.trader.SetTakeProfit(order.Symbol, positionSide, quantity, order.TakeProfit); err != nil {
+				log.Printf("  ⚠ 设置止盈失败: %v", err)
+			}
+		}
+	} else {
+		at.reprotectFilledOrder(order, quantity)
+	}
+
+	tgMessage := fmt.Sprintf("📝 **限价单成交**\n"+
+		"📋 币种: `%s`\n"+
+		"📊 方向: `%s`\n"+
+		"💰 成交数量: `%.4f`\n"+
+		"💵 限价: `%.4f`\n"+
+		"🛑 止损: `%.4f`\n"+
+		"🎯 止盈: `%.4f`\n"+
+		"📝 订单ID: `%s`\n"+
+		"⏰ 时间: `%s`",
+		order.Symbol,
+		positionSide,
+		quantity,
+		order.LimitPrice,
+		order.StopLoss,
+		order.TakeProfit,
+		order.OrderID,
+		time.Now().Format("2006-01-02 15:04:05"))
+	logger.SendTelegramMessage(tgMessage)
+}
+
+// reprotectFilledOrder 限价单再次部分成交：撤销已有止盈止损，按累计成交数量 ProtectedQuantity 重新设置。
+// 已建立分批止盈时把新成交的 quantity 按档位累加后，为未成交的档位重新挂单
+func (at *AutoTrader) reprotectFilledOrder(order *pendingOrder, quantity float64) {
+	if remaining, stopLoss, pending, ok := at.extendTakeProfitLadder(order.Symbol, order.Side, quantity, order.TakeProfitLevels); ok {
+		at.rearmTakeProfitLadder(order.Symbol, order.Side, remaining, stopLoss, pending)
+		return
+	}
+
+	positionSide := strings.ToUpper(order.Side)
+	if err := at.trader.CancelStopOrders(order.Symbol); err != nil {
+		log.Printf("  ⚠ 取消旧止盈止损单失败: %v", err)
+	}
+	if err := at.trader.SetStopLoss(order.Symbol, positionSide, order.ProtectedQuantity, order.StopLoss); err != nil {
+		log.Printf("  ⚠ 设置止损失败: %v", err)
+	}
+	if err := at.trader.SetTakeProfit(order.Symbol, positionSide, order.ProtectedQuantity, order.TakeProfit); err != nil {
+		log.Printf("  ⚠ 设置止盈失败: %v", err)
+	}
+}
+
+// filledQuantity 已成交数量，交易所未返回时使用下单数量
+func filledQuantity(result OrderResult, fallback float64) float64 {
+	if result.FilledQuantity > 0 {
+		return result.FilledQuantity
+	}
+	return fallback
+}
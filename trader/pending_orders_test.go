@@ -0,0 +1,198 @@
+package trader
+
+import (
+	"nofx/decision"
+	"nofx/logger"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newPendingOrderTestTrader(mock *MockTrader, maxCycles int) *AutoTrader {
+	return &AutoTrader{
+		trader:                mock,
+		config:                AutoTraderConfig{PendingOrderMaxCycles: maxCycles},
+		positionFirstSeenTime: make(map[string]int64),
+		pendingOrders:         make(map[string]*pendingOrder),
+		callCount:             1,
+	}
+}
+
+func limitDecision(symbol string) *decision.Decision {
+	return &decision.Decision{
+		Symbol:     symbol,
+		Action:     "open_long",
+		Leverage:   5,
+		StopLoss:   90,
+		TakeProfit: 130,
+		OrderType:  decision.OrderTypeLimit,
+		LimitPrice: 100,
+	}
+}
+
+func TestPlaceLimitOrder_TracksAndRejectsDuplicate(t *testing.T) {
+	mock := &MockTrader{}
+	at := newPendingOrderTestTrader(mock, 3)
+
+	record := &logger.DecisionAction{}
+	require.NoError(t, at.placeLimitOrder(limitDecision("BTCUSDT"), "long", 1, record))
+	assert.Len(t, at.pendingOrders, 1)
+	assert.Equal(t, 100.0, record.Price)
+	assert.Empty(t, mock.stopLosses, "未成交前不应设置止损")
+
+	err := at.placeLimitOrder(limitDecision("BTCUSDT"), "long", 1, &logger.DecisionAction{})
+	assert.Error(t, err, "同币种同方向已有挂单时应拒绝")
+}
+
+func TestProcessPendingOrders_FilledSetsProtection(t *testing.T) {
+	mock := &MockTrader{}
+	at := newPendingOrderTestTrader(mock, 3)
+	require.NoError(t, at.placeLimitOrder(limitDecision("ETHUSDT"), "long", 2, &logger.DecisionAction{}))
+
+	pending := at.pendingOrders[pendingOrderKey("ETHUSDT", "long")]
+	require.NotNil(t, pending)
+	mock.orders[pending.OrderID]["status"] = "FILLED"
+	mock.orders[pending.OrderID]["executedQty"] = 2.0
+
+	at.callCount++
+	at.processPendingOrders()
+
+	assert.Empty(t, at.pendingOrders)
+	assert.Equal(t, 90.0, mock.stopLosses["ETHUSDT"])
+	assert.Contains(t, at.positionFirstSeenTime, "ETHUSDT_long")
+}
+
+func TestProcessPendingOrders_CancelsAfterMaxCycles(t *testing.T) {
+	mock := &MockTrader{}
+	at := newPendingOrderTestTrader(mock, 2)
+	require.NoError(t, at.placeLimitOrder(limitDecision("SOLUSDT"), "long", 3, &logger.DecisionAction{}))
+	orderID := at.pendingOrders[pendingOrderKey("SOLUSDT", "long")].OrderID
+
+	at.callCount++
+	at.processPendingOrders()
+	assert.Len(t, at.pendingOrders, 1, "未到挂单周期上限不应撤单")
+	assert.Empty(t, mock.canceledOrders)
+
+	// 撤单前已部分成交，需要为已成交部分设置止损
+	mock.orders[orderID]["status"] = OrderStatusPartiallyFilled
+	mock.orders[orderID]["executedQty"] = 1.0
+
+	at.callCount++
+	at.processPendingOrders()
+	assert.Empty(t, at.pendingOrders)
+	assert.Equal(t, []string{orderID}, mock.canceledOrders)
+	assert.Equal(t, 90.0, mock.stopLosses["SOLUSDT"])
+}
+
+func TestProcessPendingOrders_ProtectsEachPartialFill(t *testing.T) {
+	mock := &MockTrader{}
+	at := newPendingOrderTestTrader(mock, 5)
+	require.NoError(t, at.placeLimitOrder(limitDecision("BNBUSDT"), "long", 3, &logger.DecisionAction{}))
+	orderID := at.pendingOrders[pendingOrderKey("BNBUSDT", "long")].OrderID
+
+	mock.orders[orderID]["status"] = OrderStatusPartiallyFilled
+	mock.orders[orderID]["executedQty"] = 1.0
+	at.callCount++
+	at.processPendingOrders()
+	assert.Len(t, at.pendingOrders, 1, "部分成交时继续跟踪剩余数量")
+	assert.Equal(t, []float64{1}, mock.stopLossQuantities, "首次部分成交保护已成交数量")
+
+	// 成交数量未变化时不重复设置
+	at.callCount++
+	at.processPendingOrders()
+	assert.Equal(t, []float64{1}, mock.stopLossQuantities)
+
+	mock.orders[orderID]["executedQty"] = 2.5
+	at.callCount++
+	at.processPendingOrders()
+	assert.Equal(t, []float64{1, 2.5}, mock.stopLossQuantities, "再次部分成交按累计成交数量重新设置止损")
+
+	mock.orders[orderID]["status"] = OrderStatusFilled
+	mock.orders[orderID]["executedQty"] = 3.0
+	at.callCount++
+	at.processPendingOrders()
+	assert.Empty(t, at.pendingOrders)
+	assert.InDeltaSlice(t, []float64{1, 2.5, 3}, mock.stopLossQuantities, 1e-9, "完全成交时止损覆盖全部成交数量")
+}
+
+func TestProtectNewFills_StopCoversAllPartialFills(t *testing.T) {
+	futures := &FuturesTrader{slTpConditions: make(map[string]*StopLossTakeProfitCondition)}
+	at := &AutoTrader{
+		trader:                futures,
+		positionFirstSeenTime: make(map[string]int64),
+		pendingOrders:         make(map[string]*pendingOrder),
+	}
+	order := &pendingOrder{Symbol: "BNBUSDT", Side: "long", Quantity: 3, LimitPrice: 100, StopLoss: 90, TakeProfit: 130}
+
+	require.True(t, at.protectNewFills(order, 1))
+	require.True(t, at.protectNewFills(order, 2.5))
+
+	cond := futures.slTpConditions["BNBUSDT_LONG"]
+	require.NotNil(t, cond)
+	assert.Equal(t, 2.5, cond.Quantity, "止损应覆盖两次部分成交的累计数量")
+	assert.Equal(t, 90.0, cond.StopLossPrice)
+	assert.Equal(t, 130.0, cond.TakeProfitPrice, "止盈按全部成交数量设置，而不是作为分批止盈档位追加")
+	assert.Empty(t, cond.TakeProfitLevels)
+}
+
+// memoryPendingOrderStore 内存版限价挂单状态存储
+type memoryPendingOrderStore struct {
+	states map[string]string
+}
+
+func (s *memoryPendingOrderStore) GetPendingOrderState(traderID string) (string, error) {
+	return s.states[traderID], nil
+}
+
+func (s *memoryPendingOrderStore) SavePendingOrderState(traderID, userID, state string) error {
+	s.states[traderID] = state
+	return nil
+}
+
+func TestPendingOrders_RestoredAfterRestart(t *testing.T) {
+	mock := &MockTrader{}
+	store := &memoryPendingOrderStore{states: map[string]string{}}
+	at := newPendingOrderTestTrader(mock, 3)
+	at.id, at.pendingOrderStore = "pending_test", store
+	require.NoError(t, at.placeLimitOrder(limitDecision("XRPUSDT"), "long", 10, &logger.DecisionAction{}))
+	orderID := at.pendingOrders[pendingOrderKey("XRPUSDT", "long")].OrderID
+
+	mock.orders[orderID]["status"] = OrderStatusPartiallyFilled
+	mock.orders[orderID]["executedQty"] = 4.0
+	at.callCount++
+	at.processPendingOrders()
+
+	// 模拟重启：新的 AutoTrader 从存储恢复挂单，已保护的数量不再重复保护
+	restarted := newPendingOrderTestTrader(mock, 3)
+	restarted.id, restarted.pendingOrderStore = "pending_test", store
+	restarted.loadPendingOrders()
+	restored, ok := restarted.pendingOrders[pendingOrderKey("XRPUSDT", "long")]
+	require.True(t, ok)
+	assert.Equal(t, orderID, restored.OrderID)
+	assert.Equal(t, 90.0, restored.StopLoss)
+	assert.Equal(t, 4.0, restored.ProtectedQuantity)
+
+	mock.orders[orderID]["status"] = OrderStatusFilled
+	mock.orders[orderID]["executedQty"] = 10.0
+	restarted.processPendingOrders()
+	assert.Equal(t, []float64{4, 10}, mock.stopLossQuantities, "重启后按累计成交数量重新设置止损")
+	assert.Empty(t, restarted.pendingOrders)
+	assert.Equal(t, "[]", store.states["pending_test"], "成交后应从存储中移除")
+}
+
+func TestNormalizeOrderStatus(t *testing.T) {
+	tests := map[string]string{
+		"NEW":              OrderStatusNew,
+		"PartiallyFilled":  OrderStatusPartiallyFilled,
+		"Filled":           OrderStatusFilled,
+		"Cancelled":        OrderStatusCanceled,
+		"open":             OrderStatusNew,
+		"badAloPxRejected": OrderStatusRejected,
+		"marginCanceled":   OrderStatusCanceled,
+		"EXPIRED":          OrderStatusExpired,
+	}
+	for raw, want := range tests {
+		assert.Equal(t, want, NormalizeOrderStatus(raw), raw)
+	}
+}
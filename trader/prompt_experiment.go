@@ -0,0 +1,116 @@
+package trader
+
+import (
+	"log"
+	"nofx/decision"
+	"nofx/logger"
+)
+
+// PromptRevisionStore 提示词版本持久化（由 config.Database 实现），用于追踪每个版本产生的交易
+type PromptRevisionStore interface {
+	SavePromptRevision(hash, kind, name, userID, content string) error
+}
+
+// promptSettings 一组提示词配置（A/B 实验中的一个分组）
+type promptSettings struct {
+	arm          string
+	template     string
+	customPrompt string
+	overrideBase bool
+}
+
+// promptSettingsFor 返回指定分组的提示词配置：A 组（及未开启实验时）使用交易员当前配置，B 组使用实验配置
+func (at *AutoTrader) promptSettingsFor(arm string) promptSettings {
+	settings := promptSettings{
+		arm:          arm,
+		template:     at.systemPromptTemplate,
+		customPrompt: at.customPrompt,
+		overrideBase: at.overrideBasePrompt,
+	}
+	if exp := at.config.PromptExperiment; exp != nil && arm == decision.PromptArmB {
+		if exp.Template != "" {
+			settings.template = exp.Template
+		}
+		settings.customPrompt = exp.CustomPrompt
+		settings.overrideBase = exp.OverrideBasePrompt
+	}
+	return settings
+}
+
+// requestDecision 请求AI决策。开启提示词 A/B 实验时按周期交替分组，或按币种拆分上下文后两组分别决策。
+func (at *AutoTrader) requestDecision(ctx *decision.Context, record *logger.DecisionRecord) (*decision.FullDecision, error) {
+	exp := at.config.PromptExperiment
+	if exp == nil {
+		settings := at.promptSettingsFor("")
+		at.recordPromptVersion(record, settings)
+		return at.requestDecisionWithPrompt(ctx, record, settings)
+	}
+
+	if exp.Split == decision.PromptSplitCycle {
+		settings := at.promptSettingsFor(exp.ArmForCycle(at.callCount))
+		at.recordPromptVersion(record, settings)
+		log.Printf("🧪 提示词 A/B 实验: 本周期使用 %s 组 [模板: %s]", settings.arm, settings.template)
+		return at.requestDecisionWithPrompt(ctx, record, settings)
+	}
+
+	contexts := exp.SplitContextBySymbol(ctx)
+	results := make([]decision.MemberResult, 0, len(contexts))
+	for _, arm := range []string{decision.PromptArmA, decision.PromptArmB} {
+		settings := at.promptSettingsFor(arm)
+		at.recordPromptVersion(record, settings)
+
+		armCtx := contexts[arm]
+		if len(armCtx.Positions) == 0 && len(armCtx.CandidateCoins) == 0 {
+			results = append(results, decision.MemberResult{Name: arm, Decision: &decision.FullDecision{CoTTrace: "本组没有需要分析的币种"}})
+			continue
+		}
+		log.Printf("🧪 提示词 A/B 实验: %s 组分析 %d 个持仓、%d 个候选币 [模板: %s]",
+			arm, len(armCtx.Positions), len(armCtx.CandidateCoins), settings.template)
+		full, err := at.requestDecisionWithPrompt(armCtx, record, settings)
+		results = append(results, decision.MemberResult{Name: arm, Decision: full, Err: err})
+	}
+	return decision.MergeArmDecisions(results)
+}
+
+// recordPromptVersion 把本周期使用的提示词版本写入决策记录，并保存首次出现的模板和自定义提示词原文
+func (at *AutoTrader) recordPromptVersion(record *logger.DecisionRecord, settings promptSettings) {
+	version := decision.ResolvePromptVersion(settings.arm, settings.template, settings.customPrompt, settings.overrideBase)
+	record.PromptVersions = append(record.PromptVersions, logger.PromptVersion(version))
+
+	if version.TemplateHash != "" {
+		if tmpl, err := decision.GetPromptTemplate(version.Template); err == nil && tmpl.Hash == version.TemplateHash {
+			at.savePromptRevision(tmpl.Hash, "template", tmpl.Name, "", tmpl.Content)
+		}
+	}
+	if version.CustomHash != "" {
+		at.savePromptRevision(version.CustomHash, "custom", at.id, at.userID, settings.customPrompt)
+	}
+}
+
+// savePromptRevision 保存提示词版本，同一内容在进程内只写一次数据库
+func (at *AutoTrader) savePromptRevision(hash, kind, name, userID, content string) {
+	if at.promptRevisionStore == nil || at.savedPromptRevisions[hash] {
+		return
+	}
+	if err := at.promptRevisionStore.SavePromptRevision(hash, kind, name, userID, content); err != nil {
+		log.Printf("⚠️ [%s] 保存提示词版本失败: %v", at.name, err)
+		return
+	}
+	at.savedPromptRevisions[hash] = true
+}
+
+// promptHashForSymbol 返回产生该币种决策的提示词版本
+func (at *AutoTrader) promptHashForSymbol(record *logger.DecisionRecord, symbol string) string {
+	if len(record.PromptVersions) == 1 {
+		return record.PromptVersions[0].Hash
+	}
+	if exp := at.config.PromptExperiment; exp != nil {
+		arm := exp.ArmForSymbol(symbol)
+		for _, version := range record.PromptVersions {
+			if version.Arm == arm {
+				return version.Hash
+			}
+		}
+	}
+	return ""
+}
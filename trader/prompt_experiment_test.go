@@ -0,0 +1,72 @@
+package trader
+
+import (
+	"nofx/decision"
+	"nofx/logger"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryPromptRevisionStore 内存版提示词版本存储
+type memoryPromptRevisionStore struct {
+	saves    int
+	contents map[string]string
+}
+
+func (s *memoryPromptRevisionStore) SavePromptRevision(hash, kind, name, userID, content string) error {
+	s.saves++
+	s.contents[kind+"/"+hash] = content
+	return nil
+}
+
+func newPromptExperimentTestTrader(exp *decision.PromptExperiment, store PromptRevisionStore) *AutoTrader {
+	return &AutoTrader{
+		id:                   "prompt_test",
+		userID:               "user1",
+		config:               AutoTraderConfig{PromptExperiment: exp},
+		customPrompt:         "A 组策略",
+		systemPromptTemplate: "default",
+		promptRevisionStore:  store,
+		savedPromptRevisions: make(map[string]bool),
+	}
+}
+
+func TestPromptSettingsFor(t *testing.T) {
+	at := newPromptExperimentTestTrader(&decision.PromptExperiment{Split: decision.PromptSplitCycle, CustomPrompt: "B 组策略"}, nil)
+
+	a := at.promptSettingsFor(decision.PromptArmA)
+	assert.Equal(t, "A 组策略", a.customPrompt)
+	assert.Equal(t, "default", a.template)
+
+	b := at.promptSettingsFor(decision.PromptArmB)
+	assert.Equal(t, "B 组策略", b.customPrompt)
+	assert.Equal(t, "default", b.template, "B 组未设置模板时沿用 A 组模板")
+}
+
+func TestRecordPromptVersion_SavesRevisionsOnce(t *testing.T) {
+	store := &memoryPromptRevisionStore{contents: make(map[string]string)}
+	exp := &decision.PromptExperiment{Split: decision.PromptSplitSymbol, CustomPrompt: "B 组策略", OverrideBasePrompt: true}
+	at := newPromptExperimentTestTrader(exp, store)
+
+	for i := 0; i < 2; i++ {
+		record := &logger.DecisionRecord{}
+		at.recordPromptVersion(record, at.promptSettingsFor(decision.PromptArmA))
+		at.recordPromptVersion(record, at.promptSettingsFor(decision.PromptArmB))
+		require.Len(t, record.PromptVersions, 2)
+		assert.NotEqual(t, record.PromptVersions[0].Hash, record.PromptVersions[1].Hash)
+
+		for _, symbol := range []string{"BTCUSDT", "ETHUSDT", "SOLUSDT"} {
+			want := record.PromptVersions[0].Hash
+			if exp.ArmForSymbol(symbol) == decision.PromptArmB {
+				want = record.PromptVersions[1].Hash
+			}
+			assert.Equal(t, want, at.promptHashForSymbol(record, symbol), "按币种分组时决策应标记所在组的版本")
+		}
+	}
+
+	assert.Equal(t, "B 组策略", store.contents["custom/"+decision.HashPrompt("B 组策略")])
+	assert.Equal(t, "A 组策略", store.contents["custom/"+decision.HashPrompt("A 组策略")])
+	assert.Equal(t, len(store.contents), store.saves, "相同版本只保存一次")
+}
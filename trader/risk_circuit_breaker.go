@@ -0,0 +1,283 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"nofx/logger"
+	"strings"
+	"time"
+)
+
+// riskCheckInterval 风控熔断检查间隔
+const riskCheckInterval = 30 * time.Second
+
+// defaultRiskPause 未配置 StopTradingTime 时熔断后的暂停时长
+const defaultRiskPause = 60 * time.Minute
+
+// 风控熔断指标
+const (
+	riskMetricDailyLoss = "daily_loss"
+	riskMetricDrawdown  = "max_drawdown"
+)
+
+// riskBreach 触发的风控限制
+type riskBreach struct {
+	Metric   string  // daily_loss / max_drawdown
+	ValuePct float64 // 当前日亏损/回撤百分比
+	LimitPct float64 // 配置的上限
+	Equity   float64 // 触发时的账户净值
+	Baseline float64 // 计算基准：当日起始净值或净值高水位
+}
+
+func (b *riskBreach) String() string {
+	if b.Metric == riskMetricDailyLoss {
+		return fmt.Sprintf("日亏损 %.2f%% 达到上限 %.2f%%（当日起始净值 %.2f，当前 %.2f）", b.ValuePct, b.LimitPct, b.Baseline, b.Equity)
+	}
+	return fmt.Sprintf("回撤 %.2f%% 达到上限 %.2f%%（净值高水位 %.2f，当前 %.2f）", b.ValuePct, b.LimitPct, b.Baseline, b.Equity)
+}
+
+// loadRiskLocation 解析日盈亏重置使用的时区，空值或无效时使用 UTC
+func loadRiskLocation(name string) *time.Location {
+	if name == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		log.Printf("⚠️ 无效的日盈亏重置时区 %q，使用 UTC: %v", name, err)
+		return time.UTC
+	}
+	return loc
+}
+
+// tradingDayStart 返回 now 所在交易日的起点（loc 时区的零点）
+func tradingDayStart(now time.Time, loc *time.Location) time.Time {
+	if loc == nil {
+		loc = time.UTC
+	}
+	local := now.In(loc)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+}
+
+// riskPausedUntil 返回风控暂停截止时间（零值表示未暂停）
+func (at *AutoTrader) riskPausedUntil() time.Time {
+	at.riskMutex.Lock()
+	defer at.riskMutex.Unlock()
+	return at.stopUntil
+}
+
+// updateRiskMetrics 用最新净值（已实现+未实现盈亏）更新日盈亏和净值高水位，返回触发的风控限制（未触发返回 nil）。
+// 跨过配置时区的零点时以当前净值作为新交易日的基准
+func (at *AutoTrader) updateRiskMetrics(equity float64, now time.Time) *riskBreach {
+	at.riskMutex.Lock()
+	defer at.riskMutex.Unlock()
+
+	dayStart := tradingDayStart(now, at.riskLocation)
+	if at.dayStartEquity <= 0 || dayStart.After(at.lastResetTime) {
+		if at.dayStartEquity > 0 {
+			log.Printf("📅 日盈亏已重置（交易日 %s，起始净值 %.2f）", dayStart.Format("2006-01-02 MST"), equity)
+		}
+		at.dayStartEquity = equity
+		at.lastResetTime = dayStart
+	}
+	if equity > at.equityHighWaterMark {
+		at.equityHighWaterMark = equity
+	}
+	at.dailyPnL = equity - at.dayStartEquity
+
+	if at.config.MaxDailyLoss > 0 && at.dayStartEquity > 0 {
+		lossPct := -at.dailyPnL / at.dayStartEquity * 100
+		if lossPct >= at.config.MaxDailyLoss {
+			return &riskBreach{Metric: riskMetricDailyLoss, ValuePct: lossPct, LimitPct: at.config.MaxDailyLoss, Equity: equity, Baseline: at.dayStartEquity}
+		}
+	}
+	if at.config.MaxDrawdown > 0 && at.equityHighWaterMark > 0 {
+		drawdownPct := (at.equityHighWaterMark - equity) / at.equityHighWaterMark * 100
+		if drawdownPct >= at.config.MaxDrawdown {
+			return &riskBreach{Metric: riskMetricDrawdown, ValuePct: drawdownPct, LimitPct: at.config.MaxDrawdown, Equity: equity, Baseline: at.equityHighWaterMark}
+		}
+	}
+	return nil
+}
+
+// startRiskMonitor 启动风控熔断监控，在决策周期之间也能及时发现超限。日亏损和最大回撤均未限制时不启动
+func (at *AutoTrader) startRiskMonitor() {
+	if at.config.MaxDailyLoss <= 0 && at.config.MaxDrawdown <= 0 {
+		return
+	}
+
+	at.monitorWg.Add(1)
+	go func() {
+		defer at.monitorWg.Done()
+
+		ticker := time.NewTicker(riskCheckInterval)
+		defer ticker.Stop()
+
+		log.Printf("🛡️ 启动风控熔断监控（日亏损上限 %.2f%%，最大回撤 %.2f%%）", at.config.MaxDailyLoss, at.config.MaxDrawdown)
+
+		for {
+			select {
+			case <-ticker.C:
+				at.checkRiskLimits()
+			case <-at.stopMonitorCh:
+				log.Println("⏹ 停止风控熔断监控")
+				return
+			}
+		}
+	}()
+}
+
+// checkRiskLimits 获取账户净值并检查日亏损和最大回撤，超限时触发熔断。返回是否处于风控暂停中
+func (at *AutoTrader) checkRiskLimits() bool {
+	now := time.Now()
+	balance, err := FetchBalance(at.trader)
+	if err != nil {
+		log.Printf("⚠️ 风控检查：获取余额失败: %v", err)
+		return now.Before(at.riskPausedUntil())
+	}
+
+	breach := at.updateRiskMetrics(balance.TotalEquity(), now)
+	if breach == nil {
+		return now.Before(at.riskPausedUntil())
+	}
+
+	// 监控协程和决策周期可能同时发现超限，只有成功设置暂停的一方执行平仓和通知
+	stopUntil, tripped := at.armCircuitBreaker(breach, now)
+	if tripped {
+		at.tripCircuitBreaker(breach, stopUntil, now)
+	}
+	return true
+}
+
+// armCircuitBreaker 在同一把锁内检查并设置风控暂停：已处于暂停中时返回 false。
+// 日亏损超限时暂停到下一个交易日（且不少于 StopTradingTime），当日起始净值保持不变；
+// 回撤超限时暂停 StopTradingTime，净值高水位保持不变，暂停结束后回撤仍超限会再次熔断
+func (at *AutoTrader) armCircuitBreaker(breach *riskBreach, now time.Time) (time.Time, bool) {
+	at.riskMutex.Lock()
+	defer at.riskMutex.Unlock()
+
+	if now.Before(at.stopUntil) {
+		return at.stopUntil, false
+	}
+
+	pause := at.config.StopTradingTime
+	if pause <= 0 {
+		pause = defaultRiskPause
+	}
+	stopUntil := now.Add(pause)
+	if breach.Metric == riskMetricDailyLoss {
+		if nextDay := tradingDayStart(now, at.riskLocation).AddDate(0, 0, 1); nextDay.After(stopUntil) {
+			stopUntil = nextDay
+		}
+	}
+	at.stopUntil = stopUntil
+	return stopUntil, true
+}
+
+// tripCircuitBreaker 执行风控熔断的后续动作：按配置平掉所有持仓（不平仓时仍撤销限价挂单），记录决策日志并发送通知。
+// 调用前须已通过 armCircuitBreaker 设置暂停
+func (at *AutoTrader) tripCircuitBreaker(breach *riskBreach, stopUntil, now time.Time) {
+	log.Printf("🚨 [%s] 风控熔断: %s，暂停交易至 %s", at.name, breach, stopUntil.Format("2006-01-02 15:04:05"))
+
+	record := &logger.DecisionRecord{
+		ExecutionLog: []string{fmt.Sprintf("风控熔断: %s", breach)},
+		Success:      false,
+		ErrorMessage: fmt.Sprintf("风控熔断: %s，暂停交易至 %s", breach, stopUntil.Format(time.RFC3339)),
+		RiskEvent: &logger.RiskEvent{
+			Metric:         breach.Metric,
+			ValuePct:       breach.ValuePct,
+			LimitPct:       breach.LimitPct,
+			Equity:         breach.Equity,
+			Baseline:       breach.Baseline,
+			ClosePositions: at.config.RiskClosePositions,
+			PausedUntil:    stopUntil,
+		},
+	}
+
+	closed := 0
+	if at.config.RiskClosePositions {
+		closed = at.flattenAllPositions(record)
+	} else if canceled := at.cancelPendingOrders(); canceled > 0 {
+		// 不平仓时也撤销限价开仓单，避免暂停期间成交建立新仓位
+		record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("✓ 撤销 %d 个限价挂单", canceled))
+	}
+
+	if at.decisionLogger != nil {
+		if err := at.decisionLogger.LogDecision(record); err != nil {
+			log.Printf("⚠️ 记录风控熔断事件失败: %v", err)
+		}
+	}
+
+	tgMessage := fmt.Sprintf("🚨 **风控熔断**\n"+
+		"🤖 交易员: `%s`\n"+
+		"📉 原因: %s\n"+
+		"⏸ 暂停至: `%s`\n"+
+		"⏰ 时间: `%s`",
+		at.name,
+		breach,
+		stopUntil.Format("2006-01-02 15:04:05"),
+		now.Format("2006-01-02 15:04:05"))
+	if at.config.RiskClosePositions {
+		tgMessage += fmt.Sprintf("\n🧹 已平仓: `%d`", closed)
+	}
+	logger.SendTelegramMessage(tgMessage)
+}
+
+// flattenAllPositions 撤销所有挂单并市价平掉全部持仓，平仓动作写入 record，返回成功平仓数
+func (at *AutoTrader) flattenAllPositions(record *logger.DecisionRecord) int {
+	// 未成交的限价开仓单一并撤销，避免熔断期间成交
+	at.pendingOrdersMutex.Lock()
+	pending := make([]*pendingOrder, 0, len(at.pendingOrders))
+	for _, order := range at.pendingOrders {
+		pending = append(pending, order)
+	}
+	at.pendingOrdersMutex.Unlock()
+	for _, order := range pending {
+		if err := at.trader.CancelOrder(order.Symbol, order.OrderID); err != nil {
+			log.Printf("  ⚠ 撤销限价单 %s 失败: %v", order.OrderID, err)
+		}
+		at.removePendingOrder(pendingOrderKey(order.Symbol, order.Side))
+	}
+
+	positions, err := FetchPositions(at.trader)
+	if err != nil {
+		log.Printf("❌ 风控熔断：获取持仓失败，无法平仓: %v", err)
+		record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("获取持仓失败: %v", err))
+		return 0
+	}
+
+	closed := 0
+	for _, pos := range positions {
+		action := logger.DecisionAction{
+			Action:    "close_" + pos.Side,
+			Symbol:    pos.Symbol,
+			Quantity:  pos.Quantity,
+			Leverage:  pos.Leverage,
+			Price:     pos.MarkPrice,
+			Timestamp: time.Now(),
+		}
+
+		if err := at.trader.CancelAllOrders(pos.Symbol); err != nil {
+			log.Printf("  ⚠ 取消 %s 挂单失败: %v", pos.Symbol, err)
+		}
+		if pos.Side == "short" {
+			_, err = at.trader.CloseShort(pos.Symbol, 0)
+		} else {
+			_, err = at.trader.CloseLong(pos.Symbol, 0)
+		}
+
+		if err != nil {
+			action.Error = err.Error()
+			log.Printf("  ❌ 风控平仓失败 %s %s: %v", pos.Symbol, pos.Side, err)
+			record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("❌ 平仓 %s %s 失败: %v", pos.Symbol, strings.ToUpper(pos.Side), err))
+		} else {
+			action.Success = true
+			closed++
+			at.lastCloseTimeMutex.Lock()
+			at.lastCloseTime[pos.Symbol] = time.Now()
+			at.lastCloseTimeMutex.Unlock()
+			record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("✓ 平仓 %s %s", pos.Symbol, strings.ToUpper(pos.Side)))
+		}
+		record.Decisions = append(record.Decisions, action)
+	}
+	return closed
+}
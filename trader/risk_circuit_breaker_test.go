@@ -0,0 +1,210 @@
+package trader
+
+import (
+	"nofx/logger"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRiskTestTrader(t *testing.T, trader Trader, config AutoTraderConfig) *AutoTrader {
+	loc := loadRiskLocation(config.DailyResetTimezone)
+	return &AutoTrader{
+		id:             "risk_test",
+		name:           "risk_test",
+		config:         config,
+		trader:         trader,
+		decisionLogger: logger.NewDecisionLogger(t.TempDir()),
+		riskLocation:   loc,
+		lastResetTime:  tradingDayStart(time.Now(), loc),
+		pendingOrders:  make(map[string]*pendingOrder),
+		lastCloseTime:  make(map[string]time.Time),
+	}
+}
+
+func TestTradingDayStart_UsesTimezone(t *testing.T) {
+	shanghai := loadRiskLocation("Asia/Shanghai")
+	now := time.Date(2024, 1, 1, 17, 30, 0, 0, time.UTC) // 北京时间 1月2日 01:30
+
+	assert.True(t, tradingDayStart(now, shanghai).Equal(time.Date(2024, 1, 1, 16, 0, 0, 0, time.UTC)))
+	assert.True(t, tradingDayStart(now, time.UTC).Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, time.UTC, loadRiskLocation("Not/AZone"), "无效时区回退到 UTC")
+}
+
+func TestUpdateRiskMetrics_DailyResetAtTimezoneBoundary(t *testing.T) {
+	at := newRiskTestTrader(t, nil, AutoTraderConfig{MaxDailyLoss: 10, DailyResetTimezone: "Asia/Shanghai"})
+
+	start := time.Date(2024, 1, 1, 15, 0, 0, 0, time.UTC) // 北京时间 23:00
+	assert.Nil(t, at.updateRiskMetrics(1000, start))
+	assert.Nil(t, at.updateRiskMetrics(950, start.Add(59*time.Minute)))
+	assert.InDelta(t, -50, at.dailyPnL, 1e-9, "未跨过北京时间零点，继续累计")
+
+	// 北京时间 00:00（UTC 16:00）进入新交易日
+	assert.Nil(t, at.updateRiskMetrics(940, start.Add(time.Hour)))
+	assert.Equal(t, 940.0, at.dayStartEquity)
+	assert.Equal(t, 0.0, at.dailyPnL)
+	assert.Equal(t, 1000.0, at.equityHighWaterMark, "高水位不随交易日重置")
+
+	breach := at.updateRiskMetrics(840, start.Add(2*time.Hour))
+	require.NotNil(t, breach)
+	assert.Equal(t, riskMetricDailyLoss, breach.Metric)
+	assert.InDelta(t, 10.64, breach.ValuePct, 0.01)
+}
+
+func TestCircuitBreaker_DailyLossFlattensAndPauses(t *testing.T) {
+	prices := map[string]float64{"BTCUSDT": 100}
+	paper := newTestPaperTrader(t, nil, prices)
+	_, err := paper.OpenLong("BTCUSDT", 5, 2)
+	require.NoError(t, err)
+	require.NoError(t, paper.SetStopLoss("BTCUSDT", "LONG", 5, 60))
+
+	at := newRiskTestTrader(t, paper, AutoTraderConfig{
+		MaxDailyLoss:       10,
+		MaxDrawdown:        50,
+		StopTradingTime:    30 * time.Minute,
+		RiskClosePositions: true,
+	})
+	assert.False(t, at.checkRiskLimits(), "首次检查只建立基准")
+	dayStart := at.dayStartEquity
+
+	// 价格下跌 25%：5 * 25 = 125 USDT，约占净值 12.5%
+	prices["BTCUSDT"] = 75
+	before := time.Now()
+	assert.True(t, at.checkRiskLimits())
+
+	positions, err := paper.GetPositions()
+	require.NoError(t, err)
+	assert.Len(t, positions, 0, "熔断时应平掉所有持仓")
+
+	pausedUntil := at.riskPausedUntil()
+	nextDay := tradingDayStart(before, time.UTC).AddDate(0, 0, 1)
+	if nextDay.Before(before.Add(30 * time.Minute)) {
+		nextDay = before.Add(30 * time.Minute)
+	}
+	assert.WithinDuration(t, nextDay, pausedUntil, 5*time.Second, "日亏损熔断暂停到下一个交易日")
+	assert.Equal(t, dayStart, at.dayStartEquity, "熔断不重置当日起始净值")
+	assert.True(t, at.checkRiskLimits(), "暂停期间保持熔断状态")
+
+	records, err := at.decisionLogger.GetLatestRecords(10)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	event := records[0].RiskEvent
+	require.NotNil(t, event, "熔断事件应写入决策日志")
+	assert.Equal(t, riskMetricDailyLoss, event.Metric)
+	assert.Equal(t, 10.0, event.LimitPct)
+	assert.True(t, event.ClosePositions)
+	require.Len(t, records[0].Decisions, 1)
+	assert.Equal(t, "close_long", records[0].Decisions[0].Action)
+	assert.True(t, records[0].Decisions[0].Success)
+}
+
+func TestCircuitBreaker_DrawdownWithoutFlatten(t *testing.T) {
+	prices := map[string]float64{"ETHUSDT": 100}
+	paper := newTestPaperTrader(t, nil, prices)
+	_, err := paper.OpenShort("ETHUSDT", 4, 5)
+	require.NoError(t, err)
+
+	at := newRiskTestTrader(t, paper, AutoTraderConfig{MaxDrawdown: 5})
+	assert.False(t, at.checkRiskLimits())
+
+	// 先盈利抬高高水位，再回吐超过 5%
+	prices["ETHUSDT"] = 80
+	assert.False(t, at.checkRiskLimits())
+	peak := at.equityHighWaterMark
+	prices["ETHUSDT"] = 94
+	assert.True(t, at.checkRiskLimits())
+
+	positions, err := paper.GetPositions()
+	require.NoError(t, err)
+	assert.Len(t, positions, 1, "未开启平仓时保留持仓")
+	assert.Equal(t, peak, at.equityHighWaterMark, "熔断不重置净值高水位")
+	assert.WithinDuration(t, time.Now().Add(defaultRiskPause), at.riskPausedUntil(), 5*time.Second)
+
+	// 暂停结束后回撤仍超限时再次熔断
+	at.riskMutex.Lock()
+	at.stopUntil = time.Now().Add(-time.Second)
+	at.riskMutex.Unlock()
+	assert.True(t, at.checkRiskLimits(), "高水位保持不变，回撤上限在暂停后继续生效")
+	records, err := at.decisionLogger.GetLatestRecords(10)
+	require.NoError(t, err)
+	assert.Len(t, records, 2)
+}
+
+func TestCircuitBreaker_ConcurrentChecksTripOnce(t *testing.T) {
+	prices := map[string]float64{"BTCUSDT": 100}
+	paper := newTestPaperTrader(t, nil, prices)
+	_, err := paper.OpenLong("BTCUSDT", 5, 2)
+	require.NoError(t, err)
+
+	at := newRiskTestTrader(t, paper, AutoTraderConfig{MaxDailyLoss: 10, RiskClosePositions: true})
+	assert.False(t, at.checkRiskLimits())
+	prices["BTCUSDT"] = 75
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.True(t, at.checkRiskLimits())
+		}()
+	}
+	wg.Wait()
+
+	records, err := at.decisionLogger.GetLatestRecords(10)
+	require.NoError(t, err)
+	assert.Len(t, records, 1, "同时发现超限时只触发一次熔断")
+}
+
+func TestStartRiskMonitor_SkipsWhenUnlimited(t *testing.T) {
+	at := newRiskTestTrader(t, nil, AutoTraderConfig{})
+	at.startRiskMonitor()
+
+	done := make(chan struct{})
+	go func() {
+		at.monitorWg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("日亏损和最大回撤均未限制时不应启动监控")
+	}
+}
+
+func TestCircuitBreaker_CancelsPendingOrdersWithoutFlatten(t *testing.T) {
+	mock := &MockTrader{}
+	at := newRiskTestTrader(t, mock, AutoTraderConfig{MaxDrawdown: 5, PendingOrderMaxCycles: 3})
+	at.positionFirstSeenTime = make(map[string]int64)
+	require.NoError(t, at.placeLimitOrder(limitDecision("BTCUSDT"), "long", 2, &logger.DecisionAction{}))
+	orderID := at.pendingOrders[pendingOrderKey("BTCUSDT", "long")].OrderID
+	mock.orders[orderID]["status"] = OrderStatusPartiallyFilled
+	mock.orders[orderID]["executedQty"] = 1.0
+
+	breach := &riskBreach{Metric: riskMetricDrawdown, ValuePct: 6, LimitPct: 5, Equity: 940, Baseline: 1000}
+	stopUntil, tripped := at.armCircuitBreaker(breach, time.Now())
+	require.True(t, tripped)
+	at.tripCircuitBreaker(breach, stopUntil, time.Now())
+
+	assert.Empty(t, at.pendingOrders, "熔断时撤销限价挂单")
+	assert.Equal(t, []string{orderID}, mock.canceledOrders)
+	assert.Equal(t, []float64{1}, mock.stopLossQuantities, "撤单前已成交的部分补设止损")
+}
+
+func TestRunCycle_ProcessesPendingOrdersWhilePaused(t *testing.T) {
+	mock := &MockTrader{}
+	at := newRiskTestTrader(t, mock, AutoTraderConfig{MaxDrawdown: 50, PendingOrderMaxCycles: 3})
+	at.positionFirstSeenTime = make(map[string]int64)
+	require.NoError(t, at.placeLimitOrder(limitDecision("ETHUSDT"), "long", 2, &logger.DecisionAction{}))
+	orderID := at.pendingOrders[pendingOrderKey("ETHUSDT", "long")].OrderID
+	mock.orders[orderID]["status"] = OrderStatusFilled
+	mock.orders[orderID]["executedQty"] = 2.0
+
+	at.stopUntil = time.Now().Add(time.Hour)
+	require.NoError(t, at.runCycle())
+
+	assert.Empty(t, at.pendingOrders, "暂停期间仍跟踪限价单成交")
+	assert.Equal(t, 90.0, mock.stopLosses["ETHUSDT"], "暂停期间成交的限价单补设止损")
+}
@@ -0,0 +1,360 @@
+package trader
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"nofx/decision"
+	"nofx/logger"
+	"strings"
+	"time"
+)
+
+// takeProfitLadderInterval 分批止盈成交检查间隔
+const takeProfitLadderInterval = 5 * time.Second
+
+// ladderFillTolerance 判断某档已成交时允许的数量误差（占该档数量的比例），用于吸收交易所数量精度截断
+const ladderFillTolerance = 0.05
+
+// TakeProfitLadderStore 分批止盈状态持久化（由 config.Database 实现），用于重启后继续识别档位成交
+type TakeProfitLadderStore interface {
+	GetTakeProfitLadderState(traderID string) (string, error)
+	SaveTakeProfitLadderState(traderID, userID, state string) error
+}
+
+// takeProfitLadderLevel 分批止盈中的一档
+type takeProfitLadderLevel struct {
+	Price    float64 `json:"price"`
+	Quantity float64 `json:"quantity"`
+	Filled   bool    `json:"filled"`
+}
+
+// takeProfitLadder 持仓的分批止盈状态
+// 各档止盈单通过 SetTakeProfit 按档位数量挂出，成交通过持仓数量的减少来识别
+type takeProfitLadder struct {
+	Symbol            string                  `json:"symbol"`
+	Side              string                  `json:"side"` // long/short
+	EntryPrice        float64                 `json:"entry_price"`
+	StopLoss          float64                 `json:"stop_loss"`
+	InitialQuantity   float64                 `json:"initial_quantity"`
+	Levels            []takeProfitLadderLevel `json:"levels"`
+	BreakevenAfterTP1 bool                    `json:"breakeven_after_tp1"` // 第一档成交后把止损移到开仓价
+	BreakevenApplied  bool                    `json:"breakeven_applied"`
+}
+
+// markFilled 按当前剩余持仓数量标记已成交的档位，返回本次新成交的档数
+func (l *takeProfitLadder) markFilled(remaining float64) int {
+	closed := l.InitialQuantity - remaining
+	cumulative := 0.0
+	filled := 0
+	for i := range l.Levels {
+		cumulative += l.Levels[i].Quantity
+		if l.Levels[i].Filled {
+			continue
+		}
+		if closed < cumulative-l.Levels[i].Quantity*ladderFillTolerance {
+			break
+		}
+		l.Levels[i].Filled = true
+		filled++
+	}
+	return filled
+}
+
+// ToMap 转换为 /api/positions 返回的结构
+func (l *takeProfitLadder) ToMap() map[string]interface{} {
+	levels := make([]map[string]interface{}, 0, len(l.Levels))
+	for _, level := range l.Levels {
+		levels = append(levels, map[string]interface{}{
+			"price":    level.Price,
+			"quantity": level.Quantity,
+			"filled":   level.Filled,
+		})
+	}
+	return map[string]interface{}{
+		"levels":              levels,
+		"stop_loss":           l.StopLoss,
+		"breakeven_after_tp1": l.BreakevenAfterTP1,
+		"breakeven_applied":   l.BreakevenApplied,
+	}
+}
+
+// loadTakeProfitLadders 从持久化存储恢复分批止盈状态
+func (at *AutoTrader) loadTakeProfitLadders() {
+	if at.takeProfitLadderStore == nil {
+		return
+	}
+	state, err := at.takeProfitLadderStore.GetTakeProfitLadderState(at.id)
+	if err != nil {
+		log.Printf("⚠️ [%s] 读取分批止盈状态失败: %v", at.name, err)
+		return
+	}
+	if state == "" {
+		return
+	}
+
+	var ladders []*takeProfitLadder
+	if err := json.Unmarshal([]byte(state), &ladders); err != nil {
+		log.Printf("⚠️ [%s] 解析分批止盈状态失败: %v", at.name, err)
+		return
+	}
+
+	at.takeProfitLaddersMutex.Lock()
+	for _, ladder := range ladders {
+		at.takeProfitLadders[pendingOrderKey(ladder.Symbol, ladder.Side)] = ladder
+	}
+	at.takeProfitLaddersMutex.Unlock()
+	log.Printf("🔁 [%s] 已恢复 %d 个分批止盈", at.name, len(ladders))
+}
+
+// saveTakeProfitLaddersLocked 持久化分批止盈状态（调用方需持有 takeProfitLaddersMutex）
+func (at *AutoTrader) saveTakeProfitLaddersLocked() {
+	if at.takeProfitLadderStore == nil {
+		return
+	}
+	ladders := make([]*takeProfitLadder, 0, len(at.takeProfitLadders))
+	for _, ladder := range at.takeProfitLadders {
+		ladders = append(ladders, ladder)
+	}
+	data, err := json.Marshal(ladders)
+	if err != nil {
+		log.Printf("⚠️ [%s] 序列化分批止盈状态失败: %v", at.name, err)
+		return
+	}
+	if err := at.takeProfitLadderStore.SaveTakeProfitLadderState(at.id, at.userID, string(data)); err != nil {
+		log.Printf("⚠️ [%s] 保存分批止盈状态失败: %v", at.name, err)
+	}
+}
+
+// takeProfitLevels 返回开仓使用的分批止盈档位：优先使用 AI 给出的档位，否则按交易员配置的模板展开
+func (at *AutoTrader) takeProfitLevels(d *decision.Decision, entryPrice float64) []decision.TakeProfitLevel {
+	if len(d.TakeProfitLevels) > 0 {
+		return d.TakeProfitLevels
+	}
+	return decision.BuildTakeProfitLadder(entryPrice, d.TakeProfit, at.config.TakeProfitLadder)
+}
+
+// breakevenAfterTP1 决策或交易员配置任一开启即在第一档止盈后移动止损到开仓价
+func (at *AutoTrader) breakevenAfterTP1(d *decision.Decision) bool {
+	return d.BreakevenAfterTP1 || at.config.BreakevenAfterTP1
+}
+
+// armTakeProfitLadder 为新开仓位按档位挂出分批止盈单（最后一档取剩余数量）并开始跟踪。
+// 没有档位时返回 false，由调用方按单一止盈处理
+func (at *AutoTrader) armTakeProfitLadder(symbol, side string, entryPrice, quantity, stopLoss float64, levels []decision.TakeProfitLevel, breakeven bool) bool {
+	if len(levels) == 0 || quantity <= 0 {
+		return false
+	}
+
+	ladder := &takeProfitLadder{
+		Symbol:            symbol,
+		Side:              side,
+		EntryPrice:        entryPrice,
+		StopLoss:          stopLoss,
+		InitialQuantity:   quantity,
+		BreakevenAfterTP1: breakeven,
+	}
+	positionSide := strings.ToUpper(side)
+	for i, qty := range decision.TakeProfitLevelQuantities(quantity, levels) {
+		ladder.Levels = append(ladder.Levels, takeProfitLadderLevel{Price: levels[i].Price, Quantity: qty})
+		if err := at.trader.SetTakeProfit(symbol, positionSide, qty, levels[i].Price); err != nil {
+			log.Printf("  ⚠ 设置第%d档止盈失败: %v", i+1, err)
+		}
+	}
+
+	at.takeProfitLaddersMutex.Lock()
+	at.takeProfitLadders[pendingOrderKey(symbol, side)] = ladder
+	at.saveTakeProfitLaddersLocked()
+	at.takeProfitLaddersMutex.Unlock()
+
+	log.Printf("  🪜 已设置 %d 档分批止盈: %s %s（第一档后保本: %v）", len(levels), symbol, side, breakeven)
+	return true
+}
+
+// extendTakeProfitLadder 限价单后续部分成交时，把新成交的 quantity 按档位累加到已有的分批止盈状态，
+// 返回未成交档位的剩余数量、当前止损价和未成交的档位，由调用方撤销旧单后重新挂出。
+// 没有档位或尚未建立分批止盈时返回 false，由调用方按单一止盈处理
+func (at *AutoTrader) extendTakeProfitLadder(symbol, side string, quantity float64, levels []decision.TakeProfitLevel) (float64, float64, []takeProfitLadderLevel, bool) {
+	if len(levels) == 0 || quantity <= 0 {
+		return 0, 0, nil, false
+	}
+
+	at.takeProfitLaddersMutex.Lock()
+	defer at.takeProfitLaddersMutex.Unlock()
+
+	ladder, ok := at.takeProfitLadders[pendingOrderKey(symbol, side)]
+	if !ok || len(ladder.Levels) != len(levels) {
+		return 0, 0, nil, false
+	}
+	ladder.InitialQuantity += quantity
+	remaining := 0.0
+	pending := make([]takeProfitLadderLevel, 0, len(ladder.Levels))
+	for i, qty := range decision.TakeProfitLevelQuantities(quantity, levels) {
+		ladder.Levels[i].Quantity += qty
+		if !ladder.Levels[i].Filled {
+			remaining += ladder.Levels[i].Quantity
+			pending = append(pending, ladder.Levels[i])
+		}
+	}
+	at.saveTakeProfitLaddersLocked()
+	log.Printf("  🪜 分批止盈追加 %.4f: %s %s", quantity, symbol, side)
+	return remaining, ladder.StopLoss, pending, true
+}
+
+func (at *AutoTrader) getTakeProfitLadder(symbol, side string) (*takeProfitLadder, bool) {
+	at.takeProfitLaddersMutex.Lock()
+	defer at.takeProfitLaddersMutex.Unlock()
+	ladder, ok := at.takeProfitLadders[pendingOrderKey(symbol, side)]
+	return ladder, ok
+}
+
+// removeTakeProfitLadder 停止跟踪分批止盈（持仓已平或止盈被 AI 重新设置）
+func (at *AutoTrader) removeTakeProfitLadder(symbol, side string) {
+	at.takeProfitLaddersMutex.Lock()
+	if _, ok := at.takeProfitLadders[pendingOrderKey(symbol, side)]; ok {
+		delete(at.takeProfitLadders, pendingOrderKey(symbol, side))
+		at.saveTakeProfitLaddersLocked()
+	}
+	at.takeProfitLaddersMutex.Unlock()
+}
+
+// updateLadderStopLoss AI 调整止损后同步到分批止盈状态，后续重新挂单时使用新的止损价
+func (at *AutoTrader) updateLadderStopLoss(symbol, side string, stopLoss float64) {
+	at.takeProfitLaddersMutex.Lock()
+	if ladder, ok := at.takeProfitLadders[pendingOrderKey(symbol, side)]; ok {
+		ladder.StopLoss = stopLoss
+		at.saveTakeProfitLaddersLocked()
+	}
+	at.takeProfitLaddersMutex.Unlock()
+}
+
+// startTakeProfitLadderMonitor 启动分批止盈监控，识别档位成交并为剩余仓位重新设置保护
+func (at *AutoTrader) startTakeProfitLadderMonitor() {
+	at.monitorWg.Add(1)
+	go func() {
+		defer at.monitorWg.Done()
+
+		ticker := time.NewTicker(takeProfitLadderInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				at.checkTakeProfitLadders()
+			case <-at.stopMonitorCh:
+				log.Println("⏹ 停止分批止盈监控")
+				return
+			}
+		}
+	}()
+}
+
+// checkTakeProfitLadders 根据持仓数量的减少识别已成交的档位。
+// 有新成交时撤销原有止盈止损，为剩余仓位重新挂出止损（需要时移到开仓价）和未成交的档位
+func (at *AutoTrader) checkTakeProfitLadders() {
+	at.takeProfitLaddersMutex.Lock()
+	ladders := make([]*takeProfitLadder, 0, len(at.takeProfitLadders))
+	for _, ladder := range at.takeProfitLadders {
+		ladders = append(ladders, ladder)
+	}
+	at.takeProfitLaddersMutex.Unlock()
+
+	if len(ladders) == 0 {
+		return
+	}
+
+	positions, err := FetchPositions(at.trader)
+	if err != nil {
+		log.Printf("⚠️ 分批止盈：获取持仓失败: %v", err)
+		return
+	}
+	open := make(map[string]float64, len(positions))
+	for _, pos := range positions {
+		open[pendingOrderKey(pos.Symbol, pos.Side)] = math.Abs(pos.Quantity)
+	}
+
+	for _, ladder := range ladders {
+		remaining, ok := open[pendingOrderKey(ladder.Symbol, ladder.Side)]
+		if !ok || remaining <= 0 {
+			log.Printf("  ℹ %s %s 持仓已不存在，停止跟踪分批止盈", ladder.Symbol, ladder.Side)
+			at.removeTakeProfitLadder(ladder.Symbol, ladder.Side)
+			continue
+		}
+
+		at.takeProfitLaddersMutex.Lock()
+		filled := ladder.markFilled(remaining)
+		movedToBreakeven := false
+		if filled > 0 && ladder.BreakevenAfterTP1 && !ladder.BreakevenApplied && ladder.Levels[0].Filled {
+			ladder.BreakevenApplied = true
+			if (ladder.Side == "long" && ladder.StopLoss < ladder.EntryPrice) || (ladder.Side == "short" && ladder.StopLoss > ladder.EntryPrice) {
+				ladder.StopLoss = ladder.EntryPrice
+				movedToBreakeven = true
+			}
+		}
+		stopLoss := ladder.StopLoss
+		pending := make([]takeProfitLadderLevel, 0, len(ladder.Levels))
+		for _, level := range ladder.Levels {
+			if !level.Filled {
+				pending = append(pending, level)
+			}
+		}
+		if filled > 0 {
+			at.saveTakeProfitLaddersLocked()
+		}
+		at.takeProfitLaddersMutex.Unlock()
+
+		if filled == 0 {
+			continue
+		}
+
+		log.Printf("🪜 %s %s 分批止盈成交 %d 档，剩余持仓 %.4f，剩余 %d 档", ladder.Symbol, ladder.Side, filled, remaining, len(pending))
+		at.rearmTakeProfitLadder(ladder.Symbol, ladder.Side, remaining, stopLoss, pending)
+
+		tgMessage := fmt.Sprintf("🪜 **分批止盈成交**\n"+
+			"📋 币种: `%s`\n"+
+			"📊 方向: `%s`\n"+
+			"💰 剩余数量: `%.4f`\n"+
+			"🛑 止损: `%.4f`\n"+
+			"🎯 剩余档位: `%d`\n"+
+			"⏰ 时间: `%s`",
+			ladder.Symbol,
+			strings.ToUpper(ladder.Side),
+			remaining,
+			stopLoss,
+			len(pending),
+			time.Now().Format("2006-01-02 15:04:05"))
+		if movedToBreakeven {
+			tgMessage += "\n🛡️ 止损已移至开仓价"
+		}
+		logger.SendTelegramMessage(tgMessage)
+	}
+}
+
+// rearmTakeProfitLadder 为剩余仓位重新设置止损和未成交的止盈档位（最后一档取剩余数量）
+func (at *AutoTrader) rearmTakeProfitLadder(symbol, side string, remaining, stopLoss float64, pending []takeProfitLadderLevel) {
+	positionSide := strings.ToUpper(side)
+	if err := at.trader.CancelStopOrders(symbol); err != nil {
+		log.Printf("  ⚠ 取消旧止盈止损单失败: %v", err)
+	}
+	if stopLoss > 0 {
+		if err := at.trader.SetStopLoss(symbol, positionSide, remaining, stopLoss); err != nil {
+			log.Printf("  ⚠ 恢复止损失败: %v", err)
+		}
+	}
+
+	left := remaining
+	for i, level := range pending {
+		qty := math.Min(level.Quantity, left)
+		if i == len(pending)-1 {
+			qty = left
+		}
+		if qty <= 0 {
+			break
+		}
+		if err := at.trader.SetTakeProfit(symbol, positionSide, qty, level.Price); err != nil {
+			log.Printf("  ⚠ 恢复止盈档位 %.4f 失败: %v", level.Price, err)
+		}
+		left -= qty
+	}
+}
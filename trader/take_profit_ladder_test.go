@@ -0,0 +1,176 @@
+package trader
+
+import (
+	"nofx/decision"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newLadderTestTrader(trader Trader, config AutoTraderConfig) *AutoTrader {
+	return &AutoTrader{
+		id:                "ladder_test",
+		config:            config,
+		trader:            trader,
+		peakPnLCache:      make(map[string]float64),
+		takeProfitLadders: make(map[string]*takeProfitLadder),
+	}
+}
+
+func TestTakeProfitLadder_MarkFilled(t *testing.T) {
+	ladder := &takeProfitLadder{
+		InitialQuantity: 10,
+		Levels: []takeProfitLadderLevel{
+			{Price: 105, Quantity: 3}, {Price: 110, Quantity: 3}, {Price: 120, Quantity: 4},
+		},
+	}
+
+	assert.Equal(t, 0, ladder.markFilled(10))
+	assert.Equal(t, 1, ladder.markFilled(7.01), "数量精度截断导致的微小误差应视为已成交")
+	assert.True(t, ladder.Levels[0].Filled)
+	assert.Equal(t, 0, ladder.markFilled(7))
+	assert.Equal(t, 1, ladder.markFilled(4))
+	assert.False(t, ladder.Levels[2].Filled)
+}
+
+func TestAutoTrader_TakeProfitLadderMovesStopToBreakeven(t *testing.T) {
+	prices := map[string]float64{"BTCUSDT": 100}
+	paper := newTestPaperTrader(t, nil, prices)
+	_, err := paper.OpenLong("BTCUSDT", 10, 5)
+	require.NoError(t, err)
+	positions, _ := paper.GetPositions()
+	entry := positions[0]["entryPrice"].(float64)
+
+	at := newLadderTestTrader(paper, AutoTraderConfig{BreakevenAfterTP1: true})
+	require.NoError(t, paper.SetStopLoss("BTCUSDT", "LONG", 10, 90))
+	levels := []decision.TakeProfitLevel{{Price: 105, Percentage: 30}, {Price: 110, Percentage: 30}, {Price: 120}}
+	require.True(t, at.armTakeProfitLadder("BTCUSDT", "long", entry, 10, 90, levels, true))
+
+	// 第一档成交：只平掉 30%
+	prices["BTCUSDT"] = 106
+	paper.CheckTriggers()
+	positions, _ = paper.GetPositions()
+	require.Len(t, positions, 1)
+	assert.InDelta(t, 7.0, positions[0]["positionAmt"].(float64), 1e-9)
+
+	at.checkTakeProfitLadders()
+	ladder, ok := at.getTakeProfitLadder("BTCUSDT", "long")
+	require.True(t, ok)
+	assert.True(t, ladder.BreakevenApplied)
+	assert.Equal(t, entry, ladder.StopLoss, "第一档成交后止损应移到开仓价")
+
+	items, err := at.GetPositions()
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	info, ok := items[0]["take_profit_ladder"].(map[string]interface{})
+	require.True(t, ok, "/api/positions 应返回分批止盈状态")
+	assert.Equal(t, true, info["breakeven_applied"])
+
+	// 回落到开仓价以下：按保本止损平掉剩余仓位，而不是原来的 90
+	prices["BTCUSDT"] = entry - 0.5
+	paper.CheckTriggers()
+	positions, _ = paper.GetPositions()
+	assert.Len(t, positions, 0)
+
+	at.checkTakeProfitLadders()
+	_, ok = at.getTakeProfitLadder("BTCUSDT", "long")
+	assert.False(t, ok, "持仓平掉后应停止跟踪")
+}
+
+func TestAutoTrader_TakeProfitLadderFromConfigTemplate(t *testing.T) {
+	prices := map[string]float64{"ETHUSDT": 2000}
+	paper := newTestPaperTrader(t, nil, prices)
+	_, err := paper.OpenShort("ETHUSDT", 2, 5)
+	require.NoError(t, err)
+
+	steps, err := decision.ParseTakeProfitLadder(`[{"ratio":0.5,"percentage":50},{"ratio":1}]`)
+	require.NoError(t, err)
+	at := newLadderTestTrader(paper, AutoTraderConfig{TakeProfitLadder: steps})
+
+	d := &decision.Decision{Symbol: "ETHUSDT", Action: "open_short", StopLoss: 2100, TakeProfit: 1800}
+	levels := at.takeProfitLevels(d, 2000)
+	require.Len(t, levels, 2)
+	assert.Equal(t, 1900.0, levels[0].Price)
+	assert.False(t, at.breakevenAfterTP1(d))
+	require.True(t, at.armTakeProfitLadder("ETHUSDT", "short", 2000, 2, 2100, levels, false))
+
+	// 一次越过两档：两档都按各自数量成交
+	prices["ETHUSDT"] = 1790
+	paper.CheckTriggers()
+	positions, _ := paper.GetPositions()
+	assert.Len(t, positions, 0)
+
+	history, _ := paper.GetTradeHistory("ETHUSDT", 10)
+	tpFills := 0
+	for _, trade := range history {
+		if trade["action"] == "take_profit" {
+			tpFills++
+			assert.InDelta(t, 1.0, trade["qty"].(float64), 1e-9)
+		}
+	}
+	assert.Equal(t, 2, tpFills)
+}
+
+// memoryTakeProfitLadderStore 内存版分批止盈状态存储
+type memoryTakeProfitLadderStore struct {
+	states map[string]string
+}
+
+func (s *memoryTakeProfitLadderStore) GetTakeProfitLadderState(traderID string) (string, error) {
+	return s.states[traderID], nil
+}
+
+func (s *memoryTakeProfitLadderStore) SaveTakeProfitLadderState(traderID, userID, state string) error {
+	s.states[traderID] = state
+	return nil
+}
+
+func TestAutoTrader_TakeProfitLadderRestoredAfterRestart(t *testing.T) {
+	prices := map[string]float64{"BTCUSDT": 100}
+	paper := newTestPaperTrader(t, nil, prices)
+	_, err := paper.OpenLong("BTCUSDT", 10, 5)
+	require.NoError(t, err)
+	positions, _ := paper.GetPositions()
+	entry := positions[0]["entryPrice"].(float64)
+
+	store := &memoryTakeProfitLadderStore{states: map[string]string{}}
+	at := newLadderTestTrader(paper, AutoTraderConfig{})
+	at.takeProfitLadderStore = store
+	require.NoError(t, paper.SetStopLoss("BTCUSDT", "LONG", 10, 90))
+	levels := []decision.TakeProfitLevel{{Price: 105, Percentage: 30}, {Price: 110, Percentage: 30}, {Price: 120}}
+	require.True(t, at.armTakeProfitLadder("BTCUSDT", "long", entry, 10, 90, levels, true))
+	require.NotEmpty(t, store.states["ladder_test"], "设置分批止盈后应写入存储")
+
+	prices["BTCUSDT"] = 106
+	paper.CheckTriggers()
+	at.checkTakeProfitLadders()
+
+	// 模拟重启：新的 AutoTrader 从存储恢复档位成交进度和保本状态
+	restarted := newLadderTestTrader(paper, AutoTraderConfig{})
+	restarted.takeProfitLadderStore = store
+	restarted.loadTakeProfitLadders()
+	ladder, ok := restarted.getTakeProfitLadder("BTCUSDT", "long")
+	require.True(t, ok)
+	assert.Equal(t, 10.0, ladder.InitialQuantity)
+	require.Len(t, ladder.Levels, 3)
+	assert.True(t, ladder.Levels[0].Filled)
+	assert.False(t, ladder.Levels[1].Filled)
+	assert.True(t, ladder.BreakevenApplied)
+	assert.Equal(t, entry, ladder.StopLoss)
+
+	// 重启后继续识别第二档成交
+	prices["BTCUSDT"] = 111
+	paper.CheckTriggers()
+	restarted.checkTakeProfitLadders()
+	ladder, ok = restarted.getTakeProfitLadder("BTCUSDT", "long")
+	require.True(t, ok)
+	assert.True(t, ladder.Levels[1].Filled)
+
+	prices["BTCUSDT"] = 121
+	paper.CheckTriggers()
+	restarted.checkTakeProfitLadders()
+	_, ok = restarted.getTakeProfitLadder("BTCUSDT", "long")
+	assert.False(t, ok)
+	assert.Equal(t, "[]", store.states["ladder_test"], "持仓平掉后应从存储中移除")
+}
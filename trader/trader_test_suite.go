@@ -5,6 +5,7 @@ import (
 
 	"github.com/agiledragon/gomonkey/v2"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TraderTestSuite 通用的 Trader 接口测试套件（基础套件）
@@ -44,6 +45,9 @@ func (s *TraderTestSuite) RunAllTests() {
 	s.T.Run("GetPositions", func(t *testing.T) { s.TestGetPositions() })
 	s.T.Run("GetMarketPrice", func(t *testing.T) { s.TestGetMarketPrice() })
 
+	// 强类型领域模型
+	s.T.Run("DomainModel", func(t *testing.T) { s.TestDomainModel() })
+
 	// 配置方法
 	s.T.Run("SetLeverage", func(t *testing.T) { s.TestSetLeverage() })
 	s.T.Run("SetMarginMode", func(t *testing.T) { s.TestSetMarginMode() })
@@ -136,6 +140,115 @@ func (s *TraderTestSuite) TestGetPositions() {
 	}
 }
 
+// TestDomainModel 测试交易所返回的 map 能够无损转换为强类型的 Balance / Position
+func (s *TraderTestSuite) TestDomainModel() {
+	s.T.Run("余额转换", func(t *testing.T) {
+		balance, err := FetchBalance(s.Trader)
+		assert.NoError(t, err)
+		assert.Greater(t, balance.TotalWalletBalance, 0.0)
+		assert.Greater(t, balance.AvailableBalance, 0.0)
+	})
+
+	s.T.Run("持仓转换", func(t *testing.T) {
+		positions, err := FetchPositions(s.Trader)
+		assert.NoError(t, err)
+		for _, pos := range positions {
+			assert.NotEmpty(t, pos.Symbol)
+			assert.Contains(t, []string{"long", "short"}, pos.Side)
+			assert.Greater(t, pos.Quantity, 0.0)
+			assert.Greater(t, pos.EntryPrice, 0.0)
+		}
+	})
+}
+
+// DomainContractFixture 单个交易所的原始返回数据（与适配器实际输出的 map 格式一致）
+type DomainContractFixture struct {
+	Exchange  string
+	Balance   map[string]interface{}
+	Positions []map[string]interface{}
+	Order     map[string]interface{}
+	Fill      map[string]interface{}
+}
+
+// DomainContractCase 通过适配器真实解析路径（指向 httptest mock）执行契约校验的参数
+type DomainContractCase struct {
+	Exchange        string
+	Trader          Trader
+	Symbol          string  // 限价下单和查询成交记录使用的币种
+	Quantity        float64 // 限价单数量
+	Price           float64 // 限价单价格
+	HasTradeHistory bool    // 适配器是否实现了 GetTradeHistory
+	SkipLimitOrder  bool    // 下单需要本地签名的适配器（如 LIGHTER）无法在 mock 中下单，跳过限价单校验
+}
+
+// AssertDomainContract 调用适配器获取余额、持仓、限价下单结果和成交记录，
+// 校验其解析出的 map 转换为领域模型后所有字段均已填充
+func AssertDomainContract(t *testing.T, c DomainContractCase) {
+	fixture := DomainContractFixture{Exchange: c.Exchange}
+	var err error
+
+	fixture.Balance, err = c.Trader.GetBalance()
+	require.NoError(t, err, "%s GetBalance", c.Exchange)
+	fixture.Positions, err = c.Trader.GetPositions()
+	require.NoError(t, err, "%s GetPositions", c.Exchange)
+	if !c.SkipLimitOrder {
+		fixture.Order, err = c.Trader.OpenLimitOrder(c.Symbol, "long", c.Quantity, 10, c.Price, TimeInForceGTC)
+		require.NoError(t, err, "%s OpenLimitOrder", c.Exchange)
+	}
+
+	if c.HasTradeHistory {
+		fills, err := c.Trader.GetTradeHistory(c.Symbol, 10)
+		require.NoError(t, err, "%s GetTradeHistory", c.Exchange)
+		require.NotEmpty(t, fills, "%s 缺少成交记录样本", c.Exchange)
+		fixture.Fill = fills[0]
+	}
+
+	AssertDomainFixture(t, fixture)
+}
+
+// AssertDomainFixture 校验交易所原始数据转换为领域模型后所有字段均已填充
+func AssertDomainFixture(t *testing.T, fixture DomainContractFixture) {
+	balance, err := BalanceFromMap(fixture.Balance)
+	if assert.NoError(t, err, "%s 余额转换失败", fixture.Exchange) {
+		assert.NotZero(t, balance.TotalWalletBalance, "%s TotalWalletBalance", fixture.Exchange)
+		assert.NotZero(t, balance.AvailableBalance, "%s AvailableBalance", fixture.Exchange)
+		assert.NotZero(t, balance.TotalUnrealizedProfit, "%s TotalUnrealizedProfit", fixture.Exchange)
+		assert.NotZero(t, balance.TotalEquity(), "%s TotalEquity", fixture.Exchange)
+	}
+
+	assert.NotEmpty(t, fixture.Positions, "%s 缺少持仓样本", fixture.Exchange)
+	for _, raw := range fixture.Positions {
+		pos, err := PositionFromMap(raw)
+		if !assert.NoError(t, err, "%s 持仓转换失败", fixture.Exchange) {
+			continue
+		}
+		assert.NotEmpty(t, pos.Symbol, "%s Symbol", fixture.Exchange)
+		assert.Contains(t, []string{"long", "short"}, pos.Side, "%s Side", fixture.Exchange)
+		assert.Greater(t, pos.Quantity, 0.0, "%s Quantity 应为正数", fixture.Exchange)
+		assert.NotZero(t, pos.EntryPrice, "%s EntryPrice", fixture.Exchange)
+		assert.NotZero(t, pos.MarkPrice, "%s MarkPrice", fixture.Exchange)
+		assert.NotZero(t, pos.UnrealizedPnL, "%s UnrealizedPnL", fixture.Exchange)
+		assert.NotZero(t, pos.LiquidationPrice, "%s LiquidationPrice", fixture.Exchange)
+		assert.NotZero(t, pos.Leverage, "%s Leverage", fixture.Exchange)
+	}
+
+	if fixture.Order != nil {
+		order := OrderResultFromMap(fixture.Order)
+		assert.NotEmpty(t, order.OrderID, "%s OrderID", fixture.Exchange)
+		assert.NotEmpty(t, order.Symbol, "%s Order.Symbol", fixture.Exchange)
+	}
+
+	if fixture.Fill != nil {
+		fill, err := FillFromMap(fixture.Fill)
+		assert.NoError(t, err, "%s 成交记录转换失败", fixture.Exchange)
+		assert.NotEmpty(t, fill.OrderID, "%s Fill.OrderID", fixture.Exchange)
+		assert.NotEmpty(t, fill.Symbol, "%s Fill.Symbol", fixture.Exchange)
+		assert.NotZero(t, fill.Price, "%s Fill.Price", fixture.Exchange)
+		assert.NotZero(t, fill.Quantity, "%s Fill.Quantity", fixture.Exchange)
+		assert.False(t, fill.Time.IsZero(), "%s Fill.Time", fixture.Exchange)
+	}
+}
+
 // TestGetMarketPrice 测试获取市场价格
 func (s *TraderTestSuite) TestGetMarketPrice() {
 	tests := []struct {
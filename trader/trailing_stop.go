@@ -0,0 +1,356 @@
+package trader
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"nofx/decision"
+	"nofx/logger"
+	"strings"
+	"time"
+)
+
+// trailingStopInterval 本地模拟追踪止损的价格轮询间隔
+const trailingStopInterval = 5 * time.Second
+
+// NativeTrailingStopper 支持交易所原生追踪止损的交易器（币安 TRAILING_STOP_MARKET、Bybit trading-stop）
+// 未实现该接口的交易所由 AutoTrader 本地轮询价格模拟
+type NativeTrailingStopper interface {
+	// SetTrailingStop 设置追踪止损，callbackRate（百分比）与 distance（价格距离）二选一，activationPrice<=0 表示立即生效
+	SetTrailingStop(symbol, positionSide string, quantity, callbackRate, distance, activationPrice float64) (map[string]interface{}, error)
+	// CancelTrailingStop 取消追踪止损，orderID 为 SetTrailingStop 返回的订单ID（部分交易所不需要）
+	CancelTrailingStop(symbol, positionSide, orderID string) error
+}
+
+// TrailingStopStore 追踪止损状态持久化（由 config.Database 实现），用于重启后恢复
+type TrailingStopStore interface {
+	GetTrailingStopState(traderID string) (string, error)
+	SaveTrailingStopState(traderID, userID, state string) error
+}
+
+// TrailingStop 单个持仓的追踪止损
+type TrailingStop struct {
+	Symbol          string  `json:"symbol"`
+	Side            string  `json:"side"` // long/short
+	CallbackRate    float64 `json:"callback_rate,omitempty"`
+	Distance        float64 `json:"distance,omitempty"`
+	ActivationPrice float64 `json:"activation_price,omitempty"`
+	Native          bool    `json:"native"`             // true=交易所原生订单，false=本地模拟
+	OrderID         string  `json:"order_id,omitempty"` // 原生订单ID
+	Activated       bool    `json:"activated"`
+	ExtremePrice    float64 `json:"extreme_price,omitempty"` // 激活后的最高价（多）/最低价（空），仅本地模拟
+	StopPrice       float64 `json:"stop_price,omitempty"`    // 当前触发价，仅本地模拟
+	CreatedAt       int64   `json:"created_at"`
+}
+
+// Update 用最新价格推进本地追踪止损，返回状态是否变化以及是否触发
+func (s *TrailingStop) Update(price float64) (changed bool, triggered bool) {
+	if price <= 0 {
+		return false, false
+	}
+	long := s.Side == "long"
+
+	if !s.Activated {
+		if s.ActivationPrice > 0 && ((long && price < s.ActivationPrice) || (!long && price > s.ActivationPrice)) {
+			return false, false
+		}
+		s.Activated = true
+		s.ExtremePrice = price
+		changed = true
+	}
+
+	if (long && price > s.ExtremePrice) || (!long && price < s.ExtremePrice) {
+		s.ExtremePrice = price
+		changed = true
+	}
+
+	offset := s.Distance
+	if s.CallbackRate > 0 {
+		offset = s.ExtremePrice * s.CallbackRate / 100
+	}
+	if long {
+		s.StopPrice = s.ExtremePrice - offset
+		return changed, price <= s.StopPrice
+	}
+	s.StopPrice = s.ExtremePrice + offset
+	return changed, price >= s.StopPrice
+}
+
+// ToMap 转换为 API 输出格式
+func (s *TrailingStop) ToMap() map[string]interface{} {
+	mode := "emulated"
+	if s.Native {
+		mode = "native"
+	}
+	return map[string]interface{}{
+		"callback_rate":    s.CallbackRate,
+		"distance":         s.Distance,
+		"activation_price": s.ActivationPrice,
+		"mode":             mode,
+		"order_id":         s.OrderID,
+		"activated":        s.Activated,
+		"extreme_price":    s.ExtremePrice,
+		"stop_price":       s.StopPrice,
+		"created_at":       s.CreatedAt,
+	}
+}
+
+// loadTrailingStops 从数据库恢复追踪止损
+func (at *AutoTrader) loadTrailingStops() {
+	if at.trailingStopStore == nil {
+		return
+	}
+	state, err := at.trailingStopStore.GetTrailingStopState(at.id)
+	if err != nil {
+		log.Printf("⚠️ [%s] 读取追踪止损状态失败: %v", at.name, err)
+		return
+	}
+	if state == "" {
+		return
+	}
+
+	var stops []*TrailingStop
+	if err := json.Unmarshal([]byte(state), &stops); err != nil {
+		log.Printf("⚠️ [%s] 解析追踪止损状态失败: %v", at.name, err)
+		return
+	}
+
+	at.trailingStopsMutex.Lock()
+	for _, stop := range stops {
+		at.trailingStops[pendingOrderKey(stop.Symbol, stop.Side)] = stop
+	}
+	at.trailingStopsMutex.Unlock()
+	log.Printf("🔁 [%s] 已恢复 %d 个追踪止损", at.name, len(stops))
+}
+
+// saveTrailingStopsLocked 持久化追踪止损（调用方需持有 trailingStopsMutex）
+func (at *AutoTrader) saveTrailingStopsLocked() {
+	if at.trailingStopStore == nil {
+		return
+	}
+	stops := make([]*TrailingStop, 0, len(at.trailingStops))
+	for _, stop := range at.trailingStops {
+		stops = append(stops, stop)
+	}
+	data, err := json.Marshal(stops)
+	if err != nil {
+		log.Printf("⚠️ [%s] 序列化追踪止损状态失败: %v", at.name, err)
+		return
+	}
+	if err := at.trailingStopStore.SaveTrailingStopState(at.id, at.userID, string(data)); err != nil {
+		log.Printf("⚠️ [%s] 保存追踪止损状态失败: %v", at.name, err)
+	}
+}
+
+// getTrailingStop 获取持仓的追踪止损副本
+func (at *AutoTrader) getTrailingStop(symbol, side string) (TrailingStop, bool) {
+	at.trailingStopsMutex.Lock()
+	defer at.trailingStopsMutex.Unlock()
+	stop, ok := at.trailingStops[pendingOrderKey(symbol, side)]
+	if !ok {
+		return TrailingStop{}, false
+	}
+	return *stop, true
+}
+
+// hasTrailingStop 持仓是否已由追踪止损接管（接管后不再执行固定的收益率回撤平仓规则）
+func (at *AutoTrader) hasTrailingStop(symbol, side string) bool {
+	_, ok := at.getTrailingStop(symbol, side)
+	return ok
+}
+
+// removeTrailingStop 删除持仓的追踪止损记录
+func (at *AutoTrader) removeTrailingStop(symbol, side string) {
+	at.trailingStopsMutex.Lock()
+	defer at.trailingStopsMutex.Unlock()
+	key := pendingOrderKey(symbol, side)
+	if _, ok := at.trailingStops[key]; !ok {
+		return
+	}
+	delete(at.trailingStops, key)
+	at.saveTrailingStopsLocked()
+}
+
+// pruneTrailingStops 清理已不存在持仓的追踪止损（仓位已被平掉或原生订单已触发）
+func (at *AutoTrader) pruneTrailingStops(positions []decision.PositionInfo) {
+	open := make(map[string]bool, len(positions))
+	for _, pos := range positions {
+		open[pendingOrderKey(pos.Symbol, pos.Side)] = true
+	}
+
+	at.trailingStopsMutex.Lock()
+	defer at.trailingStopsMutex.Unlock()
+	removed := 0
+	for key, stop := range at.trailingStops {
+		if !open[key] {
+			log.Printf("  ℹ %s %s 持仓已不存在，移除追踪止损", stop.Symbol, stop.Side)
+			delete(at.trailingStops, key)
+			removed++
+		}
+	}
+	if removed > 0 {
+		at.saveTrailingStopsLocked()
+	}
+}
+
+// executeSetTrailingStopWithRecord 为现有持仓设置追踪止损并记录详细信息
+func (at *AutoTrader) executeSetTrailingStopWithRecord(decision *decision.Decision, actionRecord *logger.DecisionAction) error {
+	log.Printf("  🎯 设置追踪止损: %s (回调比例: %.2f%%, 回调距离: %.4f, 激活价: %.4f)",
+		decision.Symbol, decision.CallbackRate, decision.TrailingDistance, decision.ActivationPrice)
+
+	positions, err := FetchPositions(at.trader)
+	if err != nil {
+		return fmt.Errorf("获取持仓失败: %w", err)
+	}
+
+	// 决策只给出币种，双向持仓时无法确定追踪哪一边，拒绝执行而不是随意选择一边
+	var targetPosition *Position
+	for i := range positions {
+		if positions[i].Symbol != decision.Symbol {
+			continue
+		}
+		if targetPosition != nil && targetPosition.Side != positions[i].Side {
+			return fmt.Errorf("%s 存在双向持仓（LONG + SHORT），无法确定追踪止损方向", decision.Symbol)
+		}
+		targetPosition = &positions[i]
+	}
+	if targetPosition == nil {
+		return fmt.Errorf("持仓不存在: %s", decision.Symbol)
+	}
+
+	side := targetPosition.Side
+	quantity := math.Abs(targetPosition.Quantity)
+	actionRecord.Quantity = quantity
+
+	price, err := at.trader.GetMarketPrice(decision.Symbol)
+	if err != nil {
+		return fmt.Errorf("获取市场价格失败: %w", err)
+	}
+	actionRecord.Price = price
+
+	if decision.ActivationPrice > 0 {
+		if side == "long" && decision.ActivationPrice <= price {
+			return fmt.Errorf("多单追踪止损激活价必须高于当前价格 (当前: %.4f, 激活价: %.4f)", price, decision.ActivationPrice)
+		}
+		if side == "short" && decision.ActivationPrice >= price {
+			return fmt.Errorf("空单追踪止损激活价必须低于当前价格 (当前: %.4f, 激活价: %.4f)", price, decision.ActivationPrice)
+		}
+	}
+
+	stop := &TrailingStop{
+		Symbol:          decision.Symbol,
+		Side:            side,
+		CallbackRate:    decision.CallbackRate,
+		Distance:        decision.TrailingDistance,
+		ActivationPrice: decision.ActivationPrice,
+		CreatedAt:       time.Now().UnixMilli(),
+	}
+
+	if native, ok := at.trader.(NativeTrailingStopper); ok {
+		// 替换已有的原生追踪止损
+		if existing, exists := at.getTrailingStop(decision.Symbol, side); exists && existing.Native {
+			if err := native.CancelTrailingStop(decision.Symbol, strings.ToUpper(side), existing.OrderID); err != nil {
+				log.Printf("  ⚠ 取消旧追踪止损失败: %v", err)
+			}
+		}
+
+		order, err := native.SetTrailingStop(decision.Symbol, strings.ToUpper(side), quantity,
+			decision.CallbackRate, decision.TrailingDistance, decision.ActivationPrice)
+		if err != nil {
+			return fmt.Errorf("设置追踪止损失败: %w", err)
+		}
+		result := OrderResultFromMap(order)
+		stop.Native = true
+		stop.OrderID = result.OrderID
+		stop.Activated = decision.ActivationPrice <= 0
+		actionRecord.OrderID = result.NumericID()
+		log.Printf("  ✓ 交易所原生追踪止损已设置: %s %s", decision.Symbol, strings.ToUpper(side))
+	} else {
+		stop.Update(price)
+		log.Printf("  ✓ 本地追踪止损已设置: %s %s（每%v检查一次）", decision.Symbol, strings.ToUpper(side), trailingStopInterval)
+	}
+
+	at.trailingStopsMutex.Lock()
+	at.trailingStops[pendingOrderKey(decision.Symbol, side)] = stop
+	at.saveTrailingStopsLocked()
+	at.trailingStopsMutex.Unlock()
+	return nil
+}
+
+// startTrailingStopMonitor 启动本地追踪止损监控（不支持原生追踪止损的交易所）
+func (at *AutoTrader) startTrailingStopMonitor() {
+	at.monitorWg.Add(1)
+	go func() {
+		defer at.monitorWg.Done()
+
+		ticker := time.NewTicker(trailingStopInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				at.checkTrailingStops()
+			case <-at.stopMonitorCh:
+				log.Println("⏹ 停止追踪止损监控")
+				return
+			}
+		}
+	}()
+}
+
+// checkTrailingStops 推进本地模拟的追踪止损，触发时市价平仓
+func (at *AutoTrader) checkTrailingStops() {
+	at.trailingStopsMutex.Lock()
+	stops := make([]*TrailingStop, 0, len(at.trailingStops))
+	for _, stop := range at.trailingStops {
+		if !stop.Native {
+			stops = append(stops, stop)
+		}
+	}
+	at.trailingStopsMutex.Unlock()
+
+	for _, stop := range stops {
+		price, err := at.trader.GetMarketPrice(stop.Symbol)
+		if err != nil {
+			log.Printf("⚠️ 追踪止损：获取 %s 价格失败: %v", stop.Symbol, err)
+			continue
+		}
+
+		at.trailingStopsMutex.Lock()
+		changed, triggered := stop.Update(price)
+		extreme, stopPrice := stop.ExtremePrice, stop.StopPrice
+		if changed && !triggered {
+			at.saveTrailingStopsLocked()
+		}
+		at.trailingStopsMutex.Unlock()
+
+		if !triggered {
+			continue
+		}
+
+		log.Printf("🚨 触发追踪止损: %s %s | 当前价: %.4f | 极值: %.4f | 触发价: %.4f",
+			stop.Symbol, stop.Side, price, extreme, stopPrice)
+		if err := at.emergencyClosePosition(stop.Symbol, stop.Side); err != nil {
+			log.Printf("❌ 追踪止损平仓失败 (%s %s): %v", stop.Symbol, stop.Side, err)
+			continue
+		}
+		at.removeTrailingStop(stop.Symbol, stop.Side)
+		at.ClearPeakPnLCache(stop.Symbol, stop.Side)
+
+		tgMessage := fmt.Sprintf("🎯 **追踪止损触发**\n"+
+			"📋 币种: `%s`\n"+
+			"📊 方向: `%s`\n"+
+			"📈 极值价格: `%.4f`\n"+
+			"🛑 触发价格: `%.4f`\n"+
+			"💵 当前价格: `%.4f`\n"+
+			"⏰ 时间: `%s`",
+			stop.Symbol,
+			strings.ToUpper(stop.Side),
+			extreme,
+			stopPrice,
+			price,
+			time.Now().Format("2006-01-02 15:04:05"))
+		logger.SendTelegramMessage(tgMessage)
+	}
+}
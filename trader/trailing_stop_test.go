@@ -0,0 +1,140 @@
+package trader
+
+import (
+	"nofx/decision"
+	"nofx/logger"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryTrailingStopStore 内存版追踪止损状态存储
+type memoryTrailingStopStore struct {
+	states map[string]string
+}
+
+func (s *memoryTrailingStopStore) GetTrailingStopState(traderID string) (string, error) {
+	return s.states[traderID], nil
+}
+
+func (s *memoryTrailingStopStore) SaveTrailingStopState(traderID, userID, state string) error {
+	s.states[traderID] = state
+	return nil
+}
+
+func newTrailingStopTestTrader(trader Trader, store TrailingStopStore) *AutoTrader {
+	at := &AutoTrader{
+		id:                "trailing_test",
+		trader:            trader,
+		peakPnLCache:      make(map[string]float64),
+		trailingStops:     make(map[string]*TrailingStop),
+		trailingStopStore: store,
+	}
+	at.loadTrailingStops()
+	return at
+}
+
+func TestTrailingStop_Update(t *testing.T) {
+	long := &TrailingStop{Symbol: "BTCUSDT", Side: "long", CallbackRate: 2}
+	_, triggered := long.Update(100)
+	assert.False(t, triggered)
+	assert.True(t, long.Activated, "未设置激活价时应立即生效")
+
+	_, triggered = long.Update(110)
+	assert.False(t, triggered)
+	assert.Equal(t, 110.0, long.ExtremePrice)
+	assert.InDelta(t, 107.8, long.StopPrice, 1e-9)
+
+	_, triggered = long.Update(107.5)
+	assert.True(t, triggered, "从最高价回撤超过2%应触发")
+
+	short := &TrailingStop{Symbol: "ETHUSDT", Side: "short", Distance: 20, ActivationPrice: 1900}
+	changed, triggered := short.Update(1950)
+	assert.False(t, changed)
+	assert.False(t, triggered)
+	assert.False(t, short.Activated, "未到激活价不应开始追踪")
+
+	short.Update(1880)
+	assert.True(t, short.Activated)
+	short.Update(1850)
+	assert.Equal(t, 1870.0, short.StopPrice)
+
+	_, triggered = short.Update(1869)
+	assert.False(t, triggered)
+	_, triggered = short.Update(1871)
+	assert.True(t, triggered)
+}
+
+func TestAutoTrader_EmulatedTrailingStopSurvivesRestart(t *testing.T) {
+	prices := map[string]float64{"BTCUSDT": 100}
+	paper := newTestPaperTrader(t, nil, prices)
+	_, err := paper.OpenLong("BTCUSDT", 1, 5)
+	require.NoError(t, err)
+
+	store := &memoryTrailingStopStore{states: make(map[string]string)}
+	at := newTrailingStopTestTrader(paper, store)
+
+	d := &decision.Decision{Symbol: "BTCUSDT", Action: "set_trailing_stop", CallbackRate: 5}
+	require.NoError(t, at.executeDecisionWithRecord(d, &logger.DecisionAction{}))
+
+	prices["BTCUSDT"] = 120
+	at.checkTrailingStops()
+
+	positions, err := at.GetPositions()
+	require.NoError(t, err)
+	require.Len(t, positions, 1)
+	stop, ok := positions[0]["trailing_stop"].(map[string]interface{})
+	require.True(t, ok, "/api/positions 应返回追踪止损")
+	assert.Equal(t, "emulated", stop["mode"])
+	assert.Equal(t, 120.0, stop["extreme_price"])
+
+	// 重启后从存储恢复，继续沿用之前的最高价
+	restored := newTrailingStopTestTrader(paper, store)
+	prices["BTCUSDT"] = 115
+	restored.checkTrailingStops()
+	positions, _ = paper.GetPositions()
+	assert.Len(t, positions, 1, "未回撤到触发价不应平仓")
+
+	prices["BTCUSDT"] = 113
+	restored.checkTrailingStops()
+	positions, _ = paper.GetPositions()
+	assert.Len(t, positions, 0, "从120回撤超过5%应平仓")
+	assert.False(t, restored.hasTrailingStop("BTCUSDT", "long"))
+	assert.Equal(t, "[]", store.states["trailing_test"])
+}
+
+func TestAutoTrader_SetTrailingStopRejectsInvalidActivation(t *testing.T) {
+	prices := map[string]float64{"ETHUSDT": 2000}
+	paper := newTestPaperTrader(t, nil, prices)
+	_, err := paper.OpenShort("ETHUSDT", 1, 5)
+	require.NoError(t, err)
+
+	at := newTrailingStopTestTrader(paper, nil)
+	d := &decision.Decision{Symbol: "ETHUSDT", Action: "set_trailing_stop", TrailingDistance: 30, ActivationPrice: 2100}
+	assert.Error(t, at.executeDecisionWithRecord(d, &logger.DecisionAction{}), "空单激活价必须低于当前价")
+
+	at.pruneTrailingStops(nil)
+	assert.False(t, at.hasTrailingStop("ETHUSDT", "short"))
+}
+
+func TestAutoTrader_SetTrailingStopRejectsHedgedPosition(t *testing.T) {
+	mock := &MockTrader{positions: []map[string]interface{}{
+		{"symbol": "BTCUSDT", "side": "long", "positionAmt": 0.5, "entryPrice": 48000.0, "markPrice": 50000.0},
+		{"symbol": "BTCUSDT", "side": "short", "positionAmt": -0.2, "entryPrice": 51000.0, "markPrice": 50000.0},
+	}}
+	at := newTrailingStopTestTrader(mock, nil)
+
+	d := &decision.Decision{Symbol: "BTCUSDT", Action: "set_trailing_stop", CallbackRate: 2}
+	err := at.executeDecisionWithRecord(d, &logger.DecisionAction{})
+	require.Error(t, err, "双向持仓时无法确定追踪止损方向")
+	assert.Contains(t, err.Error(), "双向持仓")
+	assert.False(t, at.hasTrailingStop("BTCUSDT", "long"))
+	assert.False(t, at.hasTrailingStop("BTCUSDT", "short"))
+
+	// 只剩一边时正常设置在该方向上
+	mock.positions = mock.positions[1:]
+	require.NoError(t, at.executeDecisionWithRecord(d, &logger.DecisionAction{}))
+	assert.True(t, at.hasTrailingStop("BTCUSDT", "short"))
+	assert.False(t, at.hasTrailingStop("BTCUSDT", "long"))
+}
@@ -0,0 +1,434 @@
+package trader
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ================================
+// 统一领域模型
+// ================================
+//
+// Trader 接口历史上返回 map[string]interface{}，各交易所的字段名并不一致
+// （如 LIGHTER 使用 entry_price/size，Gate 使用 contract/fill_price）。
+// 这里定义强类型的 Balance / Position / OrderResult / Fill，
+// 通过 FetchBalance / FetchPositions 等函数把各交易所的 map 统一转换，
+// 同时提供 ToMap 兼容层供仍依赖旧 map 格式的调用方使用。
+
+// Balance 账户余额
+type Balance struct {
+	TotalWalletBalance    float64 // 钱包余额（不含未实现盈亏）
+	AvailableBalance      float64 // 可用余额
+	TotalUnrealizedProfit float64 // 未实现盈亏
+}
+
+// TotalEquity 账户净值 = 钱包余额 + 未实现盈亏
+func (b Balance) TotalEquity() float64 {
+	return b.TotalWalletBalance + b.TotalUnrealizedProfit
+}
+
+// ToMap 转换为旧版 map 格式
+func (b Balance) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"totalWalletBalance":    b.TotalWalletBalance,
+		"availableBalance":      b.AvailableBalance,
+		"totalUnrealizedProfit": b.TotalUnrealizedProfit,
+	}
+}
+
+// Position 持仓，Quantity 始终为正数，方向由 Side（long/short）表示
+type Position struct {
+	Symbol           string
+	Side             string
+	Quantity         float64
+	EntryPrice       float64
+	MarkPrice        float64
+	UnrealizedPnL    float64
+	LiquidationPrice float64
+	Leverage         int
+}
+
+// ToMap 转换为旧版 map 格式
+func (p Position) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"symbol":           p.Symbol,
+		"side":             p.Side,
+		"positionAmt":      p.Quantity,
+		"entryPrice":       p.EntryPrice,
+		"markPrice":        p.MarkPrice,
+		"unRealizedProfit": p.UnrealizedPnL,
+		"liquidationPrice": p.LiquidationPrice,
+		"leverage":         float64(p.Leverage),
+	}
+}
+
+// OrderResult 下单结果，Price 为成交均价（交易所未返回时为0）
+type OrderResult struct {
+	OrderID        string
+	Symbol         string
+	Status         string
+	Price          float64
+	Quantity       float64
+	FilledQuantity float64 // 已成交数量（限价单部分成交时小于 Quantity）
+}
+
+// IsOpen 订单是否仍在挂单中（未成交或部分成交）
+func (o OrderResult) IsOpen() bool {
+	status := NormalizeOrderStatus(o.Status)
+	return status == OrderStatusNew || status == OrderStatusPartiallyFilled
+}
+
+// IsFinal 订单是否已结束（完全成交、撤销、拒绝或过期）
+func (o OrderResult) IsFinal() bool {
+	switch NormalizeOrderStatus(o.Status) {
+	case OrderStatusFilled, OrderStatusCanceled, OrderStatusRejected, OrderStatusExpired:
+		return true
+	}
+	return false
+}
+
+// NumericID 返回数字形式的订单ID，非数字ID（如LIGHTER交易哈希）返回0
+func (o OrderResult) NumericID() int64 {
+	id, err := strconv.ParseInt(o.OrderID, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
+}
+
+// ToMap 转换为旧版 map 格式（orderId 优先使用 int64）
+func (o OrderResult) ToMap() map[string]interface{} {
+	var orderID interface{} = o.OrderID
+	if id := o.NumericID(); id != 0 {
+		orderID = id
+	}
+	return map[string]interface{}{
+		"orderId":     orderID,
+		"symbol":      o.Symbol,
+		"status":      o.Status,
+		"price":       o.Price,
+		"qty":         o.Quantity,
+		"executedQty": o.FilledQuantity,
+	}
+}
+
+// Fill 成交记录
+type Fill struct {
+	OrderID     string
+	Symbol      string
+	Side        string
+	Price       float64
+	Quantity    float64
+	Fee         float64
+	RealizedPnL float64
+	Time        time.Time
+}
+
+// ToMap 转换为旧版 map 格式
+func (f Fill) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"orderId":     f.OrderID,
+		"symbol":      f.Symbol,
+		"side":        f.Side,
+		"price":       f.Price,
+		"qty":         f.Quantity,
+		"fee":         f.Fee,
+		"realizedPnl": f.RealizedPnL,
+		"time":        f.Time.UnixMilli(),
+	}
+}
+
+// ================================
+// 限价单
+// ================================
+
+// 限价单有效方式（统一使用币安命名，各交易所实现负责转换）
+const (
+	TimeInForceGTC      = "GTC" // 一直有效直到成交或撤销
+	TimeInForceIOC      = "IOC" // 立即成交，剩余部分撤销
+	TimeInForcePostOnly = "GTX" // 只做Maker，会立即成交时由交易所拒绝
+)
+
+// 统一订单状态（统一使用币安命名）
+const (
+	OrderStatusNew             = "NEW"
+	OrderStatusPartiallyFilled = "PARTIALLY_FILLED"
+	OrderStatusFilled          = "FILLED"
+	OrderStatusCanceled        = "CANCELED"
+	OrderStatusRejected        = "REJECTED"
+	OrderStatusExpired         = "EXPIRED"
+)
+
+// NormalizeOrderStatus 将各交易所的订单状态统一为 OrderStatus* 常量
+// 如 Bybit 的 PartiallyFilled、Hyperliquid 的 open/badAloPxRejected、LIGHTER 的 cancelled、OKX 的 live
+func NormalizeOrderStatus(status string) string {
+	key := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(status), "_", ""))
+	switch key {
+	case "new", "open", "created", "pending", "untriggered", "resting", "live":
+		return OrderStatusNew
+	case "partiallyfilled":
+		return OrderStatusPartiallyFilled
+	case "filled":
+		return OrderStatusFilled
+	case "canceled", "cancelled", "partiallyfilledcanceled", "deactivated":
+		return OrderStatusCanceled
+	case "expired", "expiredinmatch":
+		return OrderStatusExpired
+	}
+	if strings.HasSuffix(key, "rejected") {
+		return OrderStatusRejected
+	}
+	if strings.HasSuffix(key, "canceled") || strings.HasSuffix(key, "cancelled") {
+		return OrderStatusCanceled
+	}
+	return strings.ToUpper(status)
+}
+
+// ================================
+// 旧版 map → 领域模型
+// ================================
+
+// 各交易所返回的同义字段（按优先级排列）
+var (
+	walletBalanceKeys    = []string{"totalWalletBalance", "wallet_balance", "balance"}
+	equityKeys           = []string{"total_equity", "totalEquity"}
+	availableBalanceKeys = []string{"availableBalance", "available_balance", "available"}
+	unrealizedKeys       = []string{"totalUnrealizedProfit", "unRealizedProfit", "unrealizedPnL", "unrealized_pnl", "unrealised_pnl", "unrealisedPnl"}
+	symbolKeys           = []string{"symbol", "contract", "coin"}
+	positionAmtKeys      = []string{"positionAmt", "size", "positionSize", "qty"}
+	entryPriceKeys       = []string{"entryPrice", "entry_price", "avgPrice"}
+	markPriceKeys        = []string{"markPrice", "mark_price"}
+	liquidationKeys      = []string{"liquidationPrice", "liquidation_price", "liq_price", "liqPrice"}
+	orderIDKeys          = []string{"orderId", "order_id", "id", "tx_hash"}
+	orderPriceKeys       = []string{"price", "avgPrice", "fill_price", "avg_price"}
+	orderQtyKeys         = []string{"qty", "executedQty", "size", "quantity"}
+	filledQtyKeys        = []string{"executedQty", "cumExecQty", "filled_qty", "filledQty"}
+	feeKeys              = []string{"fee", "commission"}
+	realizedPnLKeys      = []string{"realizedPnl", "realized_pnl", "pnl"}
+	timeKeys             = []string{"time", "create_time", "timestamp"}
+)
+
+// BalanceFromMap 将任意交易所的余额 map 转换为 Balance
+// 钱包余额（或净值）与可用余额为必需字段，缺失时返回错误而不是按0处理
+func BalanceFromMap(m map[string]interface{}) (Balance, error) {
+	var b Balance
+	if m == nil {
+		return b, fmt.Errorf("余额数据为空")
+	}
+	b.TotalUnrealizedProfit, _ = firstFloat(m, unrealizedKeys...)
+	available, ok := firstFloat(m, availableBalanceKeys...)
+	if !ok {
+		return b, fmt.Errorf("余额数据缺少可用余额字段: %v", m)
+	}
+	b.AvailableBalance = available
+
+	if wallet, ok := firstFloat(m, walletBalanceKeys...); ok {
+		b.TotalWalletBalance = wallet
+	} else if equity, ok := firstFloat(m, equityKeys...); ok {
+		// 只返回净值的交易所（如LIGHTER）：钱包余额 = 净值 - 未实现盈亏
+		b.TotalWalletBalance = equity - b.TotalUnrealizedProfit
+	} else {
+		return b, fmt.Errorf("余额数据缺少钱包余额字段: %v", m)
+	}
+	return b, nil
+}
+
+// PositionFromMap 将任意交易所的持仓 map 转换为 Position
+// symbol、数量、开仓价、标记价为必需字段，缺失时返回错误而不是按0处理
+func PositionFromMap(m map[string]interface{}) (Position, error) {
+	var p Position
+	symbol, ok := firstString(m, symbolKeys...)
+	if !ok || symbol == "" {
+		return p, fmt.Errorf("持仓数据缺少symbol字段: %v", m)
+	}
+	p.Symbol = symbol
+
+	amount, ok := firstFloat(m, positionAmtKeys...)
+	if !ok {
+		return p, fmt.Errorf("%s 持仓数据缺少数量字段: %v", symbol, m)
+	}
+	p.Quantity = math.Abs(amount)
+
+	side, _ := firstString(m, "side", "positionSide")
+	switch strings.ToLower(side) {
+	case "long", "buy":
+		p.Side = "long"
+	case "short", "sell":
+		p.Side = "short"
+	default:
+		// 单向持仓模式：用数量符号判断方向
+		if amount > 0 {
+			p.Side = "long"
+		} else if amount < 0 {
+			p.Side = "short"
+		} else {
+			return p, fmt.Errorf("无法识别 %s 的持仓方向: %v", symbol, side)
+		}
+	}
+
+	if p.EntryPrice, ok = firstFloat(m, entryPriceKeys...); !ok {
+		return p, fmt.Errorf("%s 持仓数据缺少开仓价字段: %v", symbol, m)
+	}
+	if p.MarkPrice, ok = firstFloat(m, markPriceKeys...); !ok {
+		return p, fmt.Errorf("%s 持仓数据缺少标记价字段: %v", symbol, m)
+	}
+	p.UnrealizedPnL, _ = firstFloat(m, unrealizedKeys...)
+	p.LiquidationPrice, _ = firstFloat(m, liquidationKeys...)
+	if lev, ok := firstFloat(m, "leverage"); ok {
+		p.Leverage = int(math.Round(lev))
+	}
+	return p, nil
+}
+
+// OrderResultFromMap 将任意交易所的下单结果 map 转换为 OrderResult
+func OrderResultFromMap(m map[string]interface{}) OrderResult {
+	var o OrderResult
+	if m == nil {
+		return o
+	}
+	if raw, ok := firstValue(m, orderIDKeys...); ok {
+		switch v := raw.(type) {
+		case float64:
+			o.OrderID = strconv.FormatInt(int64(v), 10)
+		default:
+			o.OrderID = fmt.Sprintf("%v", v)
+		}
+	}
+	o.Symbol, _ = firstString(m, symbolKeys...)
+	o.Status, _ = firstString(m, "status", "finish_as")
+	o.Price, _ = firstFloat(m, orderPriceKeys...)
+	qty, _ := firstFloat(m, orderQtyKeys...)
+	o.Quantity = math.Abs(qty)
+	filled, _ := firstFloat(m, filledQtyKeys...)
+	o.FilledQuantity = math.Abs(filled)
+	return o
+}
+
+// FillFromMap 将任意交易所的成交记录 map 转换为 Fill
+// symbol、成交价、成交数量为必需字段，缺失时返回错误而不是按0处理
+func FillFromMap(m map[string]interface{}) (Fill, error) {
+	symbol, ok := firstString(m, symbolKeys...)
+	if !ok || symbol == "" {
+		return Fill{}, fmt.Errorf("成交记录缺少symbol字段: %v", m)
+	}
+	if _, ok := firstFloat(m, orderPriceKeys...); !ok {
+		return Fill{}, fmt.Errorf("%s 成交记录缺少成交价字段: %v", symbol, m)
+	}
+	if _, ok := firstFloat(m, orderQtyKeys...); !ok {
+		return Fill{}, fmt.Errorf("%s 成交记录缺少成交数量字段: %v", symbol, m)
+	}
+
+	order := OrderResultFromMap(m)
+	f := Fill{
+		OrderID:  order.OrderID,
+		Symbol:   order.Symbol,
+		Price:    order.Price,
+		Quantity: order.Quantity,
+	}
+	f.Side, _ = firstString(m, "side")
+	f.Fee, _ = firstFloat(m, feeKeys...)
+	f.RealizedPnL, _ = firstFloat(m, realizedPnLKeys...)
+	if ts, ok := firstFloat(m, timeKeys...); ok && ts > 0 {
+		// 秒级时间戳（如Gate create_time）转为毫秒
+		if ts < 1e12 {
+			ts *= 1000
+		}
+		f.Time = time.UnixMilli(int64(ts))
+	}
+	return f, nil
+}
+
+// ================================
+// 强类型访问入口
+// ================================
+
+// FetchBalance 获取强类型账户余额
+func FetchBalance(t Trader) (Balance, error) {
+	raw, err := t.GetBalance()
+	if err != nil {
+		return Balance{}, err
+	}
+	return BalanceFromMap(raw)
+}
+
+// FetchPositions 获取强类型持仓列表，自动跳过数量明确为0的已平仓记录
+func FetchPositions(t Trader) ([]Position, error) {
+	raw, err := t.GetPositions()
+	if err != nil {
+		return nil, err
+	}
+	positions := make([]Position, 0, len(raw))
+	for _, m := range raw {
+		if amount, ok := firstFloat(m, positionAmtKeys...); ok && amount == 0 {
+			continue
+		}
+		pos, err := PositionFromMap(m)
+		if err != nil {
+			return nil, err
+		}
+		positions = append(positions, pos)
+	}
+	return positions, nil
+}
+
+// FetchFills 获取强类型成交记录
+func FetchFills(t Trader, symbol string, limit int) ([]Fill, error) {
+	raw, err := t.GetTradeHistory(symbol, limit)
+	if err != nil {
+		return nil, err
+	}
+	fills := make([]Fill, 0, len(raw))
+	for _, m := range raw {
+		fill, err := FillFromMap(m)
+		if err != nil {
+			return nil, err
+		}
+		fills = append(fills, fill)
+	}
+	return fills, nil
+}
+
+// FindPosition 在持仓列表中查找指定币种和方向的持仓
+func FindPosition(positions []Position, symbol, side string) (Position, bool) {
+	for _, pos := range positions {
+		if pos.Symbol == symbol && pos.Side == side {
+			return pos, true
+		}
+	}
+	return Position{}, false
+}
+
+func firstValue(m map[string]interface{}, keys ...string) (interface{}, bool) {
+	for _, key := range keys {
+		if v, ok := m[key]; ok && v != nil {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+func firstFloat(m map[string]interface{}, keys ...string) (float64, bool) {
+	for _, key := range keys {
+		if _, ok := m[key]; !ok {
+			continue
+		}
+		if v, err := SafeFloat64(m, key); err == nil {
+			return v, true
+		}
+	}
+	return 0, false
+}
+
+func firstString(m map[string]interface{}, keys ...string) (string, bool) {
+	for _, key := range keys {
+		if v, ok := m[key]; ok && v != nil {
+			if s, err := SafeString(m, key); err == nil {
+				return s, true
+			}
+		}
+	}
+	return "", false
+}
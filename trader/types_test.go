@@ -0,0 +1,103 @@
+package trader
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBalanceFromMap_EquityOnly(t *testing.T) {
+	balance, err := BalanceFromMap(map[string]interface{}{
+		"total_equity":      1050.0,
+		"available_balance": 900.0,
+		"unrealized_pnl":    50.0,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1000.0, balance.TotalWalletBalance)
+	assert.Equal(t, 1050.0, balance.TotalEquity())
+
+	_, err = BalanceFromMap(map[string]interface{}{"availableBalance": 1.0})
+	assert.Error(t, err, "缺少钱包余额字段应返回错误而不是静默为0")
+
+	_, err = BalanceFromMap(map[string]interface{}{"totalWalletBalance": 1000.0})
+	assert.Error(t, err, "缺少可用余额字段应返回错误而不是静默为0")
+}
+
+func TestPositionFromMap_Side(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      map[string]interface{}
+		wantSide string
+		wantQty  float64
+		wantErr  bool
+	}{
+		{"显式多头", map[string]interface{}{"symbol": "BTCUSDT", "side": "LONG", "positionAmt": 1.0, "entryPrice": 100.0, "markPrice": 101.0}, "long", 1, false},
+		{"单向模式负数为空头", map[string]interface{}{"symbol": "BTCUSDT", "positionAmt": "-0.3", "entryPrice": "100", "markPrice": "99"}, "short", 0.3, false},
+		{"Buy映射为多头", map[string]interface{}{"symbol": "BTCUSDT", "side": "Buy", "size": 2.0, "avgPrice": 100.0, "mark_price": 101.0}, "long", 2, false},
+		{"无方向且数量为0", map[string]interface{}{"symbol": "BTCUSDT", "positionAmt": 0.0, "entryPrice": 100.0, "markPrice": 101.0}, "", 0, true},
+		{"缺少symbol", map[string]interface{}{"side": "long", "positionAmt": 1.0, "entryPrice": 100.0, "markPrice": 101.0}, "", 0, true},
+		{"缺少数量", map[string]interface{}{"symbol": "BTCUSDT", "side": "long", "entryPrice": 100.0, "markPrice": 101.0}, "", 0, true},
+		{"缺少开仓价", map[string]interface{}{"symbol": "BTCUSDT", "side": "long", "positionAmt": 1.0, "markPrice": 101.0}, "", 0, true},
+		{"缺少标记价", map[string]interface{}{"symbol": "BTCUSDT", "side": "long", "positionAmt": 1.0, "entryPrice": 100.0}, "", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pos, err := PositionFromMap(tt.raw)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantSide, pos.Side)
+			assert.InDelta(t, tt.wantQty, pos.Quantity, 1e-9)
+		})
+	}
+}
+
+func TestFetchPositions_SkipsClosedPositions(t *testing.T) {
+	mock := &MockTrader{
+		positions: []map[string]interface{}{
+			{"symbol": "BTCUSDT", "side": "long", "positionAmt": 0.0},
+			{"symbol": "ETHUSDT", "side": "short", "positionAmt": -1.5, "entryPrice": 3000.0, "markPrice": 2990.0},
+		},
+	}
+
+	positions, err := FetchPositions(mock)
+	require.NoError(t, err)
+	require.Len(t, positions, 1)
+
+	pos, ok := FindPosition(positions, "ETHUSDT", "short")
+	assert.True(t, ok)
+	assert.Equal(t, 1.5, pos.Quantity)
+
+	_, ok = FindPosition(positions, "BTCUSDT", "long")
+	assert.False(t, ok, "数量为0的持仓应被跳过")
+}
+
+func TestOrderResult_ToMapRoundTrip(t *testing.T) {
+	numeric := OrderResultFromMap(map[string]interface{}{"orderId": float64(42), "symbol": "BTCUSDT", "price": 100.0})
+	assert.Equal(t, "42", numeric.OrderID)
+	assert.Equal(t, int64(42), numeric.NumericID())
+	assert.Equal(t, int64(42), numeric.ToMap()["orderId"])
+
+	hash := OrderResultFromMap(map[string]interface{}{"orderId": "0xdeadbeef"})
+	assert.Equal(t, int64(0), hash.NumericID())
+	assert.Equal(t, "0xdeadbeef", hash.ToMap()["orderId"])
+}
+
+func TestFillFromMap_RequiredFields(t *testing.T) {
+	fill, err := FillFromMap(map[string]interface{}{
+		"contract": "BTC_USDT", "fill_price": "50000", "size": -2.0, "create_time": float64(1700000000),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 50000.0, fill.Price)
+	assert.Equal(t, 2.0, fill.Quantity)
+	assert.Equal(t, int64(1700000000000), fill.Time.UnixMilli(), "秒级时间戳应转换为毫秒")
+
+	_, err = FillFromMap(map[string]interface{}{"symbol": "BTCUSDT", "qty": 1.0})
+	assert.Error(t, err, "缺少成交价应返回错误而不是静默为0")
+	_, err = FillFromMap(map[string]interface{}{"symbol": "BTCUSDT", "price": 100.0})
+	assert.Error(t, err, "缺少成交数量应返回错误而不是静默为0")
+}
@@ -3,6 +3,7 @@ import { useLanguage } from '../contexts/LanguageContext'
 import { t } from '../i18n/translations'
 import { stripLeadingIcons } from '../lib/text'
 import { api } from '../lib/api'
+import type { PromptPerformance } from '../types'
 import {
   Brain,
   BarChart3,
@@ -55,6 +56,7 @@ interface PerformanceAnalysis {
   symbol_stats: { [key: string]: SymbolPerformance }
   best_symbol: string
   worst_symbol: string
+  prompt_stats?: { [hash: string]: PromptPerformance }
 }
 
 interface AILearningProps {
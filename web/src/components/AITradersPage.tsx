@@ -295,6 +295,9 @@ export function AITradersPage({ onTraderSelect }: AITradersPageProps) {
         is_cross_margin: data.is_cross_margin,
         use_coin_pool: data.use_coin_pool,
         use_oi_top: data.use_oi_top,
+        take_profit_ladder: data.take_profit_ladder,
+        breakeven_after_tp1: data.breakeven_after_tp1,
+        validation_rules: data.validation_rules,
       }
 
       await toast.promise(api.updateTrader(editingTrader.trader_id, request), {
@@ -579,6 +582,7 @@ export function AITradersPage({ onTraderSelect }: AITradersPageProps) {
               aster_user: exchange.asterUser || '',
               aster_signer: exchange.asterSigner || '',
               aster_private_key: exchange.asterPrivateKey || '',
+              passphrase: exchange.passphrase || '',
             },
           ])
         ),
@@ -605,7 +609,8 @@ export function AITradersPage({ onTraderSelect }: AITradersPageProps) {
     hyperliquidWalletAddr?: string,
     asterUser?: string,
     asterSigner?: string,
-    asterPrivateKey?: string
+    asterPrivateKey?: string,
+    passphrase?: string
   ) => {
     try {
       // 找到要配置的交易所（从supportedExchanges中）
@@ -635,6 +640,7 @@ export function AITradersPage({ onTraderSelect }: AITradersPageProps) {
                   asterUser,
                   asterSigner,
                   asterPrivateKey,
+                  passphrase,
                   enabled: true,
                 }
               : e
@@ -650,6 +656,7 @@ export function AITradersPage({ onTraderSelect }: AITradersPageProps) {
           asterUser,
           asterSigner,
           asterPrivateKey,
+          passphrase,
           enabled: true,
         }
         updatedExchanges = [...(allExchanges || []), newExchange]
@@ -668,6 +675,7 @@ export function AITradersPage({ onTraderSelect }: AITradersPageProps) {
               aster_user: exchange.asterUser || '',
               aster_signer: exchange.asterSigner || '',
               aster_private_key: exchange.asterPrivateKey || '',
+              passphrase: exchange.passphrase || '',
             },
           ])
         ),
@@ -1762,7 +1770,8 @@ function ExchangeConfigModal({
     hyperliquidWalletAddr?: string,
     asterUser?: string,
     asterSigner?: string,
-    asterPrivateKey?: string
+    asterPrivateKey?: string,
+    passphrase?: string
   ) => Promise<void>
   onDelete: (exchangeId: string) => void
   onClose: () => void
@@ -1951,9 +1960,22 @@ function ExchangeConfigModal({
         asterSigner.trim(),
         asterPrivateKey.trim()
       )
-    } else if (selectedExchange?.id === 'okx') {
+    } else if (
+      selectedExchange?.id === 'okx' ||
+      selectedExchange?.id === 'bitget'
+    ) {
       if (!apiKey.trim() || !secretKey.trim() || !passphrase.trim()) return
-      await onSave(selectedExchangeId, apiKey.trim(), secretKey.trim(), testnet)
+      await onSave(
+        selectedExchangeId,
+        apiKey.trim(),
+        secretKey.trim(),
+        testnet,
+        undefined,
+        undefined,
+        undefined,
+        undefined,
+        passphrase.trim()
+      )
     } else {
       // 默认情况（其他CEX交易所）
       if (!apiKey.trim() || !secretKey.trim()) return
@@ -2245,7 +2267,8 @@ function ExchangeConfigModal({
                         />
                       </div>
 
-                      {selectedExchange.id === 'okx' && (
+                      {(selectedExchange.id === 'okx' ||
+                        selectedExchange.id === 'bitget') && (
                         <div>
                           <label
                             className="block text-sm font-semibold mb-2"
@@ -2575,7 +2598,8 @@ function ExchangeConfigModal({
                 !selectedExchange ||
                 (selectedExchange.id === 'binance' &&
                   (!apiKey.trim() || !secretKey.trim())) ||
-                (selectedExchange.id === 'okx' &&
+                ((selectedExchange.id === 'okx' ||
+                  selectedExchange.id === 'bitget') &&
                   (!apiKey.trim() ||
                     !secretKey.trim() ||
                     !passphrase.trim())) ||
@@ -2593,6 +2617,7 @@ function ExchangeConfigModal({
                   selectedExchange.id !== 'binance' &&
                   selectedExchange.id !== 'bybit' &&
                   selectedExchange.id !== 'okx' &&
+                  selectedExchange.id !== 'bitget' &&
                   (!apiKey.trim() || !secretKey.trim()))
               }
               className="flex-1 px-4 py-2 rounded text-sm font-semibold disabled:opacity-50"
@@ -1129,6 +1129,10 @@ export function BacktestPage() {
                     label={tr('metrics.profitFactor')}
                     value={metrics.profit_factor}
                   />
+                  <Metric
+                    label={tr('metrics.fundingPnl')}
+                    value={metrics.funding_pnl}
+                  />
                 </>
               ) : (
                 <div style={{ color: '#5E6673' }}>{tr('metrics.pending')}</div>
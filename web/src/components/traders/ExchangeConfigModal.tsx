@@ -30,7 +30,8 @@ interface ExchangeConfigModalProps {
     asterPrivateKey?: string,
     lighterWalletAddr?: string,
     lighterPrivateKey?: string,
-    lighterApiKeyPrivateKey?: string
+    lighterApiKeyPrivateKey?: string,
+    passphrase?: string
   ) => Promise<void>
   onDelete: (exchangeId: string) => void
   onClose: () => void
@@ -260,9 +261,28 @@ export function ExchangeConfigModal({
         lighterPrivateKey.trim(),
         lighterApiKeyPrivateKey.trim()
       )
-    } else if (selectedExchange?.id === 'okx') {
+    } else if (
+      selectedExchange?.id === 'okx' ||
+      selectedExchange?.id === 'bitget'
+    ) {
       if (!apiKey.trim() || !secretKey.trim() || !passphrase.trim()) return
-      await onSave(selectedExchangeId, apiKey.trim(), secretKey.trim(), testnet)
+      await onSave(
+        selectedExchangeId,
+        apiKey.trim(),
+        secretKey.trim(),
+        testnet,
+        undefined,
+        undefined,
+        undefined,
+        undefined,
+        undefined,
+        undefined,
+        undefined,
+        passphrase.trim()
+      )
+    } else if (selectedExchange?.id === 'paper') {
+      // 模拟盘不需要 API 凭证
+      await onSave(selectedExchangeId, '', '', false)
     } else {
       // 默认情况（其他CEX交易所）
       if (!apiKey.trim() || !secretKey.trim()) return
@@ -554,7 +574,8 @@ export function ExchangeConfigModal({
                         />
                       </div>
 
-                      {selectedExchange.id === 'okx' && (
+                      {(selectedExchange.id === 'okx' ||
+                        selectedExchange.id === 'bitget') && (
                         <div>
                           <label
                             className="block text-sm font-semibold mb-2"
@@ -638,6 +659,20 @@ export function ExchangeConfigModal({
                     </>
                   )}
 
+                {/* 模拟盘无需凭证 */}
+                {selectedExchange.id === 'paper' && (
+                  <div
+                    className="p-3 rounded text-sm"
+                    style={{
+                      background: '#1a3a52',
+                      border: '1px solid #2b5278',
+                      color: '#EAECEF',
+                    }}
+                  >
+                    {t('paperExchangeNoCredentials', language)}
+                  </div>
+                )}
+
                 {/* Aster 交易所的字段 */}
                 {selectedExchange.id === 'aster' && (
                   <>
@@ -1001,7 +1036,8 @@ export function ExchangeConfigModal({
                 !selectedExchange ||
                 (selectedExchange.id === 'binance' &&
                   (!apiKey.trim() || !secretKey.trim())) ||
-                (selectedExchange.id === 'okx' &&
+                ((selectedExchange.id === 'okx' ||
+                  selectedExchange.id === 'bitget') &&
                   (!apiKey.trim() ||
                     !secretKey.trim() ||
                     !passphrase.trim())) ||
@@ -1022,6 +1058,7 @@ export function ExchangeConfigModal({
                   selectedExchange.id !== 'binance' &&
                   selectedExchange.id !== 'bybit' &&
                   selectedExchange.id !== 'okx' &&
+                  selectedExchange.id !== 'bitget' &&
                   (!apiKey.trim() || !secretKey.trim()))
               }
               className="flex-1 px-4 py-2 rounded text-sm font-semibold disabled:opacity-50"
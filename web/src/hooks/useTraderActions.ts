@@ -187,6 +187,9 @@ export function useTraderActions({
         is_cross_margin: data.is_cross_margin,
         use_coin_pool: data.use_coin_pool,
         use_oi_top: data.use_oi_top,
+        take_profit_ladder: data.take_profit_ladder,
+        breakeven_after_tp1: data.breakeven_after_tp1,
+        validation_rules: data.validation_rules,
       }
 
       await toast.promise(api.updateTrader(editingTrader.trader_id, request), {
@@ -500,7 +503,8 @@ export function useTraderActions({
     asterPrivateKey?: string,
     lighterWalletAddr?: string,
     lighterPrivateKey?: string,
-    lighterApiKeyPrivateKey?: string
+    lighterApiKeyPrivateKey?: string,
+    passphrase?: string
   ) => {
     try {
       // 找到要配置的交易所(从supportedExchanges中)
@@ -533,6 +537,7 @@ export function useTraderActions({
                   lighterWalletAddr,
                   lighterPrivateKey,
                   lighterApiKeyPrivateKey,
+                  passphrase,
                   enabled: true,
                 }
               : e
@@ -551,6 +556,7 @@ export function useTraderActions({
           lighterWalletAddr,
           lighterPrivateKey,
           lighterApiKeyPrivateKey,
+          passphrase,
           enabled: true,
         }
         updatedExchanges = [...(allExchanges || []), newExchange]
@@ -572,6 +578,7 @@ export function useTraderActions({
               lighter_wallet_addr: exchange.lighterWalletAddr || '',
               lighter_private_key: exchange.lighterPrivateKey || '',
               lighter_api_key_private_key: exchange.lighterApiKeyPrivateKey || '',
+              passphrase: exchange.passphrase || '',
             },
           ])
         ),
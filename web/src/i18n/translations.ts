@@ -217,6 +217,7 @@ export const translations = {
         maxDrawdown: 'Max Drawdown %',
         sharpe: 'Sharpe',
         profitFactor: 'Profit Factor',
+        fundingPnl: 'Funding PnL',
         pending: 'Calculating...',
         realized: 'Realized PnL',
         unrealized: 'Unrealized PnL',
@@ -360,7 +361,7 @@ export const translations = {
     enterUser: 'Enter User',
     enterSigner: 'Enter Signer Address',
     enterSecretKey: 'Enter Secret Key',
-    enterPassphrase: 'Enter Passphrase (Required for OKX)',
+    enterPassphrase: 'Enter Passphrase (Required for OKX and Bitget)',
     hyperliquidPrivateKeyDesc:
       'Hyperliquid uses private key for trading authentication',
     hyperliquidWalletAddressDesc:
@@ -400,6 +401,8 @@ export const translations = {
     lighterV1Description: 'Basic Mode - Limited functionality, testing framework only',
     lighterV2Description: 'Full Mode - Supports Poseidon2 signing and real trading',
     lighterPrivateKeyImported: 'LIGHTER private key imported',
+    paperExchangeNoCredentials:
+      'Paper trading simulates orders against live market data and needs no API credentials.',
 
     // Exchange names
     hyperliquidExchangeName: 'Hyperliquid',
@@ -1241,6 +1244,7 @@ export const translations = {
         maxDrawdown: '最大回撤 %',
         sharpe: '夏普比率',
         profitFactor: '盈亏因子',
+        fundingPnl: '资金费盈亏',
         pending: '计算中...',
         realized: '已实现盈亏',
         unrealized: '未实现盈亏',
@@ -1383,7 +1387,7 @@ export const translations = {
     enterWalletAddress: '输入钱包地址',
     enterUser: '输入用户名',
     enterSigner: '输入签名者地址',
-    enterPassphrase: '输入Passphrase (OKX必填)',
+    enterPassphrase: '输入Passphrase (OKX、Bitget必填)',
     hyperliquidPrivateKeyDesc: 'Hyperliquid 使用私钥进行交易认证',
     hyperliquidWalletAddressDesc: '与私钥对应的钱包地址',
     // Hyperliquid 代理钱包 (新安全模型)
@@ -1421,6 +1425,7 @@ export const translations = {
     lighterV1Description: '基本模式 - 功能受限，僅用於測試框架',
     lighterV2Description: '完整模式 - 支持 Poseidon2 簽名和真實交易',
     lighterPrivateKeyImported: 'LIGHTER 私鑰已導入',
+    paperExchangeNoCredentials: '模拟盘使用实时行情模拟下单，无需填写 API 凭证。',
 
     // Exchange names
     hyperliquidExchangeName: 'Hyperliquid',
@@ -38,6 +38,102 @@ export interface Position {
   unrealized_pnl_pct: number
   liquidation_price: number
   margin_used: number
+  trailing_stop?: TrailingStopInfo
+  take_profit_ladder?: TakeProfitLadderInfo
+}
+
+// 追踪止损（native=交易所原生订单，emulated=本地价格轮询模拟）
+export interface TrailingStopInfo {
+  callback_rate: number
+  distance: number
+  activation_price: number
+  mode: 'native' | 'emulated'
+  order_id?: string
+  activated: boolean
+  extreme_price: number
+  stop_price: number
+  created_at: number
+}
+
+// 分批止盈状态（按触发顺序排列，最后一档平掉剩余仓位）
+export interface TakeProfitLadderInfo {
+  levels: { price: number; quantity: number; filled: boolean }[]
+  stop_loss: number
+  breakeven_after_tp1: boolean
+  breakeven_applied: boolean
+}
+
+// 分批止盈模板：ratio 为该档位于入场价到止盈价之间的位置 (0-1]
+export interface TakeProfitLadderStep {
+  ratio: number
+  percentage?: number
+}
+
+// 决策输出方式：text 解析文本中的 JSON，tool_call 通过 submit_decisions 函数调用提交，agent 先调用只读数据工具再提交
+export type DecisionMode = 'text' | 'tool_call' | 'agent'
+
+// 集成决策投票方式：简单多数、信心度加权、开仓需全体一致
+export type EnsembleVote = 'majority' | 'confidence_weighted' | 'unanimous'
+
+// 决策校验规则链配置（按交易员保存），字段均可省略以使用默认值
+export interface ValidationConfig {
+  disabled?: ('leverage_cap' | 'position_value_cap' | 'risk_reward')[]
+  major_coins?: string[] // 默认 BTC/ETH/SOL/BNB
+  major_position_multiple?: number // 默认 200
+  alt_position_multiple?: number // 默认 100
+  min_risk_reward?: number // 默认 3
+}
+
+// 被校验规则拒绝的决策
+export interface RuleRejection {
+  rule: string
+  symbol: string
+  action: string
+  reason: string
+}
+
+// 集成决策中单个模型的输出
+export interface EnsembleMemberRecord {
+  name: string
+  raw_response?: string
+  cot_trace?: string
+  decision_json?: string
+  error?: string
+  duration_ms?: number
+  from_cache?: boolean
+}
+
+// 提示词 A/B 实验（按交易员保存），A 组为交易员当前提示词，B 组为这里的配置
+export interface PromptExperiment {
+  name?: string
+  split: 'cycle' | 'symbol' // 按周期交替 | 按币种固定分组
+  template?: string // B 组模板，空表示沿用 A 组模板
+  custom_prompt?: string
+  override_base_prompt?: boolean
+}
+
+// 决策使用的提示词版本
+export interface PromptVersion {
+  hash: string
+  arm?: 'A' | 'B'
+  template?: string
+  template_hash?: string
+  custom_hash?: string
+  override_base?: boolean
+}
+
+// 单个提示词版本的表现统计（/api/performance 的 prompt_stats）
+export interface PromptPerformance {
+  prompt_hash: string
+  arm?: 'A' | 'B'
+  template?: string
+  total_trades: number
+  winning_trades: number
+  losing_trades: number
+  win_rate: number
+  total_pn_l: number
+  avg_pn_l: number
+  profit_factor: number
 }
 
 export interface DecisionAction {
@@ -51,6 +147,7 @@ export interface DecisionAction {
   success: boolean
   error?: string
   reasoning?: string
+  prompt_hash?: string
 }
 
 export interface AccountSnapshot {
@@ -74,6 +171,34 @@ export interface DecisionRecord {
   execution_log: string[]
   success: boolean
   error_message?: string
+  ensemble_vote?: EnsembleVote
+  ensemble_members?: EnsembleMemberRecord[]
+  rule_rejections?: RuleRejection[]
+  prompt_versions?: PromptVersion[]
+  prompt_tokens?: number
+  prompt_trims?: string[]
+  ai_usage?: AIUsage
+  ai_model?: string // 实际给出回答的模型（故障转移时可能是备用模型）
+  safe_mode?: boolean // 所有AI模型不可用，本周期由安全模式管理持仓
+}
+
+// AI 调用的 token 用量与费用
+export interface AIUsage {
+  calls: number
+  prompt_tokens: number
+  completion_tokens: number
+  reasoning_tokens: number
+  cost_usd: number
+}
+
+// 当前决策周期 AI 的流式输出（/decisions/thinking）
+export interface LiveThinking {
+  cycle_number: number
+  started_at: string
+  updated_at: string
+  reasoning: string
+  content: string
+  done: boolean
 }
 
 export interface Statistics {
@@ -82,6 +207,8 @@ export interface Statistics {
   failed_cycles: number
   total_open_positions: number
   total_close_positions: number
+  ai_usage?: AIUsage
+  ai_month_cost_usd?: number
 }
 
 // AI Trading相关类型
@@ -110,7 +237,7 @@ export interface AIModel {
 export interface Exchange {
   id: string
   name: string
-  type: 'cex' | 'dex'
+  type: 'cex' | 'dex' | 'paper'
   enabled: boolean
   apiKey?: string
   secretKey?: string
@@ -125,6 +252,8 @@ export interface Exchange {
   lighterWalletAddr?: string
   lighterPrivateKey?: string
   lighterApiKeyPrivateKey?: string
+  // OKX、Bitget 特定字段
+  passphrase?: string
 }
 
 export interface CreateTraderRequest {
@@ -142,6 +271,16 @@ export interface CreateTraderRequest {
   is_cross_margin?: boolean
   use_coin_pool?: boolean
   use_oi_top?: boolean
+  pending_order_max_cycles?: number // 限价单最多挂单周期数，默认3
+  take_profit_ladder?: string // 分批止盈模板（TakeProfitLadderStep[] 的 JSON），空表示不启用
+  breakeven_after_tp1?: boolean
+  decision_mode?: DecisionMode // 决策输出方式，默认 text
+  ensemble_model_ids?: string // 参与集成决策的其他AI模型ID，逗号分隔
+  ensemble_vote?: EnsembleVote
+  validation_rules?: string // ValidationConfig 的 JSON，空表示默认规则
+  prompt_experiment?: string // PromptExperiment 的 JSON，空表示不开启
+  fallback_model_ids?: string // 故障转移的备用AI模型ID，按尝试顺序逗号分隔
+  ai_safe_mode?: boolean // 所有AI模型不可用时只管理现有持仓
 }
 
 export interface UpdateModelConfigRequest {
@@ -172,6 +311,8 @@ export interface UpdateExchangeConfigRequest {
       lighter_wallet_addr?: string
       lighter_private_key?: string
       lighter_api_key_private_key?: string
+      // OKX 特定字段
+      passphrase?: string
     }
   }
 }
@@ -212,6 +353,16 @@ export interface TraderConfigData {
   use_oi_top: boolean
   initial_balance: number
   scan_interval_minutes: number
+  pending_order_max_cycles?: number
+  take_profit_ladder?: string
+  breakeven_after_tp1?: boolean
+  decision_mode?: DecisionMode
+  ensemble_model_ids?: string
+  ensemble_vote?: EnsembleVote
+  validation_rules?: string
+  prompt_experiment?: string
+  fallback_model_ids?: string
+  ai_safe_mode?: boolean
   is_running: boolean
 }
 
@@ -296,6 +447,9 @@ export interface BacktestMetrics {
   trades: number;
   avg_win: number;
   avg_loss: number;
+  funding_pnl?: number;
+  funding_events?: number;
+  ai_usage?: AIUsage;
   best_symbol: string;
   worst_symbol: string;
   liquidated: boolean;
@@ -325,6 +479,20 @@ export interface BacktestStartConfig {
   fee_bps: number;
   slippage_bps: number;
   fill_policy: string;
+  protective_priority?: 'stop_loss_first' | 'take_profit_first' | 'nearest_open';
+  pending_order_max_cycles?: number;
+  take_profit_ladder?: TakeProfitLadderStep[];
+  breakeven_after_tp1?: boolean;
+  decision_mode?: DecisionMode;
+  ensemble?: {
+    name?: string;
+    provider?: string;
+    model?: string;
+    key?: string;
+    base_url?: string;
+  }[];
+  ensemble_vote?: EnsembleVote;
+  validation_rules?: ValidationConfig;
   prompt_variant?: string;
   prompt_template?: string;
   custom_prompt?: string;